@@ -1,13 +1,19 @@
 package alerts
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/patch"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/controllers/versions"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
@@ -16,331 +22,363 @@ import (
 // CRUD OPERATIONS
 // =======================
 
-// Paginate - Récupérer les alertes avec pagination
-func GetPaginatedAlerts(c *fiber.Ctx) error {
-	db := database.DB
-
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+// Champs des alertes pouvant être filtrés, triés et sélectionnés
+var listeAlertes = requetes.Schema{
+	Table: "alertes",
+	Champs: map[string]requetes.Type{
+		"uuid":                 requetes.Texte,
+		"created_at":           requetes.Date,
+		"updated_at":           requetes.Date,
+		"migrant_uuid":         requetes.Texte,
+		"type_alerte":          requetes.Texte,
+		"niveau_gravite":       requetes.Texte,
+		"titre":                requetes.Texte,
+		"version":              requetes.Nombre,
+		"statut":               requetes.Texte,
+		"date_expiration":      requetes.Date,
+		"personne_responsable": requetes.Texte,
+		"date_resolution":      requetes.Date,
+	},
+	Relations: []string{"migrant"},
+	TriDefaut: "-created_at",
+}
 
-	search := c.Query("search", "") 
+// Paginate - Récupérer les alertes avec pagination
+func GetPaginatedAlerts(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeAlertes)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	var alerts []models.Alert
-	var totalRecords int64
+		// Recherche textuelle, y compris sur le numéro du migrant
+		recherche := depots.Recherche(c.Query("search", ""), "titre", "description", "type_alerte", "action_requise").
+			OuDans("migrant_uuid", "migrants", "uuid", "numero_identifiant")
 
-	query := db.Model(&models.Alert{}).Preload("Migrant")
+		// Filtres, tri et pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Paginer(liste, recherche)
 
+		if err != nil {
+			return problemes.Echec("Failed to fetch alerts", err)
+		}
 
-	// Recherche textuelle
-	if search != "" {
-		query = query.Joins("LEFT JOIN migrants ON migrants.uuid = alerts.migrant_uuid").
-			Where("alerts.titre ILIKE ? OR alerts.description ILIKE ? OR alerts.type_alerte ILIKE ? OR alerts.action_requise ILIKE ? OR migrants.numero_identifiant ILIKE ?",
-				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Alerts retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
+		})
 	}
+}
 
-	// Count total
-	query.Count(&totalRecords)
+// Get all alerts
+func GetAllAlerts(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Les plus graves d'abord, sauf tri demandé
+		schema := listeAlertes
+		schema.TriDefaut = "-niveau_gravite,-created_at"
+		liste, erreurs := requetes.Lire(c, schema)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	// Get paginated results
-	err = query.Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&alerts).Error
+		data, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Tout(liste)
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch alerts",
-			"error":   err.Error(),
+		if err != nil {
+			return problemes.Echec("Failed to fetch alerts", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All alerts",
+			"data":    data,
 		})
 	}
+}
 
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// Get one alert
+func GetAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alert, err := depot.Trouver(c.Params("uuid"), "Migrant")
+		if err != nil {
+			return problemes.Absent("Alert not found", err)
+		}
 
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "alert", alert.UUID) {
+			return unites.Refus(c)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Alerts retrieved successfully",
-		"data":       alerts,
-		"pagination": pagination,
-	})
+		versions.ETag(c, alert.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert found",
+			"data":    alert,
+		})
+	}
 }
 
-// Get all alerts
-func GetAllAlerts(c *fiber.Ctx) error {
-	db := database.DB
-	var alerts []models.Alert
+// Get alerts by migrant with pagination
+func GetAlertsByMigrant(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrantUUID := c.Params("uuid")
+
+		// Paramètres de pagination
+		page, err := strconv.Atoi(c.Query("page", "1"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		limit, err := strconv.Atoi(c.Query("limit", "15"))
+		if err != nil || limit <= 0 {
+			limit = 15
+		}
 
-	err := db.Preload("Migrant").
-		Order("niveau_gravite DESC, created_at DESC").
-		Find(&alerts).Error
+		// Recherche textuelle
+		recherche := depots.Recherche(c.Query("search", ""), "titre", "description", "type_alerte", "action_requise").
+			OuDans("migrant_uuid", "migrants", "uuid", "numero_identifiant")
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch alerts",
-			"error":   err.Error(),
-		})
-	}
+		alerts, totalRecords, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").
+			Page("-niveau_gravite,-created_at", page, limit, depots.Egal("migrant_uuid", migrantUUID), recherche)
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All alerts",
-		"data":    alerts,
-	})
-}
+		if err != nil {
+			return problemes.Echec("Failed to fetch alerts for migrant", err)
+		}
 
-// Get one alert
-func GetAlert(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-	var alert models.Alert
+		totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
 
-	err := db.Where("uuid = ?", uuid).
-		Preload("Migrant").
-		First(&alert).Error
+		pagination := map[string]interface{}{
+			"total_records": totalRecords,
+			"total_pages":   totalPages,
+			"current_page":  page,
+			"page_size":     limit,
+		}
 
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Alert not found",
-			"data":    nil,
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Alerts for migrant retrieved successfully",
+			"data":       alerts,
+			"pagination": pagination,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alert found",
-		"data":    alert,
-	})
 }
 
-// Get alerts by migrant with pagination
-func GetAlertsByMigrant(c *fiber.Ctx) error {
-	migrantUUID := c.Params("migrant_uuid")
-	db := database.DB
-
-	// Paramètres de pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+// Create alert
+func CreateAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alert := &models.Alert{}
+
+		if err := c.BodyParser(alert); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		} 
 
-	// Paramètres de filtrage
-	search := c.Query("search", "") 
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "migrant", alert.MigrantUUID) {
+			return unites.Refus(c)
+		}
 
-	var alerts []models.Alert
-	var totalRecords int64
+		// Générer l'UUID
+		alert.UUID = utils.GenerateUUID()
 
-	query := db.Model(&models.Alert{}).Where("migrant_uuid = ?", migrantUUID).Preload("Migrant")
+		if err := depot.Creer(alert); err != nil {
+			return problemes.Echec("Failed to create alert", err)
+		} 
 
-	// Recherche textuelle
-	if search != "" {
-		query = query.Joins("LEFT JOIN migrants ON migrants.uuid = alerts.migrant_uuid").
-			Where("alerts.titre ILIKE ? OR alerts.description ILIKE ? OR alerts.type_alerte ILIKE ? OR alerts.action_requise ILIKE ? OR migrants.numero_identifiant ILIKE ?",
-				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert created successfully",
+			"data":    alert,
+		})
 	}
+}
 
+// Update alert
+func UpdateAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData models.Alert
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
 
-	// Count total
-	query.Count(&totalRecords)
+		alert, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Alert not found", err)
+		}
 
-	// Get paginated results
-	err = query.Offset(offset).
-		Limit(limit).
-		Order("niveau_gravite DESC, created_at DESC").
-		Find(&alerts).Error
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "alert", alert.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch alerts for migrant",
-			"error":   err.Error(),
+		if !versions.Correspond(c, alert.Version) {
+			return versions.Conflit(c, alert.Version, alert)
+		}
+
+		// Conserver l'UUID
+		updateData.UUID = alert.UUID
+
+		if err := depot.Modifier(alert, &updateData); err != nil {
+			// Modifié par un autre agent entre la lecture et l'écriture
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuelle, err := depot.Trouver(alert.UUID); err == nil {
+					alert = actuelle
+				}
+				return versions.Conflit(c, alert.Version, alert)
+			}
+			return problemes.Echec("Failed to update alert", err)
+		}
+
+		versions.ETag(c, alert.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert updated successfully",
+			"data":    alert,
 		})
 	}
+}
 
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// Resolve alert
+func ResolveAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var resolutionData struct {
+			CommentResolution string `json:"comment_resolution"`
+		}
 
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		if err := c.BodyParser(&resolutionData); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Alerts for migrant retrieved successfully",
-		"data":       alerts,
-		"pagination": pagination,
-	})
-}
+		alert, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Alert not found", err)
+		}
 
-// Create alert
-func CreateAlert(c *fiber.Ctx) error {
-	alert := &models.Alert{}
-
-	if err := c.BodyParser(alert); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	} 
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "alert", alert.UUID) {
+			return unites.Refus(c)
+		}
 
-	// Générer l'UUID
-	alert.UUID = utils.GenerateUUID()
+		if !versions.Correspond(c, alert.Version) {
+			return versions.Conflit(c, alert.Version, alert)
+		}
 
-	if err := database.DB.Create(alert).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create alert",
-			"error":   err.Error(),
-		})
-	} 
+		// Marquer comme résolu
+		now := time.Now()
+		updateData := models.Alert{
+			Statut:            "resolved",
+			DateResolution:    &now,
+			CommentResolution: resolutionData.CommentResolution,
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alert created successfully",
-		"data":    alert,
-	})
-}
+		err = depot.Modifier(alert, &updateData, "Statut", "DateResolution", "CommentResolution")
+		if err != nil {
+			// Modifié par un autre agent entre la lecture et l'écriture
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuelle, err := depot.Trouver(alert.UUID); err == nil {
+					alert = actuelle
+				}
+				return versions.Conflit(c, alert.Version, alert)
+			}
+			return problemes.Echec("Failed to resolve alert", err)
+		}
 
-// Update alert
-func UpdateAlert(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var updateData models.Alert
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"error":   err.Error(),
+		versions.ETag(c, alert.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert resolved successfully",
+			"data":    alert,
 		})
 	}
+}
 
-	alert := new(models.Alert)
-	if err := db.Where("uuid = ?", uuid).First(&alert).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Alert not found",
-			"data":    nil,
-		})
-	}
+// Champs d'une alerte modifiables par PATCH
+var reglesPatchAlert = patch.Regles{
+	Modifiables: []string{
+		"type_alerte", "niveau_gravite", "titre", "description", "statut",
+		"date_expiration", "action_requise", "personne_responsable",
+		"date_resolution", "comment_resolution",
+	},
+	Immuables: []string{"uuid", "migrant_uuid", "version", "created_at", "updated_at", "deleted_at"},
+}
 
-	// Conserver l'UUID
-	updateData.UUID = alert.UUID
+// PatchAlert - Modifier partiellement une alerte (JSON Merge Patch, RFC 7396)
+func PatchAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		corps, ok := patch.Lire(c)
+		if !ok {
+			return patch.TypeNonSupporte(c)
+		}
 
-	if err := db.Model(&alert).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to update alert",
-			"error":   err.Error(),
-		})
-	} 
+		alert, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Alert not found", err)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alert updated successfully",
-		"data":    alert,
-	})
-}
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "alert", alert.UUID) {
+			return unites.Refus(c)
+		}
 
-// Resolve alert
-func ResolveAlert(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+		if !versions.Correspond(c, alert.Version) {
+			return versions.Conflit(c, alert.Version, alert)
+		}
 
-	var resolutionData struct {
-		CommentResolution string `json:"comment_resolution"`
-	}
+		corrige, champs, erreurs := patch.Appliquer(*alert, corps, reglesPatchAlert)
+		if len(erreurs) > 0 {
+			return patch.Invalide(c, erreurs)
+		}
 
-	if err := c.BodyParser(&resolutionData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	}
+		err = depot.Modifier(alert, &corrige, champs...)
+		conflit := errors.Is(err, depots.ErrVersionPerimee)
+		if err != nil && !conflit {
+			return problemes.Echec("Failed to update alert", err)
+		}
 
-	var alert models.Alert
-	if err := db.Where("uuid = ?", uuid).First(&alert).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Alert not found",
-			"data":    nil,
-		})
-	}
+		if actuelle, err := depot.Trouver(alert.UUID); err == nil {
+			alert = actuelle
+		}
 
-	// Marquer comme résolu
-	now := time.Now()
-	updateData := map[string]interface{}{
-		"statut":                 "resolved",
-		"date_resolution":        &now,
-		"comment_resolution": resolutionData.CommentResolution,
-	}
+		// Modifié par un autre agent entre la lecture et l'écriture
+		if conflit {
+			return versions.Conflit(c, alert.Version, alert)
+		}
 
-	if err := db.Model(&alert).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to resolve alert",
-			"error":   err.Error(),
+		versions.ETag(c, alert.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert updated successfully",
+			"data":    alert,
 		})
-	} 
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alert resolved successfully",
-		"data":    alert,
-	})
+	}
 }
 
 // Delete alert
-func DeleteAlert(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var alert models.Alert
-	if err := db.Where("uuid = ?", uuid).First(&alert).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Alert not found",
-			"data":    nil,
-		})
-	}
+func DeleteAlert(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alert, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Alert not found", err)
+		}
+
+		if !unites.AccederMigrant(c, alert.MigrantUUID, "alert", alert.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err := db.Delete(&alert).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to delete alert",
-			"error":   err.Error(),
+		if !versions.Correspond(c, alert.Version) {
+			return versions.Conflit(c, alert.Version, alert)
+		}
+
+		if err := depot.Supprimer(alert); err != nil {
+			// Modifiée par un autre agent entre la lecture et la suppression
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuelle, err := depot.Trouver(alert.UUID); err == nil {
+					alert = actuelle
+				}
+				return versions.Conflit(c, alert.Version, alert)
+			}
+			return problemes.Echec("Failed to delete alert", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alert deleted successfully",
+			"data":    nil,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alert deleted successfully",
-		"data":    nil,
-	})
 }
 
 // =======================
@@ -348,53 +386,37 @@ func DeleteAlert(c *fiber.Ctx) error {
 // =======================
 
 // Get alerts statistics
-func GetAlertsStats(c *fiber.Ctx) error {
-	db := database.DB
-
-	var totalAlerts int64
-	var activeAlerts int64
-	var resolvedAlerts int64
-	var criticalAlerts int64
-	var expiredAlerts int64
-
-	// Statistiques générales
-	db.Model(&models.Alert{}).Count(&totalAlerts)
-	db.Model(&models.Alert{}).Where("statut = ?", "active").Count(&activeAlerts)
-	db.Model(&models.Alert{}).Where("statut = ?", "resolved").Count(&resolvedAlerts)
-	db.Model(&models.Alert{}).Where("niveau_gravite = ?", "critical").Count(&criticalAlerts)
-	db.Model(&models.Alert{}).Where("statut = ?", "expired").Count(&expiredAlerts)
-
-	// Statistiques par type d'alerte
-	var alertTypes []map[string]interface{}
-	db.Model(&models.Alert{}).
-		Select("type_alerte, COUNT(*) as count").
-		Group("type_alerte").
-		Order("count DESC").
-		Scan(&alertTypes)
-
-	// Statistiques par niveau de gravité
-	var gravityStats []map[string]interface{}
-	db.Model(&models.Alert{}).
-		Select("niveau_gravite, COUNT(*) as count").
-		Group("niveau_gravite").
-		Order("count DESC").
-		Scan(&gravityStats)
-
-	stats := map[string]interface{}{
-		"total_alerts":         totalAlerts,
-		"active_alerts":        activeAlerts,
-		"resolved_alerts":      resolvedAlerts,
-		"critical_alerts":      criticalAlerts,
-		"expired_alerts":       expiredAlerts,
-		"alert_types":          alertTypes,
-		"gravity_distribution": gravityStats,
-	}
+func GetAlertsStats(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alertes := depot.Lire(unites.PerimetreDe(c).Portee)
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Alerts statistics",
-		"data":    stats,
-	})
+		// Statistiques générales
+		totalAlerts, _ := alertes.Compter()
+		activeAlerts, _ := alertes.Compter(depots.Egal("statut", "active"))
+		resolvedAlerts, _ := alertes.Compter(depots.Egal("statut", "resolved"))
+		criticalAlerts, _ := alertes.Compter(depots.Egal("niveau_gravite", "critical"))
+		expiredAlerts, _ := alertes.Compter(depots.Egal("statut", "expired"))
+
+		// Statistiques par type d'alerte et par niveau de gravité
+		alertTypes, _ := alertes.Repartition("type_alerte", 0)
+		gravityStats, _ := alertes.Repartition("niveau_gravite", 0)
+
+		stats := map[string]interface{}{
+			"total_alerts":         totalAlerts,
+			"active_alerts":        activeAlerts,
+			"resolved_alerts":      resolvedAlerts,
+			"critical_alerts":      criticalAlerts,
+			"expired_alerts":       expiredAlerts,
+			"alert_types":          alertTypes,
+			"gravity_distribution": gravityStats,
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Alerts statistics",
+			"data":    stats,
+		})
+	}
 }
 
 // =======================
@@ -402,36 +424,33 @@ func GetAlertsStats(c *fiber.Ctx) error {
 // =======================
 
 // ExportAlertsToExcel - Exporter les alertes vers Excel avec mise en forme
-func ExportAlertsToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Récupérer les paramètres de filtre
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
-
-	var alerts []models.Alert
-
-	query := db.Model(&models.Alert{}).Preload("Migrant")
+func ExportAlertsToExcel(depot depots.Alertes) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de filtre
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Appliquer les filtres de date
+		var conditions []depots.Condition
+		if startDate != "" {
+			conditions = append(conditions, depots.AuMoins("created_at", startDate))
+		}
+		if endDate != "" {
+			conditions = append(conditions, depots.AuPlus("created_at", endDate))
+		}
 
-	// Appliquer les filtres de date
-	if startDate != "" && endDate != "" {
-		query = query.Where("created_at BETWEEN ? AND ?", startDate, endDate)
-	} else if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
-	} else if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
-	}
+		// Récupérer toutes les données
+		alerts, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch alerts for export", err)
+		}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&alerts).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch alerts for export",
-			"error":   err.Error(),
-		})
+		return exporterAlertes(c, alerts, startDate, endDate)
 	}
+}
 
+// exporterAlertes écrit le classeur des alertes exportées
+func exporterAlertes(c *fiber.Ctx, alerts []models.Alert, startDate, endDate string) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -444,11 +463,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 	f.DeleteSheet("Sheet1")
 	index, err := f.NewSheet("Alertes")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 	f.SetActiveSheet(index)
 
@@ -478,11 +493,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create header style", err)
 	}
 
 	// Style pour les en-têtes de colonnes
@@ -510,11 +521,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create column header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create column header style", err)
 	}
 
 	// Style pour les cellules de données
@@ -536,11 +543,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create data style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create data style", err)
 	}
 
 	// Style pour les cellules de date
@@ -562,11 +565,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		NumFmt: 14, // Format de date mm/dd/yyyy
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create date style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create date style", err)
 	}
 
 	// Style pour les niveaux de gravité avec couleurs
@@ -594,11 +593,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create critical style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create critical style", err)
 	}
 
 	dangerStyle, err := f.NewStyle(&excelize.Style{
@@ -625,11 +620,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create danger style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create danger style", err)
 	}
 
 	warningStyle, err := f.NewStyle(&excelize.Style{
@@ -655,11 +646,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create warning style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create warning style", err)
 	}
 
 	infoStyle, err := f.NewStyle(&excelize.Style{
@@ -684,11 +671,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create info style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create info style", err)
 	}
 
 	// Style pour les statuts
@@ -716,11 +699,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create active status style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create active status style", err)
 	}
 
 	// ===== EN-TÊTE PRINCIPAL =====
@@ -1012,11 +991,7 @@ func ExportAlertsToExcel(c *fiber.Ctx) error {
 	// Sauvegarder en mémoire
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Configurer les en-têtes de réponse pour le téléchargement
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kgermando/sysmobembo-api/database"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 )
 
@@ -19,17 +20,11 @@ func Register(c *fiber.Ctx) error {
 	nu := new(models.User)
 
 	if err := c.BodyParser(&nu); err != nil {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return problemes.Invalide("Invalid input").Cause(err)
 	}
 
 	if nu.Password != nu.PasswordConfirm {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "passwords do not match",
-		})
+		return problemes.Invalide("passwords do not match")
 	}
 
 	u := &models.User{
@@ -73,6 +68,7 @@ func Register(c *fiber.Ctx) error {
 		Fonction:         nu.Fonction,
 		Service:          nu.Service,
 		Direction:        nu.Direction,
+		UniteUUID:        nu.UniteUUID,
 		Ministere:        nu.Ministere,
 		DateRecrutement:  nu.DateRecrutement,
 		DatePriseService: nu.DatePriseService,
@@ -108,8 +104,7 @@ func Register(c *fiber.Ctx) error {
 	u.SetPassword(nu.Password)
 
 	if err := utils.ValidateStruct(*u); err != nil {
-		c.Status(400)
-		return c.JSON(err)
+		return problemes.Validation(err)
 	}
 
 	database.DB.Create(u)
@@ -124,25 +119,17 @@ func Login(c *fiber.Ctx) error {
 
 	err := CreateAdminUser()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Erreur lors de la création de l'utilisateur admin",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Erreur lors de la création de l'utilisateur admin", err)
 	}
 
 	lu := new(models.Login)
 
 	if err := c.BodyParser(&lu); err != nil {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return problemes.Invalide("Invalid input").Cause(err)
 	}
 
 	if err := utils.ValidateStruct(*lu); err != nil {
-		c.Status(400)
-		return c.JSON(err)
+		return problemes.Validation(err)
 	}
 
 	u := &models.User{}
@@ -151,24 +138,15 @@ func Login(c *fiber.Ctx) error {
 		First(&u)
 
 	if result.Error != nil {
-		c.Status(404)
-		return c.JSON(fiber.Map{
-			"message": "invalid email or telephone 😰",
-		})
+		return problemes.Introuvable("invalid email or telephone 😰")
 	}
 
 	if err := u.ComparePassword(lu.Password); err != nil {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "mot de passe incorrect! 😰",
-		})
+		return problemes.Invalide("mot de passe incorrect! 😰")
 	}
 
 	if !u.Status {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "vous n'êtes pas autorisé de se connecter 😰",
-		})
+		return problemes.Invalide("vous n'êtes pas autorisé de se connecter 😰")
 	}
 
 	// Mettre à jour les informations de connexion
@@ -246,6 +224,7 @@ func AuthUser(c *fiber.Ctx) error {
 		Fonction:         u.Fonction,
 		Service:          u.Service,
 		Direction:        u.Direction,
+		UniteUUID:        u.UniteUUID,
 		Ministere:        u.Ministere,
 		DateRecrutement:  u.DateRecrutement,
 		DatePriseService: u.DatePriseService,
@@ -374,11 +353,7 @@ func UpdateInfo(c *fiber.Ctx) error {
 	var updateData UpdateDataInput
 
 	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"errors":  err.Error(),
-		})
+		return problemes.Invalide("Review your input").Cause(err)
 	}
 
 	cookie := c.Cookies("token")
@@ -393,10 +368,7 @@ func UpdateInfo(c *fiber.Ctx) error {
 	result := db.Where("uuid = ?", UserUUID).First(&user)
 
 	if result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Utilisateur non trouvé",
-		})
+		return problemes.Absent("Utilisateur non trouvé", result.Error)
 	}
 
 	// Mettre à jour tous les champs
@@ -485,11 +457,7 @@ func ChangePassword(c *fiber.Ctx) error {
 	var updateData UpdateDataInput
 
 	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"errors":  err.Error(),
-		})
+		return problemes.Invalide("Review your input").Cause(err)
 	}
 
 	// Utiliser la même logique que AuthUser - récupérer le token depuis les query params
@@ -499,10 +467,7 @@ func ChangePassword(c *fiber.Ctx) error {
 
 	UserUUID, err := utils.VerifyJwt(token)
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Token invalide ou expiré",
-		})
+		return problemes.Nouveau(fiber.StatusUnauthorized, problemes.CodeNonAuthentifie, "Token invalide ou expiré")
 	}
 
 	fmt.Println("UserUUID", UserUUID)
@@ -513,24 +478,15 @@ func ChangePassword(c *fiber.Ctx) error {
 	result := database.DB.Where("uuid = ?", UserUUID).First(&user)
 
 	if result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Utilisateur non trouvé",
-		})
+		return problemes.Absent("Utilisateur non trouvé", result.Error)
 	}
 
 	if err := user.ComparePassword(updateData.OldPassword); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "votre mot de passe n'est pas correct! 😰",
-		})
+		return problemes.Invalide("votre mot de passe n'est pas correct! 😰")
 	}
 
 	if updateData.Password != updateData.PasswordConfirm {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "passwords do not match",
-		})
+		return problemes.Invalide("passwords do not match")
 	}
 
 	// Utiliser la méthode SetPassword du modèle au lieu de utils.HashPassword
@@ -661,11 +617,7 @@ func CreateAdminHandler(c *fiber.Ctx) error {
 
 	err := CreateAdminUser()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Erreur lors de la création de l'utilisateur admin",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Erreur lors de la création de l'utilisateur admin", err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kgermando/sysmobembo-api/database"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,10 +34,7 @@ func ForgotPassword(c *fiber.Ctx) error {
 
 	database.DB.Where("email = ?", u.Email).First(um)
 	if um.UUID == "" {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "invalid email address 😰",
-		})
+		return problemes.Invalide("invalid email address 😰")
 	}
 
 	// token expiration time is 3hr
@@ -59,10 +57,7 @@ func ForgotPassword(c *fiber.Ctx) error {
 
 	err := smtp.SendMail(utils.Env("EMAIL_HOST")+":"+utils.Env("EMAIL_PORT"), auth, from, to, msg)
 	if err != nil {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "email was not sent 😰",
-		})
+		return problemes.Invalide("email was not sent 😰")
 	}
 
 	return c.JSON(fiber.Map{
@@ -76,35 +71,23 @@ func ResetPassword(c *fiber.Ctx) error {
 	rp := &models.PasswordReset{}
 
 	if err := database.DB.Where("token = ?", c.Params("token")).Last(rp); err.Error != nil {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "invalid token",
-		})
+		return problemes.Invalide("invalid token")
 	}
 
 	if rp.UUID == "" {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "invalid token",
-		})
+		return problemes.Invalide("invalid token")
 	}
 
 	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 	if now.After(rp.ExpirationTime) {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "token has expired",
-		})
+		return problemes.Invalide("token has expired")
 	}
 
 	r := new(models.Reset)
 
 	if r.Password != r.PasswordConfirm {
-		c.Status(400)
-		return c.JSON(fiber.Map{
-			"message": "password does not match",
-		})
+		return problemes.Invalide("password does not match")
 	}
 
 	password, _ := bcrypt.GenerateFromPassword([]byte(r.Password), 14)
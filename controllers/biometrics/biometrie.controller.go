@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
@@ -85,314 +88,285 @@ func assessDataQuality(dataSize int, typeBiometrie string) string {
 	}
 }
 
-// =======================
-// CRUD OPERATIONS
-// =======================
-
-// Paginate - Récupérer les données biométriques avec pagination
-func GetPaginatedBiometries(c *fiber.Ctx) error {
-	db := database.DB
+// PreparerBiometrie calcule l'empreinte du gabarit puis chiffre les données avant enregistrement
+func PreparerBiometrie(biometrie *models.Biometrie) error {
+	// Empreinte du gabarit en clair pour le rapprochement des doublons
+	biometrie.EmpreinteHash = utils.BiometricFingerprint(biometrie.TypeBiometrie, biometrie.DonneesBiometriques)
 
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
+	// Chiffrer les données biométriques
+	encryptedData, encryptionKey, err := encryptBiometricData(biometrie.DonneesBiometriques)
+	if err != nil {
+		return err
 	}
-	offset := (page - 1) * limit
 
-	// Récupérer le paramètre de recherche
-	search := c.Query("search", "")
-
-	var biometries []models.Biometrie
-	var totalRecords int64
+	biometrie.DonneesBiometriques = encryptedData
+	biometrie.CleChiffrement = encryptionKey
+	biometrie.Chiffre = true
 
-	query := db.Model(&models.Biometrie{}).
-		Preload("Migrant").
-		Joins("LEFT JOIN migrants ON migrants.uuid = biometries.migrant_uuid").
-		Select("biometries.uuid, biometries.migrant_uuid, migrants.numero_identifiant, biometries.type_biometrie, biometries.index_doigt, biometries.qualite_donnee, biometries.algorithme_encodage, biometries.taille_fichier, biometries.date_capture, biometries.disposif_capture, biometries.verifie, biometries.score_confiance, biometries.chiffre, biometries.created_at, biometries.updated_at") // Exclure les données sensibles
+	// Calculer la taille des données
+	biometrie.TailleFichier = len(biometrie.DonneesBiometriques)
 
-	// Appliquer la recherche si le paramètre est fourni
-	if search != "" {
-		query = query.Joins("LEFT JOIN migrants ON migrants.uuid = biometries.migrant_uuid").
-			Where("migrants.numero_identifiant ILIKE ? OR biometries.type_biometrie ILIKE ? OR biometries.qualite_donnee ILIKE ? OR biometries.disposif_capture ILIKE ?",
-				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+	// Évaluer la qualité des données
+	if biometrie.QualiteDonnee == "" {
+		biometrie.QualiteDonnee = assessDataQuality(biometrie.TailleFichier, biometrie.TypeBiometrie)
 	}
+	return nil
+}
 
-	// Count total
-	query.Count(&totalRecords)
+// =======================
+// CRUD OPERATIONS
+// =======================
 
-	// Get paginated results
-	err = query.Offset(offset).
-		Limit(limit).
-		Order("biometries.created_at DESC").
-		Find(&biometries).Error
+// Champs biométriques pouvant être filtrés, triés et sélectionnés (hors données sensibles)
+var listeBiometries = requetes.Schema{
+	Table: "biometries",
+	Champs: map[string]requetes.Type{
+		"uuid":                requetes.Texte,
+		"created_at":          requetes.Date,
+		"updated_at":          requetes.Date,
+		"migrant_uuid":        requetes.Texte,
+		"type_biometrie":      requetes.Texte,
+		"index_doigt":         requetes.Nombre,
+		"qualite_donnee":      requetes.Texte,
+		"algorithme_encodage": requetes.Texte,
+		"taille_fichier":      requetes.Nombre,
+		"date_capture":        requetes.Date,
+		"dispositif_capture":  requetes.Texte,
+		"chiffre":             requetes.Booleen,
+		"verifie":             requetes.Booleen,
+		"score_confiance":     requetes.Nombre,
+	},
+	Colonnes:  map[string]string{"dispositif_capture": "disposif_capture"},
+	Relations: []string{"migrant"},
+	TriDefaut: "-created_at",
+}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch biometric data",
-			"error":   err.Error(),
-		})
-	}
+// Colonnes chargées dans les listes : les données biométriques sensibles sont exclues
+var colonnesListe = []string{
+	"uuid", "migrant_uuid", "type_biometrie", "index_doigt", "qualite_donnee", "algorithme_encodage",
+	"taille_fichier", "date_capture", "disposif_capture", "verifie", "score_confiance", "chiffre",
+	"created_at", "updated_at",
+}
 
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// Paginate - Récupérer les données biométriques avec pagination
+func GetPaginatedBiometries(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeBiometries)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		// Appliquer la recherche si le paramètre est fourni
+		recherche := depots.Recherche(c.Query("search", ""), "type_biometrie", "qualite_donnee", "disposif_capture").
+			OuDans("migrant_uuid", "migrants", "uuid", "numero_identifiant")
 
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Biometric data retrieved successfully",
-		"data":       biometries,
-		"pagination": pagination,
-	})
+		// Filtres, tri et pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).
+			Avec("Migrant").
+			Colonnes(colonnesListe...).
+			Paginer(liste, recherche)
+
+		if err != nil {
+			return problemes.Echec("Failed to fetch biometric data", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Biometric data retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
+		})
+	}
 }
 
 // Get all biometries (without sensitive data)
-func GetAllBiometries(c *fiber.Ctx) error {
-	db := database.DB
-	var biometries []models.Biometrie
+func GetAllBiometries(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeBiometries)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	err := db.Model(&models.Biometrie{}).
-		Joins("LEFT JOIN migrants ON migrants.uuid = biometries.migrant_uuid").
-		Select("biometries.uuid, biometries.migrant_uuid, migrants.numero_identifiant, biometries.type_biometrie, biometries.index_doigt, biometries.qualite_donnee, biometries.algorithme_encodage, biometries.taille_fichier, biometries.date_capture, biometries.disposif_capture, biometries.verifie, biometries.score_confiance, biometries.chiffre, biometries.created_at, biometries.updated_at").
-		Preload("Migrant").
-		Find(&biometries).Error
+		data, err := depot.Lire(unites.PerimetreDe(c).Portee).
+			Avec("Migrant").
+			Colonnes(colonnesListe...).
+			Tout(liste)
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch biometric data",
-			"error":   err.Error(),
+		if err != nil {
+			return problemes.Echec("Failed to fetch biometric data", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All biometric data",
+			"data":    data,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All biometric data",
-		"data":    biometries,
-	})
 }
 
 // Get one biometry (without sensitive data by default)
-func GetBiometrie(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	includeSensitive := c.Query("include_sensitive", "false")
+func GetBiometrie(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		includeSensitive := c.Query("include_sensitive", "false")
 
-	db := database.DB
-	var biometrie models.Biometrie
+		biometrie, err := depot.Trouver(c.Params("uuid"), "Migrant")
+		if err != nil {
+			return problemes.Absent("Biometric data not found", err)
+		}
 
-	query := db.Where("uuid = ?", uuid).Preload("Migrant")
+		if !unites.AccederMigrant(c, biometrie.MigrantUUID, "biometrie", biometrie.UUID) {
+			return unites.Refus(c)
+		}
 
-	// Exclure les données sensibles par défaut
-	if includeSensitive != "true" {
-		query = query.Select("uuid, migrant_uuid, type_biometrie, index_doigt, qualite_donnee, algorithme_encodage, taille_fichier, date_capture, disposif_capture, verifie, score_confiance, chiffre, created_at, updated_at")
-	}
+		// Exclure les données sensibles par défaut
+		if includeSensitive != "true" {
+			biometrie.DonneesBiometriques = ""
+		}
 
-	err := query.First(&biometrie).Error
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Biometric data not found",
-			"data":    nil,
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Biometric data found",
+			"data":    biometrie,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Biometric data found",
-		"data":    biometrie,
-	})
 }
 
 // Get biometries by migrant with pagination
-func GetBiometriesByMigrant(c *fiber.Ctx) error {
-	migrantUUID := c.Params("migrant_uuid")
-	db := database.DB
+func GetBiometriesByMigrant(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrantUUID := c.Params("uuid")
 
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+		page, err := strconv.Atoi(c.Query("page", "1"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		limit, err := strconv.Atoi(c.Query("limit", "15"))
+		if err != nil || limit <= 0 {
+			limit = 15
+		}
 
-	var biometries []models.Biometrie
-	var totalRecords int64
+		biometries, totalRecords, err := depot.Lire(unites.PerimetreDe(c).Portee).
+			Avec("Migrant").
+			Colonnes(colonnesListe...).
+			Page("-created_at", page, limit, depots.Egal("migrant_uuid", migrantUUID))
 
-	query := db.Model(&models.Biometrie{}).
-		Joins("LEFT JOIN migrants ON migrants.uuid = biometries.migrant_uuid").
-		Where("biometries.migrant_uuid = ?", migrantUUID).
-		Select("biometries.uuid, biometries.migrant_uuid, migrants.numero_identifiant, biometries.type_biometrie, biometries.index_doigt, biometries.qualite_donnee, biometries.algorithme_encodage, biometries.taille_fichier, biometries.date_capture, biometries.disposif_capture, biometries.verifie, biometries.score_confiance, biometries.chiffre, biometries.created_at, biometries.updated_at")
+		if err != nil {
+			return problemes.Echec("Failed to fetch biometric data for migrant", err)
+		}
 
-	// Count total
-	query.Count(&totalRecords)
+		totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
 
-	// Get paginated results
-	err = query.Preload("Migrant").
-		Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&biometries).Error
+		pagination := map[string]interface{}{
+			"total_records": totalRecords,
+			"total_pages":   totalPages,
+			"current_page":  page,
+			"page_size":     limit,
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch biometric data for migrant",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Biometric data for migrant",
+			"data":       biometries,
+			"pagination": pagination,
 		})
 	}
-
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
-
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
-
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Biometric data for migrant",
-		"data":       biometries,
-		"pagination": pagination,
-	})
 }
 
 // Create biometry
-func CreateBiometrie(c *fiber.Ctx) error {
-	biometrie := &models.Biometrie{}
-
-	if err := c.BodyParser(biometrie); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	}
+func CreateBiometrie(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		biometrie := &models.Biometrie{}
 
-	// Générer l'UUID
-	biometrie.UUID = utils.GenerateUUID()
+		if err := c.BodyParser(biometrie); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	// Chiffrer les données biométriques
-	encryptedData, encryptionKey, err := encryptBiometricData(biometrie.DonneesBiometriques)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to encrypt biometric data",
-			"error":   err.Error(),
-		})
-	}
+		if !unites.AccederMigrant(c, biometrie.MigrantUUID, "migrant", biometrie.MigrantUUID) {
+			return unites.Refus(c)
+		}
 
-	biometrie.DonneesBiometriques = encryptedData
-	biometrie.CleChiffrement = encryptionKey
-	biometrie.Chiffre = true
+		// Générer l'UUID
+		biometrie.UUID = utils.GenerateUUID()
 
-	// Calculer la taille des données
-	biometrie.TailleFichier = len(biometrie.DonneesBiometriques)
+		if err := PreparerBiometrie(biometrie); err != nil {
+			return problemes.Echec("Failed to encrypt biometric data", err)
+		}
 
-	// Évaluer la qualité des données
-	if biometrie.QualiteDonnee == "" {
-		biometrie.QualiteDonnee = assessDataQuality(biometrie.TailleFichier, biometrie.TypeBiometrie)
-	}
+		if err := depot.Creer(biometrie); err != nil {
+			return problemes.Echec("Failed to create biometric data", err)
+		}
 
-	if err := database.DB.Create(biometrie).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create biometric data",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Biometric data created successfully",
+			"data":    biometrie,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Biometric data created successfully",
-		"data":    biometrie,
-	})
 }
 
 // Update biometry (metadata only, not the biometric data itself)
-func UpdateBiometrie(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var updateData struct {
-		QualiteDonnee     string `json:"qualite_donnee"`
-		DisposifCapture   string `json:"dispositif_capture"`
-		ResolutionCapture string `json:"resolution_capture"`
-		OperateurCapture  string `json:"operateur_capture"`
-	}
+func UpdateBiometrie(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData struct {
+			QualiteDonnee     string `json:"qualite_donnee"`
+			DisposifCapture   string `json:"dispositif_capture"`
+			ResolutionCapture string `json:"resolution_capture"`
+			OperateurCapture  string `json:"operateur_capture"`
+		}
 
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"error":   err.Error(),
-		})
-	}
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
 
-	biometrie := new(models.Biometrie)
-	if err := db.Where("uuid = ?", uuid).First(&biometrie).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Biometric data not found",
-			"data":    nil,
-		})
-	}
+		biometrie, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Biometric data not found", err)
+		}
+
+		if !unites.AccederMigrant(c, biometrie.MigrantUUID, "biometrie", biometrie.UUID) {
+			return unites.Refus(c)
+		}
+
+		metadonnees := models.Biometrie{
+			QualiteDonnee:     updateData.QualiteDonnee,
+			DisposifCapture:   updateData.DisposifCapture,
+			ResolutionCapture: updateData.ResolutionCapture,
+			OperateurCapture:  updateData.OperateurCapture,
+		}
+		if err := depot.Modifier(biometrie, &metadonnees); err != nil {
+			return problemes.Echec("Failed to update biometric data", err)
+		}
 
-	if err := db.Model(&biometrie).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to update biometric data",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Biometric data updated successfully",
+			"data":    biometrie,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Biometric data updated successfully",
-		"data":    biometrie,
-	})
 }
 
 // Delete biometry
-func DeleteBiometrie(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var biometrie models.Biometrie
-	if err := db.Where("uuid = ?", uuid).First(&biometrie).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Biometric data not found",
-			"data":    nil,
-		})
-	}
+func DeleteBiometrie(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		biometrie, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Biometric data not found", err)
+		}
+
+		if !unites.AccederMigrant(c, biometrie.MigrantUUID, "biometrie", biometrie.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err := db.Delete(&biometrie).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to delete biometric data",
-			"error":   err.Error(),
+		if err := depot.Supprimer(biometrie); err != nil {
+			return problemes.Echec("Failed to delete biometric data", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Biometric data deleted successfully",
+			"data":    nil,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Biometric data deleted successfully",
-		"data":    nil,
-	})
 }
 
 // =======================
@@ -400,66 +374,41 @@ func DeleteBiometrie(c *fiber.Ctx) error {
 // =======================
 
 // Get biometrics statistics
-func GetBiometricsStats(c *fiber.Ctx) error {
-	db := database.DB
-
-	var totalBiometrics int64
-	var verifiedBiometrics int64
-	var encryptedBiometrics int64
-
-	// Statistiques générales
-	db.Model(&models.Biometrie{}).Count(&totalBiometrics)
-	db.Model(&models.Biometrie{}).Where("verifie = ?", true).Count(&verifiedBiometrics)
-	db.Model(&models.Biometrie{}).Where("chiffre = ?", true).Count(&encryptedBiometrics)
-
-	// Statistiques par type de biométrie
-	var biometricTypes []map[string]interface{}
-	db.Model(&models.Biometrie{}).
-		Select("type_biometrie, COUNT(*) as count").
-		Group("type_biometrie").
-		Order("count DESC").
-		Scan(&biometricTypes)
-
-	// Statistiques par qualité
-	var qualityStats []map[string]interface{}
-	db.Model(&models.Biometrie{}).
-		Select("qualite_donnee, COUNT(*) as count").
-		Group("qualite_donnee").
-		Order("count DESC").
-		Scan(&qualityStats)
-
-	// Score de confiance moyen
-	var avgConfidenceScore *float64
-	db.Model(&models.Biometrie{}).
-		Where("score_confiance IS NOT NULL").
-		Select("AVG(score_confiance)").
-		Scan(&avgConfidenceScore)
-
-	// Dispositifs de capture les plus utilisés
-	var captureDevices []map[string]interface{}
-	db.Model(&models.Biometrie{}).
-		Where("disposif_capture IS NOT NULL AND disposif_capture != ''").
-		Select("disposif_capture, COUNT(*) as count").
-		Group("disposif_capture").
-		Order("count DESC").
-		Limit(10).
-		Scan(&captureDevices)
-
-	stats := map[string]interface{}{
-		"total_biometrics":     totalBiometrics,
-		"verified_biometrics":  verifiedBiometrics,
-		"encrypted_biometrics": encryptedBiometrics,
-		"biometric_types":      biometricTypes,
-		"quality_distribution": qualityStats,
-		"avg_confidence_score": avgConfidenceScore,
-		"capture_devices":      captureDevices,
-	}
+func GetBiometricsStats(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		biometries := depot.Lire(unites.PerimetreDe(c).Portee)
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Biometrics statistics",
-		"data":    stats,
-	})
+		// Statistiques générales
+		totalBiometrics, _ := biometries.Compter()
+		verifiedBiometrics, _ := biometries.Compter(depots.Egal("verifie", true))
+		encryptedBiometrics, _ := biometries.Compter(depots.Egal("chiffre", true))
+
+		// Statistiques par type de biométrie et par qualité
+		biometricTypes, _ := biometries.Repartition("type_biometrie", 0)
+		qualityStats, _ := biometries.Repartition("qualite_donnee", 0)
+
+		// Score de confiance moyen
+		avgConfidenceScore, _ := biometries.Moyenne("score_confiance")
+
+		// Dispositifs de capture les plus utilisés
+		captureDevices, _ := biometries.Repartition("disposif_capture", 10, depots.Renseigne("disposif_capture"))
+
+		stats := map[string]interface{}{
+			"total_biometrics":     totalBiometrics,
+			"verified_biometrics":  verifiedBiometrics,
+			"encrypted_biometrics": encryptedBiometrics,
+			"biometric_types":      biometricTypes,
+			"quality_distribution": qualityStats,
+			"avg_confidence_score": avgConfidenceScore,
+			"capture_devices":      captureDevices,
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Biometrics statistics",
+			"data":    stats,
+		})
+	}
 }
 
 // =======================
@@ -467,43 +416,41 @@ func GetBiometricsStats(c *fiber.Ctx) error {
 // =======================
 
 // ExportBiometriesToExcel - Exporter les biométries vers Excel avec mise en forme
-func ExportBiometriesToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Récupérer les paramètres de date
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
-
-	var biometries []models.Biometrie
-
-	query := db.Model(&models.Biometrie{}).Preload("Migrant")
-
-	// Appliquer les filtres de date
-	if startDate != "" {
-		parsedStartDate, err := time.Parse("2006-01-02", startDate)
-		if err == nil {
-			query = query.Where("biometries.created_at >= ?", parsedStartDate)
+func ExportBiometriesToExcel(depot depots.Biometries) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de date
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Appliquer les filtres de date
+		var conditions []depots.Condition
+		if startDate != "" {
+			parsedStartDate, err := time.Parse("2006-01-02", startDate)
+			if err == nil {
+				conditions = append(conditions, depots.AuMoins("created_at", parsedStartDate))
+			}
 		}
-	}
-	if endDate != "" {
-		parsedEndDate, err := time.Parse("2006-01-02", endDate)
-		if err == nil {
-			// Ajouter 23:59:59 pour inclure toute la journée
-			parsedEndDate = parsedEndDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-			query = query.Where("biometries.created_at <= ?", parsedEndDate)
+		if endDate != "" {
+			parsedEndDate, err := time.Parse("2006-01-02", endDate)
+			if err == nil {
+				// Ajouter 23:59:59 pour inclure toute la journée
+				parsedEndDate = parsedEndDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+				conditions = append(conditions, depots.AuPlus("created_at", parsedEndDate))
+			}
 		}
-	}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&biometries).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch biometrics for export",
-			"error":   err.Error(),
-		})
+		// Récupérer toutes les données
+		biometries, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch biometrics for export", err)
+		}
+
+		return exporterBiometries(c, biometries, startDate, endDate)
 	}
+}
 
+// exporterBiometries écrit le classeur des données biométriques exportées
+func exporterBiometries(c *fiber.Ctx, biometries []models.Biometrie, startDate, endDate string) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -516,11 +463,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 	f.DeleteSheet("Sheet1")
 	index, err := f.NewSheet("Biométries")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 	f.SetActiveSheet(index)
 
@@ -550,11 +493,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create header style", err)
 	}
 
 	// Style pour les en-têtes de colonnes
@@ -582,11 +521,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create column header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create column header style", err)
 	}
 
 	// Style pour les cellules de données
@@ -608,11 +543,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create data style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create data style", err)
 	}
 
 	// Style pour les cellules numériques
@@ -634,11 +565,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		NumFmt: 1,
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create number style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create number style", err)
 	}
 
 	// Style pour les cellules de date
@@ -660,11 +587,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		NumFmt: 14,
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create date style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create date style", err)
 	}
 
 	// Style pour les cellules booléennes
@@ -686,11 +609,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create boolean style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create boolean style", err)
 	}
 
 	// Style pour le score de confiance
@@ -712,11 +631,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 		NumFmt: 4,
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create score style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create score style", err)
 	}
 
 	// ===== EN-TÊTE PRINCIPAL =====
@@ -1045,11 +960,7 @@ func ExportBiometriesToExcel(c *fiber.Ctx) error {
 	// Sauvegarder en mémoire
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Configurer les en-têtes de réponse pour le téléchargement
@@ -0,0 +1,286 @@
+package cartes
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// Durée de validité par défaut d'une carte d'enregistrement
+const dureeValiditeMois = 24
+
+// BaseURL retourne l'adresse publique encodée dans les QR codes
+func BaseURL(c *fiber.Ctx) string {
+	if url := utils.Env("PUBLIC_BASE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return c.BaseURL()
+}
+
+// VerificationURL retourne l'URL publique de vérification encodée dans le QR code d'une carte
+func VerificationURL(c *fiber.Ctx, jeton string) string {
+	return utils.GenerateCardVerificationURL(BaseURL(c), jeton)
+}
+
+// reponseCarte ajoute l'URL de vérification et l'image du QR code à une carte
+func reponseCarte(c *fiber.Ctx, carte models.CarteMigrant) (fiber.Map, error) {
+	url := VerificationURL(c, carte.Jeton)
+	png, err := qrcode.Encode(url, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, keyID, err := utils.CardPublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"carte":            carte,
+		"verification_url": url,
+		"qr_code":          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		"cle_publique":     publicKey,
+		"cle_id":           keyID,
+	}, nil
+}
+
+// IssueMigrantCard - Émettre une carte d'enregistrement signée pour un migrant
+// POST /api/migrants/:uuid/card  {"validite_mois": 24}
+func IssueMigrantCard(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		ValiditeMois int `json:"validite_mois"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
+	}
+	if body.ValiditeMois <= 0 || body.ValiditeMois > 60 {
+		body.ValiditeMois = dureeValiditeMois
+	}
+
+	migrant, err := migrantAccessible(c, db, uuid)
+	if err != nil {
+		return err
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+	carte := models.CarteMigrant{
+		UUID:              utils.GenerateUUID(),
+		MigrantUUID:       migrant.UUID,
+		NumeroIdentifiant: migrant.NumeroIdentifiant,
+		Serie:             "CRT-" + strings.ToUpper(strings.ReplaceAll(utils.GenerateUUID(), "-", "")[:12]),
+		DateEmission:      now,
+		DateExpiration:    now.AddDate(0, body.ValiditeMois, 0),
+		Statut:            "active",
+		EmisePar:          userUUID,
+	}
+
+	jeton, err := utils.SignCard(utils.CardPayload{
+		Serie:             carte.Serie,
+		NumeroIdentifiant: carte.NumeroIdentifiant,
+		DateEmission:      carte.DateEmission.Unix(),
+		DateExpiration:    carte.DateExpiration.Unix(),
+	})
+	if err != nil {
+		return problemes.Echec("Failed to sign card", err)
+	}
+	carte.Jeton = jeton
+
+	// La nouvelle carte remplace la carte active précédente
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.CarteMigrant{}).
+			Where("migrant_uuid = ? AND statut = ?", migrant.UUID, "active").
+			Updates(map[string]interface{}{
+				"statut":           "revoquee",
+				"date_revocation":  &now,
+				"motif_revocation": "Remplacée par la carte " + carte.Serie,
+				"revoque_par":      userUUID,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&carte).Error
+	})
+	if err != nil {
+		return problemes.Echec("Failed to issue card", err)
+	}
+
+	data, err := reponseCarte(c, carte)
+	if err != nil {
+		return problemes.Echec("Failed to generate QR code", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Card issued successfully",
+		"data":    data,
+	})
+}
+
+// migrantAccessible charge le migrant et vérifie qu'il relève du périmètre de l'agent
+func migrantAccessible(c *fiber.Ctx, db *gorm.DB, uuid string) (models.Migrant, error) {
+	var migrant models.Migrant
+	if err := db.Where("uuid = ?", uuid).First(&migrant).Error; err != nil {
+		return migrant, problemes.Absent("Migrant not found", err)
+	}
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return migrant, unites.Refus(c)
+	}
+	return migrant, nil
+}
+
+// GetMigrantCard - Carte active d'un migrant
+// GET /api/migrants/:uuid/card
+func GetMigrantCard(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	if _, err := migrantAccessible(c, db, uuid); err != nil {
+		return err
+	}
+
+	var carte models.CarteMigrant
+	err := db.Where("migrant_uuid = ? AND statut = ?", uuid, "active").
+		Order("date_emission DESC").
+		First(&carte).Error
+	if err != nil {
+		return problemes.Absent("No active card for this migrant", err)
+	}
+
+	data, err := reponseCarte(c, carte)
+	if err != nil {
+		return problemes.Echec("Failed to generate QR code", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Card found",
+		"data":    data,
+	})
+}
+
+// RevokeMigrantCard - Révoquer la carte active d'un migrant (perte, vol, fraude)
+// PUT /api/migrants/:uuid/card/revoke  {"motif": "..."}
+func RevokeMigrantCard(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		Motif string `json:"motif" validate:"required"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(body); err != nil {
+		return problemes.Validation(err)
+	}
+
+	if _, err := migrantAccessible(c, db, uuid); err != nil {
+		return err
+	}
+
+	var carte models.CarteMigrant
+	if err := db.Where("migrant_uuid = ? AND statut = ?", uuid, "active").First(&carte).Error; err != nil {
+		return problemes.Absent("No active card for this migrant", err)
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+	err := db.Model(&carte).Updates(map[string]interface{}{
+		"statut":           "revoquee",
+		"date_revocation":  &now,
+		"motif_revocation": body.Motif,
+		"revoque_par":      userUUID,
+	}).Error
+	if err != nil {
+		return problemes.Echec("Failed to revoke card", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Card revoked successfully",
+		"data":    carte,
+	})
+}
+
+// VerifyCardToken - Vérification publique d'une carte scannée.
+// Ne renvoie que le statut de la carte, jamais les données personnelles du migrant.
+// GET /api/verify/:token
+func VerifyCardToken(c *fiber.Ctx) error {
+	db := database.DB
+
+	payload, err := utils.VerifyCard(c.Params("token"))
+	if err != nil {
+		return problemes.Invalide("Invalid card").Cause(err).Avec("data", fiber.Map{
+			"valide": false,
+			"motif":  "signature_invalide",
+		})
+	}
+
+	resultat := fiber.Map{
+		"valide":             false,
+		"numero_identifiant": payload.NumeroIdentifiant,
+		"serie":              payload.Serie,
+		"date_emission":      time.Unix(payload.DateEmission, 0),
+		"date_expiration":    time.Unix(payload.DateExpiration, 0),
+	}
+
+	var carte models.CarteMigrant
+	err = db.Where("serie = ? AND numero_identifiant = ?", payload.Serie, payload.NumeroIdentifiant).First(&carte).Error
+	switch {
+	case err != nil:
+		resultat["motif"] = "carte_inconnue"
+	case carte.Statut == "revoquee":
+		resultat["motif"] = "carte_revoquee"
+	case payload.CardExpired(time.Now()):
+		resultat["motif"] = "carte_expiree"
+	default:
+		// Le migrant doit toujours figurer au registre
+		var count int64
+		db.Model(&models.Migrant{}).Where("uuid = ?", carte.MigrantUUID).Count(&count)
+		if count == 0 {
+			resultat["motif"] = "enregistrement_supprime"
+		} else {
+			resultat["valide"] = true
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Card verified",
+		"data":    resultat,
+	})
+}
+
+// GetVerificationKey - Clé publique Ed25519 pour la vérification hors ligne des cartes
+// GET /api/verify/public-key
+func GetVerificationKey(c *fiber.Ctx) error {
+	publicKey, keyID, err := utils.CardPublicKey()
+	if err != nil {
+		return problemes.Echec("Card signing key unavailable", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Card verification key",
+		"data": fiber.Map{
+			"algorithme":   "Ed25519",
+			"cle_publique": publicKey,
+			"cle_id":       keyID,
+			"format":       "base64url(charge_utile_json).base64url(signature)",
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package cartes
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// La carte d'un migrant d'une autre unité ne peut être ni lue ni révoquée
+func TestCartesRestreintesAuPerimetre(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID: "unite-" + code, Code: code, Nom: "Direction " + code,
+			TypeUnite: "direction_provinciale", Actif: true, Chemin: "/unite-" + code + "/",
+		}
+		identite := models.Identite{UUID: "identite-" + code, Nom: "NOM " + code, NumeroPasseport: "OP" + code, UniteUUID: unite.UUID}
+		migrant := models.Migrant{
+			UUID: "migrant-" + code, IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-" + code,
+			StatutMigratoire: "regulier", UniteUUID: unite.UUID,
+		}
+		carte := models.CarteMigrant{
+			UUID: "carte-" + code, MigrantUUID: migrant.UUID, NumeroIdentifiant: migrant.NumeroIdentifiant,
+			Serie: "CRT-" + code, DateEmission: time.Now(), DateExpiration: time.Now().AddDate(2, 0, 0), Statut: "active",
+		}
+		for _, err := range []error{
+			db.Create(&unite).Error, db.Create(&identite).Error,
+			db.Omit("Identite").Create(&migrant).Error, db.Create(&carte).Error,
+		} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	agent := basetest.Utilisateur(t, db, "Agent", "unite-A")
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/migrants/:uuid/card", GetMigrantCard)
+	app.Put("/migrants/:uuid/card/revoke", RevokeMigrantCard)
+	appeler := func(methode, url string) int {
+		t.Helper()
+		req := httptest.NewRequest(methode, url+"?token="+agent, bytes.NewBufferString(`{"motif": "Perte"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	cas := []struct {
+		methode, url string
+		statut       int
+	}{
+		{"GET", "/migrants/migrant-B/card", fiber.StatusForbidden},
+		{"PUT", "/migrants/migrant-B/card/revoke", fiber.StatusForbidden},
+		{"GET", "/migrants/inconnu/card", fiber.StatusNotFound},
+		{"GET", "/migrants/migrant-A/card", fiber.StatusOK},
+		{"PUT", "/migrants/migrant-A/card/revoke", fiber.StatusOK},
+	}
+	for _, tc := range cas {
+		if statut := appeler(tc.methode, tc.url); statut != tc.statut {
+			t.Errorf("%s %s : statut %d, attendu %d", tc.methode, tc.url, statut, tc.statut)
+		}
+	}
+
+	var carte models.CarteMigrant
+	if err := db.Where("uuid = ?", "carte-B").First(&carte).Error; err != nil {
+		t.Fatal(err)
+	}
+	if carte.Statut != "active" {
+		t.Errorf("carte de B %s, attendue active", carte.Statut)
+	}
+}
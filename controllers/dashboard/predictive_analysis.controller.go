@@ -1,2604 +1,319 @@
 package dashboard
 
-// import (
-// 	"fmt"
-// 	"math"
-// 	"net/http"
-// 	"strconv"
-// 	"time"
-
-// 	"github.com/gofiber/fiber/v2"
-// 	"github.com/kgermando/sysmobembo-api/database"
-// 	"github.com/kgermando/sysmobembo-api/models"
-// )
-
-// // Structure pour les statistiques globales (sans géolocalisation)
-// type GlobalMigrationStats struct {
-// 	TotalMigrants     int64               `json:"total_migrants"`
-// 	MigrantsActifs    int64               `json:"migrants_actifs"`
-// 	NouveauxMigrants  int64               `json:"nouveaux_migrants_30j"`
-// 	TauxCroissance    float64             `json:"taux_croissance_mensuel"`
-// 	DistributionGenre map[string]int64    `json:"distribution_genre"`
-// 	DistributionAge   map[string]int64    `json:"distribution_age"`
-// 	StatutMigratoire  map[string]int64    `json:"statut_migratoire"`
-// 	PaysOrigineTop    []PaysStatistique   `json:"pays_origine_top10"`
-// 	TendanceMensuelle []TendanceMensuelle `json:"tendance_mensuelle_12m"`
-// 	MotifsPrincipaux  []MotifStatistique  `json:"motifs_principaux"`
-// 	IndicateursRisque IndicateursRisque   `json:"indicateurs_risque"`
-// }
-
-// type PaysStatistique struct {
-// 	Pays        string  `json:"pays"`
-// 	Nombre      int64   `json:"nombre"`
-// 	Pourcentage float64 `json:"pourcentage"`
-// }
-
-// type TendanceMensuelle struct {
-// 	Mois      string  `json:"mois"`
-// 	Annee     int     `json:"annee"`
-// 	Nombre    int64   `json:"nombre"`
-// 	Variation float64 `json:"variation_pourcentage"`
-// }
-
-// type MotifStatistique struct {
-// 	Motif       string  `json:"motif"`
-// 	Nombre      int64   `json:"nombre"`
-// 	Pourcentage float64 `json:"pourcentage"`
-// 	Urgence     string  `json:"urgence_moyenne"`
-// }
-
-// type IndicateursRisque struct {
-// 	RisqueSecuritaire  float64 `json:"risque_securitaire"`
-// 	RisqueHumanitaire  float64 `json:"risque_humanitaire"`
-// 	RisqueSante        float64 `json:"risque_sante"`
-// 	ScoreVulnerabilite float64 `json:"score_vulnerabilite"`
-// 	AlertesActives     int64   `json:"alertes_actives"`
-// }
-
-// // Structure pour l'analyse prédictive (sans géolocalisation)
-// type PredictiveAnalysis struct {
-// 	PredictionFluxMigratoire FluxMigratoire       `json:"prediction_flux_migratoire"`
-// 	ModelePredictif          ModelePredictif      `json:"modele_predictif"`
-// 	AnalyseComportementale   ComportementAnalysis `json:"analyse_comportementale"`
-// 	AlertesPredictives       []AlertePredictive   `json:"alertes_predictives"`
-// 	ScenariosPrevision       []ScenarioPrevision  `json:"scenarios_prevision"`
-// 	AnalyseTemporelie        AnalyseTemporelle    `json:"analyse_temporelle"`
-// 	ModelesStatistiques      ModelesStatistiques  `json:"modeles_statistiques"`
-// }
-
-// type FluxMigratoire struct {
-// 	ProchaineMigration   []PrevisionMigration   `json:"prochaine_migration"`
-// 	CorridorsMigratoires []CorridorMigration    `json:"corridors_migratoires"`
-// 	SaisonnaliteFlux     []SaisonnaliteAnalysis `json:"saisonnalite_flux"`
-// }
-
-// type PrevisionMigration struct {
-// 	Periode      string   `json:"periode"`
-// 	NombrePrevus int64    `json:"nombre_prevus"`
-// 	Confiance    float64  `json:"niveau_confiance"`
-// 	Facteurs     []string `json:"facteurs_determinants"`
-// }
-
-// type CorridorMigration struct {
-// 	Origine     string  `json:"origine"`
-// 	Destination string  `json:"destination"`
-// 	Volume      int64   `json:"volume"`
-// 	Frequence   float64 `json:"frequence"`
-// 	RisqueLevel string  `json:"niveau_risque"`
-// }
-
-// type SaisonnaliteAnalysis struct {
-// 	Mois            int     `json:"mois"`
-// 	NomMois         string  `json:"nom_mois"`
-// 	IndexSaisonnier float64 `json:"index_saisonnier"`
-// 	VolumeAttendu   int64   `json:"volume_attendu"`
-// }
-
-// type ModelePredictif struct {
-// 	Algorithme    string    `json:"algorithme"`
-// 	Precision     float64   `json:"precision"`
-// 	DerniereMAJ   time.Time `json:"derniere_maj"`
-// 	VariablesClés []string  `json:"variables_cles"`
-// 	ScoreAccuracy float64   `json:"score_accuracy"`
-// }
-
-// type ComportementAnalysis struct {
-// 	PatternsMobilite     []PatternMobilite `json:"patterns_mobilite"`
-// 	SegmentationMigrants []SegmentMigrant  `json:"segmentation_migrants"`
-// 	AnalyseReseau        AnalyseReseau     `json:"analyse_reseau"`
-// }
-
-// type PatternMobilite struct {
-// 	Type        string  `json:"type"`
-// 	Frequence   float64 `json:"frequence"`
-// 	Duree       int     `json:"duree_moyenne"`
-// 	Description string  `json:"description"`
-// }
-
-// type SegmentMigrant struct {
-// 	Segment          string   `json:"segment"`
-// 	Taille           int64    `json:"taille"`
-// 	Caracteristiques []string `json:"caracteristiques"`
-// 	ComportementType string   `json:"comportement_type"`
-// }
-
-// type AnalyseReseau struct {
-// 	CommunautesDetectees int             `json:"communautes_detectees"`
-// 	DensiteReseau        float64         `json:"densite_reseau"`
-// 	NoeudsInfluents      []NoeudInfluent `json:"noeuds_influents"`
-// }
-
-// type NoeudInfluent struct {
-// 	Localisation   string  `json:"localisation"`
-// 	ScoreInfluence float64 `json:"score_influence"`
-// 	TypeInfluence  string  `json:"type_influence"`
-// }
-
-// // Nouvelles structures pour l'analyse temporelle
-// type AnalyseTemporelle struct {
-// 	TendancesLongTerme     []TendanceLongTerme    `json:"tendances_long_terme"`
-// 	CyclesSaisonniers      []CycleSaisonnier      `json:"cycles_saisonniers"`
-// 	AnalyseFrequence       AnalyseFrequence       `json:"analyse_frequence"`
-// 	PredictionsTemporelles []PredictionTemporelle `json:"predictions_temporelles"`
-// }
-
-// type TendanceLongTerme struct {
-// 	Periode     string  `json:"periode"`
-// 	Tendance    string  `json:"tendance"` // croissante, decroissante, stable
-// 	Coefficient float64 `json:"coefficient"`
-// 	Confiance   float64 `json:"confiance"`
-// }
-
-// type CycleSaisonnier struct {
-// 	Saison         string  `json:"saison"`
-// 	Multiplicateur float64 `json:"multiplicateur"`
-// 	Variance       float64 `json:"variance"`
-// 	Predictibilite float64 `json:"predictibilite"`
-// }
-
-// type AnalyseFrequence struct {
-// 	FrequenceArrivees map[string]float64 `json:"frequence_arrivees"`
-// 	FrequenceDeparts  map[string]float64 `json:"frequence_departs"`
-// 	PeaksDetected     []Peak             `json:"peaks_detected"`
-// 	PatternRecurrents []PatternRecurrent `json:"pattern_recurrents"`
-// }
-
-// type Peak struct {
-// 	Date      time.Time `json:"date"`
-// 	Intensite float64   `json:"intensite"`
-// 	Type      string    `json:"type"`
-// 	Duree     int       `json:"duree_jours"`
-// }
-
-// type PatternRecurrent struct {
-// 	Nom       string  `json:"nom"`
-// 	Frequence int     `json:"frequence_jours"`
-// 	Amplitude float64 `json:"amplitude"`
-// 	Confiance float64 `json:"confiance"`
-// }
-
-// type PredictionTemporelle struct {
-// 	DateCible  time.Time           `json:"date_cible"`
-// 	Prediction float64             `json:"prediction"`
-// 	Confiance  float64             `json:"confiance"`
-// 	Intervalle IntervalleConfiance `json:"intervalle"`
-// }
-
-// type IntervalleConfiance struct {
-// 	Min float64 `json:"min"`
-// 	Max float64 `json:"max"`
-// }
-
-// // Nouvelles structures pour les modèles statistiques
-// type ModelesStatistiques struct {
-// 	ModelesRegression     []ModeleRegression     `json:"modeles_regression"`
-// 	ModelesClassification []ModeleClassification `json:"modeles_classification"`
-// 	ModelesTimeSeries     []ModeleTimeSeries     `json:"modeles_time_series"`
-// 	MetriquesPerformance  MetriquesPerformance   `json:"metriques_performance"`
-// }
-
-// type ModeleRegression struct {
-// 	Nom         string    `json:"nom"`
-// 	Variables   []string  `json:"variables"`
-// 	RSquared    float64   `json:"r_squared"`
-// 	MAE         float64   `json:"mae"`
-// 	RMSE        float64   `json:"rmse"`
-// 	LastTrained time.Time `json:"last_trained"`
-// }
-
-// type ModeleClassification struct {
-// 	Nom         string    `json:"nom"`
-// 	Classes     []string  `json:"classes"`
-// 	Accuracy    float64   `json:"accuracy"`
-// 	Precision   float64   `json:"precision"`
-// 	Recall      float64   `json:"recall"`
-// 	F1Score     float64   `json:"f1_score"`
-// 	LastTrained time.Time `json:"last_trained"`
-// }
-
-// type ModeleTimeSeries struct {
-// 	Nom         string    `json:"nom"`
-// 	Type        string    `json:"type"` // ARIMA, LSTM, Prophet, etc.
-// 	Horizon     int       `json:"horizon_days"`
-// 	Accuracy    float64   `json:"accuracy"`
-// 	LastTrained time.Time `json:"last_trained"`
-// }
-
-// type MetriquesPerformance struct {
-// 	AccuracyGlobale     float64            `json:"accuracy_globale"`
-// 	TempsEntrainement   float64            `json:"temps_entrainement_minutes"`
-// 	TempsPrediction     float64            `json:"temps_prediction_ms"`
-// 	ConsommationMemoire float64            `json:"consommation_memoire_mb"`
-// 	MetriquesProfondeur map[string]float64 `json:"metriques_profondeur"`
-// }
-
-// type AlertePredictive struct {
-// 	ID          string    `json:"id"`
-// 	Type        string    `json:"type"`
-// 	Priorite    string    `json:"priorite"`
-// 	Message     string    `json:"message"`
-// 	Probabilite float64   `json:"probabilite"`
-// 	DatePrevue  time.Time `json:"date_prevue"`
-// 	ZoneImpact  string    `json:"zone_impact"`
-// }
-
-// type ScenarioPrevision struct {
-// 	Nom         string   `json:"nom"`
-// 	Probabilite float64  `json:"probabilite"`
-// 	Impact      string   `json:"impact"`
-// 	Description string   `json:"description"`
-// 	Mesures     []string `json:"mesures_recommandees"`
-// 	Horizon     string   `json:"horizon_temporel"`
-// }
-
-// // GetAdvancedMigrationStats - Statistiques globales avancées
-// func GetAdvancedMigrationStats(c *fiber.Ctx) error {
-// 	var stats GlobalMigrationStats
-
-// 	// Total des migrants
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ?", true).Count(&stats.TotalMigrants)
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ? AND created_at >= ?", true, time.Now().AddDate(0, 0, -30)).Count(&stats.NouveauxMigrants)
-
-// 	// Calcul du taux de croissance
-// 	var migrantsMonth1, migrantsMonth2 int64
-// 	database.DB.Model(&models.Migrant{}).Where("created_at >= ? AND created_at < ?",
-// 		time.Now().AddDate(0, -1, 0), time.Now()).Count(&migrantsMonth1)
-// 	database.DB.Model(&models.Migrant{}).Where("created_at >= ? AND created_at < ?",
-// 		time.Now().AddDate(0, -2, 0), time.Now().AddDate(0, -1, 0)).Count(&migrantsMonth2)
-
-// 	if migrantsMonth2 > 0 {
-// 		stats.TauxCroissance = float64(migrantsMonth1-migrantsMonth2) / float64(migrantsMonth2) * 100
-// 	}
-
-// 	// Distribution par genre
-// 	stats.DistributionGenre = make(map[string]int64)
-// 	var genreResults []struct {
-// 		Sexe  string
-// 		Count int64
-// 	}
-// 	database.DB.Model(&models.Migrant{}).Select("sexe, count(*) as count").
-// 		Where("actif = ?", true).Group("sexe").Scan(&genreResults)
-// 	for _, result := range genreResults {
-// 		stats.DistributionGenre[result.Sexe] = result.Count
-// 	}
-
-// 	// Distribution par âge
-// 	stats.DistributionAge = make(map[string]int64)
-// 	var ageResults []struct {
-// 		TrancheAge string
-// 		Count      int64
-// 	}
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			CASE 
-// 				WHEN EXTRACT(YEAR FROM AGE(date_naissance)) < 18 THEN 'Mineur'
-// 				WHEN EXTRACT(YEAR FROM AGE(date_naissance)) BETWEEN 18 AND 25 THEN '18-25'
-// 				WHEN EXTRACT(YEAR FROM AGE(date_naissance)) BETWEEN 26 AND 35 THEN '26-35'
-// 				WHEN EXTRACT(YEAR FROM AGE(date_naissance)) BETWEEN 36 AND 50 THEN '36-50'
-// 				WHEN EXTRACT(YEAR FROM AGE(date_naissance)) BETWEEN 51 AND 65 THEN '51-65'
-// 				ELSE '65+'
-// 			END as tranche_age,
-// 			COUNT(*) as count
-// 		FROM migrants 
-// 		WHERE actif = true 
-// 		GROUP BY tranche_age
-// 	`).Scan(&ageResults)
-// 	for _, result := range ageResults {
-// 		stats.DistributionAge[result.TrancheAge] = result.Count
-// 	}
-
-// 	// Statut migratoire
-// 	stats.StatutMigratoire = make(map[string]int64)
-// 	var statutResults []struct {
-// 		StatutMigratoire string
-// 		Count            int64
-// 	}
-// 	database.DB.Model(&models.Migrant{}).Select("statut_migratoire, count(*) as count").
-// 		Where("actif = ?", true).Group("statut_migratoire").Scan(&statutResults)
-// 	for _, result := range statutResults {
-// 		stats.StatutMigratoire[result.StatutMigratoire] = result.Count
-// 	}
-
-// 	// Top 10 pays d'origine
-// 	var paysResults []struct {
-// 		PaysOrigine string
-// 		Count       int64
-// 	}
-// 	database.DB.Model(&models.Migrant{}).Select("pays_origine, count(*) as count").
-// 		Where("actif = ?", true).Group("pays_origine").Order("count DESC").Limit(10).Scan(&paysResults)
-
-// 	for _, result := range paysResults {
-// 		pourcentage := float64(result.Count) / float64(stats.TotalMigrants) * 100
-// 		stats.PaysOrigineTop = append(stats.PaysOrigineTop, PaysStatistique{
-// 			Pays:        result.PaysOrigine,
-// 			Nombre:      result.Count,
-// 			Pourcentage: math.Round(pourcentage*100) / 100,
-// 		})
-// 	}
-
-// 	// Tendance mensuelle sur 12 mois
-// 	var tendanceResults []struct {
-// 		Mois  int
-// 		Annee int
-// 		Count int64
-// 	}
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			EXTRACT(MONTH FROM created_at) as mois,
-// 			EXTRACT(YEAR FROM created_at) as annee,
-// 			COUNT(*) as count
-// 		FROM migrants 
-// 		WHERE created_at >= ? AND actif = true
-// 		GROUP BY EXTRACT(YEAR FROM created_at), EXTRACT(MONTH FROM created_at)
-// 		ORDER BY annee, mois
-// 	`, time.Now().AddDate(-1, 0, 0)).Scan(&tendanceResults)
-
-// 	var previousCount int64 = 0
-// 	for i, result := range tendanceResults {
-// 		variation := float64(0)
-// 		if i > 0 && previousCount > 0 {
-// 			variation = float64(result.Count-previousCount) / float64(previousCount) * 100
-// 		}
-
-// 		moisNoms := []string{"", "Jan", "Fév", "Mar", "Avr", "Mai", "Jun",
-// 			"Jul", "Aoû", "Sep", "Oct", "Nov", "Déc"}
-
-// 		stats.TendanceMensuelle = append(stats.TendanceMensuelle, TendanceMensuelle{
-// 			Mois:      moisNoms[result.Mois],
-// 			Annee:     result.Annee,
-// 			Nombre:    result.Count,
-// 			Variation: math.Round(variation*100) / 100,
-// 		})
-// 		previousCount = result.Count
-// 	}
-
-// 	// Motifs principaux
-// 	var motifResults []struct {
-// 		TypeMotif  string
-// 		Count      int64
-// 		UrgenceMoy string
-// 	}
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			md.type_motif,
-// 			COUNT(*) as count,
-// 			MODE() WITHIN GROUP (ORDER BY md.urgence) as urgence_moy
-// 		FROM motif_deplacements md
-// 		JOIN migrants m ON md.migrant_uuid = m.uuid
-// 		WHERE m.actif = true
-// 		GROUP BY md.type_motif
-// 		ORDER BY count DESC
-// 	`).Scan(&motifResults)
-
-// 	for _, result := range motifResults {
-// 		pourcentage := float64(result.Count) / float64(stats.TotalMigrants) * 100
-// 		stats.MotifsPrincipaux = append(stats.MotifsPrincipaux, MotifStatistique{
-// 			Motif:       result.TypeMotif,
-// 			Nombre:      result.Count,
-// 			Pourcentage: math.Round(pourcentage*100) / 100,
-// 			Urgence:     result.UrgenceMoy,
-// 		})
-// 	}
-
-// 	// Zones géographiques avec clustering (données statistiques uniquement)
-// 	var regionResults []struct {
-// 		Region        string
-// 		Count         int64
-// 		TypePrincipal string
-// 	}
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			g.ville as region,
-// 			COUNT(*) as count,
-// 			MODE() WITHIN GROUP (ORDER BY g.type_localisation) as type_principal
-// 		FROM geolocalisations g
-// 		JOIN migrants m ON g.migrant_uuid = m.uuid
-// 		WHERE m.actif = true
-// 		GROUP BY g.ville
-// 		HAVING COUNT(*) > 5
-// 		ORDER BY count DESC
-// 		LIMIT 20
-// 	`).Scan(&regionResults)
-
-// 	// Calcul des indicateurs de risque
-// 	var alertesActives int64
-// 	database.DB.Model(&models.Alert{}).Where("statut = ?", "active").Count(&alertesActives)
-
-// 	// Calculs des scores de risque basés sur plusieurs facteurs
-// 	stats.IndicateursRisque = IndicateursRisque{
-// 		RisqueSecuritaire:  calculateSecurityRisk(),
-// 		RisqueHumanitaire:  calculateHumanitarianRisk(),
-// 		RisqueSante:        calculateHealthRisk(),
-// 		ScoreVulnerabilite: calculateVulnerabilityScore(),
-// 		AlertesActives:     alertesActives,
-// 	}
-
-// 	stats.MigrantsActifs = stats.TotalMigrants
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"success": true,
-// 		"data":    stats,
-// 		"message": "Statistiques globales récupérées avec succès",
-// 	})
-// }
-
-// // GetAdvancedPredictiveAnalysis - Analyse prédictive avancée
-// func GetAdvancedPredictiveAnalysis(c *fiber.Ctx) error {
-// 	var analysis PredictiveAnalysis
-
-// 	// Prédiction des flux migratoires
-// 	analysis.PredictionFluxMigratoire = generateFluxMigratoire()
-
-// 	// Modèle prédictif
-// 	analysis.ModelePredictif = ModelePredictif{
-// 		Algorithme:    "Random Forest + LSTM",
-// 		Precision:     87.5,
-// 		DerniereMAJ:   time.Now(),
-// 		VariablesClés: []string{"saison", "pays_origine", "motif_principal", "situation_economique", "stabilite_politique"},
-// 		ScoreAccuracy: 0.875,
-// 	}
-
-// 	// Analyse comportementale
-// 	analysis.AnalyseComportementale = generateComportementAnalysis()
-
-// 	// Alertes prédictives
-// 	analysis.AlertesPredictives = generateAlertesPredictives()
-
-// 	// Scénarios de prévision
-// 	analysis.ScenariosPrevision = generateScenariosPrevision()
-
-// 	// Analyse temporelle
-// 	analysis.AnalyseTemporelie = generateAnalyseTemporelle()
-
-// 	// Modèles statistiques
-// 	analysis.ModelesStatistiques = generateModelesStatistiques()
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"success": true,
-// 		"data":    analysis,
-// 		"message": "Analyse prédictive générée avec succès",
-// 	})
-// }
-
-// // Fonctions utilitaires pour les calculs de risque
-// func calculateSecurityRisk() float64 {
-// 	// Simulation d'un calcul de risque sécuritaire basé sur plusieurs facteurs
-// 	var irregularCount int64
-// 	database.DB.Model(&models.Migrant{}).Where("statut_migratoire = ? AND actif = ?", "irregulier", true).Count(&irregularCount)
-
-// 	var totalCount int64
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ?", true).Count(&totalCount)
-
-// 	if totalCount == 0 {
-// 		return 0
-// 	}
-
-// 	riskScore := float64(irregularCount) / float64(totalCount) * 100
-// 	return math.Min(riskScore, 100)
-// }
-
-// func calculateHumanitarianRisk() float64 {
-// 	// Calcul basé sur les motifs de déplacement critiques
-// 	var criticalCount int64
-// 	database.DB.Raw(`
-// 		SELECT COUNT(DISTINCT m.uuid)
-// 		FROM migrants m
-// 		JOIN motif_deplacements md ON m.uuid = md.migrant_uuid
-// 		WHERE (md.persecution = true OR md.conflit_arme = true OR md.violence_generalisee = true)
-// 		AND m.actif = true
-// 	`).Scan(&criticalCount)
-
-// 	var totalCount int64
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ?", true).Count(&totalCount)
-
-// 	if totalCount == 0 {
-// 		return 0
-// 	}
-
-// 	return math.Min(float64(criticalCount)/float64(totalCount)*100, 100)
-// }
-
-// func calculateHealthRisk() float64 {
-// 	// Simulation basée sur la densité de population et les conditions
-// 	var denseAreaCount int64
-// 	database.DB.Raw(`
-// 		SELECT COUNT(*)
-// 		FROM (
-// 			SELECT COUNT(*) as density
-// 			FROM geolocalisations g
-// 			JOIN migrants m ON g.migrant_uuid = m.uuid
-// 			WHERE m.actif = true
-// 			GROUP BY ROUND(g.latitude::numeric, 2), ROUND(g.longitude::numeric, 2)
-// 			HAVING COUNT(*) > 10
-// 		) dense_areas
-// 	`).Scan(&denseAreaCount)
-
-// 	// Score basé sur la densité et d'autres facteurs
-// 	return math.Min(float64(denseAreaCount)*2.5, 100)
-// }
-
-// func calculateVulnerabilityScore() float64 {
-// 	// Score composite basé sur plusieurs indicateurs
-// 	securityRisk := calculateSecurityRisk()
-// 	humanitarianRisk := calculateHumanitarianRisk()
-// 	healthRisk := calculateHealthRisk()
-
-// 	// Moyenne pondérée
-// 	return (securityRisk*0.4 + humanitarianRisk*0.4 + healthRisk*0.2)
-// }
-
-// func generateFluxMigratoire() FluxMigratoire {
-// 	var flux FluxMigratoire
-
-// 	// Prédictions pour les 6 prochains mois
-// 	for i := 1; i <= 6; i++ {
-// 		// Simulation de prédiction basée sur les tendances historiques
-// 		baseVolume := int64(100 + i*10)     // Simulation
-// 		confiance := 0.85 - float64(i)*0.05 // Confiance décroissante avec le temps
-
-// 		flux.ProchaineMigration = append(flux.ProchaineMigration, PrevisionMigration{
-// 			Periode:      time.Now().AddDate(0, i, 0).Format("2006-01"),
-// 			NombrePrevus: baseVolume,
-// 			Confiance:    math.Max(confiance, 0.5),
-// 			Facteurs:     []string{"tendance_historique", "saison", "situation_economique"},
-// 		})
-// 	}
-
-// 	// Corridors migratoires principaux
-// 	corridors := []struct {
-// 		Origine, Destination string
-// 		Volume               int64
-// 		Frequence            float64
-// 		Risque               string
-// 	}{
-// 		{"Mali", "Burkina Faso", 150, 0.8, "moyen"},
-// 		{"Niger", "Nigeria", 120, 0.7, "élevé"},
-// 		{"Côte d'Ivoire", "Ghana", 100, 0.6, "faible"},
-// 		{"Cameroun", "Tchad", 80, 0.5, "moyen"},
-// 	}
-
-// 	for _, corridor := range corridors {
-// 		flux.CorridorsMigratoires = append(flux.CorridorsMigratoires, CorridorMigration{
-// 			Origine:     corridor.Origine,
-// 			Destination: corridor.Destination,
-// 			Volume:      corridor.Volume,
-// 			Frequence:   corridor.Frequence,
-// 			RisqueLevel: corridor.Risque,
-// 		})
-// 	}
-
-// 	// Analyse de saisonnalité
-// 	moisNoms := []string{"Jan", "Fév", "Mar", "Avr", "Mai", "Jun", "Jul", "Aoû", "Sep", "Oct", "Nov", "Déc"}
-// 	indexSaisonniers := []float64{0.8, 0.7, 0.9, 1.1, 1.3, 1.5, 1.4, 1.2, 1.0, 0.9, 0.8, 0.6}
-
-// 	for i, nom := range moisNoms {
-// 		flux.SaisonnaliteFlux = append(flux.SaisonnaliteFlux, SaisonnaliteAnalysis{
-// 			Mois:            i + 1,
-// 			NomMois:         nom,
-// 			IndexSaisonnier: indexSaisonniers[i],
-// 			VolumeAttendu:   int64(100 * indexSaisonniers[i]),
-// 		})
-// 	}
-
-// 	return flux
-// }
-
-// func generateComportementAnalysis() ComportementAnalysis {
-// 	var comportement ComportementAnalysis
-
-// 	// Patterns de mobilité
-// 	patterns := []struct {
-// 		Type, Description string
-// 		Frequence         float64
-// 		Duree             int
-// 	}{
-// 		{"Migration circulaire", "Déplacements périodiques entre deux zones", 0.35, 90},
-// 		{"Transit temporaire", "Séjour court en zone de passage", 0.25, 15},
-// 		{"Installation permanente", "Établissement durable dans nouvelle zone", 0.20, 365},
-// 		{"Migration forcée", "Déplacement d'urgence pour raisons sécuritaires", 0.15, 60},
-// 		{"Migration économique", "Recherche d'opportunités économiques", 0.05, 180},
-// 	}
-
-// 	for _, pattern := range patterns {
-// 		comportement.PatternsMobilite = append(comportement.PatternsMobilite, PatternMobilite{
-// 			Type:        pattern.Type,
-// 			Frequence:   pattern.Frequence,
-// 			Duree:       pattern.Duree,
-// 			Description: pattern.Description,
-// 		})
-// 	}
-
-// 	// Segmentation des migrants
-// 	segments := []struct {
-// 		Segment, ComportementType string
-// 		Taille                    int64
-// 		Caracteristiques          []string
-// 	}{
-// 		{"Jeunes économiques", "Opportuniste", 450, []string{"18-30 ans", "recherche emploi", "mobile"}},
-// 		{"Familles réfugiées", "Sécuritaire", 280, []string{"avec enfants", "fuite conflit", "stable"}},
-// 		{"Travailleurs saisonniers", "Cyclique", 320, []string{"agriculture", "temporaire", "récurrent"}},
-// 		{"Étudiants", "Temporaire", 150, []string{"formation", "jeune", "retour prévu"}},
-// 	}
-
-// 	for _, segment := range segments {
-// 		comportement.SegmentationMigrants = append(comportement.SegmentationMigrants, SegmentMigrant{
-// 			Segment:          segment.Segment,
-// 			Taille:           segment.Taille,
-// 			Caracteristiques: segment.Caracteristiques,
-// 			ComportementType: segment.ComportementType,
-// 		})
-// 	}
-
-// 	// Analyse de réseau
-// 	comportement.AnalyseReseau = AnalyseReseau{
-// 		CommunautesDetectees: 12,
-// 		DensiteReseau:        0.67,
-// 		NoeudsInfluents: []NoeudInfluent{
-// 			{"Ouagadougou", 0.85, "Hub économique"},
-// 			{"Abidjan", 0.78, "Port d'entrée"},
-// 			{"Bamako", 0.72, "Carrefour régional"},
-// 			{"Niamey", 0.65, "Zone de transit"},
-// 		},
-// 	}
-
-// 	return comportement
-// }
-
-// // Nouvelle fonction pour l'analyse temporelle
-// func generateAnalyseTemporelle() AnalyseTemporelle {
-// 	var analyse AnalyseTemporelle
-
-// 	// Tendances long terme
-// 	analyse.TendancesLongTerme = []TendanceLongTerme{
-// 		{"2023-2024", "croissante", 1.25, 0.85},
-// 		{"2024-2025", "stable", 1.02, 0.78},
-// 		{"2025-2026", "croissante", 1.15, 0.72},
-// 	}
-
-// 	// Cycles saisonniers
-// 	analyse.CyclesSaisonniers = []CycleSaisonnier{
-// 		{"Printemps", 1.2, 0.15, 0.85},
-// 		{"Été", 1.5, 0.22, 0.90},
-// 		{"Automne", 0.8, 0.12, 0.82},
-// 		{"Hiver", 0.6, 0.18, 0.75},
-// 	}
-
-// 	// Analyse de fréquence
-// 	analyse.AnalyseFrequence = AnalyseFrequence{
-// 		FrequenceArrivees: map[string]float64{
-// 			"lundi":    0.12,
-// 			"mardi":    0.14,
-// 			"mercredi": 0.16,
-// 			"jeudi":    0.15,
-// 			"vendredi": 0.18,
-// 			"samedi":   0.13,
-// 			"dimanche": 0.12,
-// 		},
-// 		FrequenceDeparts: map[string]float64{
-// 			"lundi":    0.15,
-// 			"mardi":    0.13,
-// 			"mercredi": 0.14,
-// 			"jeudi":    0.16,
-// 			"vendredi": 0.20,
-// 			"samedi":   0.12,
-// 			"dimanche": 0.10,
-// 		},
-// 		PeaksDetected: []Peak{
-// 			{time.Now().AddDate(0, 0, -15), 2.5, "arrivee_massive", 3},
-// 			{time.Now().AddDate(0, 0, -8), 1.8, "pic_saisonnier", 2},
-// 		},
-// 		PatternRecurrents: []PatternRecurrent{
-// 			{"Cycle hebdomadaire", 7, 0.3, 0.88},
-// 			{"Cycle mensuel", 30, 0.5, 0.75},
-// 		},
-// 	}
-
-// 	// Prédictions temporelles
-// 	for i := 1; i <= 30; i++ {
-// 		// Calcul basé sur la tendance historique des derniers 30 jours
-// 		var baseCount int64
-// 		database.DB.Model(&models.Migrant{}).Where("created_at >= ?", time.Now().AddDate(0, 0, -30)).Count(&baseCount)
-
-// 		prediction := float64(baseCount) + float64(i)*2.5
-// 		confiance := 0.9 - float64(i)*0.01
-
-// 		analyse.PredictionsTemporelles = append(analyse.PredictionsTemporelles, PredictionTemporelle{
-// 			DateCible:  time.Now().AddDate(0, 0, i),
-// 			Prediction: prediction,
-// 			Confiance:  math.Max(confiance, 0.5),
-// 			Intervalle: IntervalleConfiance{
-// 				Min: prediction * 0.85,
-// 				Max: prediction * 1.15,
-// 			},
-// 		})
-// 	}
-
-// 	return analyse
-// }
-
-// // Nouvelle fonction pour les modèles statistiques
-// func generateModelesStatistiques() ModelesStatistiques {
-// 	var modeles ModelesStatistiques
-
-// 	// Modèles de régression
-// 	modeles.ModelesRegression = []ModeleRegression{
-// 		{
-// 			Nom:         "Prédiction Volume Migration",
-// 			Variables:   []string{"saison", "situation_economique", "stabilite_politique", "climat"},
-// 			RSquared:    0.875,
-// 			MAE:         12.5,
-// 			RMSE:        18.3,
-// 			LastTrained: time.Now().AddDate(0, 0, -7),
-// 		},
-// 		{
-// 			Nom:         "Durée Séjour Prédiction",
-// 			Variables:   []string{"age", "motif", "pays_origine", "situation_familiale"},
-// 			RSquared:    0.782,
-// 			MAE:         8.7,
-// 			RMSE:        14.2,
-// 			LastTrained: time.Now().AddDate(0, 0, -5),
-// 		},
-// 	}
-
-// 	// Modèles de classification
-// 	modeles.ModelesClassification = []ModeleClassification{
-// 		{
-// 			Nom:         "Classification Statut Migratoire",
-// 			Classes:     []string{"regulier", "irregulier", "demandeur_asile", "refugie"},
-// 			Accuracy:    0.892,
-// 			Precision:   0.885,
-// 			Recall:      0.878,
-// 			F1Score:     0.881,
-// 			LastTrained: time.Now().AddDate(0, 0, -3),
-// 		},
-// 		{
-// 			Nom:         "Classification Risque",
-// 			Classes:     []string{"faible", "moyen", "eleve", "critique"},
-// 			Accuracy:    0.834,
-// 			Precision:   0.828,
-// 			Recall:      0.841,
-// 			F1Score:     0.834,
-// 			LastTrained: time.Now().AddDate(0, 0, -2),
-// 		},
-// 	}
-
-// 	// Modèles de séries temporelles
-// 	modeles.ModelesTimeSeries = []ModeleTimeSeries{
-// 		{
-// 			Nom:         "ARIMA Migration Forecast",
-// 			Type:        "ARIMA",
-// 			Horizon:     30,
-// 			Accuracy:    0.865,
-// 			LastTrained: time.Now().AddDate(0, 0, -1),
-// 		},
-// 		{
-// 			Nom:         "LSTM Deep Prediction",
-// 			Type:        "LSTM",
-// 			Horizon:     90,
-// 			Accuracy:    0.891,
-// 			LastTrained: time.Now().AddDate(0, 0, -1),
-// 		},
-// 	}
-
-// 	// Métriques de performance
-// 	modeles.MetriquesPerformance = MetriquesPerformance{
-// 		AccuracyGlobale:     0.875,
-// 		TempsEntrainement:   45.5,
-// 		TempsPrediction:     12.3,
-// 		ConsommationMemoire: 256.7,
-// 		MetriquesProfondeur: map[string]float64{
-// 			"precision_micro": 0.883,
-// 			"precision_macro": 0.871,
-// 			"recall_weighted": 0.889,
-// 			"auc_score":       0.924,
-// 		},
-// 	}
-
-// 	return modeles
-// }
-
-// func generateAlertesPredictives() []AlertePredictive {
-// 	alertes := []AlertePredictive{
-// 		{
-// 			ID:          "ALERT_001",
-// 			Type:        "flux_massif",
-// 			Priorite:    "HAUTE",
-// 			Message:     "Augmentation prévue de 40% des arrivées dans les 15 prochains jours",
-// 			Probabilite: 0.82,
-// 			DatePrevue:  time.Now().AddDate(0, 0, 15),
-// 			ZoneImpact:  "Frontière Nord",
-// 		},
-// 		{
-// 			ID:          "ALERT_002",
-// 			Type:        "risque_securitaire",
-// 			Priorite:    "CRITIQUE",
-// 			Message:     "Risque d'incidents sécuritaires dans la zone de transit principal",
-// 			Probabilite: 0.75,
-// 			DatePrevue:  time.Now().AddDate(0, 0, 7),
-// 			ZoneImpact:  "Corridor Central",
-// 		},
-// 		{
-// 			ID:          "ALERT_003",
-// 			Type:        "saturation_capacite",
-// 			Priorite:    "MOYENNE",
-// 			Message:     "Capacités d'accueil proches de la saturation",
-// 			Probabilite: 0.68,
-// 			DatePrevue:  time.Now().AddDate(0, 0, 30),
-// 			ZoneImpact:  "Centres urbains",
-// 		},
-// 	}
-
-// 	return alertes
-// }
-
-// func generateScenariosPrevision() []ScenarioPrevision {
-// 	scenarios := []ScenarioPrevision{
-// 		{
-// 			Nom:         "Scénario de base",
-// 			Probabilite: 0.60,
-// 			Impact:      "MODERE",
-// 			Description: "Continuation des tendances actuelles avec variations saisonnières normales",
-// 			Mesures:     []string{"surveillance routine", "capacités standard", "coordination régulière"},
-// 			Horizon:     "3-6 mois",
-// 		},
-// 		{
-// 			Nom:         "Crise humanitaire régionale",
-// 			Probabilite: 0.25,
-// 			Impact:      "ELEVE",
-// 			Description: "Détérioration sécuritaire majeure entraînant des déplacements massifs",
-// 			Mesures:     []string{"activation plan urgence", "renforcement capacités", "coordination internationale"},
-// 			Horizon:     "1-3 mois",
-// 		},
-// 		{
-// 			Nom:         "Amélioration stabilité",
-// 			Probabilite: 0.15,
-// 			Impact:      "FAIBLE",
-// 			Description: "Stabilisation régionale et réduction des flux migratoires",
-// 			Mesures:     []string{"programmes retour", "développement local", "réintégration"},
-// 			Horizon:     "6-12 mois",
-// 		},
-// 	}
-
-// 	return scenarios
-// }
-
-// // GetAdvancedMigrationTrends - Tendances détaillées des migrations
-// func GetAdvancedMigrationTrends(c *fiber.Ctx) error {
-// 	period := c.Query("period", "12") // mois par défaut
-// 	periodInt, _ := strconv.Atoi(period)
-
-// 	var trends []struct {
-// 		Date    string `json:"date"`
-// 		Count   int64  `json:"count"`
-// 		Type    string `json:"type"`
-// 		Country string `json:"country"`
-// 	}
-
-// 	// Tendances par période
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			TO_CHAR(created_at, 'YYYY-MM') as date,
-// 			COUNT(*) as count,
-// 			statut_migratoire as type,
-// 			pays_origine as country
-// 		FROM migrants 
-// 		WHERE created_at >= ? AND actif = true
-// 		GROUP BY TO_CHAR(created_at, 'YYYY-MM'), statut_migratoire, pays_origine
-// 		ORDER BY date DESC, count DESC
-// 	`, time.Now().AddDate(0, -periodInt, 0)).Scan(&trends)
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"success": true,
-// 		"data":    trends,
-// 		"message": "Tendances migratoires récupérées avec succès",
-// 	})
-// }
-
-// // GetAdvancedRiskAnalysis - Analyse de risques avancée (sans géolocalisation)
-// func GetAdvancedRiskAnalysis(c *fiber.Ctx) error {
-// 	riskAnalysis := struct {
-// 		ScoreGlobal     float64            `json:"score_global"`
-// 		NiveauRisque    string             `json:"niveau_risque"`
-// 		FacteursRisque  []string           `json:"facteurs_risque"`
-// 		Recommandations []string           `json:"recommandations"`
-// 		TendanceRisque  string             `json:"tendance_risque"`
-// 		MetriquesRisque map[string]float64 `json:"metriques_risque"`
-// 		EvolutionRisque []EvolutionRisque  `json:"evolution_risque"`
-// 	}{
-// 		ScoreGlobal:  calculateGlobalRiskScore(),
-// 		NiveauRisque: "MOYEN",
-// 		FacteursRisque: []string{
-// 			"Augmentation flux irréguliers",
-// 			"Instabilité régionale",
-// 			"Capacités d'accueil limitées",
-// 			"Tensions communautaires",
-// 		},
-// 		Recommandations: []string{
-// 			"Renforcer la surveillance frontalière",
-// 			"Améliorer les capacités d'accueil",
-// 			"Développer programmes d'intégration",
-// 			"Coordination régionale renforcée",
-// 		},
-// 		TendanceRisque: "CROISSANTE",
-// 		MetriquesRisque: map[string]float64{
-// 			"risque_securitaire":  calculateSecurityRisk(),
-// 			"risque_humanitaire":  calculateHumanitarianRisk(),
-// 			"risque_sante":        calculateHealthRisk(),
-// 			"score_vulnerabilite": calculateVulnerabilityScore(),
-// 			"indice_stabilite":    75.5,
-// 			"capacite_absorption": 60.2,
-// 		},
-// 		EvolutionRisque: generateEvolutionRisque(),
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"success": true,
-// 		"data":    riskAnalysis,
-// 		"message": "Analyse de risques générée avec succès",
-// 	})
-// }
-
-// type EvolutionRisque struct {
-// 	Date  string  `json:"date"`
-// 	Score float64 `json:"score"`
-// 	Type  string  `json:"type"`
-// }
-
-// func generateEvolutionRisque() []EvolutionRisque {
-// 	var evolution []EvolutionRisque
-
-// 	for i := 30; i >= 0; i-- {
-// 		date := time.Now().AddDate(0, 0, -i)
-
-// 		// Calcul du score basé sur les données réelles de la date
-// 		var alertsCount, migrantsCount int64
-// 		database.DB.Model(&models.Alert{}).Where("created_at >= ? AND created_at < ?",
-// 			date, date.AddDate(0, 0, 1)).Count(&alertsCount)
-// 		database.DB.Model(&models.Migrant{}).Where("created_at >= ? AND created_at < ?",
-// 			date, date.AddDate(0, 0, 1)).Count(&migrantsCount)
-
-// 		// Score basé sur le ratio d'alertes par rapport aux migrants
-// 		baseScore := 30.0
-// 		if migrantsCount > 0 {
-// 			alertRatio := float64(alertsCount) / float64(migrantsCount)
-// 			baseScore = alertRatio * 100
-// 		}
-
-// 		score := math.Max(0, math.Min(100, baseScore))
-
-// 		riskType := "normal"
-// 		if score > 80 {
-// 			riskType = "eleve"
-// 		} else if score > 60 {
-// 			riskType = "moyen"
-// 		} else {
-// 			riskType = "faible"
-// 		}
-
-// 		evolution = append(evolution, EvolutionRisque{
-// 			Date:  date.Format("2006-01-02"),
-// 			Score: math.Round(score*100) / 100,
-// 			Type:  riskType,
-// 		})
-// 	}
-
-// 	return evolution
-// }
-
-// // GetPredictiveModelsPerformance - Performance des modèles prédictifs
-// func GetPredictiveModelsPerformance(c *fiber.Ctx) error {
-// 	performance := struct {
-// 		ModelesActifs       int                    `json:"modeles_actifs"`
-// 		AccuracyMoyenne     float64                `json:"accuracy_moyenne"`
-// 		DerniereEvaluation  time.Time              `json:"derniere_evaluation"`
-// 		MetriquesDetaillees map[string]interface{} `json:"metriques_detaillees"`
-// 		ComparaisonModeles  []ComparaisonModele    `json:"comparaison_modeles"`
-// 		RecommandationsIA   []string               `json:"recommandations_ia"`
-// 	}{
-// 		ModelesActifs:      8,
-// 		AccuracyMoyenne:    0.867,
-// 		DerniereEvaluation: time.Now().AddDate(0, 0, -1),
-// 		MetriquesDetaillees: map[string]interface{}{
-// 			"precision_globale":        0.874,
-// 			"recall_global":            0.859,
-// 			"f1_score_global":          0.866,
-// 			"auc_moyenne":              0.923,
-// 			"temps_entrainement_total": "2h 45min",
-// 			"consommation_memoire":     "1.2 GB",
-// 		},
-// 		ComparaisonModeles: []ComparaisonModele{
-// 			{"Random Forest", 0.845, 0.15, "Stable"},
-// 			{"LSTM", 0.891, 2.3, "Excellent"},
-// 			{"SVM", 0.823, 0.8, "Bon"},
-// 			{"Naive Bayes", 0.756, 0.05, "Rapide"},
-// 			{"XGBoost", 0.889, 1.2, "Très bon"},
-// 		},
-// 		RecommandationsIA: []string{
-// 			"Augmenter la taille du dataset d'entraînement",
-// 			"Implémenter un ensemble de modèles",
-// 			"Optimiser les hyperparamètres du modèle LSTM",
-// 			"Ajouter des features temporelles avancées",
-// 			"Mettre en place un monitoring en temps réel",
-// 		},
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"success": true,
-// 		"data":    performance,
-// 		"message": "Performance des modèles récupérée avec succès",
-// 	})
-// }
-
-// type ComparaisonModele struct {
-// 	Nom               string  `json:"nom"`
-// 	Accuracy          float64 `json:"accuracy"`
-// 	TempsEntrainement float64 `json:"temps_entrainement_heures"`
-// 	Evaluation        string  `json:"evaluation"`
-// }
-
-// func calculateGlobalRiskScore() float64 {
-// 	// Calcul composite du score de risque global
-// 	securityRisk := calculateSecurityRisk()
-// 	humanitarianRisk := calculateHumanitarianRisk()
-// 	healthRisk := calculateHealthRisk()
-
-// 	// Pondération personnalisée
-// 	globalScore := (securityRisk*0.35 + humanitarianRisk*0.35 + healthRisk*0.30)
-// 	return math.Round(globalScore*100) / 100
-// }
-
-// // Structure pour l'analyse des flux migratoires
-// type MigrationFlowData struct {
-// 	TotalFlows    int64                   `json:"total_flows"`
-// 	FlowsByRegion []RegionFlowData        `json:"flows_by_region"`
-// 	FlowsByMonth  []MonthlyFlowData       `json:"flows_by_month"`
-// 	Corridors     []MigrationCorridor     `json:"migration_corridors"`
-// 	Predictions   MigrationFlowPrediction `json:"predictions"`
-// }
-
-// type RegionFlowData struct {
-// 	Region     string  `json:"region"`
-// 	Inbound    int64   `json:"inbound"`
-// 	Outbound   int64   `json:"outbound"`
-// 	NetFlow    int64   `json:"net_flow"`
-// 	GrowthRate float64 `json:"growth_rate"`
-// }
-
-// type MonthlyFlowData struct {
-// 	Month    string `json:"month"`
-// 	Year     int    `json:"year"`
-// 	Inflows  int64  `json:"inflows"`
-// 	Outflows int64  `json:"outflows"`
-// 	NetFlow  int64  `json:"net_flow"`
-// }
-
-// type MigrationCorridor struct {
-// 	Origin      string `json:"origin"`
-// 	Destination string `json:"destination"`
-// 	Volume      int64  `json:"volume"`
-// 	Trend       string `json:"trend"`
-// 	RiskLevel   string `json:"risk_level"`
-// }
-
-// type MigrationFlowPrediction struct {
-// 	NextMonth     FlowPrediction `json:"next_month"`
-// 	Next3Months   FlowPrediction `json:"next_3_months"`
-// 	Next6Months   FlowPrediction `json:"next_6_months"`
-// 	AnnualOutlook FlowPrediction `json:"annual_outlook"`
-// }
-
-// type FlowPrediction struct {
-// 	ExpectedVolume int64    `json:"expected_volume"`
-// 	Confidence     float64  `json:"confidence"`
-// 	Variance       float64  `json:"variance"`
-// 	Factors        []string `json:"factors"`
-// }
-
-// // Structure pour l'analyse démographique
-// type DemographicAnalysisData struct {
-// 	Population      PopulationBreakdown    `json:"population_breakdown"`
-// 	AgeDistribution []AgeGroupData         `json:"age_distribution"`
-// 	GenderRatio     GenderRatioData        `json:"gender_ratio"`
-// 	Nationality     []NationalityData      `json:"nationality_distribution"`
-// 	Education       []EducationLevelData   `json:"education_levels"`
-// 	Employment      EmploymentStatusData   `json:"employment_status"`
-// 	Family          FamilyStructureData    `json:"family_structure"`
-// 	Projections     DemographicProjections `json:"projections"`
-// }
-
-// type PopulationBreakdown struct {
-// 	Total        int64   `json:"total"`
-// 	Active       int64   `json:"active"`
-// 	Inactive     int64   `json:"inactive"`
-// 	Children     int64   `json:"children"`
-// 	Adults       int64   `json:"adults"`
-// 	Elderly      int64   `json:"elderly"`
-// 	GrowthRate   float64 `json:"growth_rate"`
-// 	DensityIndex float64 `json:"density_index"`
-// }
-
-// type AgeGroupData struct {
-// 	AgeGroup   string  `json:"age_group"`
-// 	Count      int64   `json:"count"`
-// 	Percentage float64 `json:"percentage"`
-// 	Trend      string  `json:"trend"`
-// }
-
-// type GenderRatioData struct {
-// 	Male          int64   `json:"male"`
-// 	Female        int64   `json:"female"`
-// 	Ratio         float64 `json:"ratio"`
-// 	BalanceStatus string  `json:"balance_status"`
-// }
-
-// type NationalityData struct {
-// 	Country    string  `json:"country"`
-// 	Count      int64   `json:"count"`
-// 	Percentage float64 `json:"percentage"`
-// 	Status     string  `json:"status"`
-// }
-
-// type EducationLevelData struct {
-// 	Level      string  `json:"level"`
-// 	Count      int64   `json:"count"`
-// 	Percentage float64 `json:"percentage"`
-// }
-
-// type EmploymentStatusData struct {
-// 	Employed   int64   `json:"employed"`
-// 	Unemployed int64   `json:"unemployed"`
-// 	Student    int64   `json:"student"`
-// 	Retired    int64   `json:"retired"`
-// 	Other      int64   `json:"other"`
-// 	Rate       float64 `json:"employment_rate"`
-// }
-
-// type FamilyStructureData struct {
-// 	SinglePerson     int64   `json:"single_person"`
-// 	Couples          int64   `json:"couples"`
-// 	FamiliesChildren int64   `json:"families_with_children"`
-// 	SingleParents    int64   `json:"single_parents"`
-// 	AverageSize      float64 `json:"average_family_size"`
-// }
-
-// type DemographicProjections struct {
-// 	OneYear   PopulationProjection `json:"one_year"`
-// 	FiveYears PopulationProjection `json:"five_years"`
-// 	TenYears  PopulationProjection `json:"ten_years"`
-// }
-
-// type PopulationProjection struct {
-// 	Total      int64   `json:"total"`
-// 	GrowthRate float64 `json:"growth_rate"`
-// 	Confidence float64 `json:"confidence"`
-// }
-
-// // Structure pour l'analyse de risque avancée
-// type AdvancedRiskAnalysisData struct {
-// 	OverallRisk       RiskAssessment       `json:"overall_risk"`
-// 	SecurityRisk      RiskAssessment       `json:"security_risk"`
-// 	HealthRisk        RiskAssessment       `json:"health_risk"`
-// 	SocialRisk        RiskAssessment       `json:"social_risk"`
-// 	EconomicRisk      RiskAssessment       `json:"economic_risk"`
-// 	EnvironmentalRisk RiskAssessment       `json:"environmental_risk"`
-// 	HotSpots          []RiskHotSpot        `json:"risk_hotspots"`
-// 	Vulnerabilities   []VulnerabilityArea  `json:"vulnerabilities"`
-// 	Mitigation        []MitigationStrategy `json:"mitigation_strategies"`
-// 	Predictions       RiskPredictions      `json:"predictions"`
-// }
-
-// type RiskAssessment struct {
-// 	Level       string    `json:"level"`
-// 	Score       float64   `json:"score"`
-// 	Trend       string    `json:"trend"`
-// 	Factors     []string  `json:"factors"`
-// 	LastUpdated time.Time `json:"last_updated"`
-// }
-
-// type RiskHotSpot struct {
-// 	Location    string  `json:"location"`
-// 	RiskType    string  `json:"risk_type"`
-// 	Severity    string  `json:"severity"`
-// 	Population  int64   `json:"affected_population"`
-// 	Probability float64 `json:"probability"`
-// 	Impact      string  `json:"impact"`
-// }
-
-// type VulnerabilityArea struct {
-// 	Area       string   `json:"area"`
-// 	Population int64    `json:"vulnerable_population"`
-// 	Risks      []string `json:"risks"`
-// 	Urgency    string   `json:"urgency"`
-// 	Resources  []string `json:"required_resources"`
-// }
-
-// type MitigationStrategy struct {
-// 	Strategy  string   `json:"strategy"`
-// 	Priority  string   `json:"priority"`
-// 	Timeline  string   `json:"timeline"`
-// 	Resources []string `json:"resources"`
-// 	Expected  string   `json:"expected_outcome"`
-// }
-
-// type RiskPredictions struct {
-// 	ShortTerm  RiskForecast `json:"short_term"`
-// 	MediumTerm RiskForecast `json:"medium_term"`
-// 	LongTerm   RiskForecast `json:"long_term"`
-// }
-
-// type RiskForecast struct {
-// 	Timeframe  string   `json:"timeframe"`
-// 	RiskLevel  string   `json:"risk_level"`
-// 	Confidence float64  `json:"confidence"`
-// 	KeyFactors []string `json:"key_factors"`
-// }
-
-// // GetMigrationFlow - Analyse des flux migratoires
-// func GetMigrationFlow(c *fiber.Ctx) error {
-// 	var flowData MigrationFlowData
-
-// 	// Calculer le total des flux
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ?", true).Count(&flowData.TotalFlows)
-
-// 	// Flux par région basés sur les données de géolocalisation
-// 	var regionResults []struct {
-// 		Pays  string
-// 		Count int64
-// 	}
-
-// 	// Compter les flux entrants par pays/région
-// 	database.DB.Table("geolocalisations").
-// 		Select("pays, COUNT(*) as count").
-// 		Where("type_mouvement = ? AND deleted_at IS NULL", "arrivee").
-// 		Group("pays").
-// 		Order("count DESC").
-// 		Find(&regionResults)
-
-// 	flowData.FlowsByRegion = make([]RegionFlowData, 0)
-// 	for _, result := range regionResults {
-// 		if len(flowData.FlowsByRegion) >= 5 { // Limiter à top 5
-// 			break
-// 		}
-
-// 		// Calculer les flux sortants pour ce pays
-// 		var outbound int64
-// 		database.DB.Table("geolocalisations").
-// 			Where("pays = ? AND type_mouvement = ? AND deleted_at IS NULL", result.Pays, "depart").
-// 			Count(&outbound)
-
-// 		// Calculer le taux de croissance (comparaison avec le mois précédent)
-// 		var currentMonth, previousMonth int64
-// 		database.DB.Table("geolocalisations").
-// 			Where("pays = ? AND type_mouvement = ? AND created_at >= ? AND deleted_at IS NULL",
-// 				result.Pays, "arrivee", time.Now().AddDate(0, -1, 0)).
-// 			Count(&currentMonth)
-// 		database.DB.Table("geolocalisations").
-// 			Where("pays = ? AND type_mouvement = ? AND created_at >= ? AND created_at < ? AND deleted_at IS NULL",
-// 				result.Pays, "arrivee", time.Now().AddDate(0, -2, 0), time.Now().AddDate(0, -1, 0)).
-// 			Count(&previousMonth)
-
-// 		var growthRate float64
-// 		if previousMonth > 0 {
-// 			growthRate = float64(currentMonth-previousMonth) / float64(previousMonth) * 100
-// 		}
-
-// 		regionData := RegionFlowData{
-// 			Region:     result.Pays,
-// 			Inbound:    result.Count,
-// 			Outbound:   outbound,
-// 			NetFlow:    result.Count - outbound,
-// 			GrowthRate: math.Round(growthRate*100) / 100,
-// 		}
-// 		flowData.FlowsByRegion = append(flowData.FlowsByRegion, regionData)
-// 	}
-
-// 	// Flux par mois (12 derniers mois) basés sur les géolocalisations
-// 	flowData.FlowsByMonth = make([]MonthlyFlowData, 12)
-// 	for i := 0; i < 12; i++ {
-// 		monthDate := time.Now().AddDate(0, -i, 0)
-// 		startOfMonth := time.Date(monthDate.Year(), monthDate.Month(), 1, 0, 0, 0, 0, monthDate.Location())
-// 		endOfMonth := startOfMonth.AddDate(0, 1, -1)
-
-// 		var inflows, outflows int64
-// 		database.DB.Table("geolocalisations").
-// 			Where("type_mouvement = ? AND created_at >= ? AND created_at <= ? AND deleted_at IS NULL",
-// 				"arrivee", startOfMonth, endOfMonth).
-// 			Count(&inflows)
-// 		database.DB.Table("geolocalisations").
-// 			Where("type_mouvement = ? AND created_at >= ? AND created_at <= ? AND deleted_at IS NULL",
-// 				"depart", startOfMonth, endOfMonth).
-// 			Count(&outflows)
-
-// 		flowData.FlowsByMonth[11-i] = MonthlyFlowData{
-// 			Month:    monthDate.Format("January"),
-// 			Year:     monthDate.Year(),
-// 			Inflows:  inflows,
-// 			Outflows: outflows,
-// 			NetFlow:  inflows - outflows,
-// 		}
-// 	}
-
-// 	// Corridors migratoires principaux basés sur pays d'origine et destination
-// 	var corridorResults []struct {
-// 		PaysOrigine     string
-// 		PaysDestination string
-// 		Count           int64
-// 	}
-
-// 	database.DB.Table("migrants m").
-// 		Select("m.pays_origine, m.pays_actuel as pays_destination, COUNT(*) as count").
-// 		Where("m.actif = ? AND m.deleted_at IS NULL AND m.pays_origine != m.pays_actuel", true).
-// 		Group("m.pays_origine, m.pays_actuel").
-// 		Order("count DESC").
-// 		Limit(5).
-// 		Find(&corridorResults)
-
-// 	flowData.Corridors = make([]MigrationCorridor, 0)
-// 	for _, result := range corridorResults {
-// 		// Calculer la tendance basée sur l'évolution des 3 derniers mois
-// 		var trend string
-// 		var month1, month2, month3 int64
-
-// 		database.DB.Table("migrants").
-// 			Where("pays_origine = ? AND pays_actuel = ? AND created_at >= ? AND actif = ? AND deleted_at IS NULL",
-// 				result.PaysOrigine, result.PaysDestination, time.Now().AddDate(0, -1, 0), true).
-// 			Count(&month1)
-// 		database.DB.Table("migrants").
-// 			Where("pays_origine = ? AND pays_actuel = ? AND created_at >= ? AND created_at < ? AND actif = ? AND deleted_at IS NULL",
-// 				result.PaysOrigine, result.PaysDestination, time.Now().AddDate(0, -2, 0), time.Now().AddDate(0, -1, 0), true).
-// 			Count(&month2)
-// 		database.DB.Table("migrants").
-// 			Where("pays_origine = ? AND pays_actuel = ? AND created_at >= ? AND created_at < ? AND actif = ? AND deleted_at IS NULL",
-// 				result.PaysOrigine, result.PaysDestination, time.Now().AddDate(0, -3, 0), time.Now().AddDate(0, -2, 0), true).
-// 			Count(&month3)
-
-// 		if month1 > month2 && month2 > month3 {
-// 			trend = "Croissant"
-// 		} else if month1 < month2 && month2 < month3 {
-// 			trend = "Décroissant"
-// 		} else {
-// 			trend = "Stable"
-// 		}
-
-// 		// Évaluer le niveau de risque basé sur les alertes
-// 		var alertCount int64
-// 		database.DB.Table("alertes a").
-// 			Joins("JOIN migrants m ON a.migrant_uuid = m.uuid").
-// 			Where("m.pays_origine = ? AND m.pays_actuel = ? AND a.statut = ? AND a.deleted_at IS NULL",
-// 				result.PaysOrigine, result.PaysDestination, "active").
-// 			Count(&alertCount)
-
-// 		var riskLevel string
-// 		alertRatio := float64(alertCount) / float64(result.Count)
-// 		if alertRatio > 0.3 {
-// 			riskLevel = "Critique"
-// 		} else if alertRatio > 0.15 {
-// 			riskLevel = "Élevé"
-// 		} else if alertRatio > 0.05 {
-// 			riskLevel = "Modéré"
-// 		} else {
-// 			riskLevel = "Faible"
-// 		}
-
-// 		corridor := MigrationCorridor{
-// 			Origin:      result.PaysOrigine,
-// 			Destination: result.PaysDestination,
-// 			Volume:      result.Count,
-// 			Trend:       trend,
-// 			RiskLevel:   riskLevel,
-// 		}
-// 		flowData.Corridors = append(flowData.Corridors, corridor)
-// 	}
-
-// 	// Prédictions basées sur les tendances historiques
-// 	var totalMigrantsLastMonth, totalMigrants2MonthsAgo, totalMigrants3MonthsAgo int64
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("created_at >= ? AND actif = ? AND deleted_at IS NULL", time.Now().AddDate(0, -1, 0), true).
-// 		Count(&totalMigrantsLastMonth)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("created_at >= ? AND created_at < ? AND actif = ? AND deleted_at IS NULL",
-// 			time.Now().AddDate(0, -2, 0), time.Now().AddDate(0, -1, 0), true).
-// 		Count(&totalMigrants2MonthsAgo)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("created_at >= ? AND created_at < ? AND actif = ? AND deleted_at IS NULL",
-// 			time.Now().AddDate(0, -3, 0), time.Now().AddDate(0, -2, 0), true).
-// 		Count(&totalMigrants3MonthsAgo)
-
-// 	// Calculer le taux de croissance moyen
-// 	var avgGrowthRate float64
-// 	if totalMigrants2MonthsAgo > 0 && totalMigrants3MonthsAgo > 0 {
-// 		growthRate1 := float64(totalMigrantsLastMonth-totalMigrants2MonthsAgo) / float64(totalMigrants2MonthsAgo)
-// 		growthRate2 := float64(totalMigrants2MonthsAgo-totalMigrants3MonthsAgo) / float64(totalMigrants3MonthsAgo)
-// 		avgGrowthRate = (growthRate1 + growthRate2) / 2
-// 	}
-
-// 	// Déterminer les facteurs influents basés sur les motifs de déplacement
-// 	var topMotifs []struct {
-// 		TypeMotif string
-// 		Count     int64
-// 	}
-// 	database.DB.Table("motif_deplacements").
-// 		Select("type_motif, COUNT(*) as count").
-// 		Where("created_at >= ? AND deleted_at IS NULL", time.Now().AddDate(0, -3, 0)).
-// 		Group("type_motif").
-// 		Order("count DESC").
-// 		Limit(3).
-// 		Find(&topMotifs)
-
-// 	factors := make([]string, 0)
-// 	for _, motif := range topMotifs {
-// 		switch motif.TypeMotif {
-// 		case "economique":
-// 			factors = append(factors, "Opportunités économiques")
-// 		case "politique":
-// 			factors = append(factors, "Situation politique")
-// 		case "persecution":
-// 			factors = append(factors, "Persécutions")
-// 		case "naturelle":
-// 			factors = append(factors, "Catastrophes naturelles")
-// 		case "sanitaire":
-// 			factors = append(factors, "Crises sanitaires")
-// 		default:
-// 			factors = append(factors, "Facteurs "+motif.TypeMotif)
-// 		}
-// 	}
-
-// 	flowData.Predictions = MigrationFlowPrediction{
-// 		NextMonth: FlowPrediction{
-// 			ExpectedVolume: int64(float64(flowData.TotalFlows) * (1 + avgGrowthRate)),
-// 			Confidence:     0.85,
-// 			Variance:       math.Abs(avgGrowthRate * 0.5),
-// 			Factors:        factors,
-// 		},
-// 		Next3Months: FlowPrediction{
-// 			ExpectedVolume: int64(float64(flowData.TotalFlows) * (1 + avgGrowthRate*3)),
-// 			Confidence:     0.75,
-// 			Variance:       math.Abs(avgGrowthRate * 1.2),
-// 			Factors:        factors,
-// 		},
-// 		Next6Months: FlowPrediction{
-// 			ExpectedVolume: int64(float64(flowData.TotalFlows) * (1 + avgGrowthRate*6)),
-// 			Confidence:     0.65,
-// 			Variance:       math.Abs(avgGrowthRate * 2.0),
-// 			Factors:        factors,
-// 		},
-// 		AnnualOutlook: FlowPrediction{
-// 			ExpectedVolume: int64(float64(flowData.TotalFlows) * (1 + avgGrowthRate*12)),
-// 			Confidence:     0.55,
-// 			Variance:       math.Abs(avgGrowthRate * 3.5),
-// 			Factors:        factors,
-// 		},
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"status": "success",
-// 		"data":   flowData,
-// 	})
-// }
-
-// // GetAdvancedRiskAnalysisData - Analyse de risque avancée
-// func GetAdvancedRiskAnalysisData(c *fiber.Ctx) error {
-// 	var riskData AdvancedRiskAnalysisData
-
-// 	// Compter les alertes par type et niveau de gravité
-// 	var totalAlerts, criticalAlerts, securityAlerts, healthAlerts, socialAlerts int64
-// 	database.DB.Model(&models.Alert{}).Where("statut = ? AND deleted_at IS NULL", "active").Count(&totalAlerts)
-// 	database.DB.Model(&models.Alert{}).Where("niveau_gravite = ? AND statut = ? AND deleted_at IS NULL", "critical", "active").Count(&criticalAlerts)
-// 	database.DB.Model(&models.Alert{}).Where("type_alerte = ? AND statut = ? AND deleted_at IS NULL", "securite", "active").Count(&securityAlerts)
-// 	database.DB.Model(&models.Alert{}).Where("type_alerte = ? AND statut = ? AND deleted_at IS NULL", "sante", "active").Count(&healthAlerts)
-// 	database.DB.Model(&models.Alert{}).Where("type_alerte IN (?) AND statut = ? AND deleted_at IS NULL", []string{"humanitaire", "juridique"}, "active").Count(&socialAlerts)
-
-// 	// Calculer les scores de risque basés sur les données réelles
-// 	var totalMigrants int64
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ? AND deleted_at IS NULL", true).Count(&totalMigrants)
-
-// 	var securityScore, healthScore, socialScore, overallScore float64
-// 	if totalMigrants > 0 {
-// 		securityScore = float64(securityAlerts) / float64(totalMigrants) * 100
-// 		healthScore = float64(healthAlerts) / float64(totalMigrants) * 100
-// 		socialScore = float64(socialAlerts) / float64(totalMigrants) * 100
-// 		overallScore = float64(totalAlerts) / float64(totalMigrants) * 100
-// 	}
-
-// 	// Ajuster les scores sur une échelle de 1-10
-// 	securityScore = math.Min(securityScore*2, 10)
-// 	healthScore = math.Min(healthScore*2, 10)
-// 	socialScore = math.Min(socialScore*2, 10)
-// 	overallScore = math.Min(overallScore*1.5, 10)
-
-// 	// Déterminer les tendances basées sur l'évolution des alertes
-// 	var alertsThisMonth, alertsLastMonth int64
-// 	database.DB.Model(&models.Alert{}).
-// 		Where("created_at >= ? AND statut = ? AND deleted_at IS NULL", time.Now().AddDate(0, -1, 0), "active").
-// 		Count(&alertsThisMonth)
-// 	database.DB.Model(&models.Alert{}).
-// 		Where("created_at >= ? AND created_at < ? AND deleted_at IS NULL",
-// 			time.Now().AddDate(0, -2, 0), time.Now().AddDate(0, -1, 0)).
-// 		Count(&alertsLastMonth)
-
-// 	var overallTrend, securityTrend, healthTrend, socialTrend string
-// 	if alertsThisMonth > alertsLastMonth {
-// 		overallTrend = "Croissant"
-// 	} else if alertsThisMonth < alertsLastMonth {
-// 		overallTrend = "Décroissant"
-// 	} else {
-// 		overallTrend = "Stable"
-// 	}
-
-// 	// Appliquer la même logique pour chaque type
-// 	securityTrend = overallTrend // Simplification, peut être calculé séparément
-// 	healthTrend = overallTrend
-// 	socialTrend = overallTrend
-
-// 	// Analyser les facteurs de risque basés sur les motifs de déplacement
-// 	var riskFactors []struct {
-// 		TypeMotif string
-// 		Count     int64
-// 	}
-// 	database.DB.Table("motif_deplacements").
-// 		Select("type_motif, COUNT(*) as count").
-// 		Where("deleted_at IS NULL").
-// 		Group("type_motif").
-// 		Order("count DESC").
-// 		Find(&riskFactors)
-
-// 	overallFactors := make([]string, 0)
-// 	securityFactors := make([]string, 0)
-// 	healthFactors := make([]string, 0)
-// 	socialFactors := make([]string, 0)
-
-// 	for _, factor := range riskFactors {
-// 		switch factor.TypeMotif {
-// 		case "politique":
-// 			overallFactors = append(overallFactors, "Instabilité politique")
-// 			securityFactors = append(securityFactors, "Conflits politiques")
-// 		case "persecution":
-// 			overallFactors = append(overallFactors, "Persécutions")
-// 			securityFactors = append(securityFactors, "Persécutions ciblées")
-// 		case "naturelle":
-// 			overallFactors = append(overallFactors, "Catastrophes naturelles")
-// 			healthFactors = append(healthFactors, "Conditions environnementales")
-// 		case "sanitaire":
-// 			overallFactors = append(overallFactors, "Crises sanitaires")
-// 			healthFactors = append(healthFactors, "Épidémies")
-// 		case "economique":
-// 			socialFactors = append(socialFactors, "Pauvreté")
-// 		case "familial":
-// 			socialFactors = append(socialFactors, "Séparations familiales")
-// 		}
-// 	}
-
-// 	// Évaluation globale des risques
-// 	riskData.OverallRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(overallScore),
-// 		Score:       math.Round(overallScore*100) / 100,
-// 		Trend:       overallTrend,
-// 		Factors:     overallFactors,
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Risque sécuritaire
-// 	riskData.SecurityRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(securityScore),
-// 		Score:       math.Round(securityScore*100) / 100,
-// 		Trend:       securityTrend,
-// 		Factors:     securityFactors,
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Risque sanitaire
-// 	riskData.HealthRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(healthScore),
-// 		Score:       math.Round(healthScore*100) / 100,
-// 		Trend:       healthTrend,
-// 		Factors:     healthFactors,
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Risque social (combinaison de facteurs)
-// 	riskData.SocialRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(socialScore),
-// 		Score:       math.Round(socialScore*100) / 100,
-// 		Trend:       socialTrend,
-// 		Factors:     socialFactors,
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Risque économique basé sur les motifs économiques
-// 	var economicMotifs int64
-// 	database.DB.Model(&models.MotifDeplacement{}).
-// 		Where("type_motif = ? AND deleted_at IS NULL", "economique").
-// 		Count(&economicMotifs)
-// 	economicScore := float64(economicMotifs) / float64(totalMigrants) * 100
-// 	economicScore = math.Min(economicScore*3, 10)
-
-// 	riskData.EconomicRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(economicScore),
-// 		Score:       math.Round(economicScore*100) / 100,
-// 		Trend:       "Stable",
-// 		Factors:     []string{"Migration économique", "Recherche d'emploi", "Conditions de vie"},
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Risque environnemental basé sur les catastrophes naturelles
-// 	var environmentalMotifs int64
-// 	database.DB.Model(&models.MotifDeplacement{}).
-// 		Where("type_motif = ? AND catastrophe_naturelle = ? AND deleted_at IS NULL", "naturelle", true).
-// 		Count(&environmentalMotifs)
-// 	environmentalScore := float64(environmentalMotifs) / float64(totalMigrants) * 100
-// 	environmentalScore = math.Min(environmentalScore*4, 10)
-
-// 	riskData.EnvironmentalRisk = RiskAssessment{
-// 		Level:       determineRiskLevel(environmentalScore),
-// 		Score:       math.Round(environmentalScore*100) / 100,
-// 		Trend:       "Croissant",
-// 		Factors:     []string{"Changement climatique", "Catastrophes naturelles", "Dégradation environnementale"},
-// 		LastUpdated: time.Now(),
-// 	}
-
-// 	// Points chauds de risque basés sur la géolocalisation et les alertes
-// 	var hotSpotData []struct {
-// 		Pays       string
-// 		Ville      string
-// 		AlertCount int64
-// 		Population int64
-// 	}
-
-// 	database.DB.Table("alertes a").
-// 		Select("g.pays, g.ville, COUNT(a.uuid) as alert_count, COUNT(DISTINCT m.uuid) as population").
-// 		Joins("JOIN migrants m ON a.migrant_uuid = m.uuid").
-// 		Joins("JOIN geolocalisations g ON m.uuid = g.migrant_uuid").
-// 		Where("a.statut = ? AND a.deleted_at IS NULL AND g.deleted_at IS NULL", "active").
-// 		Group("g.pays, g.ville").
-// 		Order("alert_count DESC").
-// 		Limit(5).
-// 		Find(&hotSpotData)
-
-// 	riskData.HotSpots = make([]RiskHotSpot, 0)
-// 	for _, spot := range hotSpotData {
-// 		location := spot.Ville + ", " + spot.Pays
-// 		if spot.Ville == "" {
-// 			location = spot.Pays
-// 		}
-
-// 		var probability float64
-// 		if spot.Population > 0 {
-// 			probability = float64(spot.AlertCount) / float64(spot.Population)
-// 		}
-
-// 		severity := "Modérée"
-// 		if probability > 0.5 {
-// 			severity = "Critique"
-// 		} else if probability > 0.3 {
-// 			severity = "Élevée"
-// 		}
-
-// 		hotspot := RiskHotSpot{
-// 			Location:    location,
-// 			RiskType:    "Multiple",
-// 			Severity:    severity,
-// 			Population:  spot.Population,
-// 			Probability: math.Round(probability*100) / 100,
-// 			Impact:      "Détérioration des conditions",
-// 		}
-// 		riskData.HotSpots = append(riskData.HotSpots, hotspot)
-// 	}
-
-// 	// Zones de vulnérabilité basées sur la concentration de migrants et d'alertes
-// 	var vulnerabilityData []struct {
-// 		TypeLocalisation string
-// 		Count            int64
-// 		AlertCount       int64
-// 	}
-
-// 	database.DB.Table("geolocalisations g").
-// 		Select("g.type_localisation, COUNT(DISTINCT g.migrant_uuid) as count, COUNT(DISTINCT a.uuid) as alert_count").
-// 		Joins("LEFT JOIN alertes a ON g.migrant_uuid = a.migrant_uuid AND a.statut = 'active'").
-// 		Where("g.deleted_at IS NULL").
-// 		Group("g.type_localisation").
-// 		Order("count DESC").
-// 		Find(&vulnerabilityData)
-
-// 	riskData.Vulnerabilities = make([]VulnerabilityArea, 0)
-// 	for _, vuln := range vulnerabilityData {
-// 		if vuln.Count == 0 {
-// 			continue
-// 		}
-
-// 		risks := []string{}
-// 		urgency := "Normale"
-// 		resources := []string{}
-
-// 		switch vuln.TypeLocalisation {
-// 		case "centre_accueil":
-// 			risks = []string{"Surpopulation", "Conditions précaires", "Manque de ressources"}
-// 			resources = []string{"Logements", "Soins médicaux", "Aide alimentaire"}
-// 			if vuln.Count > 100 {
-// 				urgency = "Élevée"
-// 			}
-// 		case "frontiere":
-// 			risks = []string{"Insécurité", "Trafic", "Conditions d'attente"}
-// 			resources = []string{"Sécurité", "Points d'eau", "Abris temporaires"}
-// 			urgency = "Élevée"
-// 		case "residence_actuelle":
-// 			risks = []string{"Intégration", "Accès aux services", "Tensions communautaires"}
-// 			resources = []string{"Programmes d'intégration", "Services sociaux", "Formation"}
-// 		}
-
-// 		if float64(vuln.AlertCount)/float64(vuln.Count) > 0.2 {
-// 			urgency = "Critique"
-// 		}
-
-// 		vulnerability := VulnerabilityArea{
-// 			Area:       vuln.TypeLocalisation,
-// 			Population: vuln.Count,
-// 			Risks:      risks,
-// 			Urgency:    urgency,
-// 			Resources:  resources,
-// 		}
-// 		riskData.Vulnerabilities = append(riskData.Vulnerabilities, vulnerability)
-// 	}
-
-// 	// Stratégies d'atténuation basées sur les types d'alertes les plus fréquents
-// 	var alertTypes []struct {
-// 		TypeAlerte string
-// 		Count      int64
-// 	}
-
-// 	database.DB.Model(&models.Alert{}).
-// 		Select("type_alerte, COUNT(*) as count").
-// 		Where("statut = ? AND deleted_at IS NULL", "active").
-// 		Group("type_alerte").
-// 		Order("count DESC").
-// 		Find(&alertTypes)
-
-// 	riskData.Mitigation = make([]MitigationStrategy, 0)
-// 	for _, alertType := range alertTypes {
-// 		var strategy MitigationStrategy
-
-// 		switch alertType.TypeAlerte {
-// 		case "securite":
-// 			strategy = MitigationStrategy{
-// 				Strategy:  "Renforcement de la sécurité",
-// 				Priority:  "Haute",
-// 				Timeline:  "Immédiat",
-// 				Resources: []string{"Forces de sécurité", "Surveillance", "Éclairage"},
-// 				Expected:  "Réduction des incidents sécuritaires",
-// 			}
-// 		case "sante":
-// 			strategy = MitigationStrategy{
-// 				Strategy:  "Amélioration des services de santé",
-// 				Priority:  "Haute",
-// 				Timeline:  "1-3 mois",
-// 				Resources: []string{"Personnel médical", "Médicaments", "Équipements"},
-// 				Expected:  "Amélioration de l'état de santé général",
-// 			}
-// 		case "humanitaire":
-// 			strategy = MitigationStrategy{
-// 				Strategy:  "Renforcement de l'aide humanitaire",
-// 				Priority:  "Moyenne",
-// 				Timeline:  "1-6 mois",
-// 				Resources: []string{"Aide alimentaire", "Logements", "Services sociaux"},
-// 				Expected:  "Amélioration des conditions de vie",
-// 			}
-// 		default:
-// 			continue
-// 		}
-
-// 		riskData.Mitigation = append(riskData.Mitigation, strategy)
-// 	}
-
-// 	// Prédictions de risque basées sur les tendances
-// 	shortTermLevel := determineRiskLevel(overallScore * 1.1)
-// 	mediumTermLevel := determineRiskLevel(overallScore * 1.2)
-// 	longTermLevel := "Variable"
-
-// 	riskData.Predictions = RiskPredictions{
-// 		ShortTerm: RiskForecast{
-// 			Timeframe:  "1-3 mois",
-// 			RiskLevel:  shortTermLevel,
-// 			Confidence: 0.85,
-// 			KeyFactors: overallFactors,
-// 		},
-// 		MediumTerm: RiskForecast{
-// 			Timeframe:  "3-12 mois",
-// 			RiskLevel:  mediumTermLevel,
-// 			Confidence: 0.70,
-// 			KeyFactors: overallFactors,
-// 		},
-// 		LongTerm: RiskForecast{
-// 			Timeframe:  "1-5 ans",
-// 			RiskLevel:  longTermLevel,
-// 			Confidence: 0.55,
-// 			KeyFactors: []string{"Stabilité régionale", "Développement durable", "Coopération internationale"},
-// 		},
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"status": "success",
-// 		"data":   riskData,
-// 	})
-// }
-
-// // Fonction utilitaire pour déterminer le niveau de risque
-// func determineRiskLevel(score float64) string {
-// 	if score >= 8.0 {
-// 		return "Critique"
-// 	} else if score >= 6.0 {
-// 		return "Élevé"
-// 	} else if score >= 4.0 {
-// 		return "Modéré"
-// 	}
-// 	return "Faible"
-// }
-
-// // GetDemographicAnalysis - Analyse démographique détaillée
-// func GetDemographicAnalysis(c *fiber.Ctx) error {
-// 	var demoData DemographicAnalysisData
-
-// 	// Répartition de la population basée sur les données réelles
-// 	var totalMigrants, activeMigrants, children, adults, elderly int64
-// 	database.DB.Model(&models.Migrant{}).Where("deleted_at IS NULL").Count(&totalMigrants)
-// 	database.DB.Model(&models.Migrant{}).Where("actif = ? AND deleted_at IS NULL", true).Count(&activeMigrants)
-
-// 	// Calcul des tranches d'âge basé sur la date de naissance
-// 	currentTime := time.Now()
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("date_naissance > ? AND deleted_at IS NULL", currentTime.AddDate(-18, 0, 0)).
-// 		Count(&children)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("date_naissance <= ? AND date_naissance > ? AND deleted_at IS NULL",
-// 			currentTime.AddDate(-18, 0, 0), currentTime.AddDate(-65, 0, 0)).
-// 		Count(&adults)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("date_naissance <= ? AND deleted_at IS NULL", currentTime.AddDate(-65, 0, 0)).
-// 		Count(&elderly)
-
-// 	// Calculer le taux de croissance réel
-// 	var currentMonthMigrants, previousMonthMigrants int64
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("created_at >= ? AND deleted_at IS NULL", currentTime.AddDate(0, -1, 0)).
-// 		Count(&currentMonthMigrants)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("created_at >= ? AND created_at < ? AND deleted_at IS NULL",
-// 			currentTime.AddDate(0, -2, 0), currentTime.AddDate(0, -1, 0)).
-// 		Count(&previousMonthMigrants)
-
-// 	var growthRate float64
-// 	if previousMonthMigrants > 0 {
-// 		growthRate = float64(currentMonthMigrants-previousMonthMigrants) / float64(previousMonthMigrants) * 100
-// 	}
-
-// 	// Calculer l'indice de densité basé sur la répartition géographique
-// 	var uniqueLocations int64
-// 	database.DB.Table("geolocalisations").
-// 		Select("COUNT(DISTINCT CONCAT(pays, ville))").
-// 		Where("deleted_at IS NULL").
-// 		Count(&uniqueLocations)
-
-// 	var densityIndex float64
-// 	if uniqueLocations > 0 {
-// 		densityIndex = float64(totalMigrants) / float64(uniqueLocations)
-// 	}
-
-// 	demoData.Population = PopulationBreakdown{
-// 		Total:        totalMigrants,
-// 		Active:       activeMigrants,
-// 		Inactive:     totalMigrants - activeMigrants,
-// 		Children:     children,
-// 		Adults:       adults,
-// 		Elderly:      elderly,
-// 		GrowthRate:   math.Round(growthRate*100) / 100,
-// 		DensityIndex: math.Round(densityIndex*100) / 100,
-// 	}
-
-// 	// Distribution par âge détaillée avec vraies données
-// 	ageGroups := []struct {
-// 		Label  string
-// 		MinAge int
-// 		MaxAge int
-// 		Trend  string
-// 	}{
-// 		{"0-17", 0, 17, "Stable"},
-// 		{"18-25", 18, 25, "Croissant"},
-// 		{"26-35", 26, 35, "Stable"},
-// 		{"36-50", 36, 50, "Stable"},
-// 		{"51-65", 51, 65, "Stable"},
-// 		{"65+", 65, 150, "Croissant"},
-// 	}
-
-// 	demoData.AgeDistribution = make([]AgeGroupData, 0)
-// 	for _, group := range ageGroups {
-// 		var count int64
-// 		if group.MaxAge == 150 { // 65+
-// 			database.DB.Model(&models.Migrant{}).
-// 				Where("date_naissance <= ? AND deleted_at IS NULL", currentTime.AddDate(-group.MinAge, 0, 0)).
-// 				Count(&count)
-// 		} else {
-// 			database.DB.Model(&models.Migrant{}).
-// 				Where("date_naissance <= ? AND date_naissance > ? AND deleted_at IS NULL",
-// 					currentTime.AddDate(-group.MinAge, 0, 0), currentTime.AddDate(-group.MaxAge-1, 0, 0)).
-// 				Count(&count)
-// 		}
-
-// 		var percentage float64
-// 		if totalMigrants > 0 {
-// 			percentage = float64(count) / float64(totalMigrants) * 100
-// 		}
-
-// 		ageData := AgeGroupData{
-// 			AgeGroup:   group.Label,
-// 			Count:      count,
-// 			Percentage: math.Round(percentage*100) / 100,
-// 			Trend:      group.Trend,
-// 		}
-// 		demoData.AgeDistribution = append(demoData.AgeDistribution, ageData)
-// 	}
-
-// 	// Ratio de genre basé sur les données réelles
-// 	var maleCount, femaleCount int64
-// 	database.DB.Model(&models.Migrant{}).Where("sexe = ? AND deleted_at IS NULL", "M").Count(&maleCount)
-// 	database.DB.Model(&models.Migrant{}).Where("sexe = ? AND deleted_at IS NULL", "F").Count(&femaleCount)
-
-// 	var ratio float64
-// 	if femaleCount > 0 {
-// 		ratio = float64(maleCount) / float64(femaleCount)
-// 	}
-
-// 	var balanceStatus string
-// 	if ratio > 1.1 {
-// 		balanceStatus = "Dominance masculine"
-// 	} else if ratio < 0.9 {
-// 		balanceStatus = "Dominance féminine"
-// 	} else {
-// 		balanceStatus = "Équilibré"
-// 	}
-
-// 	demoData.GenderRatio = GenderRatioData{
-// 		Male:          maleCount,
-// 		Female:        femaleCount,
-// 		Ratio:         math.Round(ratio*100) / 100,
-// 		BalanceStatus: balanceStatus,
-// 	}
-
-// 	// Distribution par nationalité basée sur les données réelles
-// 	var nationalityResults []struct {
-// 		Nationalite string
-// 		Count       int64
-// 	}
-
-// 	database.DB.Model(&models.Migrant{}).
-// 		Select("nationalite, COUNT(*) as count").
-// 		Where("deleted_at IS NULL AND nationalite IS NOT NULL AND nationalite != ''").
-// 		Group("nationalite").
-// 		Order("count DESC").
-// 		Limit(10).
-// 		Find(&nationalityResults)
-
-// 	demoData.Nationality = make([]NationalityData, 0)
-// 	for _, result := range nationalityResults {
-// 		var percentage float64
-// 		if totalMigrants > 0 {
-// 			percentage = float64(result.Count) / float64(totalMigrants) * 100
-// 		}
-
-// 		// Déterminer le statut basé sur l'évolution récente
-// 		var recentCount int64
-// 		database.DB.Model(&models.Migrant{}).
-// 			Where("nationalite = ? AND created_at >= ? AND deleted_at IS NULL",
-// 				result.Nationalite, currentTime.AddDate(0, -3, 0)).
-// 			Count(&recentCount)
-
-// 		status := "Stable"
-// 		if float64(recentCount)/float64(result.Count) > 0.3 {
-// 			status = "Croissant"
-// 		} else if float64(recentCount)/float64(result.Count) < 0.1 {
-// 			status = "Décroissant"
-// 		}
-
-// 		nationalityData := NationalityData{
-// 			Country:    result.Nationalite,
-// 			Count:      result.Count,
-// 			Percentage: math.Round(percentage*100) / 100,
-// 			Status:     status,
-// 		}
-// 		demoData.Nationality = append(demoData.Nationality, nationalityData)
-// 	}
-
-// 	// Niveaux d'éducation - estimation basée sur des patterns démographiques régionaux
-// 	// Note: Ces données ne sont pas directement dans le modèle Migrant, donc on utilise des estimations
-// 	demoData.Education = []EducationLevelData{
-// 		{Level: "Aucune éducation", Count: int64(float64(totalMigrants) * 0.25), Percentage: 25.0},
-// 		{Level: "Primaire", Count: int64(float64(totalMigrants) * 0.35), Percentage: 35.0},
-// 		{Level: "Secondaire", Count: int64(float64(totalMigrants) * 0.25), Percentage: 25.0},
-// 		{Level: "Supérieur", Count: int64(float64(totalMigrants) * 0.15), Percentage: 15.0},
-// 	}
-
-// 	// Statut d'emploi - estimation basée sur la situation matrimoniale et l'âge
-// 	var marriedCount, singleCount int64
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("situation_matrimoniale = ? AND deleted_at IS NULL", "marie").
-// 		Count(&marriedCount)
-// 	database.DB.Model(&models.Migrant{}).
-// 		Where("situation_matrimoniale = ? AND deleted_at IS NULL", "celibataire").
-// 		Count(&singleCount)
-
-// 	// Estimation de l'emploi basée sur les adultes actifs
-// 	employed := int64(float64(adults) * 0.30) // Estimation conservatrice
-// 	unemployed := adults - employed
-// 	student := int64(float64(children) * 0.60) // Enfants en âge scolaire
-// 	retired := elderly
-// 	other := int64(float64(adults) * 0.10)
-
-// 	var employmentRate float64
-// 	if adults > 0 {
-// 		employmentRate = float64(employed) / float64(adults) * 100
-// 	}
-
-// 	demoData.Employment = EmploymentStatusData{
-// 		Employed:   employed,
-// 		Unemployed: unemployed,
-// 		Student:    student,
-// 		Retired:    retired,
-// 		Other:      other,
-// 		Rate:       math.Round(employmentRate*100) / 100,
-// 	}
-
-// 	// Structure familiale basée sur les données réelles
-// 	var familyData []struct {
-// 		SituationMatrimoniale string
-// 		NombreEnfants         int
-// 		Count                 int64
-// 	}
-
-// 	database.DB.Model(&models.Migrant{}).
-// 		Select("situation_matrimoniale, nombre_enfants, COUNT(*) as count").
-// 		Where("deleted_at IS NULL AND situation_matrimoniale IS NOT NULL").
-// 		Group("situation_matrimoniale, nombre_enfants").
-// 		Find(&familyData)
-
-// 	var singlePersons, couples, familiesWithChildren, singleParents int64
-// 	var totalFamilySize int64
-
-// 	for _, family := range familyData {
-// 		switch family.SituationMatrimoniale {
-// 		case "celibataire":
-// 			if family.NombreEnfants > 0 {
-// 				singleParents += family.Count
-// 				totalFamilySize += family.Count * int64(1+family.NombreEnfants)
-// 			} else {
-// 				singlePersons += family.Count
-// 				totalFamilySize += family.Count
-// 			}
-// 		case "marie":
-// 			if family.NombreEnfants > 0 {
-// 				familiesWithChildren += family.Count
-// 				totalFamilySize += family.Count * int64(2+family.NombreEnfants)
-// 			} else {
-// 				couples += family.Count
-// 				totalFamilySize += family.Count * 2
-// 			}
-// 		}
-// 	}
-
-// 	var averageFamilySize float64
-// 	if totalMigrants > 0 {
-// 		averageFamilySize = float64(totalFamilySize) / float64(totalMigrants)
-// 	}
-
-// 	demoData.Family = FamilyStructureData{
-// 		SinglePerson:     singlePersons,
-// 		Couples:          couples,
-// 		FamiliesChildren: familiesWithChildren,
-// 		SingleParents:    singleParents,
-// 		AverageSize:      math.Round(averageFamilySize*100) / 100,
-// 	}
-
-// 	// Projections démographiques basées sur les tendances réelles
-// 	oneYearProjection := int64(float64(totalMigrants) * (1 + growthRate/100*12))
-// 	fiveYearProjection := int64(float64(totalMigrants) * math.Pow(1+growthRate/100, 60))
-// 	tenYearProjection := int64(float64(totalMigrants) * math.Pow(1+growthRate/100, 120))
-
-// 	demoData.Projections = DemographicProjections{
-// 		OneYear: PopulationProjection{
-// 			Total:      oneYearProjection,
-// 			GrowthRate: growthRate * 12,
-// 			Confidence: 0.85,
-// 		},
-// 		FiveYears: PopulationProjection{
-// 			Total:      fiveYearProjection,
-// 			GrowthRate: (float64(fiveYearProjection)/float64(totalMigrants) - 1) * 100,
-// 			Confidence: 0.65,
-// 		},
-// 		TenYears: PopulationProjection{
-// 			Total:      tenYearProjection,
-// 			GrowthRate: (float64(tenYearProjection)/float64(totalMigrants) - 1) * 100,
-// 			Confidence: 0.45,
-// 		},
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"status": "success",
-// 		"data":   demoData,
-// 	})
-// }
-
-// // Structure pour l'analyse des patterns de mouvement
-// type MovementPatternsData struct {
-// 	OverallPatterns    MovementOverview          `json:"overall_patterns"`
-// 	RouteAnalysis      []RoutePattern            `json:"route_analysis"`
-// 	TemporalPatterns   TemporalMovement          `json:"temporal_patterns"`
-// 	SeasonalTrends     []MovementSeasonalPattern `json:"seasonal_trends"`
-// 	GeographicHotspots []GeographicHotspot       `json:"geographic_hotspots"`
-// 	MobilityMetrics    MobilityMetrics           `json:"mobility_metrics"`
-// 	Predictions        MovementPredictions       `json:"predictions"`
-// }
-
-// type MovementOverview struct {
-// 	TotalMovements      int64   `json:"total_movements"`
-// 	ActiveRoutes        int64   `json:"active_routes"`
-// 	AverageDistance     float64 `json:"average_distance_km"`
-// 	AverageStayDuration float64 `json:"average_stay_duration_days"`
-// 	MobilityIndex       float64 `json:"mobility_index"`
-// 	CircularMigration   int64   `json:"circular_migration_count"`
-// }
-
-// type RoutePattern struct {
-// 	RouteID           string  `json:"route_id"`
-// 	Origin            string  `json:"origin"`
-// 	Destination       string  `json:"destination"`
-// 	Frequency         int64   `json:"frequency"`
-// 	AverageTime       float64 `json:"average_time_days"`
-// 	RiskLevel         string  `json:"risk_level"`
-// 	PopularityTrend   string  `json:"popularity_trend"`
-// 	SeasonalVariation float64 `json:"seasonal_variation"`
-// }
-
-// type TemporalMovement struct {
-// 	PeakMovementMonths []string              `json:"peak_movement_months"`
-// 	LowMovementMonths  []string              `json:"low_movement_months"`
-// 	DailyPatterns      []DailyMovementData   `json:"daily_patterns"`
-// 	WeeklyPatterns     []WeeklyMovementData  `json:"weekly_patterns"`
-// 	MonthlyPatterns    []MonthlyMovementData `json:"monthly_patterns"`
-// }
-
-// type DailyMovementData struct {
-// 	Hour      int   `json:"hour"`
-// 	Movements int64 `json:"movements"`
-// }
-
-// type WeeklyMovementData struct {
-// 	Weekday   string `json:"weekday"`
-// 	Movements int64  `json:"movements"`
-// }
-
-// type MonthlyMovementData struct {
-// 	Month     string `json:"month"`
-// 	Year      int    `json:"year"`
-// 	Movements int64  `json:"movements"`
-// 	Trend     string `json:"trend"`
-// }
-
-// type MovementSeasonalPattern struct {
-// 	Season    string   `json:"season"`
-// 	Movements int64    `json:"movements"`
-// 	Routes    []string `json:"popular_routes"`
-// 	Drivers   []string `json:"main_drivers"`
-// 	Intensity string   `json:"intensity"`
-// }
-
-// type GeographicHotspot struct {
-// 	Location      string   `json:"location"`
-// 	Type          string   `json:"type"` // origin, destination, transit
-// 	Movements     int64    `json:"movements"`
-// 	Concentration float64  `json:"concentration_index"`
-// 	Accessibility string   `json:"accessibility"`
-// 	Services      []string `json:"available_services"`
-// }
-
-// type MobilityMetrics struct {
-// 	AverageMigrationsPerPerson float64 `json:"average_migrations_per_person"`
-// 	ReturnMigrationRate        float64 `json:"return_migration_rate"`
-// 	OnwardMigrationRate        float64 `json:"onward_migration_rate"`
-// 	SettlementRate             float64 `json:"settlement_rate"`
-// 	TransitDuration            float64 `json:"average_transit_duration_days"`
-// }
-
-// type MovementPredictions struct {
-// 	NextMonth        MovementForecast `json:"next_month"`
-// 	NextQuarter      MovementForecast `json:"next_quarter"`
-// 	NextSeason       MovementForecast `json:"next_season"`
-// 	AnnualProjection MovementForecast `json:"annual_projection"`
-// }
-
-// type MovementForecast struct {
-// 	ExpectedMovements  int64    `json:"expected_movements"`
-// 	PopularRoutes      []string `json:"popular_routes"`
-// 	RiskAreas          []string `json:"risk_areas"`
-// 	Confidence         float64  `json:"confidence"`
-// 	InfluencingFactors []string `json:"influencing_factors"`
-// }
-
-// // GetMovementPatterns - Analyse des patterns de mouvement migratoire
-// func GetMovementPatterns(c *fiber.Ctx) error {
-// 	var patternsData MovementPatternsData
-
-// 	// Calculer les statistiques générales de mouvement
-// 	var totalGeolocations int64
-// 	database.DB.Model(&models.Geolocalisation{}).Count(&totalGeolocations)
-
-// 	// Compter les routes uniques (combinaisons origine-destination)
-// 	var activeRoutes int64
-// 	database.DB.Model(&models.Geolocalisation{}).
-// 		Select("DISTINCT CONCAT(COALESCE(pays, ''), '-', COALESCE(ville, ''))").
-// 		Where("pays IS NOT NULL AND ville IS NOT NULL").
-// 		Count(&activeRoutes)
-
-// 	// Calculer la distance moyenne (simulation basée sur les données disponibles)
-// 	avgDistance := calculateAverageDistance()
-
-// 	// Calculer la durée moyenne de séjour
-// 	avgStayDuration := calculateAverageStayDuration()
-
-// 	// Index de mobilité basé sur le nombre de déplacements par migrant
-// 	var totalMigrants int64
-// 	database.DB.Model(&models.Migrant{}).Count(&totalMigrants)
-// 	mobilityIndex := float64(0)
-// 	if totalMigrants > 0 {
-// 		mobilityIndex = float64(totalGeolocations) / float64(totalMigrants)
-// 	}
-
-// 	// Migration circulaire (migrants avec plus d'une géolocalisation)
-// 	var circularMigration int64
-// 	database.DB.Raw(`
-// 		SELECT COUNT(DISTINCT migrant_uuid) 
-// 		FROM geolocalisations 
-// 		GROUP BY migrant_uuid 
-// 		HAVING COUNT(*) > 1
-// 	`).Scan(&circularMigration)
-
-// 	patternsData.OverallPatterns = MovementOverview{
-// 		TotalMovements:      totalGeolocations,
-// 		ActiveRoutes:        activeRoutes,
-// 		AverageDistance:     avgDistance,
-// 		AverageStayDuration: avgStayDuration,
-// 		MobilityIndex:       math.Round(mobilityIndex*100) / 100,
-// 		CircularMigration:   circularMigration,
-// 	}
-
-// 	// Analyse des routes principales
-// 	var routeResults []struct {
-// 		Origin      string
-// 		Destination string
-// 		Count       int64
-// 	}
-
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			COALESCE(pays, 'Inconnu') as origin,
-// 			COALESCE(ville, 'Inconnu') as destination,
-// 			COUNT(*) as count
-// 		FROM geolocalisations 
-// 		WHERE pays IS NOT NULL AND ville IS NOT NULL
-// 		GROUP BY pays, ville
-// 		ORDER BY count DESC
-// 		LIMIT 10
-// 	`).Scan(&routeResults)
-
-// 	patternsData.RouteAnalysis = make([]RoutePattern, len(routeResults))
-// 	for i, route := range routeResults {
-// 		patternsData.RouteAnalysis[i] = RoutePattern{
-// 			RouteID:           fmt.Sprintf("R%03d", i+1),
-// 			Origin:            route.Origin,
-// 			Destination:       route.Destination,
-// 			Frequency:         route.Count,
-// 			AverageTime:       calculateRouteAverageTime(route.Origin, route.Destination),
-// 			RiskLevel:         assessRouteRisk(route.Origin, route.Destination),
-// 			PopularityTrend:   calculateRouteTrend(route.Origin, route.Destination),
-// 			SeasonalVariation: calculateSeasonalVariation(route.Origin, route.Destination),
-// 		}
-// 	}
-
-// 	// Patterns temporels
-// 	patternsData.TemporalPatterns = calculateTemporalPatterns()
-
-// 	// Tendances saisonnières
-// 	patternsData.SeasonalTrends = []MovementSeasonalPattern{
-// 		{
-// 			Season:    "Saison sèche (Nov-Mar)",
-// 			Movements: calculateSeasonMovements("dry"),
-// 			Routes:    []string{"RDC-Angola", "RCA-Cameroun", "Tchad-Niger"},
-// 			Drivers:   []string{"Recherche d'eau", "Pâturage", "Commerce"},
-// 			Intensity: "Élevée",
-// 		},
-// 		{
-// 			Season:    "Saison des pluies (Avr-Oct)",
-// 			Movements: calculateSeasonMovements("wet"),
-// 			Routes:    []string{"Mali-Burkina", "Niger-Nigeria", "Soudan-Tchad"},
-// 			Drivers:   []string{"Agriculture", "Inondations", "Déplacements forcés"},
-// 			Intensity: "Modérée",
-// 		},
-// 	}
-
-// 	// Hotspots géographiques
-// 	patternsData.GeographicHotspots = calculateGeographicHotspots()
-
-// 	// Métriques de mobilité
-// 	patternsData.MobilityMetrics = calculateMobilityMetrics()
-
-// 	// Prédictions de mouvement
-// 	patternsData.Predictions = MovementPredictions{
-// 		NextMonth: MovementForecast{
-// 			ExpectedMovements:  int64(float64(totalGeolocations) * 1.05),
-// 			PopularRoutes:      []string{"RDC-Angola", "RCA-Cameroun", "Soudan-Ouganda"},
-// 			RiskAreas:          []string{"Frontière RDC-Angola", "Province du Kasaï"},
-// 			Confidence:         0.82,
-// 			InfluencingFactors: []string{"Conditions météorologiques", "Stabilité sécuritaire"},
-// 		},
-// 		NextQuarter: MovementForecast{
-// 			ExpectedMovements:  int64(float64(totalGeolocations) * 1.15),
-// 			PopularRoutes:      []string{"Mali-Burkina", "Niger-Nigeria", "Tchad-Cameroun"},
-// 			RiskAreas:          []string{"Sahel central", "Bassin du Lac Tchad"},
-// 			Confidence:         0.75,
-// 			InfluencingFactors: []string{"Saison agricole", "Activités pastorales", "Conflits"},
-// 		},
-// 		NextSeason: MovementForecast{
-// 			ExpectedMovements:  int64(float64(totalGeolocations) * 1.25),
-// 			PopularRoutes:      []string{"Corridor Sahélien", "Route Atlantique"},
-// 			RiskAreas:          []string{"Zone des trois frontières", "Delta du Niger"},
-// 			Confidence:         0.68,
-// 			InfluencingFactors: []string{"Changement climatique", "Élections régionales"},
-// 		},
-// 		AnnualProjection: MovementForecast{
-// 			ExpectedMovements:  int64(float64(totalGeolocations) * 1.4),
-// 			PopularRoutes:      []string{"Routes transahariennes", "Corridors côtiers"},
-// 			RiskAreas:          []string{"Zones de conflit persistant", "Régions climatiquement vulnérables"},
-// 			Confidence:         0.60,
-// 			InfluencingFactors: []string{"Démographie", "Développement économique", "Politiques migratoires"},
-// 		},
-// 	}
-
-// 	return c.Status(http.StatusOK).JSON(fiber.Map{
-// 		"status": "success",
-// 		"data":   patternsData,
-// 	})
-// }
-
-// // Fonctions utilitaires pour les calculs de mouvement
-
-// func calculateAverageDistance() float64 {
-// 	// Calcul basé sur la distribution géographique des migrations
-// 	// Distance moyenne approximative entre les principales routes migratoires en Afrique
-// 	return 650.0
-// }
-
-// func calculateAverageStayDuration() float64 {
-// 	// Calculer la durée moyenne basée sur les créations de géolocalisations
-// 	var avgDays float64
-// 	database.DB.Raw(`
-// 		SELECT AVG(EXTRACT(DAY FROM (NOW() - created_at))) 
-// 		FROM geolocalisations 
-// 		WHERE created_at >= NOW() - INTERVAL '1 year'
-// 	`).Scan(&avgDays)
-
-// 	if avgDays == 0 {
-// 		return 45.0 // Valeur par défaut
-// 	}
-// 	return math.Round(avgDays*100) / 100
-// }
-
-// func calculateRouteAverageTime(origin, destination string) float64 {
-// 	// Calcul basé sur la distance et le mode de transport typique
-// 	distances := map[string]float64{
-// 		"République Démocratique du Congo-Luanda": 15.0,
-// 		"République Centrafricaine-Yaoundé":       12.0,
-// 		"Tchad-Ndjamena":                          8.0,
-// 		"Mali-Ouagadougou":                        10.0,
-// 		"Niger-Abuja":                             14.0,
-// 	}
-
-// 	key := origin + "-" + destination
-// 	if time, exists := distances[key]; exists {
-// 		return time
-// 	}
-// 	return 12.0 // Moyenne par défaut
-// }
-
-// func assessRouteRisk(origin, destination string) string {
-// 	// Évaluation du risque basée sur les données d'alertes via la relation avec les migrants
-// 	var alertCount int64
-// 	database.DB.Model(&models.Alert{}).
-// 		Joins("JOIN migrants ON alertes.migrant_uuid = migrants.uuid").
-// 		Where("(migrants.pays_actuel ILIKE ? OR migrants.ville_actuelle ILIKE ?) OR (migrants.pays_actuel ILIKE ? OR migrants.ville_actuelle ILIKE ?)",
-// 			"%"+origin+"%", "%"+origin+"%", "%"+destination+"%", "%"+destination+"%").
-// 		Where("alertes.statut != ?", "resolved").
-// 		Count(&alertCount)
-
-// 	if alertCount > 10 {
-// 		return "Critique"
-// 	} else if alertCount > 5 {
-// 		return "Élevé"
-// 	} else if alertCount > 2 {
-// 		return "Modéré"
-// 	}
-// 	return "Faible"
-// }
-
-// func calculateRouteTrend(origin, destination string) string {
-// 	// Analyser la tendance des 6 derniers mois
-// 	var currentCount, previousCount int64
-
-// 	database.DB.Model(&models.Geolocalisation{}).
-// 		Where("pays = ? AND ville = ?", origin, destination).
-// 		Where("created_at >= ?", time.Now().AddDate(0, -3, 0)).
-// 		Count(&currentCount)
-
-// 	database.DB.Model(&models.Geolocalisation{}).
-// 		Where("pays = ? AND ville = ?", origin, destination).
-// 		Where("created_at >= ? AND created_at < ?",
-// 			time.Now().AddDate(0, -6, 0), time.Now().AddDate(0, -3, 0)).
-// 		Count(&previousCount)
-
-// 	if currentCount > previousCount {
-// 		return "Croissant"
-// 	} else if currentCount < previousCount {
-// 		return "Décroissant"
-// 	}
-// 	return "Stable"
-// }
-
-// func calculateSeasonalVariation(origin, destination string) float64 {
-// 	// Calculer la variation saisonnière basée sur les données historiques
-// 	return 0.25 + (float64(len(origin)+len(destination)) * 0.01)
-// }
-
-// func calculateTemporalPatterns() TemporalMovement {
-// 	// Patterns mensuels
-// 	var monthlyPatterns []MonthlyMovementData
-// 	for i := 0; i < 12; i++ {
-// 		month := time.Now().AddDate(0, -i, 0)
-// 		var count int64
-// 		database.DB.Model(&models.Geolocalisation{}).
-// 			Where("EXTRACT(MONTH FROM created_at) = ? AND EXTRACT(YEAR FROM created_at) = ?",
-// 				month.Month(), month.Year()).
-// 			Count(&count)
-
-// 		trend := "Stable"
-// 		if i > 0 {
-// 			var prevCount int64
-// 			prevMonth := time.Now().AddDate(0, -i-1, 0)
-// 			database.DB.Model(&models.Geolocalisation{}).
-// 				Where("EXTRACT(MONTH FROM created_at) = ? AND EXTRACT(YEAR FROM created_at) = ?",
-// 					prevMonth.Month(), prevMonth.Year()).
-// 				Count(&prevCount)
-
-// 			if count > prevCount {
-// 				trend = "Croissant"
-// 			} else if count < prevCount {
-// 				trend = "Décroissant"
-// 			}
-// 		}
-
-// 		monthlyPatterns = append(monthlyPatterns, MonthlyMovementData{
-// 			Month:     month.Format("January"),
-// 			Year:      month.Year(),
-// 			Movements: count,
-// 			Trend:     trend,
-// 		})
-// 	}
-
-// 	return TemporalMovement{
-// 		PeakMovementMonths: []string{"Novembre", "Décembre", "Janvier", "Février"},
-// 		LowMovementMonths:  []string{"Juin", "Juillet", "Août"},
-// 		MonthlyPatterns:    monthlyPatterns,
-// 		DailyPatterns:      []DailyMovementData{},  // Pas de données horaires disponibles
-// 		WeeklyPatterns:     []WeeklyMovementData{}, // Pas de données hebdomadaires détaillées
-// 	}
-// }
-
-// func calculateSeasonMovements(season string) int64 {
-// 	var count int64
-// 	if season == "dry" {
-// 		// Saison sèche : Nov, Dec, Jan, Feb, Mar
-// 		database.DB.Model(&models.Geolocalisation{}).
-// 			Where("EXTRACT(MONTH FROM created_at) IN (11, 12, 1, 2, 3)").
-// 			Count(&count)
-// 	} else {
-// 		// Saison des pluies : Avr-Oct
-// 		database.DB.Model(&models.Geolocalisation{}).
-// 			Where("EXTRACT(MONTH FROM created_at) IN (4, 5, 6, 7, 8, 9, 10)").
-// 			Count(&count)
-// 	}
-// 	return count
-// }
-
-// func calculateGeographicHotspots() []GeographicHotspot {
-// 	var hotspots []GeographicHotspot
-
-// 	// Analyser les destinations les plus populaires
-// 	var locationResults []struct {
-// 		Location string
-// 		Count    int64
-// 	}
-
-// 	database.DB.Raw(`
-// 		SELECT 
-// 			COALESCE(ville, 'Inconnu') as location,
-// 			COUNT(*) as count
-// 		FROM geolocalisations 
-// 		WHERE ville IS NOT NULL
-// 		GROUP BY ville
-// 		ORDER BY count DESC
-// 		LIMIT 5
-// 	`).Scan(&locationResults)
-
-// 	for _, result := range locationResults {
-// 		concentration := float64(result.Count) / 1000.0 // Index normalisé
-
-// 		hotspots = append(hotspots, GeographicHotspot{
-// 			Location:      result.Location,
-// 			Type:          "destination",
-// 			Movements:     result.Count,
-// 			Concentration: math.Round(concentration*100) / 100,
-// 			Accessibility: "Modérée",
-// 			Services:      []string{"Hébergement", "Transport", "Services de base"},
-// 		})
-// 	}
-
-// 	return hotspots
-// }
-
-// func calculateMobilityMetrics() MobilityMetrics {
-// 	var totalMigrants, multipleMovements int64
-
-// 	database.DB.Model(&models.Migrant{}).Count(&totalMigrants)
-
-// 	// Migrants avec plusieurs géolocalisations
-// 	database.DB.Raw(`
-// 		SELECT COUNT(DISTINCT migrant_uuid) 
-// 		FROM geolocalisations 
-// 		GROUP BY migrant_uuid 
-// 		HAVING COUNT(*) > 1
-// 	`).Scan(&multipleMovements)
-
-// 	var avgMigrationsPerPerson float64
-// 	if totalMigrants > 0 {
-// 		var totalGeolocations int64
-// 		database.DB.Model(&models.Geolocalisation{}).Count(&totalGeolocations)
-// 		avgMigrationsPerPerson = float64(totalGeolocations) / float64(totalMigrants)
-// 	}
-
-// 	// Métriques basées sur les patterns observés
-// 	returnRate := 0.15        // 15% de migration de retour
-// 	onwardRate := 0.35        // 35% de migration continue
-// 	settlementRate := 0.50    // 50% de stabilisation
-// 	avgTransitDuration := 8.5 // jours moyens en transit
-
-// 	return MobilityMetrics{
-// 		AverageMigrationsPerPerson: math.Round(avgMigrationsPerPerson*100) / 100,
-// 		ReturnMigrationRate:        returnRate,
-// 		OnwardMigrationRate:        onwardRate,
-// 		SettlementRate:             settlementRate,
-// 		TransitDuration:            avgTransitDuration,
-// 	}
-// }
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/previsions"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"gorm.io/gorm"
+)
+
+// =================== ANALYSE PRÉDICTIVE ===================
+
+// Les séries sont les enregistrements mensuels de migrants (date de création du
+// dossier), du premier mois de l'historique au dernier mois complet. Chaque série
+// est ajustée par les modèles ci-dessous, évalués sur les derniers mois observés.
+var modelesPrevision = []struct {
+	nom     string
+	methode previsions.Methode
+}{
+	{previsions.ModeleHoltWinters, previsions.HoltWinters},
+	{previsions.ModeleNaifSaisonnier, previsions.NaifSaisonnier},
+}
+
+// Clé de province d'un migrant : province de son unité, vide si elle n'est pas connue.
+// Les enregistrements antérieurs aux unités sont rattachés à la direction générale :
+// leur province n'est pas connue, même si celle de la direction générale (Kinshasa) est renseignée.
+const provinceMigrant = "CASE WHEN u.type_unite <> 'national' THEN COALESCE(u.province, '') ELSE '' END"
+
+// Structure principale de réponse de l'analyse prédictive
+type AnalysePredictive struct {
+	// total, province ou type_motif
+	Dimension string `json:"dimension"`
+	Horizon   int    `json:"horizon"`
+	Saison    int    `json:"saison"`
+	// Premier et dernier mois de l'historique (AAAA-MM)
+	DebutHistorique string           `json:"debut_historique"`
+	FinHistorique   string           `json:"fin_historique"`
+	Series          []SeriePrevision `json:"series"`
+	// Par province : enregistrements dont la province n'est pas connue (unité nationale
+	// ou sans province), hors des séries ; nil s'il n'y en a pas
+	ProvinceInconnue *SeriePrevision `json:"province_inconnue,omitempty"`
+	DateGeneration   time.Time       `json:"date_generation"`
+}
+
+// Série mensuelle d'une province, d'un type de motif ou de l'ensemble, du premier
+// mois avec un enregistrement au dernier mois de l'historique
+type SeriePrevision struct {
+	Cle        string             `json:"cle"`
+	Total      int64              `json:"total"`
+	Historique []PointHistorique  `json:"historique"`
+	Modeles    []ModeleTimeSeries `json:"modeles"`
+	// Modèle de plus faible RMSE au backtest, vide si aucun n'a pu être évalué
+	MeilleurModele string `json:"meilleur_modele"`
+}
+
+type PointHistorique struct {
+	Periode string `json:"periode"`
+	Nombre  int64  `json:"nombre"`
+}
+
+// Modèle ajusté sur une série ; Erreur est renseignée si l'historique est trop court
+type ModeleTimeSeries struct {
+	Nom        string               `json:"nom"`
+	Parametres map[string]float64   `json:"parametres,omitempty"`
+	Backtest   *MetriquesBacktest   `json:"backtest"`
+	Previsions []PrevisionMigration `json:"previsions"`
+	Erreur     string               `json:"erreur,omitempty"`
+}
+
+// Erreurs du modèle ajusté sans les derniers mois, sur ces mois
+type MetriquesBacktest struct {
+	MAPE        *float64 `json:"mape"`
+	RMSE        float64  `json:"rmse"`
+	MoisEvalues int      `json:"mois_evalues"`
+}
+
+type PrevisionMigration struct {
+	Periode      string                `json:"periode"`
+	NombrePrevus float64               `json:"nombre_prevus"`
+	Intervalles  []IntervalleConfiance `json:"intervalles"`
+}
+
+type IntervalleConfiance struct {
+	Niveau int     `json:"niveau"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// =================== ENDPOINTS ===================
+
+// GetPrevisionEnregistrements - Prévision des enregistrements mensuels de l'ensemble du périmètre
+// GET /api/dashboard/predictive/enregistrements?horizon=6&historique=36&saison=12
+func GetPrevisionEnregistrements(c *fiber.Ctx) error {
+	return analysePredictive(c, "total", func(db *gorm.DB) *gorm.DB {
+		return db.Table("migrants m").
+			Select("'Total' AS cle, to_char(date_trunc('month', m.created_at), 'YYYY-MM') AS periode, COUNT(*) AS nombre").
+			Where("m.deleted_at IS NULL")
+	})
+}
+
+// GetPrevisionProvinces - Prévision des enregistrements mensuels par province
+// GET /api/dashboard/predictive/provinces?horizon=6&historique=36&saison=12&province=
+func GetPrevisionProvinces(c *fiber.Ctx) error {
+	province := c.Query("province", "")
+
+	return analysePredictive(c, "province", func(db *gorm.DB) *gorm.DB {
+		query := db.Table("migrants m").
+			Select(provinceMigrant + " AS cle, to_char(date_trunc('month', m.created_at), 'YYYY-MM') AS periode, COUNT(*) AS nombre").
+			Joins("LEFT JOIN org_units u ON u.uuid = m.unite_uuid").
+			Where("m.deleted_at IS NULL")
+		if province != "" {
+			query = query.Where(provinceMigrant+" = ?", province)
+		}
+		return query
+	})
+}
+
+// GetPrevisionMotifs - Prévision des enregistrements mensuels par type de motif de déplacement
+// GET /api/dashboard/predictive/motifs?horizon=6&historique=36&saison=12&motif=
+func GetPrevisionMotifs(c *fiber.Ctx) error {
+	motif := c.Query("motif", "")
+
+	return analysePredictive(c, "type_motif", func(db *gorm.DB) *gorm.DB {
+		// Un migrant compte une fois par type, même avec plusieurs motifs du même type
+		query := db.Table("motif_deplacements md").
+			Select("md.type_motif AS cle, to_char(date_trunc('month', m.created_at), 'YYYY-MM') AS periode, COUNT(DISTINCT m.uuid) AS nombre").
+			Joins("JOIN migrants m ON md.migrant_uuid = m.uuid").
+			Where("md.deleted_at IS NULL AND m.deleted_at IS NULL")
+		if motif != "" {
+			query = query.Where("md.type_motif = ?", motif)
+		}
+		return query
+	})
+}
+
+// =================== CALCUL ===================
+
+// analysePredictive lit les paramètres, compte les enregistrements par clé et par mois
+// dans le périmètre de l'utilisateur et ajuste les modèles sur chaque série
+func analysePredictive(c *fiber.Ctx, dimension string, requete func(db *gorm.DB) *gorm.DB) error {
+	horizon, invalideHorizon := parametreEntier(c, "horizon", 6, 1, 24)
+	historique, invalideHistorique := parametreEntier(c, "historique", 36, 12, 120)
+	saison, invalideSaison := parametreEntier(c, "saison", 12, 2, 12)
+	for _, message := range []string{invalideHorizon, invalideHistorique, invalideSaison} {
+		if message != "" {
+			return problemes.Invalide(message)
+		}
+	}
+
+	// Le mois en cours, incomplet, est exclu de l'historique
+	now := time.Now()
+	fin := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	debut := fin.AddDate(0, -historique, 0)
+	periodes := make([]string, historique)
+	index := make(map[string]int, historique)
+	for i := range periodes {
+		periodes[i] = debut.AddDate(0, i, 0).Format("2006-01")
+		index[periodes[i]] = i
+	}
+
+	var lignes []struct {
+		Cle     string
+		Periode string
+		Nombre  int64
+	}
+	p := unites.PerimetreDe(c)
+	err := requete(database.DB).Scopes(p.ParUnite("m.unite_uuid")).
+		Where("m.created_at >= ? AND m.created_at < ?", debut, fin).
+		Group("cle, periode").
+		Scan(&lignes).Error
+	if err != nil {
+		return problemes.Echec("Failed to fetch registrations", err)
+	}
+
+	// Mois sans enregistrement à 0 dans la fenêtre
+	comptes := map[string][]int64{}
+	for _, ligne := range lignes {
+		i, ok := index[ligne.Periode]
+		if !ok {
+			continue
+		}
+		if comptes[ligne.Cle] == nil {
+			comptes[ligne.Cle] = make([]int64, historique)
+		}
+		comptes[ligne.Cle][i] += ligne.Nombre
+	}
+
+	analyse := AnalysePredictive{
+		Dimension:       dimension,
+		Horizon:         horizon,
+		Saison:          saison,
+		DebutHistorique: periodes[0],
+		FinHistorique:   periodes[historique-1],
+		Series:          []SeriePrevision{},
+		DateGeneration:  now,
+	}
+	for cle, valeurs := range comptes {
+		// La série commence à sa première observation : les mois antérieurs ne sont pas
+		// des mois sans enregistrement mais des mois hors de l'activité de la clé
+		premier := 0
+		for valeurs[premier] == 0 {
+			premier++
+		}
+		serie := prevoirSerie(cle, valeurs[premier:], periodes[premier:], fin, saison, horizon)
+		if dimension == "province" && cle == "" {
+			analyse.ProvinceInconnue = &serie
+			continue
+		}
+		analyse.Series = append(analyse.Series, serie)
+	}
+	// Séries les plus fournies en premier
+	sort.Slice(analyse.Series, func(i, j int) bool {
+		if analyse.Series[i].Total != analyse.Series[j].Total {
+			return analyse.Series[i].Total > analyse.Series[j].Total
+		}
+		return analyse.Series[i].Cle < analyse.Series[j].Cle
+	})
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Predictive analysis",
+		"data":    analyse,
+	})
+}
+
+// prevoirSerie ajuste chaque modèle sur la série et l'évalue sur ses derniers mois ;
+// debut est le premier mois prévu
+func prevoirSerie(cle string, valeurs []int64, periodes []string, debut time.Time, saison, horizon int) SeriePrevision {
+	serie := SeriePrevision{Cle: cle}
+	historique := make([]float64, len(valeurs))
+	for i, nombre := range valeurs {
+		historique[i] = float64(nombre)
+		serie.Total += nombre
+		serie.Historique = append(serie.Historique, PointHistorique{Periode: periodes[i], Nombre: nombre})
+	}
+
+	// Backtest sur l'horizon demandé, sans priver l'apprentissage de plus d'un tiers de la série
+	test := horizon
+	if test > len(historique)/3 {
+		test = len(historique) / 3
+	}
+
+	var meilleur float64
+	for _, modele := range modelesPrevision {
+		resultat := ModeleTimeSeries{Nom: modele.nom, Previsions: []PrevisionMigration{}}
+
+		ajuste, err := modele.methode(historique, saison, horizon)
+		if err != nil {
+			resultat.Erreur = messagePrevision(err)
+			serie.Modeles = append(serie.Modeles, resultat)
+			continue
+		}
+		// Nom effectif : holt ou naif si l'historique est trop court pour la saisonnalité
+		resultat.Nom = ajuste.Modele
+		resultat.Parametres = ajuste.Parametres
+		for h := range ajuste.Previsions {
+			prevision := PrevisionMigration{
+				Periode:      debut.AddDate(0, h, 0).Format("2006-01"),
+				NombrePrevus: arrondi(ajuste.Previsions[h]),
+			}
+			for _, niveau := range previsions.NiveauxIntervalle {
+				min, max := ajuste.Intervalle(h, niveau)
+				prevision.Intervalles = append(prevision.Intervalles, IntervalleConfiance{
+					Niveau: niveau,
+					Min:    arrondi(min),
+					Max:    arrondi(max),
+				})
+			}
+			resultat.Previsions = append(resultat.Previsions, prevision)
+		}
+
+		if metriques, err := previsions.Evaluer(modele.methode, historique, saison, test); err == nil {
+			resultat.Backtest = &MetriquesBacktest{RMSE: arrondi(metriques.RMSE), MoisEvalues: metriques.Points}
+			if metriques.MAPE != nil {
+				mape := arrondi(*metriques.MAPE)
+				resultat.Backtest.MAPE = &mape
+			}
+			if serie.MeilleurModele == "" || metriques.RMSE < meilleur {
+				serie.MeilleurModele, meilleur = resultat.Nom, metriques.RMSE
+			}
+		}
+		serie.Modeles = append(serie.Modeles, resultat)
+	}
+	return serie
+}
+
+// parametreEntier lit un paramètre entier borné ; le message d'erreur est vide si la
+// valeur est absente (défaut) ou valide
+func parametreEntier(c *fiber.Ctx, nom string, defaut, min, max int) (int, string) {
+	brut := c.Query(nom, "")
+	if brut == "" {
+		return defaut, ""
+	}
+	valeur, err := strconv.Atoi(brut)
+	if err != nil || valeur < min || valeur > max {
+		return 0, fmt.Sprintf("%s must be an integer between %d and %d", nom, min, max)
+	}
+	return valeur, ""
+}
+
+func messagePrevision(err error) string {
+	if errors.Is(err, previsions.ErrHistoriqueInsuffisant) {
+		return "Not enough history to fit the model"
+	}
+	return err.Error()
+}
+
+// arrondi à deux décimales
+func arrondi(valeur float64) float64 {
+	return math.Round(valeur*100) / 100
+}
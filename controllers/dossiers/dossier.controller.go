@@ -0,0 +1,640 @@
+package dossiers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/controllers/versions"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Champs des dossiers pouvant être filtrés, triés et sélectionnés
+var listeDossiers = requetes.Schema{
+	Table: "dossiers",
+	Champs: map[string]requetes.Type{
+		"uuid":                requetes.Texte,
+		"created_at":          requetes.Date,
+		"updated_at":          requetes.Date,
+		"numero_dossier":      requetes.Texte,
+		"migrant_uuid":        requetes.Texte,
+		"type_dossier":        requetes.Texte,
+		"priorite":            requetes.Texte,
+		"etape":               requetes.Texte,
+		"date_entretien":      requetes.Date,
+		"date_limite":         requetes.Date,
+		"decision":            requetes.Texte,
+		"date_decision":       requetes.Date,
+		"statut_attribue":     requetes.Texte,
+		"date_limite_recours": requetes.Date,
+		"date_cloture":        requetes.Date,
+		"enregistre_par":      requetes.Texte,
+		"version":             requetes.Nombre,
+	},
+	Relations: []string{"migrant", "agents"},
+	TriDefaut: "-updated_at",
+}
+
+// Paginate - Récupérer les dossiers avec pagination et filtres
+func GetPaginatedDossiers(c *fiber.Ctx) error {
+	db := unites.Base(unites.PerimetreDe(c).ParMigrant("dossiers.migrant_uuid"))
+
+	liste, erreurs := requetes.Lire(c, listeDossiers)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	search := c.Query("search", "")
+
+	var dossiers []models.Dossier
+
+	query := filtrerDossiers(db.Model(&models.Dossier{}), c)
+
+	if search != "" {
+		query = query.Joins("LEFT JOIN migrants ON dossiers.migrant_uuid = migrants.uuid").
+			Joins("LEFT JOIN identites ON migrants.identite_uuid = identites.uuid").
+			Where("dossiers.numero_dossier ILIKE ? OR migrants.numero_identifiant ILIKE ? OR identites.nom ILIKE ? OR identites.postnom ILIKE ? OR identites.prenom ILIKE ?",
+				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	data, pagination, err := liste.Executer(query.
+		Preload("Migrant.Identite").
+		Preload("Agents"), &dossiers)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch cases", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Cases retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// filtrerDossiers applique les filtres communs à la liste et à l'export
+func filtrerDossiers(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if typeDossier := c.Query("type_dossier", ""); typeDossier != "" {
+		query = query.Where("dossiers.type_dossier = ?", typeDossier)
+	}
+	if etape := c.Query("etape", ""); etape != "" {
+		query = query.Where("dossiers.etape = ?", etape)
+	}
+	if priorite := c.Query("priorite", ""); priorite != "" {
+		query = query.Where("dossiers.priorite = ?", priorite)
+	}
+	if agentUUID := c.Query("agent_uuid", ""); agentUUID != "" {
+		query = query.Where("dossiers.uuid IN (?)",
+			database.DB.Model(&models.DossierAgent{}).Select("dossier_uuid").Where("agent_uuid = ?", agentUUID))
+	}
+	if c.Query("en_retard", "") == "true" {
+		query = query.Where("dossiers.etape <> ? AND dossiers.date_limite < ?", "cloture", time.Now())
+	}
+	if startDate := c.Query("start_date", ""); startDate != "" {
+		if parsed, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("dossiers.created_at >= ?", parsed)
+		}
+	}
+	if endDate := c.Query("end_date", ""); endDate != "" {
+		if parsed, err := time.Parse("2006-01-02", endDate); err == nil {
+			query = query.Where("dossiers.created_at <= ?", parsed.Add(23*time.Hour+59*time.Minute+59*time.Second))
+		}
+	}
+	return query
+}
+
+// Get one data
+func GetDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var dossier models.Dossier
+
+	err := db.Where("uuid = ?", uuid).
+		Preload("Migrant.Identite").
+		Preload("Agents").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Etapes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&dossier).Error
+
+	if err != nil {
+		return problemes.Absent("Case not found", err)
+	}
+
+	if !unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossier.UUID) {
+		return unites.Refus(c)
+	}
+
+	renseignerNomsAgents(dossier.Agents)
+
+	versions.ETag(c, dossier.Version)
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Case found",
+		"data":    dossier,
+	})
+}
+
+// GetMigrantDossiers - Récupérer les dossiers d'un migrant
+func GetMigrantDossiers(c *fiber.Ctx) error {
+	migrantUUID := c.Params("uuid")
+	db := database.DB
+	var dossiers []models.Dossier
+
+	if !unites.AccederMigrant(c, migrantUUID, "migrant", migrantUUID) {
+		return unites.Refus(c)
+	}
+
+	err := db.Where("migrant_uuid = ?", migrantUUID).
+		Preload("Agents").
+		Order("created_at DESC").
+		Find(&dossiers).Error
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch cases", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Migrant cases retrieved successfully",
+		"data":    dossiers,
+	})
+}
+
+// Create data
+func CreateDossier(c *fiber.Ctx) error {
+	dossier := &models.Dossier{}
+
+	if err := c.BodyParser(dossier); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(*dossier); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var migrant models.Migrant
+	if err := database.DB.Where("uuid = ?", dossier.MigrantUUID).First(&migrant).Error; err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	// Un seul dossier ouvert par type et par migrant
+	var ouverts int64
+	database.DB.Model(&models.Dossier{}).
+		Where("migrant_uuid = ? AND type_dossier = ? AND etape <> ?", dossier.MigrantUUID, dossier.TypeDossier, "cloture").
+		Count(&ouverts)
+	if ouverts > 0 {
+		return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "An open case of this type already exists for this migrant")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+
+	dossier.UUID = utils.GenerateUUID()
+	dossier.Etape = "enregistre"
+	dossier.Decision = ""
+	dossier.DateDecision = nil
+	dossier.DateCloture = nil
+	dossier.EnregistrePar = userUUID
+	if dossier.Priorite == "" {
+		dossier.Priorite = "normale"
+	}
+	if dossier.DateLimite == nil {
+		limite := time.Now().AddDate(0, 0, delaiParDefaut(dossier.TypeDossier))
+		dossier.DateLimite = &limite
+	}
+
+	// Les agents fournis à la création sont enregistrés avec le dossier
+	for i := range dossier.Agents {
+		dossier.Agents[i].UUID = utils.GenerateUUID()
+		if dossier.Agents[i].Role == "" {
+			dossier.Agents[i].Role = "responsable"
+		}
+	}
+	dossier.Notes = nil
+	dossier.Etapes = []models.DossierEtape{{
+		UUID:        utils.GenerateUUID(),
+		Etape:       "enregistre",
+		EffectuePar: userUUID,
+		Commentaire: "Enregistrement du dossier",
+	}}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		numeros, err := models.NumerotationDossiers.Reserver(tx, "", 1)
+		if err != nil {
+			return err
+		}
+		dossier.NumeroDossier = numeros[0]
+		if err := tx.Omit("Migrant").Create(dossier).Error; err != nil {
+			return err
+		}
+
+		// L'enregistrement d'une demande d'asile confère le statut de demandeur d'asile
+		if dossier.TypeDossier == "demande_asile" && migrant.StatutMigratoire != "refugie" {
+			return changerStatutMigratoire(tx, &migrant, "demandeur_asile", models.ContexteStatut{
+				Motif:             "Enregistrement de la demande d'asile",
+				ReferenceDecision: dossier.NumeroDossier,
+				EffectuePar:       userUUID,
+			})
+		}
+		return nil
+	})
+
+	if err != nil {
+		return problemes.Echec("Failed to create case", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Case created successfully",
+		"data":    dossier,
+	})
+}
+
+// Update data - seules les informations descriptives sont modifiables ici,
+// l'étape et la décision passent par le workflow
+func UpdateDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var updateData struct {
+		Description string     `json:"description"`
+		Priorite    string     `json:"priorite" validate:"omitempty,oneof=normale haute urgente"`
+		DateLimite  *time.Time `json:"date_limite"`
+	}
+
+	if err := c.BodyParser(&updateData); err != nil {
+		return problemes.Invalide("Review your input").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(updateData); err != nil {
+		return problemes.Validation(err)
+	}
+
+	dossier := new(models.Dossier)
+	if err := db.Where("uuid = ?", uuid).First(&dossier).Error; err != nil {
+		return problemes.Absent("Case not found", err)
+	}
+
+	if !unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossier.UUID) {
+		return unites.Refus(c)
+	}
+
+	if !versions.Correspond(c, dossier.Version) {
+		return versions.Conflit(c, dossier.Version, dossier)
+	}
+
+	result := db.Model(&dossier).Where("version = ?", dossier.Version).Updates(models.Dossier{
+		Description: updateData.Description,
+		Priorite:    updateData.Priorite,
+		DateLimite:  updateData.DateLimite,
+	})
+	if result.Error != nil {
+		return problemes.Echec("Failed to update case", result.Error)
+	}
+
+	// Modifié par un autre agent entre la lecture et l'écriture
+	if result.RowsAffected == 0 {
+		db.Where("uuid = ?", uuid).First(&dossier)
+		return versions.Conflit(c, dossier.Version, dossier)
+	}
+
+	versions.ETag(c, dossier.Version)
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Case updated successfully",
+		"data":    dossier,
+	})
+}
+
+// Delete data
+func DeleteDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var dossier models.Dossier
+	if err := db.Where("uuid = ?", uuid).First(&dossier).Error; err != nil {
+		return problemes.Absent("Case not found", err)
+	}
+
+	if !unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossier.UUID) {
+		return unites.Refus(c)
+	}
+
+	if !versions.Correspond(c, dossier.Version) {
+		return versions.Conflit(c, dossier.Version, dossier)
+	}
+
+	result := db.Where("version = ?", dossier.Version).Delete(&dossier)
+	if result.Error != nil {
+		return problemes.Echec("Failed to delete case", result.Error)
+	}
+
+	// Modifié par un autre agent entre la lecture et la suppression
+	if result.RowsAffected == 0 {
+		db.Where("uuid = ?", uuid).First(&dossier)
+		return versions.Conflit(c, dossier.Version, dossier)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Case deleted successfully",
+		"data":    nil,
+	})
+}
+
+// GetDossiersStats - Statistiques des dossiers par type, étape et décision
+func GetDossiersStats(c *fiber.Ctx) error {
+	db := unites.Base(unites.PerimetreDe(c).ParMigrant("dossiers.migrant_uuid"))
+
+	type compte struct {
+		Cle    string `json:"cle"`
+		Nombre int64  `json:"nombre"`
+	}
+
+	var parType, parEtape, parDecision []compte
+	db.Model(&models.Dossier{}).Select("type_dossier as cle, COUNT(*) as nombre").Group("type_dossier").Scan(&parType)
+	db.Model(&models.Dossier{}).Select("etape as cle, COUNT(*) as nombre").Group("etape").Scan(&parEtape)
+	db.Model(&models.Dossier{}).Select("decision as cle, COUNT(*) as nombre").Where("decision <> ''").Group("decision").Scan(&parDecision)
+
+	var total, enRetard int64
+	db.Model(&models.Dossier{}).Count(&total)
+	db.Model(&models.Dossier{}).Where("etape <> ? AND date_limite < ?", "cloture", time.Now()).Count(&enRetard)
+
+	// Délai moyen de décision en jours
+	var delaiMoyen float64
+	db.Model(&models.Dossier{}).
+		Select("COALESCE(AVG(EXTRACT(EPOCH FROM (date_decision - created_at)) / 86400), 0)").
+		Where("date_decision IS NOT NULL").
+		Scan(&delaiMoyen)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cases statistics",
+		"data": fiber.Map{
+			"total":                total,
+			"en_retard":            enRetard,
+			"delai_moyen_decision": delaiMoyen,
+			"par_type":             parType,
+			"par_etape":            parEtape,
+			"par_decision":         parDecision,
+		},
+	})
+}
+
+// =======================
+// EXCEL EXPORT
+// =======================
+
+// ExportDossiersToExcel - Exporter les dossiers vers Excel avec mise en forme
+func ExportDossiersToExcel(c *fiber.Ctx) error {
+	db := unites.Base(unites.PerimetreDe(c).ParMigrant("dossiers.migrant_uuid"))
+
+	var dossiers []models.Dossier
+	query := filtrerDossiers(db.Model(&models.Dossier{}), c).
+		Preload("Migrant.Identite").
+		Preload("Agents")
+
+	if err := query.Order("dossiers.created_at DESC").Find(&dossiers).Error; err != nil {
+		return problemes.Echec("Failed to fetch cases for export", err)
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	f.DeleteSheet("Sheet1")
+	index, err := f.NewSheet("Dossiers")
+	if err != nil {
+		return problemes.Echec("Failed to create Excel sheet", err)
+	}
+	f.SetActiveSheet(index)
+
+	// ===== STYLES =====
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 16, Color: "FFFFFF", Family: "Calibri"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#2E75B6"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+	columnHeaderStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 12, Color: "FFFFFF", Family: "Calibri"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4F81BD"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+	dataStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Size: 11, Family: "Calibri"},
+		Alignment: &excelize.Alignment{Horizontal: "left", Vertical: "center", WrapText: true},
+		Border: []excelize.Border{
+			{Type: "left", Color: "CCCCCC", Style: 1},
+			{Type: "top", Color: "CCCCCC", Style: 1},
+			{Type: "bottom", Color: "CCCCCC", Style: 1},
+			{Type: "right", Color: "CCCCCC", Style: 1},
+		},
+	})
+	retardStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Size: 11, Family: "Calibri", Color: "C00000", Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border: []excelize.Border{
+			{Type: "left", Color: "CCCCCC", Style: 1},
+			{Type: "top", Color: "CCCCCC", Style: 1},
+			{Type: "bottom", Color: "CCCCCC", Style: 1},
+			{Type: "right", Color: "CCCCCC", Style: 1},
+		},
+	})
+
+	// ===== EN-TÊTE PRINCIPAL =====
+	headers := []string{
+		"N° Dossier",
+		"N° Migrant",
+		"Nom",
+		"Postnom",
+		"Prénom",
+		"Nationalité",
+		"Type de dossier",
+		"Priorité",
+		"Étape",
+		"Date d'entretien",
+		"Échéance",
+		"Décision",
+		"Date de décision",
+		"Statut attribué",
+		"Agents",
+		"Date de clôture",
+		"Date création",
+	}
+	lastColumn, _ := excelize.ColumnNumberToName(len(headers))
+
+	currentTime := time.Now().Format("02/01/2006 15:04")
+	f.SetCellValue("Dossiers", "A1", fmt.Sprintf("RAPPORT D'EXPORT DES DOSSIERS - %s", currentTime))
+	f.MergeCell("Dossiers", "A1", lastColumn+"1")
+	f.SetCellStyle("Dossiers", "A1", lastColumn+"1", headerStyle)
+	f.SetRowHeight("Dossiers", 1, 30)
+
+	row := 3
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue("Dossiers", cell, header)
+		f.SetCellStyle("Dossiers", cell, cell, columnHeaderStyle)
+	}
+	f.SetRowHeight("Dossiers", row, 25)
+
+	formatDate := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("02/01/2006")
+	}
+
+	// Noms des agents affectés
+	var agentUUIDs []string
+	for _, dossier := range dossiers {
+		for _, agent := range dossier.Agents {
+			agentUUIDs = append(agentUUIDs, agent.AgentUUID)
+		}
+	}
+	noms := nomsAgents(agentUUIDs)
+
+	// ===== DONNÉES =====
+	now := time.Now()
+	for i, dossier := range dossiers {
+		dataRow := row + 1 + i
+
+		agents := ""
+		for j, agent := range dossier.Agents {
+			if j > 0 {
+				agents += ", "
+			}
+			agents += noms[agent.AgentUUID] + " (" + agent.Role + ")"
+		}
+
+		values := []interface{}{
+			dossier.NumeroDossier,
+			dossier.Migrant.NumeroIdentifiant,
+			dossier.Migrant.Identite.Nom,
+			dossier.Migrant.Identite.Postnom,
+			dossier.Migrant.Identite.Prenom,
+			dossier.Migrant.Identite.Nationalite,
+			dossier.TypeDossier,
+			dossier.Priorite,
+			dossier.Etape,
+			formatDate(dossier.DateEntretien),
+			formatDate(dossier.DateLimite),
+			dossier.Decision,
+			formatDate(dossier.DateDecision),
+			dossier.StatutAttribue,
+			agents,
+			formatDate(dossier.DateCloture),
+			dossier.CreatedAt.Format("02/01/2006 15:04"),
+		}
+		for col, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, dataRow)
+			f.SetCellValue("Dossiers", cell, value)
+			f.SetCellStyle("Dossiers", cell, cell, dataStyle)
+		}
+
+		// Échéance dépassée en rouge
+		if dossier.Etape != "cloture" && dossier.DateLimite != nil && dossier.DateLimite.Before(now) {
+			cell, _ := excelize.CoordinatesToCellName(11, dataRow)
+			f.SetCellStyle("Dossiers", cell, cell, retardStyle)
+		}
+
+		f.SetRowHeight("Dossiers", dataRow, 20)
+	}
+
+	// ===== AJUSTEMENT DE LA LARGEUR DES COLONNES =====
+	columnWidths := []float64{18, 18, 15, 15, 15, 15, 22, 10, 18, 14, 14, 12, 14, 16, 30, 14, 18}
+	for i, width := range columnWidths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetColWidth("Dossiers", col, col, width)
+	}
+
+	// ===== FEUILLE DE STATISTIQUES =====
+	if _, err := f.NewSheet("Statistiques"); err == nil {
+		typeCount := make(map[string]int)
+		etapeCount := make(map[string]int)
+		decisionCount := make(map[string]int)
+		for _, dossier := range dossiers {
+			typeCount[dossier.TypeDossier]++
+			etapeCount[dossier.Etape]++
+			if dossier.Decision != "" {
+				decisionCount[dossier.Decision]++
+			}
+		}
+
+		f.SetCellValue("Statistiques", "A1", "STATISTIQUES DES DOSSIERS")
+		f.MergeCell("Statistiques", "A1", "C1")
+		f.SetCellStyle("Statistiques", "A1", "C1", headerStyle)
+
+		row = 3
+		f.SetCellValue("Statistiques", fmt.Sprintf("A%d", row), "Total des dossiers:")
+		f.SetCellValue("Statistiques", fmt.Sprintf("B%d", row), len(dossiers))
+		row += 2
+
+		sections := []struct {
+			titre  string
+			counts map[string]int
+		}{
+			{"Par type de dossier:", typeCount},
+			{"Par étape:", etapeCount},
+			{"Par décision:", decisionCount},
+		}
+		for _, section := range sections {
+			f.SetCellValue("Statistiques", fmt.Sprintf("A%d", row), section.titre)
+			f.SetCellStyle("Statistiques", fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), columnHeaderStyle)
+			row++
+			for cle, nb := range section.counts {
+				f.SetCellValue("Statistiques", fmt.Sprintf("A%d", row), cle)
+				f.SetCellValue("Statistiques", fmt.Sprintf("B%d", row), nb)
+				row++
+			}
+			row++
+		}
+
+		f.SetColWidth("Statistiques", "A", "A", 25)
+		f.SetColWidth("Statistiques", "B", "B", 15)
+	}
+
+	// ===== GÉNÉRATION DU FICHIER =====
+	filename := fmt.Sprintf("dossiers_export_%s.xlsx", time.Now().Format("20060102_150405"))
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return problemes.Echec("Failed to generate Excel file", err)
+	}
+
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Set("Content-Length", strconv.Itoa(len(buffer.Bytes())))
+
+	return c.Send(buffer.Bytes())
+}
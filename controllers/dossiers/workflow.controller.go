@@ -0,0 +1,528 @@
+package dossiers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Transitions autorisées entre les étapes d'un dossier
+var transitions = map[string][]string{
+	"enregistre":         {"entretien_planifie", "cloture"},
+	"entretien_planifie": {"entretien_planifie", "decision", "cloture"},
+	"decision":           {"recours", "cloture"},
+	"recours":            {"entretien_planifie", "decision", "cloture"},
+	"cloture":            {},
+}
+
+// Délai de recours après une décision (jours)
+const delaiRecours = 30
+
+// delaiParDefaut retourne le délai de traitement initial d'un dossier (jours)
+func delaiParDefaut(typeDossier string) int {
+	switch typeDossier {
+	case "demande_asile":
+		return 90
+	case "regroupement_familial":
+		return 180
+	case "retour_volontaire":
+		return 30
+	default:
+		return 60
+	}
+}
+
+// statutApresDecision retourne le statut migratoire découlant d'une décision,
+// ou une chaîne vide si la décision ne modifie pas le statut
+func statutApresDecision(typeDossier, decision string) string {
+	switch typeDossier {
+	case "demande_asile":
+		if decision == "accordee" {
+			return "refugie"
+		}
+		return "irregulier"
+	case "regularisation":
+		if decision == "accordee" {
+			return "regulier"
+		}
+		return "irregulier"
+	}
+	return ""
+}
+
+// echeanceRecours retourne la date limite de recours contre une décision rendue à la
+// date donnée ; seule une décision de rejet peut faire l'objet d'un recours
+func echeanceRecours(decision string, date time.Time) *time.Time {
+	if decision != "rejetee" {
+		return nil
+	}
+	limite := date.AddDate(0, 0, delaiRecours)
+	return &limite
+}
+
+// recoursIrrecevable retourne le motif pour lequel un recours contre la décision du
+// dossier ne peut être formé à la date donnée, ou une chaîne vide s'il est recevable
+func recoursIrrecevable(dossier models.Dossier, ref time.Time) string {
+	if dossier.Decision != "rejetee" {
+		return "Only rejected cases can be appealed"
+	}
+	if dossier.DateLimiteRecours != nil && ref.After(*dossier.DateLimiteRecours) {
+		return "The appeal deadline has passed"
+	}
+	return ""
+}
+
+func transitionAutorisee(depuis, vers string) bool {
+	for _, etape := range transitions[depuis] {
+		if etape == vers {
+			return true
+		}
+	}
+	return false
+}
+
+// changerStatutMigratoire met à jour le statut d'un migrant dans la transaction fournie
+// et l'inscrit dans son historique avec la référence du dossier
+func changerStatutMigratoire(tx *gorm.DB, migrant *models.Migrant, statut string, ctx models.ContexteStatut) error {
+	if statut == "" || migrant.StatutMigratoire == statut {
+		return nil
+	}
+	tx = models.AvecContexteStatut(tx, ctx)
+	if err := tx.Model(migrant).Update("statut_migratoire", statut).Error; err != nil {
+		return err
+	}
+	migrant.StatutMigratoire = statut
+	return nil
+}
+
+// nomsAgents retourne le nom complet des agents demandés
+func nomsAgents(uuids []string) map[string]string {
+	noms := map[string]string{}
+	if len(uuids) == 0 {
+		return noms
+	}
+
+	var users []struct {
+		UUID    string
+		Nom     string
+		PostNom string
+		Prenom  string
+	}
+	database.DB.Model(&models.User{}).
+		Select("uuid, nom, post_nom, prenom").
+		Where("uuid IN ?", uuids).
+		Scan(&users)
+
+	for _, u := range users {
+		noms[u.UUID] = strings.TrimSpace(u.Prenom + " " + u.Nom + " " + u.PostNom)
+	}
+	return noms
+}
+
+// accederDossier contrôle l'accès à un dossier selon l'unité de son migrant
+func accederDossier(c *fiber.Ctx, dossierUUID string) bool {
+	var dossier models.Dossier
+	database.DB.Select("uuid", "migrant_uuid").Where("uuid = ?", dossierUUID).First(&dossier)
+	return unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossierUUID)
+}
+
+func renseignerNomsAgents(agents []models.DossierAgent) {
+	uuids := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		uuids = append(uuids, agent.AgentUUID)
+	}
+	noms := nomsAgents(uuids)
+	for i := range agents {
+		agents[i].AgentNom = noms[agents[i].AgentUUID]
+	}
+}
+
+// =======================
+// WORKFLOW
+// =======================
+
+// TransitionDossier - Faire passer un dossier à l'étape suivante
+// PUT /api/dossiers/transition/:uuid  {"etape": "entretien_planifie", "date_entretien": "...", "date_limite": "...", "commentaire": "..."}
+func TransitionDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		Etape         string     `json:"etape" validate:"required,oneof=entretien_planifie recours cloture"`
+		DateEntretien *time.Time `json:"date_entretien"`
+		DateLimite    *time.Time `json:"date_limite"`
+		Commentaire   string     `json:"commentaire"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(body); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var dossier models.Dossier
+	if err := db.Where("uuid = ?", uuid).First(&dossier).Error; err != nil {
+		return problemes.Absent("Case not found", err)
+	}
+
+	if !unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossier.UUID) {
+		return unites.Refus(c)
+	}
+
+	if !transitionAutorisee(dossier.Etape, body.Etape) {
+		return problemes.Invalide("Transition from "+dossier.Etape+" to "+body.Etape+" is not allowed").
+			Avec("data", transitions[dossier.Etape])
+	}
+
+	updates := map[string]interface{}{"etape": body.Etape}
+	now := time.Now()
+
+	switch body.Etape {
+	case "entretien_planifie":
+		if body.DateEntretien == nil {
+			return problemes.Invalide("date_entretien is required to schedule an interview")
+		}
+		updates["date_entretien"] = body.DateEntretien
+		updates["date_limite"] = body.DateEntretien
+	case "recours":
+		if motif := recoursIrrecevable(dossier, now); motif != "" {
+			return problemes.Invalide(motif)
+		}
+		limite := now.AddDate(0, 0, delaiParDefaut(dossier.TypeDossier))
+		updates["date_limite"] = &limite
+	case "cloture":
+		updates["date_cloture"] = &now
+		updates["date_limite"] = nil
+	}
+	if body.DateLimite != nil {
+		updates["date_limite"] = body.DateLimite
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&dossier).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.DossierEtape{
+			UUID:            utils.GenerateUUID(),
+			DossierUUID:     dossier.UUID,
+			EtapePrecedente: dossier.Etape,
+			Etape:           body.Etape,
+			EffectuePar:     userUUID,
+			Commentaire:     body.Commentaire,
+		}).Error
+	})
+
+	if err != nil {
+		return problemes.Echec("Failed to update case stage", err)
+	}
+
+	db.Where("uuid = ?", uuid).First(&dossier)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Case stage updated successfully",
+		"data":    dossier,
+	})
+}
+
+// DecideDossier - Enregistrer la décision et mettre à jour le statut migratoire dans la même transaction
+// POST /api/dossiers/decision/:uuid  {"decision": "accordee", "motif_decision": "...", "statut_migratoire": ""}
+func DecideDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		Decision         string `json:"decision" validate:"required,oneof=accordee rejetee"`
+		MotifDecision    string `json:"motif_decision"`
+		StatutMigratoire string `json:"statut_migratoire" validate:"omitempty,oneof=regulier irregulier demandeur_asile refugie"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(body); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var dossier models.Dossier
+	if err := db.Where("uuid = ?", uuid).First(&dossier).Error; err != nil {
+		return problemes.Absent("Case not found", err)
+	}
+
+	if !unites.AccederMigrant(c, dossier.MigrantUUID, "dossier", dossier.UUID) {
+		return unites.Refus(c)
+	}
+
+	if !transitionAutorisee(dossier.Etape, "decision") {
+		return problemes.Invalide("A decision cannot be recorded at stage " + dossier.Etape)
+	}
+
+	// Le statut explicite prime sur le statut déduit du type de dossier
+	statut := body.StatutMigratoire
+	if statut == "" {
+		statut = statutApresDecision(dossier.TypeDossier, body.Decision)
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+
+	updates := map[string]interface{}{
+		"etape":           "decision",
+		"decision":        body.Decision,
+		"date_decision":   &now,
+		"motif_decision":  body.MotifDecision,
+		"statut_attribue": statut,
+		"date_limite":     nil,
+	}
+	if limiteRecours := echeanceRecours(body.Decision, now); limiteRecours != nil {
+		updates["date_limite_recours"] = limiteRecours
+		updates["date_limite"] = limiteRecours
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var migrant models.Migrant
+		if err := tx.Where("uuid = ?", dossier.MigrantUUID).First(&migrant).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&dossier).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.DossierEtape{
+			UUID:            utils.GenerateUUID(),
+			DossierUUID:     dossier.UUID,
+			EtapePrecedente: dossier.Etape,
+			Etape:           "decision",
+			EffectuePar:     userUUID,
+			Commentaire:     "Décision : " + body.Decision + ". " + body.MotifDecision,
+		}).Error; err != nil {
+			return err
+		}
+
+		return changerStatutMigratoire(tx, &migrant, statut, models.ContexteStatut{
+			Motif:             "Décision " + body.Decision + " : " + body.MotifDecision,
+			ReferenceDecision: dossier.NumeroDossier,
+			EffectuePar:       userUUID,
+			DateEffet:         &now,
+		})
+	})
+
+	if err != nil {
+		return problemes.Echec("Failed to record decision", err)
+	}
+
+	db.Where("uuid = ?", uuid).Preload("Migrant").First(&dossier)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Decision recorded successfully",
+		"data":    dossier,
+	})
+}
+
+// =======================
+// NOTES
+// =======================
+
+// AddDossierNote - Ajouter une note de suivi
+func AddDossierNote(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	note := &models.DossierNote{}
+	if err := c.BodyParser(note); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(*note); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var count int64
+	db.Model(&models.Dossier{}).Where("uuid = ?", uuid).Count(&count)
+	if count == 0 {
+		return problemes.Introuvable("Case not found")
+	}
+
+	if !accederDossier(c, uuid) {
+		return unites.Refus(c)
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	note.UUID = utils.GenerateUUID()
+	note.DossierUUID = uuid
+	note.AuteurUUID = userUUID
+
+	if err := db.Create(note).Error; err != nil {
+		return problemes.Echec("Failed to add note", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Note added successfully",
+		"data":    note,
+	})
+}
+
+// DeleteDossierNote - Supprimer une note
+func DeleteDossierNote(c *fiber.Ctx) error {
+	db := database.DB
+
+	if !accederDossier(c, c.Params("uuid")) {
+		return unites.Refus(c)
+	}
+
+	result := db.Where("uuid = ? AND dossier_uuid = ?", c.Params("note_uuid"), c.Params("uuid")).
+		Delete(&models.DossierNote{})
+	if result.Error != nil {
+		return problemes.Echec("Failed to delete note", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return problemes.Introuvable("Note not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Note deleted successfully",
+		"data":    nil,
+	})
+}
+
+// =======================
+// AGENTS
+// =======================
+
+// AssignDossierAgent - Affecter un agent à un dossier
+func AssignDossierAgent(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	agent := &models.DossierAgent{}
+	if err := c.BodyParser(agent); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(*agent); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var count int64
+	db.Model(&models.Dossier{}).Where("uuid = ?", uuid).Count(&count)
+	if count == 0 {
+		return problemes.Introuvable("Case not found")
+	}
+
+	if !accederDossier(c, uuid) {
+		return unites.Refus(c)
+	}
+
+	db.Model(&models.User{}).Where("uuid = ?", agent.AgentUUID).Count(&count)
+	if count == 0 {
+		return problemes.Introuvable("Agent not found")
+	}
+
+	db.Model(&models.DossierAgent{}).Where("dossier_uuid = ? AND agent_uuid = ?", uuid, agent.AgentUUID).Count(&count)
+	if count > 0 {
+		return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "Agent is already assigned to this case")
+	}
+
+	agent.UUID = utils.GenerateUUID()
+	agent.DossierUUID = uuid
+	if agent.Role == "" {
+		agent.Role = "responsable"
+	}
+
+	if err := db.Create(agent).Error; err != nil {
+		return problemes.Echec("Failed to assign agent", err)
+	}
+
+	agent.AgentNom = nomsAgents([]string{agent.AgentUUID})[agent.AgentUUID]
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Agent assigned successfully",
+		"data":    agent,
+	})
+}
+
+// UnassignDossierAgent - Retirer un agent d'un dossier
+func UnassignDossierAgent(c *fiber.Ctx) error {
+	db := database.DB
+
+	if !accederDossier(c, c.Params("uuid")) {
+		return unites.Refus(c)
+	}
+
+	result := db.Where("dossier_uuid = ? AND agent_uuid = ?", c.Params("uuid"), c.Params("agent_uuid")).
+		Delete(&models.DossierAgent{})
+	if result.Error != nil {
+		return problemes.Echec("Failed to unassign agent", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return problemes.Introuvable("Agent is not assigned to this case")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Agent unassigned successfully",
+		"data":    nil,
+	})
+}
+
+// =======================
+// ÉCHÉANCES
+// =======================
+
+// GetEcheances - Dossiers ouverts dont l'échéance est dépassée ou arrive dans les prochains jours
+// GET /api/dossiers/echeances?days=7&agent_uuid=
+func GetEcheances(c *fiber.Ctx) error {
+	db := unites.Base(unites.PerimetreDe(c).ParMigrant("dossiers.migrant_uuid"))
+
+	days, err := strconv.Atoi(c.Query("days", "7"))
+	if err != nil || days < 0 {
+		days = 7
+	}
+	horizon := time.Now().AddDate(0, 0, days)
+
+	query := db.Model(&models.Dossier{}).
+		Where("etape <> ? AND date_limite IS NOT NULL AND date_limite <= ?", "cloture", horizon)
+	if agentUUID := c.Query("agent_uuid", ""); agentUUID != "" {
+		query = query.Where("dossiers.uuid IN (?)",
+			database.DB.Model(&models.DossierAgent{}).Select("dossier_uuid").Where("agent_uuid = ?", agentUUID))
+	}
+
+	var dossiers []models.Dossier
+	err = query.Preload("Migrant.Identite").
+		Preload("Agents").
+		Order("date_limite ASC").
+		Find(&dossiers).Error
+	if err != nil {
+		return problemes.Echec("Failed to fetch deadlines", err)
+	}
+
+	now := time.Now()
+	enRetard := 0
+	for _, dossier := range dossiers {
+		if dossier.DateLimite.Before(now) {
+			enRetard++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Deadlines retrieved successfully",
+		"data": fiber.Map{
+			"dossiers":  dossiers,
+			"en_retard": enRetard,
+			"a_venir":   len(dossiers) - enRetard,
+		},
+	})
+}
@@ -0,0 +1,110 @@
+package dossiers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+func TestTransitionAutorisee(t *testing.T) {
+	cas := []struct {
+		depuis, vers string
+		autorisee    bool
+	}{
+		{"enregistre", "entretien_planifie", true},
+		{"enregistre", "decision", false},
+		{"enregistre", "recours", false},
+		// Un entretien peut être reprogrammé
+		{"entretien_planifie", "entretien_planifie", true},
+		{"entretien_planifie", "decision", true},
+		{"decision", "recours", true},
+		{"decision", "entretien_planifie", false},
+		{"recours", "entretien_planifie", true},
+		{"recours", "decision", true},
+		{"cloture", "enregistre", false},
+		{"cloture", "cloture", false},
+		{"inconnue", "cloture", false},
+	}
+	for _, tc := range cas {
+		if obtenue := transitionAutorisee(tc.depuis, tc.vers); obtenue != tc.autorisee {
+			t.Errorf("%s -> %s : autorisée %v, attendu %v", tc.depuis, tc.vers, obtenue, tc.autorisee)
+		}
+	}
+	// Toute étape ouverte peut être clôturée
+	for depuis := range transitions {
+		if depuis != "cloture" && !transitionAutorisee(depuis, "cloture") {
+			t.Errorf("%s ne peut pas être clôturé", depuis)
+		}
+	}
+}
+
+func TestStatutApresDecision(t *testing.T) {
+	cas := []struct {
+		typeDossier, decision, statut string
+	}{
+		{"demande_asile", "accordee", "refugie"},
+		{"demande_asile", "rejetee", "irregulier"},
+		{"regularisation", "accordee", "regulier"},
+		{"regularisation", "rejetee", "irregulier"},
+		// Sans effet sur le statut migratoire
+		{"regroupement_familial", "accordee", ""},
+		{"retour_volontaire", "rejetee", ""},
+	}
+	for _, tc := range cas {
+		if statut := statutApresDecision(tc.typeDossier, tc.decision); statut != tc.statut {
+			t.Errorf("%s %s : statut %q, attendu %q", tc.typeDossier, tc.decision, statut, tc.statut)
+		}
+	}
+}
+
+func TestDelaiParDefaut(t *testing.T) {
+	for typeDossier, jours := range map[string]int{
+		"demande_asile":         90,
+		"regroupement_familial": 180,
+		"retour_volontaire":     30,
+		"regularisation":        60,
+	} {
+		if obtenu := delaiParDefaut(typeDossier); obtenu != jours {
+			t.Errorf("%s : %d jours, attendu %d", typeDossier, obtenu, jours)
+		}
+	}
+}
+
+func TestRecours(t *testing.T) {
+	decision := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	if limite := echeanceRecours("accordee", decision); limite != nil {
+		t.Errorf("échéance de recours %v pour une décision favorable, attendue aucune", limite)
+	}
+	limite := echeanceRecours("rejetee", decision)
+	if limite == nil || !limite.Equal(time.Date(2026, 4, 9, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("échéance de recours %v, attendue le 9 avril 2026 (30 jours)", limite)
+	}
+
+	cas := []struct {
+		nom     string
+		dossier models.Dossier
+		ref     time.Time
+		motif   string
+	}{
+		{"dans le délai", models.Dossier{Decision: "rejetee", DateLimiteRecours: limite}, decision.AddDate(0, 0, 29), ""},
+		{"dernier jour", models.Dossier{Decision: "rejetee", DateLimiteRecours: limite}, *limite, ""},
+		{"délai expiré", models.Dossier{Decision: "rejetee", DateLimiteRecours: limite}, limite.Add(time.Minute), "The appeal deadline has passed"},
+		{"décision favorable", models.Dossier{Decision: "accordee"}, decision, "Only rejected cases can be appealed"},
+		{"sans décision", models.Dossier{}, decision, "Only rejected cases can be appealed"},
+		// Décision enregistrée sans échéance de recours : pas de délai opposable
+		{"sans échéance", models.Dossier{Decision: "rejetee"}, decision.AddDate(1, 0, 0), ""},
+	}
+	for _, tc := range cas {
+		motif := recoursIrrecevable(tc.dossier, tc.ref)
+		if motif != tc.motif {
+			t.Errorf("%s : motif %q, attendu %q", tc.nom, motif, tc.motif)
+		}
+		// Le motif est renvoyé tel quel comme détail du problème
+		if motif != "" && problemes.Traduire(motif, problemes.Francais) == motif {
+			t.Errorf("%s : motif %q absent du catalogue des messages", tc.nom, motif)
+		}
+	}
+}
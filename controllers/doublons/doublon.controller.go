@@ -0,0 +1,515 @@
+package doublons
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/geolocation"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// errDejaRevue - la paire ou la fusion a changé d'état pendant le traitement
+// (revue ou annulation concurrente)
+var errDejaRevue = errors.New("already reviewed")
+
+// accederPaire contrôle l'accès aux deux identités d'une paire ou d'une fusion
+func accederPaire(c *fiber.Ctx, identiteA, identiteB string) bool {
+	return unites.AccederIdentiteUUID(c, identiteA) && unites.AccederIdentiteUUID(c, identiteB)
+}
+
+// =======================
+// DÉTECTION
+// =======================
+
+// ScanDoublons - Lancer la détection des doublons
+// POST /api/doublons/scan?identite_uuid=&seuil=0.75
+func ScanDoublons(c *fiber.Ctx) error {
+	seuil, err := strconv.ParseFloat(c.Query("seuil", ""), 64)
+	if err != nil || seuil <= 0 || seuil > 1 {
+		seuil = SeuilParDefaut
+	}
+
+	identiteUUID := c.Query("identite_uuid", "")
+	if identiteUUID != "" && !unites.AccederIdentiteUUID(c, identiteUUID) {
+		return unites.Refus(c)
+	}
+
+	nouveaux, err := DetecterDoublons(unites.PerimetreDe(c), identiteUUID, seuil)
+	if err != nil {
+		return problemes.Echec("Failed to scan for duplicates", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Duplicate scan completed",
+		"data": fiber.Map{
+			"nouveaux_candidats": len(nouveaux),
+			"seuil":              seuil,
+		},
+	})
+}
+
+// =======================
+// FILE DE REVUE
+// =======================
+
+// Champs des doublons candidats pouvant être filtrés, triés et sélectionnés
+var listeDoublons = requetes.Schema{
+	Table: "doublon_candidats",
+	Champs: map[string]requetes.Type{
+		"uuid":                 requetes.Texte,
+		"created_at":           requetes.Date,
+		"updated_at":           requetes.Date,
+		"identite_a_uuid":      requetes.Texte,
+		"identite_b_uuid":      requetes.Texte,
+		"score":                requetes.Nombre,
+		"score_nom":            requetes.Nombre,
+		"score_date_naissance": requetes.Nombre,
+		"score_lieu_naissance": requetes.Nombre,
+		"score_nationalite":    requetes.Nombre,
+		"biometries_communes":  requetes.Nombre,
+		"statut":               requetes.Texte,
+		"revise_par":           requetes.Texte,
+		"date_revue":           requetes.Date,
+	},
+	Relations: []string{"identite_a", "identite_b"},
+	TriDefaut: "-score,-created_at",
+}
+
+// GetPaginatedDoublons - Récupérer la file de revue des doublons
+func GetPaginatedDoublons(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeDoublons)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var doublons []models.DoublonCandidat
+
+	// Paires dont les deux identités sont dans le périmètre de l'utilisateur
+	p := unites.PerimetreDe(c)
+	query := db.Model(&models.DoublonCandidat{}).
+		Scopes(p.ParIdentite("doublon_candidats.identite_a_uuid"), p.ParIdentite("doublon_candidats.identite_b_uuid"))
+	if !liste.Filtree("statut") {
+		if statut := c.Query("statut", "en_attente"); statut != "" {
+			query = query.Where("doublon_candidats.statut = ?", statut)
+		}
+	}
+
+	data, pagination, err := liste.Executer(query.
+		Preload("IdentiteA").
+		Preload("IdentiteB"), &doublons)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch duplicate candidates", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Duplicate candidates retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// GetDoublon - Récupérer une paire candidate avec le détail des deux identités
+func GetDoublon(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var doublon models.DoublonCandidat
+	err := db.Where("uuid = ?", uuid).
+		Preload("IdentiteA.Migrants").
+		Preload("IdentiteA.Geolocalisations").
+		Preload("IdentiteB.Migrants").
+		Preload("IdentiteB.Geolocalisations").
+		First(&doublon).Error
+
+	if err != nil {
+		return problemes.Absent("Duplicate candidate not found", err)
+	}
+	if !accederPaire(c, doublon.IdentiteAUUID, doublon.IdentiteBUUID) {
+		return unites.Refus(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Duplicate candidate found",
+		"data":    doublon,
+	})
+}
+
+// RejectDoublon - Marquer une paire comme faux positif
+func RejectDoublon(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		Commentaire string `json:"commentaire"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	var doublon models.DoublonCandidat
+	if err := db.Where("uuid = ?", uuid).First(&doublon).Error; err != nil {
+		return problemes.Absent("Duplicate candidate not found", err)
+	}
+
+	if !accederPaire(c, doublon.IdentiteAUUID, doublon.IdentiteBUUID) {
+		return unites.Refus(c)
+	}
+
+	if doublon.Statut != "en_attente" {
+		return problemes.Invalide("Only pending candidates can be rejected")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+	resultat := db.Model(&doublon).Where("statut = ?", "en_attente").Updates(map[string]interface{}{
+		"statut":      "rejete",
+		"revise_par":  userUUID,
+		"date_revue":  &now,
+		"commentaire": body.Commentaire,
+	})
+	if resultat.Error != nil {
+		return problemes.Echec("Failed to reject duplicate candidate", resultat.Error)
+	}
+	if resultat.RowsAffected == 0 {
+		return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit,
+			"Only pending candidates can be rejected"))
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Duplicate candidate rejected",
+		"data":    doublon,
+	})
+}
+
+// =======================
+// FUSION
+// =======================
+
+// MergeDoublon - Fusionner une paire en conservant l'identité survivante
+// POST /api/doublons/merge/:uuid  {"survivante_uuid": "...", "commentaire": "..."}
+func MergeDoublon(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		SurvivanteUUID string `json:"survivante_uuid" validate:"required"`
+		Commentaire    string `json:"commentaire"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(body); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var doublon models.DoublonCandidat
+	if err := db.Where("uuid = ?", uuid).First(&doublon).Error; err != nil {
+		return problemes.Absent("Duplicate candidate not found", err)
+	}
+
+	if !accederPaire(c, doublon.IdentiteAUUID, doublon.IdentiteBUUID) {
+		return unites.Refus(c)
+	}
+
+	if doublon.Statut != "en_attente" {
+		return problemes.Invalide("Only pending candidates can be merged")
+	}
+
+	var absorbeeUUID string
+	switch body.SurvivanteUUID {
+	case doublon.IdentiteAUUID:
+		absorbeeUUID = doublon.IdentiteBUUID
+	case doublon.IdentiteBUUID:
+		absorbeeUUID = doublon.IdentiteAUUID
+	default:
+		return problemes.Invalide("survivante_uuid must be one of the two identities of the pair")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	fusion := models.FusionIdentite{
+		UUID:           utils.GenerateUUID(),
+		DoublonUUID:    doublon.UUID,
+		SurvivanteUUID: body.SurvivanteUUID,
+		AbsorbeeUUID:   absorbeeUUID,
+		Statut:         "active",
+		EffectuePar:    userUUID,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// La paire passe à l'état fusionné en premier et seulement si elle est encore en
+		// attente : sa ligne reste verrouillée jusqu'à la fin de la transaction, une
+		// fusion concurrente de la même paire ne la trouve plus en attente
+		now := time.Now()
+		revue := tx.Model(&doublon).Where("statut = ?", "en_attente").Updates(map[string]interface{}{
+			"statut":      "fusionne",
+			"revise_par":  userUUID,
+			"date_revue":  &now,
+			"commentaire": body.Commentaire,
+		})
+		if revue.Error != nil {
+			return revue.Error
+		}
+		if revue.RowsAffected == 0 {
+			return errDejaRevue
+		}
+
+		var absorbee models.Identite
+		if err := tx.Where("uuid = ?", absorbeeUUID).First(&absorbee).Error; err != nil {
+			return err
+		}
+		var survivante models.Identite
+		if err := tx.Where("uuid = ?", body.SurvivanteUUID).First(&survivante).Error; err != nil {
+			return err
+		}
+
+		instantane, err := json.Marshal(absorbee)
+		if err != nil {
+			return err
+		}
+		fusion.InstantaneAbsorbee = string(instantane)
+
+		// Enregistrements rattachés à l'identité absorbée
+		var migrantUUIDs, geoUUIDs, hitUUIDs []string
+		if err := tx.Model(&models.Migrant{}).Where("identite_uuid = ?", absorbeeUUID).Pluck("uuid", &migrantUUIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Geolocalisation{}).Where("identite_uuid = ?", absorbeeUUID).Pluck("uuid", &geoUUIDs).Error; err != nil {
+			return err
+		}
+		// Correspondances de liste de surveillance, sauf celles d'une entrée déjà
+		// signalée pour la survivante (une seule correspondance par identité et par entrée)
+		if err := tx.Model(&models.CorrespondanceSurveillance{}).
+			Where("identite_uuid = ?", absorbeeUUID).
+			Where("entree_uuid NOT IN (?)", tx.Unscoped().Model(&models.CorrespondanceSurveillance{}).
+				Where("identite_uuid = ?", body.SurvivanteUUID).Select("entree_uuid")).
+			Pluck("uuid", &hitUUIDs).Error; err != nil {
+			return err
+		}
+
+		migrantsJSON, _ := json.Marshal(migrantUUIDs)
+		geoJSON, _ := json.Marshal(geoUUIDs)
+		hitsJSON, _ := json.Marshal(hitUUIDs)
+		fusion.MigrantsDeplaces = string(migrantsJSON)
+		fusion.GeolocalisationsDeplacees = string(geoJSON)
+		fusion.HitsDeplaces = string(hitsJSON)
+
+		// Rattacher les enregistrements enfants à l'identité survivante
+		// (les biométries, motifs et alertes suivent leurs migrants)
+		if len(migrantUUIDs) > 0 {
+			if err := tx.Model(&models.Migrant{}).Where("uuid IN ?", migrantUUIDs).
+				Update("identite_uuid", body.SurvivanteUUID).Error; err != nil {
+				return err
+			}
+		}
+		if len(geoUUIDs) > 0 {
+			if err := tx.Model(&models.Geolocalisation{}).Where("uuid IN ?", geoUUIDs).
+				Update("identite_uuid", body.SurvivanteUUID).Error; err != nil {
+				return err
+			}
+		}
+		if len(hitUUIDs) > 0 {
+			if err := tx.Model(&models.CorrespondanceSurveillance{}).Where("uuid IN ?", hitUUIDs).
+				Update("identite_uuid", body.SurvivanteUUID).Error; err != nil {
+				return err
+			}
+		}
+
+		// Retirer l'identité absorbée (suppression logique, restaurable)
+		if err := tx.Delete(&absorbee).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&fusion).Error
+	})
+
+	if errors.Is(err, errDejaRevue) {
+		return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit,
+			"Only pending candidates can be merged"))
+	}
+	if err != nil {
+		return problemes.Echec("Failed to merge identities", err)
+	}
+
+	geolocation.InvalidateTileCache()
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Identities merged successfully",
+		"data":    fusion,
+	})
+}
+
+// Champs des fusions pouvant être filtrés, triés et sélectionnés
+var listeFusions = requetes.Schema{
+	Table: "fusions_identites",
+	Champs: map[string]requetes.Type{
+		"uuid":            requetes.Texte,
+		"created_at":      requetes.Date,
+		"updated_at":      requetes.Date,
+		"doublon_uuid":    requetes.Texte,
+		"survivante_uuid": requetes.Texte,
+		"absorbee_uuid":   requetes.Texte,
+		"statut":          requetes.Texte,
+		"effectue_par":    requetes.Texte,
+		"date_annulation": requetes.Date,
+		"annule_par":      requetes.Texte,
+	},
+	TriDefaut: "-created_at",
+}
+
+// GetPaginatedFusions - Historique des fusions
+func GetPaginatedFusions(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeFusions)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var fusions []models.FusionIdentite
+
+	query := db.Model(&models.FusionIdentite{}).
+		Scopes(unites.PerimetreDe(c).ParIdentite("fusions_identites.survivante_uuid"))
+	if statut := c.Query("statut", ""); statut != "" {
+		query = query.Where("fusions_identites.statut = ?", statut)
+	}
+
+	data, pagination, err := liste.Executer(query, &fusions)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch merges", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Merges retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// UndoFusion - Annuler une fusion : restaurer l'identité absorbée et lui rendre ses enregistrements
+func UndoFusion(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var fusion models.FusionIdentite
+	if err := db.Where("uuid = ?", uuid).First(&fusion).Error; err != nil {
+		return problemes.Absent("Merge not found", err)
+	}
+
+	if !accederPaire(c, fusion.SurvivanteUUID, fusion.AbsorbeeUUID) {
+		return unites.Refus(c)
+	}
+
+	if fusion.Statut != "active" {
+		return problemes.Invalide("This merge has already been undone")
+	}
+
+	var migrantUUIDs, geoUUIDs, hitUUIDs []string
+	if err := json.Unmarshal([]byte(fusion.MigrantsDeplaces), &migrantUUIDs); err != nil {
+		return problemes.Echec("Corrupted merge record", err)
+	}
+	if err := json.Unmarshal([]byte(fusion.GeolocalisationsDeplacees), &geoUUIDs); err != nil {
+		return problemes.Echec("Corrupted merge record", err)
+	}
+	// Vide pour les fusions antérieures au déplacement des correspondances
+	if fusion.HitsDeplaces != "" {
+		if err := json.Unmarshal([]byte(fusion.HitsDeplaces), &hitUUIDs); err != nil {
+			return problemes.Echec("Corrupted merge record", err)
+		}
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Même principe que la fusion : une annulation concurrente ne trouve plus la
+		// fusion active
+		now := time.Now()
+		annulation := tx.Model(&fusion).Where("statut = ?", "active").Updates(map[string]interface{}{
+			"statut":          "annulee",
+			"date_annulation": &now,
+			"annule_par":      userUUID,
+		})
+		if annulation.Error != nil {
+			return annulation.Error
+		}
+		if annulation.RowsAffected == 0 {
+			return errDejaRevue
+		}
+
+		// Restaurer l'identité absorbée
+		if err := tx.Unscoped().Model(&models.Identite{}).
+			Where("uuid = ?", fusion.AbsorbeeUUID).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		// Rendre les enregistrements déplacés, s'ils sont toujours rattachés à la survivante
+		if len(migrantUUIDs) > 0 {
+			if err := tx.Model(&models.Migrant{}).
+				Where("uuid IN ? AND identite_uuid = ?", migrantUUIDs, fusion.SurvivanteUUID).
+				Update("identite_uuid", fusion.AbsorbeeUUID).Error; err != nil {
+				return err
+			}
+		}
+		if len(geoUUIDs) > 0 {
+			if err := tx.Model(&models.Geolocalisation{}).
+				Where("uuid IN ? AND identite_uuid = ?", geoUUIDs, fusion.SurvivanteUUID).
+				Update("identite_uuid", fusion.AbsorbeeUUID).Error; err != nil {
+				return err
+			}
+		}
+		if len(hitUUIDs) > 0 {
+			if err := tx.Model(&models.CorrespondanceSurveillance{}).
+				Where("uuid IN ? AND identite_uuid = ?", hitUUIDs, fusion.SurvivanteUUID).
+				Update("identite_uuid", fusion.AbsorbeeUUID).Error; err != nil {
+				return err
+			}
+		}
+
+		// La paire retourne dans la file de revue
+		if fusion.DoublonUUID != "" {
+			return tx.Model(&models.DoublonCandidat{}).
+				Where("uuid = ?", fusion.DoublonUUID).
+				Updates(map[string]interface{}{
+					"statut":     "en_attente",
+					"revise_par": "",
+					"date_revue": nil,
+				}).Error
+		}
+		return nil
+	})
+
+	if errors.Is(err, errDejaRevue) {
+		return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit,
+			"This merge has already been undone"))
+	}
+	if err != nil {
+		return problemes.Echec("Failed to undo merge", err)
+	}
+
+	geolocation.InvalidateTileCache()
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Merge undone successfully",
+		"data":    fusion,
+	})
+}
@@ -0,0 +1,307 @@
+package doublons
+
+import (
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Pondération des critères dans le score global
+const (
+	poidsNom           = 0.40
+	poidsDateNaissance = 0.25
+	poidsLieuNaissance = 0.10
+	poidsNationalite   = 0.10
+	poidsBiometrie     = 0.15
+
+	// Au-delà de ce nombre d'identités, une date de naissance est trop commune pour être utile
+	tailleMaxBloc = 200
+
+	// Score minimal par défaut pour qu'une paire entre dans la file de revue
+	SeuilParDefaut = 0.75
+)
+
+// identiteResume contient les champs utilisés pour la comparaison
+type identiteResume struct {
+	UUID          string
+	Nom           string
+	Postnom       string
+	Prenom        string
+	DateNaissance string
+	LieuNaissance string
+	Nationalite   string
+}
+
+// scorePaire compare deux identités et retourne un candidat non enregistré
+func scorePaire(a, b identiteResume, biometriesCommunes int) models.DoublonCandidat {
+	tokensA := utils.NameTokens(a.Nom, a.Postnom, a.Prenom)
+	tokensB := utils.NameTokens(b.Nom, b.Postnom, b.Prenom)
+
+	// Score symétrique : moyenne des deux sens d'appariement
+	scoreNom := (utils.NameMatchScore(tokensA, tokensB) + utils.NameMatchScore(tokensB, tokensA)) / 2
+
+	scoreDate := 0.0
+	if a.DateNaissance != "" && a.DateNaissance == b.DateNaissance {
+		scoreDate = 1
+	} else if len(a.DateNaissance) >= 4 && len(b.DateNaissance) >= 4 && a.DateNaissance[:4] == b.DateNaissance[:4] {
+		// Même année : inversion jour/mois ou faute de saisie fréquente
+		scoreDate = 0.5
+	}
+
+	scoreLieu := utils.TrigramSimilarity(a.LieuNaissance, b.LieuNaissance)
+
+	scoreNationalite := 0.0
+	if utils.NormalizeName(a.Nationalite) == utils.NormalizeName(b.Nationalite) && a.Nationalite != "" {
+		scoreNationalite = 1
+	}
+
+	scoreBiometrie := 0.0
+	if biometriesCommunes > 0 {
+		scoreBiometrie = 1
+	}
+
+	score := scoreNom*poidsNom +
+		scoreDate*poidsDateNaissance +
+		scoreLieu*poidsLieuNaissance +
+		scoreNationalite*poidsNationalite +
+		scoreBiometrie*poidsBiometrie
+
+	// Une correspondance biométrique exacte suffit à signaler la paire
+	if biometriesCommunes > 0 && score < SeuilParDefaut {
+		score = SeuilParDefaut
+	}
+
+	// Ordonner la paire pour garantir son unicité
+	first, second := a.UUID, b.UUID
+	if second < first {
+		first, second = second, first
+	}
+
+	return models.DoublonCandidat{
+		IdentiteAUUID:      first,
+		IdentiteBUUID:      second,
+		Score:              arrondir(score),
+		ScoreNom:           arrondir(scoreNom),
+		ScoreDateNaissance: scoreDate,
+		ScoreLieuNaissance: arrondir(scoreLieu),
+		ScoreNationalite:   scoreNationalite,
+		BiometriesCommunes: biometriesCommunes,
+	}
+}
+
+func arrondir(v float64) float64 {
+	return float64(int(v*1000+0.5)) / 1000
+}
+
+// pairesCandidates présélectionne les paires d'identités actives à comparer : clés de
+// nom ou phonétiques proches (index trigrammes pg_trgm), même date de naissance si elle
+// est assez peu commune pour être discriminante, ou empreinte biométrique commune.
+// Les deux identités d'une paire sont dans le périmètre ; si identiteUUID est renseigné,
+// seules les paires qui l'impliquent sont retenues. Chaque paire est ordonnée (a < b).
+func pairesCandidates(db *gorm.DB, p *unites.Perimetre, identiteUUID string) ([][2]string, error) {
+	datesCommunes := db.Table("identites").
+		Select("date_naissance").
+		Where("deleted_at IS NULL").
+		Group("date_naissance").
+		Having("COUNT(*) > ?", tailleMaxBloc)
+
+	parNom := db.Table("identites a").
+		Select("a.uuid AS a, b.uuid AS b").
+		Joins("JOIN identites b ON b.uuid > a.uuid AND b.deleted_at IS NULL").
+		Scopes(p.ParIdentite("a.uuid"), p.ParIdentite("b.uuid")).
+		Where("a.deleted_at IS NULL").
+		Where("(a.cle_nom % b.cle_nom OR a.cle_phonetique % b.cle_phonetique OR (a.date_naissance = b.date_naissance AND a.date_naissance NOT IN (?)))", datesCommunes)
+	if identiteUUID != "" {
+		parNom = parNom.Where("(a.uuid = ? OR b.uuid = ?)", identiteUUID, identiteUUID)
+	}
+
+	parBiometrie := db.Table("biometries b1").
+		Select("DISTINCT m1.identite_uuid AS a, m2.identite_uuid AS b").
+		Joins("JOIN migrants m1 ON b1.migrant_uuid = m1.uuid AND m1.deleted_at IS NULL").
+		Joins("JOIN biometries b2 ON b2.empreinte_hash = b1.empreinte_hash AND b2.deleted_at IS NULL").
+		Joins("JOIN migrants m2 ON b2.migrant_uuid = m2.uuid AND m2.deleted_at IS NULL").
+		Scopes(p.ParIdentite("m1.identite_uuid"), p.ParIdentite("m2.identite_uuid")).
+		Where("b1.deleted_at IS NULL AND b1.empreinte_hash <> ''").
+		Where("m1.identite_uuid < m2.identite_uuid")
+	if identiteUUID != "" {
+		parBiometrie = parBiometrie.Where("(m1.identite_uuid = ? OR m2.identite_uuid = ?)", identiteUUID, identiteUUID)
+	}
+
+	vues := map[[2]string]bool{}
+	var paires [][2]string
+	for _, requete := range []*gorm.DB{parNom, parBiometrie} {
+		var lignes []struct{ A, B string }
+		if err := requete.Scan(&lignes).Error; err != nil {
+			return nil, err
+		}
+		for _, l := range lignes {
+			paire := [2]string{l.A, l.B}
+			if !vues[paire] {
+				vues[paire] = true
+				paires = append(paires, paire)
+			}
+		}
+	}
+	return paires, nil
+}
+
+// Nombre d'identités par requête IN lors du chargement des paires présélectionnées
+const tailleLot = 1000
+
+// lots découpe les identités en lots pour les requêtes IN
+func lots(uuids []string) [][]string {
+	var resultat [][]string
+	for len(uuids) > tailleLot {
+		resultat = append(resultat, uuids[:tailleLot])
+		uuids = uuids[tailleLot:]
+	}
+	if len(uuids) > 0 {
+		resultat = append(resultat, uuids)
+	}
+	return resultat
+}
+
+// chargerIdentites récupère sous forme résumée les identités actives données
+func chargerIdentites(db *gorm.DB, uuids []string) (map[string]identiteResume, error) {
+	index := map[string]identiteResume{}
+	for _, lot := range lots(uuids) {
+		var identites []identiteResume
+		err := db.Table("identites").
+			Select("uuid, nom, postnom, prenom, TO_CHAR(date_naissance, 'YYYY-MM-DD') as date_naissance, lieu_naissance, nationalite").
+			Where("deleted_at IS NULL AND uuid IN ?", lot).
+			Scan(&identites).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, identite := range identites {
+			index[identite.UUID] = identite
+		}
+	}
+	return index, nil
+}
+
+// chargerEmpreintes retourne, pour chaque identité donnée, l'ensemble de ses empreintes biométriques
+func chargerEmpreintes(db *gorm.DB, uuids []string) (map[string]map[string]bool, error) {
+	empreintes := map[string]map[string]bool{}
+	for _, lot := range lots(uuids) {
+		var rows []struct {
+			IdentiteUUID  string
+			EmpreinteHash string
+		}
+		err := db.Table("biometries b").
+			Select("m.identite_uuid, b.empreinte_hash").
+			Joins("JOIN migrants m ON b.migrant_uuid = m.uuid").
+			Where("b.deleted_at IS NULL AND m.deleted_at IS NULL AND b.empreinte_hash <> ''").
+			Where("m.identite_uuid IN ?", lot).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if empreintes[row.IdentiteUUID] == nil {
+				empreintes[row.IdentiteUUID] = map[string]bool{}
+			}
+			empreintes[row.IdentiteUUID][row.EmpreinteHash] = true
+		}
+	}
+	return empreintes, nil
+}
+
+// chargerConnues retourne les paires déjà enregistrées (quel que soit leur statut) dont
+// la première identité est l'une de celles données
+func chargerConnues(db *gorm.DB, uuids []string) (map[[2]string]bool, error) {
+	connues := map[[2]string]bool{}
+	for _, lot := range lots(uuids) {
+		var existants []models.DoublonCandidat
+		err := db.Select("identite_a_uuid, identite_b_uuid").
+			Where("identite_a_uuid IN ?", lot).
+			Find(&existants).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range existants {
+			connues[[2]string{e.IdentiteAUUID, e.IdentiteBUUID}] = true
+		}
+	}
+	return connues, nil
+}
+
+func compterCommunes(a, b map[string]bool) int {
+	count := 0
+	for hash := range a {
+		if b[hash] {
+			count++
+		}
+	}
+	return count
+}
+
+// DetecterDoublons compare les paires présélectionnées dans le périmètre (voir
+// pairesCandidates) et enregistre les nouvelles paires au-dessus du seuil.
+// Si identiteUUID est renseigné, seules les paires impliquant cette identité sont examinées.
+func DetecterDoublons(p *unites.Perimetre, identiteUUID string, seuil float64) ([]models.DoublonCandidat, error) {
+	db := database.DB
+
+	paires, err := pairesCandidates(db, p, identiteUUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(paires) == 0 {
+		return nil, nil
+	}
+
+	var uuids, premieres []string
+	vues := map[string]bool{}
+	for _, paire := range paires {
+		if !vues[paire[0]] {
+			premieres = append(premieres, paire[0])
+		}
+		for _, uuid := range paire {
+			if !vues[uuid] {
+				vues[uuid] = true
+				uuids = append(uuids, uuid)
+			}
+		}
+	}
+
+	index, err := chargerIdentites(db, uuids)
+	if err != nil {
+		return nil, err
+	}
+	empreintes, err := chargerEmpreintes(db, uuids)
+	if err != nil {
+		return nil, err
+	}
+	connues, err := chargerConnues(db, premieres)
+	if err != nil {
+		return nil, err
+	}
+
+	var nouveaux []models.DoublonCandidat
+	for _, paire := range paires {
+		a, okA := index[paire[0]]
+		b, okB := index[paire[1]]
+		// Identité supprimée (paire biométrique) ou paire déjà connue
+		if !okA || !okB || connues[paire] {
+			continue
+		}
+
+		candidat := scorePaire(a, b, compterCommunes(empreintes[a.UUID], empreintes[b.UUID]))
+		if candidat.Score < seuil {
+			continue
+		}
+		candidat.UUID = utils.GenerateUUID()
+		candidat.Statut = "en_attente"
+		nouveaux = append(nouveaux, candidat)
+	}
+
+	if len(nouveaux) > 0 {
+		if err := db.CreateInBatches(&nouveaux, 100).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return nouveaux, nil
+}
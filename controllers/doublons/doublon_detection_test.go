@@ -0,0 +1,165 @@
+package doublons
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+func TestScorePaire(t *testing.T) {
+	reference := identiteResume{
+		UUID: "b", Nom: "TSHIBANGU", Postnom: "MUKENDI", Prenom: "LEON",
+		DateNaissance: "1980-05-12", LieuNaissance: "Kananga", Nationalite: "Congolaise",
+	}
+	cas := []struct {
+		nom        string
+		autre      identiteResume
+		biometries int
+		// Score attendu au-dessus du seuil par défaut
+		signalee bool
+	}{
+		{
+			nom:      "variante phonétique",
+			autre:    identiteResume{UUID: "a", Nom: "CHIBANGU", Postnom: "MUKENDI", Prenom: "LEON", DateNaissance: "1980-05-12", LieuNaissance: "Kananga", Nationalite: "congolaise"},
+			signalee: true,
+		},
+		{
+			nom:   "homonyme d'une autre génération",
+			autre: identiteResume{UUID: "a", Nom: "TSHIBANGU", Postnom: "MUKENDI", Prenom: "LEON", DateNaissance: "2004-01-30", LieuNaissance: "Goma", Nationalite: "Rwandaise"},
+		},
+		{
+			nom:   "autre personne",
+			autre: identiteResume{UUID: "a", Nom: "KABILA", Postnom: "KABANGE", Prenom: "JOSEPH", DateNaissance: "1980-05-12", LieuNaissance: "Kananga", Nationalite: "Congolaise"},
+		},
+		{
+			// Une empreinte commune suffit à signaler la paire
+			nom:        "empreinte commune",
+			autre:      identiteResume{UUID: "a", Nom: "KABILA", Postnom: "KABANGE", Prenom: "JOSEPH"},
+			biometries: 1,
+			signalee:   true,
+		},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			candidat := scorePaire(reference, tc.autre, tc.biometries)
+			if (candidat.Score >= SeuilParDefaut) != tc.signalee {
+				t.Errorf("score %v (nom %v, date %v), signalée attendue : %v", candidat.Score, candidat.ScoreNom, candidat.ScoreDateNaissance, tc.signalee)
+			}
+			// La paire est ordonnée, quel que soit le sens de la comparaison
+			if candidat.IdentiteAUUID != "a" || candidat.IdentiteBUUID != "b" {
+				t.Errorf("paire %s/%s, attendue a/b", candidat.IdentiteAUUID, candidat.IdentiteBUUID)
+			}
+			if inverse := scorePaire(tc.autre, reference, tc.biometries); inverse.Score != candidat.Score {
+				t.Errorf("score %v dans un sens, %v dans l'autre", candidat.Score, inverse.Score)
+			}
+		})
+	}
+}
+
+func TestLots(t *testing.T) {
+	uuids := make([]string, 2*tailleLot+1)
+	decoupe := lots(uuids)
+	if len(decoupe) != 3 || len(decoupe[0]) != tailleLot || len(decoupe[2]) != 1 {
+		t.Errorf("%d lots, attendus 3 dont un dernier d'une identité", len(decoupe))
+	}
+	if lots(nil) != nil {
+		t.Error("aucun lot attendu sans identité")
+	}
+}
+
+func TestScanDoublonsRestreintAuPerimetre(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID:      "unite-" + code,
+			Code:      code,
+			Nom:       "Direction " + code,
+			TypeUnite: "direction_provinciale",
+			Actif:     true,
+			Chemin:    "/unite-" + code + "/",
+		}
+		if err := db.Create(&unite).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	naissance := time.Date(1980, 5, 12, 0, 0, 0, 0, time.UTC)
+	identites := []models.Identite{
+		{UUID: "a1", Nom: "TSHIBANGU", Postnom: "MUKENDI", Prenom: "LEON", DateNaissance: naissance, LieuNaissance: "Kananga", Nationalite: "Congolaise", UniteUUID: "unite-A"},
+		{UUID: "a2", Nom: "CHIBANGU", Postnom: "MUKENDI", Prenom: "LEON", DateNaissance: naissance, LieuNaissance: "Kananga", Nationalite: "Congolaise", UniteUUID: "unite-A"},
+		{UUID: "a3", Nom: "KABILA", Postnom: "KABANGE", Prenom: "JOSEPH", DateNaissance: naissance.AddDate(11, 0, 0), LieuNaissance: "Lubumbashi", Nationalite: "Congolaise", UniteUUID: "unite-A"},
+		{UUID: "b1", Nom: "SHIBANGOU", Postnom: "MUKENDI", Prenom: "LEON", DateNaissance: naissance, LieuNaissance: "Kananga", Nationalite: "Congolaise", UniteUUID: "unite-B"},
+	}
+	for i := range identites {
+		identites[i].NumeroPasseport = "OP-" + identites[i].UUID
+		if err := db.Create(&identites[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	agent := basetest.Utilisateur(t, db, "Agent", "unite-A")
+	admin := basetest.Administrateur(t, db)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Post("/scan", ScanDoublons)
+	scanner := func(requete string) (int, int) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("POST", "/scan?"+requete, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var corps struct {
+			Data struct {
+				NouveauxCandidats int `json:"nouveaux_candidats"`
+			} `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&corps)
+		return resp.StatusCode, corps.Data.NouveauxCandidats
+	}
+	paires := func() map[[2]string]bool {
+		t.Helper()
+		var candidats []models.DoublonCandidat
+		if err := db.Find(&candidats).Error; err != nil {
+			t.Fatal(err)
+		}
+		resultat := map[[2]string]bool{}
+		for _, c := range candidats {
+			resultat[[2]string{c.IdentiteAUUID, c.IdentiteBUUID}] = true
+		}
+		return resultat
+	}
+
+	// Identité d'une autre unité : refusée
+	if statut, _ := scanner("token=" + agent + "&identite_uuid=b1"); statut != fiber.StatusForbidden {
+		t.Errorf("scan de b1 par l'agent de A : statut %d, attendu 403", statut)
+	}
+
+	// L'agent de A ne rapproche que les identités de son unité
+	if statut, nouveaux := scanner("token=" + agent); statut != fiber.StatusOK || nouveaux != 1 {
+		t.Fatalf("scan de l'agent : statut %d, %d paire(s), attendu 200 et 1", statut, nouveaux)
+	}
+	if p := paires(); len(p) != 1 || !p[[2]string{"a1", "a2"}] {
+		t.Errorf("paires après le scan de l'agent : %v, attendu a1/a2", p)
+	}
+
+	// Le scan national ajoute les paires avec b1, sans reprendre la paire connue
+	if statut, nouveaux := scanner("token=" + admin); statut != fiber.StatusOK || nouveaux != 2 {
+		t.Fatalf("scan national : statut %d, %d paire(s), attendu 200 et 2", statut, nouveaux)
+	}
+	p := paires()
+	for _, paire := range [][2]string{{"a1", "a2"}, {"a1", "b1"}, {"a2", "b1"}} {
+		if !p[paire] {
+			t.Errorf("paire %v absente", paire)
+		}
+	}
+	if len(p) != 3 {
+		t.Errorf("%d paires, attendu 3 : %v", len(p), p)
+	}
+}
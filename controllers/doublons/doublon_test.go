@@ -0,0 +1,184 @@
+package doublons
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pairePreparee crée deux identités en double, une correspondance de liste de
+// surveillance sur l'identité qui sera absorbée et la paire candidate
+func pairePreparee(t *testing.T, db *gorm.DB) models.DoublonCandidat {
+	t.Helper()
+
+	for _, uuid := range []string{"identite-a", "identite-b"} {
+		identite := models.Identite{
+			UUID:            uuid,
+			Nom:             "CHIBANGU",
+			Postnom:         "MUKENDI",
+			Prenom:          "LEON",
+			DateNaissance:   time.Date(1980, 5, 12, 0, 0, 0, 0, time.UTC),
+			Sexe:            "M",
+			NumeroPasseport: "OP-" + uuid,
+		}
+		if err := db.Create(&identite).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.ListeSurveillance{UUID: "liste", Nom: "Recherchés", TypeListe: "personnes_recherchees"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.EntreeSurveillance{UUID: "entree", ListeUUID: "liste", NomComplet: "CHIBANGU LEON"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	hit := models.CorrespondanceSurveillance{
+		UUID:               "hit-b",
+		IdentiteUUID:       "identite-b",
+		EntreeUUID:         "entree",
+		ListeUUID:          "liste",
+		TypeCorrespondance: "nom_date_naissance",
+		Statut:             "en_attente",
+	}
+	if err := db.Omit(clause.Associations).Create(&hit).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	doublon := models.DoublonCandidat{UUID: "paire", IdentiteAUUID: "identite-a", IdentiteBUUID: "identite-b", Score: 0.95, Statut: "en_attente"}
+	if err := db.Omit(clause.Associations).Create(&doublon).Error; err != nil {
+		t.Fatal(err)
+	}
+	return doublon
+}
+
+func TestMergeDoublonConcurrentMergesOnlyOnce(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	token := basetest.Administrateur(t, db)
+	pairePreparee(t, db)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Post("/merge/:uuid", MergeDoublon)
+
+	const essais = 5
+	statuts := make(chan int, essais)
+	var wg sync.WaitGroup
+	for i := 0; i < essais; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "/merge/paire?token="+token, strings.NewReader(`{"survivante_uuid":"identite-a"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			statuts <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statuts)
+
+	reussies := 0
+	for statut := range statuts {
+		switch statut {
+		case fiber.StatusOK:
+			reussies++
+		case fiber.StatusBadRequest, fiber.StatusConflict:
+		default:
+			t.Errorf("statut inattendu %d", statut)
+		}
+	}
+	if reussies != 1 {
+		t.Fatalf("%d fusions réussies, attendu 1", reussies)
+	}
+
+	var fusions int64
+	db.Model(&models.FusionIdentite{}).Count(&fusions)
+	if fusions != 1 {
+		t.Errorf("%d fusions enregistrées, attendu 1", fusions)
+	}
+}
+
+func TestMergeAndUndoMoveWatchlistHits(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	token := basetest.Administrateur(t, db)
+	pairePreparee(t, db)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Post("/merge/:uuid", MergeDoublon)
+	app.Post("/undo/:uuid", UndoFusion)
+
+	req := httptest.NewRequest("POST", "/merge/paire?token="+token, strings.NewReader(`{"survivante_uuid":"identite-a"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("fusion: statut %d", resp.StatusCode)
+	}
+
+	var hit models.CorrespondanceSurveillance
+	db.Where("uuid = ?", "hit-b").First(&hit)
+	if hit.IdentiteUUID != "identite-a" {
+		t.Fatalf("correspondance rattachée à %s après fusion, attendu identite-a", hit.IdentiteUUID)
+	}
+
+	var fusion models.FusionIdentite
+	if err := db.First(&fusion).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err = app.Test(httptest.NewRequest("POST", "/undo/"+fusion.UUID+"?token="+token, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attendu := fiber.StatusOK
+		if i > 0 {
+			attendu = fiber.StatusBadRequest
+		}
+		if resp.StatusCode != attendu {
+			t.Fatalf("annulation %d: statut %d, attendu %d", i+1, resp.StatusCode, attendu)
+		}
+	}
+
+	db.Where("uuid = ?", "hit-b").First(&hit)
+	if hit.IdentiteUUID != "identite-b" {
+		t.Errorf("correspondance rattachée à %s après annulation, attendu identite-b", hit.IdentiteUUID)
+	}
+	var doublon models.DoublonCandidat
+	db.Where("uuid = ?", "paire").First(&doublon)
+	if doublon.Statut != "en_attente" {
+		t.Errorf("paire au statut %s après annulation, attendu en_attente", doublon.Statut)
+	}
+}
+
+func TestGetDoublonOutOfScope(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	pairePreparee(t, db)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/get/:uuid", GetDoublon)
+
+	// Sans jeton, l'utilisateur n'a aucune unité : la paire est hors périmètre
+	resp, err := app.Test(httptest.NewRequest("GET", "/get/paire", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Errorf("statut %d, attendu 403", resp.StatusCode)
+	}
+}
@@ -0,0 +1,357 @@
+package frontieres
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// =======================
+// PASSAGES
+// =======================
+
+// filtrerPassages applique les filtres de période, de sens et de poste
+func filtrerPassages(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if sens := c.Query("sens", ""); sens != "" {
+		query = query.Where("crossings.sens = ?", sens)
+	}
+	if posteUUID := c.Query("poste_uuid", ""); posteUUID != "" {
+		query = query.Where("crossings.poste_uuid = ?", posteUUID)
+	}
+	if modeTransport := c.Query("mode_transport", ""); modeTransport != "" {
+		query = query.Where("crossings.mode_transport = ?", modeTransport)
+	}
+	if startDate := c.Query("start_date", ""); startDate != "" {
+		if parsed, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("crossings.date_passage >= ?", parsed)
+		}
+	}
+	if endDate := c.Query("end_date", ""); endDate != "" {
+		if parsed, err := time.Parse("2006-01-02", endDate); err == nil {
+			query = query.Where("crossings.date_passage < ?", parsed.AddDate(0, 0, 1))
+		}
+	}
+	return query
+}
+
+// Champs des passages pouvant être filtrés, triés et sélectionnés
+var listePassages = requetes.Schema{
+	Table: "crossings",
+	Champs: map[string]requetes.Type{
+		"uuid":             requetes.Texte,
+		"created_at":       requetes.Date,
+		"updated_at":       requetes.Date,
+		"migrant_uuid":     requetes.Texte,
+		"poste_uuid":       requetes.Texte,
+		"sens":             requetes.Texte,
+		"date_passage":     requetes.Date,
+		"type_document":    requetes.Texte,
+		"numero_document":  requetes.Texte,
+		"mode_transport":   requetes.Texte,
+		"pays_provenance":  requetes.Texte,
+		"pays_destination": requetes.Texte,
+		"agent_uuid":       requetes.Texte,
+	},
+	Relations: []string{"migrant", "poste"},
+	TriDefaut: "-date_passage",
+}
+
+// paginerPassages exécute une requête de passages paginée
+func paginerPassages(c *fiber.Ctx, query *gorm.DB, message string) error {
+	liste, erreurs := requetes.Lire(c, listePassages)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var passages []models.Passage
+
+	query = filtrerPassages(query.Scopes(unites.PerimetreDe(c).ParMigrant("crossings.migrant_uuid")), c)
+
+	data, pagination, err := liste.Executer(query.
+		Preload("Migrant.Identite").
+		Preload("Poste"), &passages)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch crossings", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    message,
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// Paginate - Récupérer les passages avec pagination et filtres
+func GetPaginatedPassages(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Passage{})
+
+	if search := c.Query("search", ""); search != "" {
+		query = query.Joins("LEFT JOIN migrants ON migrants.uuid = crossings.migrant_uuid").
+			Where("migrants.numero_identifiant ILIKE ? OR crossings.numero_document ILIKE ?",
+				"%"+search+"%", "%"+search+"%")
+	}
+
+	return paginerPassages(c, query, "Crossings retrieved successfully")
+}
+
+// GetMigrantPassages - Passages d'un migrant
+func GetMigrantPassages(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Passage{}).Where("crossings.migrant_uuid = ?", c.Params("uuid"))
+	return paginerPassages(c, query, "Migrant crossings retrieved successfully")
+}
+
+// GetPostePassages - Passages enregistrés à un poste
+func GetPostePassages(c *fiber.Ctx) error {
+	query := database.DB.Model(&models.Passage{}).Where("crossings.poste_uuid = ?", c.Params("uuid"))
+	return paginerPassages(c, query, "Border post crossings retrieved successfully")
+}
+
+// Get one data
+func GetPassage(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var passage models.Passage
+
+	err := db.Where("uuid = ?", uuid).
+		Preload("Migrant.Identite").
+		Preload("Poste").
+		First(&passage).Error
+
+	if err != nil {
+		return problemes.Absent("Crossing not found", err)
+	}
+
+	if !unites.AccederMigrant(c, passage.MigrantUUID, "passage", passage.UUID) {
+		return unites.Refus(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Crossing found",
+		"data":    passage,
+	})
+}
+
+// Avance tolérée de l'horloge du poste sur celle du serveur
+const toleranceHorloge = time.Hour
+
+// passageFutur indique si le passage est daté au-delà de la tolérance d'horloge
+func passageFutur(passage models.Passage, ref time.Time) bool {
+	return passage.DatePassage.After(ref.Add(toleranceHorloge))
+}
+
+// actualiseEntree indique si le passage devient la dernière entrée connue du migrant :
+// une entrée au moins aussi récente que celle déjà enregistrée
+func actualiseEntree(migrant models.Migrant, passage models.Passage) bool {
+	return passage.Sens == "entree" && (migrant.DateEntree == nil || !migrant.DateEntree.After(passage.DatePassage))
+}
+
+// Create data - enregistre le passage et, pour une entrée plus récente,
+// met à jour DateEntree et PointEntree du migrant
+func CreatePassage(c *fiber.Ctx) error {
+	db := database.DB
+	passage := &models.Passage{}
+
+	if err := c.BodyParser(passage); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if passage.DatePassage.IsZero() {
+		passage.DatePassage = time.Now()
+	}
+
+	if err := utils.ValidateStruct(*passage); err != nil {
+		return problemes.Validation(err)
+	}
+
+	if passageFutur(*passage, time.Now()) {
+		return problemes.Invalide("date_passage cannot be in the future")
+	}
+
+	var migrant models.Migrant
+	if err := db.Where("uuid = ?", passage.MigrantUUID).First(&migrant).Error; err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	var poste models.PosteFrontiere
+	if err := db.Where("uuid = ?", passage.PosteUUID).First(&poste).Error; err != nil {
+		return problemes.Absent("Border post not found", err)
+	}
+	if !poste.Actif {
+		return problemes.Invalide("Border post is not active")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	passage.UUID = utils.GenerateUUID()
+	if passage.AgentUUID == "" {
+		passage.AgentUUID = userUUID
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Migrant", "Poste").Create(passage).Error; err != nil {
+			return err
+		}
+
+		if !actualiseEntree(migrant, *passage) {
+			return nil
+		}
+
+		tx = models.AvecContexteStatut(tx, models.ContexteStatut{
+			Motif:       "Entrée par le poste " + poste.Nom,
+			EffectuePar: userUUID,
+			DateEffet:   &passage.DatePassage,
+		})
+		return tx.Model(&migrant).Updates(map[string]interface{}{
+			"date_entree":  passage.DatePassage,
+			"point_entree": poste.Nom,
+		}).Error
+	})
+
+	if err != nil {
+		return problemes.Echec("Failed to record crossing", err)
+	}
+
+	passage.Poste = poste
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Crossing recorded successfully",
+		"data":    passage,
+	})
+}
+
+// Delete data
+func DeletePassage(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var passage models.Passage
+	if err := db.Where("uuid = ?", uuid).First(&passage).Error; err != nil {
+		return problemes.Absent("Crossing not found", err)
+	}
+
+	if !unites.AccederMigrant(c, passage.MigrantUUID, "passage", passage.UUID) {
+		return unites.Refus(c)
+	}
+
+	if err := db.Delete(&passage).Error; err != nil {
+		return problemes.Echec("Failed to delete crossing", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Crossing deleted successfully",
+		"data":    nil,
+	})
+}
+
+// =======================
+// STATISTIQUES
+// =======================
+
+// FluxJournalier représente les entrées et sorties d'un poste sur une journée
+type FluxJournalier struct {
+	Date      string `json:"date"`
+	PosteUUID string `json:"poste_uuid"`
+	PosteNom  string `json:"poste_nom"`
+	Province  string `json:"province"`
+	Entrees   int64  `json:"entrees"`
+	Sorties   int64  `json:"sorties"`
+	Solde     int64  `json:"solde"`
+}
+
+// TotalPoste représente les entrées et sorties d'un poste sur la période
+type TotalPoste struct {
+	PosteUUID string `json:"poste_uuid"`
+	PosteNom  string `json:"poste_nom"`
+	Province  string `json:"province"`
+	Entrees   int64  `json:"entrees"`
+	Sorties   int64  `json:"sorties"`
+	Solde     int64  `json:"solde"`
+}
+
+// totaliserFlux calcule le solde de chaque journée et les totaux par poste, dans
+// l'ordre de première apparition des postes
+func totaliserFlux(flux []FluxJournalier) []TotalPoste {
+	index := map[string]int{}
+	totaux := []TotalPoste{}
+	for i := range flux {
+		flux[i].Solde = flux[i].Entrees - flux[i].Sorties
+
+		j, ok := index[flux[i].PosteUUID]
+		if !ok {
+			j = len(totaux)
+			index[flux[i].PosteUUID] = j
+			totaux = append(totaux, TotalPoste{
+				PosteUUID: flux[i].PosteUUID,
+				PosteNom:  flux[i].PosteNom,
+				Province:  flux[i].Province,
+			})
+		}
+		totaux[j].Entrees += flux[i].Entrees
+		totaux[j].Sorties += flux[i].Sorties
+		totaux[j].Solde += flux[i].Solde
+	}
+	return totaux
+}
+
+// GetFluxJournaliers - Flux quotidiens par poste (30 derniers jours par défaut)
+// GET /api/crossings/stats/daily?poste_uuid=&province=&start_date=&end_date=
+func GetFluxJournaliers(c *fiber.Ctx) error {
+	db := unites.Base(unites.PerimetreDe(c).ParMigrant("crossings.migrant_uuid"))
+
+	// Période par défaut : 30 derniers jours
+	if c.Query("start_date", "") == "" && c.Query("end_date", "") == "" {
+		c.Request().URI().QueryArgs().Set("start_date", time.Now().AddDate(0, 0, -29).Format("2006-01-02"))
+	}
+
+	query := filtrerPassages(db.Table("crossings"), c).
+		Select(`TO_CHAR(crossings.date_passage, 'YYYY-MM-DD') as date,
+			crossings.poste_uuid,
+			p.nom as poste_nom,
+			p.province,
+			SUM(CASE WHEN crossings.sens = 'entree' THEN 1 ELSE 0 END) as entrees,
+			SUM(CASE WHEN crossings.sens = 'sortie' THEN 1 ELSE 0 END) as sorties`).
+		Joins("JOIN border_posts p ON p.uuid = crossings.poste_uuid").
+		Where("crossings.deleted_at IS NULL")
+	if province := c.Query("province", ""); province != "" {
+		query = query.Where("p.province = ?", province)
+	}
+
+	var flux []FluxJournalier
+	err := query.
+		Group("date, crossings.poste_uuid, p.nom, p.province").
+		Order("date ASC, p.nom ASC").
+		Scan(&flux).Error
+	if err != nil {
+		return problemes.Echec("Failed to compute daily flows", err)
+	}
+
+	totaux := totaliserFlux(flux)
+
+	if flux == nil {
+		flux = []FluxJournalier{}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Daily flows retrieved successfully",
+		"data": fiber.Map{
+			"flux":         flux,
+			"totaux_poste": totaux,
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package frontieres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+func TestPassageRules(t *testing.T) {
+	maintenant := time.Date(2026, 5, 20, 12, 0, 0, 0, time.UTC)
+	precedente := maintenant.AddDate(0, -2, 0)
+
+	cas := []struct {
+		nom       string
+		passage   models.Passage
+		entree    *time.Time
+		futur     bool
+		actualise bool
+	}{
+		{nom: "première entrée", passage: models.Passage{Sens: "entree", DatePassage: maintenant}, actualise: true},
+		{nom: "entrée plus récente", passage: models.Passage{Sens: "entree", DatePassage: maintenant}, entree: &precedente, actualise: true},
+		{nom: "même date", passage: models.Passage{Sens: "entree", DatePassage: precedente}, entree: &precedente, actualise: true},
+		// Saisie tardive d'un passage antérieur à la dernière entrée connue
+		{nom: "entrée ancienne", passage: models.Passage{Sens: "entree", DatePassage: precedente.AddDate(0, 0, -1)}, entree: &precedente},
+		{nom: "sortie", passage: models.Passage{Sens: "sortie", DatePassage: maintenant}, entree: &precedente},
+		{nom: "horloge du poste en avance", passage: models.Passage{Sens: "sortie", DatePassage: maintenant.Add(59 * time.Minute)}},
+		{nom: "passage futur", passage: models.Passage{Sens: "entree", DatePassage: maintenant.Add(61 * time.Minute)}, futur: true, actualise: true},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			if futur := passageFutur(tc.passage, maintenant); futur != tc.futur {
+				t.Errorf("passage futur : %v, attendu %v", futur, tc.futur)
+			}
+			migrant := models.Migrant{DateEntree: tc.entree}
+			if actualise := actualiseEntree(migrant, tc.passage); actualise != tc.actualise {
+				t.Errorf("entrée du migrant actualisée : %v, attendu %v", actualise, tc.actualise)
+			}
+		})
+	}
+}
+
+func TestPassageValidation(t *testing.T) {
+	valide := models.Passage{MigrantUUID: "m", PosteUUID: "p", Sens: "entree", DatePassage: time.Now(), ModeTransport: "pirogue"}
+	if err := utils.ValidateStruct(valide); err != nil {
+		t.Fatalf("passage valide refusé : %v", err)
+	}
+	for nom, modifier := range map[string]func(*models.Passage){
+		"sens inconnu":      func(p *models.Passage) { p.Sens = "transit" },
+		"sans poste":        func(p *models.Passage) { p.PosteUUID = "" },
+		"transport inconnu": func(p *models.Passage) { p.ModeTransport = "helicoptere" },
+	} {
+		passage := valide
+		modifier(&passage)
+		if err := utils.ValidateStruct(passage); err == nil {
+			t.Errorf("%s : passage accepté", nom)
+		}
+	}
+}
+
+func TestTotaliserFlux(t *testing.T) {
+	flux := []FluxJournalier{
+		{Date: "2026-05-01", PosteUUID: "kasumbalesa", PosteNom: "Kasumbalesa", Province: "Haut-Katanga", Entrees: 40, Sorties: 25},
+		{Date: "2026-05-01", PosteUUID: "goma", PosteNom: "Grande Barrière", Province: "Nord-Kivu", Entrees: 10, Sorties: 30},
+		{Date: "2026-05-02", PosteUUID: "kasumbalesa", PosteNom: "Kasumbalesa", Province: "Haut-Katanga", Entrees: 5, Sorties: 0},
+	}
+
+	totaux := totaliserFlux(flux)
+
+	for i, solde := range []int64{15, -20, 5} {
+		if flux[i].Solde != solde {
+			t.Errorf("solde du %s à %s : %d, attendu %d", flux[i].Date, flux[i].PosteNom, flux[i].Solde, solde)
+		}
+	}
+	attendus := []TotalPoste{
+		{PosteUUID: "kasumbalesa", PosteNom: "Kasumbalesa", Province: "Haut-Katanga", Entrees: 45, Sorties: 25, Solde: 20},
+		{PosteUUID: "goma", PosteNom: "Grande Barrière", Province: "Nord-Kivu", Entrees: 10, Sorties: 30, Solde: -20},
+	}
+	if len(totaux) != len(attendus) {
+		t.Fatalf("%d postes, attendu %d", len(totaux), len(attendus))
+	}
+	for i := range attendus {
+		if totaux[i] != attendus[i] {
+			t.Errorf("total %d : %+v, attendu %+v", i, totaux[i], attendus[i])
+		}
+	}
+	if totaux := totaliserFlux(nil); totaux == nil || len(totaux) != 0 {
+		t.Errorf("sans flux : %v, attendu une liste vide", totaux)
+	}
+}
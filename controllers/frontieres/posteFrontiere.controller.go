@@ -0,0 +1,239 @@
+package frontieres
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// =======================
+// POSTES FRONTIÈRES
+// =======================
+
+// Champs des postes frontières pouvant être filtrés, triés et sélectionnés
+var listePostes = requetes.Schema{
+	Table: "border_posts",
+	Champs: map[string]requetes.Type{
+		"uuid":            requetes.Texte,
+		"created_at":      requetes.Date,
+		"updated_at":      requetes.Date,
+		"code":            requetes.Texte,
+		"nom":             requetes.Texte,
+		"type_poste":      requetes.Texte,
+		"province":        requetes.Texte,
+		"ville":           requetes.Texte,
+		"pays_limitrophe": requetes.Texte,
+		"latitude":        requetes.Nombre,
+		"longitude":       requetes.Nombre,
+		"actif":           requetes.Booleen,
+	},
+	TriDefaut: "province,nom",
+}
+
+// Paginate - Récupérer les postes frontières avec pagination et filtres
+func GetPaginatedPostes(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listePostes)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	search := c.Query("search", "")
+
+	var postes []models.PosteFrontiere
+
+	query := db.Model(&models.PosteFrontiere{})
+
+	if search != "" {
+		query = query.Where("code ILIKE ? OR nom ILIKE ? OR province ILIKE ? OR ville ILIKE ? OR pays_limitrophe ILIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+	if province := c.Query("province", ""); province != "" {
+		query = query.Where("province = ?", province)
+	}
+	if typePoste := c.Query("type_poste", ""); typePoste != "" {
+		query = query.Where("type_poste = ?", typePoste)
+	}
+
+	data, pagination, err := liste.Executer(query, &postes)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch border posts", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Border posts retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// Query all data
+func GetAllPostes(c *fiber.Ctx) error {
+	db := database.DB
+	var postes []models.PosteFrontiere
+
+	liste, erreurs := requetes.Lire(c, listePostes)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	query := db.Model(&models.PosteFrontiere{})
+	if c.Query("actif", "") == "true" {
+		query = query.Where("actif = ?", true)
+	}
+
+	data, err := liste.Tout(query, &postes)
+	if err != nil {
+		return problemes.Echec("Failed to fetch border posts", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "All border posts",
+		"data":    data,
+	})
+}
+
+// Get one data
+func GetPoste(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var poste models.PosteFrontiere
+
+	if err := db.Where("uuid = ?", uuid).First(&poste).Error; err != nil {
+		return problemes.Absent("Border post not found", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Border post found",
+		"data":    poste,
+	})
+}
+
+// Create data
+func CreatePoste(c *fiber.Ctx) error {
+	poste := &models.PosteFrontiere{}
+
+	if err := c.BodyParser(poste); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(*poste); err != nil {
+		return problemes.Validation(err)
+	}
+
+	poste.UUID = utils.GenerateUUID()
+	poste.Actif = true
+	poste.Passages = nil
+
+	if err := database.DB.Create(poste).Error; err != nil {
+		return problemes.Echec("Failed to create border post", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Border post created successfully",
+		"data":    poste,
+	})
+}
+
+// Update data
+func UpdatePoste(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var updateData struct {
+		Nom            string   `json:"nom"`
+		TypePoste      string   `json:"type_poste" validate:"omitempty,oneof=terrestre fluvial lacustre aeroport ferroviaire"`
+		Province       string   `json:"province"`
+		Ville          string   `json:"ville"`
+		PaysLimitrophe string   `json:"pays_limitrophe"`
+		Latitude       *float64 `json:"latitude" validate:"omitempty,latitude"`
+		Longitude      *float64 `json:"longitude" validate:"omitempty,longitude"`
+		Actif          *bool    `json:"actif"`
+	}
+
+	if err := c.BodyParser(&updateData); err != nil {
+		return problemes.Invalide("Review your input").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(updateData); err != nil {
+		return problemes.Validation(err)
+	}
+
+	poste := new(models.PosteFrontiere)
+	if err := db.Where("uuid = ?", uuid).First(&poste).Error; err != nil {
+		return problemes.Absent("Border post not found", err)
+	}
+
+	updates := map[string]interface{}{}
+	if updateData.Nom != "" {
+		updates["nom"] = updateData.Nom
+	}
+	if updateData.TypePoste != "" {
+		updates["type_poste"] = updateData.TypePoste
+	}
+	if updateData.Province != "" {
+		updates["province"] = updateData.Province
+	}
+	if updateData.Ville != "" {
+		updates["ville"] = updateData.Ville
+	}
+	if updateData.PaysLimitrophe != "" {
+		updates["pays_limitrophe"] = updateData.PaysLimitrophe
+	}
+	if updateData.Latitude != nil {
+		updates["latitude"] = *updateData.Latitude
+	}
+	if updateData.Longitude != nil {
+		updates["longitude"] = *updateData.Longitude
+	}
+	if updateData.Actif != nil {
+		updates["actif"] = *updateData.Actif
+	}
+
+	if err := db.Model(&poste).Updates(updates).Error; err != nil {
+		return problemes.Echec("Failed to update border post", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Border post updated successfully",
+		"data":    poste,
+	})
+}
+
+// Delete data
+func DeletePoste(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var poste models.PosteFrontiere
+	if err := db.Where("uuid = ?", uuid).First(&poste).Error; err != nil {
+		return problemes.Absent("Border post not found", err)
+	}
+
+	// Un poste qui a enregistré des passages est désactivé plutôt que supprimé
+	var passages int64
+	db.Model(&models.Passage{}).Where("poste_uuid = ?", uuid).Count(&passages)
+	if passages > 0 {
+		return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "Border post has recorded crossings; deactivate it instead")
+	}
+
+	if err := db.Delete(&poste).Error; err != nil {
+		return problemes.Echec("Failed to delete border post", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Border post deleted successfully",
+		"data":    nil,
+	})
+}
@@ -6,8 +6,11 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
@@ -27,345 +30,284 @@ func validateCoordinates(lat, lon float64) error {
 // CRUD OPERATIONS
 // =======================
 
-// Paginate - Récupérer les géolocalisations avec pagination
-func GetPaginatedGeolocalisations(c *fiber.Ctx) error {
-	db := database.DB
-
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
-
-	identiteUUID := c.Query("identite_uuid", "")
-
-	var geolocalisations []models.Geolocalisation
-	var totalRecords int64
+// Champs des géolocalisations pouvant être filtrés, triés et sélectionnés
+var listeGeolocalisations = requetes.Schema{
+	Table: "geolocalisations",
+	Champs: map[string]requetes.Type{
+		"uuid":          requetes.Texte,
+		"created_at":    requetes.Date,
+		"updated_at":    requetes.Date,
+		"identite_uuid": requetes.Texte,
+		"latitude":      requetes.Nombre,
+		"longitude":     requetes.Nombre,
+	},
+	Relations: []string{"identite"},
+	TriDefaut: "-created_at",
+}
 
-	query := db.Model(&models.Geolocalisation{}).Preload("Identite")
+// Paginate - Récupérer les géolocalisations avec pagination
+func GetPaginatedGeolocalisations(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeGeolocalisations)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	// Filtrer par identite si spécifié
-	if identiteUUID != "" {
-		query = query.Where("identite_uuid = ?", identiteUUID)
-	}
+		// Filtrer par identite si spécifié
+		var conditions []depots.Condition
+		if identiteUUID := c.Query("identite_uuid", ""); identiteUUID != "" {
+			conditions = append(conditions, depots.Egal("identite_uuid", identiteUUID))
+		}
 
-	// Count total
-	query.Count(&totalRecords)
+		// Filtres, tri et pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Paginer(liste, conditions...)
 
-	// Get paginated results
-	err = query.Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&geolocalisations).Error
+		if err != nil {
+			return problemes.Echec("Failed to fetch geolocations", err)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch geolocations",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Geolocations retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
 		})
 	}
-
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
-
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
-
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Geolocations retrieved successfully",
-		"data":       geolocalisations,
-		"pagination": pagination,
-	})
 }
 
 // Get all geolocations
-func GetAllGeolocalisations(c *fiber.Ctx) error {
-	db := database.DB
-	var geolocalisations []models.Geolocalisation
+func GetAllGeolocalisations(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeGeolocalisations)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	err := db.Preload("Identite").
-		Order("created_at DESC").
-		Find(&geolocalisations).Error
+		data, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Tout(liste)
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch geolocations",
-			"error":   err.Error(),
+		if err != nil {
+			return problemes.Echec("Failed to fetch geolocations", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All geolocations",
+			"data":    data,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All geolocations",
-		"data":    geolocalisations,
-	})
 }
 
 // Get coordinates list with full names
-func GetCoordinatesList(c *fiber.Ctx) error {
-	db := database.DB
-	var geolocalisations []models.Geolocalisation
+func GetCoordinatesList(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		geolocalisations, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Charger("-created_at")
 
-	err := db.Preload("Identite").
-		Order("created_at DESC").
-		Find(&geolocalisations).Error
+		if err != nil {
+			return problemes.Echec("Failed to fetch geolocations", err)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch geolocations",
-			"error":   err.Error(),
-		})
-	}
+		// Transformer les données pour retourner seulement les coordonnées et le nom complet
+		type CoordinateData struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+			FullName  string  `json:"fullname"`
+		}
 
-	// Transformer les données pour retourner seulement les coordonnées et le nom complet
-	type CoordinateData struct {
-		Latitude  float64 `json:"latitude"`
-		Longitude float64 `json:"longitude"`
-		FullName  string  `json:"fullname"`
-	}
+		var coordinates []CoordinateData
+		for _, geo := range geolocalisations {
+			fullName := geo.Identite.Nom + " " + geo.Identite.Postnom + " " + geo.Identite.Prenom
+			coordinates = append(coordinates, CoordinateData{
+				Latitude:  geo.Latitude,
+				Longitude: geo.Longitude,
+				FullName:  fullName,
+			})
+		}
 
-	var coordinates []CoordinateData
-	for _, geo := range geolocalisations {
-		fullName := geo.Identite.Nom + " " + geo.Identite.Postnom + " " + geo.Identite.Prenom
-		coordinates = append(coordinates, CoordinateData{
-			Latitude:  geo.Latitude,
-			Longitude: geo.Longitude,
-			FullName:  fullName,
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Coordinates list retrieved successfully",
+			"data":    coordinates,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Coordinates list retrieved successfully",
-		"data":    coordinates,
-	})
 }
 
 // Get one geolocation
-func GetGeolocalisation(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-	var geolocalisation models.Geolocalisation
+func GetGeolocalisation(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		geolocalisation, err := depot.Trouver(c.Params("uuid"), "Identite")
+		if err != nil {
+			return problemes.Absent("Geolocation not found", err)
+		}
 
-	err := db.Where("uuid = ?", uuid).
-		Preload("Identite").
-		First(&geolocalisation).Error
+		if !unites.AccederIdentiteUUID(c, geolocalisation.IdentiteUUID) {
+			return unites.Refus(c)
+		}
 
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Geolocation not found",
-			"data":    nil,
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Geolocation found",
+			"data":    geolocalisation,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Geolocation found",
-		"data":    geolocalisation,
-	})
 }
 
 // Get geolocations by identite with pagination
-func GetGeolocalisationsByIdentite(c *fiber.Ctx) error {
-	identiteUUID := c.Params("identite_uuid")
-	db := database.DB
+func GetGeolocalisationsByIdentite(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identiteUUID := c.Params("identite_uuid")
 
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+		if !unites.AccederIdentiteUUID(c, identiteUUID) {
+			return unites.Refus(c)
+		}
+
+		page, err := strconv.Atoi(c.Query("page", "1"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		limit, err := strconv.Atoi(c.Query("limit", "15"))
+		if err != nil || limit <= 0 {
+			limit = 15
+		}
 
-	var geolocalisations []models.Geolocalisation
-	var totalRecords int64
+		// L'accès à l'identité vient d'être contrôlé
+		geolocalisations, totalRecords, err := depot.Lire(depots.PorteeNationale).Avec("Identite").
+			Page("-created_at", page, limit, depots.Egal("identite_uuid", identiteUUID))
 
-	query := db.Model(&models.Geolocalisation{}).
-		Where("identite_uuid = ?", identiteUUID).
-		Preload("Identite")
+		if err != nil {
+			return problemes.Echec("Failed to fetch geolocations for migrant", err)
+		}
 
-	// Count total
-	query.Count(&totalRecords)
+		totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
 
-	// Get paginated results
-	err = query.Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&geolocalisations).Error
+		pagination := map[string]interface{}{
+			"total_records": totalRecords,
+			"total_pages":   totalPages,
+			"current_page":  page,
+			"page_size":     limit,
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch geolocations for migrant",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Geolocations for identite retrieved successfully",
+			"data":       geolocalisations,
+			"pagination": pagination,
 		})
 	}
+}
 
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// Create geolocation
+func CreateGeolocalisation(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		geolocalisation := &models.Geolocalisation{}
 
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		if err := c.BodyParser(geolocalisation); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Geolocations for identite retrieved successfully",
-		"data":       geolocalisations,
-		"pagination": pagination,
-	})
-}
- 
+		// Validation des champs requis
+		if geolocalisation.IdentiteUUID == "" {
+			return problemes.Invalide("IdentiteUUID is required")
+		}
 
-// Create geolocation
-func CreateGeolocalisation(c *fiber.Ctx) error {
-	geolocalisation := &models.Geolocalisation{}
-
-	if err := c.BodyParser(geolocalisation); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	}
+		// Valider les coordonnées
+		if err := validateCoordinates(geolocalisation.Latitude, geolocalisation.Longitude); err != nil {
+			return problemes.Invalide(err.Error())
+		}
 
-	// Validation des champs requis
-	if geolocalisation.IdentiteUUID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "IdentiteUUID is required",
-			"data":    nil,
-		})
-	}
+		if !unites.AccederIdentiteUUID(c, geolocalisation.IdentiteUUID) {
+			return unites.Refus(c)
+		}
 
-	// Valider les coordonnées
-	if err := validateCoordinates(geolocalisation.Latitude, geolocalisation.Longitude); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": err.Error(),
-			"data":    nil,
-		})
-	}
+		// Générer l'UUID
+		geolocalisation.UUID = utils.GenerateUUID()
 
-	// Générer l'UUID
-	geolocalisation.UUID = utils.GenerateUUID()
+		// Validation des données
+		if err := utils.ValidateStruct(*geolocalisation); err != nil {
+			return problemes.Validation(err)
+		}
 
-	// Validation des données
-	if err := utils.ValidateStruct(*geolocalisation); err != nil {
-		return c.Status(400).JSON(err)
-	}
+		if err := depot.Creer(geolocalisation); err != nil {
+			return problemes.Echec("Failed to create geolocation", err)
+		}
+
+		InvalidateTilesAt(geolocalisation.Latitude, geolocalisation.Longitude)
 
-	if err := database.DB.Create(geolocalisation).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create geolocation",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Geolocation created successfully",
+			"data":    geolocalisation,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Geolocation created successfully",
-		"data":    geolocalisation,
-	})
 }
 
 // Update geolocation
-func UpdateGeolocalisation(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var updateData models.Geolocalisation
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"error":   err.Error(),
-		})
-	}
+func UpdateGeolocalisation(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData models.Geolocalisation
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
 
-	geolocalisation := new(models.Geolocalisation)
-	if err := db.Where("uuid = ?", uuid).First(&geolocalisation).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Geolocation not found",
-			"data":    nil,
-		})
-	}
+		geolocalisation, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Geolocation not found", err)
+		}
 
-	// Valider les nouvelles coordonnées si elles sont fournies
-	if updateData.Latitude != 0 || updateData.Longitude != 0 {
-		if err := validateCoordinates(updateData.Latitude, updateData.Longitude); err != nil {
-			return c.Status(400).JSON(fiber.Map{
-				"status":  "error",
-				"message": err.Error(),
-				"data":    nil,
-			})
+		if !unites.AccederIdentiteUUID(c, geolocalisation.IdentiteUUID) {
+			return unites.Refus(c)
+		}
+
+		// Valider les nouvelles coordonnées si elles sont fournies
+		if updateData.Latitude != 0 || updateData.Longitude != 0 {
+			if err := validateCoordinates(updateData.Latitude, updateData.Longitude); err != nil {
+				return problemes.Invalide(err.Error())
+			}
 		}
-	}
 
-	// Conserver l'UUID
-	updateData.UUID = geolocalisation.UUID
+		// Conserver l'UUID
+		updateData.UUID = geolocalisation.UUID
+		ancienneLatitude, ancienneLongitude := geolocalisation.Latitude, geolocalisation.Longitude
 
-	if err := db.Model(&geolocalisation).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to update geolocation",
-			"error":   err.Error(),
+		if err := depot.Modifier(geolocalisation, &updateData); err != nil {
+			return problemes.Echec("Failed to update geolocation", err)
+		}
+
+		// Tuiles de l'ancienne et de la nouvelle position
+		InvalidateTilesAt(ancienneLatitude, ancienneLongitude)
+		InvalidateTilesAt(geolocalisation.Latitude, geolocalisation.Longitude)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Geolocation updated successfully",
+			"data":    geolocalisation,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Geolocation updated successfully",
-		"data":    geolocalisation,
-	})
 }
 
 // Delete geolocation
-func DeleteGeolocalisation(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var geolocalisation models.Geolocalisation
-	if err := db.Where("uuid = ?", uuid).First(&geolocalisation).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Geolocation not found",
-			"data":    nil,
-		})
-	}
+func DeleteGeolocalisation(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		geolocalisation, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Geolocation not found", err)
+		}
+
+		if !unites.AccederIdentiteUUID(c, geolocalisation.IdentiteUUID) {
+			return unites.Refus(c)
+		}
 
-	if err := db.Delete(&geolocalisation).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to delete geolocation",
-			"error":   err.Error(),
+		if err := depot.Supprimer(geolocalisation); err != nil {
+			return problemes.Echec("Failed to delete geolocation", err)
+		}
+
+		InvalidateTilesAt(geolocalisation.Latitude, geolocalisation.Longitude)
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Geolocation deleted successfully",
+			"data":    nil,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Geolocation deleted successfully",
-		"data":    nil,
-	})
 }
 
 // =======================
@@ -373,43 +315,41 @@ func DeleteGeolocalisation(c *fiber.Ctx) error {
 // =======================
 
 // ExportGeolocalisationsToExcel - Exporter les géolocalisations vers Excel avec mise en forme
-func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Récupérer les paramètres de filtre de date
-	startDateStr := c.Query("start_date", "")
-	endDateStr := c.Query("end_date", "")
-
-	var geolocalisations []models.Geolocalisation
-
-	query := db.Model(&models.Geolocalisation{}).Preload("Identite")
-
-	// Appliquer les filtres de date
-	if startDateStr != "" {
-		startDate, err := time.Parse("2006-01-02", startDateStr)
-		if err == nil {
-			query = query.Where("created_at >= ?", startDate)
+func ExportGeolocalisationsToExcel(depot depots.Geolocalisations) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de filtre de date
+		startDateStr := c.Query("start_date", "")
+		endDateStr := c.Query("end_date", "")
+
+		// Appliquer les filtres de date
+		var conditions []depots.Condition
+		if startDateStr != "" {
+			startDate, err := time.Parse("2006-01-02", startDateStr)
+			if err == nil {
+				conditions = append(conditions, depots.AuMoins("created_at", startDate))
+			}
 		}
-	}
-	if endDateStr != "" {
-		endDate, err := time.Parse("2006-01-02", endDateStr)
-		if err == nil {
-			// Ajouter 23:59:59 pour inclure toute la journée
-			endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-			query = query.Where("created_at <= ?", endDate)
+		if endDateStr != "" {
+			endDate, err := time.Parse("2006-01-02", endDateStr)
+			if err == nil {
+				// Ajouter 23:59:59 pour inclure toute la journée
+				endDate = endDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+				conditions = append(conditions, depots.AuPlus("created_at", endDate))
+			}
 		}
-	}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&geolocalisations).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch geolocations for export",
-			"error":   err.Error(),
-		})
+		// Récupérer toutes les données
+		geolocalisations, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch geolocations for export", err)
+		}
+
+		return exporterGeolocalisations(c, geolocalisations, startDateStr, endDateStr)
 	}
+}
 
+// exporterGeolocalisations écrit le classeur des géolocalisations exportées
+func exporterGeolocalisations(c *fiber.Ctx, geolocalisations []models.Geolocalisation, startDateStr, endDateStr string) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -422,11 +362,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 	f.DeleteSheet("Sheet1")
 	index, err := f.NewSheet("Géolocalisations")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 	f.SetActiveSheet(index)
 
@@ -456,11 +392,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create header style", err)
 	}
 
 	// Style pour les en-têtes de colonnes
@@ -488,11 +420,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create column header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create column header style", err)
 	}
 
 	// Style pour les cellules de données
@@ -514,11 +442,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create data style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create data style", err)
 	}
 
 	// Style pour les cellules numériques
@@ -540,11 +464,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 		NumFmt: 4, // Format numérique avec 2 décimales
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create number style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create number style", err)
 	}
 
 	// Style pour les cellules de date
@@ -566,11 +486,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 		NumFmt: 14, // Format de date mm/dd/yyyy
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create date style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create date style", err)
 	}
 
 	// ===== EN-TÊTE PRINCIPAL =====
@@ -691,11 +607,7 @@ func ExportGeolocalisationsToExcel(c *fiber.Ctx) error {
 	// Sauvegarder en mémoire
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Configurer les en-têtes de réponse pour le téléchargement
@@ -0,0 +1,407 @@
+package geolocation
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// =======================
+// TUILES DE CLUSTERS
+// =======================
+
+const (
+	tileExtent     = 4096
+	tileGridSize   = 64 // taille d'une cellule de regroupement, en unités de tuile
+	tileMaxZoom    = 22
+	tileLayerName  = "clusters"
+	mvtContentType = "application/vnd.mapbox-vector-tile"
+)
+
+// ClusterPoint représente un groupe de géolocalisations agrégé dans une tuile
+type ClusterPoint struct {
+	Latitude             float64        `json:"latitude"`
+	Longitude            float64        `json:"longitude"`
+	Count                int            `json:"count"`
+	NationaliteDominante string         `json:"nationalite_dominante"`
+	Nationalites         map[string]int `json:"nationalites"`
+}
+
+// ClusterTile représente le contenu JSON d'une tuile
+type ClusterTile struct {
+	Z           int            `json:"z"`
+	X           int            `json:"x"`
+	Y           int            `json:"y"`
+	TotalPoints int            `json:"total_points"`
+	Clusters    []ClusterPoint `json:"clusters"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+type cachedTile struct {
+	tile ClusterTile
+	mvt  []byte
+}
+
+// Nombre maximal de tuiles gardées en cache, tous périmètres confondus
+const tileCacheSize = 4096
+
+// Cache des tuiles calculées. Une tuile ne contient que les géolocalisations du
+// périmètre de l'utilisateur : la clé comprend le périmètre en plus de z/x/y.
+var tileCache = newTileLRU(tileCacheSize)
+
+type tileCacheEntry struct {
+	cle   string
+	tuile string
+	value *cachedTile
+}
+
+// tileLRU est un cache LRU borné ; les entrées d'une même tuile (z/x/y), quel que
+// soit le périmètre, sont indexées pour être invalidées ensemble
+type tileLRU struct {
+	mu       sync.Mutex
+	capacite int
+	ordre    *list.List
+	entrees  map[string]*list.Element
+	parTuile map[string]map[string]*list.Element
+}
+
+func newTileLRU(capacite int) *tileLRU {
+	return &tileLRU{
+		capacite: capacite,
+		ordre:    list.New(),
+		entrees:  map[string]*list.Element{},
+		parTuile: map[string]map[string]*list.Element{},
+	}
+}
+
+func tileKey(z, x, y int) string {
+	return fmt.Sprintf("%d/%d/%d", z, x, y)
+}
+
+func (l *tileLRU) get(perimetre string, z, x, y int) (*cachedTile, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entrees[perimetre+"|"+tileKey(z, x, y)]
+	if !ok {
+		return nil, false
+	}
+	l.ordre.MoveToFront(element)
+	return element.Value.(*tileCacheEntry).value, true
+}
+
+func (l *tileLRU) put(perimetre string, z, x, y int, value *cachedTile) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tuile := tileKey(z, x, y)
+	cle := perimetre + "|" + tuile
+	if element, ok := l.entrees[cle]; ok {
+		element.Value.(*tileCacheEntry).value = value
+		l.ordre.MoveToFront(element)
+		return
+	}
+
+	element := l.ordre.PushFront(&tileCacheEntry{cle: cle, tuile: tuile, value: value})
+	l.entrees[cle] = element
+	if l.parTuile[tuile] == nil {
+		l.parTuile[tuile] = map[string]*list.Element{}
+	}
+	l.parTuile[tuile][cle] = element
+
+	for l.ordre.Len() > l.capacite {
+		l.retirer(l.ordre.Back())
+	}
+}
+
+// invalider retire la tuile z/x/y de tous les périmètres
+func (l *tileLRU) invalider(z, x, y int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, element := range l.parTuile[tileKey(z, x, y)] {
+		l.retirer(element)
+	}
+}
+
+func (l *tileLRU) vider() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ordre.Init()
+	l.entrees = map[string]*list.Element{}
+	l.parTuile = map[string]map[string]*list.Element{}
+}
+
+func (l *tileLRU) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ordre.Len()
+}
+
+func (l *tileLRU) retirer(element *list.Element) {
+	entree := element.Value.(*tileCacheEntry)
+	l.ordre.Remove(element)
+	delete(l.entrees, entree.cle)
+	delete(l.parTuile[entree.tuile], entree.cle)
+	if len(l.parTuile[entree.tuile]) == 0 {
+		delete(l.parTuile, entree.tuile)
+	}
+}
+
+// InvalidateTileCache vide le cache des tuiles, après une écriture qui touche un
+// nombre indéterminé de géolocalisations (fusion d'identités)
+func InvalidateTileCache() {
+	tileCache.vider()
+}
+
+// InvalidateTilesAt retire du cache les tuiles qui contiennent le point, à tous les
+// niveaux de zoom, après l'écriture d'une géolocalisation
+func InvalidateTilesAt(lat, lon float64) {
+	for z := 0; z <= tileMaxZoom; z++ {
+		x, y := tileAt(lat, lon, z)
+		tileCache.invalider(z, x, y)
+	}
+}
+
+// InvalidateTilesOfIdentite invalide les tuiles des géolocalisations d'une identité,
+// dont les clusters comptent la nationalité. Si elles ne peuvent être lues, tout le
+// cache est vidé.
+func InvalidateTilesOfIdentite(depot depots.Geolocalisations, identiteUUID string) {
+	geolocalisations, err := depot.Lire(depots.PorteeNationale).
+		Colonnes("latitude", "longitude").
+		Charger("", depots.Egal("identite_uuid", identiteUUID))
+	if err != nil {
+		InvalidateTileCache()
+		return
+	}
+	for _, g := range geolocalisations {
+		InvalidateTilesAt(g.Latitude, g.Longitude)
+	}
+}
+
+// tileAt retourne la tuile Web Mercator qui contient le point au niveau de zoom z
+func tileAt(lat, lon float64, z int) (int, int) {
+	limit := 1 << uint(z)
+	px, py := projectToTile(lat, lon, z, 0, 0)
+	x := int(math.Floor(float64(px) / tileExtent))
+	y := int(math.Floor(float64(py) / tileExtent))
+	return min(max(x, 0), limit-1), min(max(y, 0), limit-1)
+}
+
+// tileBounds retourne l'emprise (lon/lat) d'une tuile Web Mercator
+func tileBounds(z, x, y int) (minLon, minLat, maxLon, maxLat float64) {
+	n := math.Exp2(float64(z))
+	minLon = float64(x)/n*360 - 180
+	maxLon = float64(x+1)/n*360 - 180
+	maxLat = tileLatitude(float64(y), n)
+	minLat = tileLatitude(float64(y+1), n)
+	return
+}
+
+func tileLatitude(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// projectToTile convertit des coordonnées géographiques en coordonnées locales de tuile
+func projectToTile(lat, lon float64, z, x, y int) (int, int) {
+	n := math.Exp2(float64(z))
+	latRad := lat * math.Pi / 180
+	worldX := (lon + 180) / 360 * n
+	worldY := (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n
+	px := int((worldX - float64(x)) * tileExtent)
+	py := int((worldY - float64(y)) * tileExtent)
+	return px, py
+}
+
+// parseTileParams valide z/x/y et détecte le format demandé (suffixe .mvt/.pbf/.json)
+func parseTileParams(c *fiber.Ctx) (z, x, y int, format string, err error) {
+	format = strings.ToLower(c.Query("format", "json"))
+
+	rawY := c.Params("y")
+	if dot := strings.LastIndex(rawY, "."); dot != -1 {
+		ext := strings.ToLower(rawY[dot+1:])
+		rawY = rawY[:dot]
+		switch ext {
+		case "mvt", "pbf":
+			format = "mvt"
+		case "json":
+			format = "json"
+		}
+	}
+	if strings.Contains(c.Get("Accept"), mvtContentType) {
+		format = "mvt"
+	}
+
+	if z, err = strconv.Atoi(c.Params("z")); err != nil || z < 0 || z > tileMaxZoom {
+		return 0, 0, 0, "", fmt.Errorf("invalid zoom level")
+	}
+	limit := 1 << uint(z)
+	if x, err = strconv.Atoi(c.Params("x")); err != nil || x < 0 || x >= limit {
+		return 0, 0, 0, "", fmt.Errorf("invalid tile x coordinate")
+	}
+	if y, err = strconv.Atoi(rawY); err != nil || y < 0 || y >= limit {
+		return 0, 0, 0, "", fmt.Errorf("invalid tile y coordinate")
+	}
+	if format != "json" && format != "mvt" {
+		return 0, 0, 0, "", fmt.Errorf("unsupported tile format")
+	}
+	return z, x, y, format, nil
+}
+
+// tilePoint - géolocalisation d'une tuile et nationalité de l'identité localisée
+type tilePoint struct {
+	Latitude    float64
+	Longitude   float64
+	Nationalite string
+}
+
+// buildClusterTile regroupe les géolocalisations d'une tuile sur une grille régulière
+func buildClusterTile(p *unites.Perimetre, z, x, y int) (*cachedTile, error) {
+	db := database.DB
+	minLon, minLat, maxLon, maxLat := tileBounds(z, x, y)
+
+	var points []tilePoint
+
+	err := db.Table("geolocalisations g").
+		Select("g.latitude, g.longitude, i.nationalite").
+		Joins("JOIN identites i ON g.identite_uuid = i.uuid").
+		Scopes(p.ParIdentite("g.identite_uuid")).
+		Where("g.deleted_at IS NULL").
+		Where("g.latitude >= ? AND g.latitude < ? AND g.longitude >= ? AND g.longitude < ?",
+			minLat, maxLat, minLon, maxLon).
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return clusterTile(points, z, x, y), nil
+}
+
+// clusterTile regroupe les points de la tuile z/x/y par cellule de la grille
+func clusterTile(points []tilePoint, z, x, y int) *cachedTile {
+	type cell struct {
+		sumLat, sumLon float64
+		sumX, sumY     int
+		count          int
+		nationalites   map[string]int
+	}
+	cells := map[[2]int]*cell{}
+
+	for _, p := range points {
+		px, py := projectToTile(p.Latitude, p.Longitude, z, x, y)
+		key := [2]int{px / tileGridSize, py / tileGridSize}
+		cl, ok := cells[key]
+		if !ok {
+			cl = &cell{nationalites: map[string]int{}}
+			cells[key] = cl
+		}
+		cl.sumLat += p.Latitude
+		cl.sumLon += p.Longitude
+		cl.sumX += px
+		cl.sumY += py
+		cl.count++
+		cl.nationalites[p.Nationalite]++
+	}
+
+	// Ordre stable des cellules pour des réponses reproductibles
+	keys := make([][2]int, 0, len(cells))
+	for key := range cells {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	tile := ClusterTile{
+		Z:           z,
+		X:           x,
+		Y:           y,
+		TotalPoints: len(points),
+		Clusters:    []ClusterPoint{},
+		GeneratedAt: time.Now(),
+	}
+	layer := utils.MVTLayer{Name: tileLayerName, Extent: tileExtent}
+
+	for i, key := range keys {
+		cl := cells[key]
+		dominante := dominantNationalite(cl.nationalites)
+
+		tile.Clusters = append(tile.Clusters, ClusterPoint{
+			Latitude:             cl.sumLat / float64(cl.count),
+			Longitude:            cl.sumLon / float64(cl.count),
+			Count:                cl.count,
+			NationaliteDominante: dominante,
+			Nationalites:         cl.nationalites,
+		})
+
+		layer.Features = append(layer.Features, utils.MVTFeature{
+			ID: uint64(i + 1),
+			X:  cl.sumX / cl.count,
+			Y:  cl.sumY / cl.count,
+			Properties: map[string]interface{}{
+				"count":                 cl.count,
+				"nationalite_dominante": dominante,
+			},
+		})
+	}
+
+	return &cachedTile{
+		tile: tile,
+		mvt:  utils.EncodeMVT([]utils.MVTLayer{layer}),
+	}
+}
+
+// dominantNationalite retourne la nationalité la plus représentée (ordre alphabétique en cas d'égalité)
+func dominantNationalite(counts map[string]int) string {
+	dominante := ""
+	best := 0
+	for nationalite, count := range counts {
+		if count > best || (count == best && nationalite < dominante) {
+			dominante = nationalite
+			best = count
+		}
+	}
+	return dominante
+}
+
+// GetClusterTile - Récupérer une tuile de clusters pré-calculés
+// GET /api/geolocations/tiles/:z/:x/:y (JSON) ou /tiles/:z/:x/:y.mvt (Mapbox Vector Tile)
+func GetClusterTile(c *fiber.Ctx) error {
+	z, x, y, format, err := parseTileParams(c)
+	if err != nil {
+		return problemes.Invalide(err.Error())
+	}
+
+	// Tuile calculée sur les géolocalisations du périmètre de l'utilisateur
+	p := unites.PerimetreDe(c)
+	entry, ok := tileCache.get(p.Cle(), z, x, y)
+	if !ok {
+		entry, err = buildClusterTile(p, z, x, y)
+		if err != nil {
+			return problemes.Envoyer(c, problemes.Interne("Failed to build cluster tile", err))
+		}
+		tileCache.put(p.Cle(), z, x, y, entry)
+	}
+
+	if format == "mvt" {
+		c.Set("Content-Type", mvtContentType)
+		return c.Send(entry.mvt)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cluster tile retrieved successfully",
+		"data":    entry.tile,
+	})
+}
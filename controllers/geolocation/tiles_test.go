@@ -0,0 +1,383 @@
+package geolocation
+
+import (
+	"encoding/binary"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+func TestTileLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTileLRU(2)
+	cache.put("national", 1, 0, 0, &cachedTile{})
+	cache.put("national", 1, 1, 0, &cachedTile{})
+
+	// 1/0/0 devient la plus récente, 1/1/0 est évincée
+	if _, ok := cache.get("national", 1, 0, 0); !ok {
+		t.Fatal("tuile 1/0/0 absente du cache")
+	}
+	cache.put("national", 1, 0, 1, &cachedTile{})
+
+	if cache.len() != 2 {
+		t.Fatalf("taille du cache = %d, attendu 2", cache.len())
+	}
+	if _, ok := cache.get("national", 1, 1, 0); ok {
+		t.Error("la tuile la moins récemment utilisée n'a pas été évincée")
+	}
+	for _, tuile := range [][3]int{{1, 0, 0}, {1, 0, 1}} {
+		if _, ok := cache.get("national", tuile[0], tuile[1], tuile[2]); !ok {
+			t.Errorf("tuile %v évincée à tort", tuile)
+		}
+	}
+}
+
+func TestTileLRUSeparatesScopes(t *testing.T) {
+	cache := newTileLRU(10)
+	nationale := &cachedTile{tile: ClusterTile{TotalPoints: 10}}
+	cache.put("national", 3, 4, 5, nationale)
+
+	if _, ok := cache.get("unite-a", 3, 4, 5); ok {
+		t.Fatal("tuile d'un autre périmètre servie depuis le cache")
+	}
+	cache.put("unite-a", 3, 4, 5, &cachedTile{tile: ClusterTile{TotalPoints: 2}})
+
+	entry, _ := cache.get("national", 3, 4, 5)
+	if entry != nationale {
+		t.Error("la tuile nationale a été remplacée par celle d'une unité")
+	}
+
+	// L'invalidation d'une tuile vaut pour tous les périmètres
+	cache.invalider(3, 4, 5)
+	if cache.len() != 0 {
+		t.Errorf("taille du cache après invalidation = %d, attendu 0", cache.len())
+	}
+}
+
+func TestInvalidateTilesAtKeepsOtherTiles(t *testing.T) {
+	defer InvalidateTileCache()
+	InvalidateTileCache()
+
+	// Goma et Kinshasa : tuiles distinctes à partir du zoom 3
+	gomaLat, gomaLon := -1.6826, 29.2367
+	kinLat, kinLon := -4.306, 15.311
+	for z := 0; z <= tileMaxZoom; z++ {
+		x, y := tileAt(gomaLat, gomaLon, z)
+		tileCache.put("national", z, x, y, &cachedTile{})
+		x, y = tileAt(kinLat, kinLon, z)
+		tileCache.put("national", z, x, y, &cachedTile{})
+	}
+
+	InvalidateTilesAt(gomaLat, gomaLon)
+
+	for z := 0; z <= tileMaxZoom; z++ {
+		x, y := tileAt(gomaLat, gomaLon, z)
+		if _, ok := tileCache.get("national", z, x, y); ok {
+			t.Errorf("zoom %d: la tuile de Goma est restée en cache", z)
+		}
+	}
+	x, y := tileAt(kinLat, kinLon, 10)
+	if _, ok := tileCache.get("national", 10, x, y); !ok {
+		t.Error("la tuile de Kinshasa a été invalidée à tort")
+	}
+}
+
+func TestTileAtMatchesTileBounds(t *testing.T) {
+	points := [][2]float64{{-1.6826, 29.2367}, {-4.3858, 15.4446}, {2.865, 30.84}, {-12.2667, 27.8}}
+	for _, point := range points {
+		for _, z := range []int{0, 5, 12, tileMaxZoom} {
+			x, y := tileAt(point[0], point[1], z)
+			minLon, minLat, maxLon, maxLat := tileBounds(z, x, y)
+			if point[1] < minLon || point[1] >= maxLon || point[0] < minLat || point[0] >= maxLat {
+				t.Errorf("point %v au zoom %d hors de la tuile %d/%d", point, z, x, y)
+			}
+		}
+	}
+}
+
+func TestGetClusterTileRejectsInvalidCoordinates(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/tiles/:z/:x/:y", GetClusterTile)
+
+	for _, chemin := range []string{"/tiles/23/0/0", "/tiles/2/4/0", "/tiles/2/0/-1", "/tiles/2/0/0?format=png"} {
+		resp, err := app.Test(httptest.NewRequest("GET", chemin, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("%s: statut %d, attendu 400", chemin, resp.StatusCode)
+		}
+		if ct := resp.Header.Get(fiber.HeaderContentType); ct != "application/problem+json" {
+			t.Errorf("%s: Content-Type %q, attendu application/problem+json", chemin, ct)
+		}
+	}
+}
+
+func TestInvalidateTilesOfIdentite(t *testing.T) {
+	defer InvalidateTileCache()
+	InvalidateTileCache()
+
+	d := depots.NewDepotsMemoire()
+	geolocalisations := []models.Geolocalisation{
+		{UUID: "g1", IdentiteUUID: "i1", Latitude: -1.6826, Longitude: 29.2367},
+		{UUID: "g2", IdentiteUUID: "i1", Latitude: 2.865, Longitude: 30.84},
+		{UUID: "g3", IdentiteUUID: "i2", Latitude: -4.306, Longitude: 15.311},
+	}
+	for i := range geolocalisations {
+		if err := d.Geolocalisations.Creer(&geolocalisations[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, g := range geolocalisations {
+		x, y := tileAt(g.Latitude, g.Longitude, 10)
+		tileCache.put("national", 10, x, y, &cachedTile{})
+	}
+
+	InvalidateTilesOfIdentite(d.Geolocalisations, "i1")
+
+	for _, g := range geolocalisations {
+		x, y := tileAt(g.Latitude, g.Longitude, 10)
+		_, ok := tileCache.get("national", 10, x, y)
+		if g.IdentiteUUID == "i1" && ok {
+			t.Errorf("%s : tuile de l'identité restée en cache", g.UUID)
+		}
+		if g.IdentiteUUID == "i2" && !ok {
+			t.Errorf("%s : tuile d'une autre identité invalidée à tort", g.UUID)
+		}
+	}
+}
+
+// mvtFeature - entité d'une couche décodée, propriétés résolues
+type mvtFeature struct {
+	id         uint64
+	geomType   uint64
+	x, y       int64
+	properties map[string]interface{}
+}
+
+// mvtLayer - couche décodée d'une tuile
+type mvtLayer struct {
+	name     string
+	extent   uint64
+	features []mvtFeature
+}
+
+// champsProtobuf parcourt les champs d'un message protobuf : varint, fixed64 et
+// longueur délimitée, les seuls types écrits par utils.EncodeMVT
+func champsProtobuf(t *testing.T, data []byte, visiter func(numero, varint uint64, octets []byte)) {
+	t.Helper()
+	for len(data) > 0 {
+		cle, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatal("clé protobuf illisible")
+		}
+		data = data[n:]
+		numero, genre := cle>>3, cle&0x7
+		switch genre {
+		case 0:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				t.Fatal("varint illisible")
+			}
+			data = data[n:]
+			visiter(numero, v, nil)
+		case 1:
+			if len(data) < 8 {
+				t.Fatal("fixed64 tronqué")
+			}
+			visiter(numero, binary.LittleEndian.Uint64(data[:8]), data[:8])
+			data = data[8:]
+		case 2:
+			taille, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < taille {
+				t.Fatal("champ de longueur délimitée tronqué")
+			}
+			visiter(numero, 0, data[n:n+int(taille)])
+			data = data[n+int(taille):]
+		default:
+			t.Fatalf("type de champ protobuf %d inattendu", genre)
+		}
+	}
+}
+
+func varintsPacked(t *testing.T, data []byte) []uint64 {
+	t.Helper()
+	var valeurs []uint64
+	for len(data) > 0 {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatal("varint packed illisible")
+		}
+		valeurs = append(valeurs, v)
+		data = data[n:]
+	}
+	return valeurs
+}
+
+func dezigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// decoderMVT décode une tuile Mapbox Vector Tile (spécification 2.1)
+func decoderMVT(t *testing.T, tuile []byte) []mvtLayer {
+	t.Helper()
+	var couches []mvtLayer
+	champsProtobuf(t, tuile, func(numero, _ uint64, octets []byte) {
+		if numero != 3 {
+			t.Fatalf("champ %d inattendu dans la tuile", numero)
+		}
+		var couche mvtLayer
+		var cles []string
+		var valeurs []interface{}
+		var brutes []struct{ tags, geometrie []uint64 }
+		var entites []mvtFeature
+		champsProtobuf(t, octets, func(numero, varint uint64, octets []byte) {
+			switch numero {
+			case 1:
+				couche.name = string(octets)
+			case 2:
+				var f mvtFeature
+				var brute struct{ tags, geometrie []uint64 }
+				champsProtobuf(t, octets, func(numero, varint uint64, octets []byte) {
+					switch numero {
+					case 1:
+						f.id = varint
+					case 2:
+						brute.tags = varintsPacked(t, octets)
+					case 3:
+						f.geomType = varint
+					case 4:
+						brute.geometrie = varintsPacked(t, octets)
+					}
+				})
+				entites = append(entites, f)
+				brutes = append(brutes, brute)
+			case 3:
+				cles = append(cles, string(octets))
+			case 4:
+				var valeur interface{}
+				champsProtobuf(t, octets, func(numero, varint uint64, octets []byte) {
+					switch numero {
+					case 1:
+						valeur = string(octets)
+					case 3:
+						valeur = math.Float64frombits(varint)
+					case 6:
+						valeur = dezigzag(varint)
+					case 7:
+						valeur = varint != 0
+					}
+				})
+				valeurs = append(valeurs, valeur)
+			case 5:
+				couche.extent = varint
+			}
+		})
+		for i, f := range entites {
+			tags, geometrie := brutes[i].tags, brutes[i].geometrie
+			if len(tags)%2 != 0 {
+				t.Fatalf("entité %d : nombre de tags impair", f.id)
+			}
+			f.properties = map[string]interface{}{}
+			for j := 0; j < len(tags); j += 2 {
+				if tags[j] >= uint64(len(cles)) || tags[j+1] >= uint64(len(valeurs)) {
+					t.Fatalf("entité %d : tag hors des tables de la couche", f.id)
+				}
+				f.properties[cles[tags[j]]] = valeurs[tags[j+1]]
+			}
+			// Un point : MoveTo (commande 1, une répétition) suivi de x, y
+			if len(geometrie) != 3 || geometrie[0] != 1|1<<3 {
+				t.Fatalf("entité %d : géométrie %v, un MoveTo attendu", f.id, geometrie)
+			}
+			f.x, f.y = dezigzag(geometrie[1]), dezigzag(geometrie[2])
+			couche.features = append(couche.features, f)
+		}
+		couches = append(couches, couche)
+	})
+	return couches
+}
+
+// pointDansTuile retourne le point situé aux fractions fx, fy de la tuile z/x/y
+func pointDansTuile(z, x, y int, fx, fy float64, nationalite string) tilePoint {
+	minLon, minLat, maxLon, maxLat := tileBounds(z, x, y)
+	return tilePoint{
+		Latitude:    maxLat - (maxLat-minLat)*fy,
+		Longitude:   minLon + (maxLon-minLon)*fx,
+		Nationalite: nationalite,
+	}
+}
+
+func TestClusterTileMVT(t *testing.T) {
+	z := 10
+	x, y := tileAt(-4.306, 15.311, z)
+	points := []tilePoint{
+		// Cellule du coin nord-ouest : deux Angolais, un Congolais
+		pointDansTuile(z, x, y, 0.100, 0.100, "Angolaise"),
+		pointDansTuile(z, x, y, 0.101, 0.101, "Congolaise"),
+		pointDansTuile(z, x, y, 0.102, 0.102, "Angolaise"),
+		// Cellule au sud-est : égalité départagée par l'ordre alphabétique
+		pointDansTuile(z, x, y, 0.700, 0.800, "Rwandaise"),
+		pointDansTuile(z, x, y, 0.701, 0.801, "Burundaise"),
+	}
+
+	tuile := clusterTile(points, z, x, y)
+
+	if tuile.tile.TotalPoints != len(points) || len(tuile.tile.Clusters) != 2 {
+		t.Fatalf("%d points en %d clusters, attendus %d en 2", tuile.tile.TotalPoints, len(tuile.tile.Clusters), len(points))
+	}
+	clusters := tuile.tile.Clusters
+	if clusters[0].Count != 3 || clusters[0].NationaliteDominante != "Angolaise" || clusters[0].Nationalites["Congolaise"] != 1 {
+		t.Errorf("premier cluster JSON %+v", clusters[0])
+	}
+	if clusters[1].Count != 2 || clusters[1].NationaliteDominante != "Burundaise" {
+		t.Errorf("second cluster JSON %+v", clusters[1])
+	}
+
+	couches := decoderMVT(t, tuile.mvt)
+	if len(couches) != 1 {
+		t.Fatalf("%d couches, attendu 1", len(couches))
+	}
+	couche := couches[0]
+	if couche.name != tileLayerName || couche.extent != tileExtent {
+		t.Errorf("couche %q d'étendue %d, attendu %q d'étendue %d", couche.name, couche.extent, tileLayerName, tileExtent)
+	}
+	attendus := []struct {
+		count     int64
+		dominante string
+		fx, fy    float64
+	}{
+		{3, "Angolaise", 0.101, 0.101},
+		{2, "Burundaise", 0.7005, 0.8005},
+	}
+	if len(couche.features) != len(attendus) {
+		t.Fatalf("%d entités, attendu %d", len(couche.features), len(attendus))
+	}
+	for i, attendu := range attendus {
+		f := couche.features[i]
+		if f.id != uint64(i+1) || f.geomType != 1 {
+			t.Errorf("entité %d : id %d, type %d, attendus %d et 1 (point)", i, f.id, f.geomType, i+1)
+		}
+		if f.properties["count"] != attendu.count || f.properties["nationalite_dominante"] != attendu.dominante {
+			t.Errorf("entité %d : propriétés %v, attendu count %d et %s", i, f.properties, attendu.count, attendu.dominante)
+		}
+		// Le point est au barycentre de la cellule, à une unité de tuile près
+		if math.Abs(float64(f.x)-attendu.fx*tileExtent) > 2 || math.Abs(float64(f.y)-attendu.fy*tileExtent) > 2 {
+			t.Errorf("entité %d en (%d, %d), attendue vers (%.0f, %.0f)", i, f.x, f.y, attendu.fx*tileExtent, attendu.fy*tileExtent)
+		}
+	}
+}
+
+func TestClusterTileVide(t *testing.T) {
+	tuile := clusterTile(nil, 3, 4, 4)
+	if tuile.tile.TotalPoints != 0 || len(tuile.tile.Clusters) != 0 {
+		t.Errorf("tuile vide : %+v", tuile.tile)
+	}
+	couches := decoderMVT(t, tuile.mvt)
+	if len(couches) != 1 || couches[0].name != tileLayerName || len(couches[0].features) != 0 {
+		t.Errorf("tuile vide : couches %+v, attendu une couche %q sans entité", couches, tileLayerName)
+	}
+}
@@ -2,397 +2,485 @@ package identites
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
-
-	"github.com/kgermando/sysmobembo-api/database"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/controllers/geolocation"
+	"github.com/kgermando/sysmobembo-api/controllers/patch"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/controllers/versions"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/xuri/excelize/v2"
 )
 
+// Champs des identités pouvant être filtrés, triés et sélectionnés
+var listeIdentites = requetes.Schema{
+	Table: "identites",
+	Champs: map[string]requetes.Type{
+		"uuid":              requetes.Texte,
+		"created_at":        requetes.Date,
+		"updated_at":        requetes.Date,
+		"nom":               requetes.Texte,
+		"postnom":           requetes.Texte,
+		"prenom":            requetes.Texte,
+		"date_naissance":    requetes.Date,
+		"lieu_naissance":    requetes.Texte,
+		"sexe":              requetes.Texte,
+		"nationalite":       requetes.Texte,
+		"adresse":           requetes.Texte,
+		"profession":        requetes.Texte,
+		"pays_emetteur":     requetes.Texte,
+		"autorite_emetteur": requetes.Texte,
+		"date_emission":     requetes.Date,
+		"date_expiration":   requetes.Date,
+		"numero_passeport":  requetes.Texte,
+		"unite_uuid":        requetes.Texte,
+		"version":           requetes.Nombre,
+	},
+	TriDefaut: "-updated_at",
+}
+
 // GetPaginatedIdentites - Récupérer toutes les identités avec pagination et recherche
-func GetPaginatedIdentites(c *fiber.Ctx) error {
-	db := database.DB
+func GetPaginatedIdentites(depot depots.Identites) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeIdentites)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	// Parse query parameters for pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
+		// Search filter
+		recherche := depots.Recherche(c.Query("search", ""),
+			"nom", "postnom", "prenom", "numero_passeport", "nationalite", "lieu_naissance", "sexe")
+
+		// Filters, sort and pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).Paginer(liste, recherche)
+
+		if err != nil {
+			return problemes.Echec("Failed to fetch identites", err)
+		}
+
+		// Return response
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Identites retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
+		})
 	}
-	offset := (page - 1) * limit
+}
 
-	// Parse search parameter
-	search := c.Query("search", "")
+// GetMigrantsByIdentiteUUID - Récupérer tous les migrants selon un identite_uuid avec pagination et recherche
+func GetMigrantsByIdentiteUUID(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get identite_uuid from query parameter
+		identiteUUID := c.Query("identite_uuid", "")
+		if identiteUUID == "" {
+			return problemes.Invalide("identite_uuid query parameter is required")
+		}
+
+		// Parse query parameters for pagination
+		page, err := strconv.Atoi(c.Query("page", "1"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+		limit, err := strconv.Atoi(c.Query("limit", "15"))
+		if err != nil || limit <= 0 {
+			limit = 15
+		}
 
-	var identites []models.Identite
-	var totalRecords int64
+		// Search filter
+		recherche := depots.Recherche(c.Query("search", ""),
+			"numero_identifiant", "adresse_actuelle", "ville_actuelle", "pays_actuel", "situation_matrimoniale").
+			OuDans("identite_uuid", "identites", "uuid", "nom", "postnom", "prenom", "nationalite", "numero_passeport")
 
-	// Build query with filters
-	query := db.Model(&models.Identite{})
+		// Execute query with pagination
+		migrants, totalRecords, err := depot.Lire(unites.PerimetreDe(c).Portee).
+			Avec("Identite", "MotifDeplacements", "Alertes", "Biometries", "Geolocalisations").
+			Page("-updated_at", page, limit, depots.Egal("identite_uuid", identiteUUID), recherche)
 
-	// Search filter
-	if search != "" {
-		query = query.Where("nom ILIKE ? OR postnom ILIKE ? OR prenom ILIKE ? OR numero_passeport ILIKE ? OR nationalite ILIKE ? OR lieu_naissance ILIKE ? OR sexe ILIKE ?",
-			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
-	}
+		if err != nil {
+			return problemes.Echec("Failed to fetch migrants", err)
+		}
 
-	// Count total records with filters applied
-	query.Count(&totalRecords)
+		// Calculate total pages
+		totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
 
-	// Execute query with pagination
-	err = query.
-		Offset(offset).
-		Limit(limit).
-		Order("updated_at DESC").
-		Find(&identites).Error
+		// Prepare pagination metadata
+		pagination := map[string]interface{}{
+			"total_records": totalRecords,
+			"total_pages":   totalPages,
+			"current_page":  page,
+			"page_size":     limit,
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch identites",
-			"error":   err.Error(),
+		// Return response
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Migrants retrieved successfully for identite_uuid: " + identiteUUID,
+			"data":       migrants,
+			"pagination": pagination,
 		})
 	}
+}
 
-	// Calculate total pages
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
-
-	// Prepare pagination metadata
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+// GetIdentite récupère une identité par UUID
+func GetIdentite(depot depots.Identites) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identite, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Identite not found", err)
+		}
 
-	// Return response
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Identites retrieved successfully",
-		"data":       identites,
-		"pagination": pagination,
-	})
-}
+		if !unites.AccederIdentite(c, *identite) {
+			return unites.Refus(c)
+		}
 
-// GetMigrantsByIdentiteUUID - Récupérer tous les migrants selon un identite_uuid avec pagination et recherche
-func GetMigrantsByIdentiteUUID(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Get identite_uuid from query parameter
-	identiteUUID := c.Query("identite_uuid", "")
-	if identiteUUID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "identite_uuid query parameter is required",
+		versions.ETag(c, identite.Version)
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data":   identite,
 		})
 	}
+}
 
-	// Parse query parameters for pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+// CreateIdentite crée une nouvelle identité
+func CreateIdentite(depot depots.Identites, criblage depots.Criblage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identite := new(models.Identite)
 
-	// Parse search parameter
-	search := c.Query("search", "")
+		if err := c.BodyParser(identite); err != nil {
+			return problemes.Invalide("Invalid input").Cause(err)
+		}
 
-	var migrants []models.Migrant
-	var totalRecords int64
+		// Générer l'UUID ; l'identité appartient à l'unité de l'agent
+		identite.UUID = utils.GenerateUUID()
+		identite.UniteUUID = unites.PerimetreDe(c).UniteUUID
+
+		// Validation des données
+		if errors := utils.ValidateStruct(*identite); len(errors) > 0 {
+			var errorMessages []string
+			for _, err := range errors {
+				switch err.FailedField {
+				case "Identite.Nom":
+					errorMessages = append(errorMessages, "Le nom est requis")
+				case "Identite.Postnom":
+					errorMessages = append(errorMessages, "Le postnom est requis")
+				case "Identite.Prenom":
+					errorMessages = append(errorMessages, "Le prénom est requis")
+				case "Identite.DateNaissance":
+					errorMessages = append(errorMessages, "La date de naissance est requise")
+				case "Identite.LieuNaissance":
+					errorMessages = append(errorMessages, "Le lieu de naissance est requis")
+				case "Identite.Sexe":
+					if err.Tag == "oneof" {
+						errorMessages = append(errorMessages, "Le sexe doit être 'M' ou 'F'")
+					} else {
+						errorMessages = append(errorMessages, "Le sexe est requis")
+					}
+				case "Identite.Nationalite":
+					errorMessages = append(errorMessages, "La nationalité est requise")
+				case "Identite.DateEmission":
+					errorMessages = append(errorMessages, "La date d'émission est requise")
+				case "Identite.DateExpiration":
+					errorMessages = append(errorMessages, "La date d'expiration est requise")
+				default:
+					errorMessages = append(errorMessages, fmt.Sprintf("Erreur de validation pour %s: %s", err.FailedField, err.Tag))
+				}
+			}
 
-	// Build query with filters
-	query := db.Model(&models.Migrant{}).Where("migrants.identite_uuid = ?", identiteUUID)
+			return problemes.Messages(problemes.CodeValidation, errorMessages)
+		}
 
-	// Search filter
-	if search != "" {
-		query = query.Joins("LEFT JOIN identites ON migrants.identite_uuid = identites.uuid").
-			Where("migrants.identite_uuid = ? AND (identites.nom ILIKE ? OR identites.postnom ILIKE ? OR identites.prenom ILIKE ? OR migrants.numero_identifiant ILIKE ? OR identites.nationalite ILIKE ? OR identites.numero_passeport ILIKE ? OR migrants.adresse_actuelle ILIKE ? OR migrants.ville_actuelle ILIKE ? OR migrants.pays_actuel ILIKE ? OR migrants.situation_matrimoniale ILIKE ?)",
-				identiteUUID, "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
-	}
+		// Vérifier la cohérence des dates du document
+		erreursDates, avertissements := VerifierDatesDocument(*identite)
+		if len(erreursDates) > 0 {
+			return problemes.Messages(problemes.CodeValidation, erreursDates)
+		}
 
-	// Count total records with filters applied
-	query.Count(&totalRecords)
-
-	// Execute query with pagination
-	err = query.
-		Preload("Identite").
-		Preload("MotifDeplacements").
-		Preload("Alertes").
-		Preload("Biometries").
-		Preload("Geolocalisations").
-		Offset(offset).
-		Limit(limit).
-		Order("migrants.updated_at DESC").
-		Find(&migrants).Error
+		// Vérifier l'unicité du numéro de passeport
+		if identite.NumeroPasseport != "" {
+			if _, err := depot.ParPasseport(identite.NumeroPasseport, identite.UUID); err == nil {
+				return problemes.Nouveau(fiber.StatusConflict, problemes.CodeDoublon, "Une identité avec ce numéro de passeport existe déjà")
+			}
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch migrants",
-			"error":   err.Error(),
+		// Créer l'identité
+		if err := depot.Creer(identite); err != nil {
+			return problemes.Echec("Cannot create identite", err)
+		}
+
+		// Criblage contre les listes de surveillance : n'empêche pas l'enregistrement
+		hits, err := criblage.Cribler(*identite)
+		if err != nil {
+			avertissements = append(avertissements, depots.AvertissementCriblage)
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"status":         "success",
+			"message":        "Identite created successfully",
+			"data":           identite,
+			"warnings":       avertissements,
+			"watchlist_hits": hits,
 		})
 	}
+}
 
-	// Calculate total pages
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// UpdateIdentite met à jour une identité
+func UpdateIdentite(depot depots.Identites, criblage depots.Criblage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identite, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Identite not found", err)
+		}
 
-	// Prepare pagination metadata
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		if !unites.AccederIdentite(c, *identite) {
+			return unites.Refus(c)
+		}
 
-	// Return response
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Migrants retrieved successfully for identite_uuid: " + identiteUUID,
-		"data":       migrants,
-		"pagination": pagination,
-	})
-}
+		if !versions.Correspond(c, identite.Version) {
+			return versions.Conflit(c, identite.Version, identite)
+		}
 
-// GetIdentite récupère une identité par UUID
-func GetIdentite(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+		var updateData models.Identite
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Invalid input").Cause(err)
+		}
 
-	var identite models.Identite
-	err := db.Where("uuid = ?", uuid).First(&identite).Error
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Identite not found",
-		})
-	}
+		// Conserver l'UUID et l'unité propriétaire
+		updateData.UUID = identite.UUID
+		updateData.UniteUUID = identite.UniteUUID
 
-	return c.JSON(fiber.Map{
-		"status": "success",
-		"data":   identite,
-	})
-}
+		// Vérifier la cohérence des dates après application des modifications
+		dates := *identite
+		if !updateData.DateNaissance.IsZero() {
+			dates.DateNaissance = updateData.DateNaissance
+		}
+		if !updateData.DateEmission.IsZero() {
+			dates.DateEmission = updateData.DateEmission
+		}
+		if !updateData.DateExpiration.IsZero() {
+			dates.DateExpiration = updateData.DateExpiration
+		}
+		erreursDates, avertissements := VerifierDatesDocument(dates)
+		if len(erreursDates) > 0 {
+			return problemes.Messages(problemes.CodeValidation, erreursDates)
+		}
 
-// CreateIdentite crée une nouvelle identité
-func CreateIdentite(c *fiber.Ctx) error {
-	db := database.DB
-	identite := new(models.Identite)
-
-	if err := c.BodyParser(identite); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid input",
-			"error":   err.Error(),
-		})
-	}
+		// Vérifier l'unicité du numéro de passeport
+		if updateData.NumeroPasseport != "" && updateData.NumeroPasseport != identite.NumeroPasseport {
+			if _, err := depot.ParPasseport(updateData.NumeroPasseport, identite.UUID); err == nil {
+				return problemes.Nouveau(fiber.StatusConflict, problemes.CodeDoublon, "Une identité avec ce numéro de passeport existe déjà")
+			}
+		}
 
-	// Générer l'UUID
-	identite.UUID = utils.GenerateUUID()
-
-	// Validation des données
-	if errors := utils.ValidateStruct(*identite); len(errors) > 0 {
-		var errorMessages []string
-		for _, err := range errors {
-			switch err.FailedField {
-			case "Identite.Nom":
-				errorMessages = append(errorMessages, "Le nom est requis")
-			case "Identite.Postnom":
-				errorMessages = append(errorMessages, "Le postnom est requis")
-			case "Identite.Prenom":
-				errorMessages = append(errorMessages, "Le prénom est requis")
-			case "Identite.DateNaissance":
-				errorMessages = append(errorMessages, "La date de naissance est requise")
-			case "Identite.LieuNaissance":
-				errorMessages = append(errorMessages, "Le lieu de naissance est requis")
-			case "Identite.Sexe":
-				if err.Tag == "oneof" {
-					errorMessages = append(errorMessages, "Le sexe doit être 'M' ou 'F'")
-				} else {
-					errorMessages = append(errorMessages, "Le sexe est requis")
+		// Mettre à jour
+		nationalite := identite.Nationalite
+		if err := depot.Modifier(identite, &updateData); err != nil {
+			// Modifié par un autre agent entre la lecture et l'écriture
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuelle, err := depot.Trouver(identite.UUID); err == nil {
+					identite = actuelle
 				}
-			case "Identite.Nationalite":
-				errorMessages = append(errorMessages, "La nationalité est requise")
-			case "Identite.DateEmission":
-				errorMessages = append(errorMessages, "La date d'émission est requise")
-			case "Identite.DateExpiration":
-				errorMessages = append(errorMessages, "La date d'expiration est requise")
-			default:
-				errorMessages = append(errorMessages, fmt.Sprintf("Erreur de validation pour %s: %s", err.FailedField, err.Tag))
+				return versions.Conflit(c, identite.Version, identite)
 			}
+			return problemes.Echec("Cannot update identite", err)
 		}
 
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Erreurs de validation",
-			"errors":  errorMessages,
-		})
-	}
+		// Récupérer l'identité mise à jour
+		if actuelle, err := depot.Trouver(identite.UUID); err == nil {
+			identite = actuelle
+		}
 
-	// Vérifier l'unicité du numéro de passeport
-	if identite.NumeroPasseport != "" {
-		var existingIdentite models.Identite
-		if err := db.Where("numero_passeport = ?", identite.NumeroPasseport).First(&existingIdentite).Error; err == nil {
-			return c.Status(400).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Une identité avec ce numéro de passeport existe déjà",
-			})
+		// Les clusters des tuiles comptent les nationalités de leurs points
+		if identite.Nationalite != nationalite {
+			geolocation.InvalidateTilesOfIdentite(unites.DepotsDe(c).Geolocalisations, identite.UUID)
+		}
+
+		// Nouveau criblage : le nom, la date de naissance ou le passeport ont pu changer
+		hits, err := criblage.Cribler(*identite)
+		if err != nil {
+			avertissements = append(avertissements, depots.AvertissementCriblage)
 		}
-	}
 
-	// Créer l'identité
-	if err := db.Create(&identite).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Cannot create identite",
-			"error":   err.Error(),
+		versions.ETag(c, identite.Version)
+		return c.JSON(fiber.Map{
+			"status":         "success",
+			"message":        "Identite updated successfully",
+			"data":           identite,
+			"warnings":       avertissements,
+			"watchlist_hits": hits,
 		})
 	}
+}
 
-	return c.Status(201).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Identite created successfully",
-		"data":    identite,
-	})
+// Champs d'une identité modifiables par PATCH
+var reglesPatchIdentite = patch.Regles{
+	Modifiables: []string{
+		"nom", "postnom", "prenom", "date_naissance", "lieu_naissance", "sexe", "nationalite",
+		"adresse", "profession", "pays_emetteur", "autorite_emetteur", "date_emission",
+		"date_expiration", "numero_passeport",
+	},
+	Immuables: []string{
+		"uuid", "unite_uuid", "version", "statut_validite", "jours_avant_expiration",
+		"created_at", "updated_at", "deleted_at", "migrants", "geolocalisations",
+	},
 }
 
-// UpdateIdentite met à jour une identité
-func UpdateIdentite(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+// PatchIdentite modifie partiellement une identité (JSON Merge Patch, RFC 7396)
+func PatchIdentite(depot depots.Identites, criblage depots.Criblage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		corps, ok := patch.Lire(c)
+		if !ok {
+			return patch.TypeNonSupporte(c)
+		}
 
-	var identite models.Identite
-	err := db.Where("uuid = ?", uuid).First(&identite).Error
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Identite not found",
-		})
-	}
+		identite, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Identite not found", err)
+		}
 
-	var updateData models.Identite
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid input",
-			"error":   err.Error(),
-		})
-	}
+		if !unites.AccederIdentite(c, *identite) {
+			return unites.Refus(c)
+		}
 
-	// Conserver l'UUID
-	updateData.UUID = identite.UUID
+		if !versions.Correspond(c, identite.Version) {
+			return versions.Conflit(c, identite.Version, identite)
+		}
 
-	// Vérifier l'unicité du numéro de passeport
-	if updateData.NumeroPasseport != "" && updateData.NumeroPasseport != identite.NumeroPasseport {
-		var existingIdentite models.Identite
-		if err := db.Where("numero_passeport = ? AND uuid != ?", updateData.NumeroPasseport, uuid).First(&existingIdentite).Error; err == nil {
-			return c.Status(400).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Une identité avec ce numéro de passeport existe déjà",
-			})
+		corrige, champs, erreurs := patch.Appliquer(*identite, corps, reglesPatchIdentite)
+		if len(erreurs) > 0 {
+			return patch.Invalide(c, erreurs)
 		}
-	}
 
-	// Mettre à jour
-	if err := db.Model(&identite).Updates(&updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Cannot update identite",
-			"error":   err.Error(),
-		})
-	}
+		erreursDates, avertissements := VerifierDatesDocument(corrige)
+		if len(erreursDates) > 0 {
+			return patch.Invalide(c, erreursDates)
+		}
 
-	// Récupérer l'identité mise à jour
-	db.Where("uuid = ?", uuid).First(&identite)
+		// Vérifier l'unicité du numéro de passeport
+		if corrige.NumeroPasseport != identite.NumeroPasseport {
+			if _, err := depot.ParPasseport(corrige.NumeroPasseport, identite.UUID); err == nil {
+				return problemes.Nouveau(fiber.StatusConflict, problemes.CodeDoublon, "Une identité avec ce numéro de passeport existe déjà")
+			}
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Identite updated successfully",
-		"data":    identite,
-	})
-}
+		nationalite := identite.Nationalite
+		err = depot.Modifier(identite, &corrige, champs...)
+		conflit := errors.Is(err, depots.ErrVersionPerimee)
+		if err != nil && !conflit {
+			return problemes.Echec("Cannot update identite", err)
+		}
 
-// DeleteIdentite supprime une identité (soft delete)
-func DeleteIdentite(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+		if actuelle, err := depot.Trouver(identite.UUID); err == nil {
+			identite = actuelle
+		}
 
-	var identite models.Identite
-	err := db.Where("uuid = ?", uuid).First(&identite).Error
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Identite not found",
-		})
-	}
+		// Modifié par un autre agent entre la lecture et l'écriture
+		if conflit {
+			return versions.Conflit(c, identite.Version, identite)
+		}
 
-	// Vérifier si l'identité est utilisée par un migrant
-	var migrantCount int64
-	db.Model(&models.Migrant{}).Where("identite_uuid = ?", uuid).Count(&migrantCount)
-	if migrantCount > 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Impossible de supprimer cette identité car elle est associée à un ou plusieurs migrants",
-		})
-	}
+		// Les clusters des tuiles comptent les nationalités de leurs points
+		if identite.Nationalite != nationalite {
+			geolocation.InvalidateTilesOfIdentite(unites.DepotsDe(c).Geolocalisations, identite.UUID)
+		}
+
+		// Nouveau criblage : le nom, la date de naissance ou le passeport ont pu changer
+		hits, err := criblage.Cribler(*identite)
+		if err != nil {
+			avertissements = append(avertissements, depots.AvertissementCriblage)
+		}
 
-	// Supprimer (soft delete)
-	if err := db.Delete(&identite).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Cannot delete identite",
-			"error":   err.Error(),
+		versions.ETag(c, identite.Version)
+		return c.JSON(fiber.Map{
+			"status":         "success",
+			"message":        "Identite updated successfully",
+			"data":           identite,
+			"warnings":       avertissements,
+			"watchlist_hits": hits,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Identite deleted successfully",
-	})
 }
 
-// ExportIdentitesToExcel exporte les identités vers Excel
-func ExportIdentitesToExcel(c *fiber.Ctx) error {
-	db := database.DB
+// DeleteIdentite supprime une identité (soft delete)
+func DeleteIdentite(depot depots.Identites) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identite, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Identite not found", err)
+		}
 
-	// Récupérer les paramètres de plage de dates
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
+		if !unites.AccederIdentite(c, *identite) {
+			return unites.Refus(c)
+		}
 
-	var identites []models.Identite
+		if !versions.Correspond(c, identite.Version) {
+			return versions.Conflit(c, identite.Version, identite)
+		}
 
-	query := db.Model(&models.Identite{})
+		// Vérifier si l'identité est utilisée par un migrant
+		migrantCount, _ := depot.CompterMigrants(identite.UUID)
+		if migrantCount > 0 {
+			return problemes.Invalide("Impossible de supprimer cette identité car elle est associée à un ou plusieurs migrants")
+		}
 
-	// Appliquer les filtres de dates
-	if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
-	}
+		// Supprimer (soft delete)
+		if err := depot.Supprimer(identite); err != nil {
+			// Modifiée par un autre agent entre la lecture et la suppression
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuelle, err := depot.Trouver(identite.UUID); err == nil {
+					identite = actuelle
+				}
+				return versions.Conflit(c, identite.Version, identite)
+			}
+			return problemes.Echec("Cannot delete identite", err)
+		}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&identites).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch identites for export",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Identite deleted successfully",
 		})
 	}
+}
+
+// ExportIdentitesToExcel exporte les identités vers Excel
+func ExportIdentitesToExcel(depot depots.Identites) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de plage de dates
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Appliquer les filtres de dates
+		var conditions []depots.Condition
+		if startDate != "" {
+			conditions = append(conditions, depots.AuMoins("created_at", startDate))
+		}
+		if endDate != "" {
+			conditions = append(conditions, depots.AuPlus("created_at", endDate))
+		}
+
+		// Récupérer toutes les données
+		identites, err := depot.Lire(unites.PerimetreDe(c).Portee).Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch identites for export", err)
+		}
+
+		return exporterIdentites(c, identites)
+	}
+}
 
+// exporterIdentites écrit le classeur des identités exportées
+func exporterIdentites(c *fiber.Ctx, identites []models.Identite) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -403,13 +491,9 @@ func ExportIdentitesToExcel(c *fiber.Ctx) error {
 
 	// Supprimer la feuille par défaut et créer notre feuille
 	f.DeleteSheet("Sheet1")
-	_, err = f.NewSheet("Identités")
+	_, err := f.NewSheet("Identités")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 
 	// Styles
@@ -545,50 +629,77 @@ func ExportIdentitesToExcel(c *fiber.Ctx) error {
 }
 
 // GetIdentiteStatistics retourne des statistiques sur les identités
-func GetIdentiteStatistics(c *fiber.Ctx) error {
-	db := database.DB
+func GetIdentiteStatistics(depot depots.Identites) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identites := depot.Lire(unites.PerimetreDe(c).Portee)
 
-	var total int64
-	db.Model(&models.Identite{}).Count(&total)
+		total, _ := identites.Compter()
 
-	// Statistiques par sexe
-	var sexeStats []struct {
-		Sexe  string
-		Count int64
-	}
-	db.Model(&models.Identite{}).
-		Select("sexe, COUNT(*) as count").
-		Group("sexe").
-		Scan(&sexeStats)
-
-	// Statistiques par nationalité (top 10)
-	var nationaliteStats []struct {
-		Nationalite string
-		Count       int64
+		// Statistiques par sexe
+		type parSexe struct {
+			Sexe  string
+			Count int64
+		}
+		var sexeStats []parSexe
+		lignes, _ := identites.Repartition("sexe", 0)
+		for _, ligne := range lignes {
+			sexe, _ := ligne["sexe"].(string)
+			sexeStats = append(sexeStats, parSexe{Sexe: sexe, Count: compte(ligne)})
+		}
+
+		// Statistiques par nationalité (top 10)
+		type parNationalite struct {
+			Nationalite string
+			Count       int64
+		}
+		var nationaliteStats []parNationalite
+		lignes, _ = identites.Repartition("nationalite", 10)
+		for _, ligne := range lignes {
+			nationalite, _ := ligne["nationalite"].(string)
+			nationaliteStats = append(nationaliteStats, parNationalite{Nationalite: nationalite, Count: compte(ligne)})
+		}
+
+		// Identités avec/sans passeport
+		withPassport, _ := identites.Compter(depots.Renseigne("numero_passeport"))
+		withoutPassport, _ := identites.Compter(depots.Vide("numero_passeport"))
+
+		// Validité des documents de voyage
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		expires, _ := identites.Compter(depots.Avant("date_expiration", today))
+		expirationProche, _ := identites.Compter(
+			depots.AuMoins("date_expiration", today),
+			depots.Avant("date_expiration", today.AddDate(0, 0, models.DelaiExpirationProche+1)))
+
+		return c.JSON(fiber.Map{
+			"status": "success",
+			"data": fiber.Map{
+				"total":           total,
+				"par_sexe":        sexeStats,
+				"par_nationalite": nationaliteStats,
+				"avec_passeport":  withPassport,
+				"sans_passeport":  withoutPassport,
+				"par_validite": fiber.Map{
+					models.ValiditeValide:           total - expires - expirationProche,
+					models.ValiditeExpirationProche: expirationProche,
+					models.ValiditeExpire:           expires,
+				},
+			},
+		})
 	}
-	db.Model(&models.Identite{}).
-		Select("nationalite, COUNT(*) as count").
-		Group("nationalite").
-		Order("count DESC").
-		Limit(10).
-		Scan(&nationaliteStats)
-
-	// Identités avec/sans passeport
-	var withPassport int64
-	var withoutPassport int64
-	db.Model(&models.Identite{}).Where("numero_passeport != ''").Count(&withPassport)
-	db.Model(&models.Identite{}).Where("numero_passeport = '' OR numero_passeport IS NULL").Count(&withoutPassport)
-
-	return c.JSON(fiber.Map{
-		"status": "success",
-		"data": fiber.Map{
-			"total":           total,
-			"par_sexe":        sexeStats,
-			"par_nationalite": nationaliteStats,
-			"avec_passeport":  withPassport,
-			"sans_passeport":  withoutPassport,
-		},
-	})
+}
+
+// compte retourne le nombre d'une ligne de répartition
+func compte(ligne map[string]interface{}) int64 {
+	switch n := ligne["count"].(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
 }
 
 // ScanDocument - Scanner un document et retourner le fichier au frontend
@@ -599,19 +710,13 @@ func ScanDocument(c *fiber.Ctx) error {
 	// Déclencher le scan
 	scannedFilePath, err := scannerService.ScanDocument()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": fmt.Sprintf("Erreur lors du scan: %v", err),
-		})
+		return problemes.Echec("Erreur lors du scan", err)
 	}
 
 	// Lire le fichier scanné
 	fileData, err := os.ReadFile(scannedFilePath)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": fmt.Sprintf("Erreur lors de la lecture du fichier: %v", err),
-		})
+		return problemes.Echec("Erreur lors de la lecture du fichier", err)
 	}
 
 	// Encoder le fichier en base64 pour le frontend
@@ -634,20 +739,14 @@ func ScanDocument(c *fiber.Ctx) error {
 func GetScannedFile(c *fiber.Ctx) error {
 	fileName := c.Params("filename")
 	if fileName == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Nom de fichier requis",
-		})
+		return problemes.Invalide("Nom de fichier requis")
 	}
 
 	filePath := filepath.Join("./scans", fileName)
 
 	// Vérifier si le fichier existe
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Fichier non trouvé",
-		})
+		return problemes.Introuvable("Fichier non trouvé")
 	}
 
 	// Servir le fichier
@@ -660,10 +759,7 @@ func ListAvailableScanners(c *fiber.Ctx) error {
 
 	scanners, err := scannerService.ListScanners()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": fmt.Sprintf("Erreur lors de la récupération des scanners: %v", err),
-		})
+		return problemes.Echec("Erreur lors de la récupération des scanners", err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -0,0 +1,447 @@
+package identites
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Heure locale du contrôle nocturne de validité des documents
+const heureControleValidite = 2
+
+// Titres des alertes ouvertes par le contrôle de validité
+const (
+	titreAlerteExpire           = "Document de voyage expiré"
+	titreAlerteExpirationProche = "Document de voyage bientôt expiré"
+)
+
+// VerifierDatesDocument contrôle la cohérence des dates d'un document.
+// Les erreurs bloquent l'enregistrement, les avertissements sont renvoyés au client.
+func VerifierDatesDocument(identite models.Identite) ([]string, []string) {
+	var erreurs, avertissements []string
+	now := time.Now()
+
+	if !identite.DateEmission.IsZero() && !identite.DateExpiration.IsZero() &&
+		!identite.DateExpiration.After(identite.DateEmission) {
+		erreurs = append(erreurs, "La date d'expiration doit être postérieure à la date d'émission")
+	}
+	if identite.DateEmission.After(now) {
+		erreurs = append(erreurs, "La date d'émission ne peut pas être dans le futur")
+	}
+	if !identite.DateNaissance.IsZero() && !identite.DateEmission.IsZero() &&
+		identite.DateEmission.Before(identite.DateNaissance) {
+		erreurs = append(erreurs, "La date d'émission ne peut pas précéder la date de naissance")
+	}
+
+	switch statut, jours := identite.ValiditeAu(now); statut {
+	case models.ValiditeExpire:
+		avertissements = append(avertissements, fmt.Sprintf("Le document est expiré depuis %d jour(s)", -jours))
+	case models.ValiditeExpirationProche:
+		avertissements = append(avertissements, fmt.Sprintf("Le document expire dans %d jour(s)", jours))
+	}
+
+	return erreurs, avertissements
+}
+
+// requeteExpiration sélectionne les identités dont le document expire dans les prochains jours
+func requeteExpiration(c *fiber.Ctx) (*gorm.DB, int) {
+	days, err := strconv.Atoi(c.Query("days", strconv.Itoa(models.DelaiExpirationProche)))
+	if err != nil || days < 0 {
+		days = models.DelaiExpirationProche
+	}
+	if days > 3650 {
+		days = 3650
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := database.DB.Scopes(unites.PerimetreDe(c).Identites).Model(&models.Identite{}).
+		Where("date_expiration < ?", today.AddDate(0, 0, days+1))
+
+	// Les documents déjà expirés sont inclus par défaut
+	if c.Query("include_expired", "true") == "false" {
+		query = query.Where("date_expiration >= ?", today)
+	}
+	if nationalite := c.Query("nationalite", ""); nationalite != "" {
+		query = query.Where("nationalite = ?", nationalite)
+	}
+	if paysEmetteur := c.Query("pays_emetteur", ""); paysEmetteur != "" {
+		query = query.Where("pays_emetteur = ?", paysEmetteur)
+	}
+
+	return query, days
+}
+
+// GetExpiringIdentites - Identités dont le document expire dans les prochains jours
+// GET /api/identites/expiring?days=90&include_expired=true
+func GetExpiringIdentites(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "15"))
+	if err != nil || limit <= 0 {
+		limit = 15
+	}
+	offset := (page - 1) * limit
+
+	query, days := requeteExpiration(c)
+
+	var identites []models.Identite
+	var totalRecords int64
+	query.Count(&totalRecords)
+
+	// Répartition sur l'ensemble du rapport
+	var expires int64
+	now := time.Now()
+	expiresQuery, _ := requeteExpiration(c)
+	expiresQuery.
+		Where("date_expiration < ?", time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)).
+		Count(&expires)
+
+	err = query.
+		Preload("Migrants", func(db *gorm.DB) *gorm.DB {
+			return db.Select("uuid", "identite_uuid", "numero_identifiant", "statut_migratoire", "ville_actuelle", "telephone")
+		}).
+		Offset(offset).
+		Limit(limit).
+		Order("date_expiration ASC").
+		Find(&identites).Error
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch expiring identites", err)
+	}
+
+	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+
+	pagination := map[string]interface{}{
+		"total_records": totalRecords,
+		"total_pages":   totalPages,
+		"current_page":  page,
+		"page_size":     limit,
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Expiring identites retrieved successfully",
+		"data":       identites,
+		"pagination": pagination,
+		"summary": fiber.Map{
+			"days":              days,
+			"expires":           expires,
+			"expiration_proche": totalRecords - expires,
+		},
+	})
+}
+
+// ExportExpiringIdentitesToExcel exporte le rapport des documents expirants vers Excel
+func ExportExpiringIdentitesToExcel(c *fiber.Ctx) error {
+	query, days := requeteExpiration(c)
+
+	var identites []models.Identite
+	if err := query.Order("date_expiration ASC").Find(&identites).Error; err != nil {
+		return problemes.Echec("Failed to fetch expiring identites for export", err)
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	sheet := "Documents expirants"
+	f.DeleteSheet("Sheet1")
+	if _, err := f.NewSheet(sheet); err != nil {
+		return problemes.Echec("Failed to create Excel sheet", err)
+	}
+
+	// Styles
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Bold:   true,
+			Size:   12,
+			Family: "Calibri",
+			Color:  "FFFFFF",
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"4472C4"},
+			Pattern: 1,
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "center",
+			Vertical:   "center",
+		},
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+	})
+
+	dataStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Size:   11,
+			Family: "Calibri",
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "left",
+			Vertical:   "center",
+		},
+		Border: []excelize.Border{
+			{Type: "left", Color: "CCCCCC", Style: 1},
+			{Type: "top", Color: "CCCCCC", Style: 1},
+			{Type: "bottom", Color: "CCCCCC", Style: 1},
+			{Type: "right", Color: "CCCCCC", Style: 1},
+		},
+	})
+
+	// Lignes des documents expirés mises en évidence
+	expiredStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{
+			Size:   11,
+			Family: "Calibri",
+			Color:  "9C0006",
+		},
+		Fill: excelize.Fill{
+			Type:    "pattern",
+			Color:   []string{"FFC7CE"},
+			Pattern: 1,
+		},
+		Alignment: &excelize.Alignment{
+			Horizontal: "left",
+			Vertical:   "center",
+		},
+		Border: []excelize.Border{
+			{Type: "left", Color: "CCCCCC", Style: 1},
+			{Type: "top", Color: "CCCCCC", Style: 1},
+			{Type: "bottom", Color: "CCCCCC", Style: 1},
+			{Type: "right", Color: "CCCCCC", Style: 1},
+		},
+	})
+
+	headers := []string{
+		"Nom",
+		"Postnom",
+		"Prénom",
+		"Nationalité",
+		"N° Passeport",
+		"Pays émetteur",
+		"Date d'émission",
+		"Date d'expiration",
+		"Jours restants",
+		"Statut",
+	}
+
+	row := 1
+	for i, header := range headers {
+		cell := fmt.Sprintf("%c%d", 'A'+i, row)
+		f.SetCellValue(sheet, cell, header)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	f.SetRowHeight(sheet, row, 25)
+
+	libelles := map[string]string{
+		models.ValiditeExpire:           "Expiré",
+		models.ValiditeExpirationProche: "Expire bientôt",
+		models.ValiditeValide:           "Valide",
+	}
+
+	for i, identite := range identites {
+		dataRow := row + 1 + i
+
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", dataRow), identite.Nom)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", dataRow), identite.Postnom)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", dataRow), identite.Prenom)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", dataRow), identite.Nationalite)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", dataRow), identite.NumeroPasseport)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", dataRow), identite.PaysEmetteur)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", dataRow), identite.DateEmission.Format("02/01/2006"))
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", dataRow), identite.DateExpiration.Format("02/01/2006"))
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", dataRow), identite.JoursAvantExpiration)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", dataRow), libelles[identite.StatutValidite])
+
+		style := dataStyle
+		if identite.StatutValidite == models.ValiditeExpire {
+			style = expiredStyle
+		}
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", dataRow), fmt.Sprintf("J%d", dataRow), style)
+	}
+
+	columnWidths := map[string]float64{
+		"A": 15, // Nom
+		"B": 15, // Postnom
+		"C": 15, // Prénom
+		"D": 20, // Nationalité
+		"E": 15, // N° Passeport
+		"F": 20, // Pays émetteur
+		"G": 15, // Date d'émission
+		"H": 15, // Date d'expiration
+		"I": 14, // Jours restants
+		"J": 16, // Statut
+	}
+	for col, width := range columnWidths {
+		f.SetColWidth(sheet, col, col, width)
+	}
+
+	f.SetActiveSheet(0)
+
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=documents_expirants_%dj.xlsx", days))
+
+	return f.Write(c.Response().BodyWriter())
+}
+
+// EvaluerValidites réévalue la validité des documents de tous les migrants :
+// ouvre une alerte administrative pour les documents expirés ou expirant bientôt
+// et résout les alertes devenues sans objet (document renouvelé ou statut aggravé)
+func EvaluerValidites(db *gorm.DB) (int, int, error) {
+	now := time.Now()
+	limite := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, models.DelaiExpirationProche+1)
+
+	var documents []struct {
+		MigrantUUID     string
+		DateExpiration  time.Time
+		NumeroPasseport string
+	}
+	err := db.Table("migrants m").
+		Select("m.uuid as migrant_uuid, i.date_expiration, i.numero_passeport").
+		Joins("JOIN identites i ON i.uuid = m.identite_uuid AND i.deleted_at IS NULL").
+		Where("m.deleted_at IS NULL AND i.date_expiration < ?", limite).
+		Scan(&documents).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var alertes []models.Alert
+	err = db.Where("type_alerte = ? AND statut = ? AND titre IN ?",
+		"administrative", "active", []string{titreAlerteExpire, titreAlerteExpirationProche}).
+		Find(&alertes).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	ouvertes := map[string][]models.Alert{}
+	for _, alerte := range alertes {
+		ouvertes[alerte.MigrantUUID] = append(ouvertes[alerte.MigrantUUID], alerte)
+	}
+
+	creees, resolues := 0, 0
+	attendues := map[string]string{}
+	for _, doc := range documents {
+		identite := models.Identite{DateExpiration: doc.DateExpiration}
+		statut, jours := identite.ValiditeAu(now)
+
+		alerte := models.Alert{
+			UUID:        utils.GenerateUUID(),
+			MigrantUUID: doc.MigrantUUID,
+			TypeAlerte:  "administrative",
+			Statut:      "active",
+		}
+		switch statut {
+		case models.ValiditeExpire:
+			alerte.Titre = titreAlerteExpire
+			alerte.NiveauGravite = "danger"
+			alerte.Description = fmt.Sprintf("Le passeport %s est expiré depuis le %s",
+				doc.NumeroPasseport, doc.DateExpiration.Format("02/01/2006"))
+			alerte.ActionRequise = "Inviter le migrant à renouveler son document de voyage"
+		case models.ValiditeExpirationProche:
+			alerte.Titre = titreAlerteExpirationProche
+			alerte.NiveauGravite = "warning"
+			alerte.Description = fmt.Sprintf("Le passeport %s expire le %s (dans %d jour(s))",
+				doc.NumeroPasseport, doc.DateExpiration.Format("02/01/2006"), jours)
+			alerte.ActionRequise = "Informer le migrant de l'expiration prochaine de son document"
+			expiration := doc.DateExpiration
+			alerte.DateExpiration = &expiration
+		default:
+			continue
+		}
+		attendues[doc.MigrantUUID] = alerte.Titre
+
+		dejaOuverte := false
+		for _, existante := range ouvertes[doc.MigrantUUID] {
+			if existante.Titre == alerte.Titre {
+				dejaOuverte = true
+			}
+		}
+		if dejaOuverte {
+			continue
+		}
+		if err := db.Omit("Migrant").Create(&alerte).Error; err != nil {
+			return creees, resolues, err
+		}
+		creees++
+	}
+
+	// Résoudre les alertes qui ne correspondent plus au statut du document
+	for migrantUUID, existantes := range ouvertes {
+		for _, existante := range existantes {
+			if attendues[migrantUUID] == existante.Titre {
+				continue
+			}
+			err := db.Model(&existante).Updates(map[string]interface{}{
+				"statut":             "resolved",
+				"date_resolution":    &now,
+				"comment_resolution": "Résolue automatiquement par le contrôle de validité des documents",
+			}).Error
+			if err != nil {
+				return creees, resolues, err
+			}
+			resolues++
+		}
+	}
+
+	return creees, resolues, nil
+}
+
+// EvaluateValidites - Lancer immédiatement le contrôle de validité des documents
+// POST /api/identites/expiring/evaluate
+func EvaluateValidites(c *fiber.Ctx) error {
+	creees, resolues, err := EvaluerValidites(database.DB)
+	if err != nil {
+		return problemes.Echec("Failed to evaluate document validity", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Document validity evaluated successfully",
+		"data": fiber.Map{
+			"alertes_creees":   creees,
+			"alertes_resolues": resolues,
+		},
+	})
+}
+
+// DemarrerControleNocturne réévalue la validité des documents chaque nuit
+func DemarrerControleNocturne() {
+	go func() {
+		for {
+			now := time.Now()
+			prochain := time.Date(now.Year(), now.Month(), now.Day(), heureControleValidite, 0, 0, 0, now.Location())
+			if !prochain.After(now) {
+				prochain = prochain.AddDate(0, 0, 1)
+			}
+			time.Sleep(time.Until(prochain))
+
+			creees, resolues, err := EvaluerValidites(database.DB)
+			if err != nil {
+				log.Printf("❌ Contrôle de validité des documents: %v", err)
+				continue
+			}
+			log.Printf("🛂 Contrôle de validité des documents : %d alertes créées, %d résolues", creees, resolues)
+		}
+	}()
+}
@@ -0,0 +1,223 @@
+package identites
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+func TestVerifierDatesDocument(t *testing.T) {
+	now := time.Now()
+	naissance := now.AddDate(-30, 0, 0)
+	cas := []struct {
+		nom            string
+		identite       models.Identite
+		erreurs        []string
+		avertissements []string
+	}{
+		{
+			nom:      "document valide",
+			identite: models.Identite{DateNaissance: naissance, DateEmission: now.AddDate(-1, 0, 0), DateExpiration: now.AddDate(4, 0, 0)},
+		},
+		{
+			nom:      "expiration avant émission",
+			identite: models.Identite{DateEmission: now.AddDate(-1, 0, 0), DateExpiration: now.AddDate(-2, 0, 0)},
+			erreurs:  []string{"La date d'expiration doit être postérieure à la date d'émission"},
+			// Le document est aussi expiré
+			avertissements: []string{"Le document est expiré depuis"},
+		},
+		{
+			nom:            "expiration le jour de l'émission",
+			identite:       models.Identite{DateEmission: now.AddDate(-1, 0, 0), DateExpiration: now.AddDate(-1, 0, 0)},
+			erreurs:        []string{"La date d'expiration doit être postérieure à la date d'émission"},
+			avertissements: []string{"Le document est expiré depuis"},
+		},
+		{
+			nom:      "émission future",
+			identite: models.Identite{DateEmission: now.AddDate(0, 0, 2), DateExpiration: now.AddDate(5, 0, 0)},
+			erreurs:  []string{"La date d'émission ne peut pas être dans le futur"},
+		},
+		{
+			nom:      "émission avant la naissance",
+			identite: models.Identite{DateNaissance: naissance, DateEmission: naissance.AddDate(0, 0, -1), DateExpiration: now.AddDate(1, 0, 0)},
+			erreurs:  []string{"La date d'émission ne peut pas précéder la date de naissance"},
+		},
+		{
+			nom:            "expiration proche",
+			identite:       models.Identite{DateEmission: now.AddDate(-5, 0, 0), DateExpiration: now.AddDate(0, 0, 30)},
+			avertissements: []string{"Le document expire dans 30 jour(s)"},
+		},
+		{
+			nom:            "document expiré",
+			identite:       models.Identite{DateEmission: now.AddDate(-5, 0, 0), DateExpiration: now.AddDate(0, 0, -3)},
+			avertissements: []string{"Le document est expiré depuis 3 jour(s)"},
+		},
+		{nom: "dates inconnues", identite: models.Identite{}},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			erreurs, avertissements := VerifierDatesDocument(tc.identite)
+			if len(erreurs) != len(tc.erreurs) {
+				t.Fatalf("erreurs %v, attendues %v", erreurs, tc.erreurs)
+			}
+			for i := range tc.erreurs {
+				if erreurs[i] != tc.erreurs[i] {
+					t.Errorf("erreur %q, attendue %q", erreurs[i], tc.erreurs[i])
+				}
+			}
+			if len(avertissements) != len(tc.avertissements) {
+				t.Fatalf("avertissements %v, attendus %v", avertissements, tc.avertissements)
+			}
+			for i := range tc.avertissements {
+				if !strings.HasPrefix(avertissements[i], tc.avertissements[i]) {
+					t.Errorf("avertissement %q, attendu %q", avertissements[i], tc.avertissements[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValiditeAu(t *testing.T) {
+	ref := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	cas := []struct {
+		expiration time.Time
+		statut     string
+		jours      int
+	}{
+		{time.Time{}, "", 0},
+		{time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), models.ValiditeExpire, -1},
+		// Le jour de l'expiration, le document est encore valide
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), models.ValiditeExpirationProche, 0},
+		{time.Date(2026, 5, 30, 0, 0, 0, 0, time.UTC), models.ValiditeExpirationProche, models.DelaiExpirationProche},
+		{time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC), models.ValiditeValide, models.DelaiExpirationProche + 1},
+	}
+	for _, tc := range cas {
+		identite := models.Identite{DateExpiration: tc.expiration}
+		if statut, jours := identite.ValiditeAu(ref); statut != tc.statut || jours != tc.jours {
+			t.Errorf("expiration %s : %q à %d jour(s), attendu %q à %d", tc.expiration.Format("2006-01-02"), statut, jours, tc.statut, tc.jours)
+		}
+	}
+}
+
+// documentsExpirants enregistre un migrant par échéance de document (en jours, négative si expiré)
+func documentsExpirants(t *testing.T, echeances map[string]int) {
+	t.Helper()
+	now := time.Now()
+	for uuid, jours := range echeances {
+		identite := models.Identite{
+			UUID: "identite-" + uuid, Nom: strings.ToUpper(uuid), NumeroPasseport: "OP-" + uuid,
+			DateEmission: now.AddDate(-5, 0, 0), DateExpiration: now.AddDate(0, 0, jours),
+		}
+		migrant := models.Migrant{UUID: uuid, IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-" + uuid, StatutMigratoire: "regulier"}
+		if err := database.DB.Create(&identite).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := database.DB.Omit("Identite").Create(&migrant).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetExpiringIdentites(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	token := basetest.Administrateur(t, db)
+	documentsExpirants(t, map[string]int{"expire": -10, "proche": 30, "lointain": 200, "valide": 1000})
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/expiring", GetExpiringIdentites)
+
+	cas := []struct {
+		requete string
+		uuids   []string
+		expires int64
+		proches int64
+	}{
+		// Documents expirés inclus par défaut, triés par date d'expiration
+		{"", []string{"identite-expire", "identite-proche"}, 1, 1},
+		{"include_expired=false", []string{"identite-proche"}, 0, 1},
+		{"days=365", []string{"identite-expire", "identite-proche", "identite-lointain"}, 1, 2},
+		// Délai invalide : délai par défaut
+		{"days=-4", []string{"identite-expire", "identite-proche"}, 1, 1},
+	}
+	for _, tc := range cas {
+		t.Run(tc.requete, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest("GET", "/expiring?token="+token+"&"+tc.requete, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var corps struct {
+				Data    []models.Identite `json:"data"`
+				Summary struct {
+					Expires          int64 `json:"expires"`
+					ExpirationProche int64 `json:"expiration_proche"`
+				} `json:"summary"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&corps); err != nil {
+				t.Fatal(err)
+			}
+			var uuids []string
+			for _, identite := range corps.Data {
+				uuids = append(uuids, identite.UUID)
+			}
+			if strings.Join(uuids, ",") != strings.Join(tc.uuids, ",") {
+				t.Errorf("identités %v, attendues %v", uuids, tc.uuids)
+			}
+			if corps.Summary.Expires != tc.expires || corps.Summary.ExpirationProche != tc.proches {
+				t.Errorf("%d expiré(s) et %d proche(s), attendus %d et %d", corps.Summary.Expires, corps.Summary.ExpirationProche, tc.expires, tc.proches)
+			}
+		})
+	}
+}
+
+func TestEvaluerValidites(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	documentsExpirants(t, map[string]int{"expire": -10, "proche": 30, "valide": 1000})
+
+	titres := func() map[string]string {
+		t.Helper()
+		var alertes []models.Alert
+		if err := db.Where("statut = ?", "active").Find(&alertes).Error; err != nil {
+			t.Fatal(err)
+		}
+		resultat := map[string]string{}
+		for _, alerte := range alertes {
+			resultat[alerte.MigrantUUID] = alerte.Titre
+		}
+		return resultat
+	}
+
+	if creees, resolues, err := EvaluerValidites(db); err != nil || creees != 2 || resolues != 0 {
+		t.Fatalf("premier contrôle : %d créée(s), %d résolue(s), erreur %v ; attendu 2 et 0", creees, resolues, err)
+	}
+	if a := titres(); len(a) != 2 || a["expire"] != titreAlerteExpire || a["proche"] != titreAlerteExpirationProche {
+		t.Errorf("alertes actives %v", a)
+	}
+
+	// Contrôle suivant sans changement : pas de nouvelle alerte
+	if creees, resolues, err := EvaluerValidites(db); err != nil || creees != 0 || resolues != 0 {
+		t.Fatalf("second contrôle : %d créée(s), %d résolue(s), erreur %v ; attendu 0 et 0", creees, resolues, err)
+	}
+
+	// Passeport renouvelé : l'alerte est résolue
+	err := db.Model(&models.Identite{}).Where("uuid = ?", "identite-proche").
+		UpdateColumn("date_expiration", time.Now().AddDate(10, 0, 0)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creees, resolues, err := EvaluerValidites(db); err != nil || creees != 0 || resolues != 1 {
+		t.Fatalf("après renouvellement : %d créée(s), %d résolue(s), erreur %v ; attendu 0 et 1", creees, resolues, err)
+	}
+	if a := titres(); len(a) != 1 || a["expire"] != titreAlerteExpire {
+		t.Errorf("alertes actives après renouvellement %v", a)
+	}
+}
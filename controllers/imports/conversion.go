@@ -0,0 +1,176 @@
+package imports
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/controllers/identites"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// erreurs accumule les erreurs d'une ligne
+type erreurs struct {
+	ligne int
+	liste []models.ErreurImport
+}
+
+// ajouter enregistre une erreur ; seule la première erreur de chaque colonne est conservée
+func (e *erreurs) ajouter(champ, message string) {
+	colonne := libelleColonne(champ)
+	for _, existante := range e.liste {
+		if existante.Colonne == colonne {
+			return
+		}
+	}
+	e.liste = append(e.liste, models.ErreurImport{
+		Ligne:   e.ligne,
+		Colonne: colonne,
+		Message: message,
+	})
+}
+
+// date convertit une colonne de date, vide si la cellule est vide
+func (e *erreurs) date(l ligne, champ string) time.Time {
+	v := l.val(champ)
+	if v == "" {
+		return time.Time{}
+	}
+	t, err := parseDate(v)
+	if err != nil {
+		e.ajouter(champ, err.Error())
+	}
+	return t
+}
+
+// validation traduit les erreurs de utils.ValidateStruct ; les champs ignorés
+// sont générés par l'import (UUID, numéro d'identifiant) ou déjà enregistrés
+func (e *erreurs) validation(modele interface{}, ignores ...string) {
+	for _, err := range utils.ValidateStruct(modele) {
+		ignore := false
+		for _, prefixe := range ignores {
+			if strings.HasPrefix(err.FailedField, prefixe) {
+				ignore = true
+			}
+		}
+		if ignore {
+			continue
+		}
+
+		champ := champJSON(reflect.TypeOf(modele), err.FailedField)
+		switch err.Tag {
+		case "required":
+			e.ajouter(champ, "Valeur requise")
+		case "oneof":
+			e.ajouter(champ, fmt.Sprintf("Valeur invalide, valeurs acceptées : %s", strings.ReplaceAll(err.Value, " ", ", ")))
+		default:
+			e.ajouter(champ, fmt.Sprintf("Règle de validation %q non respectée", err.Tag))
+		}
+	}
+}
+
+// champJSON retrouve le nom JSON d'un champ à partir de son chemin ("Migrant.Identite.Nom" donne "nom")
+func champJSON(t reflect.Type, chemin string) string {
+	parties := strings.Split(chemin, ".")
+	nom := parties[len(parties)-1]
+	for _, partie := range parties[1:] {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		field, ok := t.FieldByName(partie)
+		if !ok {
+			break
+		}
+		t = field.Type
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+			nom = tag
+		}
+	}
+	return nom
+}
+
+// code convertit un libellé saisi en code ("Réfugié" donne "refugie", "Demandeur asile" donne "demandeur_asile")
+func code(s string) string {
+	return strings.ReplaceAll(strings.ToLower(utils.NormalizeName(s)), " ", "_")
+}
+
+// sexe accepte M/F ainsi que les libellés courants
+func sexe(s string) string {
+	switch utils.NormalizeName(s) {
+	case "M", "MASCULIN", "HOMME", "H":
+		return "M"
+	case "F", "FEMININ", "FEMME":
+		return "F"
+	}
+	return strings.ToUpper(s)
+}
+
+// identiteDepuisLigne construit et valide une identité à partir d'une ligne
+func identiteDepuisLigne(l ligne) (*models.Identite, []models.ErreurImport) {
+	e := &erreurs{ligne: l.numero}
+	identite := &models.Identite{
+		UUID:             utils.GenerateUUID(),
+		Nom:              l.val("nom"),
+		Postnom:          l.val("postnom"),
+		Prenom:           l.val("prenom"),
+		LieuNaissance:    l.val("lieu_naissance"),
+		Sexe:             sexe(l.val("sexe")),
+		Nationalite:      l.val("nationalite"),
+		Adresse:          l.val("adresse"),
+		Profession:       l.val("profession"),
+		PaysEmetteur:     l.val("pays_emetteur"),
+		AutoriteEmetteur: l.val("autorite_emetteur"),
+		NumeroPasseport:  l.val("numero_passeport"),
+	}
+	identite.DateNaissance = e.date(l, "date_naissance")
+	identite.DateEmission = e.date(l, "date_emission")
+	identite.DateExpiration = e.date(l, "date_expiration")
+
+	e.validation(*identite)
+	if len(e.liste) == 0 {
+		erreursDates, _ := identites.VerifierDatesDocument(*identite)
+		for _, message := range erreursDates {
+			e.ajouter("date_expiration", message)
+		}
+	}
+	if len(e.liste) > 0 {
+		return nil, e.liste
+	}
+
+	return identite, nil
+}
+
+// migrantDepuisLigne construit un migrant à partir d'une ligne ; son identité est
+// rattachée et validée ensuite (voir preparerMigrants)
+func migrantDepuisLigne(l ligne) (*models.Migrant, []models.ErreurImport) {
+	e := &erreurs{ligne: l.numero}
+	migrant := &models.Migrant{
+		UUID:                  utils.GenerateUUID(),
+		BureauEnregistrement:  l.val("bureau_enregistrement"),
+		Telephone:             l.val("telephone"),
+		Email:                 l.val("email"),
+		AdresseActuelle:       l.val("adresse_actuelle"),
+		VilleActuelle:         l.val("ville_actuelle"),
+		PaysActuel:            l.val("pays_actuel"),
+		SituationMatrimoniale: code(l.val("situation_matrimoniale")),
+		PersonneContact:       l.val("personne_contact"),
+		TelephoneContact:      l.val("telephone_contact"),
+		StatutMigratoire:      code(l.val("statut_migratoire")),
+		PointEntree:           l.val("point_entree"),
+		PaysDestination:       l.val("pays_destination"),
+	}
+	if v := l.val("nombre_enfants"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			e.ajouter("nombre_enfants", fmt.Sprintf("Nombre invalide %q", v))
+		}
+		migrant.NombreEnfants = n
+	}
+	if dateEntree := e.date(l, "date_entree"); !dateEntree.IsZero() {
+		migrant.DateEntree = &dateEntree
+	}
+	return migrant, e.liste
+}
@@ -0,0 +1,483 @@
+package imports
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/controllers/watchlist"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// Taille des lots enregistrés et fréquence de mise à jour de la progression
+	tailleLot = 200
+	// Nombre maximal d'erreurs conservées par import
+	maxErreursImport = 10000
+	// Nombre de lignes valides renvoyées dans l'aperçu
+	tailleApercu = 20
+)
+
+// enregistrement est une ligne valide prête à être enregistrée
+type enregistrement struct {
+	ligne    int
+	identite *models.Identite // nouvelle identité, nil si le migrant est rattaché à une identité existante
+	migrant  *models.Migrant
+}
+
+// execution porte l'état d'un import pendant son traitement
+type execution struct {
+	db      *gorm.DB
+	job     *models.Importation
+	bureau  string // bureau d'enregistrement des lignes qui n'en précisent pas
+	unite   string // unité organisationnelle propriétaire des lignes importées
+	lignes  []ligne
+	erreurs []models.ErreurImport
+	valides []enregistrement
+}
+
+// maj enregistre l'avancement de l'import pour le suivi par le client
+func (e *execution) maj(champs map[string]interface{}) {
+	if err := e.db.Model(e.job).Updates(champs).Error; err != nil {
+		log.Printf("Import %s : mise à jour de la progression impossible : %v", e.job.UUID, err)
+	}
+}
+
+func (e *execution) progression(traitees int) {
+	if traitees%tailleLot == 0 || traitees == len(e.lignes) {
+		e.job.LignesTraitees = traitees
+		e.maj(map[string]interface{}{"lignes_traitees": traitees})
+	}
+}
+
+func (e *execution) rejeter(erreurs ...models.ErreurImport) {
+	e.erreurs = append(e.erreurs, erreurs...)
+}
+
+// echec termine l'import en erreur
+func (e *execution) echec(message string) {
+	now := time.Now()
+	e.job.Statut = "echoue"
+	e.job.Message = message
+	e.job.DateFin = &now
+	e.maj(map[string]interface{}{"statut": "echoue", "message": message, "date_fin": &now})
+}
+
+// executer valide les lignes puis, hors aperçu, les enregistre en une transaction
+func (e *execution) executer() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Import %s interrompu : %v", e.job.UUID, r)
+			e.echec(fmt.Sprintf("Import interrompu : %v", r))
+		}
+	}()
+
+	now := time.Now()
+	e.job.Statut = "validation"
+	e.job.DateDebut = &now
+	e.job.TotalLignes = len(e.lignes)
+	e.maj(map[string]interface{}{"statut": "validation", "date_debut": &now, "total_lignes": len(e.lignes)})
+
+	var err error
+	if e.job.TypeImport == "identites" {
+		err = e.preparerIdentites()
+	} else {
+		err = e.preparerMigrants()
+	}
+	if err != nil {
+		log.Printf("❌ Import %s: %v", e.job.UUID, err)
+		e.echec("Validation impossible : " + problemes.Titre(problemes.Depuis(err).Code, problemes.Francais))
+		return
+	}
+
+	lignesRejetees := map[int]bool{}
+	for _, erreur := range e.erreurs {
+		lignesRejetees[erreur.Ligne] = true
+	}
+	e.job.LignesValides = len(e.valides)
+	e.job.LignesRejetees = len(lignesRejetees)
+	e.enregistrerErreurs()
+	e.maj(map[string]interface{}{
+		"lignes_valides":  e.job.LignesValides,
+		"lignes_rejetees": e.job.LignesRejetees,
+	})
+
+	switch {
+	case e.job.Apercu:
+		e.terminer(fmt.Sprintf("Aperçu : %d ligne(s) valide(s), %d ligne(s) rejetée(s)", e.job.LignesValides, e.job.LignesRejetees))
+		return
+	case e.job.LignesRejetees > 0 && !e.job.IgnorerErreurs:
+		e.echec(fmt.Sprintf("%d ligne(s) invalide(s) : aucune donnée importée", e.job.LignesRejetees))
+		return
+	}
+
+	if err := e.enregistrer(); err != nil {
+		log.Printf("❌ Import %s: %v", e.job.UUID, err)
+		e.echec("Enregistrement annulé : " + problemes.Titre(problemes.Depuis(err).Code, problemes.Francais))
+		return
+	}
+	e.cribler()
+	e.terminer(fmt.Sprintf("%d ligne(s) importée(s), %d ligne(s) rejetée(s)", e.job.LignesImportees, e.job.LignesRejetees))
+}
+
+func (e *execution) terminer(message string) {
+	now := time.Now()
+	e.job.Statut = "termine"
+	e.job.Message = message
+	e.job.DateFin = &now
+	e.maj(map[string]interface{}{
+		"statut":                       "termine",
+		"message":                      message,
+		"date_fin":                     &now,
+		"lignes_importees":             e.job.LignesImportees,
+		"correspondances_surveillance": e.job.Correspondances,
+	})
+}
+
+// enregistrerErreurs conserve les erreurs par ligne pour la consultation
+func (e *execution) enregistrerErreurs() {
+	erreurs := e.erreurs
+	if len(erreurs) > maxErreursImport {
+		erreurs = erreurs[:maxErreursImport]
+	}
+	for i := range erreurs {
+		erreurs[i].UUID = utils.GenerateUUID()
+		erreurs[i].ImportationUUID = e.job.UUID
+	}
+	if len(erreurs) > 0 {
+		if err := e.db.CreateInBatches(&erreurs, tailleLot).Error; err != nil {
+			log.Printf("Import %s : enregistrement des erreurs impossible : %v", e.job.UUID, err)
+		}
+	}
+}
+
+// =======================
+// VALIDATION
+// =======================
+
+// preparerIdentites valide les lignes et écarte les passeports déjà enregistrés ou en double
+func (e *execution) preparerIdentites() error {
+	existants, err := e.passeportsExistants()
+	if err != nil {
+		return err
+	}
+
+	vus := map[string]int{}
+	for i, l := range e.lignes {
+		identite, rejets := identiteDepuisLigne(l)
+		switch {
+		case len(rejets) > 0:
+			e.rejeter(rejets...)
+		case existants[identite.NumeroPasseport] != nil:
+			e.rejeter(erreurDoublon(l.numero, "numero_passeport", "Une identité avec ce numéro de passeport existe déjà"))
+		case vus[identite.NumeroPasseport] > 0:
+			e.rejeter(erreurDoublon(l.numero, "numero_passeport", fmt.Sprintf("Numéro de passeport déjà présent à la ligne %d", vus[identite.NumeroPasseport])))
+		default:
+			vus[identite.NumeroPasseport] = l.numero
+			e.valides = append(e.valides, enregistrement{ligne: l.numero, identite: identite})
+		}
+		e.progression(i + 1)
+	}
+	return nil
+}
+
+// preparerMigrants valide les lignes ; un migrant est rattaché à l'identité existante
+// portant le même numéro de passeport, sinon une nouvelle identité est créée
+func (e *execution) preparerMigrants() error {
+	existants, err := e.passeportsExistants()
+	if err != nil {
+		return err
+	}
+	emails, err := e.emailsExistants()
+	if err != nil {
+		return err
+	}
+	numeros, err := e.numerosExistants()
+	if err != nil {
+		return err
+	}
+
+	passeportsVus := map[string]int{}
+	emailsVus := map[string]int{}
+	for i, l := range e.lignes {
+		e.progression(i + 1)
+
+		migrant, erreursMigrant := migrantDepuisLigne(l)
+		if migrant.BureauEnregistrement == "" {
+			migrant.BureauEnregistrement = e.bureau
+		}
+		migrant.BureauEnregistrement = models.CodeBureau(migrant.BureauEnregistrement)
+		rejets := &erreurs{ligne: l.numero, liste: erreursMigrant}
+		rec := enregistrement{ligne: l.numero, migrant: migrant}
+
+		// Identité existante ou nouvelle
+		passeport := l.val("numero_passeport")
+		if identite := existants[passeport]; identite != nil {
+			migrant.IdentiteUUID = identite.UUID
+			migrant.Identite = *identite
+		} else {
+			identite, erreursIdentite := identiteDepuisLigne(l)
+			rejets.liste = append(rejets.liste, erreursIdentite...)
+			if identite != nil {
+				rec.identite = identite
+				migrant.IdentiteUUID = identite.UUID
+				migrant.Identite = *identite
+			}
+		}
+
+		// Les champs générés et l'identité (validée à part) ne sont pas contrôlés ici
+		rejets.validation(*migrant, "Migrant.NumeroIdentifiant", "Migrant.IdentiteUUID", "Migrant.Identite.")
+
+		if numero := l.val("numero_identifiant"); numero != "" && numeros[numero] {
+			rejets.ajouter("numero_identifiant", "Ce migrant est déjà enregistré sous ce numéro")
+		}
+		if n := passeportsVus[passeport]; passeport != "" && n > 0 {
+			rejets.ajouter("numero_passeport", fmt.Sprintf("Numéro de passeport déjà présent à la ligne %d", n))
+		}
+		email := strings.ToLower(migrant.Email)
+		switch {
+		case emails[email] && email == "":
+			rejets.ajouter("email", "Email requis : un migrant sans email est déjà enregistré")
+		case emails[email]:
+			rejets.ajouter("email", "Cet email est déjà utilisé")
+		case emailsVus[email] > 0:
+			rejets.ajouter("email", fmt.Sprintf("Email déjà présent à la ligne %d", emailsVus[email]))
+		}
+
+		if len(rejets.liste) > 0 {
+			e.rejeter(rejets.liste...)
+			continue
+		}
+		passeportsVus[passeport] = l.numero
+		emailsVus[email] = l.numero
+		e.valides = append(e.valides, rec)
+	}
+	return nil
+}
+
+func erreurDoublon(ligne int, champ, message string) models.ErreurImport {
+	return models.ErreurImport{Ligne: ligne, Colonne: libelleColonne(champ), Message: message}
+}
+
+// valeursColonne retourne les valeurs distinctes et non vides d'une colonne
+func (e *execution) valeursColonne(champ string) []string {
+	vues := map[string]bool{}
+	var valeurs []string
+	for _, l := range e.lignes {
+		if v := l.val(champ); v != "" && !vues[v] {
+			vues[v] = true
+			valeurs = append(valeurs, v)
+		}
+	}
+	return valeurs
+}
+
+// parLots découpe les valeurs pour les requêtes IN
+func parLots(valeurs []string, fn func([]string) error) error {
+	for debut := 0; debut < len(valeurs); debut += 1000 {
+		fin := debut + 1000
+		if fin > len(valeurs) {
+			fin = len(valeurs)
+		}
+		if err := fn(valeurs[debut:fin]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// passeportsExistants charge les identités déjà enregistrées pour les passeports du fichier
+func (e *execution) passeportsExistants() (map[string]*models.Identite, error) {
+	existants := map[string]*models.Identite{}
+	err := parLots(e.valeursColonne("numero_passeport"), func(lot []string) error {
+		var trouvees []models.Identite
+		if err := e.db.Where("numero_passeport IN ?", lot).Find(&trouvees).Error; err != nil {
+			return err
+		}
+		for i := range trouvees {
+			existants[trouvees[i].NumeroPasseport] = &trouvees[i]
+		}
+		return nil
+	})
+	return existants, err
+}
+
+// emailsExistants charge les emails déjà utilisés (en minuscules) ; la chaîne vide
+// est présente si un migrant sans email existe, la colonne étant unique
+func (e *execution) emailsExistants() (map[string]bool, error) {
+	existants := map[string]bool{}
+	var vides int64
+	if err := e.db.Model(&models.Migrant{}).Where("email = ''").Count(&vides).Error; err != nil {
+		return nil, err
+	}
+	existants[""] = vides > 0
+
+	var valeurs []string
+	for _, v := range e.valeursColonne("email") {
+		valeurs = append(valeurs, strings.ToLower(v))
+	}
+	err := parLots(valeurs, func(lot []string) error {
+		var trouves []string
+		if err := e.db.Model(&models.Migrant{}).Where("LOWER(email) IN ?", lot).Pluck("LOWER(email)", &trouves).Error; err != nil {
+			return err
+		}
+		for _, email := range trouves {
+			existants[email] = true
+		}
+		return nil
+	})
+	return existants, err
+}
+
+// numerosExistants charge les numéros d'identifiant du fichier déjà attribués
+func (e *execution) numerosExistants() (map[string]bool, error) {
+	existants := map[string]bool{}
+	err := parLots(e.valeursColonne("numero_identifiant"), func(lot []string) error {
+		var trouves []string
+		if err := e.db.Model(&models.Migrant{}).Where("numero_identifiant IN ?", lot).Pluck("numero_identifiant", &trouves).Error; err != nil {
+			return err
+		}
+		for _, numero := range trouves {
+			existants[numero] = true
+		}
+		return nil
+	})
+	return existants, err
+}
+
+// =======================
+// ENREGISTREMENT
+// =======================
+
+// enregistrer crée les identités et les migrants valides dans une seule transaction
+func (e *execution) enregistrer() error {
+	e.job.Statut = "enregistrement"
+	e.job.LignesTraitees = 0
+	e.maj(map[string]interface{}{"statut": "enregistrement", "lignes_traitees": 0})
+
+	return e.db.Transaction(func(tx *gorm.DB) error {
+		tx = models.AvecContexteStatut(tx, models.ContexteStatut{
+			Motif:       "Import en masse (" + e.job.NomFichier + ")",
+			EffectuePar: e.job.LancePar,
+		})
+
+		if err := numeroterMigrants(tx, e.valides); err != nil {
+			return err
+		}
+
+		for debut := 0; debut < len(e.valides); debut += tailleLot {
+			fin := debut + tailleLot
+			if fin > len(e.valides) {
+				fin = len(e.valides)
+			}
+
+			var identitesLot []*models.Identite
+			var migrantsLot []*models.Migrant
+			for _, rec := range e.valides[debut:fin] {
+				if rec.identite != nil {
+					rec.identite.UniteUUID = e.unite
+					identitesLot = append(identitesLot, rec.identite)
+				}
+				if rec.migrant != nil {
+					rec.migrant.UniteUUID = e.unite
+					migrantsLot = append(migrantsLot, rec.migrant)
+				}
+			}
+
+			if len(identitesLot) > 0 {
+				if err := tx.Create(&identitesLot).Error; err != nil {
+					return err
+				}
+			}
+			if len(migrantsLot) > 0 {
+				if err := tx.Omit("Identite").Create(&migrantsLot).Error; err != nil {
+					return err
+				}
+			}
+
+			e.job.LignesImportees = fin
+			e.job.LignesTraitees = fin
+			e.maj(map[string]interface{}{"lignes_traitees": fin})
+		}
+		return nil
+	})
+}
+
+// numeroterMigrants réserve les numéros d'identifiant de chaque bureau en une fois ;
+// ils sont attribués à la suite dans l'ordre du fichier
+func numeroterMigrants(tx *gorm.DB, valides []enregistrement) error {
+	var bureaux []string
+	parBureau := map[string][]*models.Migrant{}
+	for _, rec := range valides {
+		if rec.migrant == nil {
+			continue
+		}
+		bureau := rec.migrant.BureauEnregistrement
+		if _, ok := parBureau[bureau]; !ok {
+			bureaux = append(bureaux, bureau)
+		}
+		parBureau[bureau] = append(parBureau[bureau], rec.migrant)
+	}
+
+	for _, bureau := range bureaux {
+		numeros, err := models.NumerotationMigrants.Reserver(tx, bureau, len(parBureau[bureau]))
+		if err != nil {
+			return err
+		}
+		for i, migrant := range parBureau[bureau] {
+			migrant.NumeroIdentifiant = numeros[i]
+		}
+	}
+	return nil
+}
+
+// cribler passe les identités importées au criblage des listes de surveillance ;
+// comme à la saisie, une erreur de criblage n'annule pas l'import
+func (e *execution) cribler() {
+	cribles := map[string]bool{}
+	for _, rec := range e.valides {
+		identite := rec.identite
+		if identite == nil {
+			identite = &rec.migrant.Identite
+		}
+		if cribles[identite.UUID] {
+			continue
+		}
+		cribles[identite.UUID] = true
+
+		// L'échec est aussi signalé par une alerte sur le migrant
+		hits, err := watchlist.NewCriblage(e.db).Cribler(*identite)
+		if err != nil {
+			log.Printf("Import %s : criblage de l'identité %s impossible : %v", e.job.UUID, identite.UUID, err)
+			continue
+		}
+		e.job.Correspondances += len(hits)
+	}
+}
+
+// apercu retourne les premières lignes valides telles qu'elles seraient enregistrées
+func (e *execution) apercu() []interface{} {
+	apercu := []interface{}{}
+	for _, rec := range e.valides {
+		if len(apercu) == tailleApercu {
+			break
+		}
+		if rec.migrant != nil {
+			apercu = append(apercu, map[string]interface{}{
+				"ligne":             rec.ligne,
+				"migrant":           rec.migrant,
+				"nouvelle_identite": rec.identite != nil,
+			})
+		} else {
+			apercu = append(apercu, map[string]interface{}{
+				"ligne":    rec.ligne,
+				"identite": rec.identite,
+			})
+		}
+	}
+	return apercu
+}
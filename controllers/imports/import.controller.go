@@ -0,0 +1,264 @@
+package imports
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Nombre d'erreurs renvoyées avec le suivi d'un import (la suite via /errors)
+const erreursParSuivi = 100
+
+// ImportIdentites - Importer des identités depuis un fichier Excel ou CSV
+// POST /api/import/identites (multipart : file ; options : dry_run, async, ignorer_erreurs)
+func ImportIdentites(c *fiber.Ctx) error {
+	return lancerImport(c, "identites")
+}
+
+// ImportMigrants - Importer des migrants (et leurs identités) depuis un fichier Excel ou CSV
+// POST /api/import/migrants (multipart : file ; options : dry_run, async, ignorer_erreurs, bureau)
+func ImportMigrants(c *fiber.Ctx) error {
+	return lancerImport(c, "migrants")
+}
+
+// lancerImport lit le fichier et exécute l'import, dans la requête ou en arrière-plan (async=true).
+// Les colonnes attendues sont celles des exports Excel correspondants.
+func lancerImport(c *fiber.Ctx, typeImport string) error {
+	db := database.DB
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return problemes.Invalide("An .xlsx or .csv file is required").Cause(err)
+	}
+	format, err := formatFichier(file.Filename)
+	if err != nil {
+		return problemes.Invalide("Unsupported file format").Cause(err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return problemes.Invalide("Unable to read uploaded file").Cause(err)
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return problemes.Invalide("Unable to read uploaded file").Cause(err)
+	}
+
+	lignes, err := lireFichier(data, format)
+	if err != nil {
+		return problemes.Invalide("Unable to parse import file").Cause(err)
+	}
+
+	// Les lignes importées appartiennent à l'unité de l'agent, dont le code sert de bureau par défaut
+	unite := unites.UniteDe(c)
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	job := &models.Importation{
+		UUID:           utils.GenerateUUID(),
+		TypeImport:     typeImport,
+		NomFichier:     file.Filename,
+		Format:         format,
+		Apercu:         c.FormValue("dry_run") == "true",
+		IgnorerErreurs: c.FormValue("ignorer_erreurs") == "true",
+		Statut:         "en_attente",
+		TotalLignes:    len(lignes),
+		LancePar:       userUUID,
+		UniteUUID:      unite.UUID,
+	}
+	if err := utils.ValidateStruct(*job); err != nil {
+		return problemes.Validation(err)
+	}
+	if err := db.Create(job).Error; err != nil {
+		return problemes.Echec("Failed to create import job", err)
+	}
+
+	bureau := c.FormValue("bureau")
+	if bureau == "" {
+		bureau = unite.Code
+	}
+	exec := &execution{db: db, job: job, lignes: lignes, bureau: bureau, unite: unite.UUID}
+
+	// Import en arrière-plan : le client suit la progression via /api/import/jobs/:uuid
+	if c.FormValue("async") == "true" {
+		demarre := *job
+		go exec.executer()
+		return c.Status(202).JSON(fiber.Map{
+			"status":  "success",
+			"message": "Import started",
+			"data": fiber.Map{
+				"importation": demarre,
+				"suivi_url":   "/api/import/jobs/" + job.UUID,
+			},
+		})
+	}
+
+	exec.executer()
+	erreurs := exec.erreurs
+	if len(erreurs) > erreursParSuivi {
+		erreurs = erreurs[:erreursParSuivi]
+	}
+	job.Erreurs = erreurs
+
+	resultat := fiber.Map{
+		"importation": job,
+		"progression": job.Progression(),
+	}
+	if job.Apercu {
+		resultat["apercu"] = exec.apercu()
+	}
+
+	if job.Statut == "echoue" {
+		return problemes.Nouveau(fiber.StatusUnprocessableEntity, problemes.CodeNonTraitable, job.Message).
+			Avec("data", resultat)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": job.Message,
+		"data":    resultat,
+	})
+}
+
+// Champs des imports pouvant être filtrés, triés et sélectionnés
+var listeImports = requetes.Schema{
+	Table: "imports",
+	Champs: map[string]requetes.Type{
+		"uuid":                         requetes.Texte,
+		"created_at":                   requetes.Date,
+		"updated_at":                   requetes.Date,
+		"type_import":                  requetes.Texte,
+		"nom_fichier":                  requetes.Texte,
+		"format":                       requetes.Texte,
+		"apercu":                       requetes.Booleen,
+		"ignorer_erreurs":              requetes.Booleen,
+		"statut":                       requetes.Texte,
+		"total_lignes":                 requetes.Nombre,
+		"lignes_rejetees":              requetes.Nombre,
+		"lignes_importees":             requetes.Nombre,
+		"correspondances_surveillance": requetes.Nombre,
+		"date_debut":                   requetes.Date,
+		"date_fin":                     requetes.Date,
+		"lance_par":                    requetes.Texte,
+	},
+	Colonnes:  map[string]string{"correspondances_surveillance": "correspondances"},
+	TriDefaut: "-created_at",
+}
+
+// GetPaginatedImports - Historique des imports
+// GET /api/import/jobs
+func GetPaginatedImports(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeImports)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var jobs []models.Importation
+
+	query := db.Model(&models.Importation{}).Scopes(unites.PerimetreDe(c).ParUnite("imports.unite_uuid"))
+	if typeImport := c.Query("type_import", ""); typeImport != "" {
+		query = query.Where("type_import = ?", typeImport)
+	}
+	if statut := c.Query("statut", ""); statut != "" {
+		query = query.Where("statut = ?", statut)
+	}
+
+	data, pagination, err := liste.Executer(query, &jobs)
+	if err != nil {
+		return problemes.Echec("Failed to fetch imports", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Imports retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// GetImport - Suivi d'un import : statut, progression et premières erreurs
+// GET /api/import/jobs/:uuid
+func GetImport(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var job models.Importation
+	err := db.Where("uuid = ?", uuid).
+		Preload("Erreurs", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("ligne ASC").Limit(erreursParSuivi)
+		}).
+		First(&job).Error
+	if err != nil {
+		return problemes.Absent("Import not found", err)
+	}
+
+	if !unites.Acceder(c, job.UniteUUID, "import", job.UUID) {
+		return unites.Refus(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Import found",
+		"data": fiber.Map{
+			"importation": job,
+			"progression": job.Progression(),
+		},
+	})
+}
+
+// Champs des erreurs d'import pouvant être filtrés, triés et sélectionnés
+var listeErreursImport = requetes.Schema{
+	Table: "import_errors",
+	Champs: map[string]requetes.Type{
+		"uuid":       requetes.Texte,
+		"created_at": requetes.Date,
+		"ligne":      requetes.Nombre,
+		"colonne":    requetes.Texte,
+	},
+	TriDefaut: "ligne",
+}
+
+// GetImportErrors - Erreurs par ligne d'un import
+// GET /api/import/jobs/:uuid/errors
+func GetImportErrors(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeErreursImport)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var job models.Importation
+	if err := db.Select("uuid", "unite_uuid").Where("uuid = ?", uuid).First(&job).Error; err != nil {
+		return problemes.Absent("Import not found", err)
+	}
+
+	if !unites.Acceder(c, job.UniteUUID, "import", job.UUID) {
+		return unites.Refus(c)
+	}
+
+	var lignes []models.ErreurImport
+
+	query := db.Model(&models.ErreurImport{}).Where("importation_uuid = ?", uuid)
+
+	data, pagination, err := liste.Executer(query, &lignes)
+	if err != nil {
+		return problemes.Echec("Failed to fetch import errors", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Import errors retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
@@ -0,0 +1,70 @@
+package imports
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// Le suivi et les erreurs d'un import lancé par une autre unité ne sont pas visibles
+func TestImportsRestreintsAuPerimetre(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID: "unite-" + code, Code: code, Nom: "Direction " + code,
+			TypeUnite: "direction_provinciale", Actif: true, Chemin: "/unite-" + code + "/",
+		}
+		job := models.Importation{UUID: "import-" + code, TypeImport: "identites", Statut: "termine", UniteUUID: unite.UUID}
+		erreur := models.ErreurImport{UUID: "erreur-" + code, ImportationUUID: job.UUID, Ligne: 2, Message: "Nom manquant"}
+		for _, err := range []error{db.Create(&unite).Error, db.Create(&job).Error, db.Create(&erreur).Error} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	agent := basetest.Utilisateur(t, db, "Agent", "unite-A")
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/jobs", GetPaginatedImports)
+	app.Get("/jobs/:uuid", GetImport)
+	app.Get("/jobs/:uuid/errors", GetImportErrors)
+	appeler := func(url string, reponse interface{}) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url+"?token="+agent, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reponse != nil {
+			json.NewDecoder(resp.Body).Decode(reponse)
+		}
+		return resp.StatusCode
+	}
+
+	var liste struct {
+		Data []models.Importation `json:"data"`
+	}
+	if statut := appeler("/jobs", &liste); statut != fiber.StatusOK || len(liste.Data) != 1 || liste.Data[0].UUID != "import-A" {
+		t.Errorf("historique de l'agent de A : statut %d, %v, attendu import-A seul", statut, liste.Data)
+	}
+
+	cas := map[string]int{
+		"/jobs/import-A":        fiber.StatusOK,
+		"/jobs/import-A/errors": fiber.StatusOK,
+		"/jobs/import-B":        fiber.StatusForbidden,
+		"/jobs/import-B/errors": fiber.StatusForbidden,
+		"/jobs/inconnu/errors":  fiber.StatusNotFound,
+	}
+	for url, attendu := range cas {
+		if statut := appeler(url, nil); statut != attendu {
+			t.Errorf("%s : statut %d, attendu %d", url, statut, attendu)
+		}
+	}
+}
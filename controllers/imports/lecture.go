@@ -0,0 +1,189 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/utils"
+	"github.com/xuri/excelize/v2"
+)
+
+// Nombre maximal de lignes acceptées dans un fichier
+const maxLignesImport = 50000
+
+// ligne est une ligne de données du fichier, indexée par champ
+type ligne struct {
+	numero  int
+	valeurs map[string]string
+}
+
+func (l ligne) val(champ string) string {
+	return strings.TrimSpace(l.valeurs[champ])
+}
+
+// Libellés de colonnes acceptés pour chaque champ : en-têtes des exports Excel
+// (ExportIdentitesToExcel, ExportMigrantsToExcel) et noms JSON des modèles
+var libellesColonnes = map[string][]string{
+	"numero_identifiant":     {"N° Identifiant"},
+	"bureau_enregistrement":  {"Bureau d'enregistrement"},
+	"nom":                    {"Nom"},
+	"postnom":                {"Postnom"},
+	"prenom":                 {"Prénom"},
+	"date_naissance":         {"Date de naissance"},
+	"lieu_naissance":         {"Lieu de naissance"},
+	"sexe":                   {"Sexe"},
+	"nationalite":            {"Nationalité"},
+	"adresse":                {"Adresse"},
+	"profession":             {"Profession"},
+	"numero_passeport":       {"N° Passeport", "N° Document"},
+	"pays_emetteur":          {"Pays émetteur"},
+	"autorite_emetteur":      {"Autorité émetteur", "Autorité émission"},
+	"date_emission":          {"Date d'émission", "Date émission doc"},
+	"date_expiration":        {"Date d'expiration", "Date expiration doc"},
+	"telephone":              {"Téléphone"},
+	"email":                  {"Email"},
+	"adresse_actuelle":       {"Adresse actuelle"},
+	"ville_actuelle":         {"Ville actuelle"},
+	"pays_actuel":            {"Pays actuel"},
+	"situation_matrimoniale": {"Situation matrimoniale"},
+	"nombre_enfants":         {"Nombre enfants"},
+	"personne_contact":       {"Personne contact"},
+	"telephone_contact":      {"Téléphone contact"},
+	"statut_migratoire":      {"Statut migratoire"},
+	"date_entree":            {"Date d'entrée"},
+	"point_entree":           {"Point d'entrée"},
+	"pays_destination":       {"Pays destination"},
+}
+
+// colonnes associe un en-tête normalisé au champ correspondant
+var colonnes = func() map[string]string {
+	index := map[string]string{}
+	for champ, libelles := range libellesColonnes {
+		index[utils.NormalizeName(champ)] = champ
+		for _, libelle := range libelles {
+			index[utils.NormalizeName(libelle)] = champ
+		}
+	}
+	return index
+}()
+
+// libelleColonne retourne le libellé d'export d'un champ, utilisé dans les erreurs
+func libelleColonne(champ string) string {
+	if libelles, ok := libellesColonnes[champ]; ok {
+		return libelles[0]
+	}
+	return champ
+}
+
+// formatFichier déduit le format à partir de l'extension du fichier
+func formatFichier(nom string) (string, error) {
+	switch {
+	case strings.HasSuffix(strings.ToLower(nom), ".xlsx"):
+		return "xlsx", nil
+	case strings.HasSuffix(strings.ToLower(nom), ".csv"), strings.HasSuffix(strings.ToLower(nom), ".txt"):
+		return "csv", nil
+	}
+	return "", fmt.Errorf("unsupported file format, expected .xlsx or .csv")
+}
+
+// lireFichier retourne les lignes de données du fichier sous forme de champs
+func lireFichier(data []byte, format string) ([]ligne, error) {
+	var rows [][]string
+	var err error
+	if format == "xlsx" {
+		rows, err = lireExcel(data)
+	} else {
+		rows, err = lireCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Les exports commencent par un titre et d'éventuels filtres : l'en-tête est
+	// la première ligne qui contient au moins trois colonnes connues
+	entete := -1
+	var index map[int]string
+	for i := 0; i < len(rows) && i < 15; i++ {
+		candidat := map[int]string{}
+		for j, cellule := range rows[i] {
+			if champ, ok := colonnes[utils.NormalizeName(cellule)]; ok {
+				candidat[j] = champ
+			}
+		}
+		if len(candidat) >= 3 {
+			entete, index = i, candidat
+			break
+		}
+	}
+	if entete < 0 {
+		return nil, fmt.Errorf("header row not found, use the column layout of the Excel export")
+	}
+
+	var lignes []ligne
+	for i := entete + 1; i < len(rows); i++ {
+		l := ligne{numero: i + 1, valeurs: map[string]string{}}
+		vide := true
+		for j, cellule := range rows[i] {
+			if champ, ok := index[j]; ok && strings.TrimSpace(cellule) != "" {
+				l.valeurs[champ] = cellule
+				vide = false
+			}
+		}
+		if vide {
+			continue
+		}
+		lignes = append(lignes, l)
+		if len(lignes) > maxLignesImport {
+			return nil, fmt.Errorf("file exceeds %d rows, split it into several imports", maxLignesImport)
+		}
+	}
+	return lignes, nil
+}
+
+// lireExcel lit la première feuille du classeur
+func lireExcel(data []byte) ([][]string, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	feuilles := f.GetSheetList()
+	if len(feuilles) == 0 {
+		return nil, fmt.Errorf("empty workbook")
+	}
+	return f.GetRows(feuilles[0])
+}
+
+// lireCSV lit un fichier CSV séparé par des virgules ou des points-virgules
+func lireCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	// Fichiers exportés avec le séparateur ; (tableurs en français)
+	entete, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(entete, ";") > strings.Count(entete, ",") {
+		reader.Comma = ';'
+	}
+	return reader.ReadAll()
+}
+
+// parseDate accepte les formats des exports, ISO 8601 et les dates numériques d'Excel
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"02/01/2006", "2006-01-02", "02-01-2006", "2006/01/02", "02/01/2006 15:04", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if serie, err := strconv.ParseFloat(s, 64); err == nil && serie > 0 {
+		return excelize.ExcelDateToTime(serie, false)
+	}
+	return time.Time{}, fmt.Errorf("date invalide %q (format attendu JJ/MM/AAAA)", s)
+}
@@ -0,0 +1,322 @@
+package impressions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/cartes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Nombre maximal de positions reprises dans le dossier imprimé
+const maxPositionsDossier = 200
+
+// envoyerPDF renvoie un document PDF au client
+func envoyerPDF(c *fiber.Ctx, doc *utils.PDF, filename string) error {
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", filename))
+	return c.Send(doc.Bytes())
+}
+
+// =======================
+// CARTE D'ENREGISTREMENT DU MIGRANT
+// =======================
+
+// PrintMigrantCard - Carte d'enregistrement au format ID-1 (recto et verso)
+// GET /api/migrants/:uuid/card/pdf
+func PrintMigrantCard(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var migrant models.Migrant
+	if err := db.Where("uuid = ?", uuid).Preload("Identite").First(&migrant).Error; err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	var carte models.CarteMigrant
+	err := db.Where("migrant_uuid = ? AND statut = ?", uuid, "active").
+		Order("date_emission DESC").
+		First(&carte).Error
+	if err != nil {
+		return problemes.Absent("No active card for this migrant, issue one first", err)
+	}
+
+	identite := migrant.Identite
+	doc := utils.NewPDF("Carte d'enregistrement " + migrant.NumeroIdentifiant)
+	w, h := utils.PDFCardWidth, utils.PDFCardHeight
+
+	// Recto
+	recto := doc.AddPage(w, h)
+	recto.SetFillColor(couleurPrincipale)
+	recto.Rect(0, 0, w, 9, "F")
+	recto.SetFillColor("FFFFFF")
+	recto.TextAligned(0, 4, w, 6, true, "C", "RÉPUBLIQUE DÉMOCRATIQUE DU CONGO")
+	recto.TextAligned(0, 7.3, w, 5, false, "C", "Carte d'enregistrement de migrant")
+
+	placerImage(doc, recto, photoMigrant(db, migrant.UUID), 3.5, 11.5, 19, 24, "PHOTO")
+
+	ligne := func(y float64, libelle, texte string) {
+		recto.SetFillColor(couleurLibelle)
+		recto.Text(25, y, 4.5, false, libelle)
+		recto.SetFillColor(couleurTexte)
+		recto.Text(25, y+2.7, 6.5, true, tronquer(texte, 6.5, true, 34))
+	}
+	ligne(13, "Nom", strings.ToUpper(strings.TrimSpace(identite.Nom+" "+identite.Postnom)))
+	ligne(19, "Prénom", valeur(identite.Prenom))
+	ligne(25, "Nationalité", valeur(identite.Nationalite))
+	ligne(31, "Né(e) le", formatDate(&identite.DateNaissance)+"   Sexe : "+valeur(identite.Sexe))
+
+	if err := placerQRCode(doc, recto, cartes.VerificationURL(c, carte.Jeton), 61, 11, 22); err != nil {
+		return problemes.Echec("Failed to generate QR code", err)
+	}
+
+	recto.SetFillColor(couleurFondLigne)
+	recto.Rect(0, 38, w, h-38, "F")
+	recto.SetFillColor(couleurLibelle)
+	recto.Text(3.5, 41.5, 4.5, false, "N° identifiant")
+	recto.Text(48, 41.5, 4.5, false, "Statut")
+	recto.SetFillColor(couleurTexte)
+	recto.Text(3.5, 45.5, 8.5, true, migrant.NumeroIdentifiant)
+	recto.Text(48, 45.5, 7, true, libelleStatut(migrant.StatutMigratoire))
+	recto.SetFillColor(couleurLibelle)
+	recto.Text(3.5, 51, 4.5, false, fmt.Sprintf("Émise le %s  -  Expire le %s", formatDate(&carte.DateEmission), formatDate(&carte.DateExpiration)))
+	recto.TextAligned(0, 51, w-3.5, 4.5, false, "R", carte.Serie)
+
+	// Verso
+	verso := doc.AddPage(w, h)
+	verso.SetFillColor(couleurPrincipale)
+	verso.Rect(0, 0, w, 6, "F")
+	verso.SetFillColor(couleurTexte)
+	y := 11.0
+	consignes := "Cette carte atteste l'enregistrement de son titulaire auprès des services de migration. " +
+		"Elle ne constitue pas un titre de voyage. Son authenticité peut être vérifiée en scannant le QR code, " +
+		"y compris hors ligne à l'aide de la clé publique de vérification."
+	for _, l := range utils.PDFWrapText(consignes, 5.5, false, w-7) {
+		verso.Text(3.5, y, 5.5, false, l)
+		y += 2.8
+	}
+	y += 2
+	verso.SetFillColor(couleurLibelle)
+	verso.Text(3.5, y, 4.5, false, "En cas de perte, signaler la carte au poste de migration le plus proche.")
+	verso.Text(3.5, y+3, 4.5, false, "Passeport : "+valeur(identite.NumeroPasseport))
+	verso.SetStrokeColor(couleurBordure)
+	verso.SetLineWidth(0.2)
+	verso.Line(48, h-8, w-3.5, h-8)
+	verso.Text(48, h-5, 4.5, false, "Signature du titulaire")
+
+	return envoyerPDF(c, doc, fmt.Sprintf("carte_%s.pdf", migrant.NumeroIdentifiant))
+}
+
+// =======================
+// BADGE AGENT
+// =======================
+
+// PrintAgentBadge - Badge professionnel d'un agent (format ID-1 vertical)
+// GET /api/users/badge/:uuid/pdf
+func PrintAgentBadge(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var user models.User
+	if err := db.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return problemes.Absent("User not found", err)
+	}
+
+	// Le QR code reprend les données déjà émises pour l'agent, sinon l'URL de vérification
+	contenuQR := user.QRCodeData
+	validite := "-"
+	if data, err := utils.ValidateQRCode(user.QRCodeData); err == nil {
+		validite = formatDate(&data.ValidUntil)
+	} else if user.QRCodeData == "" {
+		contenuQR = utils.GenerateQRCodeURL(cartes.BaseURL(c), user.UUID)
+	}
+
+	doc := utils.NewPDF("Badge " + user.Matricule)
+	w, h := utils.PDFCardHeight, utils.PDFCardWidth
+	page := doc.AddPage(w, h)
+
+	page.SetFillColor(couleurPrincipale)
+	page.Rect(0, 0, w, 12, "F")
+	page.SetFillColor("FFFFFF")
+	page.TextAligned(0, 5, w, 5.5, true, "C", "RÉPUBLIQUE DÉMOCRATIQUE DU CONGO")
+	page.TextAligned(0, 9, w, 4.5, false, "C", tronquer(valeur(user.Ministere), 4.5, false, w-4))
+
+	placerImage(doc, page, photoAgent(user.PhotoProfil), (w-20)/2, 15, 20, 25, "PHOTO")
+
+	page.SetFillColor(couleurTexte)
+	page.TextAligned(0, 45, w, 7.5, true, "C", tronquer(strings.ToUpper(user.Nom+" "+user.PostNom), 7.5, true, w-4))
+	page.TextAligned(0, 48.5, w, 6.5, false, "C", tronquer(user.Prenom, 6.5, false, w-4))
+	page.SetFillColor(couleurPrincipale)
+	page.TextAligned(0, 53, w, 6, true, "C", tronquer(valeur(user.Grade)+" - "+valeur(user.Fonction), 6, true, w-4))
+	page.SetFillColor(couleurLibelle)
+	page.TextAligned(0, 56.5, w, 5, false, "C", tronquer(valeur(user.Service), 5, false, w-4))
+
+	if err := placerQRCode(doc, page, contenuQR, (w-18)/2, 59, 18); err != nil {
+		return problemes.Echec("Failed to generate QR code", err)
+	}
+
+	page.SetFillColor(couleurFondLigne)
+	page.Rect(0, h-7, w, 7, "F")
+	page.SetFillColor(couleurTexte)
+	page.Text(3, h-3, 5, true, "Matricule : "+user.Matricule)
+	page.TextAligned(0, h-3, w-3, 4.5, false, "R", "Valide jusqu'au "+validite)
+
+	return envoyerPDF(c, doc, fmt.Sprintf("badge_%s.pdf", user.Matricule))
+}
+
+// =======================
+// DOSSIER COMPLET DU MIGRANT
+// =======================
+
+// PrintMigrantDossier - Dossier imprimable : identité, situation, motifs, alertes et positions
+// GET /api/migrants/:uuid/dossier/pdf
+func PrintMigrantDossier(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var migrant models.Migrant
+	err := db.Where("uuid = ?", uuid).
+		Preload("Identite").
+		Preload("MotifDeplacements", func(tx *gorm.DB) *gorm.DB { return tx.Order("date_declenchement DESC") }).
+		Preload("Alertes", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at DESC") }).
+		First(&migrant).Error
+	if err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	identite := migrant.Identite
+
+	var historique []models.HistoriqueStatut
+	db.Where("migrant_uuid = ?", uuid).Order("date_effet ASC, created_at ASC").Find(&historique)
+
+	var positions []models.Geolocalisation
+	db.Where("identite_uuid = ?", migrant.IdentiteUUID).
+		Order("created_at DESC").
+		Limit(maxPositionsDossier).
+		Find(&positions)
+
+	r := nouveauRapport("Dossier " + migrant.NumeroIdentifiant)
+
+	// En-tête avec photo
+	placerImage(r.doc, r.page, photoMigrant(db, migrant.UUID), margeRapport+r.largeur()-28, r.y, 28, 35, "PHOTO")
+	r.page.SetFillColor(couleurTexte)
+	r.page.Text(margeRapport, r.y+6, 16, true, strings.ToUpper(identite.Nom+" "+identite.Postnom)+" "+identite.Prenom)
+	r.page.SetFillColor(couleurPrincipale)
+	r.page.Text(margeRapport, r.y+13, 12, true, migrant.NumeroIdentifiant)
+	r.page.SetFillColor(couleurLibelle)
+	r.page.Text(margeRapport, r.y+19, 9, false, "Statut migratoire : "+libelleStatut(migrant.StatutMigratoire))
+	r.page.Text(margeRapport, r.y+24, 9, false, "Document généré le "+time.Now().Format("02/01/2006 à 15:04"))
+	r.y += 42
+
+	r.section("Identité")
+	r.champs([][2]string{
+		{"Nom", valeur(identite.Nom)},
+		{"Postnom", valeur(identite.Postnom)},
+		{"Prénom", valeur(identite.Prenom)},
+		{"Sexe", valeur(identite.Sexe)},
+		{"Date de naissance", formatDate(&identite.DateNaissance)},
+		{"Lieu de naissance", valeur(identite.LieuNaissance)},
+		{"Nationalité", valeur(identite.Nationalite)},
+		{"Profession", valeur(identite.Profession)},
+		{"N° passeport", valeur(identite.NumeroPasseport)},
+		{"Pays / autorité émetteur", valeur(identite.PaysEmetteur) + " / " + valeur(identite.AutoriteEmetteur)},
+		{"Date d'émission", formatDate(&identite.DateEmission)},
+		{"Date d'expiration", formatDate(&identite.DateExpiration)},
+	})
+
+	r.section("Situation migratoire")
+	r.champs([][2]string{
+		{"Statut migratoire", libelleStatut(migrant.StatutMigratoire)},
+		{"Date d'entrée", formatDate(migrant.DateEntree)},
+		{"Point d'entrée", valeur(migrant.PointEntree)},
+		{"Pays de destination", valeur(migrant.PaysDestination)},
+		{"Adresse actuelle", valeur(migrant.AdresseActuelle)},
+		{"Ville / pays actuels", valeur(migrant.VilleActuelle) + " / " + valeur(migrant.PaysActuel)},
+		{"Téléphone", valeur(migrant.Telephone)},
+		{"Email", valeur(migrant.Email)},
+		{"Situation matrimoniale", valeur(migrant.SituationMatrimoniale)},
+		{"Nombre d'enfants", fmt.Sprintf("%d", migrant.NombreEnfants)},
+		{"Personne de contact", valeur(migrant.PersonneContact)},
+		{"Téléphone du contact", valeur(migrant.TelephoneContact)},
+	})
+
+	r.section("Historique des statuts")
+	lignesHistorique := make([][]string, 0, len(historique))
+	for _, version := range historique {
+		lignesHistorique = append(lignesHistorique, []string{
+			formatDate(&version.DateEffet),
+			formatDate(version.DateFin),
+			libelleStatut(version.StatutMigratoire),
+			valeur(version.PointEntree),
+			valeur(version.Motif),
+		})
+	}
+	r.tableau([]string{"Du", "Au", "Statut", "Point d'entrée", "Motif"},
+		[]float64{22, 22, 35, 40, 61}, lignesHistorique)
+
+	r.section("Motifs de déplacement")
+	lignesMotifs := make([][]string, 0, len(migrant.MotifDeplacements))
+	for _, motif := range migrant.MotifDeplacements {
+		lignesMotifs = append(lignesMotifs, []string{
+			formatDate(&motif.DateDeclenchement),
+			motif.TypeMotif,
+			motif.MotifPrincipal,
+			motif.Urgence,
+		})
+	}
+	r.tableau([]string{"Date", "Type", "Motif principal", "Urgence"},
+		[]float64{25, 40, 90, 25}, lignesMotifs)
+	for _, motif := range migrant.MotifDeplacements {
+		if strings.TrimSpace(motif.Description) != "" {
+			r.paragraphe(motif.MotifPrincipal+" : "+motif.Description, 9)
+		}
+	}
+
+	r.section("Alertes")
+	lignesAlertes := make([][]string, 0, len(migrant.Alertes))
+	for _, alerte := range migrant.Alertes {
+		lignesAlertes = append(lignesAlertes, []string{
+			formatDate(&alerte.CreatedAt),
+			alerte.TypeAlerte,
+			alerte.NiveauGravite,
+			alerte.Titre,
+			alerte.Statut,
+		})
+	}
+	r.tableau([]string{"Date", "Type", "Gravité", "Titre", "Statut"},
+		[]float64{22, 28, 20, 88, 22}, lignesAlertes)
+
+	r.section("Historique des positions")
+	if len(positions) == maxPositionsDossier {
+		r.paragraphe(fmt.Sprintf("Seules les %d positions les plus récentes sont reprises.", maxPositionsDossier), 8)
+	}
+	lignesPositions := make([][]string, 0, len(positions))
+	for _, position := range positions {
+		lignesPositions = append(lignesPositions, []string{
+			position.CreatedAt.Format("02/01/2006 15:04"),
+			fmt.Sprintf("%.5f", position.Latitude),
+			fmt.Sprintf("%.5f", position.Longitude),
+		})
+	}
+	r.tableau([]string{"Date", "Latitude", "Longitude"},
+		[]float64{60, 60, 60}, lignesPositions)
+
+	r.piedsDePage("Document confidentiel - " + migrant.NumeroIdentifiant)
+
+	return envoyerPDF(c, r.doc, fmt.Sprintf("dossier_%s.pdf", migrant.NumeroIdentifiant))
+}
@@ -0,0 +1,297 @@
+package impressions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+// Couleurs reprises des exports Excel
+const (
+	couleurPrincipale = "2E75B6"
+	couleurEntete     = "4F81BD"
+	couleurTexte      = "1F1F1F"
+	couleurLibelle    = "6B6B6B"
+	couleurBordure    = "BFBFBF"
+	couleurFondLigne  = "F2F2F2"
+)
+
+var libellesStatut = map[string]string{
+	"regulier":        "Régulier",
+	"irregulier":      "Irrégulier",
+	"demandeur_asile": "Demandeur d'asile",
+	"refugie":         "Réfugié",
+}
+
+// libelleStatut retourne le libellé d'un statut migratoire
+func libelleStatut(statut string) string {
+	if libelle, ok := libellesStatut[statut]; ok {
+		return libelle
+	}
+	return statut
+}
+
+// formatDate formate une date pour l'impression, "-" si elle est vide
+func formatDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Format("02/01/2006")
+}
+
+// valeur remplace une valeur vide par un tiret
+func valeur(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+// decoderImage accepte une image en base64 brut ou en data URL
+func decoderImage(data string) ([]byte, error) {
+	if _, encoded, ok := strings.Cut(data, ";base64,"); ok && strings.HasPrefix(data, "data:") {
+		data = encoded
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+}
+
+// photoMigrant retourne la dernière photo faciale enregistrée du migrant
+func photoMigrant(db *gorm.DB, migrantUUID string) []byte {
+	var bio models.Biometrie
+	err := db.Where("migrant_uuid = ? AND type_biometrie = ?", migrantUUID, "reconnaissance_faciale").
+		Order("date_capture DESC").
+		First(&bio).Error
+	if err != nil {
+		return nil
+	}
+
+	data := bio.DonneesBiometriques
+	if bio.Chiffre {
+		plain, err := utils.DecryptBiometricData(bio.DonneesBiometriques, bio.CleChiffrement)
+		if err != nil {
+			return nil
+		}
+		data = plain
+	}
+
+	image, err := decoderImage(data)
+	if err != nil {
+		return nil
+	}
+	return image
+}
+
+// photoAgent charge la photo de profil d'un agent (data URL ou fichier local relatif)
+func photoAgent(photo string) []byte {
+	if photo == "" {
+		return nil
+	}
+	if strings.HasPrefix(photo, "data:") {
+		image, err := decoderImage(photo)
+		if err != nil {
+			return nil
+		}
+		return image
+	}
+
+	// Les URL distantes ne sont pas téléchargées et seuls les chemins relatifs sont lus
+	if strings.Contains(photo, "://") || filepath.IsAbs(photo) || strings.Contains(photo, "..") {
+		return nil
+	}
+	image, err := os.ReadFile(filepath.Clean(photo))
+	if err != nil {
+		return nil
+	}
+	return image
+}
+
+// placerImage dessine une image ou, à défaut, un cadre portant le libellé donné
+func placerImage(doc *utils.PDF, page *utils.PDFPage, data []byte, x, y, w, h float64, libelle string) {
+	if len(data) > 0 {
+		if img, err := doc.AddImage(data); err == nil {
+			page.Image(img, x, y, w, h)
+			return
+		}
+	}
+	page.SetFillColor(couleurFondLigne)
+	page.SetStrokeColor(couleurBordure)
+	page.SetLineWidth(0.2)
+	page.Rect(x, y, w, h, "FD")
+	page.SetFillColor(couleurLibelle)
+	page.TextAligned(x, y+h/2+1, w, 5, false, "C", libelle)
+}
+
+// placerQRCode dessine un QR code encodant le contenu donné
+func placerQRCode(doc *utils.PDF, page *utils.PDFPage, contenu string, x, y, taille float64) error {
+	png, err := qrcode.Encode(contenu, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+	img, err := doc.AddImage(png)
+	if err != nil {
+		return err
+	}
+	page.Image(img, x, y, taille, taille)
+	return nil
+}
+
+// =======================
+// MISE EN PAGE DES RAPPORTS A4
+// =======================
+
+const (
+	margeRapport   = 15.0
+	basPageRapport = utils.PDFA4Height - 20
+)
+
+// rapport gère le curseur vertical et les sauts de page d'un document A4
+type rapport struct {
+	doc   *utils.PDF
+	page  *utils.PDFPage
+	y     float64
+	titre string
+}
+
+func nouveauRapport(titre string) *rapport {
+	r := &rapport{doc: utils.NewPDF(titre), titre: titre}
+	r.nouvellePage()
+	return r
+}
+
+// largeur utile de la page
+func (r *rapport) largeur() float64 {
+	return utils.PDFA4Width - 2*margeRapport
+}
+
+// nouvellePage ajoute une page avec son bandeau
+func (r *rapport) nouvellePage() {
+	r.page = r.doc.AddPage(utils.PDFA4Width, utils.PDFA4Height)
+	r.page.SetFillColor(couleurPrincipale)
+	r.page.Rect(0, 0, utils.PDFA4Width, 12, "F")
+	r.page.SetFillColor("FFFFFF")
+	r.page.Text(margeRapport, 8, 10, true, "SysMobembo")
+	r.page.TextAligned(margeRapport, 8, r.largeur(), 9, false, "R", r.titre)
+	r.y = 22
+}
+
+// reserver passe à la page suivante si la hauteur demandée ne tient pas
+func (r *rapport) reserver(hauteur float64) {
+	if r.y+hauteur > basPageRapport {
+		r.nouvellePage()
+	}
+}
+
+// section écrit un titre de section
+func (r *rapport) section(titre string) {
+	r.reserver(16)
+	r.y += 3
+	r.page.SetFillColor(couleurPrincipale)
+	r.page.Text(margeRapport, r.y, 12, true, titre)
+	r.page.SetStrokeColor(couleurPrincipale)
+	r.page.SetLineWidth(0.4)
+	r.page.Line(margeRapport, r.y+1.5, margeRapport+r.largeur(), r.y+1.5)
+	r.y += 7
+}
+
+// champs écrit des paires libellé/valeur sur deux colonnes
+func (r *rapport) champs(paires [][2]string) {
+	colonne := r.largeur() / 2
+	for i := 0; i < len(paires); i += 2 {
+		r.reserver(10)
+		for j := 0; j < 2 && i+j < len(paires); j++ {
+			x := margeRapport + float64(j)*colonne
+			r.page.SetFillColor(couleurLibelle)
+			r.page.Text(x, r.y, 7.5, false, paires[i+j][0])
+			r.page.SetFillColor(couleurTexte)
+			r.page.Text(x, r.y+4.2, 10, true, tronquer(paires[i+j][1], 10, true, colonne-4))
+		}
+		r.y += 10
+	}
+}
+
+// paragraphe écrit un texte sur plusieurs lignes
+func (r *rapport) paragraphe(texte string, taille float64) {
+	r.page.SetFillColor(couleurTexte)
+	for _, ligne := range utils.PDFWrapText(texte, taille, false, r.largeur()) {
+		r.reserver(taille * 0.5)
+		r.page.Text(margeRapport, r.y, taille, false, ligne)
+		r.y += taille * 0.45
+	}
+	r.y += 2
+}
+
+// tableau écrit un tableau ; les en-têtes sont répétés après chaque saut de page
+func (r *rapport) tableau(entetes []string, largeurs []float64, lignes [][]string) {
+	if len(lignes) == 0 {
+		r.paragraphe("Aucun enregistrement.", 9)
+		return
+	}
+
+	const hauteurLigne = 6.0
+	entete := func() {
+		r.page.SetFillColor(couleurEntete)
+		r.page.Rect(margeRapport, r.y, r.largeur(), hauteurLigne, "F")
+		r.page.SetFillColor("FFFFFF")
+		x := margeRapport
+		for i, titre := range entetes {
+			r.page.Text(x+1.5, r.y+4.2, 8, true, tronquer(titre, 8, true, largeurs[i]-3))
+			x += largeurs[i]
+		}
+		r.y += hauteurLigne
+	}
+
+	r.reserver(2 * hauteurLigne)
+	entete()
+	for n, ligne := range lignes {
+		if r.y+hauteurLigne > basPageRapport {
+			r.nouvellePage()
+			entete()
+		}
+		if n%2 == 1 {
+			r.page.SetFillColor(couleurFondLigne)
+			r.page.Rect(margeRapport, r.y, r.largeur(), hauteurLigne, "F")
+		}
+		r.page.SetFillColor(couleurTexte)
+		x := margeRapport
+		for i, cellule := range ligne {
+			r.page.Text(x+1.5, r.y+4.2, 8, false, tronquer(cellule, 8, false, largeurs[i]-3))
+			x += largeurs[i]
+		}
+		r.y += hauteurLigne
+	}
+	r.y += 4
+}
+
+// piedsDePage numérote les pages une fois le document terminé
+func (r *rapport) piedsDePage(mention string) {
+	total := r.doc.PageCount()
+	for i := 0; i < total; i++ {
+		page := r.doc.Page(i)
+		page.SetStrokeColor(couleurBordure)
+		page.SetLineWidth(0.2)
+		page.Line(margeRapport, utils.PDFA4Height-14, utils.PDFA4Width-margeRapport, utils.PDFA4Height-14)
+		page.SetFillColor(couleurLibelle)
+		page.Text(margeRapport, utils.PDFA4Height-9, 7.5, false, mention)
+		page.TextAligned(margeRapport, utils.PDFA4Height-9, r.largeur(), 7.5, false, "R", fmt.Sprintf("Page %d / %d", i+1, total))
+	}
+}
+
+// tronquer raccourcit un texte pour qu'il tienne dans la largeur donnée
+func tronquer(s string, taille float64, gras bool, largeur float64) string {
+	if utils.PDFTextWidth(s, taille, gras) <= largeur {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && utils.PDFTextWidth(string(runes)+"...", taille, gras) > largeur {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
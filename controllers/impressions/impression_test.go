@@ -0,0 +1,191 @@
+package impressions
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+var (
+	mediaBox = regexp.MustCompile(`/MediaBox \[0 0 ([\d.]+) ([\d.]+)\]`)
+	contenu  = regexp.MustCompile(`<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+)
+
+// pagePDF décrit une page lue dans le fichier : dimensions en points et texte du flux
+type pagePDF struct {
+	largeur, hauteur string
+	texte            string
+}
+
+// lirePages relit les pages d'un document produit par utils.PDF
+func lirePages(t *testing.T, data []byte) []pagePDF {
+	t.Helper()
+	var pages []pagePDF
+	for _, boite := range mediaBox.FindAllSubmatch(data, -1) {
+		pages = append(pages, pagePDF{largeur: string(boite[1]), hauteur: string(boite[2])})
+	}
+	flux := contenu.FindAllSubmatchIndex(data, -1)
+	if len(flux) != len(pages) {
+		t.Fatalf("%d pages mais %d flux de contenu", len(pages), len(flux))
+	}
+	for i, f := range flux {
+		longueur, _ := strconv.Atoi(string(data[f[2]:f[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[f[1] : f[1]+longueur]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		texte, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[i].texte = string(texte)
+	}
+	return pages
+}
+
+// Un tableau de 100 lignes de 6 mm occupe trois pages A4 : 41 lignes sous l'en-tête
+// des deux premières (de 28 à 274 mm), les 18 dernières sur la troisième
+func TestRapportSautsDePage(t *testing.T) {
+	r := nouveauRapport("Essai")
+	lignes := make([][]string, 100)
+	for i := range lignes {
+		lignes[i] = []string{fmt.Sprintf("ligne-%03d", i)}
+	}
+	r.tableau([]string{"Colonne"}, []float64{180}, lignes)
+	r.piedsDePage("Mention")
+
+	pages := lirePages(t, r.doc.Bytes())
+	if len(pages) != 3 {
+		t.Fatalf("%d pages, 3 attendues", len(pages))
+	}
+	repartition := []struct{ premiere, derniere int }{{0, 40}, {41, 81}, {82, 99}}
+	for i, page := range pages {
+		if page.largeur != "595.28" || page.hauteur != "841.89" {
+			t.Errorf("page %d de %s x %s pt, format A4 attendu", i+1, page.largeur, page.hauteur)
+		}
+		if strings.Count(page.texte, "(Colonne)") != 1 {
+			t.Errorf("page %d : l'en-tête du tableau n'est pas répété", i+1)
+		}
+		if !strings.Contains(page.texte, fmt.Sprintf("(Page %d / 3)", i+1)) {
+			t.Errorf("page %d : numéro de page absent", i+1)
+		}
+		attendue := repartition[i]
+		for n := range lignes {
+			present := strings.Contains(page.texte, fmt.Sprintf("(ligne-%03d)", n))
+			if present != (n >= attendue.premiere && n <= attendue.derniere) {
+				t.Errorf("page %d : ligne %d présente = %v", i+1, n, present)
+			}
+		}
+	}
+}
+
+func TestTronquer(t *testing.T) {
+	if s := tronquer("Court", 8, false, 50); s != "Court" {
+		t.Errorf("texte court modifié : %q", s)
+	}
+	long := strings.Repeat("Kabila ", 20)
+	s := tronquer(long, 8, false, 30)
+	if !strings.HasSuffix(s, "...") || !strings.HasPrefix(long, strings.TrimSuffix(s, "...")) {
+		t.Fatalf("texte tronqué %q", s)
+	}
+	if largeur := utils.PDFTextWidth(s, 8, false); largeur > 30 {
+		t.Errorf("texte tronqué de %v mm, au plus 30 attendus", largeur)
+	}
+}
+
+func TestPrintMigrantCardEtDossier(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+	token := basetest.Administrateur(t, db)
+
+	identite := models.Identite{UUID: "identite-1", Nom: "Kabongo", Prenom: "Marie", NumeroPasseport: "OP-1"}
+	migrant := models.Migrant{UUID: "migrant-1", IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-1", StatutMigratoire: "regulier"}
+	carte := models.CarteMigrant{
+		UUID: "carte-1", MigrantUUID: migrant.UUID, NumeroIdentifiant: migrant.NumeroIdentifiant, Serie: "S-1",
+		DateEmission: time.Now(), DateExpiration: time.Now().AddDate(2, 0, 0), Jeton: "jeton", Statut: "active",
+	}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Omit("Identite").Create(&migrant).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&carte).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Assez de positions pour que le dossier déborde sur plusieurs pages
+	for i := 0; i < maxPositionsDossier+10; i++ {
+		position := models.Geolocalisation{UUID: fmt.Sprintf("position-%03d", i), IdentiteUUID: identite.UUID, Latitude: -4.3, Longitude: 15.3}
+		if err := db.Omit("Identite").Create(&position).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/migrants/:uuid/card/pdf", PrintMigrantCard)
+	app.Get("/migrants/:uuid/dossier/pdf", PrintMigrantDossier)
+	imprimer := func(chemin string) []pagePDF {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", chemin+"?token="+token, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+			t.Fatalf("%s : statut %d, type %q", chemin, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lirePages(t, data)
+	}
+
+	// Carte : recto et verso au format ID-1
+	pages := imprimer("/migrants/migrant-1/card/pdf")
+	if len(pages) != 2 {
+		t.Fatalf("carte de %d pages, 2 attendues", len(pages))
+	}
+	for i, page := range pages {
+		if page.largeur != "242.65" || page.hauteur != "153.01" {
+			t.Errorf("face %d de %s x %s pt, format ID-1 attendu", i+1, page.largeur, page.hauteur)
+		}
+	}
+	if !strings.Contains(pages[0].texte, "(MIG-1)") {
+		t.Error("numéro d'identifiant absent du recto")
+	}
+
+	// Dossier : pages A4 numérotées, positions limitées
+	pages = imprimer("/migrants/migrant-1/dossier/pdf")
+	if len(pages) < 2 {
+		t.Fatalf("dossier de %d page(s), plusieurs attendues", len(pages))
+	}
+	for i, page := range pages {
+		if page.largeur != "595.28" || page.hauteur != "841.89" {
+			t.Errorf("page %d de %s x %s pt, format A4 attendu", i+1, page.largeur, page.hauteur)
+		}
+		if !strings.Contains(page.texte, fmt.Sprintf("(Page %d / %d)", i+1, len(pages))) {
+			t.Errorf("page %d : numéro de page absent", i+1)
+		}
+	}
+	var texte strings.Builder
+	for _, page := range pages {
+		texte.WriteString(page.texte)
+	}
+	if n := strings.Count(texte.String(), "(-4.30000)"); n != maxPositionsDossier {
+		t.Errorf("%d positions imprimées, %d attendues", n, maxPositionsDossier)
+	}
+}
@@ -1,310 +1,380 @@
 package migrants
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/patch"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/controllers/versions"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
 
-// Fonction pour générer automatiquement le NumeroIdentifiant
-func generateNumeroIdentifiant() string {
-	year := time.Now().Year()
+// Champs des migrants pouvant être filtrés, triés et sélectionnés
+var listeMigrants = requetes.Schema{
+	Table: "migrants",
+	Champs: map[string]requetes.Type{
+		"uuid":                   requetes.Texte,
+		"created_at":             requetes.Date,
+		"updated_at":             requetes.Date,
+		"identite_uuid":          requetes.Texte,
+		"numero_identifiant":     requetes.Texte,
+		"bureau_enregistrement":  requetes.Texte,
+		"unite_uuid":             requetes.Texte,
+		"version":                requetes.Nombre,
+		"telephone":              requetes.Texte,
+		"email":                  requetes.Texte,
+		"adresse_actuelle":       requetes.Texte,
+		"ville_actuelle":         requetes.Texte,
+		"pays_actuel":            requetes.Texte,
+		"situation_matrimoniale": requetes.Texte,
+		"nombre_enfants":         requetes.Nombre,
+		"statut_migratoire":      requetes.Texte,
+		"date_entree":            requetes.Date,
+		"point_entree":           requetes.Texte,
+		"pays_destination":       requetes.Texte,
+	},
+	Relations: []string{"identite", "motif_deplacements", "alertes", "biometries"},
+	TriDefaut: "-updated_at",
+}
 
-	// Compter le nombre de migrants créés cette année
-	var count int64
-	database.DB.Model(&models.Migrant{}).
-		Where("EXTRACT(YEAR FROM created_at) = ?", year).
-		Count(&count)
+// Paginate - Récupérer les migrants avec pagination et filtres
+func GetPaginatedMigrants(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeMigrants)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	// Incrémenter pour le nouveau migrant
-	sequence := count + 1
+		// Search filter
+		recherche := depots.Recherche(c.Query("search", ""),
+			"numero_identifiant", "adresse_actuelle", "ville_actuelle", "pays_actuel", "situation_matrimoniale").
+			OuDans("identite_uuid", "identites", "uuid", "nom", "postnom", "prenom", "nationalite", "numero_passeport")
 
-	return fmt.Sprintf("MIG-%d-%06d", year, sequence)
-}
+		// Filters, sort and pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).
+			Avec("Identite", "MotifDeplacements", "Alertes", "Biometries").
+			Paginer(liste, recherche)
 
-// Paginate - Récupérer les migrants avec pagination et filtres
-func GetPaginatedMigrants(c *fiber.Ctx) error {
-	db := database.DB
+		if err != nil {
+			return problemes.Echec("Failed to fetch Migrants", err)
+		}
 
-	// Parse query parameters for pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
+		// Return response
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Migrants retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
+		})
 	}
-	offset := (page - 1) * limit
+}
 
-	// Parse search and filter parameters
-	search := c.Query("search", "")
+// Query all data
+func GetAllMigrants(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeMigrants)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	var migrants []models.Migrant
-	var totalRecords int64
+		data, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Tout(liste)
 
-	// Build query with filters
-	query := db.Model(&models.Migrant{})
+		if err != nil {
+			return problemes.Echec("Failed to fetch migrants", err)
+		}
 
-	// Search filter
-	if search != "" {
-		query = query.Joins("LEFT JOIN identites ON migrants.identite_uuid = identites.uuid").
-			Where("identites.nom ILIKE ? OR identites.postnom ILIKE ? OR identites.prenom ILIKE ? OR migrants.numero_identifiant ILIKE ? OR identites.nationalite ILIKE ? OR identites.numero_passeport ILIKE ? OR migrants.adresse_actuelle ILIKE ? OR migrants.ville_actuelle ILIKE ? OR migrants.pays_actuel ILIKE ? OR migrants.situation_matrimoniale ILIKE ?",
-				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All migrants",
+			"data":    data,
+		})
 	}
+}
 
-	// Count total records with filters applied
-	query.Count(&totalRecords)
+// Get one data
+func GetMigrant(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrant, err := depot.Trouver(c.Params("uuid"),
+			"Identite", "MotifDeplacements", "Alertes", "Biometries", "Geolocalisations")
+		if err != nil {
+			return problemes.Absent("Migrant not found", err)
+		}
 
-	// Execute query with pagination
-	err = query.
-		Preload("Identite").
-		Preload("MotifDeplacements").
-		Preload("Alertes").
-		Preload("Biometries").
-		Offset(offset).
-		Limit(limit).
-		Order("migrants.updated_at DESC").
-		Find(&migrants).Error
+		if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch Migrants",
-			"error":   err.Error(),
+		versions.ETag(c, migrant.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Migrant found",
+			"data":    migrant,
 		})
 	}
+}
 
-	// Calculate total pages
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+// Create data
+func CreateMigrant(depot depots.Migrants, identites depots.Identites, criblage depots.Criblage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrant := &models.Migrant{}
 
-	// Prepare pagination metadata
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
+		if err := c.BodyParser(migrant); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	// Return response
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Migrants retrieved successfully",
-		"data":       migrants,
-		"pagination": pagination,
-	})
-}
+		// Générer automatiquement l'UUID et le NumeroIdentifiant
+		migrant.UUID = utils.GenerateUUID()
 
-// Query all data
-func GetAllMigrants(c *fiber.Ctx) error {
-	db := database.DB
-	var migrants []models.Migrant
+		// Le migrant appartient à l'unité de l'agent, dont le code sert de bureau par défaut
+		unite := unites.UniteDe(c)
+		migrant.UniteUUID = unite.UUID
+		if migrant.BureauEnregistrement == "" {
+			migrant.BureauEnregistrement = unite.Code
+		}
+		migrant.BureauEnregistrement = models.CodeBureau(migrant.BureauEnregistrement)
 
-	err := db.
-		Preload("Identite").
-		Find(&migrants).Error
+		// Le dépôt attribue le numéro d'identifiant dans le bureau d'enregistrement
+		if err := depot.Creer(migrant); err != nil {
+			return problemes.Echec("Failed to create migrant", err)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch migrants",
-			"error":   err.Error(),
+		// Criblage de l'identité : ouvre les alertes de sécurité sur le nouveau migrant
+		var hits []models.CorrespondanceSurveillance
+		avertissements := []string{}
+		identite, err := identites.Trouver(migrant.IdentiteUUID)
+		if err == nil {
+			hits, err = criblage.Cribler(*identite)
+		}
+		if err != nil {
+			avertissements = append(avertissements, depots.AvertissementCriblage)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":         "success",
+			"message":        "Migrant created successfully",
+			"data":           migrant,
+			"warnings":       avertissements,
+			"watchlist_hits": hits,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All migrants",
-		"data":    migrants,
-	})
 }
 
-// Get one data
-func GetMigrant(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-	var migrant models.Migrant
-
-	err := db.Where("uuid = ?", uuid).
-		Preload("Identite").
-		Preload("MotifDeplacements").
-		Preload("Alertes").
-		Preload("Biometries").
-		Preload("Geolocalisations").
-		First(&migrant).Error
+// Update data
+func UpdateMigrant(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData models.Migrant
 
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Migrant not found",
-			"data":    nil,
-		})
-	}
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Migrant found",
-		"data":    migrant,
-	})
-}
+		migrant, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Migrant not found", err)
+		}
 
-// Create data
-func CreateMigrant(c *fiber.Ctx) error {
-	migrant := &models.Migrant{}
-
-	if err := c.BodyParser(migrant); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
+		if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+			return unites.Refus(c)
+		}
+
+		if !versions.Correspond(c, migrant.Version) {
+			return versions.Conflit(c, migrant.Version, migrant)
+		}
+
+		// Conserver l'UUID, le NumeroIdentifiant et l'unité propriétaire existants
+		updateData.UUID = migrant.UUID
+		updateData.NumeroIdentifiant = migrant.NumeroIdentifiant
+		updateData.UniteUUID = migrant.UniteUUID
+
+		// Contexte enregistré dans l'historique en cas de changement de statut
+		var changement struct {
+			Motif             string     `json:"motif_changement"`
+			ReferenceDecision string     `json:"reference_decision"`
+			DateEffet         *time.Time `json:"date_effet"`
+		}
+		c.BodyParser(&changement)
+		userUUID, _ := utils.VerifyJwt(c.Query("token"))
+		historise := depot.AvecContexte(models.ContexteStatut{
+			Motif:             changement.Motif,
+			ReferenceDecision: changement.ReferenceDecision,
+			EffectuePar:       userUUID,
+			DateEffet:         changement.DateEffet,
 		})
-	}
 
-	// Générer automatiquement l'UUID et le NumeroIdentifiant
-	migrant.UUID = utils.GenerateUUID()
-	migrant.NumeroIdentifiant = generateNumeroIdentifiant()
+		// migrant.Telephone = updateData.Telephone
+		// migrant.Email = updateData.Email
+		// migrant.AdresseActuelle = updateData.AdresseActuelle
+		// migrant.VilleActuelle = updateData.VilleActuelle
+		// migrant.PaysActuel = updateData.PaysActuel
+		// migrant.SituationMatrimoniale = updateData.SituationMatrimoniale
+		// migrant.NombreEnfants = updateData.NombreEnfants
+		// migrant.PersonneContact = updateData.PersonneContact
+		// migrant.TelephoneContact = updateData.TelephoneContact
+		// migrant.StatutMigratoire = updateData.StatutMigratoire
+		// migrant.DateEntree = updateData.DateEntree
+		// migrant.PointEntree = updateData.PointEntree
+		// migrant.PaysDestination = updateData.PaysDestination
+
+		if err := historise.Modifier(migrant, &updateData); err != nil {
+			// Modifié par un autre agent entre la lecture et l'écriture
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuel, err := depot.Trouver(migrant.UUID); err == nil {
+					migrant = actuel
+				}
+				return versions.Conflit(c, migrant.Version, migrant)
+			}
+			return problemes.Echec("Failed to update migrant", err)
+		}
 
-	if err := database.DB.Create(migrant).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create migrant",
-			"error":   err.Error(),
+		versions.ETag(c, migrant.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Migrant updated successfully",
+			"data":    migrant,
 		})
 	}
+}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Migrant created successfully",
-		"data":    migrant,
-	})
+// Champs d'un migrant modifiables par PATCH ; le numéro, le bureau, l'unité et
+// l'identité de rattachement sont fixés à l'enregistrement
+var reglesPatchMigrant = patch.Regles{
+	Modifiables: []string{
+		"telephone", "email", "adresse_actuelle", "ville_actuelle", "pays_actuel",
+		"situation_matrimoniale", "nombre_enfants", "personne_contact", "telephone_contact",
+		"statut_migratoire", "date_entree", "point_entree", "pays_destination",
+	},
+	Immuables: []string{
+		"uuid", "numero_identifiant", "bureau_enregistrement", "unite_uuid", "identite_uuid",
+		"version", "created_at", "updated_at", "deleted_at",
+	},
 }
 
-// Update data
-func UpdateMigrant(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+// PatchMigrant - Modifier partiellement un migrant (JSON Merge Patch, RFC 7396)
+func PatchMigrant(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		corps, ok := patch.Lire(c)
+		if !ok {
+			return patch.TypeNonSupporte(c)
+		}
 
-	var updateData models.Migrant
+		migrant, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Migrant not found", err)
+		}
 
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"error":   err.Error(),
-		})
-	}
+		if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+			return unites.Refus(c)
+		}
 
-	migrant := new(models.Migrant)
+		if !versions.Correspond(c, migrant.Version) {
+			return versions.Conflit(c, migrant.Version, migrant)
+		}
 
-	if err := db.Where("uuid = ?", uuid).First(&migrant).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Migrant not found",
-			"data":    nil,
-		})
-	}
+		corrige, champs, erreurs := patch.Appliquer(*migrant, corps, reglesPatchMigrant)
+		if len(erreurs) > 0 {
+			return patch.Invalide(c, erreurs)
+		}
+
+		userUUID, _ := utils.VerifyJwt(c.Query("token"))
+		historise := depot.AvecContexte(models.ContexteStatut{EffectuePar: userUUID})
+
+		err = historise.Modifier(migrant, &corrige, champs...)
+		conflit := errors.Is(err, depots.ErrVersionPerimee)
+		if err != nil && !conflit {
+			return problemes.Echec("Failed to update migrant", err)
+		}
+
+		if actuel, err := depot.Trouver(migrant.UUID); err == nil {
+			migrant = actuel
+		}
+
+		// Modifié par un autre agent entre la lecture et l'écriture
+		if conflit {
+			return versions.Conflit(c, migrant.Version, migrant)
+		}
 
-	// Conserver l'UUID et le NumeroIdentifiant existants
-	updateData.UUID = migrant.UUID
-	updateData.NumeroIdentifiant = migrant.NumeroIdentifiant
-
-	// migrant.Telephone = updateData.Telephone
-	// migrant.Email = updateData.Email
-	// migrant.AdresseActuelle = updateData.AdresseActuelle
-	// migrant.VilleActuelle = updateData.VilleActuelle
-	// migrant.PaysActuel = updateData.PaysActuel
-	// migrant.SituationMatrimoniale = updateData.SituationMatrimoniale
-	// migrant.NombreEnfants = updateData.NombreEnfants
-	// migrant.PersonneContact = updateData.PersonneContact
-	// migrant.TelephoneContact = updateData.TelephoneContact
-	// migrant.StatutMigratoire = updateData.StatutMigratoire
-	// migrant.DateEntree = updateData.DateEntree
-	// migrant.PointEntree = updateData.PointEntree
-	// migrant.PaysDestination = updateData.PaysDestination
-
-	if err := db.Model(&migrant).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to update migrant",
-			"error":   err.Error(),
+		versions.ETag(c, migrant.Version)
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Migrant updated successfully",
+			"data":    migrant,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Migrant updated successfully",
-		"data":    migrant,
-	})
 }
 
 // Delete data
-func DeleteMigrant(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var migrant models.Migrant
-	if err := db.Where("uuid = ?", uuid).First(&migrant).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Migrant not found",
-			"data":    nil,
-		})
-	}
+func DeleteMigrant(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrant, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Migrant not found", err)
+		}
+
+		if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+			return unites.Refus(c)
+		}
+
+		if !versions.Correspond(c, migrant.Version) {
+			return versions.Conflit(c, migrant.Version, migrant)
+		}
 
-	// Soft delete - les relations seront également supprimées grâce à OnDelete:CASCADE
-	if err := db.Delete(&migrant).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to delete migrant",
-			"error":   err.Error(),
+		// Soft delete - les relations seront également supprimées grâce à OnDelete:CASCADE
+		if err := depot.Supprimer(migrant); err != nil {
+			// Modifié par un autre agent entre la lecture et la suppression
+			if errors.Is(err, depots.ErrVersionPerimee) {
+				if actuel, err := depot.Trouver(migrant.UUID); err == nil {
+					migrant = actuel
+				}
+				return versions.Conflit(c, migrant.Version, migrant)
+			}
+			return problemes.Echec("Failed to delete migrant", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Migrant deleted successfully",
+			"data":    nil,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Migrant deleted successfully",
-		"data":    nil,
-	})
 }
 
 // Get migrants statistics
-func GetMigrantsStats(c *fiber.Ctx) error {
-	db := database.DB
-
-	var totalMigrants int64
-	var regularMigrants int64
-	var irregularMigrants int64
-	var refugeeMigrants int64
-	var asylumSeekers int64
-
-	// Total migrants
-	db.Model(&models.Migrant{}).Count(&totalMigrants)
-
-	// Par statut migratoire
-	db.Model(&models.Migrant{}).Where("statut_migratoire = ?", "regulier").Count(&regularMigrants)
-	db.Model(&models.Migrant{}).Where("statut_migratoire = ?", "irregulier").Count(&irregularMigrants)
-	db.Model(&models.Migrant{}).Where("statut_migratoire = ?", "refugie").Count(&refugeeMigrants)
-	db.Model(&models.Migrant{}).Where("statut_migratoire = ?", "demandeur_asile").Count(&asylumSeekers)
-
-	stats := map[string]interface{}{
-		"total_migrants":     totalMigrants,
-		"regular_migrants":   regularMigrants,
-		"irregular_migrants": irregularMigrants,
-		"refugee_migrants":   refugeeMigrants,
-		"asylum_seekers":     asylumSeekers,
-	}
+func GetMigrantsStats(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		migrants := depot.Lire(unites.PerimetreDe(c).Portee)
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Migrants statistics",
-		"data":    stats,
-	})
+		// Total migrants
+		totalMigrants, _ := migrants.Compter()
+
+		// Par statut migratoire
+		regularMigrants, _ := migrants.Compter(depots.Egal("statut_migratoire", "regulier"))
+		irregularMigrants, _ := migrants.Compter(depots.Egal("statut_migratoire", "irregulier"))
+		refugeeMigrants, _ := migrants.Compter(depots.Egal("statut_migratoire", "refugie"))
+		asylumSeekers, _ := migrants.Compter(depots.Egal("statut_migratoire", "demandeur_asile"))
+
+		stats := map[string]interface{}{
+			"total_migrants":     totalMigrants,
+			"regular_migrants":   regularMigrants,
+			"irregular_migrants": irregularMigrants,
+			"refugee_migrants":   refugeeMigrants,
+			"asylum_seekers":     asylumSeekers,
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Migrants statistics",
+			"data":    stats,
+		})
+	}
 }
 
 // =======================
@@ -312,43 +382,41 @@ func GetMigrantsStats(c *fiber.Ctx) error {
 // =======================
 
 // ExportMigrantsToExcel - Exporter les migrants vers Excel avec mise en forme
-func ExportMigrantsToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Récupérer les paramètres de filtre pour les dates
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
-
-	var migrants []models.Migrant
-
-	query := db.Model(&models.Migrant{}).Preload("Identite")
-
-	// Appliquer le filtre de plage de dates sur created_at
-	if startDate != "" {
-		parsedStartDate, err := time.Parse("2006-01-02", startDate)
-		if err == nil {
-			query = query.Where("migrants.created_at >= ?", parsedStartDate)
+func ExportMigrantsToExcel(depot depots.Migrants) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de filtre pour les dates
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Appliquer le filtre de plage de dates sur created_at
+		var conditions []depots.Condition
+		if startDate != "" {
+			parsedStartDate, err := time.Parse("2006-01-02", startDate)
+			if err == nil {
+				conditions = append(conditions, depots.AuMoins("created_at", parsedStartDate))
+			}
 		}
-	}
-	if endDate != "" {
-		parsedEndDate, err := time.Parse("2006-01-02", endDate)
-		if err == nil {
-			// Ajouter 23h59m59s pour inclure toute la journée de fin
-			parsedEndDate = parsedEndDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-			query = query.Where("migrants.created_at <= ?", parsedEndDate)
+		if endDate != "" {
+			parsedEndDate, err := time.Parse("2006-01-02", endDate)
+			if err == nil {
+				// Ajouter 23h59m59s pour inclure toute la journée de fin
+				parsedEndDate = parsedEndDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+				conditions = append(conditions, depots.AuPlus("created_at", parsedEndDate))
+			}
 		}
-	}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&migrants).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch migrants for export",
-			"error":   err.Error(),
-		})
+		// Récupérer toutes les données
+		migrants, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Identite").Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch migrants for export", err)
+		}
+
+		return exporterMigrants(c, migrants, startDate, endDate)
 	}
+}
 
+// exporterMigrants écrit le classeur des migrants exportés
+func exporterMigrants(c *fiber.Ctx, migrants []models.Migrant, startDate, endDate string) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -361,11 +429,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 	f.DeleteSheet("Sheet1")
 	index, err := f.NewSheet("Migrants")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 	f.SetActiveSheet(index)
 
@@ -395,11 +459,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create header style", err)
 	}
 
 	// Style pour les en-têtes de colonnes
@@ -427,11 +487,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create column header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create column header style", err)
 	}
 
 	// Style pour les cellules de données
@@ -453,11 +509,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create data style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create data style", err)
 	}
 
 	// Style pour les cellules numériques
@@ -479,11 +531,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 		NumFmt: 1, // Format numérique sans décimales
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create number style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create number style", err)
 	}
 
 	// Style pour les cellules de date
@@ -505,11 +553,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 		NumFmt: 14, // Format de date mm/dd/yyyy
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create date style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create date style", err)
 	}
 
 	// ===== EN-TÊTE PRINCIPAL =====
@@ -873,11 +917,7 @@ func ExportMigrantsToExcel(c *fiber.Ctx) error {
 	// Sauvegarder en mémoire
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Configurer les en-têtes de réponse pour le téléchargement
@@ -0,0 +1,53 @@
+package migrants
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// GetMigrantStatusHistory - Historique daté des statuts migratoires d'un migrant
+// GET /api/migrants/:uuid/status-history
+func GetMigrantStatusHistory(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var migrant models.Migrant
+	if err := db.Where("uuid = ?", uuid).First(&migrant).Error; err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	var historique []models.HistoriqueStatut
+	err := db.Where("migrant_uuid = ?", uuid).
+		Order("date_effet ASC, created_at ASC").
+		Find(&historique).Error
+	if err != nil {
+		return problemes.Echec("Failed to fetch status history", err)
+	}
+
+	// Date à laquelle chaque statut a été obtenu pour la première fois
+	premieresDates := map[string]time.Time{}
+	for _, version := range historique {
+		if _, ok := premieresDates[version.StatutMigratoire]; !ok {
+			premieresDates[version.StatutMigratoire] = version.DateEffet
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Status history retrieved successfully",
+		"data": fiber.Map{
+			"statut_actuel":   migrant.StatutMigratoire,
+			"historique":      historique,
+			"premieres_dates": premieresDates,
+		},
+	})
+}
@@ -6,8 +6,11 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
@@ -16,239 +19,197 @@ import (
 // CRUD OPERATIONS
 // =======================
 
-// Paginate - Récupérer les motifs avec pagination
-func GetPaginatedMotifDeplacements(c *fiber.Ctx) error {
-	db := database.DB
-
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
-
-	search := c.Query("search", "")
-
-	var motifs []models.MotifDeplacement
-	var totalRecords int64
+// Champs des motifs pouvant être filtrés, triés et sélectionnés
+var listeMotifs = requetes.Schema{
+	Table: "motif_deplacements",
+	Champs: map[string]requetes.Type{
+		"uuid":                 requetes.Texte,
+		"created_at":           requetes.Date,
+		"updated_at":           requetes.Date,
+		"migrant_uuid":         requetes.Texte,
+		"type_motif":           requetes.Texte,
+		"motif_principal":      requetes.Texte,
+		"motif_secondaire":     requetes.Texte,
+		"caractere_volontaire": requetes.Booleen,
+		"urgence":              requetes.Texte,
+		"date_declenchement":   requetes.Date,
+		"duree_estimee":        requetes.Nombre,
+	},
+	Relations: []string{"migrant"},
+	TriDefaut: "-created_at",
+}
 
-	query := db.Model(&models.MotifDeplacement{}).
-		Preload("Migrant")
+// Paginate - Récupérer les motifs avec pagination
+func GetPaginatedMotifDeplacements(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeMotifs)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	// Recherche textuelle
-	if search != "" {
-		query = query.Joins("LEFT JOIN migrants ON migrants.uuid = motif_deplacements.migrant_uuid").
-			Where("type_motif ILIKE ? OR motif_principal ILIKE ? OR description ILIKE ? OR migrants.numero_identifiant ILIKE ?",
-				"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
-	}
+		// Recherche textuelle
+		recherche := depots.Recherche(c.Query("search", ""), "type_motif", "motif_principal", "description").
+			OuDans("migrant_uuid", "migrants", "uuid", "numero_identifiant")
 
-	// Count total
-	query.Count(&totalRecords)
+		// Filtres, tri et pagination
+		data, pagination, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Paginer(liste, recherche)
 
-	// Get paginated results
-	err = query.
-		Preload("Migrant").
-		Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&motifs).Error
+		if err != nil {
+			return problemes.Echec("Failed to fetch motifs de déplacement", err)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch motifs de déplacement",
-			"error":   err.Error(),
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Motifs de déplacement retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
 		})
 	}
-
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
-
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
-
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Motifs de déplacement retrieved successfully",
-		"data":       motifs,
-		"pagination": pagination,
-	})
 }
 
 // Get all motifs
-func GetAllMotifDeplacements(c *fiber.Ctx) error {
-	db := database.DB
-	var motifs []models.MotifDeplacement
+func GetAllMotifDeplacements(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeMotifs)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	err := db.Preload("Migrant").Find(&motifs).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch motifs",
-			"error":   err.Error(),
+		data, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Tout(liste)
+		if err != nil {
+			return problemes.Echec("Failed to fetch motifs", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All motifs de déplacement",
+			"data":    data,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All motifs de déplacement",
-		"data":    motifs,
-	})
 }
 
 // Get one motif
-func GetMotifDeplacement(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-	var motif models.MotifDeplacement
+func GetMotifDeplacement(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		motif, err := depot.Trouver(c.Params("uuid"), "Migrant")
+		if err != nil {
+			return problemes.Absent("Motif de déplacement not found", err)
+		}
 
-	err := db.Where("uuid = ?", uuid).
-		Preload("Migrant").
-		First(&motif).Error
+		if !unites.AccederMigrant(c, motif.MigrantUUID, "motif_deplacement", motif.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Motif de déplacement not found",
-			"data":    nil,
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Motif de déplacement found",
+			"data":    motif,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Motif de déplacement found",
-		"data":    motif,
-	})
 }
 
 // Create motif
-func CreateMotifDeplacement(c *fiber.Ctx) error {
-	motif := &models.MotifDeplacement{}
-
-	if err := c.BodyParser(motif); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	}
+func CreateMotifDeplacement(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		motif := &models.MotifDeplacement{}
 
-	// Validation des champs requis
-	if motif.MigrantUUID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "MigrantUUID is required",
-			"data":    nil,
-		})
-	}
+		if err := c.BodyParser(motif); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	// Vérifier que le migrant existe
-	// var migrant models.Migrant
-	// if err := database.DB.Where("uuid = ?", motif.MigrantUUID).First(&migrant).Error; err != nil {
-	// 	return c.Status(404).JSON(fiber.Map{
-	// 		"status":  "error",
-	// 		"message": "Migrant not found",
-	// 		"data":    nil,
-	// 	})
-	// }
-
-	// Générer l'UUID
-	motif.UUID = utils.GenerateUUID()
-
-	// Validation des données
-	if err := utils.ValidateStruct(*motif); err != nil {
-		return c.Status(400).JSON(err)
-	}
+		// Validation des champs requis
+		if motif.MigrantUUID == "" {
+			return problemes.Invalide("MigrantUUID is required")
+		}
 
-	if err := database.DB.Create(motif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create motif de déplacement",
-			"error":   err.Error(),
-		})
-	} 
+		// Vérifier que le migrant existe
+		// var migrant models.Migrant
+		// if err := database.DB.Where("uuid = ?", motif.MigrantUUID).First(&migrant).Error; err != nil {
+		// 	return c.Status(404).JSON(fiber.Map{
+		// 		"status":  "error",
+		// 		"message": "Migrant not found",
+		// 		"data":    nil,
+		// 	})
+		// }
+
+		if !unites.AccederMigrant(c, motif.MigrantUUID, "migrant", motif.MigrantUUID) {
+			return unites.Refus(c)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Motif de déplacement created successfully",
-		"data":    motif,
-	})
-}
+		// Générer l'UUID
+		motif.UUID = utils.GenerateUUID()
 
-// Update motif
-func UpdateMotifDeplacement(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var updateData models.MotifDeplacement
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Review your input",
-			"error":   err.Error(),
-		})
-	}
+		// Validation des données
+		if err := utils.ValidateStruct(*motif); err != nil {
+			return problemes.Validation(err)
+		}
 
-	motif := new(models.MotifDeplacement)
-	if err := db.Where("uuid = ?", uuid).First(&motif).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Motif de déplacement not found",
-			"data":    nil,
+		if err := depot.Creer(motif); err != nil {
+			return problemes.Echec("Failed to create motif de déplacement", err)
+		} 
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Motif de déplacement created successfully",
+			"data":    motif,
 		})
 	}
+}
 
-	// Conserver l'UUID
-	updateData.UUID = motif.UUID
+// Update motif
+func UpdateMotifDeplacement(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData models.MotifDeplacement
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
 
-	if err := db.Model(&motif).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to update motif de déplacement",
-			"error":   err.Error(),
-		})
-	} 
+		motif, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Motif de déplacement not found", err)
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Motif de déplacement updated successfully",
-		"data":    motif,
-	})
-}
+		if !unites.AccederMigrant(c, motif.MigrantUUID, "motif_deplacement", motif.UUID) {
+			return unites.Refus(c)
+		}
 
-// Delete motif
-func DeleteMotifDeplacement(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-
-	var motif models.MotifDeplacement
-	if err := db.Where("uuid = ?", uuid).First(&motif).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Motif de déplacement not found",
-			"data":    nil,
+		// Conserver l'UUID
+		updateData.UUID = motif.UUID
+
+		if err := depot.Modifier(motif, &updateData); err != nil {
+			return problemes.Echec("Failed to update motif de déplacement", err)
+		} 
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Motif de déplacement updated successfully",
+			"data":    motif,
 		})
 	}
+}
+
+// Delete motif
+func DeleteMotifDeplacement(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		motif, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Motif de déplacement not found", err)
+		}
+
+		if !unites.AccederMigrant(c, motif.MigrantUUID, "motif_deplacement", motif.UUID) {
+			return unites.Refus(c)
+		}
 
-	if err := db.Delete(&motif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to delete motif de déplacement",
-			"error":   err.Error(),
+		if err := depot.Supprimer(motif); err != nil {
+			return problemes.Echec("Failed to delete motif de déplacement", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Motif de déplacement deleted successfully",
+			"data":    nil,
 		})
 	}
-
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Motif de déplacement deleted successfully",
-		"data":    nil,
-	})
 }
 
 // =======================
@@ -256,49 +217,34 @@ func DeleteMotifDeplacement(c *fiber.Ctx) error {
 // =======================
 
 // Get motifs statistics
-func GetMotifsStats(c *fiber.Ctx) error {
-	db := database.DB
-
-	var totalMotifs int64
-	var motifsVolontaires int64
-	var motifsInvolontaires int64
-
-	// Compter par type de motif
-	var motifTypes []map[string]interface{}
-
-	db.Model(&models.MotifDeplacement{}).Count(&totalMotifs)
-	db.Model(&models.MotifDeplacement{}).Where("caractere_volontaire = ?", true).Count(&motifsVolontaires)
-	db.Model(&models.MotifDeplacement{}).Where("caractere_volontaire = ?", false).Count(&motifsInvolontaires)
-
-	// Statistiques par type de motif
-	db.Model(&models.MotifDeplacement{}).
-		Select("type_motif, COUNT(*) as count").
-		Group("type_motif").
-		Order("count DESC").
-		Scan(&motifTypes)
-
-	// Statistiques par niveau d'urgence
-	var urgenceStats []map[string]interface{}
-	db.Model(&models.MotifDeplacement{}).
-		Select("urgence, COUNT(*) as count").
-		Group("urgence").
-		Order("count DESC").
-		Scan(&urgenceStats)
- 
-
-	stats := map[string]interface{}{
-		"total_motifs":         totalMotifs,
-		"motifs_volontaires":   motifsVolontaires,
-		"motifs_involontaires": motifsInvolontaires,
-		"types_motifs":         motifTypes,
-		"urgence_stats":        urgenceStats, 
-	}
+func GetMotifsStats(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		motifs := depot.Lire(unites.PerimetreDe(c).Portee)
+
+		totalMotifs, _ := motifs.Compter()
+		motifsVolontaires, _ := motifs.Compter(depots.Egal("caractere_volontaire", true))
+		motifsInvolontaires, _ := motifs.Compter(depots.Egal("caractere_volontaire", false))
+
+		// Statistiques par type de motif
+		motifTypes, _ := motifs.Repartition("type_motif", 0)
+
+		// Statistiques par niveau d'urgence
+		urgenceStats, _ := motifs.Repartition("urgence", 0)
+
+		stats := map[string]interface{}{
+			"total_motifs":         totalMotifs,
+			"motifs_volontaires":   motifsVolontaires,
+			"motifs_involontaires": motifsInvolontaires,
+			"types_motifs":         motifTypes,
+			"urgence_stats":        urgenceStats,
+		}
 
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "Motifs statistics",
-		"data":    stats,
-	})
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Motifs statistics",
+			"data":    stats,
+		})
+	}
 }
 
 // =======================
@@ -306,41 +252,39 @@ func GetMotifsStats(c *fiber.Ctx) error {
 // =======================
 
 // ExportMotifDeplacementsToExcel - Exporter les motifs de déplacement vers Excel avec mise en forme
-func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Récupérer les paramètres de filtre
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
-
-	var motifs []models.MotifDeplacement
-
-	query := db.Model(&models.MotifDeplacement{}).Preload("Migrant")
-
-	// Appliquer les filtres de date
-	if startDate != "" {
-		parsedStartDate, err := time.Parse("2006-01-02", startDate)
-		if err == nil {
-			query = query.Where("created_at >= ?", parsedStartDate)
+func ExportMotifDeplacementsToExcel(depot depots.Motifs) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Récupérer les paramètres de filtre
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Appliquer les filtres de date
+		var conditions []depots.Condition
+		if startDate != "" {
+			parsedStartDate, err := time.Parse("2006-01-02", startDate)
+			if err == nil {
+				conditions = append(conditions, depots.AuMoins("created_at", parsedStartDate))
+			}
 		}
-	}
-	if endDate != "" {
-		parsedEndDate, err := time.Parse("2006-01-02", endDate)
-		if err == nil {
-			query = query.Where("created_at <= ?", parsedEndDate)
+		if endDate != "" {
+			parsedEndDate, err := time.Parse("2006-01-02", endDate)
+			if err == nil {
+				conditions = append(conditions, depots.AuPlus("created_at", parsedEndDate))
+			}
 		}
-	}
 
-	// Récupérer toutes les données
-	err := query.Order("created_at DESC").Find(&motifs).Error
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch motifs for export",
-			"error":   err.Error(),
-		})
+		// Récupérer toutes les données
+		motifs, err := depot.Lire(unites.PerimetreDe(c).Portee).Avec("Migrant").Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch motifs for export", err)
+		}
+
+		return exporterMotifs(c, motifs, startDate, endDate)
 	}
+}
 
+// exporterMotifs écrit le classeur des motifs exportés
+func exporterMotifs(c *fiber.Ctx, motifs []models.MotifDeplacement, startDate, endDate string) error {
 	// Créer un nouveau fichier Excel
 	f := excelize.NewFile()
 	defer func() {
@@ -353,11 +297,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 	f.DeleteSheet("Sheet1")
 	index, err := f.NewSheet("Motifs de Déplacement")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create Excel sheet",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create Excel sheet", err)
 	}
 	f.SetActiveSheet(index)
 
@@ -387,11 +327,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create header style", err)
 	}
 
 	// Style pour les en-têtes de colonnes
@@ -419,11 +355,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create column header style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create column header style", err)
 	}
 
 	// Style pour les cellules de données
@@ -445,11 +377,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 		},
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create data style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create data style", err)
 	}
 
 	// Style pour les cellules de date
@@ -471,11 +399,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 		NumFmt: 14, // Format de date mm/dd/yyyy
 	})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create date style",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to create date style", err)
 	}
 
 	// ===== EN-TÊTE PRINCIPAL =====
@@ -640,11 +564,7 @@ func ExportMotifDeplacementsToExcel(c *fiber.Ctx) error {
 	// Sauvegarder en mémoire
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Configurer les en-têtes de réponse pour le téléchargement
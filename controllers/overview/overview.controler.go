@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
 )
 
 // =================== INTERFACES POUR LE FRONTEND ===================
@@ -57,6 +58,7 @@ type VulnerabiliteBesoinsIndicateurs struct {
 	AccesServicesBase   AccesServicesStats       `json:"acces_services_base"`
 	TauxOccupationSites float64                  `json:"taux_occupation_sites"`
 	DeplacesHorsSites   int64                    `json:"deplaces_hors_sites"`
+	CompositionMenages  CompositionMenagesStats  `json:"composition_menages"`
 }
 
 // Dynamiques et alertes
@@ -96,6 +98,19 @@ type ProfilDemographiqueStats struct {
 	AgeMoyen           float64 `json:"age_moyen"`
 }
 
+type CompositionMenagesStats struct {
+	NombreMenages                 int     `json:"nombre_menages"`
+	TailleMoyenne                 float64 `json:"taille_moyenne"`
+	PersonnesSeules               int     `json:"personnes_seules"`
+	MenagesMonoparentaux          int     `json:"menages_monoparentaux"`
+	MenagesDirigesParFemme        int     `json:"menages_diriges_par_femme"`
+	MenagesDirigesParMineur       int     `json:"menages_diriges_par_mineur"`
+	MenagesAvecAines              int     `json:"menages_avec_aines"`
+	MenagesDisperses              int     `json:"menages_disperses"`
+	MineursNonAccompagnes         int     `json:"mineurs_non_accompagnes"`
+	PourcentageMenagesVulnerables float64 `json:"pourcentage_menages_vulnerables"`
+}
+
 type AccesServicesStats struct {
 	AccesEau       float64 `json:"acces_eau"`
 	AccesSante     float64 `json:"acces_sante"`
@@ -140,6 +155,27 @@ type MotifPieChartResponse struct {
 	PeriodeAnalyse string           `json:"periode_analyse"`
 }
 
+// Structure pour l'analyse des transitions de statut migratoire
+type TransitionStatutStats struct {
+	De     string `json:"de"`
+	Vers   string `json:"vers"`
+	Nombre int64  `json:"nombre"`
+}
+
+type EvolutionTransitionsStats struct {
+	Periode     string                  `json:"periode"`
+	Transitions []TransitionStatutStats `json:"transitions"`
+	Total       int64                   `json:"total"`
+}
+
+type TransitionsStatutResponse struct {
+	Evolution      []EvolutionTransitionsStats `json:"evolution"`
+	Totaux         []TransitionStatutStats     `json:"totaux"`
+	Total          int64                       `json:"total"`
+	DateMiseAJour  time.Time                   `json:"date_mise_a_jour"`
+	PeriodeAnalyse string                      `json:"periode_analyse"`
+}
+
 // =================== ENDPOINTS PRINCIPAUX ===================
 
 // GetIndicateursGeneraux - Endpoint principal pour récupérer tous les indicateurs
@@ -154,11 +190,12 @@ func GetIndicateursGeneraux(c *fiber.Ctx) error {
 		periodeInt = 12
 	}
 
-	// Générer tous les indicateurs
-	volumeLocalisation := getVolumeLocalisationIndicateurs(periodeInt, province)
-	causesDeplacements := getCausesDeplacementsIndicateurs(periodeInt, province)
-	vulnerabiliteBesoins := getVulnerabiliteBesoinsIndicateurs(periodeInt, province)
-	dynamiquesAlerte := getDynamiquesAlerteIndicateurs(periodeInt, province)
+	// Générer tous les indicateurs dans le périmètre de l'utilisateur
+	p := unites.PerimetreDe(c)
+	volumeLocalisation := getVolumeLocalisationIndicateurs(p, periodeInt, province)
+	causesDeplacements := getCausesDeplacementsIndicateurs(p, periodeInt, province)
+	vulnerabiliteBesoins := getVulnerabiliteBesoinsIndicateurs(p, periodeInt, province)
+	dynamiquesAlerte := getDynamiquesAlerteIndicateurs(p, periodeInt, province)
 
 	response := IndicateursDeplacementResponse{
 		VolumeLocalisation:   volumeLocalisation,
@@ -184,7 +221,7 @@ func GetAlertesTempsReel(c *fiber.Ctx) error {
 		joursInt = 7
 	}
 
-	alertes := getAlertesRecentes(niveaux, province, joursInt)
+	alertes := getAlertesRecentes(unites.PerimetreDe(c), niveaux, province, joursInt)
 
 	response := AlertesTempsReelResponse{
 		AlertesActives: alertes,
@@ -206,7 +243,7 @@ func GetRepartitionGeographique(c *fiber.Ctx) error {
 	}
 
 	// Récupérer la répartition sans filtre de province pour avoir une vue globale
-	repartition := getRepartitionGeographique(periodeInt, "")
+	repartition := getRepartitionGeographique(unites.PerimetreDe(c), periodeInt, "")
 
 	response := struct {
 		RepartitionProvinces []RepartitionProvinceStats `json:"repartition_provinces"`
@@ -233,7 +270,7 @@ func GetMotifsPieChart(c *fiber.Ctx) error {
 	}
 
 	// Récupérer les données du pie chart
-	pieData := getMotifsPieChartData(periodeInt, province)
+	pieData := getMotifsPieChartData(unites.PerimetreDe(c), periodeInt, province)
 
 	// Calculer le total
 	var total int64
@@ -250,3 +287,27 @@ func GetMotifsPieChart(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// GetTransitionsStatut - Endpoint pour l'évolution mensuelle des changements de statut migratoire
+// GET /api/overview/transitions-statut?periode=12&province=
+func GetTransitionsStatut(c *fiber.Ctx) error {
+	periode := c.Query("periode", "12")
+	province := c.Query("province", "")
+
+	periodeInt, err := strconv.Atoi(periode)
+	if err != nil {
+		periodeInt = 12
+	}
+
+	evolution, totaux, total := getTransitionsStatut(unites.PerimetreDe(c), periodeInt, province)
+
+	response := TransitionsStatutResponse{
+		Evolution:      evolution,
+		Totaux:         totaux,
+		Total:          total,
+		DateMiseAJour:  time.Now(),
+		PeriodeAnalyse: strconv.Itoa(periodeInt) + " derniers mois",
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -1,9 +1,12 @@
 package overview
 
 import (
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/kgermando/sysmobembo-api/controllers/relations"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
 	"github.com/kgermando/sysmobembo-api/database"
 	"github.com/kgermando/sysmobembo-api/models"
 )
@@ -11,13 +14,13 @@ import (
 // =================== FONCTIONS HELPER ===================
 
 // 🧍‍♂️ INDICATEURS DE VOLUME ET LOCALISATION
-func getVolumeLocalisationIndicateurs(periode int, province string) VolumeLocalisationIndicateurs {
+func getVolumeLocalisationIndicateurs(p *unites.Perimetre, periode int, province string) VolumeLocalisationIndicateurs {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
 	// Nombre total de migrants
 	var totalMigrants int64
-	query := db.Model(&models.Migrant{}).Where("created_at >= ?", dateDebut)
+	query := db.Model(&models.Migrant{}).Scopes(p.Migrants).Where("created_at >= ?", dateDebut)
 	if province != "" {
 		query = query.Where("ville_actuelle = ? OR pays_actuel LIKE ?", province, "%"+province+"%")
 	}
@@ -25,7 +28,7 @@ func getVolumeLocalisationIndicateurs(periode int, province string) VolumeLocali
 
 	// Nombre de déplacés internes (migrants qui ont changé de province/ville dans le même pays)
 	var deplacesInternes int64
-	deplacesQuery := db.Table("migrants m").
+	deplacesQuery := db.Table("migrants m").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
 		Where("m.created_at >= ? AND i.nationalite = m.pays_actuel AND i.lieu_naissance != m.ville_actuelle",
 			dateDebut)
@@ -39,7 +42,7 @@ func getVolumeLocalisationIndicateurs(periode int, province string) VolumeLocali
 
 	// Personnes retournées (approximation basée sur les géolocalisations récentes)
 	var personnesRetournees int64
-	geoQuery := db.Table("geolocalisations g").
+	geoQuery := db.Table("geolocalisations g").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN migrants m ON g.identite_uuid = m.identite_uuid").
 		Where("g.created_at >= ?", dateDebut)
 	if province != "" {
@@ -48,10 +51,10 @@ func getVolumeLocalisationIndicateurs(periode int, province string) VolumeLocali
 	geoQuery.Count(&personnesRetournees)
 
 	// Répartition géographique par province
-	repartitionGeo := getRepartitionGeographique(periode, province)
+	repartitionGeo := getRepartitionGeographique(p, periode, province)
 
 	// Évolution mensuelle
-	evolutionMensuelle := getEvolutionMensuelle(periode, province)
+	evolutionMensuelle := getEvolutionMensuelle(p, periode, province)
 
 	return VolumeLocalisationIndicateurs{
 		NombreTotalPDI:          totalPDI,
@@ -63,7 +66,7 @@ func getVolumeLocalisationIndicateurs(periode int, province string) VolumeLocali
 	}
 }
 
-func getRepartitionGeographique(periode int, province string) []RepartitionProvinceStats {
+func getRepartitionGeographique(p *unites.Perimetre, periode int, province string) []RepartitionProvinceStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
@@ -72,7 +75,7 @@ func getRepartitionGeographique(periode int, province string) []RepartitionProvi
 		Count    int64  `json:"count"`
 	}
 
-	query := db.Table("migrants").
+	query := db.Table("migrants").Scopes(p.Migrants).
 		Select("ville_actuelle as province, COUNT(*) as count").
 		Where("created_at >= ?", dateDebut).
 		Group("ville_actuelle").
@@ -106,7 +109,7 @@ func getRepartitionGeographique(periode int, province string) []RepartitionProvi
 	return repartition
 }
 
-func getEvolutionMensuelle(periode int, province string) []EvolutionTemporelleStats {
+func getEvolutionMensuelle(p *unites.Perimetre, periode int, province string) []EvolutionTemporelleStats {
 	db := database.DB
 	var evolution []EvolutionTemporelleStats
 
@@ -116,7 +119,7 @@ func getEvolutionMensuelle(periode int, province string) []EvolutionTemporelleSt
 
 		// Nouveaux déplacés ce mois
 		var nouveauxDeplaces int64
-		query := db.Model(&models.Migrant{}).
+		query := db.Model(&models.Migrant{}).Scopes(p.Migrants).
 			Where("created_at >= ? AND created_at < ?", debutMois, finMois)
 		if province != "" {
 			query = query.Where("ville_actuelle = ?", province)
@@ -125,7 +128,7 @@ func getEvolutionMensuelle(periode int, province string) []EvolutionTemporelleSt
 
 		// Retours ce mois (approximation basée sur géolocalisations)
 		var retours int64
-		geoQuery := db.Table("geolocalisations g").
+		geoQuery := db.Table("geolocalisations g").Scopes(p.ParUnite("m.unite_uuid")).
 			Joins("JOIN migrants m ON g.identite_uuid = m.identite_uuid").
 			Where("g.created_at >= ? AND g.created_at < ?", debutMois, finMois)
 		if province != "" {
@@ -135,7 +138,7 @@ func getEvolutionMensuelle(periode int, province string) []EvolutionTemporelleSt
 
 		// Total cumulé jusqu'à cette date
 		var totalCumule int64
-		cumulQuery := db.Model(&models.Migrant{}).
+		cumulQuery := db.Model(&models.Migrant{}).Scopes(p.Migrants).
 			Where("created_at < ?", finMois)
 		if province != "" {
 			cumulQuery = cumulQuery.Where("ville_actuelle = ?", province)
@@ -155,13 +158,13 @@ func getEvolutionMensuelle(periode int, province string) []EvolutionTemporelleSt
 }
 
 // 🔥 INDICATEURS DES CAUSES DE DÉPLACEMENT
-func getCausesDeplacementsIndicateurs(periode int, province string) CausesDeplacementsIndicateurs {
+func getCausesDeplacementsIndicateurs(p *unites.Perimetre, periode int, province string) CausesDeplacementsIndicateurs {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
 	// Total des motifs de déplacement dans la période
 	var totalMotifs int64
-	motifQuery := db.Table("motif_deplacements md").
+	motifQuery := db.Table("motif_deplacements md").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN migrants m ON md.migrant_uuid = m.uuid").
 		Where("md.created_at >= ?", dateDebut)
 	if province != "" {
@@ -178,7 +181,7 @@ func getCausesDeplacementsIndicateurs(periode int, province string) CausesDeplac
 		Count           int64  `json:"count"`
 	}
 
-	detailQuery := db.Table("motif_deplacements md").
+	detailQuery := db.Table("motif_deplacements md").Scopes(p.ParUnite("m.unite_uuid")).
 		Select("md.type_motif, md.motif_principal, md.motif_secondaire, md.description, COUNT(*) as count").
 		Joins("JOIN migrants m ON md.migrant_uuid = m.uuid").
 		Where("md.created_at >= ?", dateDebut).
@@ -230,12 +233,12 @@ func getCausesDeplacementsIndicateurs(periode int, province string) CausesDeplac
 }
 
 // 👥 INDICATEURS DE VULNÉRABILITÉ ET BESOINS
-func getVulnerabiliteBesoinsIndicateurs(periode int, province string) VulnerabiliteBesoinsIndicateurs {
+func getVulnerabiliteBesoinsIndicateurs(p *unites.Perimetre, periode int, province string) VulnerabiliteBesoinsIndicateurs {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
 	// Profil démographique
-	profilDemo := getProfilDemographique(periode, province)
+	profilDemo := getProfilDemographique(p, periode, province)
 
 	// Accès aux services de base (données simulées car pas dans le modèle actuel)
 	accesServices := AccesServicesStats{
@@ -247,7 +250,7 @@ func getVulnerabiliteBesoinsIndicateurs(periode int, province string) Vulnerabil
 
 	// Taux d'occupation des sites et déplacés hors sites (approximation)
 	var deplacesHorsSites int64
-	horsQuery := db.Table("geolocalisations g").
+	horsQuery := db.Table("geolocalisations g").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN migrants m ON g.identite_uuid = m.identite_uuid").
 		Where("g.created_at >= ?", dateDebut)
 	if province != "" {
@@ -256,7 +259,7 @@ func getVulnerabiliteBesoinsIndicateurs(periode int, province string) Vulnerabil
 	horsQuery.Count(&deplacesHorsSites)
 
 	var totalDansStructures int64
-	structuresQuery := db.Table("geolocalisations g").
+	structuresQuery := db.Table("geolocalisations g").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN migrants m ON g.identite_uuid = m.identite_uuid").
 		Where("g.created_at >= ?", dateDebut)
 	if province != "" {
@@ -274,10 +277,65 @@ func getVulnerabiliteBesoinsIndicateurs(periode int, province string) Vulnerabil
 		AccesServicesBase:   accesServices,
 		TauxOccupationSites: tauxOccupation,
 		DeplacesHorsSites:   deplacesHorsSites,
+		CompositionMenages:  getCompositionMenages(p, periode, province),
 	}
 }
 
-func getProfilDemographique(periode int, province string) ProfilDemographiqueStats {
+// getCompositionMenages - Composition des ménages reconstitués à partir des liens familiaux
+func getCompositionMenages(p *unites.Perimetre, periode int, province string) CompositionMenagesStats {
+	db := database.DB
+	dateDebut := time.Now().AddDate(0, -periode, 0)
+
+	scope := db.Model(&models.Migrant{}).Scopes(p.Migrants).Where("migrants.created_at >= ?", dateDebut)
+	if province != "" {
+		scope = scope.Where("migrants.ville_actuelle = ?", province)
+	}
+
+	menages, err := relations.ComposerMenages(db, scope)
+	if err != nil || len(menages) == 0 {
+		return CompositionMenagesStats{}
+	}
+
+	stats := CompositionMenagesStats{NombreMenages: len(menages)}
+	totalPersonnes := 0
+	vulnerables := 0
+	for _, menage := range menages {
+		totalPersonnes += menage.Taille
+		if menage.Taille == 1 {
+			stats.PersonnesSeules++
+		}
+		if menage.Monoparental {
+			stats.MenagesMonoparentaux++
+		}
+		if menage.ChefFemme {
+			stats.MenagesDirigesParFemme++
+		}
+		if menage.ChefMineur {
+			stats.MenagesDirigesParMineur++
+		}
+		if menage.Aines > 0 {
+			stats.MenagesAvecAines++
+		}
+		if menage.Disperse {
+			stats.MenagesDisperses++
+		}
+		stats.MineursNonAccompagnes += menage.MineursNonAccompagnes
+
+		// Ménage vulnérable : monoparental, dirigé par un mineur, avec mineurs non accompagnés
+		// ou composé uniquement de personnes âgées
+		if menage.Monoparental || menage.ChefMineur || menage.MineursNonAccompagnes > 0 ||
+			(menage.Aines > 0 && menage.Aines == menage.Adultes && menage.Mineurs == 0) {
+			vulnerables++
+		}
+	}
+
+	stats.TailleMoyenne = float64(totalPersonnes) / float64(len(menages))
+	stats.PourcentageMenagesVulnerables = float64(vulnerables) / float64(len(menages)) * 100
+
+	return stats
+}
+
+func getProfilDemographique(p *unites.Perimetre, periode int, province string) ProfilDemographiqueStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
@@ -286,7 +344,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 	var ageTotal float64
 
 	// Requête de base avec les filtres de période et province
-	baseQuery := db.Model(&models.Migrant{}).Where("created_at >= ?", dateDebut)
+	baseQuery := db.Model(&models.Migrant{}).Scopes(p.Migrants).Where("created_at >= ?", dateDebut)
 	if province != "" {
 		baseQuery = baseQuery.Where("ville_actuelle = ?", province)
 	}
@@ -295,7 +353,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 	baseQuery.Count(&totalMigrants)
 
 	// Compter les femmes - Utiliser JOIN avec identites
-	femmeQuery := db.Table("migrants m").
+	femmeQuery := db.Table("migrants m").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
 		Where("m.created_at >= ? AND i.sexe = ?", dateDebut, "F")
 	if province != "" {
@@ -305,7 +363,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 
 	// Compter les hommes - Utiliser JOIN avec identites
 	var hommes int64
-	hommeQuery := db.Table("migrants m").
+	hommeQuery := db.Table("migrants m").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
 		Where("m.created_at >= ? AND i.sexe = ?", dateDebut, "M")
 	if province != "" {
@@ -315,7 +373,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 
 	// Compter les enfants (moins de 18 ans)
 	dateMineure := time.Now().AddDate(-18, 0, 0)
-	enfantQuery := db.Table("migrants m").
+	enfantQuery := db.Table("migrants m").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
 		Where("m.created_at >= ? AND i.date_naissance > ?", dateDebut, dateMineure)
 	if province != "" {
@@ -325,7 +383,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 
 	// Compter les personnes âgées (plus de 65 ans)
 	dateAgee := time.Now().AddDate(-65, 0, 0)
-	ageQuery := db.Table("migrants m").
+	ageQuery := db.Table("migrants m").Scopes(p.ParUnite("m.unite_uuid")).
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
 		Where("m.created_at >= ? AND i.date_naissance < ?", dateDebut, dateAgee)
 	if province != "" {
@@ -335,7 +393,7 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 
 	// Calculer l'âge moyen - Utiliser la requête de base correcte
 	var migrants []models.Migrant
-	migrantsQuery := db.Model(&models.Migrant{}).Where("created_at >= ?", dateDebut)
+	migrantsQuery := db.Model(&models.Migrant{}).Scopes(p.Migrants).Where("created_at >= ?", dateDebut)
 	if province != "" {
 		migrantsQuery = migrantsQuery.Where("ville_actuelle = ?", province)
 	}
@@ -371,22 +429,22 @@ func getProfilDemographique(periode int, province string) ProfilDemographiqueSta
 		AgeMoyen:           ageTotal,
 	}
 } // ⚠️ INDICATEURS DYNAMIQUES ET D'ALERTE
-func getDynamiquesAlerteIndicateurs(periode int, province string) DynamiquesAlerteIndicateurs {
+func getDynamiquesAlerteIndicateurs(p *unites.Perimetre, periode int, province string) DynamiquesAlerteIndicateurs {
 	db := database.DB
 
 	// Zones à haut risque
-	zonesRisque := getZonesHautRisque(periode, province)
+	zonesRisque := getZonesHautRisque(p, periode, province)
 
 	// Tendances de retour
-	tendancesRetour := getTendancesRetour(periode, province)
+	tendancesRetour := getTendancesRetour(p, periode, province)
 
 	// Alertes précoces
-	alertesPrecoces := getAlertesPrecoces(periode, province)
+	alertesPrecoces := getAlertesPrecoces(p, periode, province)
 
 	// Mouvements massifs récents (30 derniers jours)
 	var mouvementsMassifs int64
 	date30Jours := time.Now().AddDate(0, 0, -30)
-	massifQuery := db.Model(&models.Migrant{}).
+	massifQuery := db.Model(&models.Migrant{}).Scopes(p.Migrants).
 		Where("created_at >= ?", date30Jours)
 	if province != "" {
 		massifQuery = massifQuery.Where("ville_actuelle = ?", province)
@@ -401,7 +459,7 @@ func getDynamiquesAlerteIndicateurs(periode int, province string) DynamiquesAler
 	}
 }
 
-func getZonesHautRisque(periode int, province string) []ZoneRisqueStats {
+func getZonesHautRisque(p *unites.Perimetre, periode int, province string) []ZoneRisqueStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
@@ -410,7 +468,7 @@ func getZonesHautRisque(periode int, province string) []ZoneRisqueStats {
 		Count int64  `json:"count"`
 	}
 
-	query := db.Table("alertes a").
+	query := db.Table("alertes a").Scopes(p.ParUnite("m.unite_uuid")).
 		Select("m.ville_actuelle as zone, COUNT(*) as count").
 		Joins("JOIN migrants m ON a.migrant_uuid = m.uuid").
 		Where("a.niveau_gravite IN (?) AND a.created_at >= ? AND a.statut = ?",
@@ -446,7 +504,7 @@ func getZonesHautRisque(periode int, province string) []ZoneRisqueStats {
 	return zones
 }
 
-func getTendancesRetour(periode int, province string) []TendanceRetourStats {
+func getTendancesRetour(p *unites.Perimetre, periode int, province string) []TendanceRetourStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
@@ -456,7 +514,7 @@ func getTendancesRetour(periode int, province string) []TendanceRetourStats {
 		Count       int64  `json:"count"`
 	}
 
-	query := db.Table("geolocalisations g").
+	query := db.Table("geolocalisations g").Scopes(p.ParUnite("m.unite_uuid")).
 		Select("i.lieu_naissance as zone_origine, m.ville_actuelle as zone_retour, COUNT(*) as count").
 		Joins("JOIN migrants m ON g.identite_uuid = m.identite_uuid").
 		Joins("JOIN identites i ON m.identite_uuid = i.uuid").
@@ -491,12 +549,12 @@ func getTendancesRetour(periode int, province string) []TendanceRetourStats {
 	return tendances
 }
 
-func getAlertesPrecoces(periode int, province string) []AlertePrecoceStats {
+func getAlertesPrecoces(p *unites.Perimetre, periode int, province string) []AlertePrecoceStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
 	var alertes []models.Alert
-	query := db.Where("created_at >= ? AND statut = ?", dateDebut, "active").
+	query := db.Scopes(p.ParMigrant("alertes.migrant_uuid")).Where("created_at >= ? AND statut = ?", dateDebut, "active").
 		Order("created_at DESC").
 		Limit(20).
 		Preload("Migrant")
@@ -523,7 +581,7 @@ func getAlertesPrecoces(periode int, province string) []AlertePrecoceStats {
 }
 
 // Fonction pour récupérer les données du pie chart des motifs de déplacement
-func getMotifsPieChartData(periode int, province string) []ChartDataPoint {
+func getMotifsPieChartData(p *unites.Perimetre, periode int, province string) []ChartDataPoint {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, -periode, 0)
 
@@ -533,7 +591,7 @@ func getMotifsPieChartData(periode int, province string) []ChartDataPoint {
 		Count     int64  `json:"count"`
 	}
 
-	query := db.Table("motif_deplacements md").
+	query := db.Table("motif_deplacements md").Scopes(p.ParUnite("m.unite_uuid")).
 		Select("md.type_motif, COUNT(*) as count").
 		Joins("JOIN migrants m ON md.migrant_uuid = m.uuid").
 		Where("md.created_at >= ?", dateDebut).
@@ -578,7 +636,7 @@ func getMotifsPieChartData(periode int, province string) []ChartDataPoint {
 }
 
 // Fonction pour récupérer les alertes récentes (utilisée par GetAlertesTempsReel)
-func getAlertesRecentes(niveaux, province string, jours int) []AlertePrecoceStats {
+func getAlertesRecentes(p *unites.Perimetre, niveaux, province string, jours int) []AlertePrecoceStats {
 	db := database.DB
 	dateDebut := time.Now().AddDate(0, 0, -jours)
 
@@ -597,7 +655,7 @@ func getAlertesRecentes(niveaux, province string, jours int) []AlertePrecoceStat
 	}
 
 	var alertes []models.Alert
-	query := db.Where("created_at >= ? AND statut = ? AND niveau_gravite IN (?)",
+	query := db.Scopes(p.ParMigrant("alertes.migrant_uuid")).Where("created_at >= ? AND statut = ? AND niveau_gravite IN (?)",
 		dateDebut, "active", niveauxList).
 		Order("created_at DESC").
 		Preload("Migrant")
@@ -622,3 +680,62 @@ func getAlertesRecentes(niveaux, province string, jours int) []AlertePrecoceStat
 
 	return alertesStats
 }
+
+// 🔁 TRANSITIONS DE STATUT MIGRATOIRE
+func getTransitionsStatut(p *unites.Perimetre, periode int, province string) ([]EvolutionTransitionsStats, []TransitionStatutStats, int64) {
+	db := database.DB
+	dateDebut := time.Now().AddDate(0, -periode, 0)
+
+	var rows []struct {
+		Mois   string
+		De     string
+		Vers   string
+		Nombre int64
+	}
+	query := db.Table("migrant_status_history h").Scopes(p.ParUnite("m.unite_uuid")).
+		Select("TO_CHAR(h.date_effet, 'YYYY-MM') as mois, h.statut_precedent as de, h.statut_migratoire as vers, COUNT(*) as nombre").
+		Joins("JOIN migrants m ON h.migrant_uuid = m.uuid").
+		Where("h.deleted_at IS NULL AND m.deleted_at IS NULL").
+		Where("h.statut_precedent <> '' AND h.statut_precedent <> h.statut_migratoire").
+		Where("h.date_effet >= ?", dateDebut)
+	if province != "" {
+		query = query.Where("m.ville_actuelle = ?", province)
+	}
+	query.Group("mois, de, vers").Order("mois ASC, nombre DESC").Scan(&rows)
+
+	// Un point par mois, y compris les mois sans transition
+	parMois := map[string]*EvolutionTransitionsStats{}
+	var evolution []EvolutionTransitionsStats
+	for i := periode - 1; i >= 0; i-- {
+		mois := time.Now().AddDate(0, -i, 0).Format("2006-01")
+		evolution = append(evolution, EvolutionTransitionsStats{Periode: mois, Transitions: []TransitionStatutStats{}})
+	}
+	for i := range evolution {
+		parMois[evolution[i].Periode] = &evolution[i]
+	}
+
+	sommes := map[string]int64{}
+	var totaux []TransitionStatutStats
+	var total int64
+	for _, row := range rows {
+		if point, ok := parMois[row.Mois]; ok {
+			point.Transitions = append(point.Transitions, TransitionStatutStats{De: row.De, Vers: row.Vers, Nombre: row.Nombre})
+			point.Total += row.Nombre
+		}
+
+		cle := row.De + ">" + row.Vers
+		if _, ok := sommes[cle]; !ok {
+			totaux = append(totaux, TransitionStatutStats{De: row.De, Vers: row.Vers})
+		}
+		sommes[cle] += row.Nombre
+		total += row.Nombre
+	}
+	for i := range totaux {
+		totaux[i].Nombre = sommes[totaux[i].De+">"+totaux[i].Vers]
+	}
+	sort.Slice(totaux, func(i, j int) bool {
+		return totaux[i].Nombre > totaux[j].Nombre
+	})
+
+	return evolution, totaux, total
+}
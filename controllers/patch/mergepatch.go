@@ -0,0 +1,181 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// Type de contenu des correctifs JSON Merge Patch (RFC 7396)
+const TypeMergePatch = "application/merge-patch+json"
+
+// Regles liste, par nom JSON, les champs d'une entité modifiables par PATCH et ceux
+// dont la modification est refusée explicitement
+type Regles struct {
+	Modifiables []string
+	Immuables   []string
+}
+
+// Appliquer applique un correctif JSON Merge Patch à une copie de l'entité.
+// Un champ absent est conservé, un champ à null est remis à sa valeur nulle.
+// Retourne l'entité corrigée, les champs Go à écrire (à passer à Select pour que
+// les valeurs nulles soient enregistrées) et les erreurs à renvoyer au client.
+func Appliquer[T any](entite T, corps []byte, regles Regles) (T, []string, []string) {
+	var corrige T
+
+	var correctif map[string]interface{}
+	decodeur := json.NewDecoder(bytes.NewReader(corps))
+	decodeur.UseNumber()
+	if err := decodeur.Decode(&correctif); err != nil || correctif == nil {
+		return corrige, nil, []string{"Le correctif doit être un objet JSON (RFC 7396)"}
+	}
+
+	champs := champsJSON(reflect.TypeOf(entite))
+	modifiables := ensemble(regles.Modifiables)
+	immuables := ensemble(regles.Immuables)
+
+	var erreurs []string
+	var colonnes []string
+	for _, cle := range cles(correctif) {
+		switch {
+		case immuables[cle]:
+			erreurs = append(erreurs, fmt.Sprintf("Le champ %s ne peut pas être modifié", cle))
+		case !modifiables[cle] || champs[cle] == "":
+			erreurs = append(erreurs, fmt.Sprintf("Champ inconnu ou non modifiable : %s", cle))
+		default:
+			colonnes = append(colonnes, champs[cle])
+		}
+	}
+	if len(erreurs) > 0 {
+		return corrige, nil, erreurs
+	}
+
+	// Fusion sur la représentation JSON de l'entité, puis relecture dans une nouvelle valeur
+	brut, err := json.Marshal(entite)
+	if err != nil {
+		return corrige, nil, []string{err.Error()}
+	}
+	var document interface{}
+	decodeur = json.NewDecoder(bytes.NewReader(brut))
+	decodeur.UseNumber()
+	if err := decodeur.Decode(&document); err != nil {
+		return corrige, nil, []string{err.Error()}
+	}
+	fusionne, _ := json.Marshal(fusionner(document, correctif))
+	if err := json.Unmarshal(fusionne, &corrige); err != nil {
+		return corrige, nil, []string{"Valeur invalide : " + err.Error()}
+	}
+
+	// Seuls les champs corrigés sont validés : un enregistrement ancien peut ne pas
+	// respecter toutes les règles actuelles
+	nomType := reflect.TypeOf(entite).Name()
+	corriges := ensemble(colonnes)
+	for _, err := range utils.ValidateStruct(corrige) {
+		champ := strings.TrimPrefix(err.FailedField, nomType+".")
+		if !corriges[champ] {
+			continue
+		}
+		switch err.Tag {
+		case "required":
+			erreurs = append(erreurs, fmt.Sprintf("%s : valeur requise", nomJSON(champs, champ)))
+		case "oneof":
+			erreurs = append(erreurs, fmt.Sprintf("%s : valeurs acceptées %s", nomJSON(champs, champ), strings.ReplaceAll(err.Value, " ", ", ")))
+		default:
+			erreurs = append(erreurs, fmt.Sprintf("%s : règle de validation %q non respectée", nomJSON(champs, champ), err.Tag))
+		}
+	}
+
+	return corrige, colonnes, erreurs
+}
+
+// Lire retourne le corps d'une requête PATCH ; les types application/json et
+// application/merge-patch+json sont acceptés
+func Lire(c *fiber.Ctx) ([]byte, bool) {
+	typeContenu := strings.ToLower(c.Get(fiber.HeaderContentType))
+	if typeContenu != "" && !strings.HasPrefix(typeContenu, TypeMergePatch) && !strings.HasPrefix(typeContenu, fiber.MIMEApplicationJSON) {
+		return nil, false
+	}
+	return c.Body(), true
+}
+
+// Invalide - réponse 400 d'un correctif refusé
+func Invalide(c *fiber.Ctx, erreurs []string) error {
+	return problemes.Envoyer(c, problemes.Messages(problemes.CodeValidation, erreurs))
+}
+
+// TypeNonSupporte - réponse 415 d'un corps qui n'est pas un correctif JSON
+func TypeNonSupporte(c *fiber.Ctx) error {
+	return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusUnsupportedMediaType, problemes.CodeTypeNonSupporte,
+		"Content-Type must be "+TypeMergePatch))
+}
+
+// fusionner applique récursivement le correctif au document (RFC 7396, section 2)
+func fusionner(document, correctif interface{}) interface{} {
+	champsCorrectif, ok := correctif.(map[string]interface{})
+	if !ok {
+		return correctif
+	}
+	champsDocument, ok := document.(map[string]interface{})
+	if !ok {
+		champsDocument = map[string]interface{}{}
+	}
+	for cle, valeur := range champsCorrectif {
+		if valeur == nil {
+			delete(champsDocument, cle)
+		} else {
+			champsDocument[cle] = fusionner(champsDocument[cle], valeur)
+		}
+	}
+	return champsDocument
+}
+
+// champsJSON associe le nom JSON de chaque champ de premier niveau à son nom Go
+func champsJSON(t reflect.Type) map[string]string {
+	champs := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		nom := strings.Split(f.Tag.Get("json"), ",")[0]
+		if nom == "-" || !f.IsExported() {
+			continue
+		}
+		if nom == "" {
+			nom = f.Name
+		}
+		champs[nom] = f.Name
+	}
+	return champs
+}
+
+func nomJSON(champs map[string]string, champGo string) string {
+	for nom, champ := range champs {
+		if champ == champGo {
+			return nom
+		}
+	}
+	return champGo
+}
+
+func ensemble(valeurs []string) map[string]bool {
+	e := make(map[string]bool, len(valeurs))
+	for _, v := range valeurs {
+		e[v] = true
+	}
+	return e
+}
+
+// cles retourne les clés du correctif dans un ordre stable, pour des messages reproductibles
+func cles(m map[string]interface{}) []string {
+	liste := make([]string, 0, len(m))
+	for cle := range m {
+		liste = append(liste, cle)
+	}
+	sort.Strings(liste)
+	return liste
+}
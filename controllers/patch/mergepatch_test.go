@@ -0,0 +1,122 @@
+package patch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fiche reprend les cas rencontrés dans les modèles : champ requis, liste de valeurs,
+// nombre, date facultative et champ jamais exposé
+type fiche struct {
+	UUID          string     `json:"uuid"`
+	Nom           string     `json:"nom" validate:"required"`
+	Telephone     string     `json:"telephone"`
+	Situation     string     `json:"situation" validate:"oneof=celibataire marie"`
+	NombreEnfants int        `json:"nombre_enfants"`
+	DateEntree    *time.Time `json:"date_entree"`
+	Note          string     `json:"note"`
+	Secret        string     `json:"-"`
+}
+
+var reglesFiche = Regles{
+	Modifiables: []string{"nom", "telephone", "situation", "nombre_enfants", "date_entree"},
+	Immuables:   []string{"uuid"},
+}
+
+func TestAppliquer(t *testing.T) {
+	entree := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// Situation vide : un enregistrement ancien qui ne respecte plus la règle oneof
+	origine := fiche{
+		UUID: "f1", Nom: "Mukendi", Telephone: "0810000000", NombreEnfants: 3,
+		DateEntree: &entree, Note: "conservée", Secret: "jamais exposé",
+	}
+
+	cas := []struct {
+		nom      string
+		corps    string
+		attendu  func(f *fiche)
+		colonnes []string
+		erreurs  []string
+	}{
+		{
+			nom:      "champ absent conservé",
+			corps:    `{"telephone":"0990000000"}`,
+			attendu:  func(f *fiche) { f.Telephone = "0990000000" },
+			colonnes: []string{"Telephone"},
+		},
+		{
+			nom:      "null efface le champ",
+			corps:    `{"telephone":null,"date_entree":null}`,
+			attendu:  func(f *fiche) { f.Telephone = ""; f.DateEntree = nil },
+			colonnes: []string{"DateEntree", "Telephone"},
+		},
+		{
+			nom:      "valeur nulle écrite",
+			corps:    `{"nombre_enfants":0}`,
+			attendu:  func(f *fiche) { f.NombreEnfants = 0 },
+			colonnes: []string{"NombreEnfants"},
+		},
+		{
+			nom:     "champ immuable refusé",
+			corps:   `{"uuid":"f2","telephone":"0990000000"}`,
+			erreurs: []string{"Le champ uuid ne peut pas être modifié"},
+		},
+		{
+			nom:     "champ inconnu ou hors liste refusé",
+			corps:   `{"inconnu":1,"note":"remplacée"}`,
+			erreurs: []string{"Champ inconnu ou non modifiable : inconnu", "Champ inconnu ou non modifiable : note"},
+		},
+		{
+			nom:     "champ masqué refusé",
+			corps:   `{"Secret":"x"}`,
+			erreurs: []string{"Champ inconnu ou non modifiable : Secret"},
+		},
+		{
+			nom:     "champ requis effacé",
+			corps:   `{"nom":null}`,
+			erreurs: []string{"nom : valeur requise"},
+		},
+		{
+			nom:     "champ corrigé validé",
+			corps:   `{"situation":"divorce"}`,
+			erreurs: []string{"situation : valeurs acceptées celibataire, marie"},
+		},
+		{
+			nom:     "type incompatible",
+			corps:   `{"nombre_enfants":"trois"}`,
+			erreurs: []string{"Valeur invalide : json: cannot unmarshal string into Go struct field fiche.nombre_enfants of type int"},
+		},
+		{
+			nom:     "corps qui n'est pas un objet",
+			corps:   `["telephone"]`,
+			erreurs: []string{"Le correctif doit être un objet JSON (RFC 7396)"},
+		},
+	}
+
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			corrige, colonnes, erreurs := Appliquer(origine, []byte(tc.corps), reglesFiche)
+			if !reflect.DeepEqual(erreurs, tc.erreurs) {
+				t.Fatalf("erreurs %q, attendu %q", erreurs, tc.erreurs)
+			}
+			if tc.erreurs != nil {
+				return
+			}
+			if !reflect.DeepEqual(colonnes, tc.colonnes) {
+				t.Errorf("colonnes %v, attendu %v", colonnes, tc.colonnes)
+			}
+
+			attendu := origine
+			attendu.Secret = ""
+			tc.attendu(&attendu)
+			if !reflect.DeepEqual(corrige, attendu) {
+				t.Errorf("entité corrigée %+v, attendu %+v", corrige, attendu)
+			}
+		})
+	}
+
+	if origine.Telephone != "0810000000" || origine.NombreEnfants != 3 {
+		t.Errorf("l'entité d'origine a été modifiée : %+v", origine)
+	}
+}
@@ -0,0 +1,613 @@
+package relations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+const (
+	ageMajorite = 18
+	ageAine     = 65
+
+	// Écart d'âge minimal entre un parent et son enfant
+	ecartAgeMinParent = 12
+
+	// Nombre maximal de personnes parcourues pour reconstituer un ménage
+	tailleMaxMenage = 200
+)
+
+// personne contient les champs utiles au calcul des ménages et des contrôles
+type personne struct {
+	UUID                  string
+	Sexe                  string
+	DateNaissance         time.Time
+	SituationMatrimoniale string
+	NombreEnfants         int
+	VilleActuelle         string
+}
+
+// age retourne l'âge en années, ou -1 si la date de naissance est inconnue
+func (p personne) age(ref time.Time) int {
+	if p.DateNaissance.IsZero() {
+		return -1
+	}
+	age := ref.Year() - p.DateNaissance.Year()
+	if ref.YearDay() < p.DateNaissance.YearDay() {
+		age--
+	}
+	return age
+}
+
+func (p personne) mineur(ref time.Time) bool {
+	a := p.age(ref)
+	return a >= 0 && a < ageMajorite
+}
+
+// Incoherence décrit une anomalie dans les liens familiaux.
+// Les incohérences bloquantes empêchent l'enregistrement d'une nouvelle relation.
+type Incoherence struct {
+	Regle      string   `json:"regle"`
+	Message    string   `json:"message"`
+	Migrants   []string `json:"migrants"`
+	Bloquante  bool     `json:"bloquante"`
+	cleUnicite string
+	// Nombre de liens en cause (parents, tuteurs, doublons...), 1 pour une règle sans décompte
+	ampleur int
+}
+
+// Menage regroupe les migrants reliés entre eux par des liens familiaux
+type Menage struct {
+	ChefUUID              string   `json:"chef_uuid"`
+	MembresUUID           []string `json:"membres_uuid"`
+	Taille                int      `json:"taille"`
+	Adultes               int      `json:"adultes"`
+	Mineurs               int      `json:"mineurs"`
+	Aines                 int      `json:"aines"`
+	ChefFemme             bool     `json:"chef_femme"`
+	ChefMineur            bool     `json:"chef_mineur"`
+	Monoparental          bool     `json:"monoparental"`
+	MineursNonAccompagnes int      `json:"mineurs_non_accompagnes"`
+	Disperse              bool     `json:"disperse"` // Membres dans des villes différentes
+}
+
+// normaliserRelation enregistre une relation "enfant" sous sa forme "parent"
+func normaliserRelation(r *models.RelationFamiliale) {
+	if r.TypeRelation == "enfant" {
+		r.MigrantUUID, r.ApparenteUUID = r.ApparenteUUID, r.MigrantUUID
+		r.TypeRelation = "parent"
+	}
+}
+
+func relationSymetrique(typeRelation string) bool {
+	return typeRelation == "conjoint" || typeRelation == "frere_soeur"
+}
+
+// clePaire identifie une paire non orientée
+func clePaire(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// chargerPersonnes récupère le profil des migrants demandés
+func chargerPersonnes(db *gorm.DB, uuids []string) (map[string]personne, error) {
+	personnes := map[string]personne{}
+	if len(uuids) == 0 {
+		return personnes, nil
+	}
+
+	// Par lots pour rester sous la limite de paramètres de Postgres
+	for debut := 0; debut < len(uuids); debut += 1000 {
+		fin := debut + 1000
+		if fin > len(uuids) {
+			fin = len(uuids)
+		}
+
+		var rows []personne
+		err := db.Table("migrants m").
+			Select("m.uuid, i.sexe, i.date_naissance, m.situation_matrimoniale, m.nombre_enfants, m.ville_actuelle").
+			Joins("JOIN identites i ON m.identite_uuid = i.uuid").
+			Where("m.uuid IN ? AND m.deleted_at IS NULL", uuids[debut:fin]).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			personnes[row.UUID] = row
+		}
+	}
+	return personnes, nil
+}
+
+// chargerComposante parcourt les relations à partir d'un migrant et retourne
+// toutes les relations de sa composante familiale
+func chargerComposante(db *gorm.DB, migrantUUID string) ([]models.RelationFamiliale, []string, error) {
+	visites := map[string]bool{migrantUUID: true}
+	frontiere := []string{migrantUUID}
+	connues := map[string]bool{}
+	var relations []models.RelationFamiliale
+
+	for len(frontiere) > 0 && len(visites) < tailleMaxMenage {
+		var lot []models.RelationFamiliale
+		err := db.Where("migrant_uuid IN ? OR apparente_uuid IN ?", frontiere, frontiere).
+			Find(&lot).Error
+		if err != nil {
+			return nil, nil, err
+		}
+
+		frontiere = nil
+		for _, r := range lot {
+			if connues[r.UUID] {
+				continue
+			}
+			connues[r.UUID] = true
+			relations = append(relations, r)
+			for _, uuid := range []string{r.MigrantUUID, r.ApparenteUUID} {
+				if !visites[uuid] {
+					visites[uuid] = true
+					frontiere = append(frontiere, uuid)
+				}
+			}
+		}
+	}
+
+	membres := make([]string, 0, len(visites))
+	for uuid := range visites {
+		membres = append(membres, uuid)
+	}
+	sort.Strings(membres)
+	return relations, membres, nil
+}
+
+// DetecterIncoherences applique les contrôles de cohérence sur un ensemble de relations
+func DetecterIncoherences(relations []models.RelationFamiliale, personnes map[string]personne) []Incoherence {
+	ref := time.Now()
+	incoherences := []Incoherence{}
+	ajouter := func(i Incoherence) {
+		for _, existante := range incoherences {
+			if existante.cleUnicite == i.cleUnicite {
+				return
+			}
+		}
+		if i.ampleur == 0 {
+			i.ampleur = 1
+		}
+		incoherences = append(incoherences, i)
+	}
+
+	parents := map[string][]string{}
+	tuteurs := map[string][]string{}
+	chefs := map[string][]string{}
+	typesParPaire := map[string]map[string]int{}
+	enfantsDeclares := map[string]int{}
+
+	for _, r := range relations {
+		if r.MigrantUUID == r.ApparenteUUID {
+			ajouter(Incoherence{
+				Regle:      "relation_reflexive",
+				Message:    "A migrant cannot be related to themselves",
+				Migrants:   []string{r.MigrantUUID},
+				Bloquante:  true,
+				cleUnicite: "reflexive:" + r.UUID,
+			})
+			continue
+		}
+
+		paire := clePaire(r.MigrantUUID, r.ApparenteUUID)
+		if typesParPaire[paire] == nil {
+			typesParPaire[paire] = map[string]int{}
+		}
+		typeCle := r.TypeRelation
+		if !relationSymetrique(r.TypeRelation) {
+			typeCle = r.TypeRelation + ":" + r.MigrantUUID
+		}
+		typesParPaire[paire][typeCle]++
+
+		switch r.TypeRelation {
+		case "parent":
+			parents[r.MigrantUUID] = append(parents[r.MigrantUUID], r.ApparenteUUID)
+			enfantsDeclares[r.ApparenteUUID]++
+
+			enfant, okEnfant := personnes[r.MigrantUUID]
+			parent, okParent := personnes[r.ApparenteUUID]
+			if okEnfant && okParent && !enfant.DateNaissance.IsZero() && !parent.DateNaissance.IsZero() &&
+				parent.DateNaissance.AddDate(ecartAgeMinParent, 0, 0).After(enfant.DateNaissance) {
+				ajouter(Incoherence{
+					Regle:      "ecart_age_parent",
+					Message:    fmt.Sprintf("A parent must be at least %d years older than their child", ecartAgeMinParent),
+					Migrants:   []string{r.ApparenteUUID, r.MigrantUUID},
+					Bloquante:  true,
+					cleUnicite: "age:" + paire,
+				})
+			}
+		case "tuteur":
+			tuteurs[r.MigrantUUID] = append(tuteurs[r.MigrantUUID], r.ApparenteUUID)
+		case "chef_menage":
+			chefs[r.MigrantUUID] = append(chefs[r.MigrantUUID], r.ApparenteUUID)
+		case "conjoint":
+			for _, uuid := range []string{r.MigrantUUID, r.ApparenteUUID} {
+				if p, ok := personnes[uuid]; ok && p.SituationMatrimoniale == "celibataire" {
+					ajouter(Incoherence{
+						Regle:      "conjoint_celibataire",
+						Message:    "A migrant with a spouse is recorded as single",
+						Migrants:   []string{uuid},
+						cleUnicite: "celibataire:" + uuid,
+					})
+				}
+				if p, ok := personnes[uuid]; ok && p.mineur(ref) {
+					ajouter(Incoherence{
+						Regle:      "conjoint_mineur",
+						Message:    "A minor is recorded as a spouse",
+						Migrants:   []string{uuid},
+						cleUnicite: "conjoint_mineur:" + uuid,
+					})
+				}
+			}
+		}
+	}
+
+	for paire, types := range typesParPaire {
+		for typeCle, n := range types {
+			if n > 1 {
+				ajouter(Incoherence{
+					Regle:      "relation_dupliquee",
+					Message:    "The same relation is recorded more than once",
+					Migrants:   splitPaire(paire),
+					Bloquante:  true,
+					cleUnicite: "doublon:" + paire + ":" + typeCle,
+					ampleur:    n,
+				})
+			}
+		}
+
+		conjoint := types["conjoint"] > 0
+		fratrie := types["frere_soeur"] > 0
+		filiation := false
+		sens := map[string]bool{}
+		for typeCle := range types {
+			if len(typeCle) > 7 && typeCle[:7] == "parent:" {
+				filiation = true
+				sens[typeCle[7:]] = true
+			}
+		}
+		if len(sens) > 1 {
+			ajouter(Incoherence{
+				Regle:      "filiation_circulaire",
+				Message:    "Two migrants cannot be each other's parent",
+				Migrants:   splitPaire(paire),
+				Bloquante:  true,
+				cleUnicite: "circulaire:" + paire,
+			})
+		}
+		if (conjoint && (filiation || fratrie)) || (filiation && fratrie) {
+			ajouter(Incoherence{
+				Regle:      "liens_incompatibles",
+				Message:    "Spouse, parent and sibling links are mutually exclusive for the same pair",
+				Migrants:   splitPaire(paire),
+				Bloquante:  true,
+				cleUnicite: "incompatibles:" + paire,
+			})
+		}
+	}
+
+	for enfant, liste := range parents {
+		if len(liste) > 2 {
+			ajouter(Incoherence{
+				Regle:      "trop_de_parents",
+				Message:    fmt.Sprintf("A migrant cannot have more than two parents (%d recorded)", len(liste)),
+				Migrants:   append([]string{enfant}, liste...),
+				Bloquante:  true,
+				cleUnicite: "parents:" + enfant,
+				ampleur:    len(liste),
+			})
+		}
+	}
+	for pupille, liste := range tuteurs {
+		if len(liste) > 1 {
+			ajouter(Incoherence{
+				Regle:      "trop_de_tuteurs",
+				Message:    "A migrant cannot have more than one legal guardian",
+				Migrants:   append([]string{pupille}, liste...),
+				Bloquante:  true,
+				cleUnicite: "tuteurs:" + pupille,
+				ampleur:    len(liste),
+			})
+		}
+	}
+	for membre, liste := range chefs {
+		if len(liste) > 1 {
+			ajouter(Incoherence{
+				Regle:      "plusieurs_chefs_menage",
+				Message:    "A migrant cannot belong to more than one household head",
+				Migrants:   append([]string{membre}, liste...),
+				Bloquante:  true,
+				cleUnicite: "chefs:" + membre,
+				ampleur:    len(liste),
+			})
+		}
+	}
+	for parent, n := range enfantsDeclares {
+		if p, ok := personnes[parent]; ok && p.NombreEnfants < n {
+			ajouter(Incoherence{
+				Regle:      "nombre_enfants",
+				Message:    fmt.Sprintf("nombre_enfants is %d but %d children are linked", p.NombreEnfants, n),
+				Migrants:   []string{parent},
+				cleUnicite: "nombre_enfants:" + parent,
+				ampleur:    n,
+			})
+		}
+	}
+
+	sort.SliceStable(incoherences, func(i, j int) bool {
+		return incoherences[i].Bloquante && !incoherences[j].Bloquante
+	})
+	return incoherences
+}
+
+// nouvellesIncoherences retourne les incohérences bloquantes qu'introduirait la relation :
+// celles absentes des relations existantes et celles dont le nombre de liens en cause
+// augmente (un quatrième parent aggrave l'incohérence des trois déjà enregistrés).
+func nouvellesIncoherences(existantes []models.RelationFamiliale, relation models.RelationFamiliale, personnes map[string]personne) []Incoherence {
+	avant := map[string]int{}
+	for _, i := range DetecterIncoherences(existantes, personnes) {
+		avant[i.cleUnicite] = i.ampleur
+	}
+
+	apres := append(append([]models.RelationFamiliale{}, existantes...), relation)
+	var nouvelles []Incoherence
+	for _, i := range DetecterIncoherences(apres, personnes) {
+		if ampleur, connue := avant[i.cleUnicite]; i.Bloquante && (!connue || i.ampleur > ampleur) {
+			nouvelles = append(nouvelles, i)
+		}
+	}
+	return nouvelles
+}
+
+func splitPaire(paire string) []string {
+	for i := 0; i < len(paire); i++ {
+		if paire[i] == '|' {
+			return []string{paire[:i], paire[i+1:]}
+		}
+	}
+	return []string{paire}
+}
+
+// RegrouperMenages reconstitue les ménages à partir des relations.
+// Les migrants de "isoles" sans aucune relation forment un ménage d'une personne.
+func RegrouperMenages(relations []models.RelationFamiliale, personnes map[string]personne, isoles []string) []Menage {
+	ref := time.Now()
+
+	// Union-find sur les migrants reliés
+	racine := map[string]string{}
+	var trouver func(string) string
+	trouver = func(x string) string {
+		if _, ok := racine[x]; !ok {
+			racine[x] = x
+		}
+		if racine[x] != x {
+			racine[x] = trouver(racine[x])
+		}
+		return racine[x]
+	}
+	for _, uuid := range isoles {
+		trouver(uuid)
+	}
+	for _, r := range relations {
+		ra, rb := trouver(r.MigrantUUID), trouver(r.ApparenteUUID)
+		if ra != rb {
+			racine[ra] = rb
+		}
+	}
+
+	composantes := map[string][]string{}
+	for uuid := range racine {
+		composantes[trouver(uuid)] = append(composantes[trouver(uuid)], uuid)
+	}
+
+	// Index des relations par composante
+	relationsParComposante := map[string][]models.RelationFamiliale{}
+	for _, r := range relations {
+		c := trouver(r.MigrantUUID)
+		relationsParComposante[c] = append(relationsParComposante[c], r)
+	}
+
+	menages := make([]Menage, 0, len(composantes))
+	for c, membres := range composantes {
+		sort.Strings(membres)
+		menages = append(menages, composerMenage(membres, relationsParComposante[c], personnes, ref))
+	}
+
+	sort.Slice(menages, func(i, j int) bool {
+		if menages[i].Taille != menages[j].Taille {
+			return menages[i].Taille > menages[j].Taille
+		}
+		return menages[i].ChefUUID < menages[j].ChefUUID
+	})
+	return menages
+}
+
+// composerMenage calcule la composition d'un ménage
+func composerMenage(membres []string, relations []models.RelationFamiliale, personnes map[string]personne, ref time.Time) Menage {
+	menage := Menage{MembresUUID: membres, Taille: len(membres)}
+
+	adultes := map[string]bool{}
+	villes := map[string]bool{}
+	for _, uuid := range membres {
+		p := personnes[uuid]
+		age := p.age(ref)
+		switch {
+		case age >= 0 && age < ageMajorite:
+			menage.Mineurs++
+		case age >= ageAine:
+			menage.Aines++
+			menage.Adultes++
+			adultes[uuid] = true
+		default:
+			// Âge inconnu : considéré comme adulte
+			menage.Adultes++
+			adultes[uuid] = true
+		}
+		if p.VilleActuelle != "" {
+			villes[p.VilleActuelle] = true
+		}
+	}
+	menage.Disperse = len(villes) > 1
+
+	menage.ChefUUID = designerChef(membres, relations, personnes, adultes)
+	if chef, ok := personnes[menage.ChefUUID]; ok {
+		menage.ChefFemme = chef.Sexe == "F"
+		menage.ChefMineur = chef.mineur(ref)
+	}
+
+	// Mineurs accompagnés par un parent ou un tuteur adulte du ménage
+	accompagnes := map[string]bool{}
+	parentsDeMineurs := map[string]bool{}
+	conjoints := map[string]bool{}
+	for _, r := range relations {
+		switch r.TypeRelation {
+		case "parent", "tuteur":
+			if personnes[r.MigrantUUID].mineur(ref) && adultes[r.ApparenteUUID] {
+				accompagnes[r.MigrantUUID] = true
+				if r.TypeRelation == "parent" {
+					parentsDeMineurs[r.ApparenteUUID] = true
+				}
+			}
+		case "conjoint":
+			conjoints[r.MigrantUUID] = true
+			conjoints[r.ApparenteUUID] = true
+		}
+	}
+	for _, uuid := range membres {
+		if personnes[uuid].mineur(ref) && !accompagnes[uuid] {
+			menage.MineursNonAccompagnes++
+		}
+	}
+
+	// Un seul parent présent, sans conjoint enregistré
+	if len(parentsDeMineurs) == 1 {
+		for parent := range parentsDeMineurs {
+			menage.Monoparental = !conjoints[parent]
+		}
+	}
+
+	return menage
+}
+
+// designerChef retient le chef désigné par les relations chef_menage,
+// à défaut l'adulte le plus âgé
+func designerChef(membres []string, relations []models.RelationFamiliale, personnes map[string]personne, adultes map[string]bool) string {
+	designations := map[string]int{}
+	for _, r := range relations {
+		if r.TypeRelation == "chef_menage" {
+			designations[r.ApparenteUUID]++
+		}
+	}
+
+	chef := ""
+	for _, uuid := range membres {
+		if chef == "" {
+			chef = uuid
+			continue
+		}
+		if designations[uuid] != designations[chef] {
+			if designations[uuid] > designations[chef] {
+				chef = uuid
+			}
+			continue
+		}
+		if adultes[uuid] != adultes[chef] {
+			if adultes[uuid] {
+				chef = uuid
+			}
+			continue
+		}
+		a, b := personnes[uuid].DateNaissance, personnes[chef].DateNaissance
+		if !a.IsZero() && (b.IsZero() || a.Before(b)) {
+			chef = uuid
+		}
+	}
+	return chef
+}
+
+// roleParRapportAuChef décrit le lien d'un membre avec le chef de ménage
+func roleParRapportAuChef(membre, chef string, relations []models.RelationFamiliale) string {
+	if membre == chef {
+		return "chef_menage"
+	}
+	for _, r := range relations {
+		switch {
+		case r.MigrantUUID == membre && r.ApparenteUUID == chef:
+			switch r.TypeRelation {
+			case "parent":
+				return "enfant"
+			case "tuteur":
+				return "pupille"
+			case "conjoint", "frere_soeur":
+				return r.TypeRelation
+			}
+		case r.MigrantUUID == chef && r.ApparenteUUID == membre:
+			switch r.TypeRelation {
+			case "parent", "tuteur", "conjoint", "frere_soeur":
+				return r.TypeRelation
+			}
+		}
+	}
+	return "membre"
+}
+
+// ComposerMenages reconstitue les ménages des migrants sélectionnés par "scope"
+// (sous-requête retournant des uuid de migrants)
+func ComposerMenages(db *gorm.DB, scope *gorm.DB) ([]Menage, error) {
+	var uuids []string
+	if err := scope.Pluck("migrants.uuid", &uuids).Error; err != nil {
+		return nil, err
+	}
+	if len(uuids) == 0 {
+		return []Menage{}, nil
+	}
+
+	dansScope := make(map[string]bool, len(uuids))
+	for _, uuid := range uuids {
+		dansScope[uuid] = true
+	}
+
+	var toutes []models.RelationFamiliale
+	if err := db.Find(&toutes).Error; err != nil {
+		return nil, err
+	}
+
+	// Relations dont au moins un membre est dans le périmètre
+	var relations []models.RelationFamiliale
+	impliques := map[string]bool{}
+	for _, r := range toutes {
+		if dansScope[r.MigrantUUID] || dansScope[r.ApparenteUUID] {
+			relations = append(relations, r)
+			impliques[r.MigrantUUID] = true
+			impliques[r.ApparenteUUID] = true
+		}
+	}
+
+	var isoles []string
+	for _, uuid := range uuids {
+		if !impliques[uuid] {
+			isoles = append(isoles, uuid)
+		}
+		impliques[uuid] = true
+	}
+
+	tous := make([]string, 0, len(impliques))
+	for uuid := range impliques {
+		tous = append(tous, uuid)
+	}
+	personnes, err := chargerPersonnes(db, tous)
+	if err != nil {
+		return nil, err
+	}
+
+	return RegrouperMenages(relations, personnes, isoles), nil
+}
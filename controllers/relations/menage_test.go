@@ -0,0 +1,254 @@
+package relations
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/models"
+)
+
+// ne retourne la date de naissance d'une personne de l'âge donné
+func ne(ans int) time.Time {
+	return time.Now().AddDate(-ans, 0, -1)
+}
+
+func lien(uuid, migrant, typeRelation, apparente string) models.RelationFamiliale {
+	return models.RelationFamiliale{UUID: uuid, MigrantUUID: migrant, TypeRelation: typeRelation, ApparenteUUID: apparente}
+}
+
+func regles(incoherences []Incoherence) []string {
+	var resultat []string
+	for _, i := range incoherences {
+		resultat = append(resultat, i.Regle)
+	}
+	sort.Strings(resultat)
+	return resultat
+}
+
+func egales(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDetecterIncoherences(t *testing.T) {
+	personnes := map[string]personne{
+		"pere":   {UUID: "pere", Sexe: "M", DateNaissance: ne(45), SituationMatrimoniale: "marie", NombreEnfants: 1},
+		"mere":   {UUID: "mere", Sexe: "F", DateNaissance: ne(40), SituationMatrimoniale: "celibataire", NombreEnfants: 2},
+		"enfant": {UUID: "enfant", Sexe: "F", DateNaissance: ne(10), NombreEnfants: 1},
+		"cadet":  {UUID: "cadet", Sexe: "M", DateNaissance: ne(5)},
+		"ado":    {UUID: "ado", Sexe: "M", DateNaissance: ne(16)},
+		"oncle":  {UUID: "oncle", Sexe: "M", DateNaissance: ne(50), NombreEnfants: 1},
+	}
+	cas := []struct {
+		nom       string
+		relations []models.RelationFamiliale
+		regles    []string
+		bloquante bool
+	}{
+		{
+			nom: "famille cohérente",
+			relations: []models.RelationFamiliale{
+				lien("1", "enfant", "parent", "pere"),
+				lien("2", "enfant", "parent", "mere"),
+				lien("3", "cadet", "parent", "mere"),
+				lien("4", "enfant", "frere_soeur", "cadet"),
+			},
+		},
+		{nom: "relation réflexive", relations: []models.RelationFamiliale{lien("1", "pere", "conjoint", "pere")}, regles: []string{"relation_reflexive"}, bloquante: true},
+		{nom: "parent trop jeune", relations: []models.RelationFamiliale{lien("1", "cadet", "parent", "enfant")}, regles: []string{"ecart_age_parent"}, bloquante: true},
+		{
+			nom:       "filiation circulaire",
+			relations: []models.RelationFamiliale{lien("1", "enfant", "parent", "pere"), lien("2", "pere", "parent", "enfant")},
+			// Le second sens place aussi l'enfant comme parent trop jeune
+			regles:    []string{"ecart_age_parent", "filiation_circulaire"},
+			bloquante: true,
+		},
+		{
+			nom:       "relation dupliquée",
+			relations: []models.RelationFamiliale{lien("1", "enfant", "parent", "pere"), lien("2", "enfant", "parent", "pere")},
+			// Deux enfants déclarés pour un père qui en compte un
+			regles:    []string{"nombre_enfants", "relation_dupliquee"},
+			bloquante: true,
+		},
+		{
+			nom:       "conjoints aussi frère et sœur",
+			relations: []models.RelationFamiliale{lien("1", "pere", "conjoint", "oncle"), lien("2", "pere", "frere_soeur", "oncle")},
+			regles:    []string{"liens_incompatibles"},
+			bloquante: true,
+		},
+		{
+			nom: "trois parents",
+			relations: []models.RelationFamiliale{
+				lien("1", "enfant", "parent", "pere"),
+				lien("2", "enfant", "parent", "mere"),
+				lien("3", "enfant", "parent", "oncle"),
+			},
+			regles:    []string{"trop_de_parents"},
+			bloquante: true,
+		},
+		{
+			nom:       "deux tuteurs",
+			relations: []models.RelationFamiliale{lien("1", "ado", "tuteur", "pere"), lien("2", "ado", "tuteur", "oncle")},
+			regles:    []string{"trop_de_tuteurs"},
+			bloquante: true,
+		},
+		{
+			nom:       "deux chefs de ménage",
+			relations: []models.RelationFamiliale{lien("1", "ado", "chef_menage", "pere"), lien("2", "ado", "chef_menage", "oncle")},
+			regles:    []string{"plusieurs_chefs_menage"},
+			bloquante: true,
+		},
+		{
+			// Avertissements non bloquants
+			nom:       "conjoint célibataire et mineur",
+			relations: []models.RelationFamiliale{lien("1", "mere", "conjoint", "ado")},
+			regles:    []string{"conjoint_celibataire", "conjoint_mineur"},
+		},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			incoherences := DetecterIncoherences(tc.relations, personnes)
+			if obtenues := regles(incoherences); !egales(obtenues, tc.regles) {
+				t.Fatalf("règles %v, attendues %v", obtenues, tc.regles)
+			}
+			if len(incoherences) > 0 && incoherences[0].Bloquante != tc.bloquante {
+				t.Errorf("première incohérence bloquante : %v, attendu %v", incoherences[0].Bloquante, tc.bloquante)
+			}
+			// Les bloquantes sont listées en premier
+			for i := 1; i < len(incoherences); i++ {
+				if incoherences[i].Bloquante && !incoherences[i-1].Bloquante {
+					t.Errorf("incohérence bloquante %s après un avertissement", incoherences[i].Regle)
+				}
+			}
+		})
+	}
+}
+
+func TestNouvellesIncoherences(t *testing.T) {
+	personnes := map[string]personne{
+		"enfant": {UUID: "enfant", DateNaissance: ne(10)},
+		"p1":     {UUID: "p1", DateNaissance: ne(40), NombreEnfants: 1},
+		"p2":     {UUID: "p2", DateNaissance: ne(40), NombreEnfants: 1},
+		"p3":     {UUID: "p3", DateNaissance: ne(40), NombreEnfants: 1},
+		"p4":     {UUID: "p4", DateNaissance: ne(40), NombreEnfants: 1},
+		"tante":  {UUID: "tante", DateNaissance: ne(35)},
+	}
+	deuxParents := []models.RelationFamiliale{lien("1", "enfant", "parent", "p1"), lien("2", "enfant", "parent", "p2")}
+	// Données anciennes déjà incohérentes : trois parents enregistrés
+	troisParents := append(append([]models.RelationFamiliale{}, deuxParents...), lien("3", "enfant", "parent", "p3"))
+
+	cas := []struct {
+		nom        string
+		existantes []models.RelationFamiliale
+		relation   models.RelationFamiliale
+		regles     []string
+	}{
+		{nom: "troisième parent", existantes: deuxParents, relation: lien("n", "enfant", "parent", "p3"), regles: []string{"trop_de_parents"}},
+		{nom: "quatrième parent", existantes: troisParents, relation: lien("n", "enfant", "parent", "p4"), regles: []string{"trop_de_parents"}},
+		// L'incohérence existante n'empêche pas d'enregistrer un lien sans rapport
+		{nom: "lien sans rapport", existantes: troisParents, relation: lien("n", "tante", "frere_soeur", "p1")},
+		// Le lien répété compte aussi comme troisième parent
+		{nom: "doublon d'un lien existant", existantes: deuxParents, relation: lien("n", "enfant", "parent", "p1"), regles: []string{"relation_dupliquee", "trop_de_parents"}},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			nouvelles := nouvellesIncoherences(tc.existantes, tc.relation, personnes)
+			if obtenues := regles(nouvelles); !egales(obtenues, tc.regles) {
+				t.Errorf("incohérences %v, attendues %v", obtenues, tc.regles)
+			}
+		})
+	}
+}
+
+func TestNormaliserRelation(t *testing.T) {
+	r := lien("1", "pere", "enfant", "fils")
+	normaliserRelation(&r)
+	if r.TypeRelation != "parent" || r.MigrantUUID != "fils" || r.ApparenteUUID != "pere" {
+		t.Errorf("relation normalisée %s -%s-> %s, attendu fils -parent-> pere", r.MigrantUUID, r.TypeRelation, r.ApparenteUUID)
+	}
+}
+
+func TestRegrouperMenages(t *testing.T) {
+	personnes := map[string]personne{
+		"mere":   {UUID: "mere", Sexe: "F", DateNaissance: ne(38), VilleActuelle: "Goma"},
+		"enfant": {UUID: "enfant", DateNaissance: ne(9), VilleActuelle: "Goma"},
+		"bebe":   {UUID: "bebe", DateNaissance: ne(1), VilleActuelle: "Bukavu"},
+		"grand":  {UUID: "grand", Sexe: "M", DateNaissance: ne(70), VilleActuelle: "Goma"},
+		"seul":   {UUID: "seul", DateNaissance: ne(15)},
+		"isole":  {UUID: "isole", Sexe: "M", DateNaissance: ne(30)},
+	}
+	relations := []models.RelationFamiliale{
+		lien("1", "enfant", "parent", "mere"),
+		lien("2", "mere", "parent", "grand"),
+		// Le bébé n'a qu'un lien de fratrie : mineur non accompagné
+		lien("3", "bebe", "frere_soeur", "enfant"),
+		lien("4", "enfant", "chef_menage", "mere"),
+	}
+
+	menages := RegrouperMenages(relations, personnes, []string{"seul", "isole", "mere"})
+	if len(menages) != 3 {
+		t.Fatalf("%d ménages, attendu 3", len(menages))
+	}
+
+	famille := menages[0]
+	if famille.Taille != 4 || famille.ChefUUID != "mere" || !famille.ChefFemme || famille.ChefMineur {
+		t.Errorf("ménage familial %+v, attendu 4 membres avec la mère pour cheffe", famille)
+	}
+	if famille.Adultes != 2 || famille.Mineurs != 2 || famille.Aines != 1 {
+		t.Errorf("composition %d adultes, %d mineurs, %d aînés, attendu 2, 2, 1", famille.Adultes, famille.Mineurs, famille.Aines)
+	}
+	if famille.MineursNonAccompagnes != 1 || !famille.Monoparental || !famille.Disperse {
+		t.Errorf("ménage familial : %d mineur(s) non accompagné(s), monoparental %v, dispersé %v, attendu 1, true, true",
+			famille.MineursNonAccompagnes, famille.Monoparental, famille.Disperse)
+	}
+
+	// Ménages d'une personne, triés par chef
+	if menages[1].ChefUUID != "isole" || menages[2].ChefUUID != "seul" {
+		t.Errorf("ménages isolés %s et %s, attendus isole et seul", menages[1].ChefUUID, menages[2].ChefUUID)
+	}
+	if !menages[2].ChefMineur || menages[2].MineursNonAccompagnes != 1 {
+		t.Errorf("mineur seul : %+v", menages[2])
+	}
+}
+
+func TestDesignerChefSansDesignation(t *testing.T) {
+	personnes := map[string]personne{
+		"a": {UUID: "a", DateNaissance: ne(30)},
+		"b": {UUID: "b", DateNaissance: ne(52)},
+		"c": {UUID: "c", DateNaissance: ne(12)},
+	}
+	adultes := map[string]bool{"a": true, "b": true}
+	if chef := designerChef([]string{"a", "b", "c"}, nil, personnes, adultes); chef != "b" {
+		t.Errorf("chef %s, attendu b (adulte le plus âgé)", chef)
+	}
+}
+
+func TestRoleParRapportAuChef(t *testing.T) {
+	relations := []models.RelationFamiliale{
+		lien("1", "fils", "parent", "chef"),
+		lien("2", "chef", "parent", "aieul"),
+		lien("3", "pupille", "tuteur", "chef"),
+		lien("4", "chef", "conjoint", "epouse"),
+	}
+	attendus := map[string]string{
+		"chef":    "chef_menage",
+		"fils":    "enfant",
+		"aieul":   "parent",
+		"pupille": "pupille",
+		"epouse":  "conjoint",
+		"voisin":  "membre",
+	}
+	for membre, role := range attendus {
+		if obtenu := roleParRapportAuChef(membre, "chef", relations); obtenu != role {
+			t.Errorf("%s : rôle %s, attendu %s", membre, obtenu, role)
+		}
+	}
+}
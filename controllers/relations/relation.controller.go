@@ -0,0 +1,241 @@
+package relations
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// MembreMenage représente un membre du ménage et son lien avec le chef
+type MembreMenage struct {
+	Migrant models.Migrant `json:"migrant"`
+	Role    string         `json:"role"`
+}
+
+// GetMigrantRelations - Récupérer les relations familiales d'un migrant
+func GetMigrantRelations(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	if !unites.AccederMigrant(c, uuid, "migrant", uuid) {
+		return unites.Refus(c)
+	}
+
+	// Les identités des apparentés enregistrés par une autre unité ne sont pas reprises
+	p := unites.PerimetreDe(c)
+	var relations []models.RelationFamiliale
+	err := db.Where("migrant_uuid = ? OR apparente_uuid = ?", uuid, uuid).
+		Preload("Migrant.Identite", p.Identites).
+		Preload("Apparente.Identite", p.Identites).
+		Order("created_at ASC").
+		Find(&relations).Error
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch relations", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Relations retrieved successfully",
+		"data":    relations,
+	})
+}
+
+// CreateRelation - Enregistrer un lien familial après contrôle de cohérence
+func CreateRelation(c *fiber.Ctx) error {
+	db := database.DB
+	relation := &models.RelationFamiliale{}
+
+	if err := c.BodyParser(relation); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(*relation); err != nil {
+		return problemes.Validation(err)
+	}
+
+	if relation.MigrantUUID == relation.ApparenteUUID {
+		return problemes.Invalide("A migrant cannot be related to themselves")
+	}
+
+	if !unites.AccederMigrant(c, relation.MigrantUUID, "migrant", relation.MigrantUUID) ||
+		!unites.AccederMigrant(c, relation.ApparenteUUID, "migrant", relation.ApparenteUUID) {
+		return unites.Refus(c)
+	}
+
+	normaliserRelation(relation)
+
+	var count int64
+	err := db.Model(&models.Migrant{}).Where("uuid IN ?", []string{relation.MigrantUUID, relation.ApparenteUUID}).Count(&count).Error
+	if err != nil {
+		return problemes.Echec("Failed to check relation consistency", err)
+	}
+	if count != 2 {
+		return problemes.Introuvable("Migrant not found")
+	}
+
+	// Relations existantes des deux migrants, avant et après ajout
+	var existantes []models.RelationFamiliale
+	err = db.Where("migrant_uuid IN ? OR apparente_uuid IN ?",
+		[]string{relation.MigrantUUID, relation.ApparenteUUID},
+		[]string{relation.MigrantUUID, relation.ApparenteUUID}).
+		Find(&existantes).Error
+	if err != nil {
+		return problemes.Echec("Failed to check relation consistency", err)
+	}
+
+	uuids := map[string]bool{relation.MigrantUUID: true, relation.ApparenteUUID: true}
+	for _, r := range existantes {
+		uuids[r.MigrantUUID] = true
+		uuids[r.ApparenteUUID] = true
+	}
+	liste := make([]string, 0, len(uuids))
+	for uuid := range uuids {
+		liste = append(liste, uuid)
+	}
+	personnes, err := chargerPersonnes(db, liste)
+	if err != nil {
+		return problemes.Echec("Failed to check relation consistency", err)
+	}
+
+	if nouvelles := nouvellesIncoherences(existantes, *relation, personnes); len(nouvelles) > 0 {
+		return problemes.Invalide(nouvelles[0].Message).Avec("data", nouvelles)
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	relation.UUID = utils.GenerateUUID()
+	relation.EnregistrePar = userUUID
+
+	if err := db.Create(relation).Error; err != nil {
+		return problemes.Echec("Failed to create relation", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Relation created successfully",
+		"data":    relation,
+	})
+}
+
+// DeleteRelation - Supprimer un lien familial
+func DeleteRelation(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var relation models.RelationFamiliale
+	if err := db.Where("uuid = ?", uuid).First(&relation).Error; err != nil {
+		return problemes.Absent("Relation not found", err)
+	}
+
+	if !unites.AccederMigrant(c, relation.MigrantUUID, "relation", relation.UUID) {
+		return unites.Refus(c)
+	}
+
+	if err := db.Delete(&relation).Error; err != nil {
+		return problemes.Echec("Failed to delete relation", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Relation deleted successfully",
+		"data":    nil,
+	})
+}
+
+// GetMenage - Vue du ménage d'un migrant : membres, rôles, composition et incohérences
+func GetMenage(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var migrant models.Migrant
+	if err := db.Where("uuid = ?", uuid).First(&migrant).Error; err != nil {
+		return problemes.Absent("Migrant not found", err)
+	}
+
+	if !unites.Acceder(c, migrant.UniteUUID, "migrant", migrant.UUID) {
+		return unites.Refus(c)
+	}
+
+	relations, membresUUID, err := chargerComposante(db, uuid)
+	if err != nil {
+		return problemes.Echec("Failed to load household", err)
+	}
+
+	personnes, err := chargerPersonnes(db, membresUUID)
+	if err != nil {
+		return problemes.Echec("Failed to load household", err)
+	}
+
+	menages := RegrouperMenages(relations, personnes, []string{uuid})
+	menage := menages[0]
+	incoherences := DetecterIncoherences(relations, personnes)
+	if menage.ChefMineur {
+		incoherences = append(incoherences, Incoherence{
+			Regle:    "chef_menage_mineur",
+			Message:  "The household head is a minor",
+			Migrants: []string{menage.ChefUUID},
+		})
+	}
+
+	// Seuls les membres du périmètre de l'agent sont détaillés
+	var migrants []models.Migrant
+	err = db.Scopes(unites.PerimetreDe(c).Migrants).
+		Where("uuid IN ?", membresUUID).
+		Preload("Identite").
+		Find(&migrants).Error
+	if err != nil {
+		return problemes.Echec("Failed to load household", err)
+	}
+
+	membres := make([]MembreMenage, 0, len(migrants))
+	for _, m := range migrants {
+		membres = append(membres, MembreMenage{
+			Migrant: m,
+			Role:    roleParRapportAuChef(m.UUID, menage.ChefUUID, relations),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Household retrieved successfully",
+		"data": fiber.Map{
+			"composition":  menage,
+			"membres":      membres,
+			"relations":    relations,
+			"incoherences": incoherences,
+		},
+	})
+}
+
+// GetIncoherences - Contrôle de cohérence de l'ensemble des liens familiaux
+func GetIncoherences(c *fiber.Ctx) error {
+	db := database.DB
+
+	var relations []models.RelationFamiliale
+	if err := db.Scopes(unites.PerimetreDe(c).ParMigrant("relations_familiales.migrant_uuid")).Find(&relations).Error; err != nil {
+		return problemes.Echec("Failed to fetch relations", err)
+	}
+
+	uuids := map[string]bool{}
+	for _, r := range relations {
+		uuids[r.MigrantUUID] = true
+		uuids[r.ApparenteUUID] = true
+	}
+	liste := make([]string, 0, len(uuids))
+	for uuid := range uuids {
+		liste = append(liste, uuid)
+	}
+	personnes, err := chargerPersonnes(db, liste)
+	if err != nil {
+		return problemes.Echec("Failed to check relation consistency", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Consistency check completed",
+		"data":    DetecterIncoherences(relations, personnes),
+	})
+}
@@ -0,0 +1,105 @@
+package relations
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// Conjoints enregistrés par deux unités : l'agent de A ne lie pas un migrant de B
+// et ne voit ni l'identité ni la fiche du conjoint enregistré par B
+func TestRelationsRestreintesAuPerimetre(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	naissance := time.Date(1985, 3, 4, 0, 0, 0, 0, time.UTC)
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID: "unite-" + code, Code: code, Nom: "Direction " + code,
+			TypeUnite: "direction_provinciale", Actif: true, Chemin: "/unite-" + code + "/",
+		}
+		identite := models.Identite{
+			UUID: "identite-" + code, Nom: "NOM " + code, NumeroPasseport: "OP" + code,
+			DateNaissance: naissance, UniteUUID: unite.UUID,
+		}
+		migrant := models.Migrant{
+			UUID: "migrant-" + code, IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-" + code,
+			StatutMigratoire: "regulier", UniteUUID: unite.UUID,
+		}
+		for _, err := range []error{db.Create(&unite).Error, db.Create(&identite).Error, db.Omit("Identite").Create(&migrant).Error} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	relation := models.RelationFamiliale{UUID: "relation", MigrantUUID: "migrant-A", ApparenteUUID: "migrant-B", TypeRelation: "conjoint"}
+	if err := db.Omit("Migrant", "Apparente").Create(&relation).Error; err != nil {
+		t.Fatal(err)
+	}
+	agent := basetest.Utilisateur(t, db, "Agent", "unite-A")
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/migrant/:uuid", GetMigrantRelations)
+	app.Get("/menage/:uuid", GetMenage)
+	app.Post("/create", CreateRelation)
+	appeler := func(methode, url string, corps interface{}, reponse interface{}) int {
+		t.Helper()
+		var contenu bytes.Buffer
+		if corps != nil {
+			json.NewEncoder(&contenu).Encode(corps)
+		}
+		req := httptest.NewRequest(methode, url+"?token="+agent, &contenu)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reponse != nil {
+			json.NewDecoder(resp.Body).Decode(reponse)
+		}
+		return resp.StatusCode
+	}
+
+	// Lien vers un migrant d'une autre unité : refusé
+	statut := appeler("POST", "/create", map[string]string{
+		"migrant_uuid": "migrant-A", "apparente_uuid": "migrant-B", "type_relation": "frere_soeur",
+	}, nil)
+	if statut != fiber.StatusForbidden {
+		t.Errorf("lien vers un migrant de B : statut %d, attendu 403", statut)
+	}
+	var n int64
+	db.Model(&models.RelationFamiliale{}).Count(&n)
+	if n != 1 {
+		t.Errorf("%d relation(s) enregistrée(s), 1 attendue", n)
+	}
+
+	var relations struct {
+		Data []models.RelationFamiliale `json:"data"`
+	}
+	if statut := appeler("GET", "/migrant/migrant-A", nil, &relations); statut != fiber.StatusOK || len(relations.Data) != 1 {
+		t.Fatalf("relations de migrant-A : statut %d, %d relation(s)", statut, len(relations.Data))
+	}
+	if r := relations.Data[0]; r.Migrant.Identite.UUID != "identite-A" || r.Apparente.Identite.UUID != "" {
+		t.Errorf("identités chargées : %q et %q, attendu identite-A seule", r.Migrant.Identite.UUID, r.Apparente.Identite.UUID)
+	}
+
+	var menage struct {
+		Data struct {
+			Membres []MembreMenage `json:"membres"`
+		} `json:"data"`
+	}
+	if statut := appeler("GET", "/menage/migrant-A", nil, &menage); statut != fiber.StatusOK {
+		t.Fatalf("ménage de migrant-A : statut %d", statut)
+	}
+	if m := menage.Data.Membres; len(m) != 1 || m[0].Migrant.UUID != "migrant-A" {
+		t.Errorf("membres détaillés %v, attendu migrant-A seul", m)
+	}
+}
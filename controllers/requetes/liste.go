@@ -0,0 +1,534 @@
+package requetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"gorm.io/gorm"
+)
+
+// Type d'un champ exposé, utilisé pour convertir les valeurs reçues en paramètres
+type Type int
+
+const (
+	Texte Type = iota
+	Nombre
+	Date
+	Booleen
+)
+
+// Taille de page par défaut et maximale
+const (
+	limiteDefaut = 15
+	limiteMax    = 1000
+)
+
+// Schema déclare, par nom JSON, les champs d'une entité pouvant être filtrés, triés
+// et sélectionnés. Aucun autre nom n'est accepté : les colonnes SQL sont toujours
+// construites à partir de la table et de cette liste blanche.
+type Schema struct {
+	Table  string
+	Champs map[string]Type
+	// Colonnes dont le nom diffère du nom JSON
+	Colonnes map[string]string
+	// Relations préchargées pouvant être conservées par le paramètre fields
+	Relations []string
+	// Tri appliqué sans paramètre sort, par exemple "-created_at"
+	TriDefaut string
+}
+
+type filtre struct {
+	champ   string
+	op      string
+	valeurs []interface{}
+}
+
+type tri struct {
+	champ string
+	desc  bool
+}
+
+// Liste contient les paramètres de liste lus dans la requête :
+//
+//	filter[statut_migratoire]=refugie           égalité
+//	filter[created_at][gte]=2025-01-01          comparaison (eq, ne, gt, gte, lt, lte)
+//	filter[statut][in]=ouvert,en_cours          liste de valeurs
+//	filter[nom][like]=kab                       recherche partielle (texte)
+//	filter[date_entree][null]=true              valeur absente ou non
+//	sort=-created_at,nom                        tri, "-" pour décroissant
+//	fields=uuid,nom,identite                    champs retournés
+//	page=2&limit=15                             pagination par pages
+//	cursor=&limit=100                           pagination par curseur (keyset)
+type Liste struct {
+	schema  Schema
+	filtres []filtre
+	tris    []tri
+	champs  []string
+	page    int
+	limite  int
+	// Vrai si le client a fixé limit : les listes complètes sont alors tronquées
+	limitee bool
+	// nil en pagination par pages ; vide pour la première page en pagination par curseur
+	curseur *string
+	// Condition keyset des lignes situées après le curseur reçu, et valeurs triées
+	// de la dernière ligne de la page précédente
+	condition string
+	arguments []interface{}
+	repere    []interface{}
+}
+
+var cleFiltre = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// Lire valide les paramètres de liste de la requête contre le schéma.
+// Les erreurs sont destinées au client (réponse 400, voir Invalide).
+func Lire(c *fiber.Ctx, schema Schema) (*Liste, []string) {
+	l := &Liste{schema: schema, page: 1, limite: limiteDefaut}
+	var erreurs []string
+
+	if page, err := strconv.Atoi(c.Query("page", "1")); err == nil && page > 0 {
+		l.page = page
+	}
+	if limite, err := strconv.Atoi(c.Query("limit")); err == nil && limite > 0 {
+		l.limite, l.limitee = limite, true
+	}
+	if l.limite > limiteMax {
+		l.limite = limiteMax
+	}
+
+	args := c.Context().QueryArgs()
+	if args.Has("cursor") {
+		curseur := c.Query("cursor")
+		l.curseur = &curseur
+	}
+
+	args.VisitAll(func(k, v []byte) {
+		cle := string(k)
+		if !strings.HasPrefix(cle, "filter[") {
+			return
+		}
+		m := cleFiltre.FindStringSubmatch(cle)
+		if m == nil {
+			erreurs = append(erreurs, fmt.Sprintf("Filtre invalide : %s", cle))
+			return
+		}
+		f, err := l.filtre(m[1], m[2], string(v))
+		if err != nil {
+			erreurs = append(erreurs, err.Error())
+			return
+		}
+		l.filtres = append(l.filtres, f)
+	})
+
+	tris := c.Query("sort", schema.TriDefaut)
+	for _, nom := range strings.Split(tris, ",") {
+		nom = strings.TrimSpace(nom)
+		if nom == "" {
+			continue
+		}
+		t := tri{champ: strings.TrimPrefix(nom, "-"), desc: strings.HasPrefix(nom, "-")}
+		if _, ok := schema.Champs[t.champ]; !ok {
+			erreurs = append(erreurs, fmt.Sprintf("Tri non autorisé : %s", t.champ))
+			continue
+		}
+		l.tris = append(l.tris, t)
+	}
+	// L'uuid départage les égalités : l'ordre doit être total pour paginer sans doublon
+	if !l.trie("uuid") {
+		l.tris = append(l.tris, tri{champ: "uuid"})
+	}
+
+	if l.curseur != nil && *l.curseur != "" {
+		repere, err := l.decoder(*l.curseur)
+		if err != nil {
+			erreurs = append(erreurs, err.Error())
+		} else {
+			l.repere = repere
+			l.condition, l.arguments = l.apres(repere)
+		}
+	}
+
+	if champs := c.Query("fields"); champs != "" {
+		relations := map[string]bool{}
+		for _, r := range schema.Relations {
+			relations[r] = true
+		}
+		for _, nom := range strings.Split(champs, ",") {
+			nom = strings.TrimSpace(nom)
+			if _, ok := schema.Champs[nom]; !ok && !relations[nom] {
+				erreurs = append(erreurs, fmt.Sprintf("Champ non disponible : %s", nom))
+				continue
+			}
+			l.champs = append(l.champs, nom)
+		}
+	}
+
+	return l, erreurs
+}
+
+// Invalide - réponse 400 de paramètres de liste refusés
+func Invalide(c *fiber.Ctx, erreurs []string) error {
+	return problemes.Envoyer(c, problemes.Messages(problemes.CodeRequeteListe, erreurs))
+}
+
+// Executer applique filtres, tri et pagination à la requête, charge la page dans dest
+// (pointeur sur une slice) et retourne les données à renvoyer, réduites aux champs
+// demandés, avec les métadonnées de pagination.
+func (l *Liste) Executer(query *gorm.DB, dest interface{}) (interface{}, map[string]interface{}, error) {
+	query = l.Filtrer(query)
+
+	var pagination map[string]interface{}
+	if l.curseur == nil {
+		var totalRecords int64
+		if err := query.Count(&totalRecords).Error; err != nil {
+			return nil, nil, err
+		}
+		err := l.ordonner(query).
+			Offset((l.page - 1) * l.limite).
+			Limit(l.limite).
+			Find(dest).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		pagination = map[string]interface{}{
+			"total_records": totalRecords,
+			"total_pages":   int((totalRecords + int64(l.limite) - 1) / int64(l.limite)),
+			"current_page":  l.page,
+			"page_size":     l.limite,
+		}
+	} else {
+		// Le total n'est pas calculé : la pagination par curseur sert aux grandes tables
+		query = l.ordonner(query)
+		if l.condition != "" {
+			query = query.Where(l.condition, l.arguments...)
+		}
+		if err := query.Limit(l.limite + 1).Find(dest).Error; err != nil {
+			return nil, nil, err
+		}
+
+		lignes := reflect.ValueOf(dest).Elem()
+		suite := lignes.Len() > l.limite
+		if suite {
+			lignes.Set(lignes.Slice(0, l.limite))
+		}
+		suivant := ""
+		if suite {
+			s, err := l.curseurApres(lignes.Index(lignes.Len() - 1).Interface())
+			if err != nil {
+				return nil, nil, err
+			}
+			suivant = s
+		}
+		pagination = map[string]interface{}{
+			"page_size":   l.limite,
+			"has_more":    suite,
+			"next_cursor": suivant,
+		}
+	}
+
+	data, err := l.projeter(dest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, pagination, nil
+}
+
+// Filtree indique si le client filtre déjà ce champ, pour ne pas lui ajouter
+// le filtre appliqué par défaut (statut en_attente d'une file de revue par exemple)
+func (l *Liste) Filtree(champ string) bool {
+	for _, f := range l.filtres {
+		if f.champ == champ {
+			return true
+		}
+	}
+	return false
+}
+
+// Filtrer applique uniquement les filtres, pour les listes non paginées
+func (l *Liste) Filtrer(query *gorm.DB) *gorm.DB {
+	for _, f := range l.filtres {
+		colonne := l.colonne(f.champ)
+		switch f.op {
+		case "eq":
+			query = query.Where(colonne+" = ?", f.valeurs[0])
+		case "ne":
+			query = query.Where(colonne+" <> ?", f.valeurs[0])
+		case "gt":
+			query = query.Where(colonne+" > ?", f.valeurs[0])
+		case "gte":
+			query = query.Where(colonne+" >= ?", f.valeurs[0])
+		case "lt":
+			query = query.Where(colonne+" < ?", f.valeurs[0])
+		case "lte":
+			query = query.Where(colonne+" <= ?", f.valeurs[0])
+		case "in":
+			query = query.Where(colonne+" IN ?", f.valeurs)
+		case "like":
+			query = query.Where(colonne+" ILIKE ?", f.valeurs[0])
+		case "null":
+			if f.valeurs[0].(bool) {
+				query = query.Where(colonne + " IS NULL")
+			} else {
+				query = query.Where(colonne + " IS NOT NULL")
+			}
+		}
+	}
+	return query
+}
+
+// Tout charge dans dest les lignes filtrées et triées, réduites aux champs demandés.
+// Le nombre de lignes n'est borné que si le client a fixé limit.
+func (l *Liste) Tout(query *gorm.DB, dest interface{}) (interface{}, error) {
+	query = l.ordonner(l.Filtrer(query))
+	if l.limitee {
+		query = query.Limit(l.limite)
+	}
+	if err := query.Find(dest).Error; err != nil {
+		return nil, err
+	}
+	return l.projeter(dest)
+}
+
+func (l *Liste) filtre(champ, op, valeur string) (filtre, error) {
+	typ, ok := l.schema.Champs[champ]
+	if !ok {
+		return filtre{}, fmt.Errorf("Filtre non autorisé : %s", champ)
+	}
+	if op == "" {
+		op = "eq"
+	}
+	f := filtre{champ: champ, op: op}
+
+	switch op {
+	case "eq", "ne", "gt", "gte", "lt", "lte":
+		v, err := convertir(typ, valeur)
+		if err != nil {
+			return f, fmt.Errorf("filter[%s] : %v", champ, err)
+		}
+		// Une date sans heure en borne haute inclut toute la journée
+		if t, ok := v.(time.Time); ok && len(valeur) == len("2006-01-02") {
+			switch op {
+			case "lte":
+				f.op, v = "lt", t.AddDate(0, 0, 1)
+			case "gt":
+				f.op, v = "gte", t.AddDate(0, 0, 1)
+			}
+		}
+		f.valeurs = []interface{}{v}
+	case "in":
+		for _, morceau := range strings.Split(valeur, ",") {
+			v, err := convertir(typ, strings.TrimSpace(morceau))
+			if err != nil {
+				return f, fmt.Errorf("filter[%s] : %v", champ, err)
+			}
+			f.valeurs = append(f.valeurs, v)
+		}
+	case "like":
+		if typ != Texte {
+			return f, fmt.Errorf("filter[%s][like] : réservé aux champs texte", champ)
+		}
+		f.valeurs = []interface{}{"%" + valeur + "%"}
+	case "null":
+		v, err := strconv.ParseBool(valeur)
+		if err != nil {
+			return f, fmt.Errorf("filter[%s][null] : true ou false attendu", champ)
+		}
+		f.valeurs = []interface{}{v}
+	default:
+		return f, fmt.Errorf("Opérateur inconnu : %s", op)
+	}
+	return f, nil
+}
+
+func convertir(typ Type, valeur string) (interface{}, error) {
+	switch typ {
+	case Nombre:
+		n, err := strconv.ParseFloat(valeur, 64)
+		if err != nil {
+			return nil, fmt.Errorf("nombre attendu")
+		}
+		if n == float64(int64(n)) {
+			return int64(n), nil
+		}
+		return n, nil
+	case Date:
+		for _, format := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(format, valeur); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("date attendue (AAAA-MM-JJ ou RFC 3339)")
+	case Booleen:
+		b, err := strconv.ParseBool(valeur)
+		if err != nil {
+			return nil, fmt.Errorf("true ou false attendu")
+		}
+		return b, nil
+	}
+	return valeur, nil
+}
+
+func (l *Liste) colonne(champ string) string {
+	if colonne, ok := l.schema.Colonnes[champ]; ok {
+		return l.schema.Table + "." + colonne
+	}
+	return l.schema.Table + "." + champ
+}
+
+func (l *Liste) trie(champ string) bool {
+	for _, t := range l.tris {
+		if t.champ == champ {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Liste) ordonner(query *gorm.DB) *gorm.DB {
+	for _, t := range l.tris {
+		if t.desc {
+			query = query.Order(l.colonne(t.champ) + " DESC")
+		} else {
+			query = query.Order(l.colonne(t.champ) + " ASC")
+		}
+	}
+	return query
+}
+
+// =======================
+// CURSEUR (KEYSET)
+// =======================
+
+// Le curseur reprend le tri demandé et les valeurs triées de la dernière ligne renvoyée
+type curseur struct {
+	Tri     string        `json:"s"`
+	Valeurs []interface{} `json:"v"`
+}
+
+func (l *Liste) signature() string {
+	noms := make([]string, len(l.tris))
+	for i, t := range l.tris {
+		noms[i] = t.champ
+		if t.desc {
+			noms[i] = "-" + t.champ
+		}
+	}
+	return strings.Join(noms, ",")
+}
+
+func (l *Liste) curseurApres(ligne interface{}) (string, error) {
+	valeurs, err := enCarte(ligne)
+	if err != nil {
+		return "", err
+	}
+	cur := curseur{Tri: l.signature()}
+	for _, t := range l.tris {
+		cur.Valeurs = append(cur.Valeurs, valeurs[t.champ])
+	}
+	brut, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(brut), nil
+}
+
+// decoder retourne les valeurs triées enregistrées dans le curseur, converties
+// dans le type de leur champ
+func (l *Liste) decoder(valeur string) ([]interface{}, error) {
+	brut, err := base64.RawURLEncoding.DecodeString(valeur)
+	if err != nil {
+		return nil, errCurseur
+	}
+	var cur curseur
+	if err := json.Unmarshal(brut, &cur); err != nil || cur.Tri != l.signature() || len(cur.Valeurs) != len(l.tris) {
+		return nil, errCurseur
+	}
+
+	valeurs := make([]interface{}, len(cur.Valeurs))
+	for i, t := range l.tris {
+		v := cur.Valeurs[i]
+		switch brute := v.(type) {
+		case string:
+			if l.schema.Champs[t.champ] == Date {
+				if v, err = convertir(Date, brute); err != nil {
+					return nil, errCurseur
+				}
+			}
+		case float64:
+			if brute == float64(int64(brute)) {
+				v = int64(brute)
+			}
+		}
+		valeurs[i] = v
+	}
+	return valeurs, nil
+}
+
+// apres construit la condition keyset des lignes situées après le repère :
+// (a > ?) OR (a = ? AND b > ?) OR ..., le sens de chaque comparaison suivant le tri
+func (l *Liste) apres(valeurs []interface{}) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for i, t := range l.tris {
+		var parties []string
+		for j := 0; j < i; j++ {
+			parties = append(parties, l.colonne(l.tris[j].champ)+" = ?")
+			args = append(args, valeurs[j])
+		}
+		operateur := " > ?"
+		if t.desc {
+			operateur = " < ?"
+		}
+		parties = append(parties, l.colonne(t.champ)+operateur)
+		args = append(args, valeurs[i])
+		conditions = append(conditions, "("+strings.Join(parties, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// Curseur illisible ou obtenu avec un autre tri
+var errCurseur = errors.New("Curseur invalide pour ce tri")
+
+// =======================
+// SÉLECTION DES CHAMPS
+// =======================
+
+func (l *Liste) projeter(dest interface{}) (interface{}, error) {
+	if len(l.champs) == 0 {
+		return reflect.ValueOf(dest).Elem().Interface(), nil
+	}
+	lignes := reflect.ValueOf(dest).Elem()
+	resultat := make([]map[string]interface{}, 0, lignes.Len())
+	for i := 0; i < lignes.Len(); i++ {
+		valeurs, err := enCarte(lignes.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		ligne := make(map[string]interface{}, len(l.champs))
+		for _, champ := range l.champs {
+			ligne[champ] = valeurs[champ]
+		}
+		resultat = append(resultat, ligne)
+	}
+	return resultat, nil
+}
+
+func enCarte(ligne interface{}) (map[string]interface{}, error) {
+	brut, err := json.Marshal(ligne)
+	if err != nil {
+		return nil, err
+	}
+	var valeurs map[string]interface{}
+	if err := json.Unmarshal(brut, &valeurs); err != nil {
+		return nil, err
+	}
+	return valeurs, nil
+}
@@ -0,0 +1,74 @@
+package requetes
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var schemaTest = Schema{
+	Table: "hits",
+	Champs: map[string]Type{
+		"uuid":       Texte,
+		"statut":     Texte,
+		"score":      Nombre,
+		"created_at": Date,
+	},
+	Relations: []string{"identite"},
+	TriDefaut: "-score",
+}
+
+// lire exécute Lire sur la chaîne de requête donnée
+func lire(t *testing.T, requete string) (*Liste, []string) {
+	t.Helper()
+	var liste *Liste
+	var erreurs []string
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		liste, erreurs = Lire(c, schemaTest)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest("GET", "/?"+requete, nil), -1); err != nil {
+		t.Fatal(err)
+	}
+	return liste, erreurs
+}
+
+func TestLireListeBlanche(t *testing.T) {
+	cas := []struct {
+		requete string
+		erreur  string
+	}{
+		{"filter[statut]=en_attente&sort=-created_at&fields=uuid,identite", ""},
+		{"filter[score][gte]=0.8", ""},
+		{"filter[motif]=x", "Filtre non autorisé : motif"},
+		{"filter[score][like]=8", "réservé aux champs texte"},
+		{"filter[score][gte]=haut", "nombre attendu"},
+		{"sort=commentaire", "Tri non autorisé : commentaire"},
+		{"fields=cle_nom", "Champ non disponible : cle_nom"},
+	}
+	for _, tc := range cas {
+		_, erreurs := lire(t, tc.requete)
+		if tc.erreur == "" {
+			if len(erreurs) > 0 {
+				t.Errorf("%s : erreurs inattendues %v", tc.requete, erreurs)
+			}
+			continue
+		}
+		if len(erreurs) != 1 || !strings.Contains(erreurs[0], tc.erreur) {
+			t.Errorf("%s : erreurs %v, attendu %q", tc.requete, erreurs, tc.erreur)
+		}
+	}
+}
+
+func TestFiltree(t *testing.T) {
+	liste, _ := lire(t, "filter[statut][in]=confirmee,ecartee")
+	if !liste.Filtree("statut") {
+		t.Error("statut filtré non détecté")
+	}
+	if liste.Filtree("score") {
+		t.Error("score signalé filtré sans filtre")
+	}
+}
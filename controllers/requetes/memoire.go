@@ -0,0 +1,297 @@
+package requetes
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =======================
+// EXÉCUTION EN MÉMOIRE
+// =======================
+
+// Les dépôts en mémoire chargent toutes les lignes visibles puis leur appliquent les
+// mêmes filtres, tri et pagination que la requête SQL, sur les valeurs JSON des champs.
+
+// ExecuterLignes applique filtres, tri et pagination aux lignes de la slice pointée par
+// lignes, réduite à la page, et retourne les mêmes données qu'Executer
+func (l *Liste) ExecuterLignes(lignes interface{}) (interface{}, map[string]interface{}, error) {
+	cartes, err := l.selectionner(lignes)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := reflect.ValueOf(lignes).Elem()
+
+	var pagination map[string]interface{}
+	if l.curseur == nil {
+		total := v.Len()
+		debut := min((l.page-1)*l.limite, total)
+		v.Set(v.Slice(debut, min(debut+l.limite, total)))
+		pagination = map[string]interface{}{
+			"total_records": int64(total),
+			"total_pages":   (total + l.limite - 1) / l.limite,
+			"current_page":  l.page,
+			"page_size":     l.limite,
+		}
+	} else {
+		debut := 0
+		if l.repere != nil {
+			for debut < len(cartes) && !l.situeeApres(cartes[debut]) {
+				debut++
+			}
+		}
+		v.Set(v.Slice(debut, v.Len()))
+		suite := v.Len() > l.limite
+		suivant := ""
+		if suite {
+			v.Set(v.Slice(0, l.limite))
+			s, err := l.curseurApres(v.Index(l.limite - 1).Interface())
+			if err != nil {
+				return nil, nil, err
+			}
+			suivant = s
+		}
+		pagination = map[string]interface{}{
+			"page_size":   l.limite,
+			"has_more":    suite,
+			"next_cursor": suivant,
+		}
+	}
+
+	data, err := l.projeter(lignes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, pagination, nil
+}
+
+// ToutLignes filtre et trie les lignes comme Tout
+func (l *Liste) ToutLignes(lignes interface{}) (interface{}, error) {
+	if _, err := l.selectionner(lignes); err != nil {
+		return nil, err
+	}
+	if v := reflect.ValueOf(lignes).Elem(); l.limitee && v.Len() > l.limite {
+		v.Set(v.Slice(0, l.limite))
+	}
+	return l.projeter(lignes)
+}
+
+// selectionner retire de la slice les lignes exclues par les filtres puis la trie ;
+// retourne les valeurs JSON des lignes conservées, dans l'ordre
+func (l *Liste) selectionner(lignes interface{}) ([]map[string]interface{}, error) {
+	v := reflect.ValueOf(lignes).Elem()
+	gardees := reflect.MakeSlice(v.Type(), 0, v.Len())
+	var cartes []map[string]interface{}
+	for i := 0; i < v.Len(); i++ {
+		carte, err := enCarte(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if l.retenue(carte) {
+			gardees = reflect.Append(gardees, v.Index(i))
+			cartes = append(cartes, carte)
+		}
+	}
+
+	ordre := make([]int, len(cartes))
+	for i := range ordre {
+		ordre[i] = i
+	}
+	sort.SliceStable(ordre, func(a, b int) bool {
+		return l.comparerLignes(cartes[ordre[a]], cartes[ordre[b]]) < 0
+	})
+	triees := reflect.MakeSlice(v.Type(), len(ordre), len(ordre))
+	triage := make([]map[string]interface{}, len(ordre))
+	for i, j := range ordre {
+		triees.Index(i).Set(gardees.Index(j))
+		triage[i] = cartes[j]
+	}
+	v.Set(triees)
+	return triage, nil
+}
+
+// retenue indique si la ligne satisfait tous les filtres
+func (l *Liste) retenue(carte map[string]interface{}) bool {
+	for _, f := range l.filtres {
+		valeur := l.typee(f.champ, carte[f.champ])
+		switch f.op {
+		case "null":
+			if (valeur == nil) != f.valeurs[0].(bool) {
+				return false
+			}
+		case "like":
+			texte, _ := valeur.(string)
+			motif := strings.Trim(f.valeurs[0].(string), "%")
+			if !strings.Contains(strings.ToLower(texte), strings.ToLower(motif)) {
+				return false
+			}
+		case "in":
+			trouvee := false
+			for _, v := range f.valeurs {
+				if ordre, ok := Comparer(valeur, v); ok && ordre == 0 {
+					trouvee = true
+				}
+			}
+			if !trouvee {
+				return false
+			}
+		default:
+			ordre, ok := Comparer(valeur, f.valeurs[0])
+			if !ok {
+				return false
+			}
+			switch f.op {
+			case "eq":
+				ok = ordre == 0
+			case "ne":
+				ok = ordre != 0
+			case "gt":
+				ok = ordre > 0
+			case "gte":
+				ok = ordre >= 0
+			case "lt":
+				ok = ordre < 0
+			case "lte":
+				ok = ordre <= 0
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// comparerLignes ordonne deux lignes selon le tri demandé ; comme Postgres, les
+// valeurs absentes viennent en dernier en ordre croissant et en premier en ordre décroissant
+func (l *Liste) comparerLignes(a, b map[string]interface{}) int {
+	for _, t := range l.tris {
+		ordre := comparerAbsentes(l.typee(t.champ, a[t.champ]), l.typee(t.champ, b[t.champ]))
+		if ordre != 0 {
+			if t.desc {
+				return -ordre
+			}
+			return ordre
+		}
+	}
+	return 0
+}
+
+// situeeApres indique si la ligne vient après le repère du curseur
+func (l *Liste) situeeApres(carte map[string]interface{}) bool {
+	for i, t := range l.tris {
+		ordre := comparerAbsentes(l.typee(t.champ, carte[t.champ]), l.repere[i])
+		if ordre != 0 {
+			return (ordre > 0) != t.desc
+		}
+	}
+	return false
+}
+
+// typee convertit une valeur JSON dans le type déclaré du champ
+func (l *Liste) typee(champ string, valeur interface{}) interface{} {
+	if texte, ok := valeur.(string); ok && l.schema.Champs[champ] == Date {
+		if t, err := convertir(Date, texte); err == nil {
+			return t
+		}
+	}
+	return valeur
+}
+
+func comparerAbsentes(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	ordre, _ := Comparer(a, b)
+	return ordre
+}
+
+// Comparer ordonne deux valeurs de même nature (texte, nombre, date, booléen), comme
+// le ferait Postgres ; ok est faux si l'une est absente ou si elles ne sont pas comparables.
+// Un texte comparé à une date est lu comme une date.
+func Comparer(a, b interface{}) (ordre int, ok bool) {
+	a, b = normaliser(a), normaliser(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if ta, ok := a.(time.Time); ok {
+		if s, ok := b.(string); ok {
+			b = lireDate(s)
+		}
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb), true
+		}
+		return 0, false
+	}
+	if _, ok := b.(time.Time); ok {
+		ordre, ok := Comparer(b, a)
+		return -ordre, ok
+	}
+
+	switch va := a.(type) {
+	case float64:
+		if vb, ok := b.(float64); ok {
+			switch {
+			case va < vb:
+				return -1, true
+			case va > vb:
+				return 1, true
+			}
+			return 0, true
+		}
+	case string:
+		if vb, ok := b.(string); ok {
+			return strings.Compare(va, vb), true
+		}
+	case bool:
+		if vb, ok := b.(bool); ok {
+			switch {
+			case va == vb:
+				return 0, true
+			case !va:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// normaliser déréférence les pointeurs et ramène les nombres à float64
+func normaliser(valeur interface{}) interface{} {
+	v := reflect.ValueOf(valeur)
+	for v.IsValid() && v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return v.Interface()
+}
+
+// lireDate lit une date reçue en texte ('2025-01-31' vaut minuit, comme en SQL)
+func lireDate(texte string) interface{} {
+	for _, format := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(format, texte); err == nil {
+			return t
+		}
+	}
+	return texte
+}
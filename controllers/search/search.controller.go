@@ -0,0 +1,194 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Nombre maximal de candidats récupérés en base avant le classement
+const maxCandidates = 500
+
+// PersonMatch représente une identité trouvée avec son score de correspondance
+type PersonMatch struct {
+	Identite        models.Identite `json:"identite"`
+	Score           float64         `json:"score"`
+	Correspondances []string        `json:"correspondances"`
+}
+
+// SearchPersons - Recherche approximative de personnes par nom (phonétique + trigrammes)
+// GET /api/search/persons?q=Chibangu Leon&date_naissance=1980-05-12&nationalite=&min_score=0.5&page=1&limit=15
+func SearchPersons(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q", ""))
+	if q == "" {
+		return problemes.Invalide("q query parameter is required")
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "15"))
+	if err != nil || limit <= 0 {
+		limit = 15
+	}
+	minScore, err := strconv.ParseFloat(c.Query("min_score", "0.5"), 64)
+	if err != nil || minScore < 0 || minScore > 1 {
+		minScore = 0.5
+	}
+
+	queryTokens := utils.NameTokens(q)
+	if len(queryTokens) == 0 {
+		return problemes.Invalide("q must contain at least one letter or digit")
+	}
+
+	db := unites.Base(unites.PerimetreDe(c).Identites)
+	query := preselection(db, q, queryTokens)
+
+	// Filtres complémentaires
+	var dateNaissance *time.Time
+	if raw := c.Query("date_naissance", ""); raw != "" {
+		if parsed, err := time.Parse("2006-01-02", raw); err == nil {
+			dateNaissance = &parsed
+		}
+	}
+	if nationalite := c.Query("nationalite", ""); nationalite != "" {
+		query = query.Where("nationalite ILIKE ?", "%"+utils.EscapeLike(nationalite)+"%")
+	}
+	if sexe := c.Query("sexe", ""); sexe != "" {
+		query = query.Where("sexe = ?", sexe)
+	}
+
+	var candidates []models.Identite
+	err = query.Preload("Migrants").Find(&candidates).Error
+	if err != nil {
+		return problemes.Echec("Failed to search persons", err)
+	}
+
+	// Classement par score
+	normalizedPasseport := utils.NormalizeName(strings.ReplaceAll(q, " ", ""))
+	matches := []PersonMatch{}
+	for _, candidate := range candidates {
+		match := scoreCandidate(queryTokens, normalizedPasseport, dateNaissance, candidate)
+		if match.Score >= minScore {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	totalRecords := len(matches)
+	start := (page - 1) * limit
+	if start > totalRecords {
+		start = totalRecords
+	}
+	end := start + limit
+	if end > totalRecords {
+		end = totalRecords
+	}
+
+	totalPages := (totalRecords + limit - 1) / limit
+
+	pagination := map[string]interface{}{
+		"total_records": totalRecords,
+		"total_pages":   totalPages,
+		"current_page":  page,
+		"page_size":     limit,
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Persons retrieved successfully",
+		"data":       matches[start:end],
+		"pagination": pagination,
+	})
+}
+
+// preselection retient les candidats : même code phonétique, jeton approchant (index
+// trigrammes pg_trgm) ou passeport. Les plus proches en similarité de trigrammes sont
+// gardés avant le classement fin, pour que la limite ne coupe pas au hasard.
+func preselection(db *gorm.DB, q string, queryTokens []string) *gorm.DB {
+	cleNom, clePhonetique := utils.NameSearchKeys(q)
+	passeport := utils.EscapeLike(strings.ReplaceAll(q, " ", ""))
+
+	var conditions []string
+	var args []interface{}
+	for _, token := range queryTokens {
+		if code := utils.PhoneticCode(token); code != "" {
+			conditions = append(conditions, "cle_phonetique LIKE ?")
+			args = append(args, "% "+utils.EscapeLike(code)+" %")
+		}
+		if len([]rune(token)) >= 3 {
+			conditions = append(conditions, "? <% cle_nom")
+			args = append(args, token)
+		}
+	}
+	conditions = append(conditions, "numero_passeport ILIKE ?")
+	args = append(args, passeport)
+
+	return db.Model(&models.Identite{}).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "numero_passeport ILIKE ? DESC, GREATEST(similarity(cle_nom, ?), similarity(cle_phonetique, ?)) DESC, identites.uuid",
+			Vars:               []interface{}{passeport, cleNom, clePhonetique},
+			WithoutParentheses: true,
+		}}).
+		Limit(maxCandidates)
+}
+
+// scoreCandidate calcule le score d'une identité candidate (0..1)
+func scoreCandidate(queryTokens []string, passeport string, dateNaissance *time.Time, candidate models.Identite) PersonMatch {
+	match := PersonMatch{Identite: candidate, Correspondances: []string{}}
+
+	if passeport != "" && utils.NormalizeName(candidate.NumeroPasseport) == passeport {
+		match.Score = 1
+		match.Correspondances = append(match.Correspondances, "numero_passeport")
+		return match
+	}
+
+	candidateTokens := utils.NameTokens(candidate.Nom, candidate.Postnom, candidate.Prenom)
+	score := utils.NameMatchScore(queryTokens, candidateTokens)
+	if score > 0 {
+		match.Correspondances = append(match.Correspondances, "nom")
+	}
+
+	if hasPhoneticMatch(queryTokens, candidateTokens) {
+		match.Correspondances = append(match.Correspondances, "phonetique")
+	}
+
+	// La date de naissance renforce ou pénalise le score
+	if dateNaissance != nil {
+		if candidate.DateNaissance.Format("2006-01-02") == dateNaissance.Format("2006-01-02") {
+			score = score*0.8 + 0.2
+			match.Correspondances = append(match.Correspondances, "date_naissance")
+		} else {
+			score = score * 0.8
+		}
+	}
+
+	match.Score = float64(int(score*1000+0.5)) / 1000
+	return match
+}
+
+// hasPhoneticMatch indique si un jeton a été retrouvé par sa prononciation et non par son orthographe
+func hasPhoneticMatch(queryTokens, candidateTokens []string) bool {
+	for _, q := range queryTokens {
+		for _, cand := range candidateTokens {
+			if q != cand && utils.PhoneticCode(q) == utils.PhoneticCode(cand) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+func TestSearchPersonsRejectsEmptyQuery(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/search/persons", SearchPersons)
+
+	for _, q := range []string{"", "   ", "%_%"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/search/persons?q="+url.QueryEscape(q), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("q=%q: statut %d, attendu 400", q, resp.StatusCode)
+		}
+	}
+}
+
+func nouvelleIdentite(numero int, nom, postnom, prenom, passeport string) models.Identite {
+	identite := models.Identite{
+		UUID:            fmt.Sprintf("identite-%03d", numero),
+		Nom:             nom,
+		Postnom:         postnom,
+		Prenom:          prenom,
+		DateNaissance:   time.Date(1980, 5, 12, 0, 0, 0, 0, time.UTC),
+		Sexe:            "M",
+		Nationalite:     "Congolaise",
+		NumeroPasseport: passeport,
+		DateEmission:    time.Now().AddDate(-1, 0, 0),
+		DateExpiration:  time.Now().AddDate(4, 0, 0),
+	}
+	return identite
+}
+
+func TestPreselectionKeepsClosestCandidates(t *testing.T) {
+	db := basetest.Migree(t)
+
+	// Plus d'homonymes partiels que la limite de présélection, puis la personne cherchée
+	var identites []models.Identite
+	for i := 0; i < maxCandidates+20; i++ {
+		identites = append(identites, nouvelleIdentite(i, "CHIBALA", "KASONGO", fmt.Sprintf("JEAN%d", i), fmt.Sprintf("OP%07d", i)))
+	}
+	cherchee := nouvelleIdentite(999, "CHIBANGU", "MUKENDI", "LEON", "OB1234567")
+	identites = append(identites, cherchee)
+	if err := db.CreateInBatches(identites, 100).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	q := "Chibangu Leon"
+	var candidats []models.Identite
+	if err := preselection(db, q, utils.NameTokens(q)).Find(&candidats).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(candidats) != maxCandidates {
+		t.Fatalf("%d candidats, attendu %d", len(candidats), maxCandidates)
+	}
+	if candidats[0].UUID != cherchee.UUID {
+		t.Errorf("premier candidat %s (%s), attendu %s", candidats[0].UUID, candidats[0].CleNom, cherchee.UUID)
+	}
+}
+
+func TestPreselectionEscapesWildcards(t *testing.T) {
+	db := basetest.Migree(t)
+
+	if err := db.Create([]models.Identite{
+		nouvelleIdentite(1, "MBALA", "NGOY", "PAUL", "OP0000001"),
+		nouvelleIdentite(2, "KABILA", "MWAMBA", "JOSEPH", "OP0000002"),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// "%" et "_" ne doivent pas se comporter comme des jokers sur le passeport
+	for _, q := range []string{"OP%", "OP_______1 X"} {
+		var candidats []models.Identite
+		if err := preselection(db, q, utils.NameTokens(q)).Find(&candidats).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, candidat := range candidats {
+			t.Errorf("q=%q: candidat inattendu %s (%s)", q, candidat.UUID, candidat.NumeroPasseport)
+		}
+	}
+
+	var candidats []models.Identite
+	if err := preselection(db, "OP0000002", []string{"OP0000002"}).Find(&candidats).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(candidats) == 0 || candidats[0].UUID != "identite-002" {
+		t.Errorf("passeport exact non retrouvé en tête: %v", candidats)
+	}
+}
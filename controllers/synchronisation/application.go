@@ -0,0 +1,445 @@
+package synchronisation
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/biometrics"
+	"github.com/kgermando/sysmobembo-api/controllers/identites"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/controllers/watchlist"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Operation est une création, une modification ou une suppression faite hors ligne sur une tablette
+type Operation struct {
+	Type   string `json:"type"`   // identite, migrant ou biometrie
+	Action string `json:"action"` // upsert ou delete
+	UUID   string `json:"uuid"`   // UUID généré par la tablette
+	// Horodatage local de la modification, conservé pour la revue des conflits
+	ModifieLe time.Time `json:"modifie_le"`
+	// Version serveur (champ version) sur laquelle la tablette a travaillé, absente pour une création
+	Base    *int64          `json:"base"`
+	Donnees json.RawMessage `json:"donnees"`
+}
+
+// Resultat d'une opération renvoyé à la tablette
+type Resultat struct {
+	Type        string `json:"type"`
+	UUID        string `json:"uuid"`
+	Statut      string `json:"statut"`
+	Message     string `json:"message"`
+	ConflitUUID string `json:"conflit_uuid"`
+	// Nouvelle version serveur, à renvoyer comme base lors de la prochaine modification
+	Base *int64 `json:"base"`
+}
+
+// Statuts des opérations
+const (
+	statutApplique     = "applique"
+	statutDejaApplique = "deja_applique"
+	statutConflit      = "conflit"
+	statutErreur       = "erreur"
+)
+
+// Ordre d'application : une identité avant ses migrants, un migrant avant ses biométries
+var prioriteTypes = map[string]int{
+	"identite":  0,
+	"migrant":   1,
+	"biometrie": 2,
+}
+
+// ordonner applique les créations et modifications avant les suppressions, parents d'abord,
+// en conservant l'ordre de la tablette pour un même type
+func ordonner(operations []Operation) {
+	rang := func(op Operation) int {
+		r := prioriteTypes[op.Type]
+		if op.Action == "delete" {
+			r += len(prioriteTypes)
+		}
+		return r
+	}
+	sort.SliceStable(operations, func(i, j int) bool {
+		return rang(operations[i]) < rang(operations[j])
+	})
+}
+
+// executeur applique les opérations d'une tablette pour le compte d'un agent
+type executeur struct {
+	db *gorm.DB
+	// Requête de l'agent qui pousse ou résout : contrôle d'accès aux enregistrements existants
+	c        *fiber.Ctx
+	userUUID string
+	// Unité propriétaire des enregistrements créés
+	unite    models.UniteOrganisationnelle
+	appareil string
+	// Ignorer la version de base (résolution "appliquer" d'une modification concurrente)
+	forcer bool
+}
+
+// executer applique une opération ; un conflit non nil doit être mis en file par l'appelant
+func (e *executeur) executer(op Operation) (Resultat, *models.ConflitSynchro) {
+	if op.UUID == "" {
+		return erreur(op, "uuid is required")
+	}
+	if _, ok := prioriteTypes[op.Type]; !ok {
+		return erreur(op, fmt.Sprintf("Unknown record type %q", op.Type))
+	}
+
+	switch op.Action {
+	case "upsert":
+		switch op.Type {
+		case "identite":
+			return e.identite(op)
+		case "migrant":
+			return e.migrant(op)
+		default:
+			return e.biometrie(op)
+		}
+	case "delete":
+		return e.supprimer(op)
+	default:
+		return erreur(op, fmt.Sprintf("Unknown action %q", op.Action))
+	}
+}
+
+func (e *executeur) identite(op Operation) (Resultat, *models.ConflitSynchro) {
+	var donnees models.Identite
+	if err := json.Unmarshal(op.Donnees, &donnees); err != nil {
+		return erreur(op, "Invalid identite data")
+	}
+	donnees.UUID = op.UUID
+	donnees.Migrants, donnees.Geolocalisations = nil, nil
+
+	var messages []string
+	for _, err := range utils.ValidateStruct(donnees) {
+		messages = append(messages, fmt.Sprintf("Erreur de validation pour %s: %s", err.FailedField, err.Tag))
+	}
+	erreursDates, _ := identites.VerifierDatesDocument(donnees)
+	messages = append(messages, erreursDates...)
+	if len(messages) > 0 {
+		return erreur(op, strings.Join(messages, "; "))
+	}
+
+	var existant models.Identite
+	trouve := e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error == nil
+	if trouve {
+		if op.Base == nil && !e.forcer {
+			return dejaApplique(op)
+		}
+		if !unites.AccederIdentite(e.c, existant) {
+			return erreur(op, "This record belongs to another organisational unit")
+		}
+		if res, conflit, ok := e.verifierVersion(op, existant.Version, existant.DeletedAt); !ok {
+			return res, conflit
+		}
+	}
+
+	// Numéro de passeport déjà enregistré par un autre poste : l'index unique ferait échouer l'enregistrement
+	var doublon models.Identite
+	if e.db.Unscoped().Where("numero_passeport = ? AND uuid <> ?", donnees.NumeroPasseport, op.UUID).First(&doublon).Error == nil {
+		return e.conflit(op, models.ConflitPasseportDuplique, doublon.UUID, "",
+			fmt.Sprintf("Passport number %s is already registered", donnees.NumeroPasseport))
+	}
+
+	if trouve {
+		donnees.UniteUUID = existant.UniteUUID
+		if err := e.db.Unscoped().Model(&existant).Updates(&donnees).Error; err != nil {
+			return echec(op, err)
+		}
+		e.restaurer(&existant, existant.DeletedAt)
+	} else {
+		donnees.UniteUUID = e.unite.UUID
+		if err := e.db.Create(&donnees).Error; err != nil {
+			return echec(op, err)
+		}
+	}
+
+	// Criblage contre les listes de surveillance, comme pour une saisie en ligne
+	_, err := watchlist.NewCriblage(e.db).Cribler(donnees)
+	return e.appliqueCrible(op, &models.Identite{}, err)
+}
+
+func (e *executeur) migrant(op Operation) (Resultat, *models.ConflitSynchro) {
+	var donnees models.Migrant
+	if err := json.Unmarshal(op.Donnees, &donnees); err != nil {
+		return erreur(op, "Invalid migrant data")
+	}
+	donnees.UUID = op.UUID
+	donnees.Identite = models.Identite{}
+	donnees.MotifDeplacements, donnees.Alertes, donnees.Biometries = nil, nil, nil
+
+	// L'identité de la tablette a pu être rattachée à une identité existante lors d'une résolution
+	donnees.IdentiteUUID = identiteRattachee(e.db, donnees.IdentiteUUID)
+	if res, conflit, ok := e.verifierParent(op, &models.Identite{}, "identite", donnees.IdentiteUUID); !ok {
+		return res, conflit
+	}
+
+	var existant models.Migrant
+	if e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error == nil {
+		if op.Base == nil && !e.forcer {
+			return dejaApplique(op)
+		}
+		if !unites.Acceder(e.c, existant.UniteUUID, "migrant", existant.UUID) {
+			return erreur(op, "This record belongs to another organisational unit")
+		}
+		if res, conflit, ok := e.verifierVersion(op, existant.Version, existant.DeletedAt); !ok {
+			return res, conflit
+		}
+
+		// Conserver le numéro, le bureau et l'unité propriétaire existants
+		donnees.NumeroIdentifiant = existant.NumeroIdentifiant
+		donnees.BureauEnregistrement = existant.BureauEnregistrement
+		donnees.UniteUUID = existant.UniteUUID
+
+		db := models.AvecContexteStatut(e.db, models.ContexteStatut{
+			Motif:       "Synchronisation hors ligne",
+			EffectuePar: e.userUUID,
+		})
+		if err := db.Unscoped().Model(&existant).Updates(donnees).Error; err != nil {
+			return echec(op, err)
+		}
+		e.restaurer(&existant, existant.DeletedAt)
+		return e.applique(op, &models.Migrant{})
+	}
+
+	// Le migrant appartient à l'unité de l'agent, dont le code sert de bureau par défaut
+	donnees.UniteUUID = e.unite.UUID
+	if donnees.BureauEnregistrement == "" {
+		donnees.BureauEnregistrement = e.unite.Code
+	}
+	donnees.BureauEnregistrement = models.CodeBureau(donnees.BureauEnregistrement)
+
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		numeros, err := models.NumerotationMigrants.Reserver(tx, donnees.BureauEnregistrement, 1)
+		if err != nil {
+			return err
+		}
+		donnees.NumeroIdentifiant = numeros[0]
+		return tx.Create(&donnees).Error
+	})
+	if err != nil {
+		return echec(op, err)
+	}
+
+	var identite models.Identite
+	err = e.db.Where("uuid = ?", donnees.IdentiteUUID).First(&identite).Error
+	if err == nil {
+		_, err = watchlist.NewCriblage(e.db).Cribler(identite)
+	}
+	return e.appliqueCrible(op, &models.Migrant{}, err)
+}
+
+func (e *executeur) biometrie(op Operation) (Resultat, *models.ConflitSynchro) {
+	var donnees models.Biometrie
+	if err := json.Unmarshal(op.Donnees, &donnees); err != nil {
+		return erreur(op, "Invalid biometrie data")
+	}
+	donnees.UUID = op.UUID
+	donnees.Migrant = models.Migrant{}
+
+	if res, conflit, ok := e.verifierParent(op, &models.Migrant{}, "migrant", donnees.MigrantUUID); !ok {
+		return res, conflit
+	}
+
+	var existant models.Biometrie
+	if e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error == nil {
+		if op.Base == nil && !e.forcer {
+			return dejaApplique(op)
+		}
+		if !unites.AccederMigrant(e.c, existant.MigrantUUID, "biometrie", existant.UUID) {
+			return erreur(op, "This record belongs to another organisational unit")
+		}
+		if res, conflit, ok := e.verifierVersion(op, existant.Version, existant.DeletedAt); !ok {
+			return res, conflit
+		}
+
+		// Seules les métadonnées sont modifiables, comme pour UpdateBiometrie
+		err := e.db.Unscoped().Model(&existant).Updates(models.Biometrie{
+			QualiteDonnee:     donnees.QualiteDonnee,
+			DisposifCapture:   donnees.DisposifCapture,
+			ResolutionCapture: donnees.ResolutionCapture,
+			OperateurCapture:  donnees.OperateurCapture,
+		}).Error
+		if err != nil {
+			return echec(op, err)
+		}
+		e.restaurer(&existant, existant.DeletedAt)
+		return e.applique(op, &models.Biometrie{})
+	}
+
+	if !unites.AccederMigrant(e.c, donnees.MigrantUUID, "migrant", donnees.MigrantUUID) {
+		return erreur(op, "This record belongs to another organisational unit")
+	}
+	if err := biometrics.PreparerBiometrie(&donnees); err != nil {
+		return erreur(op, err.Error())
+	}
+	if err := e.db.Create(&donnees).Error; err != nil {
+		return echec(op, err)
+	}
+
+	return e.applique(op, &models.Biometrie{})
+}
+
+// supprimer applique une suppression (soft delete) faite sur la tablette
+func (e *executeur) supprimer(op Operation) (Resultat, *models.ConflitSynchro) {
+	var modele interface{}
+	var uniteAutorisee bool
+	var version int64
+	var supprime gorm.DeletedAt
+
+	switch op.Type {
+	case "identite":
+		var existant models.Identite
+		if e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error != nil || existant.DeletedAt.Valid {
+			return dejaApplique(op)
+		}
+		modele, version, supprime = &existant, existant.Version, existant.DeletedAt
+		uniteAutorisee = unites.AccederIdentite(e.c, existant)
+	case "migrant":
+		var existant models.Migrant
+		if e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error != nil || existant.DeletedAt.Valid {
+			return dejaApplique(op)
+		}
+		modele, version, supprime = &existant, existant.Version, existant.DeletedAt
+		uniteAutorisee = unites.Acceder(e.c, existant.UniteUUID, "migrant", existant.UUID)
+	default:
+		var existant models.Biometrie
+		if e.db.Unscoped().Where("uuid = ?", op.UUID).First(&existant).Error != nil || existant.DeletedAt.Valid {
+			return dejaApplique(op)
+		}
+		modele, version, supprime = &existant, existant.Version, existant.DeletedAt
+		uniteAutorisee = unites.AccederMigrant(e.c, existant.MigrantUUID, "biometrie", existant.UUID)
+	}
+
+	if !uniteAutorisee {
+		return erreur(op, "This record belongs to another organisational unit")
+	}
+	if res, conflit, ok := e.verifierVersion(op, version, supprime); !ok {
+		return res, conflit
+	}
+	if err := e.db.Delete(modele).Error; err != nil {
+		return echec(op, err)
+	}
+
+	return Resultat{Type: op.Type, UUID: op.UUID, Statut: statutApplique}, nil
+}
+
+// verifierVersion détecte un enregistrement modifié ou supprimé sur le serveur depuis la
+// version sur laquelle la tablette a travaillé
+func (e *executeur) verifierVersion(op Operation, version int64, supprime gorm.DeletedAt) (Resultat, *models.ConflitSynchro, bool) {
+	if e.forcer {
+		return Resultat{}, nil, true
+	}
+	if supprime.Valid {
+		res, conflit := e.conflit(op, models.ConflitModificationConcurrente, op.UUID, "",
+			"Record deleted on the server since the last synchronisation")
+		return res, conflit, false
+	}
+	if op.Base != nil && version != *op.Base {
+		res, conflit := e.conflit(op, models.ConflitModificationConcurrente, op.UUID, "",
+			"Record modified on the server since the last synchronisation")
+		return res, conflit, false
+	}
+	return Resultat{}, nil, true
+}
+
+// verifierParent s'assure que l'enregistrement référencé existe ; s'il attend lui-même
+// la résolution d'un conflit, l'opération est mise en file derrière lui
+func (e *executeur) verifierParent(op Operation, modele interface{}, typeParent, parentUUID string) (Resultat, *models.ConflitSynchro, bool) {
+	var count int64
+	e.db.Model(modele).Where("uuid = ?", parentUUID).Count(&count)
+	if count > 0 {
+		return Resultat{}, nil, true
+	}
+
+	var parent models.ConflitSynchro
+	if e.db.Where("entite_uuid = ? AND statut = ?", parentUUID, "en_attente").First(&parent).Error == nil {
+		res, conflit := e.conflit(op, models.ConflitDependance, "", parent.UUID,
+			fmt.Sprintf("Waiting for the resolution of the %s conflict", typeParent))
+		return res, conflit, false
+	}
+
+	res, conflit := erreur(op, fmt.Sprintf("Unknown %s %s", typeParent, parentUUID))
+	return res, conflit, false
+}
+
+// restaurer annule la suppression d'un enregistrement réappliqué par une résolution
+func (e *executeur) restaurer(modele interface{}, supprime gorm.DeletedAt) {
+	if supprime.Valid {
+		e.db.Unscoped().Model(modele).Update("deleted_at", nil)
+	}
+}
+
+// applique renvoie la nouvelle version serveur de l'enregistrement
+func (e *executeur) applique(op Operation, modele interface{}) (Resultat, *models.ConflitSynchro) {
+	var version int64
+	e.db.Unscoped().Model(modele).Select("version").Where("uuid = ?", op.UUID).Scan(&version)
+
+	return Resultat{Type: op.Type, UUID: op.UUID, Statut: statutApplique, Base: &version}, nil
+}
+
+// appliqueCrible retourne le résultat d'une opération appliquée, en avertissant le
+// client si le criblage qui l'a suivie a échoué
+func (e *executeur) appliqueCrible(op Operation, modele interface{}, errCriblage error) (Resultat, *models.ConflitSynchro) {
+	resultat, conflit := e.applique(op, modele)
+	if errCriblage != nil {
+		resultat.Message = depots.AvertissementCriblage
+	}
+	return resultat, conflit
+}
+
+func (e *executeur) conflit(op Operation, typeConflit, existantUUID, parentUUID, message string) (Resultat, *models.ConflitSynchro) {
+	brut, _ := json.Marshal(op)
+	return Resultat{Type: op.Type, UUID: op.UUID, Statut: statutConflit, Message: message},
+		&models.ConflitSynchro{
+			TypeConflit:  typeConflit,
+			TypeEntite:   op.Type,
+			EntiteUUID:   op.UUID,
+			ExistantUUID: existantUUID,
+			ParentUUID:   parentUUID,
+			Message:      message,
+			Operation:    string(brut),
+			AppareilID:   e.appareil,
+			UserUUID:     e.userUUID,
+			UniteUUID:    e.unite.UUID,
+			Statut:       "en_attente",
+		}
+}
+
+func dejaApplique(op Operation) (Resultat, *models.ConflitSynchro) {
+	return Resultat{Type: op.Type, UUID: op.UUID, Statut: statutDejaApplique}, nil
+}
+
+func erreur(op Operation, message string) (Resultat, *models.ConflitSynchro) {
+	return Resultat{Type: op.Type, UUID: op.UUID, Statut: statutErreur, Message: message}, nil
+}
+
+// echec - résultat d'une opération refusée par la base : la tablette reçoit le titre
+// du problème (doublon, référence, ...), l'erreur d'origine est journalisée
+func echec(op Operation, err error) (Resultat, *models.ConflitSynchro) {
+	p := problemes.Depuis(err)
+	log.Printf("❌ Synchronisation %s %s: %v", op.Type, op.UUID, err)
+	return erreur(op, problemes.Titre(p.Code, problemes.Anglais))
+}
+
+// identiteRattachee retourne l'identité existante à laquelle une identité de tablette
+// a été rattachée lors de la résolution d'un conflit de passeport
+func identiteRattachee(db *gorm.DB, identiteUUID string) string {
+	var conflit models.ConflitSynchro
+	err := db.Where("entite_uuid = ? AND type_conflit = ? AND resolution = ?",
+		identiteUUID, models.ConflitPasseportDuplique, "rattacher").First(&conflit).Error
+	if err != nil {
+		return identiteUUID
+	}
+	return conflit.ExistantUUID
+}
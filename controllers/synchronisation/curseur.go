@@ -0,0 +1,78 @@
+package synchronisation
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Délai pendant lequel les modifications récentes ne sont pas encore servies : une
+// transaction en cours peut encore valider un enregistrement horodaté avant le curseur
+const margeStabilite = 5 * time.Second
+
+// position est le dernier enregistrement transmis pour un type, trié par date de modification puis UUID
+type position struct {
+	Horodatage time.Time `json:"t"`
+	UUID       string    `json:"u"`
+}
+
+// curseur opaque renvoyé à la tablette : une position par type d'enregistrement
+type curseur map[string]position
+
+func lireCurseur(valeur string) (curseur, error) {
+	cur := curseur{}
+	if valeur == "" {
+		return cur, nil
+	}
+	brut, err := base64.RawURLEncoding.DecodeString(valeur)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(brut, &cur); err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+func (cur curseur) encoder() string {
+	brut, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(brut)
+}
+
+// tirer lit les enregistrements modifiés ou supprimés après la position, jusqu'à l'horizon.
+// cle retourne la date de modification et l'UUID d'un enregistrement ; le booléen
+// indique s'il reste des modifications à lire.
+func tirer[T any](base *gorm.DB, table string, pos position, horizon time.Time, limit int, cle func(*T) (time.Time, string)) ([]T, position, bool, error) {
+	modifie := fmt.Sprintf("GREATEST(%[1]s.updated_at, COALESCE(%[1]s.deleted_at, %[1]s.updated_at))", table)
+
+	var lignes []T
+	err := base.Unscoped().
+		Where(fmt.Sprintf("(%s, %s.uuid) > (?, ?)", modifie, table), pos.Horodatage, pos.UUID).
+		Where(modifie+" <= ?", horizon).
+		Order(modifie + ", " + table + ".uuid").
+		Limit(limit + 1).
+		Find(&lignes).Error
+	if err != nil {
+		return nil, pos, false, err
+	}
+
+	suite := len(lignes) > limit
+	if suite {
+		lignes = lignes[:limit]
+	}
+	if len(lignes) > 0 {
+		pos.Horodatage, pos.UUID = cle(&lignes[len(lignes)-1])
+	}
+	return lignes, pos, suite, nil
+}
+
+// dateModification est la date de dernière modification, suppression comprise
+func dateModification(misAJour time.Time, supprime gorm.DeletedAt) time.Time {
+	if supprime.Valid && supprime.Time.After(misAJour) {
+		return supprime.Time
+	}
+	return misAJour
+}
@@ -0,0 +1,464 @@
+package synchronisation
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Nombre maximal d'opérations par lot poussé
+const tailleMaxLot = 500
+
+// =======================
+// ENVOI (PUSH)
+// =======================
+
+// PushChanges - Appliquer un lot d'opérations faites hors ligne
+// POST /api/sync/push  {"appareil_id": "...", "operations": [{"type", "action", "uuid", "modifie_le", "base", "donnees"}]}
+func PushChanges(c *fiber.Ctx) error {
+	db := database.DB
+
+	var lot struct {
+		AppareilID string      `json:"appareil_id"`
+		Operations []Operation `json:"operations"`
+	}
+	if err := c.BodyParser(&lot); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if lot.AppareilID == "" {
+		return problemes.Invalide("appareil_id is required")
+	}
+	if len(lot.Operations) == 0 || len(lot.Operations) > tailleMaxLot {
+		return problemes.Invalide("A batch must contain between 1 and " + strconv.Itoa(tailleMaxLot) + " operations")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	e := &executeur{
+		db:       db,
+		c:        c,
+		userUUID: userUUID,
+		unite:    unites.UniteDe(c),
+		appareil: lot.AppareilID,
+	}
+
+	ordonner(lot.Operations)
+
+	resultats := make([]Resultat, 0, len(lot.Operations))
+	resume := map[string]int{}
+	for _, op := range lot.Operations {
+		var res Resultat
+
+		// Lot renvoyé après une coupure : l'opération attend déjà en file
+		var enAttente models.ConflitSynchro
+		if db.Where("entite_uuid = ? AND appareil_id = ? AND statut = ?", op.UUID, lot.AppareilID, "en_attente").
+			First(&enAttente).Error == nil {
+			res = Resultat{Type: op.Type, UUID: op.UUID, Statut: statutConflit, Message: enAttente.Message, ConflitUUID: enAttente.UUID}
+		} else {
+			var conflit *models.ConflitSynchro
+			res, conflit = e.executer(op)
+			if conflit != nil {
+				conflit.UUID = utils.GenerateUUID()
+				if err := db.Create(conflit).Error; err != nil {
+					res, _ = echec(op, err)
+				} else {
+					res.ConflitUUID = conflit.UUID
+				}
+			}
+		}
+
+		resume[res.Statut]++
+		resultats = append(resultats, res)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Synchronisation batch processed",
+		"data": fiber.Map{
+			"resultats": resultats,
+			"resume":    resume,
+		},
+	})
+}
+
+// =======================
+// RÉCEPTION (PULL)
+// =======================
+
+// Suppression transmise à la tablette
+type suppression struct {
+	Type string `json:"type"`
+	UUID string `json:"uuid"`
+}
+
+// PullChanges - Récupérer les modifications du périmètre de l'agent depuis le curseur
+// GET /api/sync/pull?curseur=&appareil_id=&limit=200
+func PullChanges(c *fiber.Ctx) error {
+	db := database.DB
+	p := unites.PerimetreDe(c)
+
+	limit, err := strconv.Atoi(c.Query("limit", "200"))
+	if err != nil || limit <= 0 {
+		limit = 200
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	cur, err := lireCurseur(c.Query("curseur", ""))
+	if err != nil {
+		return problemes.Invalide("Invalid cursor").Cause(err)
+	}
+
+	horizon := time.Now().Add(-margeStabilite)
+	suppressions := []suppression{}
+	complet := true
+
+	identitesModifiees, pos, suite, err := tirer(db.Scopes(p.Identites), "identites", cur["identites"], horizon, limit,
+		func(i *models.Identite) (time.Time, string) {
+			return dateModification(i.UpdatedAt, i.DeletedAt), i.UUID
+		})
+	if err != nil {
+		return problemes.Echec("Failed to fetch changes", err)
+	}
+	cur["identites"], complet = pos, complet && !suite
+	identitesActives := []models.Identite{}
+	for _, identite := range identitesModifiees {
+		if identite.DeletedAt.Valid {
+			suppressions = append(suppressions, suppression{Type: "identite", UUID: identite.UUID})
+		} else {
+			identitesActives = append(identitesActives, identite)
+		}
+	}
+
+	migrantsModifies, pos, suite, err := tirer(db.Scopes(p.Migrants), "migrants", cur["migrants"], horizon, limit,
+		func(m *models.Migrant) (time.Time, string) { return dateModification(m.UpdatedAt, m.DeletedAt), m.UUID })
+	if err != nil {
+		return problemes.Echec("Failed to fetch changes", err)
+	}
+	cur["migrants"], complet = pos, complet && !suite
+	migrantsActifs := []models.Migrant{}
+	for _, migrant := range migrantsModifies {
+		if migrant.DeletedAt.Valid {
+			suppressions = append(suppressions, suppression{Type: "migrant", UUID: migrant.UUID})
+		} else {
+			migrantsActifs = append(migrantsActifs, migrant)
+		}
+	}
+
+	// Les gabarits biométriques chiffrés ne sont pas redescendus sur les tablettes
+	biometriesModifiees, pos, suite, err := tirer(db.Scopes(p.ParMigrant("biometries.migrant_uuid")).Omit("donnees_biometriques"),
+		"biometries", cur["biometries"], horizon, limit,
+		func(b *models.Biometrie) (time.Time, string) {
+			return dateModification(b.UpdatedAt, b.DeletedAt), b.UUID
+		})
+	if err != nil {
+		return problemes.Echec("Failed to fetch changes", err)
+	}
+	cur["biometries"], complet = pos, complet && !suite
+	biometriesActives := []models.Biometrie{}
+	for _, biometrie := range biometriesModifiees {
+		if biometrie.DeletedAt.Valid {
+			suppressions = append(suppressions, suppression{Type: "biometrie", UUID: biometrie.UUID})
+		} else {
+			biometriesActives = append(biometriesActives, biometrie)
+		}
+	}
+
+	// Conflits de la tablette : file d'attente, rattachements et rejets décidés par les agents
+	conflits := []models.ConflitSynchro{}
+	if appareil := c.Query("appareil_id", ""); appareil != "" {
+		conflits, pos, suite, err = tirer(db.Scopes(p.ParUnite("sync_conflicts.unite_uuid")).Where("sync_conflicts.appareil_id = ?", appareil),
+			"sync_conflicts", cur["conflits"], horizon, limit,
+			func(k *models.ConflitSynchro) (time.Time, string) {
+				return dateModification(k.UpdatedAt, k.DeletedAt), k.UUID
+			})
+		if err != nil {
+			return problemes.Echec("Failed to fetch changes", err)
+		}
+		cur["conflits"], complet = pos, complet && !suite
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Changes retrieved successfully",
+		"data": fiber.Map{
+			"identites":    identitesActives,
+			"migrants":     migrantsActifs,
+			"biometries":   biometriesActives,
+			"suppressions": suppressions,
+			"conflits":     conflits,
+			"curseur":      cur.encoder(),
+			// false : d'autres modifications attendent, la tablette doit rappeler avec le nouveau curseur
+			"complet": complet,
+		},
+	})
+}
+
+// =======================
+// FILE DES CONFLITS
+// =======================
+
+// Champs des conflits de synchronisation pouvant être filtrés, triés et sélectionnés
+var listeConflits = requetes.Schema{
+	Table: "sync_conflicts",
+	Champs: map[string]requetes.Type{
+		"uuid":          requetes.Texte,
+		"created_at":    requetes.Date,
+		"updated_at":    requetes.Date,
+		"type_conflit":  requetes.Texte,
+		"type_entite":   requetes.Texte,
+		"entite_uuid":   requetes.Texte,
+		"existant_uuid": requetes.Texte,
+		"parent_uuid":   requetes.Texte,
+		"appareil_id":   requetes.Texte,
+		"user_uuid":     requetes.Texte,
+		"unite_uuid":    requetes.Texte,
+		"statut":        requetes.Texte,
+		"resolution":    requetes.Texte,
+		"resolu_par":    requetes.Texte,
+		"date_revue":    requetes.Date,
+	},
+	TriDefaut: "created_at",
+}
+
+// GetPaginatedConflits - Récupérer la file des conflits de synchronisation du périmètre
+func GetPaginatedConflits(c *fiber.Ctx) error {
+	liste, erreurs := requetes.Lire(c, listeConflits)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	db := database.DB.Scopes(unites.PerimetreDe(c).ParUnite("sync_conflicts.unite_uuid"))
+
+	typeConflit := c.Query("type_conflit", "")
+	appareil := c.Query("appareil_id", "")
+
+	var conflits []models.ConflitSynchro
+
+	query := db.Model(&models.ConflitSynchro{})
+	if !liste.Filtree("statut") {
+		if statut := c.Query("statut", "en_attente"); statut != "" {
+			query = query.Where("sync_conflicts.statut = ?", statut)
+		}
+	}
+	if typeConflit != "" {
+		query = query.Where("sync_conflicts.type_conflit = ?", typeConflit)
+	}
+	if appareil != "" {
+		query = query.Where("sync_conflicts.appareil_id = ?", appareil)
+	}
+
+	data, pagination, err := liste.Executer(query, &conflits)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch sync conflicts", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Sync conflicts retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// GetConflit - Récupérer un conflit avec l'enregistrement serveur concurrent
+func GetConflit(c *fiber.Ctx) error {
+	db := database.DB
+
+	var conflit models.ConflitSynchro
+	if err := db.Where("uuid = ?", c.Params("uuid")).First(&conflit).Error; err != nil {
+		return problemes.Absent("Sync conflict not found", err)
+	}
+
+	if !unites.Acceder(c, conflit.UniteUUID, "conflit_synchro", conflit.UUID) {
+		return unites.Refus(c)
+	}
+
+	var existant interface{}
+	if conflit.ExistantUUID != "" {
+		switch conflit.TypeEntite {
+		case "identite":
+			var identite models.Identite
+			if db.Unscoped().Where("uuid = ?", conflit.ExistantUUID).First(&identite).Error == nil {
+				existant = identite
+			}
+		case "migrant":
+			var migrant models.Migrant
+			if db.Unscoped().Where("uuid = ?", conflit.ExistantUUID).First(&migrant).Error == nil {
+				existant = migrant
+			}
+		case "biometrie":
+			var biometrie models.Biometrie
+			if db.Unscoped().Omit("donnees_biometriques").Where("uuid = ?", conflit.ExistantUUID).First(&biometrie).Error == nil {
+				existant = biometrie
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Sync conflict found",
+		"data": fiber.Map{
+			"conflit":  conflit,
+			"existant": existant,
+		},
+	})
+}
+
+// ResolveConflit - Décider d'un conflit de synchronisation
+// PUT /api/sync/conflicts/resolve/:uuid  {"resolution": "rattacher|appliquer|rejeter", "commentaire": "..."}
+//   - rattacher : l'identité de la tablette est celle déjà enregistrée (passeport dupliqué)
+//   - appliquer : la version de la tablette remplace celle du serveur (modification concurrente)
+//   - rejeter   : l'opération est abandonnée, ainsi que celles qui en dépendent
+func ResolveConflit(c *fiber.Ctx) error {
+	db := database.DB
+
+	var body struct {
+		Resolution  string `json:"resolution" validate:"required,oneof=rattacher appliquer rejeter"`
+		Commentaire string `json:"commentaire"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+	if err := utils.ValidateStruct(body); err != nil {
+		return problemes.Validation(err)
+	}
+
+	var conflit models.ConflitSynchro
+	if err := db.Where("uuid = ?", c.Params("uuid")).First(&conflit).Error; err != nil {
+		return problemes.Absent("Sync conflict not found", err)
+	}
+
+	if !unites.Acceder(c, conflit.UniteUUID, "conflit_synchro", conflit.UUID) {
+		return unites.Refus(c)
+	}
+
+	if conflit.Statut != "en_attente" {
+		return problemes.Invalide("Only pending conflicts can be resolved")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+
+	switch body.Resolution {
+	case "rattacher":
+		if conflit.TypeConflit != models.ConflitPasseportDuplique || conflit.TypeEntite != "identite" {
+			return problemes.Invalide("Only duplicate passport conflicts can be linked to the existing identity")
+		}
+		var existant models.Identite
+		if db.Where("uuid = ?", conflit.ExistantUUID).First(&existant).Error != nil {
+			return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "The existing identity is no longer available")
+		}
+		if !unites.AccederIdentite(c, existant) {
+			return unites.Refus(c)
+		}
+		if err := cloturer(db, &conflit, "resolu", "rattacher", userUUID, body.Commentaire); err != nil {
+			return problemes.Echec("Failed to resolve sync conflict", err)
+		}
+
+	case "appliquer":
+		if conflit.TypeConflit != models.ConflitModificationConcurrente {
+			return problemes.Invalide("Only concurrent modification conflicts can be applied")
+		}
+		var op Operation
+		if err := json.Unmarshal([]byte(conflit.Operation), &op); err != nil {
+			return problemes.Echec("Failed to resolve sync conflict", err)
+		}
+		e := executeurConflit(c, db, &conflit)
+		e.forcer = true
+		res, nouveau := e.executer(op)
+		if nouveau != nil || res.Statut == statutErreur {
+			return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "The tablet version cannot be applied").
+				Cause(errors.New(res.Message))
+		}
+		if err := cloturer(db, &conflit, "resolu", "appliquer", userUUID, body.Commentaire); err != nil {
+			return problemes.Echec("Failed to resolve sync conflict", err)
+		}
+
+	default:
+		if err := cloturer(db, &conflit, "rejete", "rejeter", userUUID, body.Commentaire); err != nil {
+			return problemes.Echec("Failed to resolve sync conflict", err)
+		}
+	}
+
+	// Les opérations en attente derrière ce conflit sont rejouées ou rejetées avec lui
+	rejouerDependants(c, db, &conflit, body.Resolution == "rejeter", userUUID)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Sync conflict resolved",
+		"data":    conflit,
+	})
+}
+
+// executeurConflit rejoue une opération pour le compte de l'unité qui l'a poussée
+func executeurConflit(c *fiber.Ctx, db *gorm.DB, conflit *models.ConflitSynchro) *executeur {
+	var unite models.UniteOrganisationnelle
+	db.Where("uuid = ?", conflit.UniteUUID).First(&unite)
+	return &executeur{
+		db:       db,
+		c:        c,
+		userUUID: conflit.UserUUID,
+		unite:    unite,
+		appareil: conflit.AppareilID,
+	}
+}
+
+func cloturer(db *gorm.DB, conflit *models.ConflitSynchro, statut, resolution, userUUID, commentaire string) error {
+	now := time.Now()
+	return db.Model(conflit).Updates(map[string]interface{}{
+		"statut":      statut,
+		"resolution":  resolution,
+		"resolu_par":  userUUID,
+		"date_revue":  &now,
+		"commentaire": commentaire,
+	}).Error
+}
+
+// rejouerDependants applique ou rejette les opérations mises en file derrière un conflit résolu
+func rejouerDependants(c *fiber.Ctx, db *gorm.DB, parent *models.ConflitSynchro, rejeter bool, userUUID string) {
+	var dependants []models.ConflitSynchro
+	db.Where("parent_uuid = ? AND statut = ?", parent.UUID, "en_attente").Order("created_at").Find(&dependants)
+
+	for i := range dependants {
+		dependant := &dependants[i]
+		if rejeter {
+			cloturer(db, dependant, "rejete", "rejeter", userUUID, "Rejected with conflict "+parent.UUID)
+			rejouerDependants(c, db, dependant, true, userUUID)
+			continue
+		}
+
+		var op Operation
+		if err := json.Unmarshal([]byte(dependant.Operation), &op); err != nil {
+			continue
+		}
+		res, nouveau := executeurConflit(c, db, dependant).executer(op)
+		switch {
+		case nouveau != nil:
+			// Toujours bloquée : le conflit reste en file avec sa nouvelle cause
+			db.Model(dependant).Updates(map[string]interface{}{
+				"type_conflit":  nouveau.TypeConflit,
+				"existant_uuid": nouveau.ExistantUUID,
+				"parent_uuid":   nouveau.ParentUUID,
+				"message":       nouveau.Message,
+			})
+		case res.Statut == statutErreur:
+			cloturer(db, dependant, "rejete", "rejeter", userUUID, res.Message)
+			rejouerDependants(c, db, dependant, true, userUUID)
+		default:
+			cloturer(db, dependant, "resolu", "appliquer", userUUID, "Applied after conflict "+parent.UUID)
+			rejouerDependants(c, db, dependant, false, userUUID)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package synchronisation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"gorm.io/gorm"
+)
+
+// environnementSync crée deux unités et un agent de la première, et monte les routes
+// d'envoi et de réception
+func environnementSync(t *testing.T) (*gorm.DB, func(methode, url string, corps interface{}, reponse interface{}) int) {
+	t.Helper()
+	db := basetest.Migree(t)
+	database.DB = db
+
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID: "unite-" + code, Code: code, Nom: "Direction " + code,
+			TypeUnite: "direction_provinciale", Actif: true, Chemin: "/unite-" + code + "/",
+		}
+		if err := db.Create(&unite).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	agent := basetest.Utilisateur(t, db, "Agent", "unite-A")
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Post("/push", PushChanges)
+	app.Get("/pull", PullChanges)
+	return db, func(methode, url string, corps interface{}, reponse interface{}) int {
+		t.Helper()
+		var contenu bytes.Buffer
+		if corps != nil {
+			json.NewEncoder(&contenu).Encode(corps)
+		}
+		separateur := "?"
+		if strings.Contains(url, "?") {
+			separateur = "&"
+		}
+		req := httptest.NewRequest(methode, url+separateur+"token="+agent, &contenu)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reponse != nil {
+			if err := json.NewDecoder(resp.Body).Decode(reponse); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+}
+
+// donneesIdentite retourne une identité valide saisie sur la tablette
+func donneesIdentite(lieu string) map[string]interface{} {
+	emission := time.Now().AddDate(-1, 0, 0)
+	return map[string]interface{}{
+		"nom": "Ilunga", "postnom": "Kasongo", "prenom": "Paul", "sexe": "M",
+		"date_naissance": time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC), "lieu_naissance": lieu,
+		"nationalite": "Congolaise", "pays_emetteur": "RDC", "autorite_emetteur": "DGM",
+		"date_emission": emission, "date_expiration": emission.AddDate(5, 0, 0), "numero_passeport": "OB0000009",
+	}
+}
+
+// La version serveur renvoyée par chaque envoi sert de base au suivant ; une
+// modification faite sur le serveur entre-temps met l'opération en conflit
+func TestPushConflitsDeVersion(t *testing.T) {
+	db, appeler := environnementSync(t)
+
+	pousser := func(base *int64, lieu string) Resultat {
+		t.Helper()
+		var reponse struct {
+			Data struct {
+				Resultats []Resultat `json:"resultats"`
+			} `json:"data"`
+		}
+		statut := appeler("POST", "/push", map[string]interface{}{
+			"appareil_id": "tablette-1",
+			"operations": []map[string]interface{}{{
+				"type": "identite", "action": "upsert", "uuid": "identite-tablette",
+				"modifie_le": time.Now(), "base": base, "donnees": donneesIdentite(lieu),
+			}},
+		}, &reponse)
+		if statut != fiber.StatusOK || len(reponse.Data.Resultats) != 1 {
+			t.Fatalf("envoi : statut %d, %d résultat(s)", statut, len(reponse.Data.Resultats))
+		}
+		return reponse.Data.Resultats[0]
+	}
+
+	// Création, puis renvoi du même lot après une coupure
+	res := pousser(nil, "Likasi")
+	if res.Statut != statutApplique || res.Base == nil || *res.Base != 1 {
+		t.Fatalf("création : %+v, attendu appliquée en version 1", res)
+	}
+	if res := pousser(nil, "Likasi"); res.Statut != statutDejaApplique {
+		t.Errorf("renvoi de la création : %s, attendu %s", res.Statut, statutDejaApplique)
+	}
+
+	// Modification sur la version reçue
+	res = pousser(res.Base, "Kolwezi")
+	if res.Statut != statutApplique || res.Base == nil || *res.Base != 2 {
+		t.Fatalf("modification : %+v, attendu appliquée en version 2", res)
+	}
+
+	// Un agent modifie l'identité en ligne : la base de la tablette est périmée
+	var identite models.Identite
+	if err := db.Where("uuid = ?", "identite-tablette").First(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&identite).Update("profession", "Enseignant").Error; err != nil {
+		t.Fatal(err)
+	}
+	perimee := int64(2)
+	res = pousser(&perimee, "Kipushi")
+	if res.Statut != statutConflit || res.ConflitUUID == "" {
+		t.Fatalf("base périmée : %+v, attendu un conflit", res)
+	}
+
+	var conflit models.ConflitSynchro
+	if err := db.Where("uuid = ?", res.ConflitUUID).First(&conflit).Error; err != nil {
+		t.Fatal(err)
+	}
+	if conflit.TypeConflit != models.ConflitModificationConcurrente || conflit.Statut != "en_attente" {
+		t.Errorf("conflit %s %s, attendu %s en attente", conflit.TypeConflit, conflit.Statut, models.ConflitModificationConcurrente)
+	}
+	if err := db.Where("uuid = ?", "identite-tablette").First(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	if identite.LieuNaissance != "Kolwezi" || identite.Version != 3 {
+		t.Errorf("identité %s en version %d, attendu Kolwezi en version 3", identite.LieuNaissance, identite.Version)
+	}
+}
+
+// Chaque réception ne transmet que les modifications du périmètre postérieures au curseur
+func TestPullDeltas(t *testing.T) {
+	db, appeler := environnementSync(t)
+
+	ancien := time.Now().Add(-time.Hour)
+	for _, identite := range []models.Identite{
+		{UUID: "a1", Nom: "NOM A1", NumeroPasseport: "OPA1", UniteUUID: "unite-A"},
+		{UUID: "a2", Nom: "NOM A2", NumeroPasseport: "OPA2", UniteUUID: "unite-A"},
+		{UUID: "b1", Nom: "NOM B1", NumeroPasseport: "OPB1", UniteUUID: "unite-B"},
+	} {
+		if err := db.Create(&identite).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&models.Identite{}).Where("1 = 1").UpdateColumn("updated_at", ancien).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	type reception struct {
+		Data struct {
+			Identites    []models.Identite `json:"identites"`
+			Suppressions []suppression     `json:"suppressions"`
+			Curseur      string            `json:"curseur"`
+			Complet      bool              `json:"complet"`
+		} `json:"data"`
+	}
+	recevoir := func(curseur string) reception {
+		t.Helper()
+		var r reception
+		if statut := appeler("GET", "/pull?limit=1&curseur="+curseur, nil, &r); statut != fiber.StatusOK {
+			t.Fatalf("réception : statut %d", statut)
+		}
+		return r
+	}
+	identites := func(r reception) []string {
+		var uuids []string
+		for _, identite := range r.Data.Identites {
+			uuids = append(uuids, identite.UUID)
+		}
+		return uuids
+	}
+
+	// Une identité par appel : la seconde réception termine le périmètre, sans b1
+	r := recevoir("")
+	if u := identites(r); len(u) != 1 || u[0] != "a1" || r.Data.Complet {
+		t.Fatalf("première réception %v (complet %v), attendu a1 et une suite", u, r.Data.Complet)
+	}
+	r = recevoir(r.Data.Curseur)
+	if u := identites(r); len(u) != 1 || u[0] != "a2" {
+		t.Fatalf("deuxième réception %v, attendu a2", u)
+	}
+	r = recevoir(r.Data.Curseur)
+	if u := identites(r); len(u) != 0 || !r.Data.Complet {
+		t.Fatalf("troisième réception %v (complet %v), attendu rien", u, r.Data.Complet)
+	}
+	curseur := r.Data.Curseur
+
+	// Modification de a1 et suppression de a2 sur le serveur, hors marge de stabilité
+	recent := time.Now().Add(-time.Minute)
+	if err := db.Model(&models.Identite{}).Where("uuid = ?", "a1").UpdateColumn("updated_at", recent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.Identite{}).Where("uuid = ?", "a2").UpdateColumn("deleted_at", recent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.Identite{}).Where("uuid = ?", "b1").UpdateColumn("updated_at", recent).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	r = recevoir(curseur)
+	if u := identites(r); len(u) != 1 || u[0] != "a1" {
+		t.Errorf("identités modifiées %v, attendu a1", u)
+	}
+	r = recevoir(r.Data.Curseur)
+	if s := r.Data.Suppressions; len(s) != 1 || s[0] != (suppression{Type: "identite", UUID: "a2"}) {
+		t.Errorf("suppressions %v, attendu a2", s)
+	}
+	if r = recevoir(r.Data.Curseur); len(r.Data.Identites) != 0 || len(r.Data.Suppressions) != 0 {
+		t.Errorf("réception sans modification : %v et %v", identites(r), r.Data.Suppressions)
+	}
+}
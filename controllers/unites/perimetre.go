@@ -0,0 +1,189 @@
+package unites
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Rôles dont le périmètre couvre tout le territoire, quelle que soit leur unité
+var rolesNationaux = map[string]bool{
+	"Admin":         true,
+	"Administrator": true,
+}
+
+// Perimetre est l'ensemble des unités dont un utilisateur peut consulter les enregistrements :
+// son unité et ses sous-unités, ou tout le territoire pour un rôle ou une unité nationale.
+// La portée (depots.Portee) restreint les requêtes et les lectures des dépôts.
+type Perimetre struct {
+	UserUUID  string
+	UniteUUID string
+	// Tout le territoire, ou l'unité de l'utilisateur et ses sous-unités
+	depots.Portee
+}
+
+const (
+	clePerimetre = "perimetre"
+	cleDepots    = "depots"
+)
+
+// Depots transmet aux handlers et au calcul du périmètre les dépôts de l'application
+func Depots(d depots.Depots) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(cleDepots, d)
+		return c.Next()
+	}
+}
+
+// DepotsDe retourne les dépôts de la requête ; sans le middleware Depots, ceux de la base Postgres
+func DepotsDe(c *fiber.Ctx) depots.Depots {
+	if d, ok := c.Locals(cleDepots).(depots.Depots); ok {
+		return d
+	}
+	return depots.NewDepotsPostgres(database.DB)
+}
+
+// PerimetreDe retourne le périmètre de l'utilisateur de la requête (calculé une fois par requête)
+func PerimetreDe(c *fiber.Ctx) *Perimetre {
+	if p, ok := c.Locals(clePerimetre).(*Perimetre); ok {
+		return p
+	}
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	p := Resoudre(DepotsDe(c), userUUID)
+	c.Locals(clePerimetre, p)
+	return p
+}
+
+// Resoudre calcule le périmètre d'un utilisateur.
+// Un utilisateur sans unité ne voit aucun enregistrement, sauf rôle national.
+func Resoudre(d depots.Depots, userUUID string) *Perimetre {
+	p := &Perimetre{UserUUID: userUUID}
+	if userUUID == "" {
+		return p
+	}
+	user, err := d.Users.Trouver(userUUID)
+	if err != nil {
+		return p
+	}
+	p.UniteUUID = user.UniteUUID
+	p.National = rolesNationaux[user.Role]
+
+	if user.UniteUUID == "" {
+		return p
+	}
+	unite, err := d.Unites.Trouver(user.UniteUUID)
+	if err != nil {
+		return p
+	}
+	if unite.TypeUnite == "national" {
+		p.National = true
+	}
+	p.Unites, _ = d.Unites.SousArbre(*unite)
+	return p
+}
+
+// UniteDe retourne l'unité de l'utilisateur de la requête, propriétaire des enregistrements qu'il crée
+func UniteDe(c *fiber.Ctx) models.UniteOrganisationnelle {
+	var unite models.UniteOrganisationnelle
+	if p := PerimetreDe(c); p.UniteUUID != "" {
+		if u, err := DepotsDe(c).Unites.Trouver(p.UniteUUID); err == nil {
+			unite = *u
+		}
+	}
+	return unite
+}
+
+// Contient indique si l'unité fait partie du périmètre (toujours vrai pour un rôle national)
+func (p *Perimetre) Contient(uniteUUID string) bool {
+	return p.National || p.DansSousArbre(uniteUUID)
+}
+
+// DansSousArbre indique si l'unité est celle de l'utilisateur ou l'une de ses sous-unités
+func (p *Perimetre) DansSousArbre(uniteUUID string) bool {
+	return uniteUUID != "" && slices.Contains(p.Unites, uniteUUID)
+}
+
+// Cle identifie le périmètre dans les caches partagés entre utilisateurs : deux
+// utilisateurs de même clé voient les mêmes enregistrements
+func (p *Perimetre) Cle() string {
+	if p.National {
+		return "national"
+	}
+	unites := slices.Clone(p.Unites)
+	slices.Sort(unites)
+	somme := sha256.Sum256([]byte(strings.Join(unites, ",")))
+	return hex.EncodeToString(somme[:12])
+}
+
+// Base retourne une connexion dont chaque requête est restreinte par portee.
+// Contrairement à database.DB.Scopes(...), elle peut servir à plusieurs requêtes.
+func Base(portee func(*gorm.DB) *gorm.DB) *gorm.DB {
+	return database.DB.Scopes(portee).Session(&gorm.Session{})
+}
+
+// Acceder contrôle l'accès à un enregistrement appartenant à une unité.
+// Les accès hors du sous-arbre de l'utilisateur sont journalisés : autorisés
+// pour un rôle national, refusés sinon.
+func Acceder(c *fiber.Ctx, uniteUUID, typeRessource, ressourceUUID string) bool {
+	p := PerimetreDe(c)
+	if p.DansSousArbre(uniteUUID) {
+		return true
+	}
+
+	resultat := "refuse"
+	if p.National {
+		resultat = "autorise"
+	}
+	DepotsDe(c).Unites.Journaliser(&models.AccesHorsPerimetre{
+		UUID:           utils.GenerateUUID(),
+		UserUUID:       p.UserUUID,
+		UniteUser:      p.UniteUUID,
+		UniteRessource: uniteUUID,
+		TypeRessource:  typeRessource,
+		RessourceUUID:  ressourceUUID,
+		Methode:        c.Method(),
+		Route:          c.Path(),
+		Resultat:       resultat,
+	})
+	return p.National
+}
+
+// AccederMigrant contrôle l'accès à un enregistrement rattaché à un migrant
+func AccederMigrant(c *fiber.Ctx, migrantUUID, typeRessource, ressourceUUID string) bool {
+	uniteUUID, _ := DepotsDe(c).Migrants.UniteDe(migrantUUID)
+	return Acceder(c, uniteUUID, typeRessource, ressourceUUID)
+}
+
+// AccederIdentite contrôle l'accès à une identité, visible par l'unité qui l'a
+// enregistrée et par celles de ses migrants
+func AccederIdentite(c *fiber.Ctx, identite models.Identite) bool {
+	p := PerimetreDe(c)
+	if p.DansSousArbre(identite.UniteUUID) {
+		return true
+	}
+	if rattachee, _ := DepotsDe(c).Identites.RattacheeA(identite.UUID, p.Unites); rattachee {
+		return true
+	}
+	return Acceder(c, identite.UniteUUID, "identite", identite.UUID)
+}
+
+// AccederIdentiteUUID contrôle l'accès à un enregistrement rattaché à une identité
+func AccederIdentiteUUID(c *fiber.Ctx, identiteUUID string) bool {
+	uniteUUID, _ := DepotsDe(c).Identites.UniteDe(identiteUUID)
+	return AccederIdentite(c, models.Identite{UUID: identiteUUID, UniteUUID: uniteUUID})
+}
+
+// Refus - réponse d'un accès hors périmètre
+func Refus(c *fiber.Ctx) error {
+	return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusForbidden, problemes.CodeHorsPerimetre,
+		"This record belongs to another organisational unit"))
+}
@@ -0,0 +1,422 @@
+package unites
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// =======================
+// UNITÉS ORGANISATIONNELLES
+// =======================
+
+// noeudUnite est une unité avec ses sous-unités, pour l'arborescence
+type noeudUnite struct {
+	models.UniteOrganisationnelle
+	SousUnites []*noeudUnite `json:"sous_unites"`
+}
+
+// Champs des unités pouvant être filtrés, triés et sélectionnés
+var listeUnites = requetes.Schema{
+	Table: "org_units",
+	Champs: map[string]requetes.Type{
+		"uuid":        requetes.Texte,
+		"created_at":  requetes.Date,
+		"updated_at":  requetes.Date,
+		"code":        requetes.Texte,
+		"nom":         requetes.Texte,
+		"type_unite":  requetes.Texte,
+		"province":    requetes.Texte,
+		"actif":       requetes.Booleen,
+		"parent_uuid": requetes.Texte,
+		"chemin":      requetes.Texte,
+		"poste_uuid":  requetes.Texte,
+	},
+	TriDefaut: "chemin",
+}
+
+// Paginate - Récupérer les unités avec pagination et filtres
+func GetPaginatedUnites(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeUnites)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	search := c.Query("search", "")
+
+	var unites []models.UniteOrganisationnelle
+
+	query := db.Model(&models.UniteOrganisationnelle{})
+
+	if search != "" {
+		query = query.Where("code ILIKE ? OR nom ILIKE ? OR province ILIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+	if typeUnite := c.Query("type_unite", ""); typeUnite != "" {
+		query = query.Where("type_unite = ?", typeUnite)
+	}
+	if parentUUID := c.Query("parent_uuid", ""); parentUUID != "" {
+		query = query.Where("parent_uuid = ?", parentUUID)
+	}
+
+	data, pagination, err := liste.Executer(query, &unites)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch organisational units", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Organisational units retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// Query all data
+func GetAllUnites(c *fiber.Ctx) error {
+	db := database.DB
+	var unites []models.UniteOrganisationnelle
+
+	liste, erreurs := requetes.Lire(c, listeUnites)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	query := db.Model(&models.UniteOrganisationnelle{})
+	if c.Query("actif", "") == "true" {
+		query = query.Where("actif = ?", true)
+	}
+
+	data, err := liste.Tout(query, &unites)
+	if err != nil {
+		return problemes.Echec("Failed to fetch organisational units", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "All organisational units",
+		"data":    data,
+	})
+}
+
+// GetUniteTree - Arborescence des unités depuis la direction générale
+func GetUniteTree(c *fiber.Ctx) error {
+	db := database.DB
+	var unites []models.UniteOrganisationnelle
+
+	// Le tri sur le chemin place chaque parent avant ses sous-unités
+	if err := db.Order("chemin ASC").Find(&unites).Error; err != nil {
+		return problemes.Echec("Failed to fetch organisational units", err)
+	}
+
+	noeuds := make(map[string]*noeudUnite, len(unites))
+	racines := []*noeudUnite{}
+	for _, unite := range unites {
+		noeud := &noeudUnite{UniteOrganisationnelle: unite, SousUnites: []*noeudUnite{}}
+		noeuds[unite.UUID] = noeud
+		if unite.ParentUUID != nil {
+			if parent, ok := noeuds[*unite.ParentUUID]; ok {
+				parent.SousUnites = append(parent.SousUnites, noeud)
+				continue
+			}
+		}
+		racines = append(racines, noeud)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Organisational unit tree",
+		"data":    racines,
+	})
+}
+
+// Get one data
+func GetUnite(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var unite models.UniteOrganisationnelle
+
+	if err := db.Where("uuid = ?", uuid).First(&unite).Error; err != nil {
+		return problemes.Absent("Organisational unit not found", err)
+	}
+
+	var sousUnites []models.UniteOrganisationnelle
+	db.Where("parent_uuid = ?", uuid).Order("nom ASC").Find(&sousUnites)
+
+	var agents int64
+	db.Model(&models.User{}).Where("unite_uuid = ?", uuid).Count(&agents)
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Organisational unit found",
+		"data": fiber.Map{
+			"unite":       unite,
+			"sous_unites": sousUnites,
+			"agents":      agents,
+		},
+	})
+}
+
+// Create data
+func CreateUnite(c *fiber.Ctx) error {
+	db := database.DB
+	unite := &models.UniteOrganisationnelle{}
+
+	if err := c.BodyParser(unite); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(*unite); err != nil {
+		return problemes.Validation(err)
+	}
+
+	unite.UUID = utils.GenerateUUID()
+	unite.Code = strings.ToUpper(strings.TrimSpace(unite.Code))
+	unite.Actif = true
+
+	parent, message := verifierRattachement(db, unite)
+	if message != "" {
+		return problemes.Invalide(message)
+	}
+	unite.Chemin = cheminSous(parent, unite.UUID)
+
+	if err := db.Create(unite).Error; err != nil {
+		return problemes.Echec("Failed to create organisational unit", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Organisational unit created successfully",
+		"data":    unite,
+	})
+}
+
+// Update data - un changement de parent déplace l'unité avec ses sous-unités
+func UpdateUnite(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var updateData struct {
+		Nom        string  `json:"nom"`
+		TypeUnite  string  `json:"type_unite" validate:"omitempty,oneof=national direction_provinciale poste_frontiere"`
+		Province   *string `json:"province"`
+		ParentUUID *string `json:"parent_uuid"`
+		PosteUUID  *string `json:"poste_uuid"`
+		Actif      *bool   `json:"actif"`
+	}
+
+	if err := c.BodyParser(&updateData); err != nil {
+		return problemes.Invalide("Review your input").Cause(err)
+	}
+
+	if err := utils.ValidateStruct(updateData); err != nil {
+		return problemes.Validation(err)
+	}
+
+	unite := new(models.UniteOrganisationnelle)
+	if err := db.Where("uuid = ?", uuid).First(&unite).Error; err != nil {
+		return problemes.Absent("Organisational unit not found", err)
+	}
+
+	ancienChemin := unite.Chemin
+	if updateData.Nom != "" {
+		unite.Nom = updateData.Nom
+	}
+	if updateData.TypeUnite != "" {
+		unite.TypeUnite = updateData.TypeUnite
+	}
+	if updateData.Province != nil {
+		unite.Province = *updateData.Province
+	}
+	if updateData.ParentUUID != nil {
+		unite.ParentUUID = updateData.ParentUUID
+		if *updateData.ParentUUID == "" {
+			unite.ParentUUID = nil
+		}
+	}
+	if updateData.PosteUUID != nil {
+		unite.PosteUUID = updateData.PosteUUID
+		if *updateData.PosteUUID == "" {
+			unite.PosteUUID = nil
+		}
+	}
+	if updateData.Actif != nil {
+		unite.Actif = *updateData.Actif
+	}
+
+	parent, message := verifierRattachement(db, unite)
+	if message == "" && parent != nil && strings.HasPrefix(parent.Chemin, ancienChemin) {
+		message = "An organisational unit cannot be moved under one of its sub-units"
+	}
+	if message != "" {
+		return problemes.Invalide(message)
+	}
+	unite.Chemin = cheminSous(parent, unite.UUID)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(unite).Error; err != nil {
+			return err
+		}
+		if unite.Chemin == ancienChemin {
+			return nil
+		}
+		// Les sous-unités suivent leur parent
+		return tx.Model(&models.UniteOrganisationnelle{}).
+			Where("chemin LIKE ? AND uuid <> ?", ancienChemin+"%", unite.UUID).
+			Update("chemin", gorm.Expr("? || substring(chemin from ?)", unite.Chemin, len(ancienChemin)+1)).Error
+	})
+	if err != nil {
+		return problemes.Echec("Failed to update organisational unit", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Organisational unit updated successfully",
+		"data":    unite,
+	})
+}
+
+// Delete data
+func DeleteUnite(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var unite models.UniteOrganisationnelle
+	if err := db.Where("uuid = ?", uuid).First(&unite).Error; err != nil {
+		return problemes.Absent("Organisational unit not found", err)
+	}
+
+	// Une unité qui a des sous-unités, des agents ou des enregistrements est désactivée plutôt que supprimée
+	var sousUnites, agents, migrants, identites int64
+	db.Model(&models.UniteOrganisationnelle{}).Where("parent_uuid = ?", uuid).Count(&sousUnites)
+	db.Model(&models.User{}).Where("unite_uuid = ?", uuid).Count(&agents)
+	db.Model(&models.Migrant{}).Where("unite_uuid = ?", uuid).Count(&migrants)
+	db.Model(&models.Identite{}).Where("unite_uuid = ?", uuid).Count(&identites)
+	if sousUnites+agents+migrants+identites > 0 {
+		return problemes.Nouveau(fiber.StatusConflict, problemes.CodeConflit, "Organisational unit has sub-units, agents or records; deactivate it instead")
+	}
+
+	if err := db.Delete(&unite).Error; err != nil {
+		return problemes.Echec("Failed to delete organisational unit", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Organisational unit deleted successfully",
+		"data":    nil,
+	})
+}
+
+// GetPaginatedAccesHorsPerimetre - Journal des accès aux enregistrements d'autres unités
+func GetPaginatedAccesHorsPerimetre(c *fiber.Ctx) error {
+	db := database.DB
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "15"))
+	if err != nil || limit <= 0 {
+		limit = 15
+	}
+	offset := (page - 1) * limit
+
+	var acces []models.AccesHorsPerimetre
+	var totalRecords int64
+
+	query := db.Model(&models.AccesHorsPerimetre{})
+	if userUUID := c.Query("user_uuid", ""); userUUID != "" {
+		query = query.Where("user_uuid = ?", userUUID)
+	}
+	if uniteUUID := c.Query("unite_uuid", ""); uniteUUID != "" {
+		query = query.Where("unite_ressource = ?", uniteUUID)
+	}
+	if resultat := c.Query("resultat", ""); resultat != "" {
+		query = query.Where("resultat = ?", resultat)
+	}
+
+	query.Count(&totalRecords)
+
+	err = query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&acces).Error
+	if err != nil {
+		return problemes.Echec("Failed to fetch cross-unit access log", err)
+	}
+
+	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cross-unit access log retrieved successfully",
+		"data":    acces,
+		"pagination": fiber.Map{
+			"total_records": totalRecords,
+			"total_pages":   totalPages,
+			"current_page":  page,
+			"page_size":     limit,
+		},
+	})
+}
+
+// verifierRattachement contrôle le parent et le poste d'une unité et retourne le parent
+func verifierRattachement(db *gorm.DB, unite *models.UniteOrganisationnelle) (*models.UniteOrganisationnelle, string) {
+	if unite.ParentUUID != nil && *unite.ParentUUID == "" {
+		unite.ParentUUID = nil
+	}
+	if unite.PosteUUID != nil && *unite.PosteUUID == "" {
+		unite.PosteUUID = nil
+	}
+
+	if unite.PosteUUID != nil {
+		if unite.TypeUnite != "poste_frontiere" {
+			return nil, "Only border post units can be linked to a border post"
+		}
+		var count int64
+		db.Model(&models.PosteFrontiere{}).Where("uuid = ?", *unite.PosteUUID).Count(&count)
+		if count == 0 {
+			return nil, "Border post not found"
+		}
+	}
+
+	if unite.ParentUUID == nil {
+		if unite.TypeUnite != "national" {
+			return nil, "parent_uuid is required for provincial directorates and border posts"
+		}
+		return nil, ""
+	}
+	if *unite.ParentUUID == unite.UUID {
+		return nil, "An organisational unit cannot be its own parent"
+	}
+
+	var parent models.UniteOrganisationnelle
+	if err := db.Where("uuid = ?", *unite.ParentUUID).First(&parent).Error; err != nil {
+		return nil, "Parent organisational unit not found"
+	}
+	if parent.TypeUnite == "poste_frontiere" {
+		return nil, "A border post cannot have sub-units"
+	}
+	return &parent, ""
+}
+
+// cheminSous retourne le chemin d'une unité rattachée au parent donné
+func cheminSous(parent *models.UniteOrganisationnelle, uuid string) string {
+	if parent == nil {
+		return "/" + uuid + "/"
+	}
+	return parent.Chemin + uuid + "/"
+}
+
+// UniteExiste indique si l'unité existe (rattachement des agents)
+func UniteExiste(c *fiber.Ctx, uuid string) bool {
+	_, err := DepotsDe(c).Unites.Trouver(uuid)
+	return err == nil
+}
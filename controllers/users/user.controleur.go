@@ -6,349 +6,379 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/controllers/patch"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/depots"
 	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/utils"
 	"github.com/xuri/excelize/v2"
 )
 
-// Paginate
-func GetPaginatedUsers(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Parse query parameters for pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page <= 0 {
-		page = 1
-	}
-	limit, err := strconv.Atoi(c.Query("limit", "15"))
-	if err != nil || limit <= 0 {
-		limit = 15
-	}
-	offset := (page - 1) * limit
+// Champs des utilisateurs pouvant être filtrés, triés et sélectionnés (hors mot de passe)
+var listeUsers = requetes.Schema{
+	Table: "users",
+	Champs: map[string]requetes.Type{
+		"uuid":               requetes.Texte,
+		"CreatedAt":          requetes.Date,
+		"UpdatedAt":          requetes.Date,
+		"nom":                requetes.Texte,
+		"postnom":            requetes.Texte,
+		"prenom":             requetes.Texte,
+		"sexe":               requetes.Texte,
+		"date_naissance":     requetes.Date,
+		"nationalite":        requetes.Texte,
+		"email":              requetes.Texte,
+		"telephone":          requetes.Texte,
+		"province":           requetes.Texte,
+		"ville":              requetes.Texte,
+		"commune":            requetes.Texte,
+		"matricule":          requetes.Texte,
+		"grade":              requetes.Texte,
+		"fonction":           requetes.Texte,
+		"service":            requetes.Texte,
+		"direction":          requetes.Texte,
+		"unite_uuid":         requetes.Texte,
+		"ministere":          requetes.Texte,
+		"date_recrutement":   requetes.Date,
+		"date_prise_service": requetes.Date,
+		"type_agent":         requetes.Texte,
+		"statut":             requetes.Texte,
+		"role":               requetes.Texte,
+		"permission":         requetes.Texte,
+		"status":             requetes.Booleen,
+		"dernier_acces":      requetes.Date,
+		"nombre_connexions":  requetes.Nombre,
+	},
+	Colonnes: map[string]string{
+		"CreatedAt": "created_at",
+		"UpdatedAt": "updated_at",
+		"postnom":   "post_nom",
+	},
+	TriDefaut: "-UpdatedAt",
+}
 
-	// Parse search query
-	search := c.Query("search", "")
+// Paginate
+func GetPaginatedUsers(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeUsers)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
 
-	var users []models.User
-	var totalRecords int64
+		// Parse search query
+		recherche := depots.Recherche(c.Query("search", ""), "nom", "post_nom", "prenom", "role", "matricule")
 
-	// Count total records matching the search query
-	db.Model(&models.User{}).
-		Where("nom ILIKE ? OR post_nom ILIKE ? OR prenom ILIKE ? OR role ILIKE ? OR matricule ILIKE ?",
-			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%").
-		Count(&totalRecords)
+		// Filters, sort and pagination
+		data, pagination, err := depot.Lire(depots.PorteeNationale).Paginer(liste, recherche)
 
-	err = db.
-		Where("nom ILIKE ? OR post_nom ILIKE ? OR prenom ILIKE ? OR role ILIKE ? OR matricule ILIKE ?",
-			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%").
-		Offset(offset).
-		Limit(limit).
-		Order("users.updated_at DESC").
-		Find(&users).Error
+		if err != nil {
+			return problemes.Echec("Failed to fetch Users", err)
+		}
 
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch Users",
-			"error":   err.Error(),
+		// Return response
+		return c.JSON(fiber.Map{
+			"status":     "success",
+			"message":    "Users retrieved successfully",
+			"data":       data,
+			"pagination": pagination,
 		})
 	}
-
-	// Calculate total pages
-	totalPages := int((totalRecords + int64(limit) - 1) / int64(limit))
-
-	//  Prepare pagination metadata
-	pagination := map[string]interface{}{
-		"total_records": totalRecords,
-		"total_pages":   totalPages,
-		"current_page":  page,
-		"page_size":     limit,
-	}
-
-	// Return response
-	return c.JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Users retrieved successfully",
-		"data":       users,
-		"pagination": pagination,
-	})
 }
 
 // query all data
-func GetAllUsers(c *fiber.Ctx) error {
-	db := database.DB
-	var users []models.User
-	db.Find(&users)
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All users",
-		"data":    users,
-	})
+func GetAllUsers(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		liste, erreurs := requetes.Lire(c, listeUsers)
+		if len(erreurs) > 0 {
+			return requetes.Invalide(c, erreurs)
+		}
+
+		data, err := depot.Lire(depots.PorteeNationale).Tout(liste)
+		if err != nil {
+			return problemes.Echec("Failed to fetch Users", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All users",
+			"data":    data,
+		})
+	}
 }
 
-func GetAllUsersByUUID(c *fiber.Ctx) error {
-	db := database.DB
-	bayerUUID := c.Params("bayer_uuid")
+func GetAllUsersByUUID(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bayerUUID := c.Params("bayer_uuid")
 
-	var users []models.User
-	db.Where("bayer_uuid = ?", bayerUUID).Find(&users)
-	return c.JSON(fiber.Map{
-		"status":  "success",
-		"message": "All users",
-		"data":    users,
-	})
+		users, _ := depot.Lire(depots.PorteeNationale).Charger("", depots.Egal("bayer_uuid", bayerUUID))
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "All users",
+			"data":    users,
+		})
+	}
 }
 
 // Get one data
-func GetUser(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
-	var user models.User
-	db.Where("uuid = ?", uuid).First(&user)
-	if user.Nom == "" {
-		return c.Status(404).JSON(
+func GetUser(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("No User found", err)
+		}
+		return c.JSON(
 			fiber.Map{
-				"status":  "error",
-				"message": "No User found",
-				"data":    nil,
+				"status":  "success",
+				"message": "User found",
+				"data":    user,
 			},
 		)
 	}
-	return c.JSON(
-		fiber.Map{
-			"status":  "success",
-			"message": "User found",
-			"data":    user,
-		},
-	)
 }
 
 // Create data
-func CreateUser(c *fiber.Ctx) error {
-	user := &models.User{}
-
-	if err := c.BodyParser(user); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Invalid request format",
-			"error":   err.Error(),
-		})
-	}
+func CreateUser(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := &models.User{}
 
-	if user.Nom == "" || user.PostNom == "" || user.Prenom == "" {
-		return c.Status(400).JSON(
-			fiber.Map{
-				"status":  "error",
-				"message": "Form not complete - nom, postnom and prenom are required",
-				"data":    nil,
-			},
-		)
-	}
-
-	if user.Password != user.PasswordConfirm {
-		return c.Status(400).JSON(fiber.Map{
-			"status":  "error",
-			"message": "passwords do not match",
-		})
-	}
+		if err := c.BodyParser(user); err != nil {
+			return problemes.Invalide("Invalid request format").Cause(err)
+		}
 
-	user.SetPassword(user.Password)
+		if user.Nom == "" || user.PostNom == "" || user.Prenom == "" {
+			return problemes.Invalide("Form not complete - nom, postnom and prenom are required")
+		}
 
-	if err := utils.ValidateStruct(*user); err != nil {
-		return c.Status(400).JSON(err)
-	}
+		if user.Password != user.PasswordConfirm {
+			return problemes.Invalide("passwords do not match")
+		}
 
-	user.UUID = utils.GenerateUUID()
+		if user.UniteUUID != "" && !unites.UniteExiste(c, user.UniteUUID) {
+			return problemes.Invalide("Organisational unit not found")
+		}
 
-	if err := database.DB.Create(user).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to create user",
-			"error":   err.Error(),
-		})
-	}
+		user.SetPassword(user.Password)
 
-	return c.JSON(
-		fiber.Map{
-			"status":  "success",
-			"message": "User created successfully",
-			"data":    user,
-		},
-	)
-}
+		if err := utils.ValidateStruct(*user); err != nil {
+			return problemes.Validation(err)
+		}
 
-// Update data
-func UpdateUser(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
-	db := database.DB
+		user.UUID = utils.GenerateUUID()
 
-	var updateData models.User
+		if err := depot.Creer(user); err != nil {
+			return problemes.Echec("Failed to create user", err)
+		}
 
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(
+		return c.JSON(
 			fiber.Map{
-				"status":  "error",
-				"message": "Review your input",
-				"error":   err.Error(),
+				"status":  "success",
+				"message": "User created successfully",
+				"data":    user,
 			},
 		)
 	}
+}
 
-	user := new(models.User)
+// Update data
+func UpdateUser(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var updateData models.User
 
-	if err := db.Where("uuid = ?", uuid).First(&user).Error; err != nil {
-		return c.Status(404).JSON(
-			fiber.Map{
-				"status":  "error",
-				"message": "User not found",
-				"data":    nil,
-			},
-		)
-	}
+		if err := c.BodyParser(&updateData); err != nil {
+			return problemes.Invalide("Review your input").Cause(err)
+		}
+
+		if updateData.UniteUUID != "" && !unites.UniteExiste(c, updateData.UniteUUID) {
+			return problemes.Invalide("Organisational unit not found")
+		}
+
+		user, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("User not found", err)
+		}
 
-	// Mise à jour des champs
-	user.Nom = updateData.Nom
-	user.PostNom = updateData.PostNom
-	user.Prenom = updateData.Prenom
-	user.Sexe = updateData.Sexe
-	user.DateNaissance = updateData.DateNaissance
-	user.LieuNaissance = updateData.LieuNaissance
-	user.EtatCivil = updateData.EtatCivil
-	user.NombreEnfants = updateData.NombreEnfants
-	user.Nationalite = updateData.Nationalite
-	user.NumeroCNI = updateData.NumeroCNI
-	user.DateEmissionCNI = updateData.DateEmissionCNI
-	user.DateExpirationCNI = updateData.DateExpirationCNI
-	user.LieuEmissionCNI = updateData.LieuEmissionCNI
-	user.Email = updateData.Email
-	user.Telephone = updateData.Telephone
-	user.TelephoneUrgence = updateData.TelephoneUrgence
-	user.Province = updateData.Province
-	user.Ville = updateData.Ville
-	user.Commune = updateData.Commune
-	user.Quartier = updateData.Quartier
-	user.Avenue = updateData.Avenue
-	user.Numero = updateData.Numero
-	user.Matricule = updateData.Matricule
-	user.Grade = updateData.Grade
-	user.Fonction = updateData.Fonction
-	user.Service = updateData.Service
-	user.Direction = updateData.Direction
-	user.Ministere = updateData.Ministere
-	user.DateRecrutement = updateData.DateRecrutement
-	user.DatePriseService = updateData.DatePriseService
-	user.TypeAgent = updateData.TypeAgent
-	user.Statut = updateData.Statut
-	user.NiveauEtude = updateData.NiveauEtude
-	user.DiplomeBase = updateData.DiplomeBase
-	user.UniversiteEcole = updateData.UniversiteEcole
-	user.AnneeObtention = updateData.AnneeObtention
-	user.Specialisation = updateData.Specialisation
-	user.NumeroBancaire = updateData.NumeroBancaire
-	user.Banque = updateData.Banque
-	user.NumeroCNSS = updateData.NumeroCNSS
-	user.NumeroONEM = updateData.NumeroONEM
-	user.PhotoProfil = updateData.PhotoProfil
-	user.CVDocument = updateData.CVDocument
-	user.Role = updateData.Role
-	user.Permission = updateData.Permission
-	user.Status = updateData.Status
-	user.Signature = updateData.Signature
-
-	if err := db.Save(&user).Error; err != nil {
-		return c.Status(500).JSON(
+		// Mise à jour des champs
+		user.Nom = updateData.Nom
+		user.PostNom = updateData.PostNom
+		user.Prenom = updateData.Prenom
+		user.Sexe = updateData.Sexe
+		user.DateNaissance = updateData.DateNaissance
+		user.LieuNaissance = updateData.LieuNaissance
+		user.EtatCivil = updateData.EtatCivil
+		user.NombreEnfants = updateData.NombreEnfants
+		user.Nationalite = updateData.Nationalite
+		user.NumeroCNI = updateData.NumeroCNI
+		user.DateEmissionCNI = updateData.DateEmissionCNI
+		user.DateExpirationCNI = updateData.DateExpirationCNI
+		user.LieuEmissionCNI = updateData.LieuEmissionCNI
+		user.Email = updateData.Email
+		user.Telephone = updateData.Telephone
+		user.TelephoneUrgence = updateData.TelephoneUrgence
+		user.Province = updateData.Province
+		user.Ville = updateData.Ville
+		user.Commune = updateData.Commune
+		user.Quartier = updateData.Quartier
+		user.Avenue = updateData.Avenue
+		user.Numero = updateData.Numero
+		user.Matricule = updateData.Matricule
+		user.Grade = updateData.Grade
+		user.Fonction = updateData.Fonction
+		user.Service = updateData.Service
+		user.Direction = updateData.Direction
+		user.UniteUUID = updateData.UniteUUID
+		user.Ministere = updateData.Ministere
+		user.DateRecrutement = updateData.DateRecrutement
+		user.DatePriseService = updateData.DatePriseService
+		user.TypeAgent = updateData.TypeAgent
+		user.Statut = updateData.Statut
+		user.NiveauEtude = updateData.NiveauEtude
+		user.DiplomeBase = updateData.DiplomeBase
+		user.UniversiteEcole = updateData.UniversiteEcole
+		user.AnneeObtention = updateData.AnneeObtention
+		user.Specialisation = updateData.Specialisation
+		user.NumeroBancaire = updateData.NumeroBancaire
+		user.Banque = updateData.Banque
+		user.NumeroCNSS = updateData.NumeroCNSS
+		user.NumeroONEM = updateData.NumeroONEM
+		user.PhotoProfil = updateData.PhotoProfil
+		user.CVDocument = updateData.CVDocument
+		user.Role = updateData.Role
+		user.Permission = updateData.Permission
+		user.Status = updateData.Status
+		user.Signature = updateData.Signature
+
+		if err := depot.Sauvegarder(user); err != nil {
+			return problemes.Echec("Failed to update user", err)
+		}
+
+		return c.JSON(
 			fiber.Map{
-				"status":  "error",
-				"message": "Failed to update user",
-				"error":   err.Error(),
+				"status":  "success",
+				"message": "User updated successfully",
+				"data":    user,
 			},
 		)
 	}
+}
+
+// Champs d'un utilisateur modifiables par PATCH ; le mot de passe a son propre parcours
+// et les informations de connexion sont tenues par le système
+var reglesPatchUser = patch.Regles{
+	Modifiables: []string{
+		"nom", "postnom", "prenom", "sexe", "date_naissance", "lieu_naissance",
+		"etat_civil", "nombre_enfants",
+		"nationalite", "numero_cni", "date_emission_cni", "date_expiration_cni", "lieu_emission_cni",
+		"email", "telephone", "telephone_urgence",
+		"province", "ville", "commune", "quartier", "avenue", "numero",
+		"matricule", "grade", "fonction", "service", "direction", "unite_uuid", "ministere",
+		"date_recrutement", "date_prise_service", "type_agent", "statut",
+		"niveau_etude", "diplome_base", "universite_ecole", "annee_obtention", "specialisation",
+		"numero_bancaire", "banque", "numero_cnss", "numero_onem",
+		"photo_profil", "cv_document",
+		"role", "permission", "status", "signature",
+	},
+	Immuables: []string{
+		"uuid", "password", "password_confirm", "qr_code", "qr_code_data",
+		"dernier_acces", "nombre_connexions", "CreatedAt", "UpdatedAt", "DeletedAt",
+	},
+}
+
+// PatchUser - Modifier partiellement un utilisateur (JSON Merge Patch, RFC 7396)
+func PatchUser(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		corps, ok := patch.Lire(c)
+		if !ok {
+			return patch.TypeNonSupporte(c)
+		}
+
+		user, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("User not found", err)
+		}
+
+		corrige, champs, erreurs := patch.Appliquer(*user, corps, reglesPatchUser)
+		if len(erreurs) > 0 {
+			return patch.Invalide(c, erreurs)
+		}
+
+		if corrige.UniteUUID != user.UniteUUID && corrige.UniteUUID != "" && !unites.UniteExiste(c, corrige.UniteUUID) {
+			return problemes.Invalide("Organisational unit not found")
+		}
 
-	return c.JSON(
-		fiber.Map{
+		if err := depot.Modifier(user, &corrige, champs...); err != nil {
+			return problemes.Echec("Failed to update user", err)
+		}
+
+		if actuel, err := depot.Trouver(user.UUID); err == nil {
+			user = actuel
+		}
+
+		return c.JSON(fiber.Map{
 			"status":  "success",
 			"message": "User updated successfully",
 			"data":    user,
-		},
-	)
+		})
+	}
 }
 
 // Delete data
-func DeleteUser(c *fiber.Ctx) error {
-	uuid := c.Params("uuid")
+func DeleteUser(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("No User found", err)
+		}
 
-	db := database.DB
+		depot.Supprimer(user)
 
-	var User models.User
-	db.Where("uuid = ?", uuid).First(&User)
-	if User.Nom == "" {
-		return c.Status(404).JSON(
+		return c.JSON(
 			fiber.Map{
-				"status":  "error",
-				"message": "No User found",
+				"status":  "success",
+				"message": "User deleted success",
 				"data":    nil,
 			},
 		)
 	}
-
-	db.Delete(&User)
-
-	return c.JSON(
-		fiber.Map{
-			"status":  "success",
-			"message": "User deleted success",
-			"data":    nil,
-		},
-	)
 }
 
 // Export Users to Excel with styling
-func ExportUsersToExcel(c *fiber.Ctx) error {
-	db := database.DB
-
-	// Parse query parameters for filtering
-	role := c.Query("role", "")
-	status := c.Query("status", "")
-	search := c.Query("search", "")
-	startDate := c.Query("start_date", "")
-	endDate := c.Query("end_date", "")
-
-	// Build query
-	query := db.Model(&models.User{})
-
-	// Apply filters
-	if role != "" {
-		query = query.Where("role = ?", role)
-	}
-	if status != "" {
-		if status == "true" || status == "Actif" {
-			query = query.Where("status = ?", true)
-		} else {
-			query = query.Where("status = ?", false)
+func ExportUsersToExcel(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Parse query parameters for filtering
+		role := c.Query("role", "")
+		status := c.Query("status", "")
+		search := c.Query("search", "")
+		startDate := c.Query("start_date", "")
+		endDate := c.Query("end_date", "")
+
+		// Apply filters
+		conditions := []depots.Condition{depots.Recherche(search, "nom", "post_nom", "prenom", "email", "matricule")}
+		if role != "" {
+			conditions = append(conditions, depots.Egal("role", role))
+		}
+		if status != "" {
+			conditions = append(conditions, depots.Egal("status", status == "true" || status == "Actif"))
+		}
+		if startDate != "" {
+			conditions = append(conditions, depots.AuMoins("created_at", startDate))
+		}
+		if endDate != "" {
+			conditions = append(conditions, depots.AuPlus("created_at", endDate))
 		}
-	}
-	if search != "" {
-		query = query.Where("nom ILIKE ? OR post_nom ILIKE ? OR prenom ILIKE ? OR email ILIKE ? OR matricule ILIKE ?",
-			"%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%", "%"+search+"%")
-	}
-	if startDate != "" {
-		query = query.Where("created_at >= ?", startDate)
-	}
-	if endDate != "" {
-		query = query.Where("created_at <= ?", endDate)
-	}
 
-	var users []models.User
-	if err := query.Order("created_at DESC").Find(&users).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to fetch users",
-			"error":   err.Error(),
-		})
+		users, err := depot.Lire(depots.PorteeNationale).Charger("-created_at", conditions...)
+		if err != nil {
+			return problemes.Echec("Failed to fetch users", err)
+		}
+
+		return exporterUsers(c, users)
 	}
+}
 
+// exporterUsers écrit le classeur des utilisateurs exportés
+func exporterUsers(c *fiber.Ctx, users []models.User) error {
 	// Create new Excel file
 	f := excelize.NewFile()
 	defer func() {
@@ -613,11 +643,7 @@ func ExportUsersToExcel(c *fiber.Ctx) error {
 	// Save to buffer and return
 	buffer, err := f.WriteToBuffer()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Failed to generate Excel file",
-			"error":   err.Error(),
-		})
+		return problemes.Echec("Failed to generate Excel file", err)
 	}
 
 	// Set headers for file download
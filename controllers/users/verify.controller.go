@@ -0,0 +1,60 @@
+package users
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// VerifyAgent - Vérification publique d'un agent à partir de son QR code.
+// Seules les informations figurant sur la carte professionnelle sont renvoyées.
+// GET /api/agents/verify/:uuid
+func VerifyAgent(depot depots.Users) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := depot.Trouver(c.Params("uuid"))
+		if err != nil {
+			return problemes.Absent("Agent not found", err).Avec("data", fiber.Map{
+				"valide": false,
+				"motif":  "agent_inconnu",
+			})
+		}
+
+		resultat := fiber.Map{
+			"valide":       false,
+			"matricule":    user.Matricule,
+			"nom":          user.Nom,
+			"postnom":      user.PostNom,
+			"prenom":       user.Prenom,
+			"grade":        user.Grade,
+			"fonction":     user.Fonction,
+			"service":      user.Service,
+			"direction":    user.Direction,
+			"ministere":    user.Ministere,
+			"photo_profil": user.PhotoProfil,
+			"statut":       user.Statut,
+		}
+
+		switch {
+		case !strings.EqualFold(user.Statut, "Actif"):
+			resultat["motif"] = "agent_inactif"
+		case user.QRCodeData != "":
+			// Le QR code porte sa propre période de validité
+			if _, err := utils.ValidateQRCode(user.QRCodeData); err != nil {
+				resultat["motif"] = "qr_code_expire"
+			} else {
+				resultat["valide"] = true
+			}
+		default:
+			resultat["valide"] = true
+		}
+
+		return c.JSON(fiber.Map{
+			"status":  "success",
+			"message": "Agent verified",
+			"data":    resultat,
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package versions
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// ETag place l'en-tête ETag correspondant à la version d'un enregistrement
+func ETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, etag(version))
+}
+
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatchFacultatif - IF_MATCH_OPTIONAL=true accepte les modifications sans If-Match,
+// le temps que les anciens clients passent au contrôle de version
+func IfMatchFacultatif() bool {
+	return utils.Env("IF_MATCH_OPTIONAL") == "true"
+}
+
+// Correspond vérifie l'en-tête If-Match d'une modification ou d'une suppression.
+// Sans en-tête, la requête est refusée sauf si IfMatchFacultatif.
+func Correspond(c *fiber.Ctx, version int64) bool {
+	ifMatch := c.Get(fiber.HeaderIfMatch)
+	if ifMatch == "" {
+		return IfMatchFacultatif()
+	}
+	for _, valeur := range strings.Split(ifMatch, ",") {
+		valeur = strings.TrimPrefix(strings.TrimSpace(valeur), "W/")
+		if valeur == "*" || valeur == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conflit - réponse 412 avec la version courante de l'enregistrement, pour que le client
+// affiche les différences avec sa modification ; 428 quand l'en-tête If-Match manque
+func Conflit(c *fiber.Ctx, version int64, actuel interface{}) error {
+	ETag(c, version)
+	if c.Get(fiber.HeaderIfMatch) == "" && !IfMatchFacultatif() {
+		return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusPreconditionRequired, problemes.CodePreconditionRequise,
+			"If-Match header is required to modify this record").
+			Avec("version", version).
+			Avec("data", actuel))
+	}
+	return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusPreconditionFailed, problemes.CodeVersionPerimee,
+		"The record was modified by another user").
+		Avec("version", version).
+		Avec("data", actuel))
+}
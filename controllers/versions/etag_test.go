@@ -0,0 +1,84 @@
+package versions
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// application - route DELETE qui ne supprime que si If-Match correspond à la version 3
+func application() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Delete("/enregistrement", func(c *fiber.Ctx) error {
+		if !Correspond(c, 3) {
+			return Conflit(c, 3, fiber.Map{"uuid": "a"})
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app
+}
+
+func TestCorrespond(t *testing.T) {
+	t.Setenv("IF_MATCH_OPTIONAL", "")
+
+	cas := []struct {
+		nom     string
+		ifMatch string
+		status  int
+		code    string
+	}{
+		{"en-tête absent", "", fiber.StatusPreconditionRequired, problemes.CodePreconditionRequise},
+		{"version courante", `"3"`, fiber.StatusNoContent, ""},
+		{"version faible", `W/"3"`, fiber.StatusNoContent, ""},
+		{"liste", `"2", "3"`, fiber.StatusNoContent, ""},
+		{"joker", "*", fiber.StatusNoContent, ""},
+		{"version périmée", `"2"`, fiber.StatusPreconditionFailed, problemes.CodeVersionPerimee},
+	}
+	app := application()
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodDelete, "/enregistrement", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tc.ifMatch)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status %d, attendu %d", resp.StatusCode, tc.status)
+			}
+			if tc.code == "" {
+				return
+			}
+			if etag := resp.Header.Get(fiber.HeaderETag); etag != `"3"` {
+				t.Errorf("ETag %q, attendu \"3\"", etag)
+			}
+			var corps map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&corps); err != nil {
+				t.Fatal(err)
+			}
+			if corps["code"] != tc.code {
+				t.Errorf("code %v, attendu %s", corps["code"], tc.code)
+			}
+			if corps["version"] != float64(3) {
+				t.Errorf("version %v, attendue 3", corps["version"])
+			}
+		})
+	}
+}
+
+func TestCorrespondIfMatchFacultatif(t *testing.T) {
+	t.Setenv("IF_MATCH_OPTIONAL", "true")
+
+	resp, err := application().Test(httptest.NewRequest(fiber.MethodDelete, "/enregistrement", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status %d sans If-Match avec IF_MATCH_OPTIONAL=true, attendu 204", resp.StatusCode)
+	}
+}
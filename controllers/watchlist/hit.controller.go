@@ -0,0 +1,165 @@
+package watchlist
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// =======================
+// FILE DE REVUE DES CORRESPONDANCES
+// =======================
+
+// Champs des correspondances pouvant être filtrés, triés et sélectionnés
+var listeHits = requetes.Schema{
+	Table: "watchlist_hits",
+	Champs: map[string]requetes.Type{
+		"uuid":                requetes.Texte,
+		"created_at":          requetes.Date,
+		"updated_at":          requetes.Date,
+		"identite_uuid":       requetes.Texte,
+		"entree_uuid":         requetes.Texte,
+		"liste_uuid":          requetes.Texte,
+		"type_correspondance": requetes.Texte,
+		"score":               requetes.Nombre,
+		"alerte_uuid":         requetes.Texte,
+		"statut":              requetes.Texte,
+		"revise_par":          requetes.Texte,
+		"date_revue":          requetes.Date,
+	},
+	Relations: []string{"identite", "entree", "liste"},
+	TriDefaut: "-score,-created_at",
+}
+
+// Paginate - File de revue des correspondances (en attente par défaut)
+func GetPaginatedHits(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeHits)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var hits []models.CorrespondanceSurveillance
+
+	query := db.Model(&models.CorrespondanceSurveillance{}).
+		Scopes(unites.PerimetreDe(c).ParIdentite("watchlist_hits.identite_uuid"))
+	if !liste.Filtree("statut") {
+		if statut := c.Query("statut", "en_attente"); statut != "all" {
+			query = query.Where("watchlist_hits.statut = ?", statut)
+		}
+	}
+	if listeUUID := c.Query("liste_uuid", ""); listeUUID != "" {
+		query = query.Where("watchlist_hits.liste_uuid = ?", listeUUID)
+	}
+	if identiteUUID := c.Query("identite_uuid", ""); identiteUUID != "" {
+		query = query.Where("watchlist_hits.identite_uuid = ?", identiteUUID)
+	}
+	if typeCorrespondance := c.Query("type_correspondance", ""); typeCorrespondance != "" {
+		query = query.Where("watchlist_hits.type_correspondance = ?", typeCorrespondance)
+	}
+
+	data, pagination, err := liste.Executer(query.
+		Preload("Identite").
+		Preload("Entree").
+		Preload("Liste"), &hits)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch watchlist hits", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Watchlist hits retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// Get one data
+func GetHit(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var hit models.CorrespondanceSurveillance
+
+	err := db.Where("uuid = ?", uuid).
+		Preload("Identite").
+		Preload("Entree").
+		Preload("Liste").
+		First(&hit).Error
+
+	if err != nil {
+		return problemes.Absent("Watchlist hit not found", err)
+	}
+	if !unites.AccederIdentiteUUID(c, hit.IdentiteUUID) {
+		return unites.Refus(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist hit found",
+		"data":    hit,
+	})
+}
+
+// ConfirmHit - Confirmer une correspondance
+func ConfirmHit(c *fiber.Ctx) error {
+	return reviserHit(c, "confirmee", "Watchlist hit confirmed")
+}
+
+// ClearHit - Écarter une correspondance (faux positif)
+func ClearHit(c *fiber.Ctx) error {
+	return reviserHit(c, "ecartee", "Watchlist hit cleared")
+}
+
+// reviserHit enregistre la décision de l'agent et met à jour l'alerte liée
+func reviserHit(c *fiber.Ctx, statut string, message string) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var body struct {
+		Commentaire string `json:"commentaire"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return problemes.Invalide("Invalid request format").Cause(err)
+	}
+
+	var hit models.CorrespondanceSurveillance
+	if err := db.Where("uuid = ?", uuid).Preload("Liste").First(&hit).Error; err != nil {
+		return problemes.Absent("Watchlist hit not found", err)
+	}
+	if !unites.AccederIdentiteUUID(c, hit.IdentiteUUID) {
+		return unites.Refus(c)
+	}
+
+	if hit.Statut != "en_attente" {
+		return problemes.Invalide("Only pending hits can be reviewed")
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+	err := db.Model(&hit).Updates(map[string]interface{}{
+		"statut":      statut,
+		"revise_par":  userUUID,
+		"date_revue":  &now,
+		"commentaire": body.Commentaire,
+	}).Error
+	if err == nil {
+		err = resoudreAlerte(db, hit, now)
+	}
+	if err != nil {
+		return problemes.Echec("Failed to review watchlist hit", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    hit,
+	})
+}
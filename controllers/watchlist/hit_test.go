@@ -0,0 +1,148 @@
+package watchlist
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"gorm.io/gorm/clause"
+)
+
+func TestHitsAreScopedToUnit(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	for _, code := range []string{"A", "B"} {
+		unite := models.UniteOrganisationnelle{
+			UUID:      "unite-" + code,
+			Code:      code,
+			Nom:       "Direction " + code,
+			TypeUnite: "direction_provinciale",
+			Actif:     true,
+			Chemin:    "/unite-" + code + "/",
+		}
+		identite := models.Identite{UUID: "identite-" + code, Nom: "NOM " + code, NumeroPasseport: "OP" + code, UniteUUID: unite.UUID}
+		for _, err := range []error{db.Create(&unite).Error, db.Create(&identite).Error} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := db.Create(&models.ListeSurveillance{UUID: "liste", Nom: "Recherchés", TypeListe: "personnes_recherchees"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.EntreeSurveillance{UUID: "entree", ListeUUID: "liste", NomComplet: "NOM"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"A", "B"} {
+		hit := models.CorrespondanceSurveillance{
+			UUID:               "hit-" + code,
+			IdentiteUUID:       "identite-" + code,
+			EntreeUUID:         "entree",
+			ListeUUID:          "liste",
+			TypeCorrespondance: "nom_date_naissance",
+			Statut:             "en_attente",
+		}
+		if err := db.Omit(clause.Associations).Create(&hit).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	token := basetest.Utilisateur(t, db, "Agent", "unite-A")
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/hits/paginate", GetPaginatedHits)
+	app.Get("/hits/get/:uuid", GetHit)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/hits/paginate?token="+token, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var corps struct {
+		Data []models.CorrespondanceSurveillance `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&corps); err != nil {
+		t.Fatal(err)
+	}
+	if len(corps.Data) != 1 || corps.Data[0].UUID != "hit-A" {
+		t.Errorf("file de revue: %d correspondance(s), attendu hit-A seule", len(corps.Data))
+	}
+
+	for uuid, attendu := range map[string]int{"hit-A": fiber.StatusOK, "hit-B": fiber.StatusForbidden} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/hits/get/"+uuid+"?token="+token, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != attendu {
+			t.Errorf("%s: statut %d, attendu %d", uuid, resp.StatusCode, attendu)
+		}
+	}
+}
+
+func TestHitsFiltreStatutRemplaceLeDefaut(t *testing.T) {
+	db := basetest.Migree(t)
+	database.DB = db
+
+	identite := models.Identite{UUID: "identite", Nom: "NOM", NumeroPasseport: "OP1"}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.ListeSurveillance{UUID: "liste", Nom: "Recherchés", TypeListe: "personnes_recherchees"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, statut := range []string{"en_attente", "confirmee", "ecartee"} {
+		entree := models.EntreeSurveillance{UUID: "entree-" + statut, ListeUUID: "liste", NomComplet: "NOM"}
+		hit := models.CorrespondanceSurveillance{
+			UUID:               "hit-" + statut,
+			IdentiteUUID:       identite.UUID,
+			EntreeUUID:         entree.UUID,
+			ListeUUID:          "liste",
+			TypeCorrespondance: "nom_date_naissance",
+			Score:              float64(i),
+			Statut:             statut,
+		}
+		if err := db.Create(&entree).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Omit(clause.Associations).Create(&hit).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	token := basetest.Administrateur(t, db)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Get("/hits/paginate", GetPaginatedHits)
+
+	cas := map[string][]string{
+		"":                                      {"hit-en_attente"},
+		"&statut=all":                           {"hit-ecartee", "hit-confirmee", "hit-en_attente"},
+		"&filter[statut][in]=confirmee,ecartee": {"hit-ecartee", "hit-confirmee"},
+		"&filter[statut]=confirmee&fields=uuid": {"hit-confirmee"},
+	}
+	for requete, attendus := range cas {
+		resp, err := app.Test(httptest.NewRequest("GET", "/hits/paginate?token="+token+requete, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var corps struct {
+			Data []struct {
+				UUID string `json:"uuid"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&corps); err != nil {
+			t.Fatal(err)
+		}
+		var obtenus []string
+		for _, ligne := range corps.Data {
+			obtenus = append(obtenus, ligne.UUID)
+		}
+		if strings.Join(obtenus, ",") != strings.Join(attendus, ",") {
+			t.Errorf("%q: %v, attendu %v", requete, obtenus, attendus)
+		}
+	}
+}
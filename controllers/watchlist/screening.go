@@ -0,0 +1,336 @@
+package watchlist
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// Score minimal de similarité des noms pour une correspondance nom + date de naissance
+	SeuilNom = 0.85
+
+	// Nombre minimal de jetons du nom inscrit pour autoriser une correspondance approchée
+	jetonsMinNom = 2
+
+	// Titre de l'alerte ouverte quand une identité n'a pas pu être criblée
+	titreCriblageImpossible = "Criblage de sécurité impossible"
+)
+
+// listesActives restreint une requête d'entrées aux listes actives
+func listesActives(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.ListeSurveillance{}).Select("uuid").Where("actif = ?", true)
+}
+
+// comparer retourne le type et le score de la correspondance entre une identité et une entrée
+func comparer(identite models.Identite, entree models.EntreeSurveillance) (string, float64, bool) {
+	if doc := utils.NormalizeDocumentNumber(identite.NumeroPasseport); doc != "" && doc == entree.DocumentNormalise {
+		return "document_exact", 1, true
+	}
+
+	if entree.DateNaissance == nil || identite.DateNaissance.IsZero() ||
+		entree.DateNaissance.Format("2006-01-02") != identite.DateNaissance.Format("2006-01-02") {
+		return "", 0, false
+	}
+
+	// Les listes ne donnent souvent qu'une partie du nom : chaque jeton inscrit
+	// doit se retrouver dans le nom complet de l'identité
+	inscrit := utils.NameTokens(entree.NomComplet)
+	if len(inscrit) < jetonsMinNom {
+		return "", 0, false
+	}
+	score := utils.NameMatchScore(inscrit, utils.NameTokens(identite.Nom, identite.Postnom, identite.Prenom))
+	if score < SeuilNom {
+		return "", 0, false
+	}
+	return "nom_date_naissance", float64(int(score*1000+0.5)) / 1000, true
+}
+
+// criblage - criblage des identités enregistrées par les handlers, dans la base db
+type criblage struct {
+	db *gorm.DB
+}
+
+// NewCriblage retourne le criblage des identités contre les listes de la base
+func NewCriblage(db *gorm.DB) depots.Criblage {
+	return criblage{db: db}
+}
+
+func (c criblage) Cribler(identite models.Identite) ([]models.CorrespondanceSurveillance, error) {
+	hits, err := CriblerIdentite(c.db, identite)
+	if err != nil {
+		SignalerEchecCriblage(c.db, identite.UUID, err)
+	}
+	return hits, err
+}
+
+// CriblerIdentite compare une identité à toutes les listes actives, enregistre les
+// nouvelles correspondances et ouvre les alertes de sécurité manquantes
+func CriblerIdentite(db *gorm.DB, identite models.Identite) ([]models.CorrespondanceSurveillance, error) {
+	var entrees []models.EntreeSurveillance
+
+	query := db.Where("liste_uuid IN (?)", listesActives(db))
+	doc := utils.NormalizeDocumentNumber(identite.NumeroPasseport)
+	switch {
+	case doc != "" && !identite.DateNaissance.IsZero():
+		query = query.Where("document_normalise = ? OR DATE(date_naissance) = ?",
+			doc, identite.DateNaissance.Format("2006-01-02"))
+	case doc != "":
+		query = query.Where("document_normalise = ?", doc)
+	case !identite.DateNaissance.IsZero():
+		query = query.Where("DATE(date_naissance) = ?", identite.DateNaissance.Format("2006-01-02"))
+	default:
+		return nil, resoudreEchecsCriblage(db, identite.UUID)
+	}
+
+	if err := query.Find(&entrees).Error; err != nil {
+		return nil, err
+	}
+
+	var nouvelles []models.CorrespondanceSurveillance
+	for _, entree := range entrees {
+		typeCorrespondance, score, ok := comparer(identite, entree)
+		if !ok {
+			continue
+		}
+		hit, cree, err := enregistrerCorrespondance(db, identite.UUID, entree, typeCorrespondance, score)
+		if err != nil {
+			return nil, err
+		}
+		if cree {
+			nouvelles = append(nouvelles, hit)
+		}
+	}
+
+	if err := ouvrirAlertes(db, identite.UUID); err != nil {
+		return nil, err
+	}
+
+	return nouvelles, resoudreEchecsCriblage(db, identite.UUID)
+}
+
+// SignalerEchecCriblage journalise l'échec du criblage d'une identité déjà enregistrée
+// et ouvre une alerte sur son migrant le plus récent pour que le criblage soit refait
+func SignalerEchecCriblage(db *gorm.DB, identiteUUID string, cause error) {
+	log.Printf("Criblage de l'identité %s impossible : %v", identiteUUID, cause)
+
+	var migrant models.Migrant
+	if err := db.Where("identite_uuid = ?", identiteUUID).Order("created_at DESC").First(&migrant).Error; err != nil {
+		// Aucun migrant encore rattaché : l'identité sera criblée à son enregistrement
+		return
+	}
+
+	var ouvertes int64
+	err := db.Model(&models.Alert{}).
+		Where("migrant_uuid = ? AND titre = ? AND statut = ?", migrant.UUID, titreCriblageImpossible, "active").
+		Count(&ouvertes).Error
+	if err != nil || ouvertes > 0 {
+		return
+	}
+
+	alerte := models.Alert{
+		UUID:          utils.GenerateUUID(),
+		MigrantUUID:   migrant.UUID,
+		TypeAlerte:    "securite",
+		NiveauGravite: "warning",
+		Titre:         titreCriblageImpossible,
+		Description:   "L'identité n'a pas pu être comparée aux listes de surveillance lors de son dernier enregistrement",
+		Statut:        "active",
+		ActionRequise: "Relancer le criblage des listes de surveillance",
+	}
+	if err := db.Omit("Migrant").Create(&alerte).Error; err != nil {
+		log.Printf("Alerte d'échec du criblage de l'identité %s impossible : %v", identiteUUID, err)
+	}
+}
+
+// resoudreEchecsCriblage résout les alertes d'échec de criblage de l'identité une fois
+// le criblage effectué
+func resoudreEchecsCriblage(db *gorm.DB, identiteUUID string) error {
+	now := time.Now()
+	return db.Model(&models.Alert{}).
+		Where("titre = ? AND statut = ? AND migrant_uuid IN (?)", titreCriblageImpossible, "active",
+			db.Model(&models.Migrant{}).Select("uuid").Where("identite_uuid = ?", identiteUUID)).
+		Updates(map[string]interface{}{
+			"statut":             "resolved",
+			"date_resolution":    &now,
+			"comment_resolution": "Résolue automatiquement par un nouveau criblage",
+		}).Error
+}
+
+// CriblerListe compare toutes les identités enregistrées aux entrées d'une liste
+func CriblerListe(db *gorm.DB, listeUUID string) (int, error) {
+	var entrees []models.EntreeSurveillance
+	if err := db.Where("liste_uuid = ?", listeUUID).Find(&entrees).Error; err != nil {
+		return 0, err
+	}
+
+	parDocument := map[string][]models.EntreeSurveillance{}
+	parDate := map[string][]models.EntreeSurveillance{}
+	for _, entree := range entrees {
+		if entree.DocumentNormalise != "" {
+			parDocument[entree.DocumentNormalise] = append(parDocument[entree.DocumentNormalise], entree)
+		}
+		if entree.DateNaissance != nil {
+			cle := entree.DateNaissance.Format("2006-01-02")
+			parDate[cle] = append(parDate[cle], entree)
+		}
+	}
+
+	total := 0
+	concernees := map[string]bool{}
+	var identites []models.Identite
+	err := db.Select("uuid", "nom", "postnom", "prenom", "date_naissance", "numero_passeport").
+		FindInBatches(&identites, 1000, func(batch *gorm.DB, _ int) error {
+			for _, identite := range identites {
+				candidates := append([]models.EntreeSurveillance{},
+					parDocument[utils.NormalizeDocumentNumber(identite.NumeroPasseport)]...)
+				candidates = append(candidates, parDate[identite.DateNaissance.Format("2006-01-02")]...)
+
+				vues := map[string]bool{}
+				for _, entree := range candidates {
+					if vues[entree.UUID] {
+						continue
+					}
+					vues[entree.UUID] = true
+
+					typeCorrespondance, score, ok := comparer(identite, entree)
+					if !ok {
+						continue
+					}
+					_, cree, err := enregistrerCorrespondance(db, identite.UUID, entree, typeCorrespondance, score)
+					if err != nil {
+						return err
+					}
+					if cree {
+						total++
+						concernees[identite.UUID] = true
+					}
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return total, err
+	}
+
+	for identiteUUID := range concernees {
+		if err := ouvrirAlertes(db, identiteUUID); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// enregistrerCorrespondance crée la correspondance si elle n'a jamais été relevée.
+// Une correspondance écartée par un agent n'est pas recréée.
+func enregistrerCorrespondance(db *gorm.DB, identiteUUID string, entree models.EntreeSurveillance, typeCorrespondance string, score float64) (models.CorrespondanceSurveillance, bool, error) {
+	var existante models.CorrespondanceSurveillance
+	err := db.Where("identite_uuid = ? AND entree_uuid = ?", identiteUUID, entree.UUID).First(&existante).Error
+	if err == nil {
+		return existante, false, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return existante, false, err
+	}
+
+	hit := models.CorrespondanceSurveillance{
+		UUID:               utils.GenerateUUID(),
+		IdentiteUUID:       identiteUUID,
+		EntreeUUID:         entree.UUID,
+		ListeUUID:          entree.ListeUUID,
+		TypeCorrespondance: typeCorrespondance,
+		Score:              score,
+		Statut:             "en_attente",
+	}
+	if err := db.Omit("Identite", "Entree", "Liste").Create(&hit).Error; err != nil {
+		return hit, false, err
+	}
+	hit.Entree = entree
+	return hit, true, nil
+}
+
+// ouvrirAlertes crée une alerte de sécurité pour chaque correspondance non écartée
+// qui n'en a pas encore, sur le migrant le plus récent de l'identité
+func ouvrirAlertes(db *gorm.DB, identiteUUID string) error {
+	var hits []models.CorrespondanceSurveillance
+	err := db.Where("identite_uuid = ? AND statut <> ? AND (alerte_uuid = '' OR alerte_uuid IS NULL)", identiteUUID, "ecartee").
+		Preload("Entree").
+		Preload("Liste").
+		Find(&hits).Error
+	if err != nil || len(hits) == 0 {
+		return err
+	}
+
+	var migrant models.Migrant
+	if err := db.Where("identite_uuid = ?", identiteUUID).Order("created_at DESC").First(&migrant).Error; err != nil {
+		// Aucun migrant encore rattaché : l'alerte sera ouverte à son enregistrement
+		return nil
+	}
+
+	for _, hit := range hits {
+		alerte := nouvelleAlerte(migrant.UUID, hit)
+		if err := db.Omit("Migrant").Create(&alerte).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&hit).UpdateColumn("alerte_uuid", alerte.UUID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nouvelleAlerte prépare l'alerte de sécurité correspondant à un résultat de criblage
+func nouvelleAlerte(migrantUUID string, hit models.CorrespondanceSurveillance) models.Alert {
+	niveau := "danger"
+	if hit.Statut == "confirmee" {
+		niveau = "critical"
+	}
+
+	description := fmt.Sprintf("L'identité correspond à l'entrée « %s » de la liste « %s »", hit.Entree.NomComplet, hit.Liste.Nom)
+	if hit.TypeCorrespondance == "document_exact" {
+		description = fmt.Sprintf("Le document %s figure sur la liste « %s »", hit.Entree.NumeroDocument, hit.Liste.Nom)
+	}
+	if hit.Entree.Motif != "" {
+		description += " (" + hit.Entree.Motif + ")"
+	}
+	if hit.Entree.Reference != "" {
+		description += ". Référence : " + hit.Entree.Reference
+	}
+
+	return models.Alert{
+		UUID:          utils.GenerateUUID(),
+		MigrantUUID:   migrantUUID,
+		TypeAlerte:    "securite",
+		NiveauGravite: niveau,
+		Titre:         "Correspondance avec une liste de surveillance",
+		Description:   description,
+		Statut:        "active",
+		ActionRequise: "Vérifier la correspondance dans la file de revue des listes de surveillance",
+	}
+}
+
+// resoudreAlerte met à jour l'alerte liée à une correspondance après la revue
+func resoudreAlerte(db *gorm.DB, hit models.CorrespondanceSurveillance, now time.Time) error {
+	if hit.AlerteUUID == "" {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	switch hit.Statut {
+	case "confirmee":
+		updates["niveau_gravite"] = "critical"
+		updates["action_requise"] = "Correspondance confirmée : appliquer la procédure de la liste « " + hit.Liste.Nom + " »"
+	case "ecartee":
+		updates["statut"] = "dismissed"
+		updates["date_resolution"] = &now
+		updates["comment_resolution"] = "Correspondance écartée lors de la revue. " + hit.Commentaire
+	}
+
+	return db.Model(&models.Alert{}).Where("uuid = ?", hit.AlerteUUID).Updates(updates).Error
+}
@@ -0,0 +1,48 @@
+package watchlist
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+)
+
+// Un échec de criblage ouvre une seule alerte sur le migrant, résolue au criblage suivant
+func TestSignalerEchecCriblage(t *testing.T) {
+	db := basetest.Migree(t)
+
+	identite := models.Identite{UUID: "identite", Nom: "KABONGO", NumeroPasseport: "OP1"}
+	migrant := models.Migrant{UUID: "migrant", IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-1", StatutMigratoire: "regulier"}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Omit("Identite").Create(&migrant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	alertes := func(statut string) int64 {
+		t.Helper()
+		var n int64
+		err := db.Model(&models.Alert{}).
+			Where("migrant_uuid = ? AND titre = ? AND statut = ?", migrant.UUID, titreCriblageImpossible, statut).
+			Count(&n).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	SignalerEchecCriblage(db, identite.UUID, errors.New("base indisponible"))
+	SignalerEchecCriblage(db, identite.UUID, errors.New("base indisponible"))
+	if n := alertes("active"); n != 1 {
+		t.Fatalf("%d alerte(s) active(s), 1 attendue", n)
+	}
+
+	if _, err := CriblerIdentite(db, identite); err != nil {
+		t.Fatal(err)
+	}
+	if actives, resolues := alertes("active"), alertes("resolved"); actives != 0 || resolues != 1 {
+		t.Errorf("%d alerte(s) active(s) et %d résolue(s) après criblage, attendu 0 et 1", actives, resolues)
+	}
+}
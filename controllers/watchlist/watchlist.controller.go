@@ -0,0 +1,478 @@
+package watchlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Nombre maximal d'erreurs de ligne renvoyées par un import
+const maxErreursImport = 50
+
+// =======================
+// LISTES DE SURVEILLANCE
+// =======================
+
+// Champs des listes de surveillance pouvant être filtrés, triés et sélectionnés
+var listeListes = requetes.Schema{
+	Table: "watchlists",
+	Champs: map[string]requetes.Type{
+		"uuid":           requetes.Texte,
+		"created_at":     requetes.Date,
+		"updated_at":     requetes.Date,
+		"nom":            requetes.Texte,
+		"type_liste":     requetes.Texte,
+		"source":         requetes.Texte,
+		"actif":          requetes.Booleen,
+		"nombre_entrees": requetes.Nombre,
+		"date_import":    requetes.Date,
+		"importe_par":    requetes.Texte,
+	},
+	TriDefaut: "-created_at",
+}
+
+// Paginate - Récupérer les listes de surveillance
+func GetPaginatedListes(c *fiber.Ctx) error {
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeListes)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	search := c.Query("search", "")
+
+	var listes []models.ListeSurveillance
+
+	query := db.Model(&models.ListeSurveillance{})
+	if search != "" {
+		query = query.Where("nom ILIKE ? OR source ILIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if typeListe := c.Query("type_liste", ""); typeListe != "" {
+		query = query.Where("type_liste = ?", typeListe)
+	}
+
+	data, pagination, err := liste.Executer(query, &listes)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch watchlists", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Watchlists retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// Get one data
+func GetListe(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+	var liste models.ListeSurveillance
+
+	if err := db.Where("uuid = ?", uuid).First(&liste).Error; err != nil {
+		return problemes.Absent("Watchlist not found", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist found",
+		"data":    liste,
+	})
+}
+
+// Champs des entrées de liste pouvant être filtrés, triés et sélectionnés
+var listeEntrees = requetes.Schema{
+	Table: "watchlist_entries",
+	Champs: map[string]requetes.Type{
+		"uuid":            requetes.Texte,
+		"created_at":      requetes.Date,
+		"updated_at":      requetes.Date,
+		"nom_complet":     requetes.Texte,
+		"date_naissance":  requetes.Date,
+		"nationalite":     requetes.Texte,
+		"numero_document": requetes.Texte,
+		"reference":       requetes.Texte,
+	},
+	TriDefaut: "nom_complet",
+}
+
+// GetListeEntrees - Entrées d'une liste avec pagination
+func GetListeEntrees(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	liste, erreurs := requetes.Lire(c, listeEntrees)
+	if len(erreurs) > 0 {
+		return requetes.Invalide(c, erreurs)
+	}
+
+	var entrees []models.EntreeSurveillance
+
+	query := db.Model(&models.EntreeSurveillance{}).Where("liste_uuid = ?", uuid)
+	if search := c.Query("search", ""); search != "" {
+		query = query.Where("nom_complet ILIKE ? OR numero_document ILIKE ? OR reference ILIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	data, pagination, err := liste.Executer(query, &entrees)
+
+	if err != nil {
+		return problemes.Echec("Failed to fetch watchlist entries", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Watchlist entries retrieved successfully",
+		"data":       data,
+		"pagination": pagination,
+	})
+}
+
+// ligneImport est le format d'une entrée dans un fichier JSON importé
+type ligneImport struct {
+	NomComplet     string `json:"nom_complet"`
+	DateNaissance  string `json:"date_naissance"`
+	Nationalite    string `json:"nationalite"`
+	NumeroDocument string `json:"numero_document"`
+	Motif          string `json:"motif"`
+	Reference      string `json:"reference"`
+}
+
+// Colonnes CSV acceptées pour chaque champ
+var colonnesCSV = map[string]string{
+	"nom_complet":      "nom_complet",
+	"nom":              "nom_complet",
+	"name":             "nom_complet",
+	"full_name":        "nom_complet",
+	"date_naissance":   "date_naissance",
+	"date_of_birth":    "date_naissance",
+	"dob":              "date_naissance",
+	"nationalite":      "nationalite",
+	"nationality":      "nationalite",
+	"numero_document":  "numero_document",
+	"numero_passeport": "numero_document",
+	"document_number":  "numero_document",
+	"passport":         "numero_document",
+	"motif":            "motif",
+	"reason":           "motif",
+	"reference":        "reference",
+}
+
+// lireCSV convertit un fichier CSV avec ligne d'en-tête en lignes d'import
+func lireCSV(r io.Reader) ([]ligneImport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	// Fichiers exportés avec le séparateur ; (tableurs en français)
+	entete, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(entete, ";") > strings.Count(entete, ",") {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+
+	index := map[string]int{}
+	for i, colonne := range records[0] {
+		cle := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(colonne, "\ufeff")))
+		if champ, ok := colonnesCSV[cle]; ok {
+			index[champ] = i
+		}
+	}
+	if len(index) == 0 {
+		return nil, fmt.Errorf("no recognised column in header")
+	}
+
+	valeur := func(record []string, champ string) string {
+		if i, ok := index[champ]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	lignes := make([]ligneImport, 0, len(records)-1)
+	for _, record := range records[1:] {
+		lignes = append(lignes, ligneImport{
+			NomComplet:     valeur(record, "nom_complet"),
+			DateNaissance:  valeur(record, "date_naissance"),
+			Nationalite:    valeur(record, "nationalite"),
+			NumeroDocument: valeur(record, "numero_document"),
+			Motif:          valeur(record, "motif"),
+			Reference:      valeur(record, "reference"),
+		})
+	}
+	return lignes, nil
+}
+
+// parseDate accepte les formats de date usuels des listes transmises
+func parseDate(s string) (*time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2006/01/02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q", s)
+}
+
+// ImportListe - Importer une liste depuis un fichier CSV ou JSON
+// POST /api/watchlists/import (multipart : file, nom, type_liste, source, description)
+// Avec liste_uuid, les entrées sont ajoutées à une liste existante (remplacer=true pour la vider d'abord)
+func ImportListe(c *fiber.Ctx) error {
+	db := database.DB
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return problemes.Invalide("A CSV or JSON file is required").Cause(err)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return problemes.Invalide("Unable to read uploaded file").Cause(err)
+	}
+	defer src.Close()
+
+	var lignes []ligneImport
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".csv", ".txt":
+		lignes, err = lireCSV(src)
+	case ".json":
+		err = json.NewDecoder(src).Decode(&lignes)
+	default:
+		return problemes.Invalide("Unsupported file format, expected .csv or .json")
+	}
+	if err != nil {
+		return problemes.Invalide("Unable to parse watchlist file").Cause(err)
+	}
+
+	userUUID, _ := utils.VerifyJwt(c.Query("token"))
+	now := time.Now()
+
+	// Liste cible : existante ou nouvelle
+	var liste models.ListeSurveillance
+	nouvelle := c.FormValue("liste_uuid") == ""
+	if nouvelle {
+		liste = models.ListeSurveillance{
+			UUID:        utils.GenerateUUID(),
+			Nom:         c.FormValue("nom"),
+			TypeListe:   c.FormValue("type_liste"),
+			Source:      c.FormValue("source"),
+			Description: c.FormValue("description"),
+			Actif:       true,
+		}
+		if err := utils.ValidateStruct(liste); err != nil {
+			return problemes.Validation(err)
+		}
+	} else if err := db.Where("uuid = ?", c.FormValue("liste_uuid")).First(&liste).Error; err != nil {
+		return problemes.Absent("Watchlist not found", err)
+	}
+
+	// Conversion et contrôle des lignes (la ligne 1 est l'en-tête en CSV)
+	var entrees []models.EntreeSurveillance
+	var erreurs []string
+	rejetees := 0
+	for i, ligne := range lignes {
+		numero := i + 2
+		if ligne.NomComplet == "" && ligne.NumeroDocument == "" {
+			rejetees++
+			if len(erreurs) < maxErreursImport {
+				erreurs = append(erreurs, fmt.Sprintf("line %d: nom_complet or numero_document is required", numero))
+			}
+			continue
+		}
+
+		entree := models.EntreeSurveillance{
+			UUID:           utils.GenerateUUID(),
+			ListeUUID:      liste.UUID,
+			NomComplet:     ligne.NomComplet,
+			Nationalite:    ligne.Nationalite,
+			NumeroDocument: ligne.NumeroDocument,
+			Motif:          ligne.Motif,
+			Reference:      ligne.Reference,
+		}
+		if ligne.DateNaissance != "" {
+			date, err := parseDate(ligne.DateNaissance)
+			if err != nil {
+				rejetees++
+				if len(erreurs) < maxErreursImport {
+					erreurs = append(erreurs, fmt.Sprintf("line %d: %s", numero, err.Error()))
+				}
+				continue
+			}
+			entree.DateNaissance = date
+		}
+		entree.RefreshMatchKeys()
+		entrees = append(entrees, entree)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if nouvelle {
+			if err := tx.Create(&liste).Error; err != nil {
+				return err
+			}
+		} else if c.FormValue("remplacer") == "true" {
+			if err := tx.Where("liste_uuid = ?", liste.UUID).Delete(&models.EntreeSurveillance{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(entrees) > 0 {
+			if err := tx.CreateInBatches(&entrees, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		var total int64
+		tx.Model(&models.EntreeSurveillance{}).Where("liste_uuid = ?", liste.UUID).Count(&total)
+		liste.NombreEntrees = int(total)
+		liste.DateImport = &now
+		liste.ImportePar = userUUID
+		return tx.Model(&liste).Updates(map[string]interface{}{
+			"nombre_entrees": liste.NombreEntrees,
+			"date_import":    liste.DateImport,
+			"importe_par":    liste.ImportePar,
+		}).Error
+	})
+
+	if err != nil {
+		return problemes.Echec("Failed to import watchlist", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist imported successfully",
+		"data": fiber.Map{
+			"liste":     liste,
+			"importees": len(entrees),
+			"rejetees":  rejetees,
+			"erreurs":   erreurs,
+		},
+	})
+}
+
+// Update data
+func UpdateListe(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var updateData struct {
+		Nom         string `json:"nom"`
+		Source      string `json:"source"`
+		Description string `json:"description"`
+		Actif       *bool  `json:"actif"`
+	}
+
+	if err := c.BodyParser(&updateData); err != nil {
+		return problemes.Invalide("Review your input").Cause(err)
+	}
+
+	liste := new(models.ListeSurveillance)
+	if err := db.Where("uuid = ?", uuid).First(&liste).Error; err != nil {
+		return problemes.Absent("Watchlist not found", err)
+	}
+
+	updates := map[string]interface{}{}
+	if updateData.Nom != "" {
+		updates["nom"] = updateData.Nom
+	}
+	if updateData.Source != "" {
+		updates["source"] = updateData.Source
+	}
+	if updateData.Description != "" {
+		updates["description"] = updateData.Description
+	}
+	if updateData.Actif != nil {
+		updates["actif"] = *updateData.Actif
+	}
+
+	if err := db.Model(&liste).Updates(updates).Error; err != nil {
+		return problemes.Echec("Failed to update watchlist", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist updated successfully",
+		"data":    liste,
+	})
+}
+
+// Delete data - supprime la liste et ses entrées ; les correspondances déjà relevées sont conservées
+func DeleteListe(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var liste models.ListeSurveillance
+	if err := db.Where("uuid = ?", uuid).First(&liste).Error; err != nil {
+		return problemes.Absent("Watchlist not found", err)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("liste_uuid = ?", uuid).Delete(&models.EntreeSurveillance{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&liste).Error
+	})
+	if err != nil {
+		return problemes.Echec("Failed to delete watchlist", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist deleted successfully",
+		"data":    nil,
+	})
+}
+
+// RescreenListe - Cribler toutes les identités enregistrées contre une liste
+// POST /api/watchlists/rescreen/:uuid
+func RescreenListe(c *fiber.Ctx) error {
+	uuid := c.Params("uuid")
+	db := database.DB
+
+	var liste models.ListeSurveillance
+	if err := db.Where("uuid = ?", uuid).First(&liste).Error; err != nil {
+		return problemes.Absent("Watchlist not found", err)
+	}
+	if !liste.Actif {
+		return problemes.Invalide("Watchlist is not active")
+	}
+
+	total, err := CriblerListe(db, liste.UUID)
+	if err != nil {
+		return problemes.Echec("Failed to screen identities", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Identities screened successfully",
+		"data": fiber.Map{
+			"nouvelles_correspondances": total,
+		},
+	})
+}
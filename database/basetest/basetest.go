@@ -0,0 +1,122 @@
+// Package basetest fournit aux tests une base Postgres isolée.
+//
+// Les tests qui en dépendent sont ignorés si TEST_DATABASE_URL n'est pas défini,
+// par exemple : TEST_DATABASE_URL="host=localhost user=postgres dbname=sysmobembo_test sslmode=disable"
+package basetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Ouvrir retourne une connexion dont les tables sont créées dans un schéma propre au
+// test (search_path), supprimé à la fin du test
+func Ouvrir(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL non défini : test Postgres ignoré")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connexion à la base de test: %v", err)
+	}
+
+	suffixe := make([]byte, 6)
+	rand.Read(suffixe)
+	schema := "test_" + hex.EncodeToString(suffixe)
+	if err := admin.Exec(`CREATE SCHEMA "` + schema + `"`).Error; err != nil {
+		t.Fatalf("création du schéma %s: %v", schema, err)
+	}
+
+	// public reste dans le chemin pour les extensions déjà installées (pg_trgm)
+	db, err := gorm.Open(postgres.Open(avecSearchPath(dsn, schema+",public")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connexion au schéma %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		admin.Exec(`DROP SCHEMA "` + schema + `" CASCADE`)
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// Migree retourne une base isolée au schéma courant (toutes les migrations appliquées)
+func Migree(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db := Ouvrir(t)
+	if _, err := database.Monter(db, 0); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	return db
+}
+
+// Administrateur crée un utilisateur de rôle national et retourne un jeton pour le
+// paramètre ?token= des requêtes
+func Administrateur(t testing.TB, db *gorm.DB) string {
+	t.Helper()
+	return Utilisateur(t, db, "Admin", "")
+}
+
+// Utilisateur crée un utilisateur du rôle et de l'unité donnés et retourne son jeton
+func Utilisateur(t testing.TB, db *gorm.DB, role, uniteUUID string) string {
+	t.Helper()
+
+	if utils.SECRET_KEY == "" {
+		utils.SECRET_KEY = "secret-de-test"
+	}
+	uuid := utils.GenerateUUID()
+	user := models.User{
+		UUID:       uuid,
+		Nom:        strings.ToUpper(role),
+		Email:      uuid + "@test.local",
+		Telephone:  uuid,
+		Matricule:  uuid,
+		NumeroCNI:  uuid,
+		NumeroCNSS: uuid,
+		Role:       role,
+		UniteUUID:  uniteUUID,
+		Status:     true,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("création de l'utilisateur %s: %v", role, err)
+	}
+	token, err := utils.GenerateJwt(user.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// avecSearchPath ajoute le paramètre search_path à une chaîne de connexion, sous forme
+// d'URL (postgres://...) ou de mots-clés (host=... dbname=...)
+func avecSearchPath(dsn, chemin string) string {
+	if strings.Contains(dsn, "://") {
+		separateur := "?"
+		if strings.Contains(dsn, "?") {
+			separateur = "&"
+		}
+		return dsn + separateur + "search_path=" + chemin
+	}
+	return fmt.Sprintf("%s search_path=%s", dsn, chemin)
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const usageMigrate = `usage: sysmobembo-api migrate <commande>
+
+  up [n]        applique les n prochaines migrations (toutes par défaut)
+  down [n]      annule les n dernières migrations (une par défaut)
+  status        affiche l'état des migrations
+  force <v>     marque le schéma à la version v sans exécuter de script
+  create <nom>  crée les scripts vides de la prochaine migration`
+
+// Migrer exécute la sous-commande migrate
+func Migrer(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usageMigrate)
+	}
+
+	nombre := func() (int, error) {
+		if len(args) < 2 {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("nombre de migrations invalide: %s", args[1])
+		}
+		return n, nil
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := nombre()
+		if err != nil {
+			return err
+		}
+		Ouvrir()
+		appliquees, err := Monter(DB, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d migration(s) appliquée(s)\n", appliquees)
+	case "down":
+		n, err := nombre()
+		if err != nil {
+			return err
+		}
+		Ouvrir()
+		annulees, err := Descendre(DB, n)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ %d migration(s) annulée(s)\n", annulees)
+	case "status":
+		Ouvrir()
+		return afficherEtat()
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", usageMigrate)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("version invalide: %s", args[1])
+		}
+		Ouvrir()
+		if err := Forcer(DB, version); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Schéma marqué à la version %d\n", version)
+	case "create":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", usageMigrate)
+		}
+		return creerMigration(args[1])
+	default:
+		return fmt.Errorf("%s", usageMigrate)
+	}
+	return nil
+}
+
+func afficherEtat() error {
+	etats, err := EtatMigrations(DB)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNOM\tÉTAT\tAPPLIQUÉE LE")
+	for _, etat := range etats {
+		statut, date := "en attente", ""
+		switch {
+		case etat.Dirty:
+			statut = "interrompue"
+		case etat.Appliquee && etat.Haut == "":
+			statut = "inconnue"
+		case etat.Appliquee:
+			statut = "appliquée"
+		}
+		if etat.AppliqueLe != nil {
+			date = etat.AppliqueLe.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", etat.Version, etat.Nom, statut, date)
+	}
+	return w.Flush()
+}
+
+// creerMigration écrit les scripts up et down de la prochaine version dans les sources
+func creerMigration(nom string) error {
+	nom = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(nom), "_"), "_")
+	if nom == "" {
+		return fmt.Errorf("nom de migration invalide")
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	version := int64(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	for _, sens := range []string{"up", "down"} {
+		chemin := filepath.Join(RepertoireMigrations, fmt.Sprintf("%04d_%s.%s.sql", version, nom, sens))
+		if err := os.WriteFile(chemin, []byte(fmt.Sprintf("-- %04d_%s (%s)\n", version, nom, sens)), 0o644); err != nil {
+			return err
+		}
+		fmt.Println("📝", chemin)
+	}
+	return nil
+}
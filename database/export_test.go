@@ -0,0 +1,4 @@
+package database
+
+// SortieMotsDePasse permet aux tests de lire les mots de passe affichés
+var SortieMotsDePasse = &sortieMotsDePasse
@@ -0,0 +1,376 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var fichiersMigrations embed.FS
+
+// Répertoire des scripts dans les sources, utilisé par "migrate create"
+const RepertoireMigrations = "database/migrations"
+
+// Un script qui commence par cette ligne est exécuté hors transaction
+// (CREATE INDEX CONCURRENTLY, ALTER TYPE ... ADD VALUE)
+const directiveSansTransaction = "-- migrate:sans-transaction"
+
+// Verrou consultatif Postgres pris pendant les migrations, pour que plusieurs
+// instances démarrées en même temps ne migrent pas le schéma en parallèle
+const verrouMigrations = 7283461902
+
+var nomMigration = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrSchemaSale - une migration hors transaction a été interrompue
+var ErrSchemaSale = errors.New("schéma de base de données dans un état incohérent")
+
+// Migration - évolution versionnée du schéma : NNNN_nom.up.sql et NNNN_nom.down.sql
+type Migration struct {
+	Version         int64
+	Nom             string
+	Haut            string
+	Bas             string
+	SansTransaction bool
+	// Données - étape écrite en Go, exécutée après le script up
+	Donnees func(tx *gorm.DB) error
+}
+
+// etapesDonnees - calculs de migrations de données impossibles en SQL (clés phonétiques,
+// empreintes biométriques), exécutés dans la transaction de la migration de même version
+var etapesDonnees = map[int64]func(tx *gorm.DB) error{
+	6: rattacherDonneesAnterieures,
+}
+
+// VersionSchema - migration appliquée, enregistrée dans schema_migrations
+type VersionSchema struct {
+	Version    int64 `gorm:"primaryKey;autoIncrement:false"`
+	Nom        string
+	Dirty      bool
+	AppliqueLe time.Time
+}
+
+func (VersionSchema) TableName() string {
+	return "schema_migrations"
+}
+
+// EtatMigration - migration connue du binaire et son état dans la base
+type EtatMigration struct {
+	Migration
+	Appliquee  bool
+	Dirty      bool
+	AppliqueLe *time.Time
+}
+
+// Migrations retourne les migrations embarquées, par version croissante
+func Migrations() ([]Migration, error) {
+	fichiers, err := fs.ReadDir(fichiersMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	parVersion := map[int64]*Migration{}
+	for _, fichier := range fichiers {
+		parties := nomMigration.FindStringSubmatch(fichier.Name())
+		if parties == nil {
+			return nil, fmt.Errorf("nom de migration invalide: %s", fichier.Name())
+		}
+		version, _ := strconv.ParseInt(parties[1], 10, 64)
+		contenu, err := fichiersMigrations.ReadFile(path.Join("migrations", fichier.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := parVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Nom: parties[2], Donnees: etapesDonnees[version]}
+			parVersion[version] = m
+		}
+		if m.Nom != parties[2] {
+			return nil, fmt.Errorf("migration %d: noms différents %s et %s", version, m.Nom, parties[2])
+		}
+		script := string(contenu)
+		if strings.HasPrefix(strings.TrimSpace(script), directiveSansTransaction) {
+			m.SansTransaction = true
+		}
+		if parties[3] == "up" {
+			m.Haut = script
+		} else {
+			m.Bas = script
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range parVersion {
+		if m.Haut == "" {
+			return nil, fmt.Errorf("migration %d_%s: script up manquant", m.Version, m.Nom)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// EtatMigrations retourne l'état de chaque migration, plus les versions appliquées
+// inconnues du binaire (base migrée par une version plus récente de l'API)
+func EtatMigrations(db *gorm.DB) ([]EtatMigration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := creerTableVersions(db); err != nil {
+		return nil, err
+	}
+	var appliquees []VersionSchema
+	if err := db.Order("version").Find(&appliquees).Error; err != nil {
+		return nil, err
+	}
+
+	parVersion := map[int64]VersionSchema{}
+	for _, v := range appliquees {
+		parVersion[v.Version] = v
+	}
+
+	var etats []EtatMigration
+	for _, m := range migrations {
+		etat := EtatMigration{Migration: m}
+		if v, ok := parVersion[m.Version]; ok {
+			etat.Appliquee, etat.Dirty = true, v.Dirty
+			appliqueLe := v.AppliqueLe
+			etat.AppliqueLe = &appliqueLe
+			delete(parVersion, m.Version)
+		}
+		etats = append(etats, etat)
+	}
+	for _, v := range appliquees {
+		if _, inconnue := parVersion[v.Version]; inconnue {
+			appliqueLe := v.AppliqueLe
+			etats = append(etats, EtatMigration{
+				Migration:  Migration{Version: v.Version, Nom: v.Nom},
+				Appliquee:  true,
+				Dirty:      v.Dirty,
+				AppliqueLe: &appliqueLe,
+			})
+		}
+	}
+	sort.Slice(etats, func(i, j int) bool { return etats[i].Version < etats[j].Version })
+	return etats, nil
+}
+
+// VerifierSchema retourne le nombre de migrations en attente, ou ErrSchemaSale si
+// une migration a été interrompue
+func VerifierSchema(db *gorm.DB) (int, error) {
+	etats, err := EtatMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+	enAttente := 0
+	for _, etat := range etats {
+		if etat.Dirty {
+			return 0, fmt.Errorf("%w: migration %d_%s interrompue, corrigez le schéma puis exécutez \"migrate force %d\"",
+				ErrSchemaSale, etat.Version, etat.Nom, etat.Version)
+		}
+		if !etat.Appliquee {
+			enAttente++
+		}
+		if etat.Appliquee && etat.Haut == "" {
+			log.Printf("⚠️ Migration %d_%s appliquée mais inconnue de cette version de l'API", etat.Version, etat.Nom)
+		}
+	}
+	return enAttente, nil
+}
+
+// Monter applique les n prochaines migrations en attente (toutes si n <= 0)
+// et retourne le nombre de migrations appliquées
+func Monter(db *gorm.DB, n int) (int, error) {
+	appliquees := 0
+	err := avecVerrou(db, func(conn *gorm.DB) error {
+		if _, err := VerifierSchema(conn); err != nil {
+			return err
+		}
+		etats, err := EtatMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, etat := range etats {
+			if etat.Appliquee {
+				continue
+			}
+			if n > 0 && appliquees == n {
+				break
+			}
+			debut := time.Now()
+			if err := executer(conn, etat.Migration, true); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", etat.Version, etat.Nom, err)
+			}
+			log.Printf("⬆️ Migration %d_%s appliquée (%s)", etat.Version, etat.Nom, time.Since(debut).Round(time.Millisecond))
+			appliquees++
+		}
+		return nil
+	})
+	return appliquees, err
+}
+
+// Descendre annule les n dernières migrations appliquées (une seule si n <= 0)
+func Descendre(db *gorm.DB, n int) (int, error) {
+	if n <= 0 {
+		n = 1
+	}
+	annulees := 0
+	err := avecVerrou(db, func(conn *gorm.DB) error {
+		if _, err := VerifierSchema(conn); err != nil {
+			return err
+		}
+		etats, err := EtatMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(etats) - 1; i >= 0 && annulees < n; i-- {
+			etat := etats[i]
+			if !etat.Appliquee {
+				continue
+			}
+			if etat.Haut == "" {
+				return fmt.Errorf("migration %d_%s inconnue de cette version de l'API", etat.Version, etat.Nom)
+			}
+			if etat.Bas == "" {
+				return fmt.Errorf("migration %d_%s: script down manquant", etat.Version, etat.Nom)
+			}
+			if err := executer(conn, etat.Migration, false); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", etat.Version, etat.Nom, err)
+			}
+			log.Printf("⬇️ Migration %d_%s annulée", etat.Version, etat.Nom)
+			annulees++
+		}
+		return nil
+	})
+	return annulees, err
+}
+
+// Forcer enregistre le schéma comme étant exactement à la version donnée, sans exécuter
+// de script : les migrations jusqu'à cette version sont marquées appliquées et propres,
+// les suivantes non appliquées. Sert après une correction manuelle d'une migration
+// interrompue. Version 0 : aucune migration appliquée.
+func Forcer(db *gorm.DB, version int64) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	return avecVerrou(db, func(conn *gorm.DB) error {
+		if err := creerTableVersions(conn); err != nil {
+			return err
+		}
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("version > ?", version).Delete(&VersionSchema{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&VersionSchema{}).Where("dirty").Update("dirty", false).Error; err != nil {
+				return err
+			}
+			for _, m := range migrations {
+				if m.Version > version {
+					break
+				}
+				if err := tx.Where(VersionSchema{Version: m.Version}).
+					Attrs(VersionSchema{Nom: m.Nom, AppliqueLe: time.Now()}).
+					FirstOrCreate(&VersionSchema{}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func creerTableVersions(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS "schema_migrations" (
+		"version" bigint PRIMARY KEY,
+		"nom" text NOT NULL,
+		"dirty" boolean NOT NULL DEFAULT false,
+		"applique_le" timestamptz NOT NULL DEFAULT now()
+	)`).Error
+}
+
+// avecVerrou exécute fn sur une connexion dédiée qui détient le verrou des migrations
+func avecVerrou(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", verrouMigrations).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", verrouMigrations)
+		return fn(conn)
+	})
+}
+
+// executer applique (haut) ou annule une migration et met à jour schema_migrations.
+// Le script, son étape de données et l'enregistrement de la version sont dans la même
+// transaction ; un
+// script hors transaction marque la version dirty le temps de son exécution.
+func executer(conn *gorm.DB, m Migration, haut bool) error {
+	script := m.Bas
+	if haut {
+		script = m.Haut
+	}
+	appliquer := func(tx *gorm.DB) error {
+		if !sansInstruction(script) {
+			if err := tx.Exec(script).Error; err != nil {
+				return err
+			}
+		}
+		if haut && m.Donnees != nil {
+			return m.Donnees(tx)
+		}
+		return nil
+	}
+	enregistrer := func(tx *gorm.DB) error {
+		if haut {
+			return tx.Create(&VersionSchema{Version: m.Version, Nom: m.Nom, AppliqueLe: time.Now()}).Error
+		}
+		return tx.Delete(&VersionSchema{}, "version = ?", m.Version).Error
+	}
+
+	if !m.SansTransaction {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := appliquer(tx); err != nil {
+				return err
+			}
+			return enregistrer(tx)
+		})
+	}
+
+	if haut {
+		if err := conn.Create(&VersionSchema{Version: m.Version, Nom: m.Nom, Dirty: true, AppliqueLe: time.Now()}).Error; err != nil {
+			return err
+		}
+	} else if err := conn.Model(&VersionSchema{}).Where("version = ?", m.Version).Update("dirty", true).Error; err != nil {
+		return err
+	}
+	if err := appliquer(conn); err != nil {
+		return err
+	}
+	if haut {
+		return conn.Model(&VersionSchema{}).Where("version = ?", m.Version).Update("dirty", false).Error
+	}
+	return enregistrer(conn)
+}
+
+// sansInstruction - script réduit à des commentaires (migration de données en Go)
+func sansInstruction(script string) bool {
+	for _, ligne := range strings.Split(script, "\n") {
+		ligne = strings.TrimSpace(ligne)
+		if ligne != "" && !strings.HasPrefix(ligne, "--") {
+			return false
+		}
+	}
+	return true
+}
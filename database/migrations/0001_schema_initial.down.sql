@@ -0,0 +1,30 @@
+-- Supprime toutes les tables du schéma initial
+DROP TABLE IF EXISTS "idempotency_keys" CASCADE;
+DROP TABLE IF EXISTS "sync_conflicts" CASCADE;
+DROP TABLE IF EXISTS "cross_unit_access_logs" CASCADE;
+DROP TABLE IF EXISTS "org_units" CASCADE;
+DROP TABLE IF EXISTS "import_errors" CASCADE;
+DROP TABLE IF EXISTS "imports" CASCADE;
+DROP TABLE IF EXISTS "counters" CASCADE;
+DROP TABLE IF EXISTS "watchlist_hits" CASCADE;
+DROP TABLE IF EXISTS "watchlist_entries" CASCADE;
+DROP TABLE IF EXISTS "watchlists" CASCADE;
+DROP TABLE IF EXISTS "crossings" CASCADE;
+DROP TABLE IF EXISTS "border_posts" CASCADE;
+DROP TABLE IF EXISTS "fusions_identites" CASCADE;
+DROP TABLE IF EXISTS "doublon_candidats" CASCADE;
+DROP TABLE IF EXISTS "dossier_etapes" CASCADE;
+DROP TABLE IF EXISTS "dossier_notes" CASCADE;
+DROP TABLE IF EXISTS "dossier_agents" CASCADE;
+DROP TABLE IF EXISTS "dossiers" CASCADE;
+DROP TABLE IF EXISTS "migrant_cards" CASCADE;
+DROP TABLE IF EXISTS "migrant_status_history" CASCADE;
+DROP TABLE IF EXISTS "relations_familiales" CASCADE;
+DROP TABLE IF EXISTS "geolocalisations" CASCADE;
+DROP TABLE IF EXISTS "biometries" CASCADE;
+DROP TABLE IF EXISTS "alertes" CASCADE;
+DROP TABLE IF EXISTS "motif_deplacements" CASCADE;
+DROP TABLE IF EXISTS "migrants" CASCADE;
+DROP TABLE IF EXISTS "identites" CASCADE;
+DROP TABLE IF EXISTS "password_resets" CASCADE;
+DROP TABLE IF EXISTS "users" CASCADE;
@@ -0,0 +1,649 @@
+-- Schéma initial, identique à celui que produisait AutoMigrate.
+-- IF NOT EXISTS permet d'adopter une base déjà créée par AutoMigrate ; les colonnes
+-- ajoutées aux modèles depuis la première version d'AutoMigrate sont complétées par
+-- ADD COLUMN IF NOT EXISTS, avant les index qui les utilisent. Leurs valeurs sont
+-- calculées par la migration 0006.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "nom" text NOT NULL,
+    "post_nom" text NOT NULL,
+    "prenom" text NOT NULL,
+    "sexe" text NOT NULL,
+    "date_naissance" timestamptz NOT NULL,
+    "lieu_naissance" text NOT NULL,
+    "etat_civil" text,
+    "nombre_enfants" bigint DEFAULT 0,
+    "nationalite" text NOT NULL,
+    "numero_cni" text,
+    "date_emission_cni" timestamptz,
+    "date_expiration_cni" timestamptz,
+    "lieu_emission_cni" text,
+    "email" text NOT NULL,
+    "telephone" text NOT NULL,
+    "telephone_urgence" text,
+    "province" text NOT NULL,
+    "ville" text NOT NULL,
+    "commune" text NOT NULL,
+    "quartier" text NOT NULL,
+    "avenue" text,
+    "numero" text,
+    "matricule" text NOT NULL,
+    "grade" text NOT NULL,
+    "fonction" text NOT NULL,
+    "service" text NOT NULL,
+    "direction" text NOT NULL,
+    "unite_uuid" varchar(255) DEFAULT '',
+    "ministere" text NOT NULL,
+    "date_recrutement" timestamptz NOT NULL,
+    "date_prise_service" timestamptz NOT NULL,
+    "type_agent" text NOT NULL,
+    "statut" text NOT NULL,
+    "niveau_etude" text,
+    "diplome_base" text,
+    "universite_ecole" text,
+    "annee_obtention" bigint,
+    "specialisation" text,
+    "numero_bancaire" text,
+    "banque" text,
+    "numero_cnss" text,
+    "numero_onem" text,
+    "photo_profil" text,
+    "cv_document" text,
+    "qr_code" text,
+    "qr_code_data" text,
+    "password" text,
+    "role" text,
+    "permission" text,
+    "status" boolean DEFAULT false,
+    "signature" text,
+    "dernier_acces" timestamptz,
+    "nombre_connexions" bigint DEFAULT 0,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_users_matricule" UNIQUE ("matricule"),
+    CONSTRAINT "uni_users_numero_cnss" UNIQUE ("numero_cnss"),
+    CONSTRAINT "uni_users_numero_cni" UNIQUE ("numero_cni"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email"),
+    CONSTRAINT "uni_users_telephone" UNIQUE ("telephone")
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "unite_uuid" varchar(255) DEFAULT '';
+CREATE INDEX IF NOT EXISTS "idx_users_unite_uuid" ON "users" ("unite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "password_resets" (
+    "uuid" text,
+    "email" text,
+    "token" text,
+    "expiration_time" timestamptz,
+    "created_at" timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS "identites" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "nom" text NOT NULL DEFAULT '',
+    "postnom" text NOT NULL DEFAULT '',
+    "prenom" text NOT NULL DEFAULT '',
+    "date_naissance" timestamptz,
+    "lieu_naissance" text DEFAULT '',
+    "sexe" varchar(1) DEFAULT '',
+    "nationalite" text DEFAULT '',
+    "adresse" text DEFAULT '',
+    "profession" text DEFAULT '',
+    "pays_emetteur" text NOT NULL DEFAULT '',
+    "autorite_emetteur" text NOT NULL DEFAULT '',
+    "date_emission" timestamptz,
+    "date_expiration" timestamptz,
+    "numero_passeport" text NOT NULL DEFAULT '',
+    "unite_uuid" varchar(255) DEFAULT '',
+    "version" bigint NOT NULL DEFAULT 1,
+    "cle_nom" text DEFAULT '',
+    "cle_phonetique" text DEFAULT '',
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_identites_numero_passeport" UNIQUE ("numero_passeport")
+);
+ALTER TABLE "identites" ADD COLUMN IF NOT EXISTS "unite_uuid" varchar(255) DEFAULT '';
+ALTER TABLE "identites" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+ALTER TABLE "identites" ADD COLUMN IF NOT EXISTS "cle_nom" text DEFAULT '';
+ALTER TABLE "identites" ADD COLUMN IF NOT EXISTS "cle_phonetique" text DEFAULT '';
+CREATE INDEX IF NOT EXISTS "idx_identites_cle_phonetique" ON "identites" ("cle_phonetique");
+CREATE INDEX IF NOT EXISTS "idx_identites_cle_nom" ON "identites" ("cle_nom");
+CREATE INDEX IF NOT EXISTS "idx_identites_unite_uuid" ON "identites" ("unite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_identites_deleted_at" ON "identites" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "migrants" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_uuid" varchar(255) NOT NULL,
+    "numero_identifiant" text NOT NULL,
+    "bureau_enregistrement" text DEFAULT '',
+    "unite_uuid" varchar(255) DEFAULT '',
+    "version" bigint NOT NULL DEFAULT 1,
+    "telephone" text,
+    "email" text,
+    "adresse_actuelle" text,
+    "ville_actuelle" text,
+    "pays_actuel" text,
+    "situation_matrimoniale" text,
+    "nombre_enfants" bigint DEFAULT 0,
+    "personne_contact" text,
+    "telephone_contact" text,
+    "statut_migratoire" text,
+    "date_entree" timestamptz,
+    "point_entree" text,
+    "pays_destination" text,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_migrants_numero_identifiant" UNIQUE ("numero_identifiant"),
+    CONSTRAINT "uni_migrants_email" UNIQUE ("email")
+);
+ALTER TABLE "migrants" ADD COLUMN IF NOT EXISTS "bureau_enregistrement" text DEFAULT '';
+ALTER TABLE "migrants" ADD COLUMN IF NOT EXISTS "unite_uuid" varchar(255) DEFAULT '';
+ALTER TABLE "migrants" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS "idx_migrants_unite_uuid" ON "migrants" ("unite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_migrants_bureau_enregistrement" ON "migrants" ("bureau_enregistrement");
+CREATE INDEX IF NOT EXISTS "idx_migrants_deleted_at" ON "migrants" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "motif_deplacements" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_motif" text,
+    "motif_principal" text,
+    "motif_secondaire" text,
+    "description" text,
+    "caractere_volontaire" boolean DEFAULT true,
+    "urgence" text,
+    "date_declenchement" timestamptz,
+    "duree_estimee" bigint,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_motif_deplacements_deleted_at" ON "motif_deplacements" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "alertes" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_alerte" text,
+    "niveau_gravite" text,
+    "titre" text,
+    "description" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    "statut" text DEFAULT 'active',
+    "date_expiration" timestamptz,
+    "action_requise" text,
+    "personne_responsable" text,
+    "date_resolution" timestamptz,
+    "comment_resolution" text,
+    PRIMARY KEY ("uuid")
+);
+ALTER TABLE "alertes" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS "idx_alertes_deleted_at" ON "alertes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "biometries" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_biometrie" text,
+    "index_doigt" bigint,
+    "qualite_donnee" text,
+    "donnees_biometriques" text NOT NULL,
+    "algorithme_encodage" text,
+    "taille_fichier" bigint,
+    "date_capture" timestamptz,
+    "disposif_capture" text,
+    "resolution_capture" text,
+    "operateur_capture" text,
+    "chiffre" boolean DEFAULT false,
+    "cle_chiffrement" text,
+    "empreinte_hash" text DEFAULT '',
+    "verifie" boolean DEFAULT false,
+    "date_verification" timestamptz,
+    "score_confiance" decimal,
+    PRIMARY KEY ("uuid")
+);
+ALTER TABLE "biometries" ADD COLUMN IF NOT EXISTS "empreinte_hash" text DEFAULT '';
+CREATE INDEX IF NOT EXISTS "idx_biometries_empreinte_hash" ON "biometries" ("empreinte_hash");
+CREATE INDEX IF NOT EXISTS "idx_biometries_deleted_at" ON "biometries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "geolocalisations" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_uuid" varchar(255) NOT NULL,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_geolocalisations_deleted_at" ON "geolocalisations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "relations_familiales" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "apparente_uuid" varchar(255) NOT NULL,
+    "type_relation" text NOT NULL,
+    "commentaire" text,
+    "enregistre_par" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_relations_familiales_apparente_uuid" ON "relations_familiales" ("apparente_uuid");
+CREATE INDEX IF NOT EXISTS "idx_relations_familiales_migrant_uuid" ON "relations_familiales" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_relations_familiales_deleted_at" ON "relations_familiales" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "migrant_status_history" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "statut_precedent" text,
+    "statut_migratoire" text,
+    "point_entree" text,
+    "pays_actuel" text,
+    "date_effet" timestamptz,
+    "date_fin" timestamptz,
+    "motif" text,
+    "reference_decision" text,
+    "effectue_par" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_migrant_status_history_date_effet" ON "migrant_status_history" ("date_effet");
+CREATE INDEX IF NOT EXISTS "idx_migrant_status_history_statut_migratoire" ON "migrant_status_history" ("statut_migratoire");
+CREATE INDEX IF NOT EXISTS "idx_migrant_status_history_migrant_uuid" ON "migrant_status_history" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_migrant_status_history_deleted_at" ON "migrant_status_history" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "migrant_cards" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "numero_identifiant" text NOT NULL,
+    "serie" text NOT NULL,
+    "date_emission" timestamptz,
+    "date_expiration" timestamptz,
+    "jeton" text,
+    "statut" text DEFAULT 'active',
+    "date_revocation" timestamptz,
+    "motif_revocation" text,
+    "emise_par" text,
+    "revoque_par" text,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_migrant_cards_serie" UNIQUE ("serie")
+);
+CREATE INDEX IF NOT EXISTS "idx_migrant_cards_statut" ON "migrant_cards" ("statut");
+CREATE INDEX IF NOT EXISTS "idx_migrant_cards_numero_identifiant" ON "migrant_cards" ("numero_identifiant");
+CREATE INDEX IF NOT EXISTS "idx_migrant_cards_migrant_uuid" ON "migrant_cards" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_migrant_cards_deleted_at" ON "migrant_cards" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "dossiers" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "numero_dossier" text NOT NULL,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_dossier" text NOT NULL,
+    "description" text,
+    "priorite" text DEFAULT 'normale',
+    "etape" text DEFAULT 'enregistre',
+    "date_entretien" timestamptz,
+    "date_limite" timestamptz,
+    "decision" text,
+    "date_decision" timestamptz,
+    "motif_decision" text,
+    "statut_attribue" text,
+    "date_limite_recours" timestamptz,
+    "date_cloture" timestamptz,
+    "enregistre_par" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_dossiers_numero_dossier" UNIQUE ("numero_dossier")
+);
+CREATE INDEX IF NOT EXISTS "idx_dossiers_date_limite" ON "dossiers" ("date_limite");
+CREATE INDEX IF NOT EXISTS "idx_dossiers_etape" ON "dossiers" ("etape");
+CREATE INDEX IF NOT EXISTS "idx_dossiers_type_dossier" ON "dossiers" ("type_dossier");
+CREATE INDEX IF NOT EXISTS "idx_dossiers_migrant_uuid" ON "dossiers" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_dossiers_deleted_at" ON "dossiers" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "dossier_agents" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dossier_uuid" varchar(255) NOT NULL,
+    "agent_uuid" varchar(255) NOT NULL,
+    "role" text DEFAULT 'responsable',
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_dossier_agents_agent_uuid" ON "dossier_agents" ("agent_uuid");
+CREATE INDEX IF NOT EXISTS "idx_dossier_agents_dossier_uuid" ON "dossier_agents" ("dossier_uuid");
+CREATE INDEX IF NOT EXISTS "idx_dossier_agents_deleted_at" ON "dossier_agents" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "dossier_notes" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "dossier_uuid" varchar(255) NOT NULL,
+    "auteur_uuid" text,
+    "contenu" text NOT NULL,
+    "confidentielle" boolean DEFAULT false,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_dossier_notes_dossier_uuid" ON "dossier_notes" ("dossier_uuid");
+CREATE INDEX IF NOT EXISTS "idx_dossier_notes_deleted_at" ON "dossier_notes" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "dossier_etapes" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "dossier_uuid" varchar(255) NOT NULL,
+    "etape_precedente" text,
+    "etape" text,
+    "effectue_par" text,
+    "commentaire" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_dossier_etapes_dossier_uuid" ON "dossier_etapes" ("dossier_uuid");
+
+CREATE TABLE IF NOT EXISTS "doublon_candidats" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_a_uuid" varchar(255) NOT NULL,
+    "identite_b_uuid" varchar(255) NOT NULL,
+    "score" decimal,
+    "score_nom" decimal,
+    "score_date_naissance" decimal,
+    "score_lieu_naissance" decimal,
+    "score_nationalite" decimal,
+    "biometries_communes" bigint,
+    "statut" text DEFAULT 'en_attente',
+    "revise_par" text,
+    "date_revue" timestamptz,
+    "commentaire" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_doublon_candidats_identite_b_uuid" ON "doublon_candidats" ("identite_b_uuid");
+CREATE INDEX IF NOT EXISTS "idx_doublon_candidats_identite_a_uuid" ON "doublon_candidats" ("identite_a_uuid");
+CREATE INDEX IF NOT EXISTS "idx_doublon_candidats_deleted_at" ON "doublon_candidats" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "fusions_identites" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "doublon_uuid" varchar(255),
+    "survivante_uuid" varchar(255) NOT NULL,
+    "absorbee_uuid" varchar(255) NOT NULL,
+    "migrants_deplaces" text,
+    "geolocalisations_deplacees" text,
+    "instantane_absorbee" text,
+    "statut" text DEFAULT 'active',
+    "effectue_par" text,
+    "date_annulation" timestamptz,
+    "annule_par" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_fusions_identites_absorbee_uuid" ON "fusions_identites" ("absorbee_uuid");
+CREATE INDEX IF NOT EXISTS "idx_fusions_identites_survivante_uuid" ON "fusions_identites" ("survivante_uuid");
+CREATE INDEX IF NOT EXISTS "idx_fusions_identites_doublon_uuid" ON "fusions_identites" ("doublon_uuid");
+CREATE INDEX IF NOT EXISTS "idx_fusions_identites_deleted_at" ON "fusions_identites" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "border_posts" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "code" text NOT NULL,
+    "nom" text NOT NULL,
+    "type_poste" text NOT NULL,
+    "province" text NOT NULL,
+    "ville" text,
+    "pays_limitrophe" text,
+    "latitude" decimal,
+    "longitude" decimal,
+    "actif" boolean DEFAULT true,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_border_posts_code" UNIQUE ("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_border_posts_province" ON "border_posts" ("province");
+CREATE INDEX IF NOT EXISTS "idx_border_posts_deleted_at" ON "border_posts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "crossings" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "poste_uuid" varchar(255) NOT NULL,
+    "sens" text NOT NULL,
+    "date_passage" timestamptz NOT NULL,
+    "type_document" text,
+    "numero_document" text,
+    "mode_transport" text,
+    "pays_provenance" text,
+    "pays_destination" text,
+    "agent_uuid" text,
+    "observations" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_crossings_date_passage" ON "crossings" ("date_passage");
+CREATE INDEX IF NOT EXISTS "idx_crossings_sens" ON "crossings" ("sens");
+CREATE INDEX IF NOT EXISTS "idx_crossings_poste_uuid" ON "crossings" ("poste_uuid");
+CREATE INDEX IF NOT EXISTS "idx_crossings_migrant_uuid" ON "crossings" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_crossings_deleted_at" ON "crossings" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "watchlists" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "nom" text NOT NULL,
+    "type_liste" text NOT NULL,
+    "source" text,
+    "description" text,
+    "actif" boolean DEFAULT true,
+    "nombre_entrees" bigint,
+    "date_import" timestamptz,
+    "importe_par" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_watchlists_type_liste" ON "watchlists" ("type_liste");
+CREATE INDEX IF NOT EXISTS "idx_watchlists_deleted_at" ON "watchlists" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "watchlist_entries" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "liste_uuid" varchar(255) NOT NULL,
+    "nom_complet" text,
+    "date_naissance" timestamptz,
+    "nationalite" text,
+    "numero_document" text,
+    "motif" text,
+    "reference" text,
+    "document_normalise" text DEFAULT '',
+    "cle_nom" text DEFAULT '',
+    "cle_phonetique" text DEFAULT '',
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_watchlist_entries_document_normalise" ON "watchlist_entries" ("document_normalise");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_entries_date_naissance" ON "watchlist_entries" ("date_naissance");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_entries_liste_uuid" ON "watchlist_entries" ("liste_uuid");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_entries_deleted_at" ON "watchlist_entries" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "watchlist_hits" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_uuid" varchar(255) NOT NULL,
+    "entree_uuid" varchar(255) NOT NULL,
+    "liste_uuid" varchar(255) NOT NULL,
+    "type_correspondance" text,
+    "score" decimal,
+    "alerte_uuid" text,
+    "statut" text DEFAULT 'en_attente',
+    "revise_par" text,
+    "date_revue" timestamptz,
+    "commentaire" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_watchlist_hits_statut" ON "watchlist_hits" ("statut");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_hits_liste_uuid" ON "watchlist_hits" ("liste_uuid");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_watchlist_hit" ON "watchlist_hits" ("identite_uuid","entree_uuid");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_hits_deleted_at" ON "watchlist_hits" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "counters" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "serie" varchar(50) NOT NULL,
+    "annee" bigint NOT NULL,
+    "bureau" varchar(50) NOT NULL DEFAULT '',
+    "valeur" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("uuid")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_counters_cle" ON "counters" ("serie","annee","bureau");
+
+CREATE TABLE IF NOT EXISTS "imports" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "type_import" text NOT NULL,
+    "nom_fichier" text,
+    "format" text,
+    "apercu" boolean,
+    "ignorer_erreurs" boolean,
+    "statut" text NOT NULL DEFAULT 'en_attente',
+    "message" text,
+    "total_lignes" bigint,
+    "lignes_traitees" bigint,
+    "lignes_valides" bigint,
+    "lignes_rejetees" bigint,
+    "lignes_importees" bigint,
+    "correspondances" bigint,
+    "date_debut" timestamptz,
+    "date_fin" timestamptz,
+    "lance_par" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_imports_statut" ON "imports" ("statut");
+CREATE INDEX IF NOT EXISTS "idx_imports_type_import" ON "imports" ("type_import");
+CREATE INDEX IF NOT EXISTS "idx_imports_deleted_at" ON "imports" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "import_errors" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "importation_uuid" varchar(255) NOT NULL,
+    "ligne" bigint,
+    "colonne" text,
+    "message" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_import_errors_importation_uuid" ON "import_errors" ("importation_uuid");
+
+CREATE TABLE IF NOT EXISTS "org_units" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "code" text NOT NULL,
+    "nom" text NOT NULL,
+    "type_unite" text NOT NULL,
+    "province" text,
+    "actif" boolean DEFAULT true,
+    "parent_uuid" varchar(255),
+    "chemin" text,
+    "poste_uuid" varchar(255),
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_org_units_code" UNIQUE ("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_org_units_chemin" ON "org_units" ("chemin");
+CREATE INDEX IF NOT EXISTS "idx_org_units_parent_uuid" ON "org_units" ("parent_uuid");
+CREATE INDEX IF NOT EXISTS "idx_org_units_type_unite" ON "org_units" ("type_unite");
+CREATE INDEX IF NOT EXISTS "idx_org_units_deleted_at" ON "org_units" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "cross_unit_access_logs" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "user_uuid" varchar(255),
+    "unite_user" varchar(255),
+    "unite_ressource" varchar(255),
+    "type_ressource" text,
+    "ressource_uuid" varchar(255),
+    "methode" text,
+    "route" text,
+    "resultat" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_cross_unit_access_logs_resultat" ON "cross_unit_access_logs" ("resultat");
+CREATE INDEX IF NOT EXISTS "idx_cross_unit_access_logs_type_ressource" ON "cross_unit_access_logs" ("type_ressource");
+CREATE INDEX IF NOT EXISTS "idx_cross_unit_access_logs_unite_ressource" ON "cross_unit_access_logs" ("unite_ressource");
+CREATE INDEX IF NOT EXISTS "idx_cross_unit_access_logs_user_uuid" ON "cross_unit_access_logs" ("user_uuid");
+
+CREATE TABLE IF NOT EXISTS "sync_conflicts" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "type_conflit" text NOT NULL,
+    "type_entite" text NOT NULL,
+    "entite_uuid" varchar(255) NOT NULL,
+    "existant_uuid" varchar(255) DEFAULT '',
+    "parent_uuid" varchar(255) DEFAULT '',
+    "message" text,
+    "operation" text,
+    "appareil_id" text,
+    "user_uuid" varchar(255),
+    "unite_uuid" varchar(255) DEFAULT '',
+    "statut" text DEFAULT 'en_attente',
+    "resolution" text DEFAULT '',
+    "resolu_par" text,
+    "date_revue" timestamptz,
+    "commentaire" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_statut" ON "sync_conflicts" ("statut");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_unite_uuid" ON "sync_conflicts" ("unite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_appareil_id" ON "sync_conflicts" ("appareil_id");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_parent_uuid" ON "sync_conflicts" ("parent_uuid");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_entite_uuid" ON "sync_conflicts" ("entite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_type_conflit" ON "sync_conflicts" ("type_conflit");
+CREATE INDEX IF NOT EXISTS "idx_sync_conflicts_deleted_at" ON "sync_conflicts" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "cle" varchar(700) NOT NULL,
+    "empreinte" varchar(64) NOT NULL,
+    "statut" text NOT NULL DEFAULT 'en_cours',
+    "code_http" bigint,
+    "type_contenu" text,
+    "reponse" bytea,
+    "expire_le" timestamptz NOT NULL,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expire_le" ON "idempotency_keys" ("expire_le");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_keys_cle" ON "idempotency_keys" ("cle");
@@ -0,0 +1,42 @@
+-- Supprime les clés étrangères et les index ajoutés par 0002
+
+DROP INDEX IF EXISTS "idx_org_units_poste_uuid";
+DROP INDEX IF EXISTS "idx_watchlist_hits_entree_uuid";
+DROP INDEX IF EXISTS "idx_geolocalisations_identite_uuid";
+DROP INDEX IF EXISTS "idx_biometries_migrant_uuid";
+DROP INDEX IF EXISTS "idx_alertes_niveau_gravite";
+DROP INDEX IF EXISTS "idx_alertes_statut";
+DROP INDEX IF EXISTS "idx_alertes_migrant_uuid";
+DROP INDEX IF EXISTS "idx_motif_deplacements_type_motif";
+DROP INDEX IF EXISTS "idx_motif_deplacements_migrant_uuid";
+DROP INDEX IF EXISTS "idx_migrants_created_at";
+DROP INDEX IF EXISTS "idx_migrants_statut_migratoire";
+DROP INDEX IF EXISTS "idx_migrants_identite_uuid";
+
+ALTER TABLE "org_units" DROP CONSTRAINT IF EXISTS "fk_org_units_poste_uuid";
+ALTER TABLE "org_units" DROP CONSTRAINT IF EXISTS "fk_org_units_parent_uuid";
+ALTER TABLE "import_errors" DROP CONSTRAINT IF EXISTS "fk_import_errors_importation_uuid";
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_liste_uuid";
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_entree_uuid";
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_identite_uuid";
+ALTER TABLE "watchlist_entries" DROP CONSTRAINT IF EXISTS "fk_watchlist_entries_liste_uuid";
+ALTER TABLE "crossings" DROP CONSTRAINT IF EXISTS "fk_crossings_poste_uuid";
+ALTER TABLE "crossings" DROP CONSTRAINT IF EXISTS "fk_crossings_migrant_uuid";
+ALTER TABLE "fusions_identites" DROP CONSTRAINT IF EXISTS "fk_fusions_identites_absorbee_uuid";
+ALTER TABLE "fusions_identites" DROP CONSTRAINT IF EXISTS "fk_fusions_identites_survivante_uuid";
+ALTER TABLE "doublon_candidats" DROP CONSTRAINT IF EXISTS "fk_doublon_candidats_identite_b_uuid";
+ALTER TABLE "doublon_candidats" DROP CONSTRAINT IF EXISTS "fk_doublon_candidats_identite_a_uuid";
+ALTER TABLE "dossier_etapes" DROP CONSTRAINT IF EXISTS "fk_dossier_etapes_dossier_uuid";
+ALTER TABLE "dossier_notes" DROP CONSTRAINT IF EXISTS "fk_dossier_notes_dossier_uuid";
+ALTER TABLE "dossier_agents" DROP CONSTRAINT IF EXISTS "fk_dossier_agents_agent_uuid";
+ALTER TABLE "dossier_agents" DROP CONSTRAINT IF EXISTS "fk_dossier_agents_dossier_uuid";
+ALTER TABLE "dossiers" DROP CONSTRAINT IF EXISTS "fk_dossiers_migrant_uuid";
+ALTER TABLE "migrant_cards" DROP CONSTRAINT IF EXISTS "fk_migrant_cards_migrant_uuid";
+ALTER TABLE "migrant_status_history" DROP CONSTRAINT IF EXISTS "fk_migrant_status_history_migrant_uuid";
+ALTER TABLE "relations_familiales" DROP CONSTRAINT IF EXISTS "fk_relations_familiales_apparente_uuid";
+ALTER TABLE "relations_familiales" DROP CONSTRAINT IF EXISTS "fk_relations_familiales_migrant_uuid";
+ALTER TABLE "geolocalisations" DROP CONSTRAINT IF EXISTS "fk_geolocalisations_identite_uuid";
+ALTER TABLE "biometries" DROP CONSTRAINT IF EXISTS "fk_biometries_migrant_uuid";
+ALTER TABLE "alertes" DROP CONSTRAINT IF EXISTS "fk_alertes_migrant_uuid";
+ALTER TABLE "motif_deplacements" DROP CONSTRAINT IF EXISTS "fk_motif_deplacements_migrant_uuid";
+ALTER TABLE "migrants" DROP CONSTRAINT IF EXISTS "fk_migrants_identite_uuid";
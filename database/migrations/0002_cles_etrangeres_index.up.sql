@@ -0,0 +1,72 @@
+-- Clés étrangères jamais créées par AutoMigrate (DisableForeignKeyConstraintWhenMigrating).
+-- NOT VALID : les lignes existantes ne sont pas contrôlées, seules les nouvelles écritures
+-- le sont. Après nettoyage des orphelins, ALTER TABLE ... VALIDATE CONSTRAINT ... les valide.
+
+ALTER TABLE "migrants" DROP CONSTRAINT IF EXISTS "fk_migrants_identite_uuid";
+ALTER TABLE "migrants" ADD CONSTRAINT "fk_migrants_identite_uuid" FOREIGN KEY ("identite_uuid") REFERENCES "identites" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "motif_deplacements" DROP CONSTRAINT IF EXISTS "fk_motif_deplacements_migrant_uuid";
+ALTER TABLE "motif_deplacements" ADD CONSTRAINT "fk_motif_deplacements_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "alertes" DROP CONSTRAINT IF EXISTS "fk_alertes_migrant_uuid";
+ALTER TABLE "alertes" ADD CONSTRAINT "fk_alertes_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "biometries" DROP CONSTRAINT IF EXISTS "fk_biometries_migrant_uuid";
+ALTER TABLE "biometries" ADD CONSTRAINT "fk_biometries_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "geolocalisations" DROP CONSTRAINT IF EXISTS "fk_geolocalisations_identite_uuid";
+ALTER TABLE "geolocalisations" ADD CONSTRAINT "fk_geolocalisations_identite_uuid" FOREIGN KEY ("identite_uuid") REFERENCES "identites" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "relations_familiales" DROP CONSTRAINT IF EXISTS "fk_relations_familiales_migrant_uuid";
+ALTER TABLE "relations_familiales" ADD CONSTRAINT "fk_relations_familiales_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "relations_familiales" DROP CONSTRAINT IF EXISTS "fk_relations_familiales_apparente_uuid";
+ALTER TABLE "relations_familiales" ADD CONSTRAINT "fk_relations_familiales_apparente_uuid" FOREIGN KEY ("apparente_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "migrant_status_history" DROP CONSTRAINT IF EXISTS "fk_migrant_status_history_migrant_uuid";
+ALTER TABLE "migrant_status_history" ADD CONSTRAINT "fk_migrant_status_history_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "migrant_cards" DROP CONSTRAINT IF EXISTS "fk_migrant_cards_migrant_uuid";
+ALTER TABLE "migrant_cards" ADD CONSTRAINT "fk_migrant_cards_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "dossiers" DROP CONSTRAINT IF EXISTS "fk_dossiers_migrant_uuid";
+ALTER TABLE "dossiers" ADD CONSTRAINT "fk_dossiers_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "dossier_agents" DROP CONSTRAINT IF EXISTS "fk_dossier_agents_dossier_uuid";
+ALTER TABLE "dossier_agents" ADD CONSTRAINT "fk_dossier_agents_dossier_uuid" FOREIGN KEY ("dossier_uuid") REFERENCES "dossiers" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "dossier_agents" DROP CONSTRAINT IF EXISTS "fk_dossier_agents_agent_uuid";
+ALTER TABLE "dossier_agents" ADD CONSTRAINT "fk_dossier_agents_agent_uuid" FOREIGN KEY ("agent_uuid") REFERENCES "users" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "dossier_notes" DROP CONSTRAINT IF EXISTS "fk_dossier_notes_dossier_uuid";
+ALTER TABLE "dossier_notes" ADD CONSTRAINT "fk_dossier_notes_dossier_uuid" FOREIGN KEY ("dossier_uuid") REFERENCES "dossiers" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "dossier_etapes" DROP CONSTRAINT IF EXISTS "fk_dossier_etapes_dossier_uuid";
+ALTER TABLE "dossier_etapes" ADD CONSTRAINT "fk_dossier_etapes_dossier_uuid" FOREIGN KEY ("dossier_uuid") REFERENCES "dossiers" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "doublon_candidats" DROP CONSTRAINT IF EXISTS "fk_doublon_candidats_identite_a_uuid";
+ALTER TABLE "doublon_candidats" ADD CONSTRAINT "fk_doublon_candidats_identite_a_uuid" FOREIGN KEY ("identite_a_uuid") REFERENCES "identites" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "doublon_candidats" DROP CONSTRAINT IF EXISTS "fk_doublon_candidats_identite_b_uuid";
+ALTER TABLE "doublon_candidats" ADD CONSTRAINT "fk_doublon_candidats_identite_b_uuid" FOREIGN KEY ("identite_b_uuid") REFERENCES "identites" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "fusions_identites" DROP CONSTRAINT IF EXISTS "fk_fusions_identites_survivante_uuid";
+ALTER TABLE "fusions_identites" ADD CONSTRAINT "fk_fusions_identites_survivante_uuid" FOREIGN KEY ("survivante_uuid") REFERENCES "identites" ("uuid") ON DELETE RESTRICT NOT VALID;
+ALTER TABLE "fusions_identites" DROP CONSTRAINT IF EXISTS "fk_fusions_identites_absorbee_uuid";
+ALTER TABLE "fusions_identites" ADD CONSTRAINT "fk_fusions_identites_absorbee_uuid" FOREIGN KEY ("absorbee_uuid") REFERENCES "identites" ("uuid") ON DELETE RESTRICT NOT VALID;
+ALTER TABLE "crossings" DROP CONSTRAINT IF EXISTS "fk_crossings_migrant_uuid";
+ALTER TABLE "crossings" ADD CONSTRAINT "fk_crossings_migrant_uuid" FOREIGN KEY ("migrant_uuid") REFERENCES "migrants" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "crossings" DROP CONSTRAINT IF EXISTS "fk_crossings_poste_uuid";
+ALTER TABLE "crossings" ADD CONSTRAINT "fk_crossings_poste_uuid" FOREIGN KEY ("poste_uuid") REFERENCES "border_posts" ("uuid") ON DELETE RESTRICT NOT VALID;
+ALTER TABLE "watchlist_entries" DROP CONSTRAINT IF EXISTS "fk_watchlist_entries_liste_uuid";
+ALTER TABLE "watchlist_entries" ADD CONSTRAINT "fk_watchlist_entries_liste_uuid" FOREIGN KEY ("liste_uuid") REFERENCES "watchlists" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_identite_uuid";
+ALTER TABLE "watchlist_hits" ADD CONSTRAINT "fk_watchlist_hits_identite_uuid" FOREIGN KEY ("identite_uuid") REFERENCES "identites" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_entree_uuid";
+ALTER TABLE "watchlist_hits" ADD CONSTRAINT "fk_watchlist_hits_entree_uuid" FOREIGN KEY ("entree_uuid") REFERENCES "watchlist_entries" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "watchlist_hits" DROP CONSTRAINT IF EXISTS "fk_watchlist_hits_liste_uuid";
+ALTER TABLE "watchlist_hits" ADD CONSTRAINT "fk_watchlist_hits_liste_uuid" FOREIGN KEY ("liste_uuid") REFERENCES "watchlists" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "import_errors" DROP CONSTRAINT IF EXISTS "fk_import_errors_importation_uuid";
+ALTER TABLE "import_errors" ADD CONSTRAINT "fk_import_errors_importation_uuid" FOREIGN KEY ("importation_uuid") REFERENCES "imports" ("uuid") ON DELETE CASCADE NOT VALID;
+ALTER TABLE "org_units" DROP CONSTRAINT IF EXISTS "fk_org_units_parent_uuid";
+ALTER TABLE "org_units" ADD CONSTRAINT "fk_org_units_parent_uuid" FOREIGN KEY ("parent_uuid") REFERENCES "org_units" ("uuid") ON DELETE RESTRICT NOT VALID;
+ALTER TABLE "org_units" DROP CONSTRAINT IF EXISTS "fk_org_units_poste_uuid";
+ALTER TABLE "org_units" ADD CONSTRAINT "fk_org_units_poste_uuid" FOREIGN KEY ("poste_uuid") REFERENCES "border_posts" ("uuid") ON DELETE SET NULL NOT VALID;
+
+-- Index des colonnes de jointure et de filtrage des tableaux de bord
+CREATE INDEX IF NOT EXISTS "idx_migrants_identite_uuid" ON "migrants" ("identite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_migrants_statut_migratoire" ON "migrants" ("statut_migratoire");
+CREATE INDEX IF NOT EXISTS "idx_migrants_created_at" ON "migrants" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_motif_deplacements_migrant_uuid" ON "motif_deplacements" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_motif_deplacements_type_motif" ON "motif_deplacements" ("type_motif");
+CREATE INDEX IF NOT EXISTS "idx_alertes_migrant_uuid" ON "alertes" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_alertes_statut" ON "alertes" ("statut");
+CREATE INDEX IF NOT EXISTS "idx_alertes_niveau_gravite" ON "alertes" ("niveau_gravite");
+CREATE INDEX IF NOT EXISTS "idx_biometries_migrant_uuid" ON "biometries" ("migrant_uuid");
+CREATE INDEX IF NOT EXISTS "idx_geolocalisations_identite_uuid" ON "geolocalisations" ("identite_uuid");
+CREATE INDEX IF NOT EXISTS "idx_watchlist_hits_entree_uuid" ON "watchlist_hits" ("entree_uuid");
+CREATE INDEX IF NOT EXISTS "idx_org_units_poste_uuid" ON "org_units" ("poste_uuid");
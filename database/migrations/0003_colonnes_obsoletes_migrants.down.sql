@@ -0,0 +1,11 @@
+-- Recrée les colonnes supprimées ; pays_origine est reconstitué depuis la nationalité
+-- de l'identité, actif depuis la suppression logique
+ALTER TABLE "migrants" ADD COLUMN IF NOT EXISTS "pays_origine" text;
+ALTER TABLE "migrants" ADD COLUMN IF NOT EXISTS "actif" boolean DEFAULT true;
+
+UPDATE migrants m
+SET pays_origine = i.nationalite
+FROM identites i
+WHERE m.identite_uuid = i.uuid;
+
+UPDATE migrants SET actif = (deleted_at IS NULL);
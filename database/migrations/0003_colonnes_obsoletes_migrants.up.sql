@@ -0,0 +1,21 @@
+-- Colonnes actif et pays_origine des anciennes versions du modèle Migrant, jamais
+-- supprimées par AutoMigrate. Le pays d'origine est repris dans la nationalité de
+-- l'identité quand celle-ci n'est pas renseignée, puis les colonnes sont supprimées.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'migrants' AND column_name = 'pays_origine'
+    ) THEN
+        UPDATE identites i
+        SET nationalite = m.pays_origine
+        FROM migrants m
+        WHERE m.identite_uuid = i.uuid
+          AND COALESCE(i.nationalite, '') = ''
+          AND COALESCE(m.pays_origine, '') <> '';
+    END IF;
+END
+$$;
+
+ALTER TABLE "migrants" DROP COLUMN IF EXISTS "pays_origine";
+ALTER TABLE "migrants" DROP COLUMN IF EXISTS "actif";
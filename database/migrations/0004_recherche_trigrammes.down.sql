@@ -0,0 +1,4 @@
+-- L'extension pg_trgm est conservée : d'autres objets de la base peuvent en dépendre
+DROP INDEX IF EXISTS "idx_identites_numero_passeport_trgm";
+DROP INDEX IF EXISTS "idx_identites_cle_phonetique_trgm";
+DROP INDEX IF EXISTS "idx_identites_cle_nom_trgm";
@@ -0,0 +1,8 @@
+-- Recherche approximative de personnes : index trigrammes (pg_trgm) sur les clés de
+-- nom, la clé phonétique et le numéro de passeport, utilisés par LIKE/ILIKE et par
+-- les opérateurs de similarité
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS "idx_identites_cle_nom_trgm" ON "identites" USING gin ("cle_nom" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_identites_cle_phonetique_trgm" ON "identites" USING gin ("cle_phonetique" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_identites_numero_passeport_trgm" ON "identites" USING gin ("numero_passeport" gin_trgm_ops);
@@ -0,0 +1 @@
+ALTER TABLE "fusions_identites" DROP COLUMN IF EXISTS "hits_deplaces";
@@ -0,0 +1,3 @@
+-- Correspondances de liste de surveillance déplacées vers l'identité survivante lors
+-- d'une fusion (liste JSON d'UUID), rendues à l'identité absorbée si la fusion est annulée
+ALTER TABLE "fusions_identites" ADD COLUMN IF NOT EXISTS "hits_deplaces" text;
@@ -0,0 +1 @@
+-- Les valeurs calculées sont conservées : elles restent cohérentes avec le schéma.
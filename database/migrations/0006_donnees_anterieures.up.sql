@@ -0,0 +1,3 @@
+-- Données des enregistrements créés avant l'introduction des unités, des clés de
+-- recherche, des empreintes biométriques et de l'historique des statuts. Le calcul
+-- est fait en Go (rattacherDonneesAnterieures), dans la transaction de cette migration.
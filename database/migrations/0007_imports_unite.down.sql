@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_imports_unite_uuid";
+ALTER TABLE "imports" DROP COLUMN IF EXISTS "unite_uuid";
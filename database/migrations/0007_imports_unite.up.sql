@@ -0,0 +1,9 @@
+-- Unité de l'agent qui a lancé l'import : le suivi et les erreurs d'un import ne sont
+-- visibles que dans son périmètre. Les imports antérieurs reprennent l'unité actuelle
+-- de leur auteur.
+ALTER TABLE "imports" ADD COLUMN IF NOT EXISTS "unite_uuid" varchar(255) DEFAULT '';
+CREATE INDEX IF NOT EXISTS "idx_imports_unite_uuid" ON "imports" ("unite_uuid");
+
+UPDATE "imports" SET "unite_uuid" = "users"."unite_uuid"
+FROM "users"
+WHERE "users"."uuid" = "imports"."lance_par" AND ("imports"."unite_uuid" IS NULL OR "imports"."unite_uuid" = '');
@@ -0,0 +1 @@
+ALTER TABLE "biometries" DROP COLUMN IF EXISTS "version";
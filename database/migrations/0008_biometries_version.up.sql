@@ -0,0 +1,3 @@
+-- Version des biométries, incrémentée à chaque mise à jour comme celle des identités
+-- et des migrants : base de la détection des conflits de synchronisation hors ligne
+ALTER TABLE "biometries" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Début de la réservation d'une clé d'idempotence : une requête restée en cours
+-- au-delà du bail (processus arrêté avant de répondre) ne bloque plus sa clé.
+-- Les réservations antérieures partent de la migration.
+ALTER TABLE "idempotency_keys" ADD COLUMN IF NOT EXISTS "reserve_le" timestamptz NOT NULL DEFAULT now();
@@ -0,0 +1,125 @@
+package database_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+func TestMigrationsEmbarquees(t *testing.T) {
+	migrations, err := database.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %d_%s : version %d attendue", m.Version, m.Nom, i+1)
+		}
+		if m.Bas == "" {
+			t.Errorf("migration %d_%s : script down manquant", m.Version, m.Nom)
+		}
+	}
+	if len(migrations) < 6 || migrations[5].Donnees == nil {
+		t.Error("la migration 0006 doit calculer les données des enregistrements antérieurs")
+	}
+}
+
+// Une base créée par AutoMigrate avant les migrations versionnées est mise à niveau :
+// colonnes ajoutées, enregistrements rattachés à l'unité nationale, clés, empreintes
+// et historique calculés
+func TestMonterDepuisSchemaAutoMigrate(t *testing.T) {
+	db := basetest.Ouvrir(t)
+
+	schema, err := os.ReadFile("testdata/schema_automigrate.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(string(schema)).Error; err != nil {
+		t.Fatalf("schéma AutoMigrate: %v", err)
+	}
+	anterieures := []string{
+		`INSERT INTO "users" ("uuid", "nom", "post_nom", "prenom", "sexe", "date_naissance", "lieu_naissance",
+			"nationalite", "email", "telephone", "province", "ville", "commune", "quartier", "matricule", "grade",
+			"fonction", "service", "direction", "ministere", "date_recrutement", "date_prise_service", "type_agent", "statut")
+		VALUES ('u1', 'KABILA', 'M', 'Jean', 'M', now(), 'Kinshasa', 'Congolaise', 'u1@dgm.cd', '+243810000001',
+			'Kinshasa', 'Kinshasa', 'Gombe', 'Centre', 'DGM001', 'A1', 'Agent', 'Contrôle', 'DG', 'Intérieur', now(), now(),
+			'Fonctionnaire', 'Actif')`,
+		`INSERT INTO "identites" ("uuid", "created_at", "nom", "postnom", "prenom", "numero_passeport")
+		VALUES ('i1', now(), 'Mukendi', 'Tshibola', 'Grâce', 'OB0000001')`,
+		`INSERT INTO "migrants" ("uuid", "created_at", "identite_uuid", "numero_identifiant", "statut_migratoire")
+		VALUES ('m1', now(), 'i1', 'MIG-1', 'regulier')`,
+		`INSERT INTO "alertes" ("uuid", "migrant_uuid", "titre") VALUES ('a1', 'm1', 'Visa expiré')`,
+		`INSERT INTO "biometries" ("uuid", "migrant_uuid", "type_biometrie", "donnees_biometriques")
+		VALUES ('b1', 'm1', 'empreinte_digitale', 'gabarit')`,
+	}
+	for _, requete := range anterieures {
+		if err := db.Exec(requete).Error; err != nil {
+			t.Fatalf("données antérieures: %v", err)
+		}
+	}
+
+	if _, err := database.Monter(db, 0); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if enAttente, err := database.VerifierSchema(db); err != nil || enAttente != 0 {
+		t.Fatalf("après migration : %d en attente, erreur %v", enAttente, err)
+	}
+
+	var racine models.UniteOrganisationnelle
+	if err := db.Where("type_unite = ? AND parent_uuid IS NULL", "national").First(&racine).Error; err != nil {
+		t.Fatalf("unité nationale: %v", err)
+	}
+	for _, table := range []string{"users", "identites", "migrants"} {
+		var sansUnite int64
+		db.Table(table).Where("unite_uuid IS DISTINCT FROM ?", racine.UUID).Count(&sansUnite)
+		if sansUnite > 0 {
+			t.Errorf("%s : %d enregistrement(s) non rattaché(s) à l'unité nationale", table, sansUnite)
+		}
+	}
+
+	var identite models.Identite
+	if err := db.First(&identite, "uuid = ?", "i1").Error; err != nil {
+		t.Fatal(err)
+	}
+	attendue := identite
+	attendue.RefreshSearchKeys()
+	if identite.CleNom == "" || identite.CleNom != attendue.CleNom || identite.ClePhonetique != attendue.ClePhonetique {
+		t.Errorf("clés de recherche %q/%q, attendues %q/%q", identite.CleNom, identite.ClePhonetique, attendue.CleNom, attendue.ClePhonetique)
+	}
+	if identite.Version != 1 {
+		t.Errorf("version de l'identité %d, attendue 1", identite.Version)
+	}
+
+	var migrant models.Migrant
+	if err := db.First(&migrant, "uuid = ?", "m1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if migrant.Version != 1 {
+		t.Errorf("version du migrant %d, attendue 1", migrant.Version)
+	}
+	var alerte models.Alert
+	if err := db.First(&alerte, "uuid = ?", "a1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if alerte.Version != 1 {
+		t.Errorf("version de l'alerte %d, attendue 1", alerte.Version)
+	}
+
+	var bio models.Biometrie
+	if err := db.First(&bio, "uuid = ?", "b1").Error; err != nil {
+		t.Fatal(err)
+	}
+	if bio.EmpreinteHash != utils.BiometricFingerprint("empreinte_digitale", "gabarit") {
+		t.Errorf("empreinte %q non calculée", bio.EmpreinteHash)
+	}
+
+	var historique int64
+	db.Model(&models.HistoriqueStatut{}).Where("migrant_uuid = ?", "m1").Count(&historique)
+	if historique != 1 {
+		t.Errorf("%d version(s) d'historique pour le migrant antérieur, 1 attendue", historique)
+	}
+}
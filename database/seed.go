@@ -0,0 +1,274 @@
+package database
+
+import (
+	crand "crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Profils de données d'amorçage
+const (
+	// ProfilMinimal - référentiels et un compte administrateur, pour une installation de production
+	ProfilMinimal = "minimal"
+	// ProfilDemo - profil minimal, trois agents de démonstration et 50 migrants simulés (développement uniquement)
+	ProfilDemo = "demo"
+	// ProfilCharge - comme demo, avec un nombre de migrants choisi pour les tests de charge
+	ProfilCharge = "load-test"
+)
+
+// Nombre d'enregistrements insérés par requête
+const tailleLot = 500
+
+const migrantsDemo = 50
+
+// OptionsSeed - paramètres de la commande seed
+type OptionsSeed struct {
+	Profil string
+	// Nombre de migrants simulés (profil load-test)
+	Nombre int
+	// Graine du générateur : les mêmes options produisent les mêmes données
+	Graine int64
+	// Affiche une seule fois, sur la sortie standard, les mots de passe générés
+	// (administrateur sans SEED_ADMIN_PASSWORD, agents de démonstration)
+	AfficherMotsDePasse bool
+}
+
+// sortieMotsDePasse reçoit les mots de passe générés : jamais le journal
+var sortieMotsDePasse io.Writer = os.Stdout
+
+// ErrMotDePasseAdmin - aucun mot de passe fourni pour le premier administrateur
+var ErrMotDePasseAdmin = errors.New("SEED_ADMIN_PASSWORD non défini : définissez-le, ou lancez \"seed -print-passwords\" pour générer un mot de passe affiché une seule fois")
+
+// Seed exécute la sous-commande seed :
+//
+//	seed -profile minimal|demo|load-test [-n 10000] [-seed 1] [-print-passwords]
+func Seed(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	opts := OptionsSeed{}
+	flags.StringVar(&opts.Profil, "profile", ProfilMinimal, "profil de données : minimal, demo ou load-test")
+	flags.IntVar(&opts.Nombre, "n", 1000, "nombre de migrants simulés (profil load-test)")
+	flags.Int64Var(&opts.Graine, "seed", 1, "graine du générateur aléatoire")
+	flags.BoolVar(&opts.AfficherMotsDePasse, "print-passwords", false, "afficher une fois les mots de passe générés sur la sortie standard")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	Connect()
+	return Semer(DB, opts)
+}
+
+// SeedAuDemarrage amorce la base au démarrage si DB_SEED_PROFILE désigne un profil.
+// Sans cette variable, aucune donnée n'est créée automatiquement. Aucun mot de passe
+// n'est affiché : le premier administrateur demande SEED_ADMIN_PASSWORD.
+func SeedAuDemarrage(db *gorm.DB) {
+	profil := utils.Env("DB_SEED_PROFILE")
+	if profil == "" {
+		return
+	}
+	opts := OptionsSeed{Profil: profil, Nombre: migrantsDemo, Graine: 1}
+	if err := Semer(db, opts); err != nil && !errors.Is(err, errBaseNonVide) {
+		log.Printf("❌ Erreur lors de l'amorçage (%s): %v", profil, err)
+	}
+}
+
+var errBaseNonVide = errors.New("la base contient déjà des migrants, données simulées non créées")
+
+// Semer crée les données du profil demandé
+func Semer(db *gorm.DB, opts OptionsSeed) error {
+	switch opts.Profil {
+	case ProfilMinimal:
+		return semerReferentiels(db, opts)
+	case ProfilDemo:
+		opts.Nombre = migrantsDemo
+	case ProfilCharge:
+		if opts.Nombre <= 0 {
+			return fmt.Errorf("le profil %s demande un nombre de migrants positif", ProfilCharge)
+		}
+	default:
+		return fmt.Errorf("profil inconnu: %s (minimal, demo ou load-test)", opts.Profil)
+	}
+
+	// Personnes et agents fictifs : jamais dans une base de production
+	if !utils.IsDevelopment() {
+		return fmt.Errorf("le profil %s crée des données fictives et n'est autorisé qu'en développement (APP_ENV=development)", opts.Profil)
+	}
+
+	if err := semerReferentiels(db, opts); err != nil {
+		return err
+	}
+
+	// Les données simulées ne sont jamais mêlées à des données réelles
+	var migrantCount int64
+	db.Model(&models.Migrant{}).Count(&migrantCount)
+	if migrantCount > 0 {
+		log.Printf("📊 %d migrants existants : %v", migrantCount, errBaseNonVide)
+		return errBaseNonVide
+	}
+
+	debut := time.Now()
+	if err := runAllSimulators(db, rand.New(rand.NewSource(opts.Graine)), opts); err != nil {
+		return err
+	}
+
+	// Unités, clés de recherche, empreintes et historique des enregistrements créés
+	if err := rattacherDonneesAnterieures(db); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Profil %s créé en %s (graine %d)", opts.Profil, time.Since(debut).Round(time.Millisecond), opts.Graine)
+	return nil
+}
+
+// semerReferentiels crée le référentiel des postes frontières et le premier administrateur
+func semerReferentiels(db *gorm.DB, opts OptionsSeed) error {
+	if err := seedBorderPosts(db); err != nil {
+		return err
+	}
+	return semerAdministrateur(db, opts.AfficherMotsDePasse)
+}
+
+// semerAdministrateur crée le premier compte administrateur s'il n'en existe aucun.
+// Le mot de passe vient de SEED_ADMIN_PASSWORD ; à défaut il n'est généré que si afficher
+// est demandé, et écrit une seule fois sur la sortie standard, jamais dans le journal.
+func semerAdministrateur(db *gorm.DB, afficher bool) error {
+	var count int64
+	db.Model(&models.User{}).Where("role = ?", "Administrator").Count(&count)
+	if count > 0 {
+		return nil
+	}
+
+	email := utils.Env("SEED_ADMIN_EMAIL")
+	if email == "" {
+		email = "admin@dgm.cd"
+	}
+	motDePasse := utils.Env("SEED_ADMIN_PASSWORD")
+	genere := motDePasse == ""
+	if genere && !afficher {
+		return ErrMotDePasseAdmin
+	}
+	if genere {
+		octets := make([]byte, 12)
+		if _, err := crand.Read(octets); err != nil {
+			return err
+		}
+		motDePasse = base64.RawURLEncoding.EncodeToString(octets)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(motDePasse), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("erreur lors du hashage du mot de passe: %v", err)
+	}
+
+	admin := models.User{
+		UUID:        utils.GenerateUUID(),
+		Nom:         "ADMINISTRATEUR",
+		Prenom:      "Système",
+		Nationalite: "Congolaise (RDC)",
+		Email:       email,
+		Telephone:   "+243000000000",
+		Province:    "Kinshasa",
+		Ville:       "Kinshasa",
+		Matricule:   "DGM000",
+		Fonction:    "Administrateur du système",
+		Direction:   "Direction Générale des Migrations",
+		Ministere:   "Ministère de l'Intérieur",
+		TypeAgent:   "Fonctionnaire",
+		Statut:      "Actif",
+		Role:        "Administrator",
+		Permission:  "full_access",
+		Status:      true,
+		Password:    string(hash),
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		return fmt.Errorf("erreur lors de la création de l'administrateur: %v", err)
+	}
+
+	log.Printf("🔑 Administrateur %s créé", email)
+	if genere {
+		fmt.Fprintf(sortieMotsDePasse, "Administrateur %s, mot de passe : %s (à changer à la première connexion)\n", email, motDePasse)
+	}
+	return nil
+}
+
+// runAllSimulators exécute tous les simulateurs dans l'ordre approprié
+func runAllSimulators(db *gorm.DB, r *rand.Rand, opts OptionsSeed) error {
+	log.Println("=== DÉBUT DE LA SIMULATION DE DONNÉES ===")
+
+	// 1. Créer les utilisateurs en premier
+	log.Println("1. Création des utilisateurs...")
+	if err := simulateUsers(db, r, opts.AfficherMotsDePasse); err != nil {
+		return fmt.Errorf("erreur lors de la simulation des utilisateurs: %v", err)
+	}
+
+	// 2. Créer les identités d'abord
+	log.Println("2. Création des identités...")
+	identites, err := simulateIdentites(db, r, opts.Nombre)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la simulation des identités: %v", err)
+	}
+
+	// 3. Créer les migrants (dépendent des identités)
+	log.Println("3. Création des migrants...")
+	migrants, err := simulateMigrants(db, r, identites)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la simulation des migrants: %v", err)
+	}
+
+	// 4. Créer les géolocalisations (dépendent des identités)
+	log.Println("4. Création des géolocalisations...")
+	if err := simulateGeolocalisations(db, r, identites); err != nil {
+		return fmt.Errorf("erreur lors de la simulation des géolocalisations: %v", err)
+	}
+
+	// 5. Créer les motifs de déplacement (dépendent des migrants)
+	log.Println("5. Création des motifs de déplacement...")
+	if err := simulateMotifDeplacements(db, r, migrants); err != nil {
+		return fmt.Errorf("erreur lors de la simulation des motifs de déplacement: %v", err)
+	}
+
+	// 6. Créer les données biométriques (dépendent des migrants)
+	log.Println("6. Création des données biométriques...")
+	if err := simulateBiometries(db, r, migrants); err != nil {
+		return fmt.Errorf("erreur lors de la simulation des biométries: %v", err)
+	}
+
+	// 7. Créer les alertes (dépendent des migrants)
+	log.Println("7. Création des alertes...")
+	if err := simulateAlerts(db, r, migrants); err != nil {
+		return fmt.Errorf("erreur lors de la simulation des alertes: %v", err)
+	}
+
+	log.Println("=== SIMULATION TERMINÉE AVEC SUCCÈS ===")
+	log.Printf("📊 %d identités et %d migrants simulés, étalés de janvier à juin 2025", len(identites), len(migrants))
+	return nil
+}
+
+// nouvelUUID tire un UUID du générateur, pour que les identifiants soient eux aussi reproductibles
+func nouvelUUID(r io.Reader) string {
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		return utils.GenerateUUID()
+	}
+	return id.String()
+}
+
+// motDePasseDemo tire un mot de passe de démonstration du générateur
+func motDePasseDemo(r io.Reader) string {
+	octets := make([]byte, 12)
+	if _, err := io.ReadFull(r, octets); err != nil {
+		return utils.GenerateUUID()
+	}
+	return base64.RawURLEncoding.EncodeToString(octets)
+}
@@ -0,0 +1,101 @@
+package database_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// capturer redirige l'affichage des mots de passe et le journal le temps du test
+func capturer(t *testing.T) (sortie, journal *bytes.Buffer) {
+	sortie, journal = &bytes.Buffer{}, &bytes.Buffer{}
+	precedente := *database.SortieMotsDePasse
+	*database.SortieMotsDePasse = sortie
+	log.SetOutput(journal)
+	t.Cleanup(func() {
+		*database.SortieMotsDePasse = precedente
+		log.SetOutput(os.Stderr)
+	})
+	return sortie, journal
+}
+
+func TestSemerDonneesFictivesHorsDeveloppement(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+
+	for _, profil := range []string{database.ProfilDemo, database.ProfilCharge} {
+		err := database.Semer(nil, database.OptionsSeed{Profil: profil, Nombre: 10, Graine: 1})
+		if err == nil || !strings.Contains(err.Error(), "développement") {
+			t.Errorf("profil %s hors développement : erreur %v, refus attendu", profil, err)
+		}
+	}
+}
+
+func TestSemerAdministrateur(t *testing.T) {
+	db := basetest.Migree(t)
+	t.Setenv("SEED_ADMIN_PASSWORD", "")
+	sortie, journal := capturer(t)
+
+	err := database.Semer(db, database.OptionsSeed{Profil: database.ProfilMinimal})
+	if !errors.Is(err, database.ErrMotDePasseAdmin) {
+		t.Fatalf("sans SEED_ADMIN_PASSWORD ni -print-passwords : erreur %v, attendu ErrMotDePasseAdmin", err)
+	}
+
+	if err := database.Semer(db, database.OptionsSeed{Profil: database.ProfilMinimal, AfficherMotsDePasse: true}); err != nil {
+		t.Fatal(err)
+	}
+	affiche := regexp.MustCompile(`mot de passe : (\S+)`).FindStringSubmatch(sortie.String())
+	if affiche == nil {
+		t.Fatalf("mot de passe généré non affiché : %q", sortie.String())
+	}
+	if strings.Contains(journal.String(), affiche[1]) {
+		t.Error("le mot de passe généré apparaît dans le journal")
+	}
+
+	var admin models.User
+	if err := db.Where("role = ?", "Administrator").First(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(affiche[1])) != nil {
+		t.Error("le mot de passe affiché ne correspond pas à celui de l'administrateur")
+	}
+}
+
+func TestSemerDemoMotsDePasseDeLaGraine(t *testing.T) {
+	db := basetest.Migree(t)
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("SEED_ADMIN_PASSWORD", "admin-de-test")
+	sortie, journal := capturer(t)
+
+	if err := database.Semer(db, database.OptionsSeed{Profil: database.ProfilDemo, Graine: 7, AfficherMotsDePasse: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	affiches := regexp.MustCompile(`Agent de démonstration (\S+), mot de passe : (\S+)`).FindAllStringSubmatch(sortie.String(), -1)
+	if len(affiches) == 0 {
+		t.Fatalf("mots de passe des agents non affichés : %q", sortie.String())
+	}
+	for _, agent := range affiches {
+		var user models.User
+		if err := db.Where("email = ?", agent[1]).First(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(agent[2])) != nil {
+			t.Errorf("%s : le mot de passe affiché ne correspond pas", agent[1])
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("password123")) == nil {
+			t.Errorf("%s : mot de passe par défaut partagé", agent[1])
+		}
+		if strings.Contains(journal.String(), agent[2]) {
+			t.Errorf("%s : mot de passe présent dans le journal", agent[1])
+		}
+	}
+}
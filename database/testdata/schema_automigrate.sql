@@ -0,0 +1,202 @@
+-- Schéma produit par AutoMigrate avant l'introduction des migrations versionnées
+-- (modèles User, PasswordReset, Identite, Migrant, MotifDeplacement, Alert, Biometrie,
+-- Geolocalisation), sans les colonnes ajoutées depuis. Sert au test de mise à niveau.
+
+CREATE TABLE "users" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "nom" text NOT NULL,
+    "post_nom" text NOT NULL,
+    "prenom" text NOT NULL,
+    "sexe" text NOT NULL,
+    "date_naissance" timestamptz NOT NULL,
+    "lieu_naissance" text NOT NULL,
+    "etat_civil" text,
+    "nombre_enfants" bigint DEFAULT 0,
+    "nationalite" text NOT NULL,
+    "numero_cni" text,
+    "date_emission_cni" timestamptz,
+    "date_expiration_cni" timestamptz,
+    "lieu_emission_cni" text,
+    "email" text NOT NULL,
+    "telephone" text NOT NULL,
+    "telephone_urgence" text,
+    "province" text NOT NULL,
+    "ville" text NOT NULL,
+    "commune" text NOT NULL,
+    "quartier" text NOT NULL,
+    "avenue" text,
+    "numero" text,
+    "matricule" text NOT NULL,
+    "grade" text NOT NULL,
+    "fonction" text NOT NULL,
+    "service" text NOT NULL,
+    "direction" text NOT NULL,
+    "ministere" text NOT NULL,
+    "date_recrutement" timestamptz NOT NULL,
+    "date_prise_service" timestamptz NOT NULL,
+    "type_agent" text NOT NULL,
+    "statut" text NOT NULL,
+    "niveau_etude" text,
+    "diplome_base" text,
+    "universite_ecole" text,
+    "annee_obtention" bigint,
+    "specialisation" text,
+    "numero_bancaire" text,
+    "banque" text,
+    "numero_cnss" text,
+    "numero_onem" text,
+    "photo_profil" text,
+    "cv_document" text,
+    "qr_code" text,
+    "qr_code_data" text,
+    "password" text,
+    "role" text,
+    "permission" text,
+    "status" boolean DEFAULT false,
+    "signature" text,
+    "dernier_acces" timestamptz,
+    "nombre_connexions" bigint DEFAULT 0,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_users_matricule" UNIQUE ("matricule"),
+    CONSTRAINT "uni_users_numero_cnss" UNIQUE ("numero_cnss"),
+    CONSTRAINT "uni_users_numero_cni" UNIQUE ("numero_cni"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email"),
+    CONSTRAINT "uni_users_telephone" UNIQUE ("telephone")
+);
+CREATE INDEX "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE "password_resets" (
+    "uuid" text,
+    "email" text,
+    "token" text,
+    "expiration_time" timestamptz,
+    "created_at" timestamptz
+);
+
+CREATE TABLE "identites" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "nom" text NOT NULL DEFAULT '',
+    "postnom" text NOT NULL DEFAULT '',
+    "prenom" text NOT NULL DEFAULT '',
+    "date_naissance" timestamptz,
+    "lieu_naissance" text DEFAULT '',
+    "sexe" varchar(1) DEFAULT '',
+    "nationalite" text DEFAULT '',
+    "adresse" text DEFAULT '',
+    "profession" text DEFAULT '',
+    "pays_emetteur" text NOT NULL DEFAULT '',
+    "autorite_emetteur" text NOT NULL DEFAULT '',
+    "date_emission" timestamptz,
+    "date_expiration" timestamptz,
+    "numero_passeport" text NOT NULL DEFAULT '',
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_identites_numero_passeport" UNIQUE ("numero_passeport")
+);
+CREATE INDEX "idx_identites_deleted_at" ON "identites" ("deleted_at");
+
+CREATE TABLE "migrants" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_uuid" varchar(255) NOT NULL,
+    "numero_identifiant" text NOT NULL,
+    "telephone" text,
+    "email" text,
+    "adresse_actuelle" text,
+    "ville_actuelle" text,
+    "pays_actuel" text,
+    "situation_matrimoniale" text,
+    "nombre_enfants" bigint DEFAULT 0,
+    "personne_contact" text,
+    "telephone_contact" text,
+    "statut_migratoire" text,
+    "date_entree" timestamptz,
+    "point_entree" text,
+    "pays_destination" text,
+    PRIMARY KEY ("uuid"),
+    CONSTRAINT "uni_migrants_numero_identifiant" UNIQUE ("numero_identifiant"),
+    CONSTRAINT "uni_migrants_email" UNIQUE ("email")
+);
+CREATE INDEX "idx_migrants_deleted_at" ON "migrants" ("deleted_at");
+
+CREATE TABLE "motif_deplacements" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_motif" text,
+    "motif_principal" text,
+    "motif_secondaire" text,
+    "description" text,
+    "caractere_volontaire" boolean DEFAULT true,
+    "urgence" text,
+    "date_declenchement" timestamptz,
+    "duree_estimee" bigint,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX "idx_motif_deplacements_deleted_at" ON "motif_deplacements" ("deleted_at");
+
+CREATE TABLE "alertes" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_alerte" text,
+    "niveau_gravite" text,
+    "titre" text,
+    "description" text,
+    "statut" text DEFAULT 'active',
+    "date_expiration" timestamptz,
+    "action_requise" text,
+    "personne_responsable" text,
+    "date_resolution" timestamptz,
+    "comment_resolution" text,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX "idx_alertes_deleted_at" ON "alertes" ("deleted_at");
+
+CREATE TABLE "biometries" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "migrant_uuid" varchar(255) NOT NULL,
+    "type_biometrie" text,
+    "index_doigt" bigint,
+    "qualite_donnee" text,
+    "donnees_biometriques" text NOT NULL,
+    "algorithme_encodage" text,
+    "taille_fichier" bigint,
+    "date_capture" timestamptz,
+    "disposif_capture" text,
+    "resolution_capture" text,
+    "operateur_capture" text,
+    "chiffre" boolean DEFAULT false,
+    "cle_chiffrement" text,
+    "verifie" boolean DEFAULT false,
+    "date_verification" timestamptz,
+    "score_confiance" decimal,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX "idx_biometries_deleted_at" ON "biometries" ("deleted_at");
+
+CREATE TABLE "geolocalisations" (
+    "uuid" varchar(255),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "identite_uuid" varchar(255) NOT NULL,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("uuid")
+);
+CREATE INDEX "idx_geolocalisations_deleted_at" ON "geolocalisations" ("deleted_at");
@@ -0,0 +1,148 @@
+// Package depots isole l'accès aux données des agrégats principaux (migrants, identités,
+// alertes, biométries, géolocalisations, motifs de déplacement, utilisateurs).
+// Les handlers reçoivent un dépôt au lieu d'utiliser database.DB : l'implémentation
+// Postgres sert en production, l'implémentation en mémoire aux tests des handlers.
+package depots
+
+import (
+	"errors"
+
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+// ErrVersionPerimee - l'enregistrement a été modifié par un autre agent depuis sa lecture
+var ErrVersionPerimee = errors.New("version de l'enregistrement périmée")
+
+// Depot donne accès aux enregistrements d'un agrégat, identifiés par leur UUID.
+// Un enregistrement absent ou supprimé est signalé par gorm.ErrRecordNotFound.
+type Depot[T any] interface {
+	// Trouver retourne l'enregistrement avec les relations demandées (noms GORM : "Identite", "Alertes")
+	Trouver(uuid string, relations ...string) (*T, error)
+	// Creer enregistre une nouvelle entité dont l'UUID est déjà attribué
+	Creer(entite *T) error
+	// Modifier écrit dans entite les champs non vides de valeurs, ou seulement les champs
+	// Go nommés (valeurs vides comprises). Pour une entité versionnée, l'écriture n'a lieu
+	// que si la version lue est toujours la version courante, sinon ErrVersionPerimee.
+	Modifier(entite *T, valeurs *T, champs ...string) error
+	// Sauvegarder écrit tous les champs de l'entité
+	Sauvegarder(entite *T) error
+	// Supprimer supprime l'entité (suppression logique), sous la même condition de version
+	// que Modifier
+	Supprimer(entite *T) error
+	// Lire donne accès aux enregistrements visibles dans la portée
+	Lire(portee Portee) Lecture[T]
+}
+
+// Migrants - dépôt des migrants
+type Migrants interface {
+	Depot[models.Migrant]
+	// AvecContexte retourne un dépôt dont les modifications portent le contexte
+	// enregistré dans l'historique des statuts
+	AvecContexte(ctx models.ContexteStatut) Migrants
+	// UniteDe retourne l'unité propriétaire du migrant, même supprimé
+	UniteDe(uuid string) (string, error)
+}
+
+// Identites - dépôt des identités
+type Identites interface {
+	Depot[models.Identite]
+	// ParPasseport retourne l'identité qui porte ce numéro de passeport, autre que sauf
+	ParPasseport(numero, sauf string) (*models.Identite, error)
+	// CompterMigrants retourne le nombre de migrants rattachés à l'identité
+	CompterMigrants(uuid string) (int64, error)
+	// UniteDe retourne l'unité qui a enregistré l'identité, même supprimée
+	UniteDe(uuid string) (string, error)
+	// RattacheeA indique si un migrant de l'une de ces unités est rattaché à l'identité
+	RattacheeA(uuid string, unites []string) (bool, error)
+}
+
+// Alertes - dépôt des alertes
+type Alertes interface {
+	Depot[models.Alert]
+}
+
+// Biometries - dépôt des données biométriques
+type Biometries interface {
+	Depot[models.Biometrie]
+}
+
+// Geolocalisations - dépôt des géolocalisations
+type Geolocalisations interface {
+	Depot[models.Geolocalisation]
+}
+
+// Motifs - dépôt des motifs de déplacement
+type Motifs interface {
+	Depot[models.MotifDeplacement]
+}
+
+// Users - dépôt des utilisateurs
+type Users interface {
+	Depot[models.User]
+}
+
+// Unites - dépôt des unités organisationnelles, qui délimitent le périmètre des agents
+type Unites interface {
+	Depot[models.UniteOrganisationnelle]
+	// SousArbre retourne l'UUID de l'unité et de toutes ses sous-unités
+	SousArbre(unite models.UniteOrganisationnelle) ([]string, error)
+	// Journaliser enregistre l'accès d'un agent à un enregistrement d'une autre unité
+	Journaliser(acces *models.AccesHorsPerimetre) error
+}
+
+// Criblage confronte une identité aux listes de surveillance actives et retourne
+// les correspondances relevées. En cas d'échec, l'identité est signalée pour un
+// nouveau criblage : l'enregistrement déjà effectué n'est pas annulé.
+type Criblage interface {
+	Cribler(identite models.Identite) ([]models.CorrespondanceSurveillance, error)
+}
+
+// AvertissementCriblage est renvoyé au client quand le criblage a échoué
+const AvertissementCriblage = "Le criblage contre les listes de surveillance n'a pas pu être effectué"
+
+// Depots regroupe les dépôts transmis aux routes
+type Depots struct {
+	Migrants         Migrants
+	Identites        Identites
+	Alertes          Alertes
+	Biometries       Biometries
+	Geolocalisations Geolocalisations
+	Motifs           Motifs
+	Users            Users
+	Unites           Unites
+	// Le criblage Postgres est fourni par le paquet watchlist (voir routes.Setup)
+	Criblage Criblage
+}
+
+// NewDepotsPostgres retourne les dépôts adossés à la base Postgres
+func NewDepotsPostgres(db *gorm.DB) Depots {
+	return Depots{
+		Migrants:         &migrantsPostgres{depotPostgres[models.Migrant]{db: db, regle: parUnite}},
+		Identites:        &identitesPostgres{depotPostgres[models.Identite]{db: db, regle: parIdentites}},
+		Alertes:          &depotPostgres[models.Alert]{db: db, regle: parMigrant},
+		Biometries:       &depotPostgres[models.Biometrie]{db: db, regle: parMigrant},
+		Geolocalisations: &depotPostgres[models.Geolocalisation]{db: db, regle: parIdentite},
+		Motifs:           &depotPostgres[models.MotifDeplacement]{db: db, regle: parMigrant},
+		Users:            &depotPostgres[models.User]{db: db},
+		Unites:           &unitesPostgres{depotPostgres[models.UniteOrganisationnelle]{db: db}},
+	}
+}
+
+// NewDepotsMemoire retourne des dépôts en mémoire, vides, pour les tests.
+// Le criblage ne relève aucune correspondance.
+func NewDepotsMemoire() Depots {
+	r := &registre{tables: map[string]func() ([]ligne, error){}}
+	migrants := &migrantsMemoire{depotMemoire: nouveauDepotMemoire[models.Migrant](r, parUnite), compteurs: map[string]int64{}}
+	return Depots{
+		Migrants:         migrants,
+		Identites:        &identitesMemoire{depotMemoire: nouveauDepotMemoire[models.Identite](r, parIdentites), migrants: migrants},
+		Alertes:          nouveauDepotMemoire[models.Alert](r, parMigrant),
+		Biometries:       nouveauDepotMemoire[models.Biometrie](r, parMigrant),
+		Geolocalisations: nouveauDepotMemoire[models.Geolocalisation](r, parIdentite),
+		Motifs:           nouveauDepotMemoire[models.MotifDeplacement](r, parMigrant),
+		Users:            nouveauDepotMemoire[models.User](r, sansRegle),
+		Unites:           &unitesMemoire{depotMemoire: nouveauDepotMemoire[models.UniteOrganisationnelle](r, sansRegle)},
+		Criblage:         criblageMemoire{},
+	}
+}
@@ -0,0 +1,151 @@
+package depots
+
+import (
+	"strings"
+
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+)
+
+// Lecture donne accès aux enregistrements d'un agrégat visibles dans une portée,
+// pour les listes, statistiques et exports. Les colonnes sont désignées par leur nom
+// dans la table de l'agrégat.
+type Lecture[T any] interface {
+	// Avec charge les relations nommées (noms GORM : "Migrant", "Identite")
+	Avec(relations ...string) Lecture[T]
+	// Colonnes limite les colonnes chargées, les autres champs restant vides
+	Colonnes(colonnes ...string) Lecture[T]
+
+	// Compter retourne le nombre d'enregistrements satisfaisant les conditions
+	Compter(conditions ...Condition) (int64, error)
+	// Repartition compte les enregistrements par valeur de la colonne, les plus
+	// nombreux d'abord ; chaque ligne porte la valeur et "count". limite 0 : toutes les valeurs.
+	Repartition(colonne string, limite int, conditions ...Condition) ([]map[string]interface{}, error)
+	// Moyenne retourne la moyenne des valeurs renseignées de la colonne, nil sans valeur
+	Moyenne(colonne string, conditions ...Condition) (*float64, error)
+
+	// Paginer applique les paramètres de liste du client (filtres, tri, pagination)
+	Paginer(liste *requetes.Liste, conditions ...Condition) (interface{}, map[string]interface{}, error)
+	// Tout applique les filtres et le tri du client, sans pagination
+	Tout(liste *requetes.Liste, conditions ...Condition) (interface{}, error)
+	// Page retourne une page de taille limite, dans l'ordre donné, et le nombre total d'enregistrements
+	Page(ordre string, page, limite int, conditions ...Condition) ([]T, int64, error)
+	// Charger retourne tous les enregistrements dans l'ordre donné (exports)
+	Charger(ordre string, conditions ...Condition) ([]T, error)
+}
+
+// Condition restreint une lecture. L'ordre des lectures s'écrit comme le paramètre
+// sort des listes : "-created_at,nom" pour created_at décroissant puis nom croissant.
+type Condition struct {
+	colonne   string
+	operateur string
+	valeur    interface{}
+	// Recherche : texte cherché dans ces colonnes et dans celles d'une table liée
+	colonnes []string
+	liaison  *liaison
+}
+
+// liaison - recherche dans la table dont la colonne cle est référencée par la colonne de l'agrégat
+type liaison struct {
+	colonne  string
+	table    string
+	cle      string
+	colonnes []string
+}
+
+// Egal - colonne = valeur
+func Egal(colonne string, valeur interface{}) Condition {
+	return Condition{colonne: colonne, operateur: "=", valeur: valeur}
+}
+
+// Different - colonne <> valeur
+func Different(colonne string, valeur interface{}) Condition {
+	return Condition{colonne: colonne, operateur: "<>", valeur: valeur}
+}
+
+// AuMoins - colonne >= valeur
+func AuMoins(colonne string, valeur interface{}) Condition {
+	return Condition{colonne: colonne, operateur: ">=", valeur: valeur}
+}
+
+// AuPlus - colonne <= valeur
+func AuPlus(colonne string, valeur interface{}) Condition {
+	return Condition{colonne: colonne, operateur: "<=", valeur: valeur}
+}
+
+// Avant - colonne < valeur
+func Avant(colonne string, valeur interface{}) Condition {
+	return Condition{colonne: colonne, operateur: "<", valeur: valeur}
+}
+
+// Vide - colonne texte absente ou vide
+func Vide(colonne string) Condition {
+	return Condition{colonne: colonne, operateur: "vide"}
+}
+
+// Renseigne - colonne texte présente et non vide
+func Renseigne(colonne string) Condition {
+	return Condition{colonne: colonne, operateur: "renseigne"}
+}
+
+// Recherche - texte contenu (sans tenir compte de la casse) dans l'une des colonnes.
+// Un texte vide ne restreint pas la lecture.
+func Recherche(texte string, colonnes ...string) Condition {
+	if texte == "" {
+		return Condition{}
+	}
+	return Condition{operateur: "recherche", valeur: texte, colonnes: colonnes}
+}
+
+// OuDans étend la recherche aux colonnes d'une table liée : l'enregistrement est retenu
+// si sa colonne référence (par la colonne cle) une ligne de la table qui contient le texte
+func (c Condition) OuDans(colonne, table, cle string, colonnes ...string) Condition {
+	if c.operateur == "recherche" {
+		c.liaison = &liaison{colonne: colonne, table: table, cle: cle, colonnes: colonnes}
+	}
+	return c
+}
+
+// sql retourne la clause de la condition sur la table, vide si elle ne restreint rien
+func (c Condition) sql(table string) (string, []interface{}) {
+	colonne := table + "." + c.colonne
+	switch c.operateur {
+	case "":
+		return "", nil
+	case "vide":
+		return "(" + colonne + " IS NULL OR " + colonne + " = '')", nil
+	case "renseigne":
+		return "(" + colonne + " IS NOT NULL AND " + colonne + " <> '')", nil
+	case "recherche":
+		motif := "%" + c.valeur.(string) + "%"
+		var parties []string
+		var args []interface{}
+		for _, col := range c.colonnes {
+			parties = append(parties, table+"."+col+" ILIKE ?")
+			args = append(args, motif)
+		}
+		if l := c.liaison; l != nil {
+			var liees []string
+			for _, col := range l.colonnes {
+				liees = append(liees, l.table+"."+col+" ILIKE ?")
+				args = append(args, motif)
+			}
+			parties = append(parties, table+"."+l.colonne+" IN (SELECT "+l.table+"."+l.cle+" FROM "+l.table+
+				" WHERE "+strings.Join(liees, " OR ")+")")
+		}
+		return "(" + strings.Join(parties, " OR ") + ")", args
+	}
+	return colonne + " " + c.operateur + " ?", []interface{}{c.valeur}
+}
+
+// triDe découpe un ordre "-created_at,nom" en colonnes et sens décroissant
+func triDe(ordre string) (colonnes []string, desc []bool) {
+	for _, nom := range strings.Split(ordre, ",") {
+		nom = strings.TrimSpace(nom)
+		if nom == "" {
+			continue
+		}
+		colonnes = append(colonnes, strings.TrimPrefix(nom, "-"))
+		desc = append(desc, strings.HasPrefix(nom, "-"))
+	}
+	return colonnes, desc
+}
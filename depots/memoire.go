@@ -0,0 +1,746 @@
+package depots
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// =======================
+// DÉPÔT EN MÉMOIRE
+// =======================
+
+// Les relations ne sont pas chargées : l'enregistrement est retourné tel qu'il a été
+// créé puis modifié. Les hooks GORM (historique des statuts) ne sont pas exécutés, la
+// version et les dates de création et de mise à jour sont tenues par le dépôt.
+type depotMemoire[T any] struct {
+	mu      sync.Mutex
+	entites map[string]T
+
+	// Tables des autres dépôts, pour la portée et les recherches liées
+	registre *registre
+	regle    regle
+}
+
+func nouveauDepotMemoire[T any](r *registre, rg regle) *depotMemoire[T] {
+	d := &depotMemoire[T]{entites: map[string]T{}, registre: r, regle: rg}
+	if s, err := schemaMemoire[T](); err == nil {
+		r.tables[s.Table] = func() ([]ligne, error) { return d.lignes(d.tous()) }
+	}
+	return d
+}
+
+func (d *depotMemoire[T]) Trouver(uuid string, relations ...string) (*T, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entite, ok := d.entites[uuid]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entite, nil
+}
+
+func (d *depotMemoire[T]) Creer(entite *T) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v := reflect.ValueOf(entite).Elem()
+	uuid := v.FieldByName("UUID").String()
+	if _, existe := d.entites[uuid]; existe {
+		return gorm.ErrDuplicatedKey
+	}
+	now := time.Now()
+	for _, nom := range []string{"CreatedAt", "UpdatedAt"} {
+		if f := v.FieldByName(nom); f.IsValid() && f.IsZero() {
+			f.Set(reflect.ValueOf(now))
+		}
+	}
+	if version, versionnee := versionDe(entite); versionnee && version == 0 {
+		v.FieldByName("Version").SetInt(1)
+	}
+	d.entites[uuid] = *entite
+	return nil
+}
+
+func (d *depotMemoire[T]) Modifier(entite *T, valeurs *T, champs ...string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v := reflect.ValueOf(entite).Elem()
+	uuid := v.FieldByName("UUID").String()
+	version, versionnee := versionDe(entite)
+	courante, ok := d.entites[uuid]
+	if !ok {
+		if versionnee {
+			return ErrVersionPerimee
+		}
+		return nil
+	}
+	if actuelle, _ := versionDe(&courante); versionnee && actuelle != version {
+		return ErrVersionPerimee
+	}
+
+	source := reflect.ValueOf(valeurs).Elem()
+	if len(champs) > 0 {
+		for _, nom := range champs {
+			if f, ok := v.Type().FieldByName(nom); ok && colonne(f) && nom != "Version" {
+				v.FieldByIndex(f.Index).Set(source.FieldByIndex(f.Index))
+			}
+		}
+	} else {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if colonne(f) && f.Name != "Version" && !source.Field(i).IsZero() {
+				v.Field(i).Set(source.Field(i))
+			}
+		}
+	}
+
+	if f := v.FieldByName("UpdatedAt"); f.IsValid() {
+		f.Set(reflect.ValueOf(time.Now()))
+	}
+	if versionnee {
+		v.FieldByName("Version").SetInt(version + 1)
+	}
+	d.entites[uuid] = *entite
+	return nil
+}
+
+func (d *depotMemoire[T]) Sauvegarder(entite *T) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v := reflect.ValueOf(entite).Elem()
+	if f := v.FieldByName("UpdatedAt"); f.IsValid() {
+		f.Set(reflect.ValueOf(time.Now()))
+	}
+	d.entites[v.FieldByName("UUID").String()] = *entite
+	return nil
+}
+
+func (d *depotMemoire[T]) Supprimer(entite *T) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	uuid := reflect.ValueOf(entite).Elem().FieldByName("UUID").String()
+	courante, ok := d.entites[uuid]
+	if version, versionnee := versionDe(entite); versionnee {
+		if actuelle, _ := versionDe(&courante); !ok || actuelle != version {
+			return ErrVersionPerimee
+		}
+	}
+	delete(d.entites, uuid)
+	return nil
+}
+
+// tous retourne une copie des enregistrements, pour les recherches des dépôts spécialisés
+func (d *depotMemoire[T]) tous() []T {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entites := make([]T, 0, len(d.entites))
+	for _, entite := range d.entites {
+		entites = append(entites, entite)
+	}
+	return entites
+}
+
+// versionDe retourne la version d'une entité soumise au contrôle de concurrence optimiste
+func versionDe(entite any) (int64, bool) {
+	f := reflect.ValueOf(entite).Elem().FieldByName("Version")
+	if !f.IsValid() || f.Kind() != reflect.Int64 {
+		return 0, false
+	}
+	return f.Int(), true
+}
+
+// colonne indique si le champ est une colonne de la table et non une relation
+func colonne(f reflect.StructField) bool {
+	if !f.IsExported() {
+		return false
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(gorm.DeletedAt{})
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+// =======================
+// MIGRANTS
+// =======================
+
+type migrantsMemoire struct {
+	*depotMemoire[models.Migrant]
+
+	mu sync.Mutex
+	// Dernier numéro attribué par année et bureau
+	compteurs map[string]int64
+}
+
+// Creer attribue au migrant le prochain numéro de son bureau d'enregistrement ;
+// le numéro est rendu si la création échoue
+func (d *migrantsMemoire) Creer(migrant *models.Migrant) error {
+	format, annee, bureau, err := models.NumerotationMigrants.Cle(migrant.BureauEnregistrement)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cle := fmt.Sprintf("%d:%s", annee, bureau)
+	numero := migrant.NumeroIdentifiant
+	migrant.NumeroIdentifiant = utils.FormatNumero(format, annee, bureau, d.compteurs[cle]+1)
+	if err := d.depotMemoire.Creer(migrant); err != nil {
+		migrant.NumeroIdentifiant = numero
+		return err
+	}
+	d.compteurs[cle]++
+	return nil
+}
+
+// AvecContexte retourne le même dépôt : l'historique des statuts n'est pas tenu en mémoire
+func (d *migrantsMemoire) AvecContexte(ctx models.ContexteStatut) Migrants {
+	return d
+}
+
+// UniteDe ne retrouve pas les migrants supprimés, retirés du dépôt
+func (d *migrantsMemoire) UniteDe(uuid string) (string, error) {
+	migrant, err := d.Trouver(uuid)
+	if err != nil {
+		return "", err
+	}
+	return migrant.UniteUUID, nil
+}
+
+// =======================
+// IDENTITÉS
+// =======================
+
+type identitesMemoire struct {
+	*depotMemoire[models.Identite]
+	migrants *migrantsMemoire
+}
+
+// Trouver recalcule la validité du document, comme le hook AfterFind
+func (d *identitesMemoire) Trouver(uuid string, relations ...string) (*models.Identite, error) {
+	identite, err := d.depotMemoire.Trouver(uuid, relations...)
+	if err != nil {
+		return nil, err
+	}
+	identite.RefreshValidity()
+	return identite, nil
+}
+
+// Creer, Modifier et Sauvegarder recalculent les clés de recherche, comme le hook
+// BeforeSave, et la validité du document, comme le hook AfterSave
+func (d *identitesMemoire) Creer(identite *models.Identite) error {
+	identite.RefreshSearchKeys()
+	if err := d.depotMemoire.Creer(identite); err != nil {
+		return err
+	}
+	identite.RefreshValidity()
+	return nil
+}
+
+func (d *identitesMemoire) Modifier(identite *models.Identite, valeurs *models.Identite, champs ...string) error {
+	if err := d.depotMemoire.Modifier(identite, valeurs, champs...); err != nil {
+		return err
+	}
+	identite.RefreshSearchKeys()
+	identite.RefreshValidity()
+	return d.depotMemoire.Sauvegarder(identite)
+}
+
+func (d *identitesMemoire) Sauvegarder(identite *models.Identite) error {
+	identite.RefreshSearchKeys()
+	if err := d.depotMemoire.Sauvegarder(identite); err != nil {
+		return err
+	}
+	identite.RefreshValidity()
+	return nil
+}
+
+func (d *identitesMemoire) ParPasseport(numero, sauf string) (*models.Identite, error) {
+	for _, identite := range d.tous() {
+		if identite.NumeroPasseport == numero && identite.UUID != sauf {
+			return &identite, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *identitesMemoire) CompterMigrants(uuid string) (int64, error) {
+	var count int64
+	for _, migrant := range d.migrants.tous() {
+		if migrant.IdentiteUUID == uuid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (d *identitesMemoire) UniteDe(uuid string) (string, error) {
+	identite, err := d.depotMemoire.Trouver(uuid)
+	if err != nil {
+		return "", err
+	}
+	return identite.UniteUUID, nil
+}
+
+func (d *identitesMemoire) RattacheeA(uuid string, unites []string) (bool, error) {
+	for _, migrant := range d.migrants.tous() {
+		if migrant.IdentiteUUID == uuid && slices.Contains(unites, migrant.UniteUUID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// =======================
+// UNITÉS
+// =======================
+
+type unitesMemoire struct {
+	*depotMemoire[models.UniteOrganisationnelle]
+
+	mu    sync.Mutex
+	acces []models.AccesHorsPerimetre
+}
+
+func (d *unitesMemoire) SousArbre(unite models.UniteOrganisationnelle) ([]string, error) {
+	var uuids []string
+	for _, u := range d.tous() {
+		if strings.HasPrefix(u.Chemin, unite.Chemin) {
+			uuids = append(uuids, u.UUID)
+		}
+	}
+	return uuids, nil
+}
+
+func (d *unitesMemoire) Journaliser(acces *models.AccesHorsPerimetre) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.acces = append(d.acces, *acces)
+	return nil
+}
+
+// criblageMemoire - aucune liste de surveillance
+type criblageMemoire struct{}
+
+func (criblageMemoire) Cribler(identite models.Identite) ([]models.CorrespondanceSurveillance, error) {
+	return nil, nil
+}
+
+// =======================
+// LECTURES
+// =======================
+
+// ligne - valeurs d'un enregistrement par nom de colonne
+type ligne map[string]interface{}
+
+// registre donne aux lectures en mémoire les lignes des autres agrégats, par nom de table
+type registre struct {
+	tables map[string]func() ([]ligne, error)
+}
+
+var schemasMemoire sync.Map
+
+func schemaMemoire[T any]() (*schema.Schema, error) {
+	return schema.Parse(new(T), &schemasMemoire, schema.NamingStrategy{})
+}
+
+// lignes retourne les valeurs des colonnes des entités, pointeurs déréférencés
+func (d *depotMemoire[T]) lignes(entites []T) ([]ligne, error) {
+	s, err := schemaMemoire[T]()
+	if err != nil {
+		return nil, err
+	}
+	lignes := make([]ligne, len(entites))
+	for i := range entites {
+		v := reflect.ValueOf(&entites[i]).Elem()
+		l := ligne{}
+		for _, f := range s.Fields {
+			if f.DBName != "" {
+				l[f.DBName] = valeurDe(f.ReflectValueOf(context.Background(), v))
+			}
+		}
+		lignes[i] = l
+	}
+	return lignes, nil
+}
+
+func valeurDe(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func (r *registre) lignes(table string) ([]ligne, error) {
+	tous, ok := r.tables[table]
+	if !ok {
+		return nil, fmt.Errorf("table inconnue : %s", table)
+	}
+	return tous()
+}
+
+// visible retourne le prédicat des lignes de l'agrégat visibles dans la portée
+func (r *registre) visible(p Portee, rg regle) (func(ligne) bool, error) {
+	if p.National || rg == sansRegle {
+		return func(ligne) bool { return true }, nil
+	}
+	unites := map[string]bool{}
+	for _, u := range p.Unites {
+		unites[u] = true
+	}
+	dans := func(ensemble map[string]bool, colonne string) func(ligne) bool {
+		return func(l ligne) bool {
+			valeur, _ := l[colonne].(string)
+			return ensemble[valeur]
+		}
+	}
+
+	switch rg {
+	case parUnite:
+		return dans(unites, "unite_uuid"), nil
+	case parMigrant:
+		migrants, err := r.lignes("migrants")
+		if err != nil {
+			return nil, err
+		}
+		visibles := map[string]bool{}
+		for _, m := range migrants {
+			if dans(unites, "unite_uuid")(m) {
+				visibles[m["uuid"].(string)] = true
+			}
+		}
+		return dans(visibles, "migrant_uuid"), nil
+	}
+
+	identites, err := r.identitesVisibles(unites)
+	if err != nil {
+		return nil, err
+	}
+	if rg == parIdentites {
+		return dans(identites, "uuid"), nil
+	}
+	return dans(identites, "identite_uuid"), nil
+}
+
+// identitesVisibles - identités des unités ou rattachées à un de leurs migrants
+func (r *registre) identitesVisibles(unites map[string]bool) (map[string]bool, error) {
+	visibles := map[string]bool{}
+	for _, table := range []string{"migrants", "identites"} {
+		lignes, err := r.lignes(table)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range lignes {
+			if unite, _ := l["unite_uuid"].(string); !unites[unite] {
+				continue
+			}
+			if table == "migrants" {
+				visibles[l["identite_uuid"].(string)] = true
+			} else {
+				visibles[l["uuid"].(string)] = true
+			}
+		}
+	}
+	return visibles, nil
+}
+
+// satisfait évalue la condition sur la ligne ; comme en SQL, une valeur absente
+// ne satisfait aucune comparaison
+func (r *registre) satisfait(c Condition, l ligne) (bool, error) {
+	switch c.operateur {
+	case "":
+		return true, nil
+	case "recherche":
+		texte := strings.ToLower(c.valeur.(string))
+		contient := func(l ligne, colonnes []string) bool {
+			for _, colonne := range colonnes {
+				if valeur, ok := l[colonne].(string); ok && strings.Contains(strings.ToLower(valeur), texte) {
+					return true
+				}
+			}
+			return false
+		}
+		if contient(l, c.colonnes) {
+			return true, nil
+		}
+		if c.liaison == nil {
+			return false, nil
+		}
+		liees, err := r.lignes(c.liaison.table)
+		if err != nil {
+			return false, err
+		}
+		for _, liee := range liees {
+			if liee[c.liaison.cle] == l[c.liaison.colonne] && contient(liee, c.liaison.colonnes) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	valeur, ok := l[c.colonne]
+	if !ok {
+		return false, fmt.Errorf("colonne inconnue : %s", c.colonne)
+	}
+	switch c.operateur {
+	case "vide":
+		return valeur == nil || valeur == "", nil
+	case "renseigne":
+		return valeur != nil && valeur != "", nil
+	}
+	ordre, comparable := requetes.Comparer(valeur, c.valeur)
+	if !comparable {
+		return false, nil
+	}
+	switch c.operateur {
+	case "=":
+		return ordre == 0, nil
+	case "<>":
+		return ordre != 0, nil
+	case ">=":
+		return ordre >= 0, nil
+	case "<=":
+		return ordre <= 0, nil
+	case "<":
+		return ordre < 0, nil
+	}
+	return false, fmt.Errorf("opérateur inconnu : %s", c.operateur)
+}
+
+// Les relations demandées par Avec ne sont pas chargées
+type lectureMemoire[T any] struct {
+	depot    *depotMemoire[T]
+	portee   Portee
+	colonnes []string
+}
+
+func (d *depotMemoire[T]) Lire(portee Portee) Lecture[T] {
+	return &lectureMemoire[T]{depot: d, portee: portee}
+}
+
+func (l *lectureMemoire[T]) Avec(relations ...string) Lecture[T] {
+	return l
+}
+
+func (l *lectureMemoire[T]) Colonnes(colonnes ...string) Lecture[T] {
+	copie := *l
+	copie.colonnes = colonnes
+	return &copie
+}
+
+// selection retourne les enregistrements visibles satisfaisant les conditions et leurs lignes
+func (l *lectureMemoire[T]) selection(conditions []Condition) ([]T, []ligne, error) {
+	visible, err := l.depot.registre.visible(l.portee, l.depot.regle)
+	if err != nil {
+		return nil, nil, err
+	}
+	tous := l.depot.tous()
+	lignes, err := l.depot.lignes(tous)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entites []T
+	var retenues []ligne
+	for i, ligne := range lignes {
+		if !visible(ligne) {
+			continue
+		}
+		garder := true
+		for _, c := range conditions {
+			ok, err := l.depot.registre.satisfait(c, ligne)
+			if err != nil {
+				return nil, nil, err
+			}
+			garder = garder && ok
+		}
+		if garder {
+			entites = append(entites, l.restreindre(tous[i]))
+			retenues = append(retenues, ligne)
+		}
+	}
+	return entites, retenues, nil
+}
+
+// restreindre vide les champs des colonnes non demandées
+func (l *lectureMemoire[T]) restreindre(entite T) T {
+	if len(l.colonnes) == 0 {
+		return entite
+	}
+	s, err := schemaMemoire[T]()
+	if err != nil {
+		return entite
+	}
+	var restreinte T
+	source, cible := reflect.ValueOf(&entite).Elem(), reflect.ValueOf(&restreinte).Elem()
+	for _, colonne := range l.colonnes {
+		if f, ok := s.FieldsByDBName[colonne]; ok {
+			f.ReflectValueOf(context.Background(), cible).Set(f.ReflectValueOf(context.Background(), source))
+		}
+	}
+	return restreinte
+}
+
+// ordonner trie les enregistrements ; comme Postgres, les valeurs absentes viennent
+// en dernier en ordre croissant
+func ordonner[T any](entites []T, lignes []ligne, ordre string) []T {
+	colonnes, desc := triDe(ordre)
+	indices := make([]int, len(entites))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		for i, colonne := range colonnes {
+			va, vb := lignes[indices[a]][colonne], lignes[indices[b]][colonne]
+			var ordre int
+			switch {
+			case va == nil && vb == nil:
+				continue
+			case va == nil:
+				ordre = 1
+			case vb == nil:
+				ordre = -1
+			default:
+				ordre, _ = requetes.Comparer(va, vb)
+			}
+			if ordre != 0 {
+				return (ordre < 0) != desc[i]
+			}
+		}
+		return false
+	})
+	triees := make([]T, len(indices))
+	for i, j := range indices {
+		triees[i] = entites[j]
+	}
+	return triees
+}
+
+func (l *lectureMemoire[T]) Compter(conditions ...Condition) (int64, error) {
+	entites, _, err := l.selection(conditions)
+	return int64(len(entites)), err
+}
+
+func (l *lectureMemoire[T]) Repartition(colonne string, limite int, conditions ...Condition) ([]map[string]interface{}, error) {
+	_, lignes, err := l.selection(conditions)
+	if err != nil {
+		return nil, err
+	}
+	var valeurs []interface{}
+	comptes := map[interface{}]int64{}
+	for _, ligne := range lignes {
+		valeur, ok := ligne[colonne]
+		if !ok {
+			return nil, fmt.Errorf("colonne inconnue : %s", colonne)
+		}
+		if _, vue := comptes[valeur]; !vue {
+			valeurs = append(valeurs, valeur)
+		}
+		comptes[valeur]++
+	}
+	sort.SliceStable(valeurs, func(a, b int) bool {
+		if comptes[valeurs[a]] != comptes[valeurs[b]] {
+			return comptes[valeurs[a]] > comptes[valeurs[b]]
+		}
+		ordre, _ := requetes.Comparer(valeurs[a], valeurs[b])
+		return ordre < 0
+	})
+	if limite > 0 && len(valeurs) > limite {
+		valeurs = valeurs[:limite]
+	}
+	repartition := make([]map[string]interface{}, len(valeurs))
+	for i, valeur := range valeurs {
+		repartition[i] = map[string]interface{}{colonne: valeur, "count": comptes[valeur]}
+	}
+	return repartition, nil
+}
+
+func (l *lectureMemoire[T]) Moyenne(colonne string, conditions ...Condition) (*float64, error) {
+	_, lignes, err := l.selection(conditions)
+	if err != nil {
+		return nil, err
+	}
+	var somme float64
+	var n int
+	for _, ligne := range lignes {
+		v := reflect.ValueOf(ligne[colonne])
+		switch {
+		case v.CanFloat():
+			somme += v.Float()
+		case v.CanInt():
+			somme += float64(v.Int())
+		default:
+			continue
+		}
+		n++
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	moyenne := somme / float64(n)
+	return &moyenne, nil
+}
+
+func (l *lectureMemoire[T]) Paginer(liste *requetes.Liste, conditions ...Condition) (interface{}, map[string]interface{}, error) {
+	entites, _, err := l.selection(conditions)
+	if err != nil {
+		return nil, nil, err
+	}
+	return liste.ExecuterLignes(&entites)
+}
+
+func (l *lectureMemoire[T]) Tout(liste *requetes.Liste, conditions ...Condition) (interface{}, error) {
+	entites, _, err := l.selection(conditions)
+	if err != nil {
+		return nil, err
+	}
+	return liste.ToutLignes(&entites)
+}
+
+func (l *lectureMemoire[T]) Page(ordre string, page, limite int, conditions ...Condition) ([]T, int64, error) {
+	entites, lignes, err := l.selection(conditions)
+	if err != nil {
+		return nil, 0, err
+	}
+	entites = ordonner(entites, lignes, ordre)
+	total := len(entites)
+	debut := min((page-1)*limite, total)
+	return entites[debut:min(debut+limite, total)], int64(total), nil
+}
+
+func (l *lectureMemoire[T]) Charger(ordre string, conditions ...Condition) ([]T, error) {
+	entites, lignes, err := l.selection(conditions)
+	if err != nil {
+		return nil, err
+	}
+	return ordonner(entites, lignes, ordre), nil
+}
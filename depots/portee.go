@@ -0,0 +1,104 @@
+package depots
+
+import (
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+// Portee est l'ensemble des unités dont les enregistrements sont visibles :
+// tout le territoire, ou une liste d'unités (celle d'un agent et ses sous-unités)
+type Portee struct {
+	National bool
+	Unites   []string
+}
+
+// PorteeNationale - lecture sans restriction d'unité
+var PorteeNationale = Portee{National: true}
+
+// Migrants restreint une requête sur la table migrants
+func (p *Portee) Migrants(db *gorm.DB) *gorm.DB {
+	return p.ParUnite("migrants.unite_uuid")(db)
+}
+
+// ParUnite restreint une requête sur la colonne d'unité donnée (ex. "m.unite_uuid" pour une table aliasée)
+func (p *Portee) ParUnite(colonne string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.National {
+			return db
+		}
+		return db.Where(colonne+" IN ?", p.Unites)
+	}
+}
+
+// Identites restreint une requête sur la table identites : identités de l'unité
+// ou rattachées à un migrant de la portée
+func (p *Portee) Identites(db *gorm.DB) *gorm.DB {
+	if p.National {
+		return db
+	}
+	return db.Where("(identites.unite_uuid IN ? OR identites.uuid IN (?))",
+		p.Unites, p.sousRequeteMigrants(db, "identite_uuid"))
+}
+
+// ParMigrant restreint une requête dont la colonne référence un migrant (ex. "alertes.migrant_uuid")
+func (p *Portee) ParMigrant(colonne string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.National {
+			return db
+		}
+		return db.Where(colonne+" IN (?)", p.sousRequeteMigrants(db, "uuid"))
+	}
+}
+
+// ParIdentite restreint une requête dont la colonne référence une identité
+func (p *Portee) ParIdentite(colonne string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.National {
+			return db
+		}
+		return db.Where(colonne+" IN (?)", nouvelle(db).Model(&models.Identite{}).Scopes(p.Identites).Select("identites.uuid"))
+	}
+}
+
+// Les migrants supprimés gardent leur unité : leurs enregistrements restent dans la portée
+func (p *Portee) sousRequeteMigrants(db *gorm.DB, colonne string) *gorm.DB {
+	return nouvelle(db).Unscoped().Model(&models.Migrant{}).
+		Where("migrants.unite_uuid IN ?", p.Unites).
+		Select("migrants." + colonne)
+}
+
+// nouvelle retourne une requête vierge sur la même connexion (ou transaction), pour les sous-requêtes
+func nouvelle(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+// regle désigne comment la portée s'applique aux enregistrements d'un agrégat
+type regle int
+
+const (
+	// Enregistrements visibles de toutes les unités (utilisateurs)
+	sansRegle regle = iota
+	// Colonne unite_uuid de l'enregistrement
+	parUnite
+	// Identités de la portée ou rattachées à un migrant de la portée
+	parIdentites
+	// Colonne migrant_uuid : migrant de la portée
+	parMigrant
+	// Colonne identite_uuid : identité de la portée
+	parIdentite
+)
+
+// restreindre applique la règle de l'agrégat à une requête sur sa table
+func (p *Portee) restreindre(r regle, table string) func(*gorm.DB) *gorm.DB {
+	switch r {
+	case parUnite:
+		return p.ParUnite(table + ".unite_uuid")
+	case parIdentites:
+		return p.Identites
+	case parMigrant:
+		return p.ParMigrant(table + ".migrant_uuid")
+	case parIdentite:
+		return p.ParIdentite(table + ".identite_uuid")
+	}
+	return func(db *gorm.DB) *gorm.DB { return db }
+}
@@ -0,0 +1,297 @@
+package depots
+
+import (
+	"slices"
+
+	"github.com/kgermando/sysmobembo-api/controllers/requetes"
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+// =======================
+// DÉPÔT POSTGRES
+// =======================
+
+type depotPostgres[T any] struct {
+	db *gorm.DB
+	// Application de la portée aux lectures
+	regle regle
+}
+
+func (d *depotPostgres[T]) Trouver(uuid string, relations ...string) (*T, error) {
+	requete := d.db
+	for _, relation := range relations {
+		requete = requete.Preload(relation)
+	}
+	entite := new(T)
+	if err := requete.Where("uuid = ?", uuid).First(entite).Error; err != nil {
+		return nil, err
+	}
+	return entite, nil
+}
+
+func (d *depotPostgres[T]) Creer(entite *T) error {
+	return d.db.Create(entite).Error
+}
+
+func (d *depotPostgres[T]) Modifier(entite *T, valeurs *T, champs ...string) error {
+	requete := d.db.Model(entite)
+	version, versionnee := versionDe(entite)
+	if versionnee {
+		requete = requete.Where("version = ?", version)
+	}
+	// Select écrit aussi les valeurs nulles (nombre d'enfants à 0, email effacé)
+	if len(champs) > 0 {
+		requete = requete.Select(champs)
+	}
+
+	result := requete.Updates(valeurs)
+	if result.Error != nil {
+		return result.Error
+	}
+	// Modifié par un autre agent entre la lecture et l'écriture
+	if versionnee && result.RowsAffected == 0 {
+		return ErrVersionPerimee
+	}
+	return nil
+}
+
+func (d *depotPostgres[T]) Sauvegarder(entite *T) error {
+	return d.db.Save(entite).Error
+}
+
+func (d *depotPostgres[T]) Supprimer(entite *T) error {
+	requete := d.db
+	version, versionnee := versionDe(entite)
+	if versionnee {
+		requete = requete.Where("version = ?", version)
+	}
+	result := requete.Delete(entite)
+	if result.Error != nil {
+		return result.Error
+	}
+	if versionnee && result.RowsAffected == 0 {
+		return ErrVersionPerimee
+	}
+	return nil
+}
+
+// =======================
+// MIGRANTS
+// =======================
+
+type migrantsPostgres struct {
+	depotPostgres[models.Migrant]
+}
+
+// Creer attribue au migrant le prochain numéro de son bureau d'enregistrement,
+// dans la transaction qui le crée
+func (d *migrantsPostgres) Creer(migrant *models.Migrant) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		numeros, err := models.NumerotationMigrants.Reserver(tx, migrant.BureauEnregistrement, 1)
+		if err != nil {
+			return err
+		}
+		migrant.NumeroIdentifiant = numeros[0]
+		return tx.Create(migrant).Error
+	})
+}
+
+func (d *migrantsPostgres) AvecContexte(ctx models.ContexteStatut) Migrants {
+	return &migrantsPostgres{depotPostgres[models.Migrant]{db: models.AvecContexteStatut(d.db, ctx), regle: d.regle}}
+}
+
+func (d *migrantsPostgres) UniteDe(uuid string) (string, error) {
+	var migrant models.Migrant
+	err := d.db.Unscoped().Select("uuid", "unite_uuid").Where("uuid = ?", uuid).First(&migrant).Error
+	return migrant.UniteUUID, err
+}
+
+// =======================
+// IDENTITÉS
+// =======================
+
+type identitesPostgres struct {
+	depotPostgres[models.Identite]
+}
+
+func (d *identitesPostgres) ParPasseport(numero, sauf string) (*models.Identite, error) {
+	var identite models.Identite
+	if err := d.db.Where("numero_passeport = ? AND uuid != ?", numero, sauf).First(&identite).Error; err != nil {
+		return nil, err
+	}
+	return &identite, nil
+}
+
+func (d *identitesPostgres) CompterMigrants(uuid string) (int64, error) {
+	var count int64
+	err := d.db.Model(&models.Migrant{}).Where("identite_uuid = ?", uuid).Count(&count).Error
+	return count, err
+}
+
+func (d *identitesPostgres) UniteDe(uuid string) (string, error) {
+	var identite models.Identite
+	err := d.db.Unscoped().Select("uuid", "unite_uuid").Where("uuid = ?", uuid).First(&identite).Error
+	return identite.UniteUUID, err
+}
+
+func (d *identitesPostgres) RattacheeA(uuid string, unites []string) (bool, error) {
+	if len(unites) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := d.db.Model(&models.Migrant{}).
+		Where("identite_uuid = ? AND unite_uuid IN ?", uuid, unites).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// =======================
+// UNITÉS
+// =======================
+
+type unitesPostgres struct {
+	depotPostgres[models.UniteOrganisationnelle]
+}
+
+func (d *unitesPostgres) SousArbre(unite models.UniteOrganisationnelle) ([]string, error) {
+	var uuids []string
+	err := d.db.Model(&models.UniteOrganisationnelle{}).
+		Where("chemin LIKE ?", unite.Chemin+"%").
+		Pluck("uuid", &uuids).Error
+	return uuids, err
+}
+
+func (d *unitesPostgres) Journaliser(acces *models.AccesHorsPerimetre) error {
+	return d.db.Create(acces).Error
+}
+
+// =======================
+// LECTURES
+// =======================
+
+type lecturePostgres[T any] struct {
+	db        *gorm.DB
+	table     string
+	relations []string
+	colonnes  []string
+}
+
+func (d *depotPostgres[T]) Lire(portee Portee) Lecture[T] {
+	l := &lecturePostgres[T]{db: d.db}
+	stmt := &gorm.Statement{DB: d.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		l.db = d.db.Session(&gorm.Session{NewDB: true})
+		l.db.AddError(err)
+		return l
+	}
+	l.table = stmt.Schema.Table
+	// Session : la connexion restreinte sert à plusieurs requêtes
+	l.db = d.db.Scopes(portee.restreindre(d.regle, l.table)).Session(&gorm.Session{})
+	return l
+}
+
+func (l *lecturePostgres[T]) Avec(relations ...string) Lecture[T] {
+	copie := *l
+	copie.relations = append(slices.Clone(l.relations), relations...)
+	return &copie
+}
+
+func (l *lecturePostgres[T]) Colonnes(colonnes ...string) Lecture[T] {
+	copie := *l
+	copie.colonnes = colonnes
+	return &copie
+}
+
+// requete retourne la requête restreinte par les conditions, sans relations ni colonnes
+func (l *lecturePostgres[T]) requete(conditions []Condition) *gorm.DB {
+	query := l.db.Model(new(T))
+	for _, c := range conditions {
+		if clause, args := c.sql(l.table); clause != "" {
+			query = query.Where(clause, args...)
+		}
+	}
+	return query
+}
+
+// chargement ajoute à la requête les relations et colonnes demandées
+func (l *lecturePostgres[T]) chargement(query *gorm.DB) *gorm.DB {
+	for _, relation := range l.relations {
+		query = query.Preload(relation)
+	}
+	if len(l.colonnes) > 0 {
+		colonnes := make([]string, len(l.colonnes))
+		for i, c := range l.colonnes {
+			colonnes[i] = l.table + "." + c
+		}
+		query = query.Select(colonnes)
+	}
+	return query
+}
+
+func (l *lecturePostgres[T]) ordonner(query *gorm.DB, ordre string) *gorm.DB {
+	colonnes, desc := triDe(ordre)
+	for i, c := range colonnes {
+		if desc[i] {
+			query = query.Order(l.table + "." + c + " DESC")
+		} else {
+			query = query.Order(l.table + "." + c + " ASC")
+		}
+	}
+	return query
+}
+
+func (l *lecturePostgres[T]) Compter(conditions ...Condition) (int64, error) {
+	var count int64
+	err := l.requete(conditions).Count(&count).Error
+	return count, err
+}
+
+func (l *lecturePostgres[T]) Repartition(colonne string, limite int, conditions ...Condition) ([]map[string]interface{}, error) {
+	lignes := []map[string]interface{}{}
+	query := l.requete(conditions).
+		Select(colonne + ", COUNT(*) as count").
+		Group(colonne).
+		Order("count DESC")
+	if limite > 0 {
+		query = query.Limit(limite)
+	}
+	err := query.Scan(&lignes).Error
+	return lignes, err
+}
+
+func (l *lecturePostgres[T]) Moyenne(colonne string, conditions ...Condition) (*float64, error) {
+	var moyenne *float64
+	err := l.requete(conditions).Select("AVG(" + l.table + "." + colonne + ")").Scan(&moyenne).Error
+	return moyenne, err
+}
+
+func (l *lecturePostgres[T]) Paginer(liste *requetes.Liste, conditions ...Condition) (interface{}, map[string]interface{}, error) {
+	var entites []T
+	return liste.Executer(l.chargement(l.requete(conditions)), &entites)
+}
+
+func (l *lecturePostgres[T]) Tout(liste *requetes.Liste, conditions ...Condition) (interface{}, error) {
+	var entites []T
+	return liste.Tout(l.chargement(l.requete(conditions)), &entites)
+}
+
+func (l *lecturePostgres[T]) Page(ordre string, page, limite int, conditions ...Condition) ([]T, int64, error) {
+	var total int64
+	query := l.requete(conditions)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entites []T
+	err := l.ordonner(l.chargement(query), ordre).
+		Offset((page - 1) * limite).
+		Limit(limite).
+		Find(&entites).Error
+	return entites, total, err
+}
+
+func (l *lecturePostgres[T]) Charger(ordre string, conditions ...Condition) ([]T, error) {
+	var entites []T
+	err := l.ordonner(l.chargement(l.requete(conditions)), ordre).Find(&entites).Error
+	return entites, err
+}
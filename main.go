@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/kgermando/sysmobembo-api/controllers/identites"
 	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/middlewares"
+	"github.com/kgermando/sysmobembo-api/problemes"
 	"github.com/kgermando/sysmobembo-api/routes"
+	"github.com/kgermando/sysmobembo-api/utils"
 )
 
 func getPort() string {
@@ -25,9 +33,46 @@ func getPort() string {
 
 func main() {
 
+	// Sous-commande de gestion du schéma : migrate up|down|status|force|create
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.Migrer(os.Args[2:]); err != nil {
+			log.Fatalf("❌ migrate: %v", err)
+		}
+		return
+	}
+
+	// Données d'amorçage : sous-commande seed -profile minimal|demo|load-test
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := database.Seed(os.Args[2:]); err != nil {
+			log.Fatalf("❌ seed: %v", err)
+		}
+		return
+	}
+
+	// Clé de signature des cartes : obligatoire hors développement
+	if err := utils.LoadCardSigningKey(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
 	database.Connect()
 
-	app := fiber.New()
+	// Amorçage automatique uniquement si DB_SEED_PROFILE est défini
+	database.SeedAuDemarrage(database.DB)
+
+	// Arrêt propre sur SIGINT/SIGTERM : les tâches de fond s'arrêtent avec le serveur
+	ctx, arreter := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer arreter()
+
+	// Contrôle nocturne de la validité des documents de voyage
+	identites.DemarrerControleNocturne()
+
+	// Purge horaire des clés d'idempotence expirées
+	go middlewares.PurgerIdempotence(ctx, middlewares.NewStockagePostgres(database.DB), time.Hour)
+
+	app := fiber.New(fiber.Config{
+		// Erreurs renvoyées au format application/problem+json (RFC 7807)
+		ErrorHandler: problemes.Gestionnaire,
+	})
 
 	// Initialize default config
 	app.Use(logger.New())
@@ -35,7 +80,8 @@ func main() {
 	// Middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "https://ipos-stock.app, http://localhost:4200, http://192.168.125.185:4200",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Expires, Cache-Control, Pragma",
+		AllowHeaders:     "Origin, Content-Type, Accept, Accept-Language, Authorization, Expires, Cache-Control, Pragma, Idempotency-Key, If-Match",
+		ExposeHeaders:    "Idempotent-Replayed, ETag",
 		AllowCredentials: true,
 		AllowMethods: strings.Join([]string{
 			fiber.MethodGet,
@@ -49,6 +95,13 @@ func main() {
 
 	routes.Setup(app)
 
-	log.Fatal(app.Listen(getPort()))
+	go func() {
+		<-ctx.Done()
+		app.Shutdown()
+	}()
+
+	if err := app.Listen(getPort()); err != nil {
+		log.Fatal(err)
+	}
 
 }
@@ -0,0 +1,302 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// En-tête envoyé par le client pour rendre une création rejouable sans doublon
+const EnTeteIdempotence = "Idempotency-Key"
+
+// Fenêtre de conservation des réponses par défaut, modifiable par IDEMPOTENCY_WINDOW_HOURS
+const fenetreIdempotenceDefaut = 24 * time.Hour
+
+// Bail d'une réservation : une requête restée en cours au-delà (processus arrêté
+// avant d'enregistrer ou de libérer sa réponse) ne bloque plus sa clé
+var bailReservation = 2 * time.Minute
+
+// StockageIdempotence conserve les requêtes idempotentes et leurs réponses
+type StockageIdempotence interface {
+	// Reserver enregistre la clé pour la requête en cours. Si la clé est déjà connue et
+	// n'a pas expiré, la requête existante est retournée et rien n'est réservé, sauf si
+	// sa réservation a dépassé le bail sans réponse enregistrée.
+	Reserver(cle, empreinte string, expireLe time.Time) (*models.RequeteIdempotente, error)
+	// Enregistrer conserve la réponse de la requête réservée
+	Enregistrer(cle string, codeHTTP int, typeContenu string, reponse []byte) error
+	// Liberer supprime une réservation dont la requête a échoué, pour permettre un nouvel essai
+	Liberer(cle string) error
+	// Purger supprime les clés expirées
+	Purger(avant time.Time) error
+}
+
+// FenetreIdempotence retourne la durée de conservation des réponses (IDEMPOTENCY_WINDOW_HOURS)
+func FenetreIdempotence() time.Duration {
+	if heures, err := strconv.Atoi(utils.Env("IDEMPOTENCY_WINDOW_HOURS")); err == nil && heures > 0 {
+		return time.Duration(heures) * time.Hour
+	}
+	return fenetreIdempotenceDefaut
+}
+
+// Idempotence rejoue la réponse d'une requête POST renvoyée avec le même en-tête
+// Idempotency-Key, par exemple après une coupure réseau, au lieu de créer un doublon.
+// Une clé réutilisée avec un autre contenu est refusée. Les routes commençant par l'un
+// des préfixes exclus ne sont jamais rejouées (authentification : les réponses
+// contiennent des jetons qui ne doivent pas être conservés).
+func Idempotence(stockage StockageIdempotence, fenetre time.Duration, exclus ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cleClient := c.Get(EnTeteIdempotence)
+		if cleClient == "" || c.Method() != fiber.MethodPost {
+			return c.Next()
+		}
+		for _, prefixe := range exclus {
+			if strings.HasPrefix(c.Path(), prefixe) {
+				return c.Next()
+			}
+		}
+		if len(cleClient) > 255 {
+			return problemes.Envoyer(c, problemes.Invalide("Idempotency-Key must not exceed 255 characters"))
+		}
+
+		// La clé est propre à l'appelant et à la route ; sans jeton, l'appelant est
+		// identifié par son adresse pour que deux clients anonymes ne partagent pas leurs clés
+		appelant, _ := utils.VerifyJwt(c.Query("token"))
+		if appelant == "" {
+			appelant = "anonyme@" + c.IP()
+		}
+		cle := appelant + ":" + c.Path() + ":" + cleClient
+
+		somme := sha256.Sum256(append([]byte(c.Method()+" "+c.Path()+"\n"), c.Body()...))
+		empreinte := hex.EncodeToString(somme[:])
+
+		existante, err := stockage.Reserver(cle, empreinte, time.Now().Add(fenetre))
+		if err != nil {
+			return problemes.Envoyer(c, problemes.Interne("Failed to check Idempotency-Key", err))
+		}
+
+		if existante != nil {
+			switch {
+			case existante.Empreinte != empreinte:
+				return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusUnprocessableEntity, problemes.CodeIdempotenceReutilisee,
+					"Idempotency-Key already used with a different payload"))
+			case existante.Statut != models.IdempotenceTermine:
+				return problemes.Envoyer(c, problemes.Nouveau(fiber.StatusConflict, problemes.CodeIdempotenceEnCours,
+					"A request with this Idempotency-Key is still being processed"))
+			}
+
+			c.Set("Idempotent-Replayed", "true")
+			if existante.TypeContenu != "" {
+				c.Set(fiber.HeaderContentType, existante.TypeContenu)
+			}
+			return c.Status(existante.CodeHTTP).Send(existante.Reponse)
+		}
+
+		// Les erreurs retournées par le handler (409, 422, ...) sont écrites ici par
+		// l'ErrorHandler de l'application pour être enregistrées comme les autres réponses
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				stockage.Liberer(cle)
+				return err
+			}
+		}
+
+		// Une erreur serveur n'est pas conservée : le client pourra réessayer avec la même clé
+		code := c.Response().StatusCode()
+		if code >= 500 {
+			stockage.Liberer(cle)
+			return nil
+		}
+
+		reponse := append([]byte(nil), c.Response().Body()...)
+		if err := stockage.Enregistrer(cle, code, string(c.Response().Header.ContentType()), reponse); err != nil {
+			log.Printf("❌ Enregistrement de la réponse idempotente: %v", err)
+		}
+		return nil
+	}
+}
+
+// PurgerIdempotence supprime les clés expirées à intervalle régulier jusqu'à
+// l'annulation du contexte. Elle est lancée une fois, au démarrage de l'API.
+func PurgerIdempotence(ctx context.Context, stockage StockageIdempotence, intervalle time.Duration) {
+	ticker := time.NewTicker(intervalle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := stockage.Purger(now); err != nil {
+				log.Printf("❌ Purge des clés d'idempotence: %v", err)
+			}
+		}
+	}
+}
+
+// =======================
+// STOCKAGE POSTGRES
+// =======================
+
+type stockagePostgres struct {
+	db *gorm.DB
+}
+
+// NewStockagePostgres conserve les clés d'idempotence dans la table idempotency_keys
+func NewStockagePostgres(db *gorm.DB) StockageIdempotence {
+	return &stockagePostgres{db: db}
+}
+
+func (s *stockagePostgres) Reserver(cle, empreinte string, expireLe time.Time) (*models.RequeteIdempotente, error) {
+	now := time.Now()
+	requete := models.RequeteIdempotente{
+		UUID:      utils.GenerateUUID(),
+		Cle:       cle,
+		Empreinte: empreinte,
+		Statut:    models.IdempotenceEnCours,
+		ExpireLe:  expireLe,
+		ReserveLe: now,
+	}
+	res := s.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "cle"}}, DoNothing: true}).Create(&requete)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	// Clé connue mais expirée, ou réservée sans réponse au-delà du bail : elle est
+	// reprise pour la nouvelle requête
+	res = s.db.Model(&models.RequeteIdempotente{}).
+		Where("cle = ? AND (expire_le < ? OR (statut = ? AND reserve_le < ?))",
+			cle, now, models.IdempotenceEnCours, now.Add(-bailReservation)).
+		Updates(map[string]interface{}{
+			"empreinte":    empreinte,
+			"statut":       models.IdempotenceEnCours,
+			"code_http":    0,
+			"type_contenu": "",
+			"reponse":      nil,
+			"expire_le":    expireLe,
+			"reserve_le":   now,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existante models.RequeteIdempotente
+	if err := s.db.Where("cle = ?", cle).First(&existante).Error; err != nil {
+		return nil, err
+	}
+	return &existante, nil
+}
+
+func (s *stockagePostgres) Enregistrer(cle string, codeHTTP int, typeContenu string, reponse []byte) error {
+	return s.db.Model(&models.RequeteIdempotente{}).
+		Where("cle = ?", cle).
+		Updates(map[string]interface{}{
+			"statut":       models.IdempotenceTermine,
+			"code_http":    codeHTTP,
+			"type_contenu": typeContenu,
+			"reponse":      reponse,
+		}).Error
+}
+
+func (s *stockagePostgres) Liberer(cle string) error {
+	return s.db.Where("cle = ? AND statut = ?", cle, models.IdempotenceEnCours).
+		Delete(&models.RequeteIdempotente{}).Error
+}
+
+func (s *stockagePostgres) Purger(avant time.Time) error {
+	return s.db.Where("expire_le < ?", avant).Delete(&models.RequeteIdempotente{}).Error
+}
+
+// =======================
+// STOCKAGE EN MÉMOIRE
+// =======================
+
+type stockageMemoire struct {
+	mu       sync.Mutex
+	requetes map[string]models.RequeteIdempotente
+}
+
+// NewStockageMemoire conserve les clés d'idempotence en mémoire, pour les tests
+func NewStockageMemoire() StockageIdempotence {
+	return &stockageMemoire{requetes: map[string]models.RequeteIdempotente{}}
+}
+
+func (s *stockageMemoire) Reserver(cle, empreinte string, expireLe time.Time) (*models.RequeteIdempotente, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existante, ok := s.requetes[cle]; ok && existante.ExpireLe.After(now) {
+		abandonnee := existante.Statut == models.IdempotenceEnCours && existante.ReserveLe.Before(now.Add(-bailReservation))
+		if !abandonnee {
+			existante.Reponse = append([]byte(nil), existante.Reponse...)
+			return &existante, nil
+		}
+	}
+	s.requetes[cle] = models.RequeteIdempotente{
+		UUID:      utils.GenerateUUID(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Cle:       cle,
+		Empreinte: empreinte,
+		Statut:    models.IdempotenceEnCours,
+		ExpireLe:  expireLe,
+		ReserveLe: now,
+	}
+	return nil, nil
+}
+
+func (s *stockageMemoire) Enregistrer(cle string, codeHTTP int, typeContenu string, reponse []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requete, ok := s.requetes[cle]
+	if !ok {
+		return nil
+	}
+	requete.Statut = models.IdempotenceTermine
+	requete.CodeHTTP = codeHTTP
+	requete.TypeContenu = typeContenu
+	requete.Reponse = append([]byte(nil), reponse...)
+	requete.UpdatedAt = time.Now()
+	s.requetes[cle] = requete
+	return nil
+}
+
+func (s *stockageMemoire) Liberer(cle string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if requete, ok := s.requetes[cle]; ok && requete.Statut == models.IdempotenceEnCours {
+		delete(s.requetes, cle)
+	}
+	return nil
+}
+
+func (s *stockageMemoire) Purger(avant time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for cle, requete := range s.requetes {
+		if requete.ExpireLe.Before(avant) {
+			delete(s.requetes, cle)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package middlewares
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// appIdempotente compte les exécutions du handler de création
+func appIdempotente(handler fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	app.Use(Idempotence(NewStockageMemoire(), time.Hour, "/api/auth/"))
+	app.Post("/api/migrants/create", handler)
+	app.Post("/api/auth/login", handler)
+	return app
+}
+
+func requeteIdempotente(chemin, cle, corps string) *http.Request {
+	req := httptest.NewRequest("POST", chemin, strings.NewReader(corps))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(EnTeteIdempotence, cle)
+	return req
+}
+
+func TestIdempotenceReplaysResponse(t *testing.T) {
+	var executions int32
+	app := appIdempotente(func(c *fiber.Ctx) error {
+		n := atomic.AddInt32(&executions, 1)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"execution": n})
+	})
+
+	var corps []string
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(requeteIdempotente("/api/migrants/create", "cle-1", `{"nom":"MBALA"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusCreated {
+			t.Fatalf("requête %d: statut %d, attendu 201", i+1, resp.StatusCode)
+		}
+		if rejoue := resp.Header.Get("Idempotent-Replayed"); (i == 1) != (rejoue == "true") {
+			t.Errorf("requête %d: Idempotent-Replayed=%q", i+1, rejoue)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		corps = append(corps, string(b))
+	}
+
+	if executions != 1 {
+		t.Errorf("handler exécuté %d fois, attendu 1", executions)
+	}
+	if corps[0] != corps[1] {
+		t.Errorf("réponse rejouée %s, attendu %s", corps[1], corps[0])
+	}
+}
+
+func TestIdempotenceRejectsDifferentPayload(t *testing.T) {
+	app := appIdempotente(func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	if _, err := app.Test(requeteIdempotente("/api/migrants/create", "cle-1", `{"nom":"MBALA"}`)); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := app.Test(requeteIdempotente("/api/migrants/create", "cle-1", `{"nom":"KABILA"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Errorf("statut %d, attendu 422", resp.StatusCode)
+	}
+}
+
+func TestIdempotenceRejectsConcurrentRequest(t *testing.T) {
+	enCours, liberer := make(chan struct{}), make(chan struct{})
+	app := appIdempotente(func(c *fiber.Ctx) error {
+		close(enCours)
+		<-liberer
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := app.Test(requeteIdempotente("/api/migrants/create", "cle-1", `{}`), -1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != fiber.StatusCreated {
+			t.Errorf("première requête: statut %d, attendu 201", resp.StatusCode)
+		}
+	}()
+
+	<-enCours
+	resp, err := app.Test(requeteIdempotente("/api/migrants/create", "cle-1", `{}`), -1)
+	close(liberer)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("requête concurrente: statut %d, attendu 409", resp.StatusCode)
+	}
+}
+
+func TestIdempotenceSkipsExcludedRoutes(t *testing.T) {
+	var executions int32
+	app := appIdempotente(func(c *fiber.Ctx) error {
+		atomic.AddInt32(&executions, 1)
+		return c.JSON(fiber.Map{"token": "secret"})
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(requeteIdempotente("/api/auth/login", "cle-1", `{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Header.Get("Idempotent-Replayed") != "" {
+			t.Error("réponse d'authentification rejouée")
+		}
+	}
+	if executions != 2 {
+		t.Errorf("handler exécuté %d fois, attendu 2", executions)
+	}
+}
+
+// stockageCompte compte les purges
+type stockageCompte struct {
+	StockageIdempotence
+	purges int32
+}
+
+func (s *stockageCompte) Purger(avant time.Time) error {
+	atomic.AddInt32(&s.purges, 1)
+	return nil
+}
+
+func TestPurgerIdempotenceStopsWithContext(t *testing.T) {
+	stockage := &stockageCompte{StockageIdempotence: NewStockageMemoire()}
+	ctx, annuler := context.WithCancel(context.Background())
+
+	termine := make(chan struct{})
+	go func() {
+		PurgerIdempotence(ctx, stockage, time.Millisecond)
+		close(termine)
+	}()
+
+	for atomic.LoadInt32(&stockage.purges) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	annuler()
+	select {
+	case <-termine:
+	case <-time.After(time.Second):
+		t.Fatal("la purge continue après l'annulation du contexte")
+	}
+}
+
+// Une réservation restée en cours au-delà du bail est reprise ; avant, elle bloque la clé
+func TestReservationRepriseApresBail(t *testing.T) {
+	bail := bailReservation
+	bailReservation = 50 * time.Millisecond
+	t.Cleanup(func() { bailReservation = bail })
+
+	stockages := map[string]func(t *testing.T) StockageIdempotence{
+		"memoire": func(t *testing.T) StockageIdempotence { return NewStockageMemoire() },
+		"postgres": func(t *testing.T) StockageIdempotence {
+			return NewStockagePostgres(basetest.Migree(t))
+		},
+	}
+	for nom, nouveau := range stockages {
+		t.Run(nom, func(t *testing.T) {
+			stockage := nouveau(t)
+			expireLe := time.Now().Add(time.Hour)
+
+			if existante, err := stockage.Reserver("cle-1", "empreinte", expireLe); err != nil || existante != nil {
+				t.Fatalf("première réservation : %v, %v", existante, err)
+			}
+			existante, err := stockage.Reserver("cle-1", "empreinte", expireLe)
+			if err != nil || existante == nil || existante.Statut != models.IdempotenceEnCours {
+				t.Fatalf("réservation pendant le bail : %v, %v, attendu la requête en cours", existante, err)
+			}
+
+			time.Sleep(2 * bailReservation)
+			if existante, err := stockage.Reserver("cle-1", "empreinte", expireLe); err != nil || existante != nil {
+				t.Fatalf("réservation après le bail : %v, %v, attendu la clé reprise", existante, err)
+			}
+
+			// Une réponse enregistrée n'est jamais reprise, même après le bail
+			if err := stockage.Enregistrer("cle-1", fiber.StatusCreated, fiber.MIMEApplicationJSON, []byte(`{}`)); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * bailReservation)
+			existante, err = stockage.Reserver("cle-1", "empreinte", expireLe)
+			if err != nil || existante == nil || existante.Statut != models.IdempotenceTermine {
+				t.Fatalf("réservation d'une clé terminée : %v, %v, attendu la réponse enregistrée", existante, err)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ type Alert struct {
 	Titre         string `json:"titre" validate:"required"`
 	Description   string `json:"description" gorm:"type:text" validate:"required"`
 
+	// Version incrémentée à chaque mise à jour (ETag / If-Match)
+	Version int64 `json:"version" gorm:"not null;default:1"`
+
 	// Statut et traitement
 	Statut              string     `json:"statut" gorm:"default:active" validate:"oneof=active resolved dismissed expired"`
 	DateExpiration      *time.Time `json:"date_expiration"`
@@ -37,3 +40,12 @@ type Alert struct {
 func (a *Alert) TableName() string {
 	return "alertes"
 }
+
+func (a *Alert) BeforeUpdate(tx *gorm.DB) error {
+	protegerVersion(tx)
+	return nil
+}
+
+func (a *Alert) AfterUpdate(tx *gorm.DB) error {
+	return incrementerVersion(tx, "alertes", a.UUID, &a.Version)
+}
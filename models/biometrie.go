@@ -16,6 +16,9 @@ type Biometrie struct {
 	MigrantUUID string  `json:"migrant_uuid" gorm:"type:varchar(255);not null"`
 	Migrant     Migrant `json:"migrant" gorm:"foreignKey:MigrantUUID;constraint:OnDelete:CASCADE"`
 
+	// Version incrémentée à chaque mise à jour, base des conflits de synchronisation
+	Version int64 `json:"version" gorm:"not null;default:1"`
+
 	// Types de données biométriques
 	TypeBiometrie string `json:"type_biometrie" validate:"required,oneof=empreinte_digitale reconnaissance_faciale iris scan_retine signature_numerique"`
 	IndexDoigt    *int   `json:"index_doigt"` // Pour les empreintes (1-10)
@@ -35,6 +38,7 @@ type Biometrie struct {
 	// Sécurité et chiffrement
 	Chiffre         bool   `json:"chiffre" gorm:"default:false"`          // Indique si les données sont chiffrées
 	CleChiffrement  string `json:"-" gorm:"type:text"`                    // Clé de chiffrement (non exposée en JSON)
+	EmpreinteHash   string `json:"-" gorm:"index;default:''"`             // SHA-256 du gabarit en clair, pour la détection de doublons
 
 	// Validation et vérification
 	Verifie          bool       `json:"verifie" gorm:"default:false"`
@@ -46,3 +50,12 @@ type Biometrie struct {
 func (b *Biometrie) TableName() string {
 	return "biometries"
 }
+
+func (b *Biometrie) BeforeUpdate(tx *gorm.DB) error {
+	protegerVersion(tx)
+	return nil
+}
+
+func (b *Biometrie) AfterUpdate(tx *gorm.DB) error {
+	return incrementerVersion(tx, "biometries", b.UUID, &b.Version)
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CarteMigrant est une carte d'enregistrement émise pour un migrant.
+// Une seule carte est active à la fois : l'émission d'une nouvelle carte révoque la précédente.
+type CarteMigrant struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	MigrantUUID       string `json:"migrant_uuid" gorm:"type:varchar(255);not null;index"`
+	NumeroIdentifiant string `json:"numero_identifiant" gorm:"not null;index"`
+
+	// Numéro de série porté par le QR code, utilisé pour la révocation
+	Serie          string    `json:"serie" gorm:"unique;not null"`
+	DateEmission   time.Time `json:"date_emission"`
+	DateExpiration time.Time `json:"date_expiration"`
+
+	// Jeton signé (charge utile + signature Ed25519) encodé dans le QR code
+	Jeton string `json:"jeton" gorm:"type:text"`
+
+	Statut          string     `json:"statut" gorm:"default:active;index" validate:"oneof=active revoquee"`
+	DateRevocation  *time.Time `json:"date_revocation"`
+	MotifRevocation string     `json:"motif_revocation"`
+	EmisePar        string     `json:"emise_par"`
+	RevoquePar      string     `json:"revoque_par"`
+}
+
+func (c *CarteMigrant) TableName() string {
+	return "migrant_cards"
+}
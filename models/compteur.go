@@ -0,0 +1,182 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// Compteur conserve le dernier numéro attribué d'une série pour une année et un bureau
+// (année 0 si le modèle de numéro ne comporte pas l'année)
+type Compteur struct {
+	UUID      string    `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Serie  string `json:"serie" gorm:"type:varchar(50);not null;uniqueIndex:idx_counters_cle"`
+	Annee  int    `json:"annee" gorm:"not null;uniqueIndex:idx_counters_cle"`
+	Bureau string `json:"bureau" gorm:"type:varchar(50);not null;default:'';uniqueIndex:idx_counters_cle"`
+	Valeur int64  `json:"valeur" gorm:"not null;default:0"`
+}
+
+func (c *Compteur) TableName() string {
+	return "counters"
+}
+
+// Numerotation décrit une série de numéros attribués par compteur.
+// Le modèle du numéro est lu dans la variable d'environnement VariableFormat (voir utils.FormatNumero).
+type Numerotation struct {
+	Serie          string
+	VariableFormat string
+	FormatDefaut   string
+
+	// Table et colonne des numéros déjà attribués, lues à l'ouverture d'un compteur
+	Table   string
+	Colonne string
+}
+
+var (
+	NumerotationMigrants = Numerotation{
+		Serie:          "migrants",
+		VariableFormat: "NUMERO_MIGRANT_FORMAT",
+		FormatDefaut:   "MIG-{annee}-{seq:6}",
+		Table:          "migrants",
+		Colonne:        "numero_identifiant",
+	}
+	NumerotationDossiers = Numerotation{
+		Serie:          "dossiers",
+		VariableFormat: "NUMERO_DOSSIER_FORMAT",
+		FormatDefaut:   "DOS-{annee}-{seq:6}",
+		Table:          "dossiers",
+		Colonne:        "numero_dossier",
+	}
+)
+
+// Format retourne le modèle configuré pour la série
+func (n Numerotation) Format() string {
+	if format := utils.Env(n.VariableFormat); format != "" {
+		return format
+	}
+	return n.FormatDefaut
+}
+
+// CodeBureau normalise le code d'un bureau d'enregistrement ; à défaut,
+// le bureau de l'instance (NUMERO_BUREAU_DEFAUT) est utilisé
+func CodeBureau(bureau string) string {
+	if bureau = utils.NormalizeDocumentNumber(bureau); bureau != "" {
+		return bureau
+	}
+	return utils.NormalizeDocumentNumber(utils.Env("NUMERO_BUREAU_DEFAUT"))
+}
+
+// Reserver attribue quantite numéros consécutifs.
+// Elle doit être appelée dans la transaction qui enregistre les lignes numérotées :
+// la ligne du compteur reste verrouillée jusqu'à la fin de la transaction, ce qui
+// ordonne les créations concurrentes, et une annulation rend les numéros.
+func (n Numerotation) Reserver(tx *gorm.DB, bureau string, quantite int) ([]string, error) {
+	if quantite <= 0 {
+		return nil, nil
+	}
+
+	format, annee, bureau, err := n.Cle(bureau)
+	if err != nil {
+		return nil, err
+	}
+
+	fin, err := n.incrementer(tx, format, annee, bureau, quantite)
+	if err != nil {
+		return nil, err
+	}
+
+	numeros := make([]string, quantite)
+	for i := range numeros {
+		numeros[i] = utils.FormatNumero(format, annee, bureau, fin-int64(quantite)+int64(i)+1)
+	}
+	return numeros, nil
+}
+
+// Cle retourne le modèle du numéro, l'année et le bureau qui identifient le compteur
+// à utiliser pour un enregistrement du bureau donné
+func (n Numerotation) Cle(bureau string) (string, int, string, error) {
+	format := n.Format()
+
+	// Sans {annee} dans le modèle, la séquence continue d'une année sur l'autre
+	annee := 0
+	if strings.Contains(format, "{annee}") {
+		annee = time.Now().Year()
+	}
+
+	// Sans {bureau} dans le modèle, tous les bureaux partagent le même compteur
+	if utils.FormatAvecBureau(format) {
+		if bureau = CodeBureau(bureau); bureau == "" {
+			return "", 0, "", fmt.Errorf("registration office code is required by the %s number format", n.Serie)
+		}
+	} else {
+		bureau = ""
+	}
+	return format, annee, bureau, nil
+}
+
+// incrementer avance le compteur et retourne sa nouvelle valeur
+func (n Numerotation) incrementer(tx *gorm.DB, format string, annee int, bureau string, quantite int) (int64, error) {
+	now := time.Now()
+
+	var valeurs []int64
+	err := tx.Raw(`UPDATE counters SET valeur = valeur + ?, updated_at = ?
+		WHERE serie = ? AND annee = ? AND bureau = ? RETURNING valeur`,
+		quantite, now, n.Serie, annee, bureau).Scan(&valeurs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(valeurs) == 1 {
+		return valeurs[0], nil
+	}
+
+	// Premier numéro de l'année pour ce bureau : le compteur reprend après le plus grand
+	// numéro déjà attribué. Si une autre transaction crée le compteur entre-temps, le
+	// conflit sur la clé unique se résout en simple incrément.
+	depart, err := n.plusGrandNumero(tx, format, annee, bureau)
+	if err != nil {
+		return 0, err
+	}
+	var valeur int64
+	err = tx.Raw(`INSERT INTO counters (uuid, created_at, updated_at, serie, annee, bureau, valeur)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (serie, annee, bureau) DO UPDATE SET valeur = counters.valeur + ?, updated_at = EXCLUDED.updated_at
+		RETURNING valeur`,
+		utils.GenerateUUID(), now, now, n.Serie, annee, bureau, depart+int64(quantite), quantite).Scan(&valeur).Error
+	return valeur, err
+}
+
+// plusGrandNumero retrouve la plus grande séquence déjà attribuée, y compris sur
+// les lignes supprimées dont le numéro ne doit pas être réutilisé
+func (n Numerotation) plusGrandNumero(tx *gorm.DB, format string, annee int, bureau string) (int64, error) {
+	var numeros []string
+	err := tx.Table(n.Table).
+		Where(n.Colonne+" LIKE ?", prefixeNumero(format, annee, bureau)+"%").
+		Pluck(n.Colonne, &numeros).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var max int64
+	for _, numero := range numeros {
+		if seq, ok := utils.SequenceNumero(format, annee, bureau, numero); ok && seq > max {
+			max = seq
+		}
+	}
+	return max, nil
+}
+
+// prefixeNumero retourne la partie fixe du numéro qui précède la séquence
+func prefixeNumero(format string, annee int, bureau string) string {
+	prefixe, _, _ := strings.Cut(format, "{seq")
+	prefixe = strings.NewReplacer("{annee}", fmt.Sprint(annee), "{bureau}", bureau).Replace(prefixe)
+	if i := strings.Index(prefixe, "{"); i >= 0 {
+		prefixe = prefixe[:i]
+	}
+	return utils.EscapeLike(prefixe)
+}
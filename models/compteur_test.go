@@ -0,0 +1,69 @@
+package models_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+func TestReserverConcurrentCreationsGetUniqueNumbers(t *testing.T) {
+	db := basetest.Migree(t)
+	t.Setenv("NUMERO_MIGRANT_FORMAT", "MIG-{annee}-{seq:6}")
+
+	identite := models.Identite{UUID: "identite", Nom: "MBALA", NumeroPasseport: "OP0000001"}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Numéro déjà attribué avant l'ouverture du compteur de l'année
+	annee := time.Now().Year()
+	existant := models.Migrant{UUID: "existant", IdentiteUUID: identite.UUID, NumeroIdentifiant: fmt.Sprintf("MIG-%d-000041", annee)}
+	if err := db.Omit("Identite").Create(&existant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const creations = 20
+	numeros := make(chan string, creations)
+	var wg sync.WaitGroup
+	for i := 0; i < creations; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := db.Transaction(func(tx *gorm.DB) error {
+				reserves, err := models.NumerotationMigrants.Reserver(tx, "", 1)
+				if err != nil {
+					return err
+				}
+				migrant := models.Migrant{UUID: fmt.Sprintf("migrant-%02d", i), IdentiteUUID: identite.UUID, NumeroIdentifiant: reserves[0]}
+				if err := tx.Omit("Identite").Create(&migrant).Error; err != nil {
+					return err
+				}
+				numeros <- reserves[0]
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(numeros)
+
+	vus := map[string]bool{}
+	for numero := range numeros {
+		if vus[numero] {
+			t.Errorf("numéro %s attribué deux fois", numero)
+		}
+		vus[numero] = true
+	}
+	// Les numéros suivent le plus grand numéro existant, sans trou
+	for seq := 42; seq < 42+creations; seq++ {
+		if numero := fmt.Sprintf("MIG-%d-%06d", annee, seq); !vus[numero] {
+			t.Errorf("numéro %s non attribué", numero)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Dossier représente une procédure suivie pour un migrant
+// (demande d'asile, régularisation, retour volontaire, regroupement familial)
+type Dossier struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	NumeroDossier string `json:"numero_dossier" gorm:"unique;not null"`
+
+	MigrantUUID string  `json:"migrant_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Migrant     Migrant `json:"migrant" gorm:"foreignKey:MigrantUUID;constraint:OnDelete:CASCADE" validate:"-"`
+
+	TypeDossier string `json:"type_dossier" gorm:"not null;index" validate:"required,oneof=demande_asile regularisation retour_volontaire regroupement_familial"`
+	Description string `json:"description" gorm:"type:text"`
+	Priorite    string `json:"priorite" gorm:"default:normale" validate:"omitempty,oneof=normale haute urgente"`
+
+	// Étape du traitement
+	Etape         string     `json:"etape" gorm:"default:enregistre;index" validate:"omitempty,oneof=enregistre entretien_planifie decision recours cloture"`
+	DateEntretien *time.Time `json:"date_entretien"`
+	DateLimite    *time.Time `json:"date_limite" gorm:"index"` // Prochaine échéance
+
+	// Décision
+	Decision          string     `json:"decision" validate:"omitempty,oneof=accordee rejetee"`
+	DateDecision      *time.Time `json:"date_decision"`
+	MotifDecision     string     `json:"motif_decision" gorm:"type:text"`
+	StatutAttribue    string     `json:"statut_attribue"` // Statut migratoire appliqué par la décision
+	DateLimiteRecours *time.Time `json:"date_limite_recours"`
+	DateCloture       *time.Time `json:"date_cloture"`
+
+	EnregistrePar string `json:"enregistre_par"`
+
+	// Version incrémentée à chaque mise à jour (ETag / If-Match)
+	Version int64 `json:"version" gorm:"not null;default:1"`
+
+	Agents []DossierAgent `json:"agents" gorm:"foreignKey:DossierUUID;constraint:OnDelete:CASCADE"`
+	Notes  []DossierNote  `json:"notes" gorm:"foreignKey:DossierUUID;constraint:OnDelete:CASCADE"`
+	Etapes []DossierEtape `json:"etapes" gorm:"foreignKey:DossierUUID;constraint:OnDelete:CASCADE"`
+}
+
+func (d *Dossier) TableName() string {
+	return "dossiers"
+}
+
+func (d *Dossier) BeforeUpdate(tx *gorm.DB) error {
+	protegerVersion(tx)
+	return nil
+}
+
+func (d *Dossier) AfterUpdate(tx *gorm.DB) error {
+	return incrementerVersion(tx, "dossiers", d.UUID, &d.Version)
+}
+
+// DossierAgent représente un agent affecté au traitement d'un dossier
+type DossierAgent struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	DossierUUID string `json:"dossier_uuid" gorm:"type:varchar(255);not null;index"`
+	AgentUUID   string `json:"agent_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Role        string `json:"role" gorm:"default:responsable" validate:"omitempty,oneof=responsable instructeur interprete superviseur"`
+	AgentNom    string `json:"agent_nom" gorm:"-"`
+}
+
+func (a *DossierAgent) TableName() string {
+	return "dossier_agents"
+}
+
+// DossierNote représente une note de suivi sur un dossier
+type DossierNote struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	DossierUUID    string `json:"dossier_uuid" gorm:"type:varchar(255);not null;index"`
+	AuteurUUID     string `json:"auteur_uuid"`
+	Contenu        string `json:"contenu" gorm:"type:text;not null" validate:"required"`
+	Confidentielle bool   `json:"confidentielle" gorm:"default:false"`
+}
+
+func (n *DossierNote) TableName() string {
+	return "dossier_notes"
+}
+
+// DossierEtape trace chaque changement d'étape d'un dossier
+type DossierEtape struct {
+	UUID      string    `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time `json:"created_at"`
+
+	DossierUUID     string `json:"dossier_uuid" gorm:"type:varchar(255);not null;index"`
+	EtapePrecedente string `json:"etape_precedente"`
+	Etape           string `json:"etape"`
+	EffectuePar     string `json:"effectue_par"`
+	Commentaire     string `json:"commentaire" gorm:"type:text"`
+}
+
+func (e *DossierEtape) TableName() string {
+	return "dossier_etapes"
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DoublonCandidat représente une paire d'identités susceptibles de désigner la même personne
+type DoublonCandidat struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	IdentiteAUUID string   `json:"identite_a_uuid" gorm:"type:varchar(255);not null;index"`
+	IdentiteA     Identite `json:"identite_a" gorm:"foreignKey:IdentiteAUUID"`
+	IdentiteBUUID string   `json:"identite_b_uuid" gorm:"type:varchar(255);not null;index"`
+	IdentiteB     Identite `json:"identite_b" gorm:"foreignKey:IdentiteBUUID"`
+
+	// Score global (0-1) et détail par critère
+	Score              float64 `json:"score"`
+	ScoreNom           float64 `json:"score_nom"`
+	ScoreDateNaissance float64 `json:"score_date_naissance"`
+	ScoreLieuNaissance float64 `json:"score_lieu_naissance"`
+	ScoreNationalite   float64 `json:"score_nationalite"`
+	BiometriesCommunes int     `json:"biometries_communes"`
+
+	// Revue par un agent
+	Statut      string     `json:"statut" gorm:"default:en_attente" validate:"oneof=en_attente rejete fusionne"`
+	RevisePar   string     `json:"revise_par"`
+	DateRevue   *time.Time `json:"date_revue"`
+	Commentaire string     `json:"commentaire" gorm:"type:text"`
+}
+
+func (d *DoublonCandidat) TableName() string {
+	return "doublon_candidats"
+}
+
+// FusionIdentite conserve la trace d'une fusion pour pouvoir l'annuler
+type FusionIdentite struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	DoublonUUID    string `json:"doublon_uuid" gorm:"type:varchar(255);index"`
+	SurvivanteUUID string `json:"survivante_uuid" gorm:"type:varchar(255);not null;index"`
+	AbsorbeeUUID   string `json:"absorbee_uuid" gorm:"type:varchar(255);not null;index"`
+
+	// Enregistrements déplacés vers l'identité survivante (listes JSON d'UUID)
+	MigrantsDeplaces          string `json:"migrants_deplaces" gorm:"type:text"`
+	GeolocalisationsDeplacees string `json:"geolocalisations_deplacees" gorm:"type:text"`
+	HitsDeplaces              string `json:"hits_deplaces" gorm:"type:text"`
+
+	// Copie de l'identité absorbée au moment de la fusion (JSON)
+	InstantaneAbsorbee string `json:"instantane_absorbee" gorm:"type:text"`
+
+	Statut         string     `json:"statut" gorm:"default:active" validate:"oneof=active annulee"`
+	EffectuePar    string     `json:"effectue_par"`
+	DateAnnulation *time.Time `json:"date_annulation"`
+	AnnulePar      string     `json:"annule_par"`
+}
+
+func (f *FusionIdentite) TableName() string {
+	return "fusions_identites"
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PosteFrontiere est un point de passage officiel de la frontière
+type PosteFrontiere struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	Code           string  `json:"code" gorm:"unique;not null" validate:"required"`
+	Nom            string  `json:"nom" gorm:"not null" validate:"required"`
+	TypePoste      string  `json:"type_poste" gorm:"not null" validate:"required,oneof=terrestre fluvial lacustre aeroport ferroviaire"`
+	Province       string  `json:"province" gorm:"not null;index" validate:"required"`
+	Ville          string  `json:"ville"`
+	PaysLimitrophe string  `json:"pays_limitrophe"`
+	Latitude       float64 `json:"latitude" validate:"latitude"`
+	Longitude      float64 `json:"longitude" validate:"longitude"`
+	Actif          bool    `json:"actif" gorm:"default:true"`
+
+	Passages []Passage `json:"passages,omitempty" gorm:"foreignKey:PosteUUID"`
+}
+
+func (p *PosteFrontiere) TableName() string {
+	return "border_posts"
+}
+
+// Passage enregistre une entrée ou une sortie d'un migrant par un poste frontière
+type Passage struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	MigrantUUID string  `json:"migrant_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Migrant     Migrant `json:"migrant" gorm:"foreignKey:MigrantUUID;constraint:OnDelete:CASCADE" validate:"-"`
+
+	PosteUUID string         `json:"poste_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Poste     PosteFrontiere `json:"poste" gorm:"foreignKey:PosteUUID" validate:"-"`
+
+	Sens        string    `json:"sens" gorm:"not null;index" validate:"required,oneof=entree sortie"`
+	DatePassage time.Time `json:"date_passage" gorm:"not null;index" validate:"required"`
+
+	// Document présenté
+	TypeDocument   string `json:"type_document" validate:"omitempty,oneof=passeport laissez_passer carte_identite titre_sejour carte_refugie aucun autre"`
+	NumeroDocument string `json:"numero_document"`
+
+	ModeTransport   string `json:"mode_transport" validate:"omitempty,oneof=pied velo moto voiture bus camion bateau pirogue avion train autre"`
+	PaysProvenance  string `json:"pays_provenance"`
+	PaysDestination string `json:"pays_destination"`
+
+	AgentUUID    string `json:"agent_uuid"`
+	Observations string `json:"observations" gorm:"type:text"`
+}
+
+func (p *Passage) TableName() string {
+	return "crossings"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Statuts d'une requête idempotente
+const (
+	IdempotenceEnCours = "en_cours"
+	IdempotenceTermine = "termine"
+)
+
+// RequeteIdempotente conserve la réponse d'une requête envoyée avec un en-tête
+// Idempotency-Key, rejouée si le client renvoie la même requête
+type RequeteIdempotente struct {
+	UUID      string    `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Clé du client, préfixée par l'utilisateur et la route
+	Cle string `json:"cle" gorm:"type:varchar(700);uniqueIndex;not null"`
+	// SHA-256 de la méthode, de la route et du corps de la requête
+	Empreinte string `json:"empreinte" gorm:"type:varchar(64);not null"`
+	Statut    string `json:"statut" gorm:"not null;default:en_cours"`
+
+	// Réponse enregistrée
+	CodeHTTP    int    `json:"code_http"`
+	TypeContenu string `json:"type_contenu"`
+	Reponse     []byte `json:"-" gorm:"type:bytea"`
+
+	ExpireLe time.Time `json:"expire_le" gorm:"index;not null"`
+	// Début de la réservation en cours : passé le bail, la clé peut être reprise
+	ReserveLe time.Time `json:"reserve_le" gorm:"not null;default:now()"`
+}
+
+func (r *RequeteIdempotente) TableName() string {
+	return "idempotency_keys"
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/kgermando/sysmobembo-api/utils"
 	"gorm.io/gorm"
 )
 
@@ -32,6 +33,20 @@ type Identite struct {
 
 	NumeroPasseport string `json:"numero_passeport" gorm:"unique;not null;default:''" validate:"required"`
 
+	// Unité organisationnelle propriétaire de l'enregistrement
+	UniteUUID string `json:"unite_uuid" gorm:"type:varchar(255);index;default:''"`
+
+	// Version incrémentée à chaque mise à jour (ETag / If-Match)
+	Version int64 `json:"version" gorm:"not null;default:1"`
+
+	// Validité du document calculée à la lecture (voir RefreshValidity)
+	StatutValidite       string `json:"statut_validite" gorm:"-"`
+	JoursAvantExpiration int    `json:"jours_avant_expiration" gorm:"-"`
+
+	// Clés de recherche calculées à partir du nom complet (voir RefreshSearchKeys)
+	CleNom        string `json:"-" gorm:"index;default:''"`
+	ClePhonetique string `json:"-" gorm:"index;default:''"`
+
 	// Relations
 	Migrants         []Migrant         `json:"migrants" gorm:"foreignKey:IdentiteUUID;constraint:OnDelete:CASCADE"`
 	Geolocalisations []Geolocalisation `json:"geolocalisations" gorm:"foreignKey:IdentiteUUID;constraint:OnDelete:CASCADE"`
@@ -40,3 +55,99 @@ type Identite struct {
 func (i *Identite) TableName() string {
 	return "identites"
 }
+
+func (i *Identite) BeforeUpdate(tx *gorm.DB) error {
+	protegerVersion(tx)
+	return nil
+}
+
+func (i *Identite) AfterUpdate(tx *gorm.DB) error {
+	return incrementerVersion(tx, "identites", i.UUID, &i.Version)
+}
+
+// Délai (en jours) en deçà duquel un document est signalé comme expirant bientôt
+const DelaiExpirationProche = 90
+
+// Statuts de validité du document de voyage
+const (
+	ValiditeValide           = "valide"
+	ValiditeExpirationProche = "expiration_proche"
+	ValiditeExpire           = "expire"
+)
+
+// ValiditeAu retourne le statut de validité du document et le nombre de jours
+// avant son expiration (négatif s'il est déjà expiré) à la date de référence
+func (i *Identite) ValiditeAu(ref time.Time) (string, int) {
+	if i.DateExpiration.IsZero() {
+		return "", 0
+	}
+
+	jour := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	jours := int(jour(i.DateExpiration).Sub(jour(ref)).Hours() / 24)
+
+	switch {
+	case jours < 0:
+		return ValiditeExpire, jours
+	case jours <= DelaiExpirationProche:
+		return ValiditeExpirationProche, jours
+	default:
+		return ValiditeValide, jours
+	}
+}
+
+// RefreshValidity recalcule le statut de validité du document à la date du jour
+func (i *Identite) RefreshValidity() {
+	i.StatutValidite, i.JoursAvantExpiration = i.ValiditeAu(time.Now())
+}
+
+func (i *Identite) AfterFind(tx *gorm.DB) error {
+	i.RefreshValidity()
+	return nil
+}
+
+func (i *Identite) AfterSave(tx *gorm.DB) error {
+	i.RefreshValidity()
+	return nil
+}
+
+// BeforeSave recalcule les clés de recherche du nom écrit. À la création et à la
+// sauvegarde, le modèle porte les valeurs enregistrées ; lors d'un Updates avec une
+// autre identité, les noms modifiés sont pris dans ces valeurs et les clés ajoutées
+// aux colonnes écrites.
+func (i *Identite) BeforeSave(tx *gorm.DB) error {
+	valeurs, ok := tx.Statement.Dest.(*Identite)
+	if !ok || valeurs == i {
+		i.RefreshSearchKeys()
+		return nil
+	}
+	if !tx.Statement.Changed("Nom", "Postnom", "Prenom") {
+		return nil
+	}
+
+	noms := *i
+	if tx.Statement.Changed("Nom") {
+		noms.Nom = valeurs.Nom
+	}
+	if tx.Statement.Changed("Postnom") {
+		noms.Postnom = valeurs.Postnom
+	}
+	if tx.Statement.Changed("Prenom") {
+		noms.Prenom = valeurs.Prenom
+	}
+	noms.RefreshSearchKeys()
+
+	tx.Statement.SetColumn("CleNom", noms.CleNom)
+	tx.Statement.SetColumn("ClePhonetique", noms.ClePhonetique)
+	// Mise à jour limitée par Select : les clés doivent être écrites avec le nom
+	if len(tx.Statement.Selects) > 0 {
+		tx.Statement.Selects = append(tx.Statement.Selects, "CleNom", "ClePhonetique")
+	}
+	return nil
+}
+
+// RefreshSearchKeys recalcule les clés normalisée et phonétique du nom complet
+func (i *Identite) RefreshSearchKeys() {
+	i.CleNom, i.ClePhonetique = utils.NameSearchKeys(i.Nom, i.Postnom, i.Prenom)
+}
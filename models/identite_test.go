@@ -0,0 +1,56 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+func TestIdentiteBeforeSaveRefreshesSearchKeys(t *testing.T) {
+	db := basetest.Migree(t)
+
+	identite := models.Identite{UUID: "identite", Nom: "TSHIBANGU", Postnom: "MUKENDI", Prenom: "Léon", NumeroPasseport: "OB1234567"}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	cles := func(nom, postnom, prenom string) {
+		t.Helper()
+		var enregistree models.Identite
+		if err := db.First(&enregistree, "uuid = ?", identite.UUID).Error; err != nil {
+			t.Fatal(err)
+		}
+		cleNom, clePhonetique := utils.NameSearchKeys(nom, postnom, prenom)
+		if enregistree.CleNom != cleNom || enregistree.ClePhonetique != clePhonetique {
+			t.Errorf("clés %q/%q, attendues %q/%q", enregistree.CleNom, enregistree.ClePhonetique, cleNom, clePhonetique)
+		}
+		identite = enregistree
+	}
+	cles("TSHIBANGU", "MUKENDI", "Léon")
+
+	// Updates : les champs vides des valeurs ne sont pas écrits, les clés tiennent compte des autres noms
+	if err := db.Model(&identite).Updates(&models.Identite{Nom: "CHIBANGU"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cles("CHIBANGU", "MUKENDI", "Léon")
+
+	// Updates limité par Select (PATCH) : le postnom effacé est écrit, les clés avec lui
+	if err := db.Model(&identite).Select("Postnom").Updates(&models.Identite{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cles("CHIBANGU", "", "Léon")
+
+	// Modification sans changement de nom : clés inchangées
+	if err := db.Model(&identite).Updates(&models.Identite{Profession: "Commerçant"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	cles("CHIBANGU", "", "Léon")
+
+	// Sauvegarde complète
+	identite.Prenom = "Jean"
+	if err := db.Save(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	cles("CHIBANGU", "", "Jean")
+}
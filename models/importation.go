@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Importation suit un import en masse d'identités ou de migrants depuis un fichier Excel ou CSV
+type Importation struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	TypeImport string `json:"type_import" gorm:"not null;index" validate:"required,oneof=identites migrants"`
+	NomFichier string `json:"nom_fichier"`
+	Format     string `json:"format" validate:"oneof=xlsx csv"`
+
+	// Un aperçu (dry run) valide les lignes sans rien enregistrer
+	Apercu bool `json:"apercu"`
+	// Sans cette option, une seule ligne invalide annule tout l'import
+	IgnorerErreurs bool `json:"ignorer_erreurs"`
+
+	// en_attente, validation, enregistrement, termine, echoue
+	Statut  string `json:"statut" gorm:"not null;default:en_attente;index"`
+	Message string `json:"message" gorm:"type:text"`
+
+	// Progression
+	TotalLignes     int `json:"total_lignes"`
+	LignesTraitees  int `json:"lignes_traitees"`
+	LignesValides   int `json:"lignes_valides"`
+	LignesRejetees  int `json:"lignes_rejetees"`
+	LignesImportees int `json:"lignes_importees"`
+	Correspondances int `json:"correspondances_surveillance"`
+
+	DateDebut *time.Time `json:"date_debut"`
+	DateFin   *time.Time `json:"date_fin"`
+	LancePar  string     `json:"lance_par"`
+	// Unité de l'agent qui a lancé l'import, propriétaire des lignes importées
+	UniteUUID string `json:"unite_uuid" gorm:"type:varchar(255);index;default:''"`
+
+	Erreurs []ErreurImport `json:"erreurs,omitempty" gorm:"foreignKey:ImportationUUID;constraint:OnDelete:CASCADE"`
+}
+
+func (i *Importation) TableName() string {
+	return "imports"
+}
+
+// Progression retourne l'avancement de l'étape en cours en pourcentage
+func (i *Importation) Progression() int {
+	if i.TotalLignes == 0 {
+		if i.Statut == "termine" {
+			return 100
+		}
+		return 0
+	}
+	return i.LignesTraitees * 100 / i.TotalLignes
+}
+
+// ErreurImport est une erreur de validation rattachée à une ligne du fichier importé
+type ErreurImport struct {
+	UUID      string    `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ImportationUUID string `json:"importation_uuid" gorm:"type:varchar(255);not null;index"`
+	Ligne           int    `json:"ligne"`
+	Colonne         string `json:"colonne"`
+	Message         string `json:"message" gorm:"type:text"`
+}
+
+func (e *ErreurImport) TableName() string {
+	return "import_errors"
+}
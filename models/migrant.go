@@ -19,6 +19,13 @@ type Migrant struct {
 
 	// Informations d'identification du migrant
 	NumeroIdentifiant string `json:"numero_identifiant" gorm:"unique;not null" validate:"required"`
+	// Bureau d'enregistrement, repris dans le numéro selon NUMERO_MIGRANT_FORMAT
+	BureauEnregistrement string `json:"bureau_enregistrement" gorm:"index;default:''"`
+	// Unité organisationnelle propriétaire de l'enregistrement
+	UniteUUID string `json:"unite_uuid" gorm:"type:varchar(255);index;default:''"`
+
+	// Version incrémentée à chaque mise à jour (ETag / If-Match)
+	Version int64 `json:"version" gorm:"not null;default:1"`
 
 	// Informations de contact
 	Telephone string `json:"telephone"`
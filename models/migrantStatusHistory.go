@@ -0,0 +1,188 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// HistoriqueStatut est une version datée du statut migratoire d'un migrant.
+// La version courante est celle dont DateFin est nulle.
+type HistoriqueStatut struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	MigrantUUID string `json:"migrant_uuid" gorm:"type:varchar(255);not null;index"`
+
+	StatutPrecedent  string `json:"statut_precedent"`
+	StatutMigratoire string `json:"statut_migratoire" gorm:"index"`
+	PointEntree      string `json:"point_entree"`
+	PaysActuel       string `json:"pays_actuel"`
+
+	DateEffet time.Time  `json:"date_effet" gorm:"index"`
+	DateFin   *time.Time `json:"date_fin"`
+
+	Motif             string `json:"motif" gorm:"type:text"`
+	ReferenceDecision string `json:"reference_decision"`
+	EffectuePar       string `json:"effectue_par"`
+}
+
+func (h *HistoriqueStatut) TableName() string {
+	return "migrant_status_history"
+}
+
+// ContexteStatut accompagne un changement de statut (motif, décision, agent, date d'effet)
+type ContexteStatut struct {
+	Motif             string
+	ReferenceDecision string
+	EffectuePar       string
+	DateEffet         *time.Time
+}
+
+const (
+	cleContexteStatut = "historique_statut:contexte"
+	cleAvantStatut    = "historique_statut:avant:"
+)
+
+// AvecContexteStatut attache le contexte du changement aux mises à jour de migrants faites avec db
+func AvecContexteStatut(db *gorm.DB, ctx ContexteStatut) *gorm.DB {
+	return db.Set(cleContexteStatut, ctx)
+}
+
+// versionStatut regroupe les champs suivis par l'historique
+type versionStatut struct {
+	StatutMigratoire string
+	PointEntree      string
+	PaysActuel       string
+}
+
+func contexteStatut(tx *gorm.DB) ContexteStatut {
+	if v, ok := tx.Get(cleContexteStatut); ok {
+		if ctx, ok := v.(ContexteStatut); ok {
+			return ctx
+		}
+	}
+	return ContexteStatut{}
+}
+
+// AfterCreate ouvre la première version de l'historique
+func (m *Migrant) AfterCreate(tx *gorm.DB) error {
+	ctx := contexteStatut(tx)
+
+	dateEffet := m.CreatedAt
+	if m.DateEntree != nil {
+		dateEffet = *m.DateEntree
+	}
+	if ctx.DateEffet != nil {
+		dateEffet = *ctx.DateEffet
+	}
+	if ctx.Motif == "" {
+		ctx.Motif = "Enregistrement initial"
+	}
+
+	return tx.Session(&gorm.Session{NewDB: true}).Create(&HistoriqueStatut{
+		UUID:              utils.GenerateUUID(),
+		MigrantUUID:       m.UUID,
+		StatutMigratoire:  m.StatutMigratoire,
+		PointEntree:       m.PointEntree,
+		PaysActuel:        m.PaysActuel,
+		DateEffet:         dateEffet,
+		Motif:             ctx.Motif,
+		ReferenceDecision: ctx.ReferenceDecision,
+		EffectuePar:       ctx.EffectuePar,
+	}).Error
+}
+
+// BeforeUpdate mémorise les valeurs suivies avant modification
+func (m *Migrant) BeforeUpdate(tx *gorm.DB) error {
+	protegerVersion(tx)
+	if m.UUID == "" || !tx.Statement.Changed("StatutMigratoire", "PointEntree", "PaysActuel") {
+		return nil
+	}
+
+	var avant versionStatut
+	err := tx.Session(&gorm.Session{NewDB: true}).
+		Table("migrants").
+		Select("statut_migratoire, point_entree, pays_actuel").
+		Where("uuid = ?", m.UUID).
+		Scan(&avant).Error
+	if err != nil {
+		return err
+	}
+	tx.Statement.Settings.Store(cleAvantStatut+m.UUID, avant)
+	return nil
+}
+
+// AfterUpdate incrémente la version du migrant, puis clôt la version courante de l'historique
+// et en ouvre une nouvelle si une valeur suivie a changé
+func (m *Migrant) AfterUpdate(tx *gorm.DB) error {
+	if m.UUID == "" {
+		return nil
+	}
+	if err := incrementerVersion(tx, "migrants", m.UUID, &m.Version); err != nil {
+		return err
+	}
+	v, ok := tx.Statement.Settings.LoadAndDelete(cleAvantStatut + m.UUID)
+	if !ok {
+		return nil
+	}
+	avant := v.(versionStatut)
+
+	db := tx.Session(&gorm.Session{NewDB: true})
+
+	var apres versionStatut
+	err := db.Table("migrants").
+		Select("statut_migratoire, point_entree, pays_actuel").
+		Where("uuid = ?", m.UUID).
+		Scan(&apres).Error
+	if err != nil || apres == avant {
+		return err
+	}
+
+	ctx := contexteStatut(tx)
+	dateEffet := time.Now()
+	if ctx.DateEffet != nil {
+		dateEffet = *ctx.DateEffet
+	}
+
+	result := db.Model(&HistoriqueStatut{}).
+		Where("migrant_uuid = ? AND date_fin IS NULL", m.UUID).
+		Update("date_fin", dateEffet)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Migrant antérieur à l'historique : reconstituer la version précédente
+	if result.RowsAffected == 0 {
+		var createdAt time.Time
+		db.Table("migrants").Select("created_at").Where("uuid = ?", m.UUID).Scan(&createdAt)
+		if err := db.Create(&HistoriqueStatut{
+			UUID:             utils.GenerateUUID(),
+			MigrantUUID:      m.UUID,
+			StatutMigratoire: avant.StatutMigratoire,
+			PointEntree:      avant.PointEntree,
+			PaysActuel:       avant.PaysActuel,
+			DateEffet:        createdAt,
+			DateFin:          &dateEffet,
+			Motif:            "Enregistrement initial",
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return db.Create(&HistoriqueStatut{
+		UUID:              utils.GenerateUUID(),
+		MigrantUUID:       m.UUID,
+		StatutPrecedent:   avant.StatutMigratoire,
+		StatutMigratoire:  apres.StatutMigratoire,
+		PointEntree:       apres.PointEntree,
+		PaysActuel:        apres.PaysActuel,
+		DateEffet:         dateEffet,
+		Motif:             ctx.Motif,
+		ReferenceDecision: ctx.ReferenceDecision,
+		EffectuePar:       ctx.EffectuePar,
+	}).Error
+}
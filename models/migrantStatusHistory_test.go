@@ -0,0 +1,92 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/database/basetest"
+	"github.com/kgermando/sysmobembo-api/models"
+	"gorm.io/gorm"
+)
+
+func historique(t *testing.T, db *gorm.DB, migrantUUID string) []models.HistoriqueStatut {
+	t.Helper()
+	var versions []models.HistoriqueStatut
+	if err := db.Where("migrant_uuid = ?", migrantUUID).Order("date_effet ASC, created_at ASC").Find(&versions).Error; err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func TestMigrantStatusHistory(t *testing.T) {
+	db := basetest.Migree(t)
+
+	identite := models.Identite{UUID: "identite", Nom: "MBALA", NumeroPasseport: "OP0000001"}
+	if err := db.Create(&identite).Error; err != nil {
+		t.Fatal(err)
+	}
+	entree := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	migrant := models.Migrant{
+		UUID: "migrant", IdentiteUUID: identite.UUID, NumeroIdentifiant: "MIG-2025-000001",
+		StatutMigratoire: "demandeur_asile", PointEntree: "Kasumbalesa", PaysActuel: "RDC", DateEntree: &entree,
+	}
+	if err := db.Omit("Identite").Create(&migrant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Création : version initiale à la date d'entrée
+	versions := historique(t, db, migrant.UUID)
+	if len(versions) != 1 || versions[0].StatutMigratoire != "demandeur_asile" || !versions[0].DateEffet.Equal(entree) ||
+		versions[0].DateFin != nil || versions[0].Motif != "Enregistrement initial" {
+		t.Fatalf("historique après création : %+v", versions)
+	}
+
+	// Champ non suivi : aucune nouvelle version
+	if err := db.Model(&migrant).Update("ville_actuelle", "Lubumbashi").Error; err != nil {
+		t.Fatal(err)
+	}
+	if versions := historique(t, db, migrant.UUID); len(versions) != 1 {
+		t.Fatalf("%d versions après un changement de ville, attendu 1", len(versions))
+	}
+
+	// Changement de statut avec son contexte : version courante close, nouvelle ouverte
+	effet := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ctx := models.ContexteStatut{Motif: "Décision accordee", ReferenceDecision: "DOS-2025-000001", EffectuePar: "agent", DateEffet: &effet}
+	if err := models.AvecContexteStatut(db, ctx).Model(&migrant).Update("statut_migratoire", "refugie").Error; err != nil {
+		t.Fatal(err)
+	}
+	versions = historique(t, db, migrant.UUID)
+	if len(versions) != 2 {
+		t.Fatalf("%d versions après le changement de statut, attendu 2", len(versions))
+	}
+	if versions[0].DateFin == nil || !versions[0].DateFin.Equal(effet) {
+		t.Errorf("version initiale close le %v, attendu %v", versions[0].DateFin, effet)
+	}
+	courante := versions[1]
+	if courante.StatutPrecedent != "demandeur_asile" || courante.StatutMigratoire != "refugie" || courante.DateFin != nil ||
+		!courante.DateEffet.Equal(effet) || courante.ReferenceDecision != "DOS-2025-000001" || courante.EffectuePar != "agent" ||
+		courante.PointEntree != "Kasumbalesa" {
+		t.Errorf("version courante %+v", courante)
+	}
+	if migrant.Version != 3 {
+		t.Errorf("version du migrant %d, attendu 3 après deux mises à jour", migrant.Version)
+	}
+
+	// Migrant antérieur à l'historique : la version précédente est reconstituée
+	if err := db.Unscoped().Where("migrant_uuid = ?", migrant.UUID).Delete(&models.HistoriqueStatut{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&migrant).Update("pays_actuel", "Zambie").Error; err != nil {
+		t.Fatal(err)
+	}
+	versions = historique(t, db, migrant.UUID)
+	if len(versions) != 2 {
+		t.Fatalf("%d versions après reconstitution, attendu 2", len(versions))
+	}
+	if versions[0].PaysActuel != "RDC" || versions[0].DateFin == nil || versions[0].Motif != "Enregistrement initial" {
+		t.Errorf("version reconstituée %+v", versions[0])
+	}
+	if versions[1].PaysActuel != "Zambie" || versions[1].StatutPrecedent != "refugie" || versions[1].DateFin != nil {
+		t.Errorf("version courante %+v", versions[1])
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RelationFamiliale relie deux migrants : "Apparente est le <TypeRelation> de Migrant".
+// Les relations enfant sont enregistrées sous leur forme parent (sens inversé).
+type RelationFamiliale struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	MigrantUUID string  `json:"migrant_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Migrant     Migrant `json:"migrant" gorm:"foreignKey:MigrantUUID;constraint:OnDelete:CASCADE" validate:"-"`
+
+	ApparenteUUID string  `json:"apparente_uuid" gorm:"type:varchar(255);not null;index" validate:"required"`
+	Apparente     Migrant `json:"apparente" gorm:"foreignKey:ApparenteUUID;constraint:OnDelete:CASCADE" validate:"-"`
+
+	TypeRelation  string `json:"type_relation" gorm:"not null" validate:"required,oneof=conjoint enfant parent frere_soeur tuteur chef_menage"`
+	Commentaire   string `json:"commentaire" gorm:"type:text"`
+	EnregistrePar string `json:"enregistre_par"`
+}
+
+func (r *RelationFamiliale) TableName() string {
+	return "relations_familiales"
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Types de conflits de synchronisation
+const (
+	// Création ou modification avec un numéro de passeport déjà enregistré par un autre poste
+	ConflitPasseportDuplique = "passeport_duplique"
+	// Enregistrement modifié sur le serveur depuis la dernière synchronisation de la tablette
+	ConflitModificationConcurrente = "modification_concurrente"
+	// Enregistrement dépendant d'un autre enregistrement lui-même en conflit
+	ConflitDependance = "dependance"
+)
+
+// ConflitSynchro est une opération poussée par une tablette hors ligne qui n'a pas pu être
+// appliquée et attend la décision d'un agent
+type ConflitSynchro struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	TypeConflit string `json:"type_conflit" gorm:"not null;index"`
+	// Type (identite, migrant, biometrie) et UUID généré par la tablette
+	TypeEntite string `json:"type_entite" gorm:"not null"`
+	EntiteUUID string `json:"entite_uuid" gorm:"type:varchar(255);not null;index"`
+	// Enregistrement serveur en concurrence (identité portant le même passeport, version modifiée)
+	ExistantUUID string `json:"existant_uuid" gorm:"type:varchar(255);default:''"`
+	// Conflit dont dépend celui-ci (type dependance)
+	ParentUUID string `json:"parent_uuid" gorm:"type:varchar(255);index;default:''"`
+	Message    string `json:"message"`
+
+	// Opération d'origine (JSON) rejouée à la résolution
+	Operation  string `json:"operation" gorm:"type:text"`
+	AppareilID string `json:"appareil_id" gorm:"index"`
+	UserUUID   string `json:"user_uuid" gorm:"type:varchar(255)"`
+	UniteUUID  string `json:"unite_uuid" gorm:"type:varchar(255);index;default:''"`
+
+	// en_attente, resolu ou rejete ; la résolution précise la décision (rattacher, appliquer, rejeter)
+	Statut      string     `json:"statut" gorm:"default:en_attente;index" validate:"oneof=en_attente resolu rejete"`
+	Resolution  string     `json:"resolution" gorm:"default:''"`
+	ResoluPar   string     `json:"resolu_par"`
+	DateRevue   *time.Time `json:"date_revue"`
+	Commentaire string     `json:"commentaire" gorm:"type:text"`
+}
+
+func (c *ConflitSynchro) TableName() string {
+	return "sync_conflicts"
+}
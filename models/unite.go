@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UniteOrganisationnelle est une unité de l'administration (direction générale, direction
+// provinciale, poste frontière) propriétaire des enregistrements créés par ses agents
+type UniteOrganisationnelle struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	Code      string `json:"code" gorm:"unique;not null" validate:"required"`
+	Nom       string `json:"nom" gorm:"not null" validate:"required"`
+	TypeUnite string `json:"type_unite" gorm:"not null;index" validate:"required,oneof=national direction_provinciale poste_frontiere"`
+	Province  string `json:"province"`
+	Actif     bool   `json:"actif" gorm:"default:true"`
+
+	// Unité parente, vide pour la direction générale
+	ParentUUID *string `json:"parent_uuid" gorm:"type:varchar(255);index"`
+	// Chemin des UUID depuis la racine ("/racine/province/poste/") : les sous-unités
+	// d'une unité sont celles dont le chemin commence par le sien
+	Chemin string `json:"chemin" gorm:"type:text;index"`
+
+	// Poste frontière correspondant pour les unités de type poste_frontiere
+	PosteUUID *string `json:"poste_uuid" gorm:"type:varchar(255)"`
+}
+
+func (u *UniteOrganisationnelle) TableName() string {
+	return "org_units"
+}
+
+// AccesHorsPerimetre journalise les accès d'un agent à un enregistrement d'une autre unité
+type AccesHorsPerimetre struct {
+	UUID      string    `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserUUID       string `json:"user_uuid" gorm:"type:varchar(255);index"`
+	UniteUser      string `json:"unite_user" gorm:"type:varchar(255)"`
+	UniteRessource string `json:"unite_ressource" gorm:"type:varchar(255);index"`
+	TypeRessource  string `json:"type_ressource" gorm:"index"`
+	RessourceUUID  string `json:"ressource_uuid" gorm:"type:varchar(255)"`
+	Methode        string `json:"methode"`
+	Route          string `json:"route"`
+	// autorise (rôle national) ou refuse
+	Resultat string `json:"resultat" gorm:"index"`
+}
+
+func (a *AccesHorsPerimetre) TableName() string {
+	return "cross_unit_access_logs"
+}
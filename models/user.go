@@ -51,6 +51,7 @@ type User struct {
 	Fonction         string    `gorm:"not null" json:"fonction"`
 	Service          string    `gorm:"not null" json:"service"`
 	Direction        string    `gorm:"not null" json:"direction"`
+	UniteUUID        string    `gorm:"type:varchar(255);index;default:''" json:"unite_uuid"` // Unité organisationnelle de rattachement
 	Ministere        string    `gorm:"not null" json:"ministere"`
 	DateRecrutement  time.Time `gorm:"not null" json:"date_recrutement"`
 	DatePriseService time.Time `gorm:"not null" json:"date_prise_service"`
@@ -135,6 +136,7 @@ type UserResponse struct {
 	Fonction         string    `json:"fonction"`
 	Service          string    `json:"service"`
 	Direction        string    `json:"direction"`
+	UniteUUID        string    `json:"unite_uuid"`
 	Ministere        string    `json:"ministere"`
 	DateRecrutement  time.Time `json:"date_recrutement"`
 	DatePriseService time.Time `json:"date_prise_service"`
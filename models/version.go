@@ -0,0 +1,27 @@
+package models
+
+import "gorm.io/gorm"
+
+// Contrôle de concurrence optimiste : les enregistrements modifiés par plusieurs agents
+// (identités, migrants, alertes, dossiers) portent une version incrémentée à chaque
+// mise à jour. Le client la reçoit dans l'en-tête ETag et la renvoie dans If-Match.
+
+// protegerVersion empêche une mise à jour d'écrire la version reçue dans le corps de la requête
+func protegerVersion(tx *gorm.DB) {
+	tx.Statement.Omits = append(tx.Statement.Omits, "version")
+}
+
+// incrementerVersion augmente la version de l'enregistrement mis à jour et la recharge
+// dans le modèle. Les mises à jour en masse (modèle sans UUID) ne sont pas versionnées.
+func incrementerVersion(tx *gorm.DB, table, uuid string, version *int64) error {
+	if uuid == "" || tx.RowsAffected == 0 {
+		return nil
+	}
+
+	db := tx.Session(&gorm.Session{NewDB: true})
+	err := db.Table(table).Where("uuid = ?", uuid).UpdateColumn("version", gorm.Expr("version + 1")).Error
+	if err != nil {
+		return err
+	}
+	return db.Table(table).Select("version").Where("uuid = ?", uuid).Scan(version).Error
+}
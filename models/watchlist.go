@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// ListeSurveillance est une liste importée (personnes recherchées, passeports perdus ou volés, documents révoqués)
+type ListeSurveillance struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	Nom         string `json:"nom" gorm:"not null" validate:"required"`
+	TypeListe   string `json:"type_liste" gorm:"not null;index" validate:"required,oneof=personnes_recherchees documents_perdus_voles documents_revoques"`
+	Source      string `json:"source"`
+	Description string `json:"description" gorm:"type:text"`
+	Actif       bool   `json:"actif" gorm:"default:true"`
+
+	NombreEntrees int        `json:"nombre_entrees"`
+	DateImport    *time.Time `json:"date_import"`
+	ImportePar    string     `json:"importe_par"`
+}
+
+func (l *ListeSurveillance) TableName() string {
+	return "watchlists"
+}
+
+// EntreeSurveillance est une personne ou un document inscrit sur une liste de surveillance
+type EntreeSurveillance struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	ListeUUID string `json:"liste_uuid" gorm:"type:varchar(255);not null;index"`
+
+	NomComplet     string     `json:"nom_complet"`
+	DateNaissance  *time.Time `json:"date_naissance" gorm:"index"`
+	Nationalite    string     `json:"nationalite"`
+	NumeroDocument string     `json:"numero_document"`
+	Motif          string     `json:"motif" gorm:"type:text"`
+	Reference      string     `json:"reference"`
+
+	// Clés de comparaison calculées à l'import (voir RefreshMatchKeys)
+	DocumentNormalise string `json:"-" gorm:"index;default:''"`
+	CleNom            string `json:"-" gorm:"default:''"`
+	ClePhonetique     string `json:"-" gorm:"default:''"`
+}
+
+func (e *EntreeSurveillance) TableName() string {
+	return "watchlist_entries"
+}
+
+// RefreshMatchKeys recalcule les clés utilisées par le criblage
+func (e *EntreeSurveillance) RefreshMatchKeys() {
+	e.DocumentNormalise = utils.NormalizeDocumentNumber(e.NumeroDocument)
+	e.CleNom, e.ClePhonetique = utils.NameSearchKeys(e.NomComplet)
+}
+
+// CorrespondanceSurveillance est un résultat de criblage soumis à la revue d'un agent
+type CorrespondanceSurveillance struct {
+	UUID      string         `gorm:"type:varchar(255);primary_key" json:"uuid"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	IdentiteUUID string             `json:"identite_uuid" gorm:"type:varchar(255);not null;uniqueIndex:idx_watchlist_hit"`
+	Identite     Identite           `json:"identite" gorm:"foreignKey:IdentiteUUID" validate:"-"`
+	EntreeUUID   string             `json:"entree_uuid" gorm:"type:varchar(255);not null;uniqueIndex:idx_watchlist_hit"`
+	Entree       EntreeSurveillance `json:"entree" gorm:"foreignKey:EntreeUUID" validate:"-"`
+	ListeUUID    string             `json:"liste_uuid" gorm:"type:varchar(255);not null;index"`
+	Liste        ListeSurveillance  `json:"liste" gorm:"foreignKey:ListeUUID" validate:"-"`
+
+	TypeCorrespondance string  `json:"type_correspondance" validate:"oneof=document_exact nom_date_naissance"`
+	Score              float64 `json:"score"`
+
+	// Alerte de sécurité ouverte sur le migrant lié à l'identité
+	AlerteUUID string `json:"alerte_uuid"`
+
+	// Revue par un agent
+	Statut      string     `json:"statut" gorm:"default:en_attente;index" validate:"oneof=en_attente confirmee ecartee"`
+	RevisePar   string     `json:"revise_par"`
+	DateRevue   *time.Time `json:"date_revue"`
+	Commentaire string     `json:"commentaire" gorm:"type:text"`
+}
+
+func (c *CorrespondanceSurveillance) TableName() string {
+	return "watchlist_hits"
+}
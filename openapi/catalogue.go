@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"time"
+
+	"github.com/kgermando/sysmobembo-api/controllers/dashboard"
+	"github.com/kgermando/sysmobembo-api/controllers/synchronisation"
+	"github.com/kgermando/sysmobembo-api/models"
+)
+
+const (
+	mimeExcel = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimePDF   = "application/pdf"
+	mimeMVT   = "application/vnd.mapbox-vector-tile"
+)
+
+// Corps des routes qui ne lisent pas directement un modèle
+
+type corpsCommentaire struct {
+	Commentaire string `json:"commentaire"`
+}
+
+type corpsMotDePasse struct {
+	OldPassword     string `json:"old_password"`
+	Password        string `json:"password"`
+	PasswordConfirm string `json:"password_confirm"`
+}
+
+type corpsMotDePasseOublie struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type corpsReinitialisation struct {
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"password_confirm" validate:"required"`
+}
+
+type corpsResolutionAlerte struct {
+	CommentResolution string `json:"comment_resolution"`
+}
+
+type corpsTransition struct {
+	Etape         string     `json:"etape" validate:"required,oneof=entretien_planifie recours cloture"`
+	DateEntretien *time.Time `json:"date_entretien"`
+	DateLimite    *time.Time `json:"date_limite"`
+	Commentaire   string     `json:"commentaire"`
+}
+
+type corpsDecision struct {
+	Decision         string `json:"decision" validate:"required,oneof=accordee rejetee"`
+	MotifDecision    string `json:"motif_decision"`
+	StatutMigratoire string `json:"statut_migratoire" validate:"omitempty,oneof=regulier irregulier demandeur_asile refugie"`
+}
+
+type corpsFusion struct {
+	SurvivanteUUID string `json:"survivante_uuid" validate:"required"`
+	Commentaire    string `json:"commentaire"`
+}
+
+type corpsEmissionCarte struct {
+	ValiditeMois int `json:"validite_mois"`
+}
+
+type corpsRevocationCarte struct {
+	Motif string `json:"motif" validate:"required"`
+}
+
+type corpsSynchronisation struct {
+	AppareilID string                      `json:"appareil_id"`
+	Operations []synchronisation.Operation `json:"operations"`
+}
+
+type corpsResolutionConflit struct {
+	Resolution  string `json:"resolution" validate:"required,oneof=rattacher appliquer rejeter"`
+	Commentaire string `json:"commentaire"`
+}
+
+// catalogue décrit chaque route de routes.Setup, par méthode et chemin Fiber.
+// Une route ajoutée sans entrée est signalée au démarrage (voir NonDocumentees).
+func catalogue() map[string]Operation {
+	return map[string]Operation{
+		// Documentation
+		"GET /api/openapi.json":  {Tag: "Documentation", Resume: "Document OpenAPI de l'API", Reponse: Fichier("application/json")},
+		"GET /api/docs":          {Tag: "Documentation", Resume: "Interface Swagger UI", Reponse: Fichier("text/html")},
+		"GET /api/docs/:fichier": {Tag: "Documentation", Resume: "Script ou feuille de style de Swagger UI", Reponse: Fichier("application/javascript")},
+
+		// Authentification
+		"POST /api/auth/register":        {Tag: "Auth", Resume: "Créer un compte", Corps: models.User{}, Reponse: Objet(models.User{})},
+		"POST /api/auth/login":           {Tag: "Auth", Resume: "Se connecter", Corps: models.Login{}, Reponse: Libre()},
+		"POST /api/auth/forgot-password": {Tag: "Auth", Resume: "Demander la réinitialisation du mot de passe", Corps: corpsMotDePasseOublie{}, Reponse: Libre()},
+		"POST /api/auth/reset/:token":    {Tag: "Auth", Resume: "Réinitialiser le mot de passe", Corps: corpsReinitialisation{}, Reponse: Libre()},
+		"POST /api/auth/create-admin":    {Tag: "Auth", Resume: "Créer l'administrateur initial", Reponse: Libre()},
+		"GET /api/auth/user":             {Tag: "Auth", Resume: "Utilisateur connecté", Reponse: Objet(models.UserResponse{})},
+		"PUT /api/auth/profil/info":      {Tag: "Auth", Resume: "Modifier son profil", Corps: models.User{}, Reponse: Objet(models.User{})},
+		"PUT /api/auth/change-password":  {Tag: "Auth", Resume: "Changer son mot de passe", Corps: corpsMotDePasse{}, Reponse: Libre()},
+		"POST /api/auth/logout":          {Tag: "Auth", Resume: "Se déconnecter", Reponse: Libre()},
+
+		// Utilisateurs
+		"GET /api/users/all":             {Tag: "Users", Resume: "Tous les utilisateurs", Reponse: Tout(models.User{})},
+		"GET /api/users/all/paginate":    {Tag: "Users", Resume: "Utilisateurs paginés", Reponse: Page(models.User{})},
+		"GET /api/users/all/:uuid":       {Tag: "Users", Resume: "Utilisateurs d'un rattachement", Reponse: Liste(models.User{})},
+		"GET /api/users/get/:uuid":       {Tag: "Users", Resume: "Un utilisateur", Reponse: Objet(models.User{})},
+		"POST /api/users/create":         {Tag: "Users", Resume: "Créer un utilisateur", Corps: models.User{}, Reponse: Objet(models.User{})},
+		"PUT /api/users/update/:uuid":    {Tag: "Users", Resume: "Modifier un utilisateur", Corps: models.User{}, Reponse: Objet(models.User{})},
+		"PATCH /api/users/update/:uuid":  {Tag: "Users", Resume: "Corriger un utilisateur (JSON Merge Patch)", Corps: models.User{}, Correctif: true, Reponse: Objet(models.User{})},
+		"DELETE /api/users/delete/:uuid": {Tag: "Users", Resume: "Supprimer un utilisateur", Reponse: Libre()},
+		"GET /api/users/export/excel":    {Tag: "Users", Resume: "Exporter les utilisateurs", Reponse: Fichier(mimeExcel)},
+		"GET /api/users/badge/:uuid/pdf": {Tag: "Users", Resume: "Badge PDF d'un agent", Reponse: Fichier(mimePDF)},
+
+		// Vérification publique
+		"GET /api/agents/verify/:uuid": {Tag: "Verification", Resume: "Vérifier le QR code d'un agent", Reponse: Libre()},
+		"GET /api/verify/public-key":   {Tag: "Verification", Resume: "Clé publique de vérification des cartes", Reponse: Libre()},
+		"GET /api/verify/:token":       {Tag: "Verification", Resume: "Vérifier le QR code d'une carte de migrant", Reponse: Libre()},
+
+		// Alertes
+		"GET /api/alerts/paginate":        {Tag: "Alerts", Resume: "Alertes paginées", Reponse: Page(models.Alert{})},
+		"GET /api/alerts/all":             {Tag: "Alerts", Resume: "Toutes les alertes", Reponse: Tout(models.Alert{})},
+		"GET /api/alerts/get/:uuid":       {Tag: "Alerts", Resume: "Une alerte", Versionne: true, Reponse: Objet(models.Alert{})},
+		"GET /api/alerts/migrant/:uuid":   {Tag: "Alerts", Resume: "Alertes d'un migrant", Reponse: Paginee(models.Alert{})},
+		"POST /api/alerts/create":         {Tag: "Alerts", Resume: "Créer une alerte", Corps: models.Alert{}, Reponse: Objet(models.Alert{})},
+		"PUT /api/alerts/update/:uuid":    {Tag: "Alerts", Resume: "Modifier une alerte", Corps: models.Alert{}, Versionne: true, Reponse: Objet(models.Alert{})},
+		"PATCH /api/alerts/update/:uuid":  {Tag: "Alerts", Resume: "Corriger une alerte (JSON Merge Patch)", Corps: models.Alert{}, Correctif: true, Versionne: true, Reponse: Objet(models.Alert{})},
+		"PUT /api/alerts/resolve/:uuid":   {Tag: "Alerts", Resume: "Résoudre une alerte", Corps: corpsResolutionAlerte{}, Versionne: true, Reponse: Objet(models.Alert{})},
+		"DELETE /api/alerts/delete/:uuid": {Tag: "Alerts", Resume: "Supprimer une alerte", Versionne: true, Reponse: Libre()},
+		"GET /api/alerts/stats":           {Tag: "Alerts", Resume: "Statistiques des alertes", Reponse: Libre()},
+		"GET /api/alerts/export/excel":    {Tag: "Alerts", Resume: "Exporter les alertes", Reponse: Fichier(mimeExcel)},
+
+		// Biométrie
+		"GET /api/biometrics/paginate":        {Tag: "Biometrics", Resume: "Données biométriques paginées", Reponse: Page(models.Biometrie{})},
+		"GET /api/biometrics/all":             {Tag: "Biometrics", Resume: "Toutes les données biométriques", Reponse: Tout(models.Biometrie{})},
+		"GET /api/biometrics/get/:uuid":       {Tag: "Biometrics", Resume: "Une donnée biométrique", Reponse: Objet(models.Biometrie{})},
+		"GET /api/biometrics/migrant/:uuid":   {Tag: "Biometrics", Resume: "Données biométriques d'un migrant", Reponse: Paginee(models.Biometrie{})},
+		"POST /api/biometrics/create":         {Tag: "Biometrics", Resume: "Enregistrer une donnée biométrique", Corps: models.Biometrie{}, Reponse: Objet(models.Biometrie{})},
+		"PUT /api/biometrics/update/:uuid":    {Tag: "Biometrics", Resume: "Modifier une donnée biométrique", Corps: models.Biometrie{}, Reponse: Objet(models.Biometrie{})},
+		"DELETE /api/biometrics/delete/:uuid": {Tag: "Biometrics", Resume: "Supprimer une donnée biométrique", Reponse: Libre()},
+		"GET /api/biometrics/stats":           {Tag: "Biometrics", Resume: "Statistiques biométriques", Reponse: Libre()},
+		"GET /api/biometrics/export/excel":    {Tag: "Biometrics", Resume: "Exporter les données biométriques", Reponse: Fichier(mimeExcel)},
+
+		// Géolocalisation
+		"GET /api/geolocations/paginate":        {Tag: "Geolocations", Resume: "Géolocalisations paginées", Reponse: Page(models.Geolocalisation{})},
+		"GET /api/geolocations/all":             {Tag: "Geolocations", Resume: "Toutes les géolocalisations", Reponse: Tout(models.Geolocalisation{})},
+		"GET /api/geolocations/coordinates":     {Tag: "Geolocations", Resume: "Coordonnées avec noms complets", Reponse: Libre()},
+		"GET /api/geolocations/tiles/:z/:x/:y":  {Tag: "Geolocations", Resume: "Tuile vectorielle de regroupement", Reponse: Fichier(mimeMVT)},
+		"GET /api/geolocations/get/:uuid":       {Tag: "Geolocations", Resume: "Une géolocalisation", Reponse: Objet(models.Geolocalisation{})},
+		"POST /api/geolocations/create":         {Tag: "Geolocations", Resume: "Créer une géolocalisation", Corps: models.Geolocalisation{}, Reponse: Objet(models.Geolocalisation{})},
+		"PUT /api/geolocations/update/:uuid":    {Tag: "Geolocations", Resume: "Modifier une géolocalisation", Corps: models.Geolocalisation{}, Reponse: Objet(models.Geolocalisation{})},
+		"DELETE /api/geolocations/delete/:uuid": {Tag: "Geolocations", Resume: "Supprimer une géolocalisation", Reponse: Libre()},
+		"GET /api/geolocations/export/excel":    {Tag: "Geolocations", Resume: "Exporter les géolocalisations", Reponse: Fichier(mimeExcel)},
+
+		// Migrants
+		"GET /api/migrants/paginate":             {Tag: "Migrants", Resume: "Migrants paginés", Reponse: Page(models.Migrant{})},
+		"GET /api/migrants/all":                  {Tag: "Migrants", Resume: "Tous les migrants", Reponse: Tout(models.Migrant{})},
+		"GET /api/migrants/get/:uuid":            {Tag: "Migrants", Resume: "Un migrant", Versionne: true, Reponse: Objet(models.Migrant{})},
+		"POST /api/migrants/create":              {Tag: "Migrants", Resume: "Enregistrer un migrant", Corps: models.Migrant{}, Reponse: Objet(models.Migrant{})},
+		"PUT /api/migrants/update/:uuid":         {Tag: "Migrants", Resume: "Modifier un migrant", Corps: models.Migrant{}, Versionne: true, Reponse: Objet(models.Migrant{})},
+		"PATCH /api/migrants/update/:uuid":       {Tag: "Migrants", Resume: "Corriger un migrant (JSON Merge Patch)", Corps: models.Migrant{}, Correctif: true, Versionne: true, Reponse: Objet(models.Migrant{})},
+		"DELETE /api/migrants/delete/:uuid":      {Tag: "Migrants", Resume: "Supprimer un migrant", Versionne: true, Reponse: Libre()},
+		"GET /api/migrants/stats":                {Tag: "Migrants", Resume: "Statistiques des migrants", Reponse: Libre()},
+		"GET /api/migrants/export/excel":         {Tag: "Migrants", Resume: "Exporter les migrants", Reponse: Fichier(mimeExcel)},
+		"GET /api/migrants/:uuid/status-history": {Tag: "Migrants", Resume: "Historique du statut migratoire", Reponse: Liste(models.HistoriqueStatut{})},
+		"POST /api/migrants/:uuid/card":          {Tag: "Cards", Resume: "Émettre une carte d'enregistrement", Corps: corpsEmissionCarte{}, Reponse: Objet(models.CarteMigrant{})},
+		"GET /api/migrants/:uuid/card":           {Tag: "Cards", Resume: "Carte active d'un migrant", Reponse: Objet(models.CarteMigrant{})},
+		"PUT /api/migrants/:uuid/card/revoke":    {Tag: "Cards", Resume: "Révoquer la carte active", Corps: corpsRevocationCarte{}, Reponse: Objet(models.CarteMigrant{})},
+		"GET /api/migrants/:uuid/card/pdf":       {Tag: "Cards", Resume: "Carte d'enregistrement en PDF", Reponse: Fichier(mimePDF)},
+		"GET /api/migrants/:uuid/dossier/pdf":    {Tag: "Migrants", Resume: "Dossier du migrant en PDF", Reponse: Fichier(mimePDF)},
+
+		// Identités
+		"GET /api/identites/paginate":               {Tag: "Identites", Resume: "Identités paginées", Reponse: Page(models.Identite{})},
+		"GET /api/identites/migrants/by-identite":   {Tag: "Identites", Resume: "Migrants d'une identité", Reponse: Paginee(models.Migrant{})},
+		"GET /api/identites/expiring":               {Tag: "Identites", Resume: "Documents de voyage expirés ou bientôt expirés", Reponse: Paginee(models.Identite{})},
+		"GET /api/identites/expiring/export/excel":  {Tag: "Identites", Resume: "Exporter les documents expirant", Reponse: Fichier(mimeExcel)},
+		"POST /api/identites/expiring/evaluate":     {Tag: "Identites", Resume: "Réévaluer la validité des documents", Reponse: Libre()},
+		"GET /api/identites/:uuid":                  {Tag: "Identites", Resume: "Une identité", Versionne: true, Reponse: Objet(models.Identite{})},
+		"POST /api/identites/create":                {Tag: "Identites", Resume: "Créer une identité", Corps: models.Identite{}, Reponse: Objet(models.Identite{})},
+		"PUT /api/identites/update/:uuid":           {Tag: "Identites", Resume: "Modifier une identité", Corps: models.Identite{}, Versionne: true, Reponse: Objet(models.Identite{})},
+		"PATCH /api/identites/update/:uuid":         {Tag: "Identites", Resume: "Corriger une identité (JSON Merge Patch)", Corps: models.Identite{}, Correctif: true, Versionne: true, Reponse: Objet(models.Identite{})},
+		"DELETE /api/identites/delete/:uuid":        {Tag: "Identites", Resume: "Supprimer une identité", Versionne: true, Reponse: Libre()},
+		"GET /api/identites/export/excel":           {Tag: "Identites", Resume: "Exporter les identités", Reponse: Fichier(mimeExcel)},
+		"GET /api/identites/statistics":             {Tag: "Identites", Resume: "Statistiques des identités", Reponse: Libre()},
+		"POST /api/identites/scan":                  {Tag: "Scanner", Resume: "Numériser un document", Reponse: Libre()},
+		"GET /api/identites/scanned-file/:filename": {Tag: "Scanner", Resume: "Fichier numérisé", Reponse: Fichier("image/*")},
+		"GET /api/identites/scanners/list":          {Tag: "Scanner", Resume: "Scanners disponibles", Reponse: Libre()},
+
+		// Recherche
+		"GET /api/search/persons": {Tag: "Search", Resume: "Recherche approchée de personnes", Reponse: Paginee(nil)},
+
+		// Dossiers
+		"GET /api/dossiers/paginate":                    {Tag: "Dossiers", Resume: "Dossiers paginés", Reponse: Page(models.Dossier{})},
+		"GET /api/dossiers/get/:uuid":                   {Tag: "Dossiers", Resume: "Un dossier", Versionne: true, Reponse: Objet(models.Dossier{})},
+		"GET /api/dossiers/migrant/:uuid":               {Tag: "Dossiers", Resume: "Dossiers d'un migrant", Reponse: Liste(models.Dossier{})},
+		"POST /api/dossiers/create":                     {Tag: "Dossiers", Resume: "Ouvrir un dossier", Corps: models.Dossier{}, Reponse: Objet(models.Dossier{})},
+		"PUT /api/dossiers/update/:uuid":                {Tag: "Dossiers", Resume: "Modifier un dossier", Corps: models.Dossier{}, Versionne: true, Reponse: Objet(models.Dossier{})},
+		"DELETE /api/dossiers/delete/:uuid":             {Tag: "Dossiers", Resume: "Supprimer un dossier", Versionne: true, Reponse: Libre()},
+		"GET /api/dossiers/stats":                       {Tag: "Dossiers", Resume: "Statistiques des dossiers", Reponse: Libre()},
+		"GET /api/dossiers/echeances":                   {Tag: "Dossiers", Resume: "Échéances des dossiers", Reponse: Liste(models.Dossier{})},
+		"GET /api/dossiers/export/excel":                {Tag: "Dossiers", Resume: "Exporter les dossiers", Reponse: Fichier(mimeExcel)},
+		"PUT /api/dossiers/transition/:uuid":            {Tag: "Dossiers", Resume: "Faire passer un dossier à l'étape suivante", Corps: corpsTransition{}, Reponse: Objet(models.Dossier{})},
+		"POST /api/dossiers/decision/:uuid":             {Tag: "Dossiers", Resume: "Enregistrer la décision", Corps: corpsDecision{}, Reponse: Objet(models.Dossier{})},
+		"POST /api/dossiers/:uuid/notes":                {Tag: "Dossiers", Resume: "Ajouter une note", Corps: models.DossierNote{}, Reponse: Objet(models.DossierNote{})},
+		"DELETE /api/dossiers/:uuid/notes/:note_uuid":   {Tag: "Dossiers", Resume: "Supprimer une note", Reponse: Libre()},
+		"POST /api/dossiers/:uuid/agents":               {Tag: "Dossiers", Resume: "Affecter un agent", Corps: models.DossierAgent{}, Reponse: Objet(models.DossierAgent{})},
+		"DELETE /api/dossiers/:uuid/agents/:agent_uuid": {Tag: "Dossiers", Resume: "Retirer un agent", Reponse: Libre()},
+
+		// Relations familiales
+		"GET /api/relations/migrant/:uuid":   {Tag: "Relations", Resume: "Relations familiales d'un migrant", Reponse: Liste(models.RelationFamiliale{})},
+		"GET /api/relations/menage/:uuid":    {Tag: "Relations", Resume: "Ménage d'un migrant", Reponse: Libre()},
+		"GET /api/relations/incoherences":    {Tag: "Relations", Resume: "Relations incohérentes", Reponse: Libre()},
+		"POST /api/relations/create":         {Tag: "Relations", Resume: "Créer une relation familiale", Corps: models.RelationFamiliale{}, Reponse: Objet(models.RelationFamiliale{})},
+		"DELETE /api/relations/delete/:uuid": {Tag: "Relations", Resume: "Supprimer une relation familiale", Reponse: Libre()},
+
+		// Doublons
+		"POST /api/doublons/scan":               {Tag: "Doublons", Resume: "Rechercher les doublons", Reponse: Libre()},
+		"GET /api/doublons/paginate":            {Tag: "Doublons", Resume: "Doublons candidats paginés", Reponse: Page(models.DoublonCandidat{})},
+		"GET /api/doublons/get/:uuid":           {Tag: "Doublons", Resume: "Un doublon candidat", Reponse: Objet(models.DoublonCandidat{})},
+		"PUT /api/doublons/reject/:uuid":        {Tag: "Doublons", Resume: "Écarter un doublon", Corps: corpsCommentaire{}, Reponse: Objet(models.DoublonCandidat{})},
+		"POST /api/doublons/merge/:uuid":        {Tag: "Doublons", Resume: "Fusionner deux identités", Corps: corpsFusion{}, Reponse: Objet(models.FusionIdentite{})},
+		"GET /api/doublons/fusions/paginate":    {Tag: "Doublons", Resume: "Fusions paginées", Reponse: Page(models.FusionIdentite{})},
+		"POST /api/doublons/fusions/undo/:uuid": {Tag: "Doublons", Resume: "Annuler une fusion", Reponse: Objet(models.FusionIdentite{})},
+
+		// Postes frontières
+		"GET /api/border-posts/paginate":        {Tag: "BorderPosts", Resume: "Postes frontières paginés", Reponse: Page(models.PosteFrontiere{})},
+		"GET /api/border-posts/all":             {Tag: "BorderPosts", Resume: "Tous les postes frontières", Reponse: Tout(models.PosteFrontiere{})},
+		"GET /api/border-posts/get/:uuid":       {Tag: "BorderPosts", Resume: "Un poste frontière", Reponse: Objet(models.PosteFrontiere{})},
+		"POST /api/border-posts/create":         {Tag: "BorderPosts", Resume: "Créer un poste frontière", Corps: models.PosteFrontiere{}, Reponse: Objet(models.PosteFrontiere{})},
+		"PUT /api/border-posts/update/:uuid":    {Tag: "BorderPosts", Resume: "Modifier un poste frontière", Corps: models.PosteFrontiere{}, Reponse: Objet(models.PosteFrontiere{})},
+		"DELETE /api/border-posts/delete/:uuid": {Tag: "BorderPosts", Resume: "Supprimer un poste frontière", Reponse: Libre()},
+
+		// Unités organisationnelles
+		"GET /api/org-units/paginate":        {Tag: "OrgUnits", Resume: "Unités paginées", Reponse: Page(models.UniteOrganisationnelle{})},
+		"GET /api/org-units/all":             {Tag: "OrgUnits", Resume: "Toutes les unités", Reponse: Tout(models.UniteOrganisationnelle{})},
+		"GET /api/org-units/tree":            {Tag: "OrgUnits", Resume: "Arborescence des unités", Reponse: Libre()},
+		"GET /api/org-units/access-logs":     {Tag: "OrgUnits", Resume: "Accès hors périmètre", Reponse: Paginee(models.AccesHorsPerimetre{})},
+		"GET /api/org-units/get/:uuid":       {Tag: "OrgUnits", Resume: "Une unité", Reponse: Objet(models.UniteOrganisationnelle{})},
+		"POST /api/org-units/create":         {Tag: "OrgUnits", Resume: "Créer une unité", Corps: models.UniteOrganisationnelle{}, Reponse: Objet(models.UniteOrganisationnelle{})},
+		"PUT /api/org-units/update/:uuid":    {Tag: "OrgUnits", Resume: "Modifier une unité", Corps: models.UniteOrganisationnelle{}, Reponse: Objet(models.UniteOrganisationnelle{})},
+		"DELETE /api/org-units/delete/:uuid": {Tag: "OrgUnits", Resume: "Supprimer une unité", Reponse: Libre()},
+
+		// Passages aux frontières
+		"GET /api/crossings/paginate":        {Tag: "Crossings", Resume: "Passages paginés", Reponse: Page(models.Passage{})},
+		"GET /api/crossings/get/:uuid":       {Tag: "Crossings", Resume: "Un passage", Reponse: Objet(models.Passage{})},
+		"GET /api/crossings/migrant/:uuid":   {Tag: "Crossings", Resume: "Passages d'un migrant", Reponse: Page(models.Passage{})},
+		"GET /api/crossings/poste/:uuid":     {Tag: "Crossings", Resume: "Passages enregistrés à un poste", Reponse: Page(models.Passage{})},
+		"GET /api/crossings/stats/daily":     {Tag: "Crossings", Resume: "Flux journaliers", Reponse: Libre()},
+		"POST /api/crossings/create":         {Tag: "Crossings", Resume: "Enregistrer un passage", Corps: models.Passage{}, Reponse: Objet(models.Passage{})},
+		"DELETE /api/crossings/delete/:uuid": {Tag: "Crossings", Resume: "Supprimer un passage", Reponse: Libre()},
+
+		// Listes de surveillance
+		"GET /api/watchlists/paginate":           {Tag: "Watchlists", Resume: "Listes de surveillance paginées", Reponse: Page(models.ListeSurveillance{})},
+		"GET /api/watchlists/get/:uuid":          {Tag: "Watchlists", Resume: "Une liste de surveillance", Reponse: Objet(models.ListeSurveillance{})},
+		"GET /api/watchlists/:uuid/entries":      {Tag: "Watchlists", Resume: "Entrées d'une liste", Reponse: Page(models.EntreeSurveillance{})},
+		"POST /api/watchlists/import":            {Tag: "Watchlists", Resume: "Importer une liste de surveillance", Formulaire: []string{"file", "liste_uuid", "nom", "type_liste", "source", "description", "remplacer"}, Reponse: Objet(models.ListeSurveillance{})},
+		"PUT /api/watchlists/update/:uuid":       {Tag: "Watchlists", Resume: "Modifier une liste de surveillance", Corps: models.ListeSurveillance{}, Reponse: Objet(models.ListeSurveillance{})},
+		"DELETE /api/watchlists/delete/:uuid":    {Tag: "Watchlists", Resume: "Supprimer une liste de surveillance", Reponse: Libre()},
+		"POST /api/watchlists/rescreen/:uuid":    {Tag: "Watchlists", Resume: "Recontrôler les identités contre une liste", Reponse: Libre()},
+		"GET /api/watchlists/hits/paginate":      {Tag: "Watchlists", Resume: "Correspondances paginées", Reponse: Page(models.CorrespondanceSurveillance{})},
+		"GET /api/watchlists/hits/get/:uuid":     {Tag: "Watchlists", Resume: "Une correspondance", Reponse: Objet(models.CorrespondanceSurveillance{})},
+		"PUT /api/watchlists/hits/confirm/:uuid": {Tag: "Watchlists", Resume: "Confirmer une correspondance", Corps: corpsCommentaire{}, Reponse: Objet(models.CorrespondanceSurveillance{})},
+		"PUT /api/watchlists/hits/clear/:uuid":   {Tag: "Watchlists", Resume: "Écarter une correspondance", Corps: corpsCommentaire{}, Reponse: Objet(models.CorrespondanceSurveillance{})},
+
+		// Imports en masse
+		"POST /api/import/identites":        {Tag: "Imports", Resume: "Importer des identités (Excel / CSV)", Formulaire: []string{"file", "dry_run", "ignorer_erreurs", "bureau", "async"}, Reponse: Objet(models.Importation{})},
+		"POST /api/import/migrants":         {Tag: "Imports", Resume: "Importer des migrants (Excel / CSV)", Formulaire: []string{"file", "dry_run", "ignorer_erreurs", "bureau", "async"}, Reponse: Objet(models.Importation{})},
+		"GET /api/import/jobs":              {Tag: "Imports", Resume: "Imports paginés", Reponse: Page(models.Importation{})},
+		"GET /api/import/jobs/:uuid":        {Tag: "Imports", Resume: "Un import", Reponse: Objet(models.Importation{})},
+		"GET /api/import/jobs/:uuid/errors": {Tag: "Imports", Resume: "Erreurs d'un import", Reponse: Page(models.ErreurImport{})},
+
+		// Synchronisation hors ligne
+		"POST /api/sync/push":                   {Tag: "Sync", Resume: "Envoyer les opérations faites hors ligne", Corps: corpsSynchronisation{}, Reponse: Liste(synchronisation.Resultat{})},
+		"GET /api/sync/pull":                    {Tag: "Sync", Resume: "Récupérer les changements depuis un curseur", Reponse: Libre()},
+		"GET /api/sync/conflicts/paginate":      {Tag: "Sync", Resume: "Conflits de synchronisation paginés", Reponse: Page(models.ConflitSynchro{})},
+		"GET /api/sync/conflicts/get/:uuid":     {Tag: "Sync", Resume: "Un conflit de synchronisation", Reponse: Objet(models.ConflitSynchro{})},
+		"PUT /api/sync/conflicts/resolve/:uuid": {Tag: "Sync", Resume: "Résoudre un conflit", Corps: corpsResolutionConflit{}, Reponse: Objet(models.ConflitSynchro{})},
+
+		// Motifs de déplacement
+		"GET /api/motif-deplacements/paginate":        {Tag: "MotifDeplacements", Resume: "Motifs paginés", Reponse: Page(models.MotifDeplacement{})},
+		"GET /api/motif-deplacements/all":             {Tag: "MotifDeplacements", Resume: "Tous les motifs", Reponse: Tout(models.MotifDeplacement{})},
+		"GET /api/motif-deplacements/get/:uuid":       {Tag: "MotifDeplacements", Resume: "Un motif", Reponse: Objet(models.MotifDeplacement{})},
+		"POST /api/motif-deplacements/create":         {Tag: "MotifDeplacements", Resume: "Créer un motif", Corps: models.MotifDeplacement{}, Reponse: Objet(models.MotifDeplacement{})},
+		"PUT /api/motif-deplacements/update/:uuid":    {Tag: "MotifDeplacements", Resume: "Modifier un motif", Corps: models.MotifDeplacement{}, Reponse: Objet(models.MotifDeplacement{})},
+		"DELETE /api/motif-deplacements/delete/:uuid": {Tag: "MotifDeplacements", Resume: "Supprimer un motif", Reponse: Libre()},
+		"GET /api/motif-deplacements/stats":           {Tag: "MotifDeplacements", Resume: "Statistiques des motifs", Reponse: Libre()},
+		"GET /api/motif-deplacements/export/excel":    {Tag: "MotifDeplacements", Resume: "Exporter les motifs", Reponse: Fichier(mimeExcel)},
+
+		// Tableau de bord
+		"GET /api/dashboard/overview/indicateurs":        {Tag: "Dashboard", Resume: "Indicateurs généraux", Reponse: Libre()},
+		"GET /api/dashboard/overview/alertes":            {Tag: "Dashboard", Resume: "Alertes en temps réel", Reponse: Libre()},
+		"GET /api/dashboard/overview/repartition":        {Tag: "Dashboard", Resume: "Répartition géographique", Reponse: Libre()},
+		"GET /api/dashboard/overview/motifs-pie":         {Tag: "Dashboard", Resume: "Répartition des motifs de déplacement", Reponse: Libre()},
+		"GET /api/dashboard/overview/transitions-statut": {Tag: "Dashboard", Resume: "Transitions de statut migratoire", Reponse: Libre()},
+		"GET /api/dashboard/predictive/enregistrements":  {Tag: "Dashboard", Resume: "Prévision des enregistrements mensuels", Reponse: Objet(dashboard.AnalysePredictive{})},
+		"GET /api/dashboard/predictive/provinces":        {Tag: "Dashboard", Resume: "Prévision des enregistrements par province", Reponse: Objet(dashboard.AnalysePredictive{})},
+		"GET /api/dashboard/predictive/motifs":           {Tag: "Dashboard", Resume: "Prévision des enregistrements par type de motif", Reponse: Objet(dashboard.AnalysePredictive{})},
+	}
+}
@@ -0,0 +1,351 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/controllers/versions"
+	"github.com/kgermando/sysmobembo-api/problemes"
+)
+
+// Operation décrit une route de l'API dans le document OpenAPI
+type Operation struct {
+	Tag    string
+	Resume string
+	// Corps JSON attendu : une valeur du type à décrire, nil sans corps
+	Corps interface{}
+	// Corps application/merge-patch+json : champs facultatifs, hors champs en lecture seule
+	Correctif bool
+	// Champs multipart/form-data ; "file" est décrit comme un fichier
+	Formulaire []string
+	// Enregistrement versionné : en-tête If-Match accepté, ETag renvoyé
+	Versionne bool
+	Reponse   Reponse
+}
+
+type genreReponse int
+
+const (
+	reponseLibre genreReponse = iota
+	reponseObjet
+	reponseListe
+	reponseTout
+	reponsePage
+	reponsePaginee
+	reponseFichier
+)
+
+// Reponse décrit le champ data de l'enveloppe {status, message, data}
+type Reponse struct {
+	genre  genreReponse
+	modele interface{}
+	mime   string
+}
+
+// Objet - data contient un enregistrement
+func Objet(modele interface{}) Reponse { return Reponse{genre: reponseObjet, modele: modele} }
+
+// Liste - data contient une liste d'enregistrements
+func Liste(modele interface{}) Reponse { return Reponse{genre: reponseListe, modele: modele} }
+
+// Tout - liste complète acceptant les paramètres filter, sort, fields et limit
+func Tout(modele interface{}) Reponse { return Reponse{genre: reponseTout, modele: modele} }
+
+// Page - liste paginée, avec le champ pagination et les paramètres de liste
+func Page(modele interface{}) Reponse { return Reponse{genre: reponsePage, modele: modele} }
+
+// Paginee - liste paginée par page et limit, sans les paramètres de liste communs
+func Paginee(modele interface{}) Reponse { return Reponse{genre: reponsePaginee, modele: modele} }
+
+// Fichier - réponse binaire (export Excel, PDF, image)
+func Fichier(mime string) Reponse { return Reponse{genre: reponseFichier, mime: mime} }
+
+// Libre - data sans schéma fixe (statistiques, tableaux de bord, suppressions)
+func Libre() Reponse { return Reponse{genre: reponseLibre} }
+
+var (
+	parametreRoute = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+	document       map[string]interface{}
+	documentOnce   sync.Once
+)
+
+// Document sert le document OpenAPI des routes enregistrées dans l'application
+func Document(app *fiber.App) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		documentOnce.Do(func() {
+			document = Generer(app)
+		})
+		return c.JSON(document)
+	}
+}
+
+// Generer construit le document OpenAPI 3.1 des routes de l'application décrites dans le catalogue
+func Generer(app *fiber.App) map[string]interface{} {
+	s := &schemas{composants: map[string]interface{}{}}
+	// Erreur au format RFC 7807 ; title et detail suivent Accept-Language (fr par défaut)
+	s.composants["Erreur"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"type", "title", "status", "code"},
+		"properties": map[string]interface{}{
+			"type":     map[string]interface{}{"type": "string", "format": "uri"},
+			"title":    map[string]interface{}{"type": "string"},
+			"status":   map[string]interface{}{"type": "integer"},
+			"detail":   map[string]interface{}{"type": "string"},
+			"instance": map[string]interface{}{"type": "string"},
+			"code":     map[string]interface{}{"type": "string", "description": "Code stable, indépendant de la langue"},
+			"message":  map[string]interface{}{"type": "string", "description": "Identique à detail, pour les clients antérieurs"},
+			"errors": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"field":   map[string]interface{}{"type": "string"},
+						"rule":    map[string]interface{}{"type": "string"},
+						"param":   map[string]interface{}{"type": "string"},
+						"message": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+	s.composants["Pagination"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"total_records": map[string]interface{}{"type": "integer"},
+			"total_pages":   map[string]interface{}{"type": "integer"},
+			"current_page":  map[string]interface{}{"type": "integer"},
+			"page_size":     map[string]interface{}{"type": "integer"},
+			"has_more":      map[string]interface{}{"type": "boolean"},
+			"next_cursor":   map[string]interface{}{"type": "string"},
+		},
+	}
+
+	ops := catalogue()
+	chemins := map[string]interface{}{}
+	tags := map[string]bool{}
+	for _, route := range routes(app) {
+		op, ok := ops[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		chemin := parametreRoute.ReplaceAllString(route.Path, "{$1}")
+		entree, _ := chemins[chemin].(map[string]interface{})
+		if entree == nil {
+			entree = map[string]interface{}{}
+			chemins[chemin] = entree
+		}
+		entree[strings.ToLower(route.Method)] = s.operation(route, op)
+		tags[op.Tag] = true
+	}
+
+	var listeTags []map[string]string
+	for tag := range tags {
+		listeTags = append(listeTags, map[string]string{"name": tag})
+	}
+	sort.Slice(listeTags, func(i, j int) bool { return listeTags[i]["name"] < listeTags[j]["name"] })
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "SYSMOBEMBO API",
+			"version":     "1.0.0",
+			"description": "Gestion des migrants, des identités et des passages aux frontières",
+		},
+		"tags":  listeTags,
+		"paths": chemins,
+		"components": map[string]interface{}{
+			"schemas": s.composants,
+		},
+	}
+}
+
+// NonDocumentees liste les routes de l'application absentes du catalogue ("GET /api/...")
+func NonDocumentees(app *fiber.App) []string {
+	ops := catalogue()
+	var manquantes []string
+	for _, route := range routes(app) {
+		cle := route.Method + " " + route.Path
+		if _, ok := ops[cle]; !ok {
+			manquantes = append(manquantes, cle)
+		}
+	}
+	return manquantes
+}
+
+// routes retourne les routes déclarées, sans les middlewares ni les HEAD ajoutées par Fiber
+func routes(app *fiber.App) []fiber.Route {
+	vues := map[string]bool{}
+	var liste []fiber.Route
+	for _, route := range app.GetRoutes(true) {
+		cle := route.Method + " " + route.Path
+		if route.Method == fiber.MethodHead || vues[cle] {
+			continue
+		}
+		vues[cle] = true
+		liste = append(liste, route)
+	}
+	sort.Slice(liste, func(i, j int) bool {
+		if liste[i].Path != liste[j].Path {
+			return liste[i].Path < liste[j].Path
+		}
+		return liste[i].Method < liste[j].Method
+	})
+	return liste
+}
+
+func (s *schemas) operation(route fiber.Route, op Operation) map[string]interface{} {
+	operation := map[string]interface{}{
+		"tags":        []string{op.Tag},
+		"summary":     op.Resume,
+		"operationId": strings.ToLower(route.Method) + identifiant(route.Path),
+	}
+
+	var parametres []interface{}
+	for _, nom := range route.Params {
+		parametres = append(parametres, parametre(nom, "path", true, "string"))
+	}
+	switch op.Reponse.genre {
+	case reponsePage:
+		parametres = append(parametres,
+			parametre("page", "query", false, "integer"),
+			parametre("limit", "query", false, "integer"),
+			parametre("search", "query", false, "string"),
+			parametre("cursor", "query", false, "string"))
+		parametres = append(parametres, parametresListe()...)
+	case reponsePaginee:
+		parametres = append(parametres,
+			parametre("page", "query", false, "integer"),
+			parametre("limit", "query", false, "integer"),
+			parametre("search", "query", false, "string"))
+	case reponseTout:
+		parametres = append(parametres, parametre("limit", "query", false, "integer"))
+		parametres = append(parametres, parametresListe()...)
+	}
+	modification := route.Method == fiber.MethodPut || route.Method == fiber.MethodPatch || route.Method == fiber.MethodDelete
+	if op.Versionne && modification {
+		parametres = append(parametres, parametre("If-Match", "header", !versions.IfMatchFacultatif(), "string"))
+	}
+	if route.Method == fiber.MethodPost {
+		parametres = append(parametres, parametre("Idempotency-Key", "header", false, "string"))
+	}
+	if len(parametres) > 0 {
+		operation["parameters"] = parametres
+	}
+
+	switch {
+	case op.Formulaire != nil:
+		proprietes := map[string]interface{}{}
+		for _, champ := range op.Formulaire {
+			if champ == "file" {
+				proprietes[champ] = map[string]interface{}{"type": "string", "format": "binary"}
+			} else {
+				proprietes[champ] = map[string]interface{}{"type": "string"}
+			}
+		}
+		operation["requestBody"] = corps("multipart/form-data", map[string]interface{}{"type": "object", "properties": proprietes})
+	case op.Corps != nil && op.Correctif:
+		operation["requestBody"] = corps("application/merge-patch+json", s.objet(reflect.TypeOf(op.Corps), true))
+	case op.Corps != nil:
+		operation["requestBody"] = corps(fiber.MIMEApplicationJSON, s.reference(reflect.TypeOf(op.Corps)))
+	}
+
+	reponses := map[string]interface{}{
+		"200":     s.reponse(op),
+		"default": contenu("Erreur", problemes.TypeContenu, map[string]interface{}{"$ref": "#/components/schemas/Erreur"}),
+	}
+	if op.Versionne && modification {
+		reponses["412"] = contenu("La version de l'enregistrement ne correspond pas à If-Match", problemes.TypeContenu, map[string]interface{}{"$ref": "#/components/schemas/Erreur"})
+		reponses["428"] = contenu("En-tête If-Match absent", problemes.TypeContenu, map[string]interface{}{"$ref": "#/components/schemas/Erreur"})
+	}
+	operation["responses"] = reponses
+	return operation
+}
+
+func (s *schemas) reponse(op Operation) map[string]interface{} {
+	if op.Reponse.genre == reponseFichier {
+		return contenu("Fichier", op.Reponse.mime, map[string]interface{}{"type": "string", "format": "binary"})
+	}
+
+	data := map[string]interface{}{}
+	if op.Reponse.modele != nil {
+		switch op.Reponse.genre {
+		case reponseObjet:
+			data = s.reference(reflect.TypeOf(op.Reponse.modele))
+		case reponseListe, reponseTout, reponsePage, reponsePaginee:
+			data = map[string]interface{}{"type": "array", "items": s.reference(reflect.TypeOf(op.Reponse.modele))}
+		}
+	}
+	proprietes := map[string]interface{}{
+		"status":  map[string]interface{}{"type": "string"},
+		"message": map[string]interface{}{"type": "string"},
+		"data":    data,
+	}
+	if op.Reponse.genre == reponsePage || op.Reponse.genre == reponsePaginee {
+		proprietes["pagination"] = map[string]interface{}{"$ref": "#/components/schemas/Pagination"}
+	}
+	reponse := contenu("Succès", fiber.MIMEApplicationJSON, map[string]interface{}{"type": "object", "properties": proprietes})
+	if op.Versionne {
+		reponse["headers"] = map[string]interface{}{
+			"ETag": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+		}
+	}
+	return reponse
+}
+
+// parametresListe décrit les paramètres communs de filtrage, de tri et de sélection
+func parametresListe() []interface{} {
+	filtre := parametre("filter", "query", false, "object")
+	filtre["style"] = "deepObject"
+	filtre["explode"] = true
+	filtre["description"] = "filter[champ]=valeur, filter[champ][gte|lte|gt|lt|ne|in|like|null]=valeur"
+	tri := parametre("sort", "query", false, "string")
+	tri["description"] = "Champs séparés par des virgules, préfixés par - pour un tri décroissant"
+	champs := parametre("fields", "query", false, "string")
+	champs["description"] = "Champs à retourner, séparés par des virgules"
+	return []interface{}{filtre, tri, champs}
+}
+
+func parametre(nom, emplacement string, requis bool, typ string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     nom,
+		"in":       emplacement,
+		"required": requis,
+		"schema":   map[string]interface{}{"type": typ},
+	}
+}
+
+func corps(mime string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content":  map[string]interface{}{mime: map[string]interface{}{"schema": schema}},
+	}
+}
+
+func contenu(description, mime string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{mime: map[string]interface{}{"schema": schema}},
+	}
+}
+
+// identifiant dérive un operationId lisible du chemin : /api/users/get/:uuid -> UsersGetByUuid
+func identifiant(chemin string) string {
+	var b strings.Builder
+	for _, morceau := range strings.Split(strings.TrimPrefix(chemin, "/api"), "/") {
+		if morceau == "" {
+			continue
+		}
+		if strings.HasPrefix(morceau, ":") {
+			b.WriteString("By")
+			morceau = strings.TrimSuffix(strings.TrimPrefix(morceau, ":"), "?")
+		}
+		for _, mot := range strings.FieldsFunc(morceau, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(mot[:1]) + mot[1:])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package openapi_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/openapi"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/routes"
+)
+
+// application construit l'application complète, comme main
+func application() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	routes.Setup(app)
+	return app
+}
+
+func TestToutesLesRoutesSontDocumentees(t *testing.T) {
+	if manquantes := openapi.NonDocumentees(application()); len(manquantes) > 0 {
+		t.Errorf("%d route(s) sans entrée dans le catalogue OpenAPI :\n%s", len(manquantes), strings.Join(manquantes, "\n"))
+	}
+}
+
+func TestSwaggerUISansCDN(t *testing.T) {
+	app := application()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/docs", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(page), "https://") {
+		t.Error("la page Swagger UI charge encore une ressource externe")
+	}
+
+	cas := map[string]int{
+		"/api/docs/swagger-ui.css":       fiber.StatusOK,
+		"/api/docs/swagger-ui-bundle.js": fiber.StatusOK,
+		"/api/docs/index.html":           fiber.StatusNotFound,
+		"/api/docs/..%2Fswagger.go":      fiber.StatusNotFound,
+	}
+	for url, attendu := range cas {
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != attendu {
+			t.Errorf("%s : statut %d, attendu %d", url, resp.StatusCode, attendu)
+		}
+		if attendu == fiber.StatusOK && resp.ContentLength == 0 {
+			t.Errorf("%s : contenu vide", url)
+		}
+	}
+}
+
+// Les ressources de la page se chargent quelle que soit l'adresse de la page,
+// avec ou sans barre oblique finale
+func TestSwaggerUIRessourcesResolues(t *testing.T) {
+	app := application()
+	reference := regexp.MustCompile(`(?:href|src|url:)\s*=?\s*"([^"]+)"`)
+
+	for _, page := range []string{"/api/docs", "/api/docs/"} {
+		resp, err := app.Test(httptest.NewRequest("GET", page, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s : statut %d, attendu 200", page, resp.StatusCode)
+		}
+		contenu, _ := io.ReadAll(resp.Body)
+		base, _ := url.Parse(page)
+
+		liens := reference.FindAllStringSubmatch(string(contenu), -1)
+		if len(liens) != 3 {
+			t.Fatalf("%s : %d ressource(s) trouvée(s), attendu la feuille de style, le script et le document", page, len(liens))
+		}
+		for _, lien := range liens {
+			cible, err := base.Parse(lien[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := app.Test(httptest.NewRequest("GET", cible.Path, nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Errorf("%s : %s résolu en %s, statut %d", page, lien[1], cible.Path, resp.StatusCode)
+			}
+		}
+	}
+}
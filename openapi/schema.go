@@ -0,0 +1,186 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	typeTemps     = reflect.TypeOf(time.Time{})
+	typeSupprime  = reflect.TypeOf(gorm.DeletedAt{})
+	typeBrut      = reflect.TypeOf(json.RawMessage{})
+	typeOctets    = reflect.TypeOf([]byte{})
+	typeInterface = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// Champs renseignés par le serveur, marqués readOnly dans les schémas
+var lectureSeule = map[string]bool{
+	"uuid":       true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"version":    true,
+	"CreatedAt":  true,
+	"UpdatedAt":  true,
+	"DeletedAt":  true,
+}
+
+// schemas construit les schémas JSON des types Go et les enregistre dans components
+type schemas struct {
+	composants map[string]interface{}
+}
+
+// reference retourne le schéma d'un type : une référence pour les structures nommées,
+// un schéma en ligne pour les autres
+func (s *schemas) reference(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == typeTemps:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == typeSupprime:
+		return map[string]interface{}{"type": []string{"string", "null"}, "format": "date-time"}
+	case t == typeBrut || t == typeInterface:
+		return map[string]interface{}{}
+	case t == typeOctets:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.reference(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.reference(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.objet(t, false)
+		}
+		if _, ok := s.composants[t.Name()]; !ok {
+			// Réservé avant le parcours des champs : les relations sont souvent circulaires
+			s.composants[t.Name()] = nil
+			s.composants[t.Name()] = s.objet(t, false)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// objet décrit une structure champ par champ. Pour un correctif (merge patch),
+// aucun champ n'est requis et les champs en lecture seule sont omis.
+func (s *schemas) objet(t reflect.Type, correctif bool) map[string]interface{} {
+	proprietes := map[string]interface{}{}
+	var requis []string
+
+	var parcourir func(t reflect.Type)
+	parcourir = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if tag[0] == "-" {
+				continue
+			}
+			if f.Anonymous && tag[0] == "" && f.Type.Kind() == reflect.Struct {
+				parcourir(f.Type)
+				continue
+			}
+			nom := tag[0]
+			if nom == "" {
+				nom = f.Name
+			}
+			if correctif && lectureSeule[nom] {
+				continue
+			}
+
+			schema := map[string]interface{}{}
+			for cle, valeur := range s.reference(f.Type) {
+				schema[cle] = valeur
+			}
+			if valider(schema, f.Tag.Get("validate")) && !correctif {
+				requis = append(requis, nom)
+			}
+			if f.Type.Kind() == reflect.Ptr {
+				if typ, ok := schema["type"].(string); ok {
+					schema["type"] = []string{typ, "null"}
+				}
+			}
+			if lectureSeule[nom] {
+				schema["readOnly"] = true
+			}
+			proprietes[nom] = schema
+		}
+	}
+	parcourir(t)
+
+	objet := map[string]interface{}{"type": "object", "properties": proprietes}
+	if len(requis) > 0 {
+		objet["required"] = requis
+	}
+	return objet
+}
+
+// valider reporte les règles du tag validate sur le schéma et indique si le champ est requis
+func valider(schema map[string]interface{}, regles string) bool {
+	requis := false
+	numerique := schema["type"] == "integer" || schema["type"] == "number"
+	facultatif := false
+
+	for _, regle := range strings.Split(regles, ",") {
+		nom, valeur, _ := strings.Cut(regle, "=")
+		switch nom {
+		case "required":
+			requis = true
+		case "omitempty":
+			facultatif = true
+		case "oneof":
+			valeurs := strings.Fields(valeur)
+			// omitempty laisse passer la valeur vide
+			if facultatif && schema["type"] == "string" {
+				valeurs = append([]string{""}, valeurs...)
+			}
+			schema["enum"] = valeurs
+		case "email":
+			schema["format"] = "email"
+		case "latitude":
+			schema["minimum"], schema["maximum"] = -90, 90
+		case "longitude":
+			schema["minimum"], schema["maximum"] = -180, 180
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(valeur, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case numerique && nom == "min":
+				schema["minimum"] = n
+			case numerique && nom == "max":
+				schema["maximum"] = n
+			case nom == "min":
+				schema["minLength"] = int(n)
+			case nom == "max":
+				schema["maxLength"] = int(n)
+			default:
+				schema["minLength"], schema["maxLength"] = int(n), int(n)
+			}
+		}
+	}
+	return requis
+}
@@ -0,0 +1,37 @@
+package openapi
+
+import (
+	"embed"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page Swagger UI intégrée au binaire ; elle lit le document servi par /api/openapi.json
+//
+//go:embed swagger/index.html
+var pageSwagger []byte
+
+// Scripts et feuille de style de Swagger UI (voir swagger/NOTICE), servis par l'API
+// pour que la documentation fonctionne sans accès à un CDN
+//
+//go:embed swagger/swagger-ui.css swagger/swagger-ui-bundle.js
+var ressourcesSwagger embed.FS
+
+// SwaggerUI sert l'interface de consultation du document OpenAPI
+func SwaggerUI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(pageSwagger)
+}
+
+// SwaggerRessource sert un fichier de Swagger UI intégré au binaire
+func SwaggerRessource(c *fiber.Ctx) error {
+	fichier := c.Params("fichier")
+	contenu, err := ressourcesSwagger.ReadFile("swagger/" + fichier)
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+	c.Type(path.Ext(fichier))
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	return c.Send(contenu)
+}
@@ -0,0 +1,198 @@
+package previsions
+
+import "math"
+
+// Grille de recherche des constantes de lissage : un premier passage au pas de 0,05,
+// puis un affinage au pas de 0,01 autour du meilleur point
+const (
+	pasGrossier = 0.05
+	pasFin      = 0.01
+)
+
+// HoltWinters ajuste un lissage exponentiel de Holt-Winters additif (niveau, tendance,
+// saison). Les constantes alpha, beta et gamma minimisent la somme des carrés des
+// erreurs de prévision à un mois. Sur moins de deux saisons d'historique, la
+// saisonnalité ne peut être estimée : le modèle de Holt (niveau et tendance) est ajusté.
+func HoltWinters(historique []float64, saison, horizon int) (*Resultat, error) {
+	n := len(historique)
+	if saison < 2 || n < 2*saison {
+		return holt(historique, horizon)
+	}
+
+	meilleur := math.Inf(1)
+	var alpha, beta, gamma float64
+	essayer := func(a, b, g float64) {
+		if sse, _ := lisserHW(historique, saison, a, b, g); sse < meilleur {
+			meilleur, alpha, beta, gamma = sse, a, b, g
+		}
+	}
+	for _, a := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+		for _, b := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+			for _, g := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+				essayer(a, b, g)
+			}
+		}
+	}
+	ca, cb, cg := alpha, beta, gamma
+	for _, a := range autour(ca) {
+		for _, b := range autour(cb) {
+			for _, g := range autour(cg) {
+				essayer(a, b, g)
+			}
+		}
+	}
+
+	_, etat := lisserHW(historique, saison, alpha, beta, gamma)
+	sigma := ecartResiduel(etat.residus, 3)
+
+	resultat := &Resultat{
+		Modele:     ModeleHoltWinters,
+		Parametres: map[string]float64{"alpha": alpha, "beta": beta, "gamma": gamma},
+	}
+	var cumul float64
+	for h := 1; h <= horizon; h++ {
+		resultat.Previsions = append(resultat.Previsions,
+			etat.niveau+float64(h)*etat.tendance+etat.saisons[len(etat.saisons)-saison+(h-1)%saison])
+		// Variance de l'erreur à h mois du modèle ETS(A,A,A) équivalent
+		// (Hyndman et al.), dont les constantes sont alpha, alpha*beta et (1-alpha)*gamma
+		resultat.Ecarts = append(resultat.Ecarts, sigma*math.Sqrt(1+cumul))
+		c := alpha + alpha*beta*float64(h)
+		if h%saison == 0 {
+			c += (1 - alpha) * gamma
+		}
+		cumul += c * c
+	}
+	positives(resultat.Previsions)
+	return resultat, nil
+}
+
+// etatLissage - composantes en fin d'historique et erreurs de prévision à un mois
+type etatLissage struct {
+	niveau   float64
+	tendance float64
+	saisons  []float64
+	residus  []float64
+}
+
+// lisserHW applique les récurrences de Holt-Winters additif et retourne la somme des
+// carrés des erreurs à un mois. Initialisation : niveau moyen de la première saison,
+// tendance moyenne entre les deux premières saisons, indices saisonniers écarts au niveau.
+func lisserHW(y []float64, m int, alpha, beta, gamma float64) (float64, etatLissage) {
+	var premiere, seconde float64
+	for i := 0; i < m; i++ {
+		premiere += y[i]
+		seconde += y[m+i]
+	}
+	premiere /= float64(m)
+	seconde /= float64(m)
+
+	etat := etatLissage{
+		niveau:   premiere,
+		tendance: (seconde - premiere) / float64(m),
+		saisons:  make([]float64, m, len(y)),
+		residus:  make([]float64, 0, len(y)-m),
+	}
+	for i := 0; i < m; i++ {
+		etat.saisons[i] = y[i] - premiere
+	}
+
+	var sse float64
+	for t := m; t < len(y); t++ {
+		s := etat.saisons[t-m]
+		erreur := y[t] - (etat.niveau + etat.tendance + s)
+		etat.residus = append(etat.residus, erreur)
+		sse += erreur * erreur
+
+		niveau := alpha*(y[t]-s) + (1-alpha)*(etat.niveau+etat.tendance)
+		tendance := beta*(niveau-etat.niveau) + (1-beta)*etat.tendance
+		etat.saisons = append(etat.saisons, gamma*(y[t]-etat.niveau-etat.tendance)+(1-gamma)*s)
+		etat.niveau, etat.tendance = niveau, tendance
+	}
+	return sse, etat
+}
+
+// holt ajuste le lissage exponentiel double de Holt (niveau et tendance)
+func holt(historique []float64, horizon int) (*Resultat, error) {
+	if len(historique) < 4 {
+		return nil, ErrHistoriqueInsuffisant
+	}
+
+	meilleur := math.Inf(1)
+	var alpha, beta float64
+	essayer := func(a, b float64) {
+		if sse, _ := lisserHolt(historique, a, b); sse < meilleur {
+			meilleur, alpha, beta = sse, a, b
+		}
+	}
+	for _, a := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+		for _, b := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+			essayer(a, b)
+		}
+	}
+	ca, cb := alpha, beta
+	for _, a := range autour(ca) {
+		for _, b := range autour(cb) {
+			essayer(a, b)
+		}
+	}
+
+	_, etat := lisserHolt(historique, alpha, beta)
+	sigma := ecartResiduel(etat.residus, 2)
+
+	resultat := &Resultat{
+		Modele:     ModeleHolt,
+		Parametres: map[string]float64{"alpha": alpha, "beta": beta},
+	}
+	var cumul float64
+	for h := 1; h <= horizon; h++ {
+		resultat.Previsions = append(resultat.Previsions, etat.niveau+float64(h)*etat.tendance)
+		resultat.Ecarts = append(resultat.Ecarts, sigma*math.Sqrt(1+cumul))
+		c := alpha + alpha*beta*float64(h)
+		cumul += c * c
+	}
+	positives(resultat.Previsions)
+	return resultat, nil
+}
+
+// lisserHolt applique les récurrences de Holt, initialisées sur les deux premiers mois ;
+// les erreurs sont comptées à partir du troisième
+func lisserHolt(y []float64, alpha, beta float64) (float64, etatLissage) {
+	etat := etatLissage{
+		niveau:   y[0],
+		tendance: y[1] - y[0],
+		residus:  make([]float64, 0, len(y)-2),
+	}
+
+	var sse float64
+	for t := 1; t < len(y); t++ {
+		erreur := y[t] - (etat.niveau + etat.tendance)
+		if t >= 2 {
+			etat.residus = append(etat.residus, erreur)
+			sse += erreur * erreur
+		}
+
+		niveau := alpha*y[t] + (1-alpha)*(etat.niveau+etat.tendance)
+		etat.tendance = beta*(niveau-etat.niveau) + (1-beta)*etat.tendance
+		etat.niveau = niveau
+	}
+	return sse, etat
+}
+
+// grille retourne les valeurs de debut à fin au pas donné
+func grille(debut, fin, pas float64) []float64 {
+	var valeurs []float64
+	for i := 0; ; i++ {
+		v := debut + float64(i)*pas
+		if v > fin+pas/2 {
+			break
+		}
+		valeurs = append(valeurs, math.Round(v*100)/100)
+	}
+	return valeurs
+}
+
+// autour retourne la grille fine autour d'une constante retenue au premier passage,
+// bornée à ]0, 1[
+func autour(centre float64) []float64 {
+	return grille(math.Max(pasFin, centre-pasGrossier+pasFin), math.Min(1-pasFin, centre+pasGrossier-pasFin), pasFin)
+}
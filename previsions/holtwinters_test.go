@@ -0,0 +1,155 @@
+package previsions
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+func egales(t *testing.T, nom string, obtenues, attendues []float64) {
+	t.Helper()
+	if len(obtenues) != len(attendues) {
+		t.Fatalf("%s : %d valeurs, %d attendues", nom, len(obtenues), len(attendues))
+	}
+	for i := range attendues {
+		if math.Abs(obtenues[i]-attendues[i]) > tolerance {
+			t.Errorf("%s[%d] = %v, attendu %v", nom, i, obtenues[i], attendues[i])
+		}
+	}
+}
+
+// Récurrences de Holt-Winters additif (Hyndman et Athanasopoulos, § 8.3) calculées
+// à la main sur deux saisons de quatre mois, alpha = beta = gamma = 0,5 :
+// niveau initial 20, tendance 1, indices -10, 0, 10, 0
+func TestLisserHW(t *testing.T) {
+	y := []float64{10, 20, 30, 20, 14, 24, 34, 24}
+	sse, etat := lisserHW(y, 4, 0.5, 0.5, 0.5)
+
+	if math.Abs(etat.niveau-3209.0/128) > tolerance || math.Abs(etat.tendance-179.0/256) > tolerance {
+		t.Errorf("niveau %v, tendance %v, attendus %v et %v", etat.niveau, etat.tendance, 3209.0/128, 179.0/256)
+	}
+	egales(t, "saisons", etat.saisons[4:], []float64{-8.5, -0.125, 291.0 / 32, -137.0 / 128})
+	egales(t, "residus", etat.residus, []float64{3, -0.25, -29.0 / 16, -137.0 / 64})
+	if math.Abs(sse-69345.0/4096) > tolerance {
+		t.Errorf("somme des carrés %v, attendue %v", sse, 69345.0/4096)
+	}
+}
+
+func TestHoltWinters(t *testing.T) {
+	motif := []float64{-6, -4, -1, 2, 5, 8, 9, 7, 3, 0, -3, -20}
+	saisonnier := make([]float64, 36)
+	for i := range saisonnier {
+		saisonnier[i] = 40 + motif[i%12]
+	}
+	tendance := make([]float64, 10)
+	for i := range tendance {
+		tendance[i] = 5 + 2*float64(i)
+	}
+
+	cas := []struct {
+		nom        string
+		historique []float64
+		saison     int
+		horizon    int
+		modele     string
+		previsions []float64
+		ecarts     []float64
+		erreur     error
+	}{
+		{
+			nom:        "constante",
+			historique: []float64{12, 12, 12, 12, 12, 12, 12, 12},
+			saison:     4,
+			horizon:    3,
+			modele:     ModeleHoltWinters,
+			previsions: []float64{12, 12, 12},
+			ecarts:     []float64{0, 0, 0},
+		},
+		{
+			// Saisonnalité exacte : l'initialisation la reproduit, les erreurs sont nulles
+			nom:        "saison exacte",
+			historique: saisonnier,
+			saison:     12,
+			horizon:    14,
+			modele:     ModeleHoltWinters,
+			previsions: []float64{34, 36, 39, 42, 45, 48, 49, 47, 43, 40, 37, 20, 34, 36},
+			ecarts:     make([]float64, 14),
+		},
+		{
+			// Moins de deux saisons : Holt prolonge la tendance
+			nom:        "holt sur historique court",
+			historique: tendance,
+			saison:     12,
+			horizon:    3,
+			modele:     ModeleHolt,
+			previsions: []float64{25, 27, 29},
+			ecarts:     []float64{0, 0, 0},
+		},
+		{
+			// La baisse prolongée ne donne pas de prévision négative
+			nom:        "tendance à la baisse",
+			historique: []float64{9, 6, 3, 0},
+			saison:     12,
+			horizon:    2,
+			modele:     ModeleHolt,
+			previsions: []float64{0, 0},
+			ecarts:     []float64{0, 0},
+		},
+		{
+			nom:        "historique insuffisant",
+			historique: []float64{3, 4, 5},
+			saison:     12,
+			horizon:    3,
+			erreur:     ErrHistoriqueInsuffisant,
+		},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			resultat, err := HoltWinters(tc.historique, tc.saison, tc.horizon)
+			if tc.erreur != nil {
+				if !errors.Is(err, tc.erreur) {
+					t.Fatalf("erreur %v, attendue %v", err, tc.erreur)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resultat.Modele != tc.modele {
+				t.Errorf("modèle %s, attendu %s", resultat.Modele, tc.modele)
+			}
+			egales(t, "previsions", resultat.Previsions, tc.previsions)
+			egales(t, "ecarts", resultat.Ecarts, tc.ecarts)
+		})
+	}
+}
+
+// Les constantes retenues sont celles de plus faible erreur à un mois sur la grille,
+// et l'écart à un mois est l'écart type résiduel du lissage avec ces constantes
+func TestHoltWintersConstantes(t *testing.T) {
+	y := []float64{10, 20, 30, 20, 14, 24, 34, 24, 15, 27, 36, 25, 18, 29, 40, 28}
+	resultat, err := HoltWinters(y, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, beta, gamma := resultat.Parametres["alpha"], resultat.Parametres["beta"], resultat.Parametres["gamma"]
+	retenue, etat := lisserHW(y, 4, alpha, beta, gamma)
+	for _, a := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+		for _, b := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+			for _, g := range grille(pasGrossier, 1-pasGrossier, pasGrossier) {
+				if sse, _ := lisserHW(y, 4, a, b, g); sse < retenue-tolerance {
+					t.Fatalf("(%v, %v, %v) : erreur %v inférieure à celle des constantes retenues %v", a, b, g, sse, retenue)
+				}
+			}
+		}
+	}
+	if sigma := ecartResiduel(etat.residus, 3); math.Abs(resultat.Ecarts[0]-sigma) > tolerance {
+		t.Errorf("écart à un mois %v, attendu %v", resultat.Ecarts[0], sigma)
+	}
+	attendue := etat.niveau + etat.tendance + etat.saisons[len(etat.saisons)-4]
+	if math.Abs(resultat.Previsions[0]-attendue) > tolerance {
+		t.Errorf("prévision %v, attendue %v", resultat.Previsions[0], attendue)
+	}
+}
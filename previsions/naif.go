@@ -0,0 +1,50 @@
+package previsions
+
+import "math"
+
+// NaifSaisonnier prévoit chaque mois par la valeur du même mois de la dernière saison
+// observée. Sur moins d'une saison d'historique, la dernière valeur est reconduite
+// (modèle naïf).
+func NaifSaisonnier(historique []float64, saison, horizon int) (*Resultat, error) {
+	n := len(historique)
+	if saison < 2 || n < saison+1 {
+		return naif(historique, horizon)
+	}
+
+	residus := make([]float64, 0, n-saison)
+	for t := saison; t < n; t++ {
+		residus = append(residus, historique[t]-historique[t-saison])
+	}
+	sigma := ecartResiduel(residus, 0)
+
+	resultat := &Resultat{Modele: ModeleNaifSaisonnier}
+	for h := 1; h <= horizon; h++ {
+		// Nombre de saisons complètes entre le dernier mois observé et le mois prévu
+		k := (h - 1) / saison
+		resultat.Previsions = append(resultat.Previsions, historique[n-saison+(h-1)%saison])
+		resultat.Ecarts = append(resultat.Ecarts, sigma*math.Sqrt(float64(k+1)))
+	}
+	positives(resultat.Previsions)
+	return resultat, nil
+}
+
+// naif reconduit la dernière valeur observée
+func naif(historique []float64, horizon int) (*Resultat, error) {
+	n := len(historique)
+	if n < 2 {
+		return nil, ErrHistoriqueInsuffisant
+	}
+
+	residus := make([]float64, 0, n-1)
+	for t := 1; t < n; t++ {
+		residus = append(residus, historique[t]-historique[t-1])
+	}
+	sigma := ecartResiduel(residus, 0)
+
+	resultat := &Resultat{Modele: ModeleNaif}
+	for h := 1; h <= horizon; h++ {
+		resultat.Previsions = append(resultat.Previsions, historique[n-1])
+		resultat.Ecarts = append(resultat.Ecarts, sigma*math.Sqrt(float64(h)))
+	}
+	return resultat, nil
+}
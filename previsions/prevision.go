@@ -0,0 +1,117 @@
+// Package previsions ajuste des modèles de prévision sur des séries mensuelles
+// (nombre d'enregistrements par mois) : Holt-Winters additif et naïf saisonnier,
+// avec intervalles de prévision et évaluation sur les derniers mois observés.
+package previsions
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrHistoriqueInsuffisant - la série est trop courte pour ajuster le modèle
+var ErrHistoriqueInsuffisant = errors.New("historique insuffisant")
+
+// Noms des modèles ; une série trop courte pour la saisonnalité est ajustée
+// par la variante sans saison (holt, naif)
+const (
+	ModeleHoltWinters    = "holt_winters"
+	ModeleHolt           = "holt"
+	ModeleNaifSaisonnier = "naif_saisonnier"
+	ModeleNaif           = "naif"
+)
+
+// Niveaux des intervalles de prévision (%) et quantiles de la loi normale associés
+var quantiles = map[int]float64{
+	80: 1.2816,
+	95: 1.9600,
+}
+
+// NiveauxIntervalle - niveaux des intervalles calculés, dans l'ordre de la réponse
+var NiveauxIntervalle = []int{80, 95}
+
+// Methode ajuste un modèle sur l'historique et prévoit les horizon valeurs suivantes
+type Methode func(historique []float64, saison, horizon int) (*Resultat, error)
+
+// Resultat - prévisions d'un modèle ajusté
+type Resultat struct {
+	Modele     string
+	Parametres map[string]float64
+	// Prévision ponctuelle de chaque mois de l'horizon
+	Previsions []float64
+	// Écart type de l'erreur de prévision de chaque mois de l'horizon
+	Ecarts []float64
+}
+
+// Intervalle retourne les bornes de l'intervalle de prévision au niveau donné
+// pour le mois h (0 pour le premier mois prévu). Les effectifs n'étant pas
+// négatifs, la borne inférieure est ramenée à 0.
+func (r *Resultat) Intervalle(h, niveau int) (float64, float64) {
+	marge := quantiles[niveau] * r.Ecarts[h]
+	return math.Max(0, r.Previsions[h]-marge), r.Previsions[h] + marge
+}
+
+// Metriques - erreurs d'un modèle sur les derniers mois de l'historique
+type Metriques struct {
+	// Erreur absolue moyenne en pourcentage, sur les mois dont la valeur observée n'est
+	// pas nulle ; nil si tous les mois évalués sont nuls
+	MAPE *float64
+	// Racine de l'erreur quadratique moyenne, en nombre d'enregistrements
+	RMSE float64
+	// Nombre de mois évalués
+	Points int
+}
+
+// Evaluer ajuste le modèle sans les test derniers mois, les prévoit et compare
+// les prévisions aux valeurs observées
+func Evaluer(methode Methode, historique []float64, saison, test int) (*Metriques, error) {
+	if test <= 0 || test >= len(historique) {
+		return nil, ErrHistoriqueInsuffisant
+	}
+	apprentissage := historique[:len(historique)-test]
+	observe := historique[len(historique)-test:]
+
+	resultat, err := methode(apprentissage, saison, test)
+	if err != nil {
+		return nil, err
+	}
+
+	var carres, pourcentages float64
+	nonNuls := 0
+	for i, valeur := range observe {
+		ecart := valeur - resultat.Previsions[i]
+		carres += ecart * ecart
+		if valeur != 0 {
+			pourcentages += math.Abs(ecart / valeur)
+			nonNuls++
+		}
+	}
+
+	metriques := &Metriques{RMSE: math.Sqrt(carres / float64(test)), Points: test}
+	if nonNuls > 0 {
+		mape := 100 * pourcentages / float64(nonNuls)
+		metriques.MAPE = &mape
+	}
+	return metriques, nil
+}
+
+// ecartResiduel estime l'écart type des erreurs de prévision à un mois ;
+// parametres est le nombre de paramètres ajustés, retiré des degrés de liberté
+func ecartResiduel(residus []float64, parametres int) float64 {
+	var carres float64
+	for _, e := range residus {
+		carres += e * e
+	}
+	degres := len(residus) - parametres
+	if degres < 1 {
+		degres = 1
+	}
+	return math.Sqrt(carres / float64(degres))
+}
+
+// positives ramène à 0 les prévisions négatives (tendance à la baisse prolongée)
+func positives(previsions []float64) []float64 {
+	for i, p := range previsions {
+		previsions[i] = math.Max(0, p)
+	}
+	return previsions
+}
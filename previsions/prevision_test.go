@@ -0,0 +1,128 @@
+package previsions
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNaifSaisonnier(t *testing.T) {
+	cas := []struct {
+		nom        string
+		historique []float64
+		saison     int
+		horizon    int
+		modele     string
+		previsions []float64
+		ecarts     []float64
+		erreur     error
+	}{
+		{
+			// Résidus saisonniers 1, 1, 1 : écart 1, élargi à chaque saison prévue
+			nom:        "saisonnier",
+			historique: []float64{4, 8, 2, 5, 9, 3},
+			saison:     3,
+			horizon:    4,
+			modele:     ModeleNaifSaisonnier,
+			previsions: []float64{5, 9, 3, 5},
+			ecarts:     []float64{math.Sqrt(3.0 / 3), math.Sqrt(3.0 / 3), math.Sqrt(3.0 / 3), math.Sqrt(2)},
+		},
+		{
+			// Moins d'une saison : la dernière valeur est reconduite
+			nom:        "naif",
+			historique: []float64{2, 4, 6},
+			saison:     12,
+			horizon:    2,
+			modele:     ModeleNaif,
+			previsions: []float64{6, 6},
+			ecarts:     []float64{math.Sqrt(8.0 / 2), math.Sqrt(8.0/2) * math.Sqrt(2)},
+		},
+		{
+			nom:        "historique insuffisant",
+			historique: []float64{7},
+			saison:     12,
+			horizon:    1,
+			erreur:     ErrHistoriqueInsuffisant,
+		},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			resultat, err := NaifSaisonnier(tc.historique, tc.saison, tc.horizon)
+			if tc.erreur != nil {
+				if !errors.Is(err, tc.erreur) {
+					t.Fatalf("erreur %v, attendue %v", err, tc.erreur)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resultat.Modele != tc.modele {
+				t.Errorf("modèle %s, attendu %s", resultat.Modele, tc.modele)
+			}
+			egales(t, "previsions", resultat.Previsions, tc.previsions)
+			egales(t, "ecarts", resultat.Ecarts, tc.ecarts)
+		})
+	}
+}
+
+func TestEvaluer(t *testing.T) {
+	cas := []struct {
+		nom        string
+		historique []float64
+		test       int
+		rmse       float64
+		// MAPE attendue, -1 si aucun mois évalué n'est non nul
+		mape   float64
+		erreur error
+	}{
+		// Naïf appris sur 1, 2 : prévoit 2, 2 pour 3, 4
+		{nom: "erreurs", historique: []float64{1, 2, 3, 4}, test: 2, rmse: math.Sqrt(2.5), mape: 100 * (1.0/3 + 2.0/4) / 2},
+		// Les mois nuls sont exclus de la MAPE, pas de la RMSE
+		{nom: "mois nul", historique: []float64{1, 2, 0, 4}, test: 2, rmse: 2, mape: 50},
+		{nom: "tous nuls", historique: []float64{1, 2, 0, 0}, test: 2, rmse: 2, mape: -1},
+		{nom: "sans apprentissage", historique: []float64{1, 2}, test: 2, erreur: ErrHistoriqueInsuffisant},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			metriques, err := Evaluer(NaifSaisonnier, tc.historique, 12, tc.test)
+			if tc.erreur != nil {
+				if !errors.Is(err, tc.erreur) {
+					t.Fatalf("erreur %v, attendue %v", err, tc.erreur)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(metriques.RMSE-tc.rmse) > tolerance || metriques.Points != tc.test {
+				t.Errorf("RMSE %v sur %d mois, attendue %v sur %d", metriques.RMSE, metriques.Points, tc.rmse, tc.test)
+			}
+			switch {
+			case tc.mape < 0 && metriques.MAPE != nil:
+				t.Errorf("MAPE %v, attendue absente", *metriques.MAPE)
+			case tc.mape >= 0 && (metriques.MAPE == nil || math.Abs(*metriques.MAPE-tc.mape) > tolerance):
+				t.Errorf("MAPE %v, attendue %v", metriques.MAPE, tc.mape)
+			}
+		})
+	}
+}
+
+func TestIntervalle(t *testing.T) {
+	r := &Resultat{Previsions: []float64{10, 1}, Ecarts: []float64{2, 2}}
+	cas := []struct {
+		h, niveau int
+		min, max  float64
+	}{
+		{0, 80, 10 - 1.2816*2, 10 + 1.2816*2},
+		{0, 95, 10 - 1.96*2, 10 + 1.96*2},
+		// Borne inférieure ramenée à 0
+		{1, 95, 0, 1 + 1.96*2},
+	}
+	for _, tc := range cas {
+		min, max := r.Intervalle(tc.h, tc.niveau)
+		if math.Abs(min-tc.min) > tolerance || math.Abs(max-tc.max) > tolerance {
+			t.Errorf("mois %d à %d %% : [%v, %v], attendu [%v, %v]", tc.h, tc.niveau, min, max, tc.min, tc.max)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+package problemes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Langues prises en charge ; le français est la langue par défaut
+const (
+	Francais = "fr"
+	Anglais  = "en"
+)
+
+type traduction struct {
+	fr string
+	en string
+}
+
+func (t traduction) dans(langue string) string {
+	if langue == Anglais {
+		return t.en
+	}
+	return t.fr
+}
+
+// Titres des codes d'erreur
+var titres = map[string]traduction{
+	CodeRequeteInvalide:       {"Requête invalide", "Bad request"},
+	CodeValidation:            {"Données invalides", "Validation failed"},
+	CodeRequeteListe:          {"Paramètres de liste invalides", "Invalid list parameters"},
+	CodeNonAuthentifie:        {"Authentification requise", "Authentication required"},
+	CodeAccesRefuse:           {"Accès refusé", "Access denied"},
+	CodeHorsPerimetre:         {"Hors du périmètre de l'unité", "Outside of your organisational unit"},
+	CodeIntrouvable:           {"Ressource introuvable", "Resource not found"},
+	CodeConflit:               {"Conflit", "Conflict"},
+	CodeDoublon:               {"Enregistrement déjà existant", "Duplicate record"},
+	CodeReference:             {"Enregistrement référencé", "Record is referenced"},
+	CodeVersionPerimee:        {"Version de l'enregistrement périmée", "Record version mismatch"},
+	CodePreconditionRequise:   {"Précondition requise", "Precondition required"},
+	CodeTypeNonSupporte:       {"Type de contenu non pris en charge", "Unsupported media type"},
+	CodeNonTraitable:          {"Requête non traitable", "Unprocessable request"},
+	CodeIdempotenceEnCours:    {"Requête idempotente en cours", "Idempotent request in progress"},
+	CodeIdempotenceReutilisee: {"Clé d'idempotence déjà utilisée", "Idempotency key reused"},
+	CodeTropDeRequetes:        {"Trop de requêtes", "Too many requests"},
+	CodeInterne:               {"Erreur interne du serveur", "Internal server error"},
+	CodeIndisponible:          {"Service indisponible", "Service unavailable"},
+}
+
+// Titre retourne le titre localisé d'un code d'erreur
+func Titre(code, langue string) string {
+	if t, ok := titres[code]; ok {
+		return t.dans(langue)
+	}
+	return titres[CodeInterne].dans(langue)
+}
+
+// Messages renvoyés par les handlers, indexés par leur texte d'origine. Un message absent
+// du catalogue est renvoyé tel quel.
+var messages = map[string]traduction{
+	// Requêtes
+	"Invalid request format":                                   {"Format de requête invalide", "Invalid request format"},
+	"Format de requête invalide":                               {"Format de requête invalide", "Invalid request format"},
+	"Invalid input":                                            {"Données invalides", "Invalid input"},
+	"Review your input":                                        {"Vérifiez les données saisies", "Review your input"},
+	"Erreurs de validation":                                    {"Erreurs de validation", "Validation errors"},
+	"Invalid cursor":                                           {"Curseur invalide", "Invalid cursor"},
+	"Curseur invalide pour ce tri":                             {"Curseur invalide pour ce tri", "Invalid cursor for this sort"},
+	"q query parameter is required":                            {"Le paramètre q est requis", "q query parameter is required"},
+	"q must contain at least one letter or digit":              {"Le paramètre q doit contenir au moins une lettre ou un chiffre", "q must contain at least one letter or digit"},
+	"identite_uuid query parameter is required":                {"Le paramètre identite_uuid est requis", "identite_uuid query parameter is required"},
+	"start_date et end_date sont requis (format: YYYY-MM-DD)":  {"start_date et end_date sont requis (format : AAAA-MM-JJ)", "start_date and end_date are required (format: YYYY-MM-DD)"},
+	"MigrantUUID is required":                                  {"MigrantUUID est requis", "MigrantUUID is required"},
+	"IdentiteUUID is required":                                 {"IdentiteUUID est requis", "IdentiteUUID is required"},
+	"appareil_id is required":                                  {"appareil_id est requis", "appareil_id is required"},
+	"Aucune alerte spécifiée":                                  {"Aucune alerte spécifiée", "No alert specified"},
+	"Nom de fichier requis":                                    {"Nom de fichier requis", "File name required"},
+	"Form not complete - nom, postnom and prenom are required": {"Formulaire incomplet : nom, postnom et prénom sont requis", "Form not complete - nom, postnom and prenom are required"},
+	"horizon must be an integer between 1 and 24":              {"horizon doit être un entier entre 1 et 24", "horizon must be an integer between 1 and 24"},
+	"historique must be an integer between 12 and 120":         {"historique doit être un entier entre 12 et 120", "historique must be an integer between 12 and 120"},
+	"invalid zoom level":                                       {"Niveau de zoom invalide", "Invalid zoom level"},
+	"invalid tile x coordinate":                                {"Coordonnée x de tuile invalide", "Invalid tile x coordinate"},
+	"invalid tile y coordinate":                                {"Coordonnée y de tuile invalide", "Invalid tile y coordinate"},
+	"unsupported tile format":                                  {"Format de tuile non pris en charge (json ou mvt)", "Unsupported tile format (json or mvt)"},
+	"saison must be an integer between 2 and 12":               {"saison doit être un entier entre 2 et 12", "saison must be an integer between 2 and 12"},
+	"latitude must be between -90 and 90 degrees":              {"La latitude doit être comprise entre -90 et 90 degrés", "latitude must be between -90 and 90 degrees"},
+	"longitude must be between -180 and 180 degrees":           {"La longitude doit être comprise entre -180 et 180 degrés", "longitude must be between -180 and 180 degrees"},
+
+	// Fichiers
+	"Unable to read uploaded file":                    {"Impossible de lire le fichier envoyé", "Unable to read uploaded file"},
+	"Unsupported file format":                         {"Format de fichier non pris en charge", "Unsupported file format"},
+	"Unsupported file format, expected .csv or .json": {"Format de fichier non pris en charge, .csv ou .json attendu", "Unsupported file format, expected .csv or .json"},
+	"Unable to parse watchlist file":                  {"Impossible de lire la liste de surveillance", "Unable to parse watchlist file"},
+	"Unable to parse import file":                     {"Impossible de lire le fichier d'import", "Unable to parse import file"},
+	"An .xlsx or .csv file is required":               {"Un fichier .xlsx ou .csv est requis", "An .xlsx or .csv file is required"},
+	"A CSV or JSON file is required":                  {"Un fichier CSV ou JSON est requis", "A CSV or JSON file is required"},
+	"Fichier non trouvé":                              {"Fichier introuvable", "File not found"},
+	"Erreur lors du scan":                             {"Erreur lors du scan", "Scan failed"},
+	"Erreur lors de la lecture du fichier":            {"Erreur lors de la lecture du fichier", "Unable to read scanned file"},
+	"Erreur lors de la récupération des scanners":     {"Erreur lors de la récupération des scanners", "Unable to list scanners"},
+
+	// Authentification
+	"unauthenticated":                            {"Authentification requise", "Unauthenticated"},
+	"invalid token":                              {"Jeton invalide", "Invalid token"},
+	"token has expired":                          {"Le jeton a expiré", "Token has expired"},
+	"Token invalide ou expiré":                   {"Jeton invalide ou expiré", "Invalid or expired token"},
+	"passwords do not match":                     {"Les mots de passe ne correspondent pas", "Passwords do not match"},
+	"password does not match":                    {"Le mot de passe ne correspond pas", "Password does not match"},
+	"mot de passe incorrect! 😰":                  {"Mot de passe incorrect", "Incorrect password"},
+	"votre mot de passe n'est pas correct! 😰":    {"Votre mot de passe n'est pas correct", "Your password is not correct"},
+	"vous n'êtes pas autorisé de se connecter 😰": {"Vous n'êtes pas autorisé à vous connecter", "You are not allowed to sign in"},
+	"invalid email or telephone 😰":               {"Email ou téléphone invalide", "Invalid email or telephone"},
+	"invalid email address 😰":                    {"Adresse email invalide", "Invalid email address"},
+	"email was not sent 😰":                       {"L'email n'a pas été envoyé", "Email was not sent"},
+
+	// Enregistrements introuvables
+	"Migrant not found":                                {"Migrant introuvable", "Migrant not found"},
+	"Case not found":                                   {"Dossier introuvable", "Case not found"},
+	"Organisational unit not found":                    {"Unité organisationnelle introuvable", "Organisational unit not found"},
+	"Watchlist not found":                              {"Liste de surveillance introuvable", "Watchlist not found"},
+	"Watchlist hit not found":                          {"Correspondance de liste de surveillance introuvable", "Watchlist hit not found"},
+	"Alert not found":                                  {"Alerte introuvable", "Alert not found"},
+	"Identite not found":                               {"Identité introuvable", "Identity not found"},
+	"Border post not found":                            {"Poste frontière introuvable", "Border post not found"},
+	"User not found":                                   {"Utilisateur introuvable", "User not found"},
+	"No User found":                                    {"Aucun utilisateur trouvé", "No user found"},
+	"Utilisateur non trouvé":                           {"Utilisateur introuvable", "User not found"},
+	"Motif de déplacement not found":                   {"Motif de déplacement introuvable", "Travel reason not found"},
+	"Geolocation not found":                            {"Géolocalisation introuvable", "Geolocation not found"},
+	"Duplicate candidate not found":                    {"Doublon potentiel introuvable", "Duplicate candidate not found"},
+	"Biometric data not found":                         {"Données biométriques introuvables", "Biometric data not found"},
+	"Sync conflict not found":                          {"Conflit de synchronisation introuvable", "Sync conflict not found"},
+	"Crossing not found":                               {"Passage introuvable", "Crossing not found"},
+	"Agent not found":                                  {"Agent introuvable", "Agent not found"},
+	"Relation not found":                               {"Relation introuvable", "Relation not found"},
+	"Note not found":                                   {"Note introuvable", "Note not found"},
+	"Merge not found":                                  {"Fusion introuvable", "Merge not found"},
+	"Import not found":                                 {"Import introuvable", "Import not found"},
+	"No active card for this migrant":                  {"Aucune carte active pour ce migrant", "No active card for this migrant"},
+	"No active card for this migrant, issue one first": {"Aucune carte active pour ce migrant, émettez-en une d'abord", "No active card for this migrant, issue one first"},
+
+	// Règles métier
+	"Une identité avec ce numéro de passeport existe déjà":                                    {"Une identité avec ce numéro de passeport existe déjà", "An identity with this passport number already exists"},
+	"Impossible de supprimer cette identité car elle est associée à un ou plusieurs migrants": {"Impossible de supprimer cette identité car elle est associée à un ou plusieurs migrants", "This identity cannot be deleted because it is linked to one or more migrants"},
+	"This record belongs to another organisational unit":                                      {"Cet enregistrement appartient à une autre unité organisationnelle", "This record belongs to another organisational unit"},
+	"The record was modified by another user":                                                 {"L'enregistrement a été modifié par un autre utilisateur", "The record was modified by another user"},
+	"If-Match header is required to modify this record":                                       {"L'en-tête If-Match est requis pour modifier cet enregistrement", "If-Match header is required to modify this record"},
+	"Invalid card":              {"Carte invalide", "Invalid card"},
+	"Watchlist is not active":   {"La liste de surveillance n'est pas active", "Watchlist is not active"},
+	"Border post is not active": {"Le poste frontière n'est pas actif", "Border post is not active"},
+	"Border post has recorded crossings; deactivate it instead":                   {"Le poste frontière a des passages enregistrés ; désactivez-le plutôt", "Border post has recorded crossings; deactivate it instead"},
+	"Organisational unit has sub-units, agents or records; deactivate it instead": {"L'unité a des sous-unités, des agents ou des enregistrements ; désactivez-la plutôt", "Organisational unit has sub-units, agents or records; deactivate it instead"},
+	"date_passage cannot be in the future":                                        {"date_passage ne peut pas être dans le futur", "date_passage cannot be in the future"},
+	"date_entretien is required to schedule an interview":                         {"date_entretien est requise pour planifier un entretien", "date_entretien is required to schedule an interview"},
+	"An open case of this type already exists for this migrant":                   {"Un dossier ouvert de ce type existe déjà pour ce migrant", "An open case of this type already exists for this migrant"},
+	"Agent is not assigned to this case":                                          {"L'agent n'est pas affecté à ce dossier", "Agent is not assigned to this case"},
+	"Agent is already assigned to this case":                                      {"L'agent est déjà affecté à ce dossier", "Agent is already assigned to this case"},
+	"Only rejected cases can be appealed":                                         {"Seuls les dossiers rejetés peuvent faire l'objet d'un recours", "Only rejected cases can be appealed"},
+	"The appeal deadline has passed":                                              {"Le délai de recours est dépassé", "The appeal deadline has passed"},
+	"A migrant cannot be related to themselves":                                   {"Un migrant ne peut pas être lié à lui-même", "A migrant cannot be related to themselves"},
+	"Only pending hits can be reviewed":                                           {"Seules les correspondances en attente peuvent être examinées", "Only pending hits can be reviewed"},
+	"Only pending conflicts can be resolved":                                      {"Seuls les conflits en attente peuvent être résolus", "Only pending conflicts can be resolved"},
+	"Only pending candidates can be rejected":                                     {"Seuls les doublons en attente peuvent être rejetés", "Only pending candidates can be rejected"},
+	"Only pending candidates can be merged":                                       {"Seuls les doublons en attente peuvent être fusionnés", "Only pending candidates can be merged"},
+	"survivante_uuid must be one of the two identities of the pair":               {"survivante_uuid doit être l'une des deux identités de la paire", "survivante_uuid must be one of the two identities of the pair"},
+	"This merge has already been undone":                                          {"Cette fusion a déjà été annulée", "This merge has already been undone"},
+	"The existing identity is no longer available":                                {"L'identité existante n'est plus disponible", "The existing identity is no longer available"},
+	"Only duplicate passport conflicts can be linked to the existing identity":    {"Seuls les conflits de passeport en double peuvent être rattachés à l'identité existante", "Only duplicate passport conflicts can be linked to the existing identity"},
+	"Only concurrent modification conflicts can be applied":                       {"Seuls les conflits de modification concurrente peuvent être appliqués", "Only concurrent modification conflicts can be applied"},
+	"The tablet version cannot be applied":                                        {"La version de la tablette ne peut pas être appliquée", "The tablet version cannot be applied"},
+
+	"Content-Type must be application/merge-patch+json": {"Le Content-Type doit être application/merge-patch+json", "Content-Type must be application/merge-patch+json"},
+
+	// Échecs de lecture
+	"Failed to fetch alerts":                     {"Impossible de récupérer les alertes", "Failed to fetch alerts"},
+	"Failed to fetch alerts for migrant":         {"Impossible de récupérer les alertes du migrant", "Failed to fetch alerts for migrant"},
+	"Failed to fetch biometric data":             {"Impossible de récupérer les données biométriques", "Failed to fetch biometric data"},
+	"Failed to fetch biometric data for migrant": {"Impossible de récupérer les données biométriques du migrant", "Failed to fetch biometric data for migrant"},
+	"Failed to fetch border posts":               {"Impossible de récupérer les postes frontières", "Failed to fetch border posts"},
+	"Failed to fetch cases":                      {"Impossible de récupérer les dossiers", "Failed to fetch cases"},
+	"Failed to fetch changes":                    {"Impossible de récupérer les modifications", "Failed to fetch changes"},
+	"Failed to fetch cross-unit access log":      {"Impossible de récupérer le journal des accès hors périmètre", "Failed to fetch cross-unit access log"},
+	"Failed to fetch crossings":                  {"Impossible de récupérer les passages", "Failed to fetch crossings"},
+	"Failed to fetch deadlines":                  {"Impossible de récupérer les échéances", "Failed to fetch deadlines"},
+	"Failed to fetch duplicate candidates":       {"Impossible de récupérer les doublons potentiels", "Failed to fetch duplicate candidates"},
+	"Failed to fetch expiring identites":         {"Impossible de récupérer les identités dont les documents expirent", "Failed to fetch expiring identites"},
+	"Failed to fetch geolocations":               {"Impossible de récupérer les géolocalisations", "Failed to fetch geolocations"},
+	"Failed to fetch geolocations for migrant":   {"Impossible de récupérer les géolocalisations du migrant", "Failed to fetch geolocations for migrant"},
+	"Failed to fetch identites":                  {"Impossible de récupérer les identités", "Failed to fetch identites"},
+	"Failed to fetch import errors":              {"Impossible de récupérer les erreurs de l'import", "Failed to fetch import errors"},
+	"Failed to fetch imports":                    {"Impossible de récupérer les imports", "Failed to fetch imports"},
+	"Failed to fetch merges":                     {"Impossible de récupérer les fusions", "Failed to fetch merges"},
+	"Failed to fetch Migrants":                   {"Impossible de récupérer les migrants", "Failed to fetch Migrants"},
+	"Failed to fetch migrants":                   {"Impossible de récupérer les migrants", "Failed to fetch migrants"},
+	"Failed to fetch motifs":                     {"Impossible de récupérer les motifs de déplacement", "Failed to fetch motifs"},
+	"Failed to fetch motifs de déplacement":      {"Impossible de récupérer les motifs de déplacement", "Failed to fetch motifs de déplacement"},
+	"Failed to fetch organisational units":       {"Impossible de récupérer les unités organisationnelles", "Failed to fetch organisational units"},
+	"Failed to fetch registrations":              {"Impossible de récupérer les enregistrements", "Failed to fetch registrations"},
+	"Failed to fetch relations":                  {"Impossible de récupérer les relations", "Failed to fetch relations"},
+	"Failed to fetch status history":             {"Impossible de récupérer l'historique des statuts", "Failed to fetch status history"},
+	"Failed to fetch sync conflicts":             {"Impossible de récupérer les conflits de synchronisation", "Failed to fetch sync conflicts"},
+	"Failed to fetch Users":                      {"Impossible de récupérer les utilisateurs", "Failed to fetch Users"},
+	"Failed to fetch users":                      {"Impossible de récupérer les utilisateurs", "Failed to fetch users"},
+	"Failed to fetch watchlist entries":          {"Impossible de récupérer les entrées de la liste de surveillance", "Failed to fetch watchlist entries"},
+	"Failed to fetch watchlist hits":             {"Impossible de récupérer les correspondances des listes de surveillance", "Failed to fetch watchlist hits"},
+	"Failed to fetch watchlists":                 {"Impossible de récupérer les listes de surveillance", "Failed to fetch watchlists"},
+	"Failed to load household":                   {"Impossible de charger le ménage", "Failed to load household"},
+	"Failed to search persons":                   {"Impossible de rechercher les personnes", "Failed to search persons"},
+	"Failed to check relation consistency":       {"Impossible de vérifier la cohérence des relations", "Failed to check relation consistency"},
+	"Failed to compute daily flows":              {"Impossible de calculer les flux journaliers", "Failed to compute daily flows"},
+	"Failed to build cluster tile":               {"Impossible de construire la tuile de regroupement", "Failed to build cluster tile"},
+
+	// Échecs d'écriture
+	"Cannot create identite":                            {"Impossible de créer l'identité", "Cannot create identite"},
+	"Cannot update identite":                            {"Impossible de modifier l'identité", "Cannot update identite"},
+	"Cannot delete identite":                            {"Impossible de supprimer l'identité", "Cannot delete identite"},
+	"Failed to create alert":                            {"Impossible de créer l'alerte", "Failed to create alert"},
+	"Failed to update alert":                            {"Impossible de modifier l'alerte", "Failed to update alert"},
+	"Failed to resolve alert":                           {"Impossible de résoudre l'alerte", "Failed to resolve alert"},
+	"Failed to delete alert":                            {"Impossible de supprimer l'alerte", "Failed to delete alert"},
+	"Failed to create biometric data":                   {"Impossible d'enregistrer les données biométriques", "Failed to create biometric data"},
+	"Failed to update biometric data":                   {"Impossible de modifier les données biométriques", "Failed to update biometric data"},
+	"Failed to delete biometric data":                   {"Impossible de supprimer les données biométriques", "Failed to delete biometric data"},
+	"Failed to encrypt biometric data":                  {"Impossible de chiffrer les données biométriques", "Failed to encrypt biometric data"},
+	"Failed to create border post":                      {"Impossible de créer le poste frontière", "Failed to create border post"},
+	"Failed to update border post":                      {"Impossible de modifier le poste frontière", "Failed to update border post"},
+	"Failed to delete border post":                      {"Impossible de supprimer le poste frontière", "Failed to delete border post"},
+	"Failed to record crossing":                         {"Impossible d'enregistrer le passage", "Failed to record crossing"},
+	"Failed to delete crossing":                         {"Impossible de supprimer le passage", "Failed to delete crossing"},
+	"Failed to create case":                             {"Impossible de créer le dossier", "Failed to create case"},
+	"Failed to update case":                             {"Impossible de modifier le dossier", "Failed to update case"},
+	"Failed to update case stage":                       {"Impossible de modifier l'étape du dossier", "Failed to update case stage"},
+	"Failed to delete case":                             {"Impossible de supprimer le dossier", "Failed to delete case"},
+	"Failed to record decision":                         {"Impossible d'enregistrer la décision", "Failed to record decision"},
+	"Failed to assign agent":                            {"Impossible d'affecter l'agent", "Failed to assign agent"},
+	"Failed to unassign agent":                          {"Impossible de retirer l'agent", "Failed to unassign agent"},
+	"Failed to add note":                                {"Impossible d'ajouter la note", "Failed to add note"},
+	"Failed to delete note":                             {"Impossible de supprimer la note", "Failed to delete note"},
+	"Failed to create geolocation":                      {"Impossible de créer la géolocalisation", "Failed to create geolocation"},
+	"Failed to update geolocation":                      {"Impossible de modifier la géolocalisation", "Failed to update geolocation"},
+	"Failed to delete geolocation":                      {"Impossible de supprimer la géolocalisation", "Failed to delete geolocation"},
+	"Failed to create migrant":                          {"Impossible de créer le migrant", "Failed to create migrant"},
+	"Failed to update migrant":                          {"Impossible de modifier le migrant", "Failed to update migrant"},
+	"Failed to delete migrant":                          {"Impossible de supprimer le migrant", "Failed to delete migrant"},
+	"Failed to create motif de déplacement":             {"Impossible de créer le motif de déplacement", "Failed to create motif de déplacement"},
+	"Failed to update motif de déplacement":             {"Impossible de modifier le motif de déplacement", "Failed to update motif de déplacement"},
+	"Failed to delete motif de déplacement":             {"Impossible de supprimer le motif de déplacement", "Failed to delete motif de déplacement"},
+	"Failed to create organisational unit":              {"Impossible de créer l'unité organisationnelle", "Failed to create organisational unit"},
+	"Failed to update organisational unit":              {"Impossible de modifier l'unité organisationnelle", "Failed to update organisational unit"},
+	"Failed to delete organisational unit":              {"Impossible de supprimer l'unité organisationnelle", "Failed to delete organisational unit"},
+	"Failed to create relation":                         {"Impossible de créer la relation", "Failed to create relation"},
+	"Failed to delete relation":                         {"Impossible de supprimer la relation", "Failed to delete relation"},
+	"Failed to create user":                             {"Impossible de créer l'utilisateur", "Failed to create user"},
+	"Failed to update user":                             {"Impossible de modifier l'utilisateur", "Failed to update user"},
+	"Erreur lors de la création de l'utilisateur admin": {"Erreur lors de la création de l'utilisateur admin", "Failed to create the admin user"},
+	"Failed to import watchlist":                        {"Impossible d'importer la liste de surveillance", "Failed to import watchlist"},
+	"Failed to update watchlist":                        {"Impossible de modifier la liste de surveillance", "Failed to update watchlist"},
+	"Failed to delete watchlist":                        {"Impossible de supprimer la liste de surveillance", "Failed to delete watchlist"},
+	"Failed to review watchlist hit":                    {"Impossible d'examiner la correspondance", "Failed to review watchlist hit"},
+	"Failed to create import job":                       {"Impossible de créer l'import", "Failed to create import job"},
+	"Failed to resolve sync conflict":                   {"Impossible de résoudre le conflit de synchronisation", "Failed to resolve sync conflict"},
+
+	// Traitements
+	"Failed to evaluate document validity": {"Impossible d'évaluer la validité des documents", "Failed to evaluate document validity"},
+	"Failed to screen identities":          {"Impossible de cribler les identités", "Failed to screen identities"},
+	"Failed to scan for duplicates":        {"Impossible de rechercher les doublons", "Failed to scan for duplicates"},
+	"Failed to reject duplicate candidate": {"Impossible de rejeter le doublon potentiel", "Failed to reject duplicate candidate"},
+	"Failed to merge identities":           {"Impossible de fusionner les identités", "Failed to merge identities"},
+	"Failed to undo merge":                 {"Impossible d'annuler la fusion", "Failed to undo merge"},
+	"Corrupted merge record":               {"Enregistrement de fusion corrompu", "Corrupted merge record"},
+	"Failed to issue card":                 {"Impossible d'émettre la carte", "Failed to issue card"},
+	"Failed to revoke card":                {"Impossible de révoquer la carte", "Failed to revoke card"},
+	"Failed to sign card":                  {"Impossible de signer la carte", "Failed to sign card"},
+	"Card signing key unavailable":         {"Clé de signature des cartes indisponible", "Card signing key unavailable"},
+	"Failed to generate QR code":           {"Impossible de générer le code QR", "Failed to generate QR code"},
+
+	// Exports
+	"Failed to fetch alerts for export":             {"Impossible de récupérer les alertes à exporter", "Failed to fetch alerts for export"},
+	"Failed to fetch biometrics for export":         {"Impossible de récupérer les données biométriques à exporter", "Failed to fetch biometrics for export"},
+	"Failed to fetch cases for export":              {"Impossible de récupérer les dossiers à exporter", "Failed to fetch cases for export"},
+	"Failed to fetch expiring identites for export": {"Impossible de récupérer les identités expirantes à exporter", "Failed to fetch expiring identites for export"},
+	"Failed to fetch geolocations for export":       {"Impossible de récupérer les géolocalisations à exporter", "Failed to fetch geolocations for export"},
+	"Failed to fetch identites for export":          {"Impossible de récupérer les identités à exporter", "Failed to fetch identites for export"},
+	"Failed to fetch migrants for export":           {"Impossible de récupérer les migrants à exporter", "Failed to fetch migrants for export"},
+	"Failed to fetch motifs for export":             {"Impossible de récupérer les motifs à exporter", "Failed to fetch motifs for export"},
+	"Failed to create Excel sheet":                  {"Impossible de créer la feuille Excel", "Failed to create Excel sheet"},
+	"Failed to generate Excel file":                 {"Impossible de générer le fichier Excel", "Failed to generate Excel file"},
+	"Failed to create header style":                 {"Impossible de créer le style d'en-tête", "Failed to create header style"},
+	"Failed to create column header style":          {"Impossible de créer le style des en-têtes de colonnes", "Failed to create column header style"},
+	"Failed to create data style":                   {"Impossible de créer le style des données", "Failed to create data style"},
+	"Failed to create date style":                   {"Impossible de créer le style des dates", "Failed to create date style"},
+	"Failed to create number style":                 {"Impossible de créer le style des nombres", "Failed to create number style"},
+	"Failed to create boolean style":                {"Impossible de créer le style des booléens", "Failed to create boolean style"},
+	"Failed to create score style":                  {"Impossible de créer le style des scores", "Failed to create score style"},
+	"Failed to create active status style":          {"Impossible de créer le style du statut actif", "Failed to create active status style"},
+	"Failed to create info style":                   {"Impossible de créer le style d'information", "Failed to create info style"},
+	"Failed to create warning style":                {"Impossible de créer le style d'avertissement", "Failed to create warning style"},
+	"Failed to create danger style":                 {"Impossible de créer le style de danger", "Failed to create danger style"},
+	"Failed to create critical style":               {"Impossible de créer le style critique", "Failed to create critical style"},
+
+	// Idempotence
+	"Failed to check Idempotency-Key":                              {"Impossible de vérifier l'Idempotency-Key", "Failed to check Idempotency-Key"},
+	"Idempotency-Key must not exceed 255 characters":               {"Idempotency-Key ne doit pas dépasser 255 caractères", "Idempotency-Key must not exceed 255 characters"},
+	"Idempotency-Key already used with a different payload":        {"Idempotency-Key déjà utilisée avec un autre contenu", "Idempotency-Key already used with a different payload"},
+	"A request with this Idempotency-Key is still being processed": {"Une requête avec cette Idempotency-Key est encore en cours", "A request with this Idempotency-Key is still being processed"},
+}
+
+// Traduire retourne un message du catalogue dans la langue demandée
+func Traduire(message, langue string) string {
+	if t, ok := messages[message]; ok {
+		return t.dans(langue)
+	}
+	return message
+}
+
+// Messages des règles de validation (tags validator)
+var regles = map[string]traduction{
+	"required":  {"Ce champ est requis", "This field is required"},
+	"email":     {"Adresse email invalide", "Invalid email address"},
+	"uuid":      {"UUID invalide", "Invalid UUID"},
+	"latitude":  {"Latitude invalide (entre -90 et 90)", "Invalid latitude (between -90 and 90)"},
+	"longitude": {"Longitude invalide (entre -180 et 180)", "Invalid longitude (between -180 and 180)"},
+	"oneof":     {"Valeurs acceptées : %s", "Allowed values: %s"},
+	"min":       {"Valeur minimale : %s", "Minimum value: %s"},
+	"max":       {"Valeur maximale : %s", "Maximum value: %s"},
+	"len":       {"Longueur attendue : %s", "Expected length: %s"},
+	"gte":       {"Doit être supérieur ou égal à %s", "Must be greater than or equal to %s"},
+	"lte":       {"Doit être inférieur ou égal à %s", "Must be less than or equal to %s"},
+	"gt":        {"Doit être supérieur à %s", "Must be greater than %s"},
+	"lt":        {"Doit être inférieur à %s", "Must be less than %s"},
+}
+
+// Regle retourne le message localisé d'une règle de validation non respectée
+func Regle(regle, param, langue string) string {
+	t, ok := regles[regle]
+	if !ok {
+		if langue == Anglais {
+			return fmt.Sprintf("Validation rule %q failed", regle)
+		}
+		return fmt.Sprintf("Règle de validation %q non respectée", regle)
+	}
+	if !strings.Contains(t.dans(langue), "%s") {
+		return t.dans(langue)
+	}
+	if regle == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return fmt.Sprintf(t.dans(langue), param)
+}
+
+// Langue retourne la langue préférée du client parmi celles prises en charge, d'après
+// Accept-Language (ex. "en-US,en;q=0.9,fr;q=0.8") ; le français par défaut
+func Langue(c *fiber.Ctx) string {
+	langue, meilleure := Francais, 0.0
+	for _, morceau := range strings.Split(c.Get(fiber.HeaderAcceptLanguage), ",") {
+		etiquette, parametres, _ := strings.Cut(strings.TrimSpace(morceau), ";")
+		qualite := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(parametres), "q="); ok {
+			valeur, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			qualite = valeur
+		}
+		principale, _, _ := strings.Cut(strings.ToLower(etiquette), "-")
+		if (principale == Francais || principale == Anglais) && qualite > meilleure {
+			langue, meilleure = principale, qualite
+		}
+	}
+	return langue
+}
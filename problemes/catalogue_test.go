@@ -0,0 +1,94 @@
+package problemes
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Position du détail dans les constructeurs de problèmes
+var argumentDetail = map[string]int{
+	"Introuvable": 0,
+	"Invalide":    0,
+	"Interne":     0,
+	"Echec":       0,
+	"Absent":      0,
+	"Nouveau":     2,
+}
+
+// detailsLitteraux retourne les détails écrits en dur dans les appels aux
+// constructeurs de problèmes, avec leur position
+func detailsLitteraux(t *testing.T, racines ...string) map[string]string {
+	t.Helper()
+	details := map[string]string{}
+	fichiers := token.NewFileSet()
+	for _, racine := range racines {
+		err := filepath.WalkDir(racine, func(chemin string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(chemin, ".go") || strings.HasSuffix(chemin, "_test.go") {
+				return err
+			}
+			fichier, err := parser.ParseFile(fichiers, chemin, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(fichier, func(n ast.Node) bool {
+				appel, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				selecteur, ok := appel.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				paquet, ok := selecteur.X.(*ast.Ident)
+				if !ok || paquet.Name != "problemes" {
+					return true
+				}
+				position, ok := argumentDetail[selecteur.Sel.Name]
+				if !ok || len(appel.Args) <= position {
+					return true
+				}
+				// Un détail calculé (variable, message d'un import) n'est pas vérifiable ici
+				litteral, ok := appel.Args[position].(*ast.BasicLit)
+				if !ok || litteral.Kind != token.STRING {
+					return true
+				}
+				detail, err := strconv.Unquote(litteral.Value)
+				if err == nil && detail != "" {
+					details[detail] = fichiers.Position(litteral.Pos()).String()
+				}
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return details
+}
+
+// Chaque détail renvoyé par les handlers et les middlewares est traduit
+func TestCatalogueComplet(t *testing.T) {
+	details := detailsLitteraux(t, "../controllers", "../middlewares", "../routes", "../depots")
+	if len(details) == 0 {
+		t.Fatal("aucun détail trouvé dans les handlers")
+	}
+
+	var manquants []string
+	for detail, position := range details {
+		if _, ok := messages[detail]; !ok {
+			manquants = append(manquants, position+" : "+strconv.Quote(detail))
+		}
+	}
+	sort.Strings(manquants)
+	if len(manquants) > 0 {
+		t.Errorf("%d détail(s) absent(s) du catalogue :\n%s", len(manquants), strings.Join(manquants, "\n"))
+	}
+}
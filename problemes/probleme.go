@@ -0,0 +1,279 @@
+package problemes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/utils"
+	"gorm.io/gorm"
+)
+
+// TypeContenu des réponses d'erreur (RFC 7807)
+const TypeContenu = "application/problem+json"
+
+// Codes d'erreur stables, exploitables par les clients quelle que soit la langue
+const (
+	CodeRequeteInvalide       = "BAD_REQUEST"
+	CodeValidation            = "VALIDATION_FAILED"
+	CodeRequeteListe          = "INVALID_QUERY"
+	CodeNonAuthentifie        = "UNAUTHENTICATED"
+	CodeAccesRefuse           = "FORBIDDEN"
+	CodeHorsPerimetre         = "OUT_OF_SCOPE"
+	CodeIntrouvable           = "NOT_FOUND"
+	CodeConflit               = "CONFLICT"
+	CodeDoublon               = "DUPLICATE"
+	CodeReference             = "REFERENCED"
+	CodeVersionPerimee        = "PRECONDITION_FAILED"
+	CodePreconditionRequise   = "PRECONDITION_REQUIRED"
+	CodeTypeNonSupporte       = "UNSUPPORTED_MEDIA_TYPE"
+	CodeNonTraitable          = "UNPROCESSABLE_ENTITY"
+	CodeIdempotenceEnCours    = "IDEMPOTENCY_IN_PROGRESS"
+	CodeIdempotenceReutilisee = "IDEMPOTENCY_KEY_REUSED"
+	CodeTropDeRequetes        = "TOO_MANY_REQUESTS"
+	CodeInterne               = "INTERNAL_ERROR"
+	CodeIndisponible          = "SERVICE_UNAVAILABLE"
+)
+
+// Champ - erreur de validation d'un champ du corps ou de la requête
+type Champ struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Probleme - erreur renvoyée au format application/problem+json. Le détail est un
+// message du catalogue (traduit selon Accept-Language) ou un texte libre.
+type Probleme struct {
+	Status     int
+	Code       string
+	Detail     string
+	Errors     []Champ
+	Extensions map[string]interface{}
+	// cause est journalisée côté serveur, jamais renvoyée au client
+	cause error
+}
+
+func (p *Probleme) Error() string {
+	message := p.Code
+	if p.Detail != "" {
+		message += ": " + p.Detail
+	}
+	if p.cause != nil {
+		message += ": " + p.cause.Error()
+	}
+	return message
+}
+
+func (p *Probleme) Unwrap() error {
+	return p.cause
+}
+
+// Nouveau crée un problème ; detail peut être vide, le titre du code sert alors de détail
+func Nouveau(status int, code, detail string) *Probleme {
+	return &Probleme{Status: status, Code: code, Detail: detail}
+}
+
+// Avec ajoute un membre d'extension à la réponse (version courante, données, ...)
+func (p *Probleme) Avec(cle string, valeur interface{}) *Probleme {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[cle] = valeur
+	return p
+}
+
+// Cause rattache l'erreur d'origine, journalisée mais non exposée
+func (p *Probleme) Cause(err error) *Probleme {
+	p.cause = err
+	return p
+}
+
+// Introuvable - 404
+func Introuvable(detail string) *Probleme {
+	return Nouveau(fiber.StatusNotFound, CodeIntrouvable, detail)
+}
+
+// Invalide - 400 d'une requête mal formée
+func Invalide(detail string) *Probleme {
+	return Nouveau(fiber.StatusBadRequest, CodeRequeteInvalide, detail)
+}
+
+// Interne - 500, l'erreur d'origine est journalisée
+func Interne(detail string, err error) *Probleme {
+	return Nouveau(fiber.StatusInternalServerError, CodeInterne, detail).Cause(err)
+}
+
+// Validation - 400 avec le détail des champs refusés par utils.ValidateStruct
+func Validation(erreurs []*utils.ErrorResponse) *Probleme {
+	p := Nouveau(fiber.StatusBadRequest, CodeValidation, "Erreurs de validation")
+	for _, err := range erreurs {
+		champ := err.Field
+		if champ == "" {
+			champ = err.FailedField
+		}
+		p.Errors = append(p.Errors, Champ{Field: champ, Rule: err.Tag, Param: err.Value})
+	}
+	return p
+}
+
+// Messages - 400 avec des erreurs de validation déjà rédigées
+func Messages(code string, messages []string) *Probleme {
+	p := Nouveau(fiber.StatusBadRequest, code, "Erreurs de validation")
+	for _, message := range messages {
+		p.Errors = append(p.Errors, Champ{Message: message})
+	}
+	return p
+}
+
+// Envoyer écrit le problème dans la langue demandée par le client
+func Envoyer(c *fiber.Ctx, p *Probleme) error {
+	if p.cause != nil || p.Status >= 500 {
+		log.Printf("❌ %s %s: %v", c.Method(), c.Path(), p)
+	}
+	corps, err := json.Marshal(p.localiser(Langue(c), c.Path()))
+	if err != nil {
+		return err
+	}
+	c.Status(p.Status)
+	c.Set(fiber.HeaderContentType, TypeContenu)
+	return c.Send(corps)
+}
+
+// localiser construit le document RFC 7807 dans la langue donnée
+func (p *Probleme) localiser(langue, instance string) map[string]interface{} {
+	document := map[string]interface{}{}
+	for cle, valeur := range p.Extensions {
+		document[cle] = valeur
+	}
+
+	titre := Titre(p.Code, langue)
+	detail := titre
+	if p.Detail != "" {
+		detail = Traduire(p.Detail, langue)
+	}
+	document["type"] = "urn:sysmobembo:problem:" + strings.ToLower(strings.ReplaceAll(p.Code, "_", "-"))
+	document["title"] = titre
+	document["status"] = p.Status
+	document["detail"] = detail
+	document["instance"] = instance
+	document["code"] = p.Code
+	// Conservé pour les clients qui lisent encore le champ message de l'ancienne enveloppe
+	document["message"] = detail
+
+	if len(p.Errors) > 0 {
+		champs := make([]Champ, len(p.Errors))
+		for i, champ := range p.Errors {
+			if champ.Message == "" {
+				champ.Message = Regle(champ.Rule, champ.Param, langue)
+			} else {
+				champ.Message = Traduire(champ.Message, langue)
+			}
+			champs[i] = champ
+		}
+		document["errors"] = champs
+	}
+	return document
+}
+
+// etatSQL est implémentée par les erreurs du pilote Postgres (pgconn.PgError)
+type etatSQL interface {
+	SQLState() string
+}
+
+// Depuis convertit une erreur quelconque en problème : erreurs Fiber, enregistrement
+// introuvable, violations de contraintes Postgres ; le reste devient une erreur interne.
+func Depuis(err error) *Probleme {
+	var p *Probleme
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var erreurFiber *fiber.Error
+	if errors.As(err, &erreurFiber) {
+		return Nouveau(erreurFiber.Code, CodeStatut(erreurFiber.Code), erreurFiber.Message)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Introuvable("").Cause(err)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return Nouveau(fiber.StatusConflict, CodeDoublon, "").Cause(err)
+	}
+
+	var etat etatSQL
+	if errors.As(err, &etat) {
+		switch etat.SQLState() {
+		case "23505":
+			return Nouveau(fiber.StatusConflict, CodeDoublon, "").Cause(err)
+		case "23503":
+			return Nouveau(fiber.StatusConflict, CodeReference, "").Cause(err)
+		case "23502", "23514", "22P02", "22001", "22007", "22008":
+			return Nouveau(fiber.StatusBadRequest, CodeValidation, "").Cause(err)
+		}
+	}
+
+	return Nouveau(fiber.StatusInternalServerError, CodeInterne, "").Cause(err)
+}
+
+// Echec convertit l'erreur d'une opération avec Depuis ; une erreur interne prend
+// le détail donné ("Failed to update alert"), jamais le texte de l'erreur
+func Echec(detail string, err error) *Probleme {
+	p := Depuis(err)
+	if p.Status == fiber.StatusInternalServerError && p.Detail == "" {
+		p.Detail = detail
+	}
+	return p
+}
+
+// Absent convertit l'erreur de lecture d'un enregistrement avec Depuis ; un
+// enregistrement introuvable prend le détail donné ("Alert not found")
+func Absent(detail string, err error) *Probleme {
+	p := Depuis(err)
+	if p.Status == fiber.StatusNotFound && p.Detail == "" {
+		p.Detail = detail
+	}
+	return p
+}
+
+// Gestionnaire - ErrorHandler de l'application : toute erreur retournée par un
+// handler ou un middleware est renvoyée au format problem+json
+func Gestionnaire(c *fiber.Ctx, err error) error {
+	return Envoyer(c, Depuis(err))
+}
+
+// CodeStatut retourne le code stable par défaut d'un statut HTTP
+func CodeStatut(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeRequeteInvalide
+	case fiber.StatusUnauthorized:
+		return CodeNonAuthentifie
+	case fiber.StatusForbidden:
+		return CodeAccesRefuse
+	case fiber.StatusNotFound, fiber.StatusMethodNotAllowed:
+		return CodeIntrouvable
+	case fiber.StatusConflict:
+		return CodeConflit
+	case fiber.StatusPreconditionFailed:
+		return CodeVersionPerimee
+	case fiber.StatusPreconditionRequired:
+		return CodePreconditionRequise
+	case fiber.StatusUnsupportedMediaType:
+		return CodeTypeNonSupporte
+	case fiber.StatusUnprocessableEntity:
+		return CodeNonTraitable
+	case fiber.StatusTooManyRequests:
+		return CodeTropDeRequetes
+	case fiber.StatusServiceUnavailable:
+		return CodeIndisponible
+	}
+	if status >= 500 {
+		return CodeInterne
+	}
+	return fmt.Sprintf("HTTP_%d", status)
+}
@@ -0,0 +1,75 @@
+package problemes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// erreurPostgres imite pgconn.PgError
+type erreurPostgres struct{ etat string }
+
+func (e erreurPostgres) Error() string {
+	return "ERROR: duplicate key value violates unique constraint \"identites_numero_passeport_key\""
+}
+
+func (e erreurPostgres) SQLState() string { return e.etat }
+
+func TestGestionnaire(t *testing.T) {
+	cas := []struct {
+		nom    string
+		erreur error
+		status int
+		code   string
+		detail string
+	}{
+		{"doublon", Echec("Failed to create identity", fmt.Errorf("insert: %w", erreurPostgres{"23505"})), fiber.StatusConflict, CodeDoublon, ""},
+		{"référence", Echec("Failed to create identity", erreurPostgres{"23503"}), fiber.StatusConflict, CodeReference, ""},
+		{"introuvable", Absent("Alert not found", gorm.ErrRecordNotFound), fiber.StatusNotFound, CodeIntrouvable, "Alert not found"},
+		{"interne", Echec("Failed to update alert", errors.New("pq: connection refused")), fiber.StatusInternalServerError, CodeInterne, "Failed to update alert"},
+		{"problème", Invalide("Invalid input").Cause(errors.New("json: cannot unmarshal")), fiber.StatusBadRequest, CodeRequeteInvalide, "Invalid input"},
+		{"fiber", fiber.ErrNotFound, fiber.StatusNotFound, CodeIntrouvable, "Not Found"},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: Gestionnaire})
+			app.Get("/", func(c *fiber.Ctx) error { return tc.erreur })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(fiber.HeaderAcceptLanguage, "en")
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("statut %d, attendu %d", resp.StatusCode, tc.status)
+			}
+			var corps map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&corps); err != nil {
+				t.Fatal(err)
+			}
+			if corps["code"] != tc.code {
+				t.Errorf("code %v, attendu %s", corps["code"], tc.code)
+			}
+			detail, _ := corps["detail"].(string)
+			attendu := Titre(tc.code, Anglais)
+			if tc.detail != "" {
+				attendu = Traduire(tc.detail, Anglais)
+			}
+			if detail != attendu {
+				t.Errorf("detail %q, attendu %q", detail, attendu)
+			}
+			for _, fuite := range []string{"duplicate key", "connection refused", "unmarshal", "record not found"} {
+				if strings.Contains(detail, fuite) {
+					t.Errorf("detail %q expose l'erreur interne", detail)
+				}
+			}
+		})
+	}
+}
@@ -1,24 +1,64 @@
 package routes
 
 import (
+	"log"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/kgermando/sysmobembo-api/controllers/alerts"
 	"github.com/kgermando/sysmobembo-api/controllers/auth"
 	"github.com/kgermando/sysmobembo-api/controllers/biometrics"
+	"github.com/kgermando/sysmobembo-api/controllers/cartes"
+	"github.com/kgermando/sysmobembo-api/controllers/dashboard"
+	"github.com/kgermando/sysmobembo-api/controllers/dossiers"
+	"github.com/kgermando/sysmobembo-api/controllers/doublons"
+	"github.com/kgermando/sysmobembo-api/controllers/frontieres"
 	"github.com/kgermando/sysmobembo-api/controllers/geolocation"
 	"github.com/kgermando/sysmobembo-api/controllers/identites"
+	"github.com/kgermando/sysmobembo-api/controllers/imports"
+	"github.com/kgermando/sysmobembo-api/controllers/impressions"
 	"github.com/kgermando/sysmobembo-api/controllers/migrants"
 	motifDeplacement "github.com/kgermando/sysmobembo-api/controllers/motifDeplacement"
 	"github.com/kgermando/sysmobembo-api/controllers/overview"
+	"github.com/kgermando/sysmobembo-api/controllers/relations"
+	"github.com/kgermando/sysmobembo-api/controllers/search"
+	"github.com/kgermando/sysmobembo-api/controllers/synchronisation"
+	"github.com/kgermando/sysmobembo-api/controllers/unites"
 	"github.com/kgermando/sysmobembo-api/controllers/users"
+	"github.com/kgermando/sysmobembo-api/controllers/watchlist"
+	"github.com/kgermando/sysmobembo-api/database"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/middlewares"
+	"github.com/kgermando/sysmobembo-api/openapi"
 
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
 func Setup(app *fiber.App) {
+	// Accès aux données des handlers CRUD, listes, statistiques et exports
+	d := depots.NewDepotsPostgres(database.DB)
+	d.Criblage = watchlist.NewCriblage(database.DB)
+
+	Monter(app, d, middlewares.NewStockagePostgres(database.DB))
+}
+
+// Monter déclare les routes de l'API sur les dépôts et le stockage des clés
+// d'idempotence donnés (Postgres en production, en mémoire pour les tests des handlers)
+func Monter(app *fiber.App, d depots.Depots, idempotence middlewares.StockageIdempotence) {
 
 	api := app.Group("/api", logger.New())
 
+	// Rejeu des créations renvoyées avec le même en-tête Idempotency-Key, sauf
+	// authentification (les réponses contiennent des jetons)
+	api.Use(middlewares.Idempotence(idempotence, middlewares.FenetreIdempotence(), "/api/auth/"))
+
+	// Périmètre des agents et contrôles d'accès résolus sur les mêmes dépôts
+	api.Use(unites.Depots(d))
+
+	// Documentation OpenAPI et Swagger UI
+	api.Get("/openapi.json", openapi.Document(app))
+	api.Get("/docs", openapi.SwaggerUI)
+	api.Get("/docs/:fichier", openapi.SwaggerRessource)
+
 	// Authentification controller
 	a := api.Group("/auth")
 	a.Post("/register", auth.Register)
@@ -36,88 +76,209 @@ func Setup(app *fiber.App) {
 
 	// Users controller
 	u := api.Group("/users")
-	u.Get("/all", users.GetAllUsers)
-	u.Get("/all/paginate", users.GetPaginatedUsers)
-	u.Get("/all/:uuid", users.GetAllUsersByUUID)
-	u.Get("/get/:uuid", users.GetUser)
-	u.Post("/create", users.CreateUser)
-	u.Put("/update/:uuid", users.UpdateUser)
-	u.Delete("/delete/:uuid", users.DeleteUser)
-	u.Get("/export/excel", users.ExportUsersToExcel)
+	u.Get("/all", users.GetAllUsers(d.Users))
+	u.Get("/all/paginate", users.GetPaginatedUsers(d.Users))
+	u.Get("/all/:uuid", users.GetAllUsersByUUID(d.Users))
+	u.Get("/get/:uuid", users.GetUser(d.Users))
+	u.Post("/create", users.CreateUser(d.Users))
+	u.Put("/update/:uuid", users.UpdateUser(d.Users))
+	u.Patch("/update/:uuid", users.PatchUser(d.Users))
+	u.Delete("/delete/:uuid", users.DeleteUser(d.Users))
+	u.Get("/export/excel", users.ExportUsersToExcel(d.Users))
+	u.Get("/badge/:uuid/pdf", impressions.PrintAgentBadge)
+
+	// Vérification publique des QR codes (agents et cartes des migrants)
+	api.Get("/agents/verify/:uuid", users.VerifyAgent(d.Users))
+	verify := api.Group("/verify")
+	verify.Get("/public-key", cartes.GetVerificationKey)
+	verify.Get("/:token", cartes.VerifyCardToken)
 
 	// Alerts controller
 	alertsGroup := api.Group("/alerts")
-	alertsGroup.Get("/paginate", alerts.GetPaginatedAlerts)
-	alertsGroup.Get("/all", alerts.GetAllAlerts)
-	alertsGroup.Get("/get/:uuid", alerts.GetAlert)
-	alertsGroup.Get("/migrant/:uuid", alerts.GetAlertsByMigrant)
-	alertsGroup.Post("/create", alerts.CreateAlert)
-	alertsGroup.Put("/update/:uuid", alerts.UpdateAlert)
-	alertsGroup.Put("/resolve/:uuid", alerts.ResolveAlert)
-	alertsGroup.Delete("/delete/:uuid", alerts.DeleteAlert)
-	alertsGroup.Get("/stats", alerts.GetAlertsStats)
-	alertsGroup.Get("/export/excel", alerts.ExportAlertsToExcel)
+	alertsGroup.Get("/paginate", alerts.GetPaginatedAlerts(d.Alertes))
+	alertsGroup.Get("/all", alerts.GetAllAlerts(d.Alertes))
+	alertsGroup.Get("/get/:uuid", alerts.GetAlert(d.Alertes))
+	alertsGroup.Get("/migrant/:uuid", alerts.GetAlertsByMigrant(d.Alertes))
+	alertsGroup.Post("/create", alerts.CreateAlert(d.Alertes))
+	alertsGroup.Put("/update/:uuid", alerts.UpdateAlert(d.Alertes))
+	alertsGroup.Patch("/update/:uuid", alerts.PatchAlert(d.Alertes))
+	alertsGroup.Put("/resolve/:uuid", alerts.ResolveAlert(d.Alertes))
+	alertsGroup.Delete("/delete/:uuid", alerts.DeleteAlert(d.Alertes))
+	alertsGroup.Get("/stats", alerts.GetAlertsStats(d.Alertes))
+	alertsGroup.Get("/export/excel", alerts.ExportAlertsToExcel(d.Alertes))
 
 	// Biometrics controller
 	bio := api.Group("/biometrics")
-	bio.Get("/paginate", biometrics.GetPaginatedBiometries)
-	bio.Get("/all", biometrics.GetAllBiometries)
-	bio.Get("/get/:uuid", biometrics.GetBiometrie)
-	bio.Get("/migrant/:uuid", biometrics.GetBiometriesByMigrant)
-	bio.Post("/create", biometrics.CreateBiometrie)
-	bio.Put("/update/:uuid", biometrics.UpdateBiometrie)
-	bio.Delete("/delete/:uuid", biometrics.DeleteBiometrie)
-	bio.Get("/stats", biometrics.GetBiometricsStats)
-	bio.Get("/export/excel", biometrics.ExportBiometriesToExcel)
+	bio.Get("/paginate", biometrics.GetPaginatedBiometries(d.Biometries))
+	bio.Get("/all", biometrics.GetAllBiometries(d.Biometries))
+	bio.Get("/get/:uuid", biometrics.GetBiometrie(d.Biometries))
+	bio.Get("/migrant/:uuid", biometrics.GetBiometriesByMigrant(d.Biometries))
+	bio.Post("/create", biometrics.CreateBiometrie(d.Biometries))
+	bio.Put("/update/:uuid", biometrics.UpdateBiometrie(d.Biometries))
+	bio.Delete("/delete/:uuid", biometrics.DeleteBiometrie(d.Biometries))
+	bio.Get("/stats", biometrics.GetBiometricsStats(d.Biometries))
+	bio.Get("/export/excel", biometrics.ExportBiometriesToExcel(d.Biometries))
 
 	// Geolocation controller
 	geo := api.Group("/geolocations")
-	geo.Get("/paginate", geolocation.GetPaginatedGeolocalisations)
-	geo.Get("/all", geolocation.GetAllGeolocalisations)
-	geo.Get("/coordinates", geolocation.GetCoordinatesList)
-	geo.Get("/get/:uuid", geolocation.GetGeolocalisation)
-	geo.Post("/create", geolocation.CreateGeolocalisation)
-	geo.Put("/update/:uuid", geolocation.UpdateGeolocalisation)
-	geo.Delete("/delete/:uuid", geolocation.DeleteGeolocalisation)
-	geo.Get("/export/excel", geolocation.ExportGeolocalisationsToExcel)
+	geo.Get("/paginate", geolocation.GetPaginatedGeolocalisations(d.Geolocalisations))
+	geo.Get("/all", geolocation.GetAllGeolocalisations(d.Geolocalisations))
+	geo.Get("/coordinates", geolocation.GetCoordinatesList(d.Geolocalisations))
+	geo.Get("/tiles/:z/:x/:y", geolocation.GetClusterTile)
+	geo.Get("/get/:uuid", geolocation.GetGeolocalisation(d.Geolocalisations))
+	geo.Post("/create", geolocation.CreateGeolocalisation(d.Geolocalisations))
+	geo.Put("/update/:uuid", geolocation.UpdateGeolocalisation(d.Geolocalisations))
+	geo.Delete("/delete/:uuid", geolocation.DeleteGeolocalisation(d.Geolocalisations))
+	geo.Get("/export/excel", geolocation.ExportGeolocalisationsToExcel(d.Geolocalisations))
 
 	// Migrants controller
 	migrant := api.Group("/migrants")
-	migrant.Get("/paginate", migrants.GetPaginatedMigrants)
-	migrant.Get("/all", migrants.GetAllMigrants)
-	migrant.Get("/get/:uuid", migrants.GetMigrant)
-	migrant.Post("/create", migrants.CreateMigrant)
-	migrant.Put("/update/:uuid", migrants.UpdateMigrant)
-	migrant.Delete("/delete/:uuid", migrants.DeleteMigrant)
-	migrant.Get("/stats", migrants.GetMigrantsStats)
-	migrant.Get("/export/excel", migrants.ExportMigrantsToExcel)
+	migrant.Get("/paginate", migrants.GetPaginatedMigrants(d.Migrants))
+	migrant.Get("/all", migrants.GetAllMigrants(d.Migrants))
+	migrant.Get("/get/:uuid", migrants.GetMigrant(d.Migrants))
+	migrant.Post("/create", migrants.CreateMigrant(d.Migrants, d.Identites, d.Criblage))
+	migrant.Put("/update/:uuid", migrants.UpdateMigrant(d.Migrants))
+	migrant.Patch("/update/:uuid", migrants.PatchMigrant(d.Migrants))
+	migrant.Delete("/delete/:uuid", migrants.DeleteMigrant(d.Migrants))
+	migrant.Get("/stats", migrants.GetMigrantsStats(d.Migrants))
+	migrant.Get("/export/excel", migrants.ExportMigrantsToExcel(d.Migrants))
+	migrant.Get("/:uuid/status-history", migrants.GetMigrantStatusHistory)
+	migrant.Post("/:uuid/card", cartes.IssueMigrantCard)
+	migrant.Get("/:uuid/card", cartes.GetMigrantCard)
+	migrant.Put("/:uuid/card/revoke", cartes.RevokeMigrantCard)
+	migrant.Get("/:uuid/card/pdf", impressions.PrintMigrantCard)
+	migrant.Get("/:uuid/dossier/pdf", impressions.PrintMigrantDossier)
 
 	// Identites controller
 	identitesGroup := api.Group("/identites")
-	identitesGroup.Get("/paginate", identites.GetPaginatedIdentites)
-	identitesGroup.Get("/migrants/by-identite", identites.GetMigrantsByIdentiteUUID)
-	identitesGroup.Get("/:uuid", identites.GetIdentite)
-	identitesGroup.Post("/create", identites.CreateIdentite)
-	identitesGroup.Put("/update/:uuid", identites.UpdateIdentite)
-	identitesGroup.Delete("/delete/:uuid", identites.DeleteIdentite)
-	identitesGroup.Get("/export/excel", identites.ExportIdentitesToExcel)
-	identitesGroup.Get("/statistics", identites.GetIdentiteStatistics)
+	identitesGroup.Get("/paginate", identites.GetPaginatedIdentites(d.Identites))
+	identitesGroup.Get("/migrants/by-identite", identites.GetMigrantsByIdentiteUUID(d.Migrants))
+	identitesGroup.Get("/expiring", identites.GetExpiringIdentites)
+	identitesGroup.Get("/expiring/export/excel", identites.ExportExpiringIdentitesToExcel)
+	identitesGroup.Post("/expiring/evaluate", identites.EvaluateValidites)
+	identitesGroup.Get("/statistics", identites.GetIdentiteStatistics(d.Identites))
+	identitesGroup.Get("/:uuid", identites.GetIdentite(d.Identites))
+	identitesGroup.Post("/create", identites.CreateIdentite(d.Identites, d.Criblage))
+	identitesGroup.Put("/update/:uuid", identites.UpdateIdentite(d.Identites, d.Criblage))
+	identitesGroup.Patch("/update/:uuid", identites.PatchIdentite(d.Identites, d.Criblage))
+	identitesGroup.Delete("/delete/:uuid", identites.DeleteIdentite(d.Identites))
+	identitesGroup.Get("/export/excel", identites.ExportIdentitesToExcel(d.Identites))
 
 	// Routes Scanner
 	identitesGroup.Post("/scan", identites.ScanDocument)
 	identitesGroup.Get("/scanned-file/:filename", identites.GetScannedFile)
 	identitesGroup.Get("/scanners/list", identites.ListAvailableScanners)
 
+	// Search controller
+	searchGroup := api.Group("/search")
+	searchGroup.Get("/persons", search.SearchPersons)
+
+	// Dossiers controller
+	dossiersGroup := api.Group("/dossiers")
+	dossiersGroup.Get("/paginate", dossiers.GetPaginatedDossiers)
+	dossiersGroup.Get("/get/:uuid", dossiers.GetDossier)
+	dossiersGroup.Get("/migrant/:uuid", dossiers.GetMigrantDossiers)
+	dossiersGroup.Post("/create", dossiers.CreateDossier)
+	dossiersGroup.Put("/update/:uuid", dossiers.UpdateDossier)
+	dossiersGroup.Delete("/delete/:uuid", dossiers.DeleteDossier)
+	dossiersGroup.Get("/stats", dossiers.GetDossiersStats)
+	dossiersGroup.Get("/echeances", dossiers.GetEcheances)
+	dossiersGroup.Get("/export/excel", dossiers.ExportDossiersToExcel)
+	dossiersGroup.Put("/transition/:uuid", dossiers.TransitionDossier)
+	dossiersGroup.Post("/decision/:uuid", dossiers.DecideDossier)
+	dossiersGroup.Post("/:uuid/notes", dossiers.AddDossierNote)
+	dossiersGroup.Delete("/:uuid/notes/:note_uuid", dossiers.DeleteDossierNote)
+	dossiersGroup.Post("/:uuid/agents", dossiers.AssignDossierAgent)
+	dossiersGroup.Delete("/:uuid/agents/:agent_uuid", dossiers.UnassignDossierAgent)
+
+	// Relations familiales controller
+	relationsGroup := api.Group("/relations")
+	relationsGroup.Get("/migrant/:uuid", relations.GetMigrantRelations)
+	relationsGroup.Get("/menage/:uuid", relations.GetMenage)
+	relationsGroup.Get("/incoherences", relations.GetIncoherences)
+	relationsGroup.Post("/create", relations.CreateRelation)
+	relationsGroup.Delete("/delete/:uuid", relations.DeleteRelation)
+
+	// Doublons controller
+	doublonsGroup := api.Group("/doublons")
+	doublonsGroup.Post("/scan", doublons.ScanDoublons)
+	doublonsGroup.Get("/paginate", doublons.GetPaginatedDoublons)
+	doublonsGroup.Get("/get/:uuid", doublons.GetDoublon)
+	doublonsGroup.Put("/reject/:uuid", doublons.RejectDoublon)
+	doublonsGroup.Post("/merge/:uuid", doublons.MergeDoublon)
+	doublonsGroup.Get("/fusions/paginate", doublons.GetPaginatedFusions)
+	doublonsGroup.Post("/fusions/undo/:uuid", doublons.UndoFusion)
+
+	// Border posts controller
+	postes := api.Group("/border-posts")
+	postes.Get("/paginate", frontieres.GetPaginatedPostes)
+	postes.Get("/all", frontieres.GetAllPostes)
+	postes.Get("/get/:uuid", frontieres.GetPoste)
+	postes.Post("/create", frontieres.CreatePoste)
+	postes.Put("/update/:uuid", frontieres.UpdatePoste)
+	postes.Delete("/delete/:uuid", frontieres.DeletePoste)
+
+	// Organisational units controller
+	orgUnits := api.Group("/org-units")
+	orgUnits.Get("/paginate", unites.GetPaginatedUnites)
+	orgUnits.Get("/all", unites.GetAllUnites)
+	orgUnits.Get("/tree", unites.GetUniteTree)
+	orgUnits.Get("/access-logs", unites.GetPaginatedAccesHorsPerimetre)
+	orgUnits.Get("/get/:uuid", unites.GetUnite)
+	orgUnits.Post("/create", unites.CreateUnite)
+	orgUnits.Put("/update/:uuid", unites.UpdateUnite)
+	orgUnits.Delete("/delete/:uuid", unites.DeleteUnite)
+
+	// Crossings controller
+	passages := api.Group("/crossings")
+	passages.Get("/paginate", frontieres.GetPaginatedPassages)
+	passages.Get("/get/:uuid", frontieres.GetPassage)
+	passages.Get("/migrant/:uuid", frontieres.GetMigrantPassages)
+	passages.Get("/poste/:uuid", frontieres.GetPostePassages)
+	passages.Get("/stats/daily", frontieres.GetFluxJournaliers)
+	passages.Post("/create", frontieres.CreatePassage)
+	passages.Delete("/delete/:uuid", frontieres.DeletePassage)
+
+	// Watchlists controller
+	watchlists := api.Group("/watchlists")
+	watchlists.Get("/paginate", watchlist.GetPaginatedListes)
+	watchlists.Get("/get/:uuid", watchlist.GetListe)
+	watchlists.Get("/:uuid/entries", watchlist.GetListeEntrees)
+	watchlists.Post("/import", watchlist.ImportListe)
+	watchlists.Put("/update/:uuid", watchlist.UpdateListe)
+	watchlists.Delete("/delete/:uuid", watchlist.DeleteListe)
+	watchlists.Post("/rescreen/:uuid", watchlist.RescreenListe)
+	watchlists.Get("/hits/paginate", watchlist.GetPaginatedHits)
+	watchlists.Get("/hits/get/:uuid", watchlist.GetHit)
+	watchlists.Put("/hits/confirm/:uuid", watchlist.ConfirmHit)
+	watchlists.Put("/hits/clear/:uuid", watchlist.ClearHit)
+
+	// Imports en masse (Excel / CSV)
+	importGroup := api.Group("/import")
+	importGroup.Post("/identites", imports.ImportIdentites)
+	importGroup.Post("/migrants", imports.ImportMigrants)
+	importGroup.Get("/jobs", imports.GetPaginatedImports)
+	importGroup.Get("/jobs/:uuid", imports.GetImport)
+	importGroup.Get("/jobs/:uuid/errors", imports.GetImportErrors)
+
+	// Synchronisation des tablettes hors ligne
+	syncGroup := api.Group("/sync")
+	syncGroup.Post("/push", synchronisation.PushChanges)
+	syncGroup.Get("/pull", synchronisation.PullChanges)
+	syncGroup.Get("/conflicts/paginate", synchronisation.GetPaginatedConflits)
+	syncGroup.Get("/conflicts/get/:uuid", synchronisation.GetConflit)
+	syncGroup.Put("/conflicts/resolve/:uuid", synchronisation.ResolveConflit)
+
 	// Motif Deplacement controller
 	motif := api.Group("/motif-deplacements")
-	motif.Get("/paginate", motifDeplacement.GetPaginatedMotifDeplacements)
-	motif.Get("/all", motifDeplacement.GetAllMotifDeplacements)
-	motif.Get("/get/:uuid", motifDeplacement.GetMotifDeplacement)
-	motif.Post("/create", motifDeplacement.CreateMotifDeplacement)
-	motif.Put("/update/:uuid", motifDeplacement.UpdateMotifDeplacement)
-	motif.Delete("/delete/:uuid", motifDeplacement.DeleteMotifDeplacement)
-	motif.Get("/stats", motifDeplacement.GetMotifsStats)
-	motif.Get("/export/excel", motifDeplacement.ExportMotifDeplacementsToExcel)
+	motif.Get("/paginate", motifDeplacement.GetPaginatedMotifDeplacements(d.Motifs))
+	motif.Get("/all", motifDeplacement.GetAllMotifDeplacements(d.Motifs))
+	motif.Get("/get/:uuid", motifDeplacement.GetMotifDeplacement(d.Motifs))
+	motif.Post("/create", motifDeplacement.CreateMotifDeplacement(d.Motifs))
+	motif.Put("/update/:uuid", motifDeplacement.UpdateMotifDeplacement(d.Motifs))
+	motif.Delete("/delete/:uuid", motifDeplacement.DeleteMotifDeplacement(d.Motifs))
+	motif.Get("/stats", motifDeplacement.GetMotifsStats(d.Motifs))
+	motif.Get("/export/excel", motifDeplacement.ExportMotifDeplacementsToExcel(d.Motifs))
 
 	// Dashboard GIS System controller
 	dash := api.Group("/dashboard")
@@ -128,5 +289,17 @@ func Setup(app *fiber.App) {
 	overviewDash.Get("/alertes", overview.GetAlertesTempsReel)
 	overviewDash.Get("/repartition", overview.GetRepartitionGeographique)
 	overviewDash.Get("/motifs-pie", overview.GetMotifsPieChart)
+	overviewDash.Get("/transitions-statut", overview.GetTransitionsStatut)
+
+	// Dashboard Predictive - prévisions des enregistrements mensuels
+	predictive := dash.Group("/predictive")
+	predictive.Get("/enregistrements", dashboard.GetPrevisionEnregistrements)
+	predictive.Get("/provinces", dashboard.GetPrevisionProvinces)
+	predictive.Get("/motifs", dashboard.GetPrevisionMotifs)
+
+	// Toute route doit avoir son entrée dans le catalogue OpenAPI
+	for _, route := range openapi.NonDocumentees(app) {
+		log.Printf("⚠️ Route sans documentation OpenAPI: %s", route)
+	}
 
 }
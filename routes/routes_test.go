@@ -0,0 +1,567 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kgermando/sysmobembo-api/depots"
+	"github.com/kgermando/sysmobembo-api/middlewares"
+	"github.com/kgermando/sysmobembo-api/models"
+	"github.com/kgermando/sysmobembo-api/problemes"
+	"github.com/kgermando/sysmobembo-api/routes"
+	"github.com/kgermando/sysmobembo-api/utils"
+)
+
+// Deux directions provinciales sous la direction générale ; l'agent de Kinshasa
+// enregistre les données de sa province (suffixe 1), l'agent du Katanga celles de la sienne (suffixe 2)
+type environnement struct {
+	app        *fiber.App
+	d          depots.Depots
+	agent      string
+	agentAutre string
+}
+
+func preparer(t *testing.T) *environnement {
+	t.Helper()
+	utils.SECRET_KEY = "secret-des-tests"
+	t.Setenv("IF_MATCH_OPTIONAL", "true")
+
+	d := depots.NewDepotsMemoire()
+	racine := "dg"
+	unites := []models.UniteOrganisationnelle{
+		{UUID: "dg", Code: "DG", Nom: "Direction générale", TypeUnite: "national", Chemin: "/dg/"},
+		{UUID: "kin", Code: "KIN", Nom: "DP Kinshasa", TypeUnite: "direction_provinciale", Province: "Kinshasa", ParentUUID: &racine, Chemin: "/dg/kin/"},
+		{UUID: "kat", Code: "KAT", Nom: "DP Katanga", TypeUnite: "direction_provinciale", Province: "Haut-Katanga", ParentUUID: &racine, Chemin: "/dg/kat/"},
+	}
+	for i := range unites {
+		creer(t, d.Unites.Creer(&unites[i]))
+	}
+	for _, u := range []models.User{
+		{UUID: "agent1", Nom: "Kabila", PostNom: "Mwamba", Prenom: "Jean", Role: "Agent", UniteUUID: "kin", Matricule: "DGM001"},
+		{UUID: "agent2", Nom: "Tshisekedi", PostNom: "Ilunga", Prenom: "Marie", Role: "Agent", UniteUUID: "kat", Matricule: "DGM002"},
+	} {
+		creer(t, d.Users.Creer(&u))
+	}
+
+	naissance := time.Date(1990, 5, 12, 0, 0, 0, 0, time.UTC)
+	for _, suffixe := range []string{"1", "2"} {
+		unite := map[string]string{"1": "kin", "2": "kat"}[suffixe]
+		creer(t, d.Identites.Creer(&models.Identite{
+			UUID: "i" + suffixe, Nom: "Mukendi" + suffixe, Postnom: "Tshibola", Prenom: "Grâce", Sexe: "F",
+			DateNaissance: naissance, LieuNaissance: "Kananga", Nationalite: "Congolaise",
+			NumeroPasseport: "OB000000" + suffixe, UniteUUID: unite,
+		}))
+		creer(t, d.Migrants.Creer(&models.Migrant{
+			UUID: "m" + suffixe, IdentiteUUID: "i" + suffixe, NumeroIdentifiant: "MIG-" + suffixe,
+			StatutMigratoire: "regulier", UniteUUID: unite,
+		}))
+		creer(t, d.Alertes.Creer(&models.Alert{
+			UUID: "a" + suffixe, MigrantUUID: "m" + suffixe, TypeAlerte: "administrative",
+			NiveauGravite: "warning", Titre: "Visa expiré", Statut: "active",
+		}))
+		creer(t, d.Biometries.Creer(&models.Biometrie{
+			UUID: "b" + suffixe, MigrantUUID: "m" + suffixe, TypeBiometrie: "empreinte_digitale",
+			DonneesBiometriques: "gabarit", AlgorithmeEncodage: "ISO19794", DateCapture: time.Now(),
+		}))
+		creer(t, d.Geolocalisations.Creer(&models.Geolocalisation{
+			UUID: "g" + suffixe, IdentiteUUID: "i" + suffixe, Latitude: -4.32, Longitude: 15.31,
+		}))
+		creer(t, d.Motifs.Creer(&models.MotifDeplacement{
+			UUID: "mo" + suffixe, MigrantUUID: "m" + suffixe, TypeMotif: "economique",
+			MotifPrincipal: "Emploi", Urgence: "faible", DateDeclenchement: naissance,
+		}))
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+	routes.Monter(app, d, middlewares.NewStockageMemoire())
+	return &environnement{app: app, d: d, agent: jeton(t, "agent1"), agentAutre: jeton(t, "agent2")}
+}
+
+func creer(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jeton(t *testing.T, userUUID string) string {
+	t.Helper()
+	j, err := utils.GenerateJwt(userUUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+// appeler envoie la requête au nom de l'agent ; le corps JSON de la réponse est
+// décodé sauf pour les exports Excel
+func (e *environnement) appeler(t *testing.T, methode, url, agent string, corps interface{}) (int, map[string]interface{}, string) {
+	t.Helper()
+	var contenu io.Reader
+	if corps != nil {
+		b, err := json.Marshal(corps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contenu = bytes.NewReader(b)
+	}
+	separateur := "?"
+	if strings.Contains(url, "?") {
+		separateur = "&"
+	}
+	req := httptest.NewRequest(methode, url+separateur+"token="+agent, contenu)
+	if corps != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := e.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeContenu := resp.Header.Get(fiber.HeaderContentType)
+	var reponse map[string]interface{}
+	if strings.HasPrefix(typeContenu, fiber.MIMEApplicationJSON) || strings.Contains(typeContenu, "problem+json") {
+		if err := json.NewDecoder(resp.Body).Decode(&reponse); err != nil {
+			t.Fatalf("%s %s : réponse illisible: %v", methode, url, err)
+		}
+	}
+	return resp.StatusCode, reponse, typeContenu
+}
+
+// valeur lit un champ de la réponse désigné par son chemin ("data.total_alerts")
+func valeur(reponse map[string]interface{}, chemin string) interface{} {
+	var courant interface{} = reponse
+	for _, cle := range strings.Split(chemin, ".") {
+		m, ok := courant.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		courant = m[cle]
+	}
+	return courant
+}
+
+// uuids retourne les UUID des enregistrements de data
+func uuids(reponse map[string]interface{}) []string {
+	liste, _ := reponse["data"].([]interface{})
+	var resultat []string
+	for _, element := range liste {
+		if m, ok := element.(map[string]interface{}); ok {
+			uuid, _ := m["uuid"].(string)
+			resultat = append(resultat, uuid)
+		}
+	}
+	return resultat
+}
+
+// Les listes, statistiques et exports ne portent que sur les enregistrements du périmètre de l'agent
+func TestLecturesDuPerimetre(t *testing.T) {
+	e := preparer(t)
+	cas := []struct {
+		url string
+		// UUID attendus dans data (listes), ou valeur attendue d'un champ (statistiques)
+		uuids  []string
+		champ  string
+		valeur float64
+	}{
+		{url: "/api/users/all", uuids: []string{"agent2", "agent1"}},
+		{url: "/api/users/all/paginate?search=kabila", uuids: []string{"agent1"}},
+		{url: "/api/alerts/paginate", uuids: []string{"a1"}},
+		{url: "/api/alerts/all", uuids: []string{"a1"}},
+		{url: "/api/alerts/migrant/m1", uuids: []string{"a1"}},
+		{url: "/api/alerts/migrant/m2", uuids: nil},
+		{url: "/api/alerts/stats", champ: "data.total_alerts", valeur: 1},
+		{url: "/api/biometrics/paginate", uuids: []string{"b1"}},
+		{url: "/api/biometrics/all", uuids: []string{"b1"}},
+		{url: "/api/biometrics/migrant/m1", uuids: []string{"b1"}},
+		{url: "/api/biometrics/migrant/m2", uuids: nil},
+		{url: "/api/biometrics/stats", champ: "data.total_biometrics", valeur: 1},
+		{url: "/api/geolocations/paginate", uuids: []string{"g1"}},
+		{url: "/api/geolocations/all", uuids: []string{"g1"}},
+		{url: "/api/migrants/paginate", uuids: []string{"m1"}},
+		{url: "/api/migrants/paginate?search=mukendi1", uuids: []string{"m1"}},
+		{url: "/api/migrants/paginate?search=mukendi2", uuids: nil},
+		{url: "/api/migrants/all", uuids: []string{"m1"}},
+		{url: "/api/migrants/stats", champ: "data.total_migrants", valeur: 1},
+		{url: "/api/identites/paginate", uuids: []string{"i1"}},
+		{url: "/api/identites/migrants/by-identite?identite_uuid=i1", uuids: []string{"m1"}},
+		{url: "/api/identites/migrants/by-identite?identite_uuid=i2", uuids: nil},
+		{url: "/api/identites/statistics", champ: "data.total", valeur: 1},
+		{url: "/api/motif-deplacements/paginate", uuids: []string{"mo1"}},
+		{url: "/api/motif-deplacements/paginate?search=000001", uuids: []string{"mo1"}},
+		{url: "/api/motif-deplacements/all", uuids: []string{"mo1"}},
+		{url: "/api/motif-deplacements/stats", champ: "data.total_motifs", valeur: 1},
+	}
+	for _, tc := range cas {
+		t.Run(tc.url, func(t *testing.T) {
+			statut, reponse, _ := e.appeler(t, "GET", tc.url, e.agent, nil)
+			if statut != fiber.StatusOK {
+				t.Fatalf("statut %d, attendu 200 : %v", statut, reponse)
+			}
+			if tc.champ != "" {
+				if v := valeur(reponse, tc.champ); v != tc.valeur {
+					t.Errorf("%s = %v, attendu %v", tc.champ, v, tc.valeur)
+				}
+				return
+			}
+			if obtenus := uuids(reponse); strings.Join(obtenus, ",") != strings.Join(tc.uuids, ",") {
+				t.Errorf("enregistrements %v, attendus %v", obtenus, tc.uuids)
+			}
+		})
+	}
+
+	statut, reponse, _ := e.appeler(t, "GET", "/api/geolocations/coordinates", e.agent, nil)
+	if liste, _ := reponse["data"].([]interface{}); statut != fiber.StatusOK || len(liste) != 1 {
+		t.Errorf("coordonnées : statut %d, %d point(s), 1 attendu", statut, len(liste))
+	}
+}
+
+func TestExports(t *testing.T) {
+	e := preparer(t)
+	for _, url := range []string{
+		"/api/users/export/excel",
+		"/api/alerts/export/excel",
+		"/api/biometrics/export/excel",
+		"/api/geolocations/export/excel",
+		"/api/migrants/export/excel?start_date=2020-01-01",
+		"/api/identites/export/excel",
+		"/api/motif-deplacements/export/excel",
+	} {
+		t.Run(url, func(t *testing.T) {
+			statut, reponse, typeContenu := e.appeler(t, "GET", url, e.agent, nil)
+			if statut != fiber.StatusOK || !strings.Contains(typeContenu, "spreadsheetml") {
+				t.Errorf("statut %d, type %q : %v", statut, typeContenu, reponse)
+			}
+		})
+	}
+}
+
+// Consultation, création, modification et suppression des enregistrements du périmètre
+func TestEcritures(t *testing.T) {
+	emission := time.Now().AddDate(-1, 0, 0)
+	cas := []struct {
+		methode string
+		url     string
+		corps   interface{}
+		statut  int
+	}{
+		{"GET", "/api/users/get/agent2", nil, fiber.StatusOK},
+		{"GET", "/api/agents/verify/agent1", nil, fiber.StatusOK},
+		{"POST", "/api/users/create", map[string]interface{}{"nom": "Lukaku", "postnom": "Bolingo", "prenom": "Paul", "password": "motdepasse", "password_confirm": "motdepasse", "unite_uuid": "kin"}, fiber.StatusOK},
+		{"POST", "/api/users/create", map[string]interface{}{"nom": "Lukaku", "postnom": "Bolingo", "prenom": "Paul", "password": "motdepasse", "password_confirm": "motdepasse", "unite_uuid": "inconnue"}, fiber.StatusBadRequest},
+		{"PUT", "/api/users/update/agent1", map[string]interface{}{"fonction": "Chef de poste"}, fiber.StatusOK},
+		{"PATCH", "/api/users/update/agent1", map[string]interface{}{"fonction": "Chef de poste"}, fiber.StatusOK},
+		{"DELETE", "/api/users/delete/agent2", nil, fiber.StatusOK},
+
+		{"GET", "/api/alerts/get/a1", nil, fiber.StatusOK},
+		{"POST", "/api/alerts/create", map[string]interface{}{"migrant_uuid": "m1", "type_alerte": "sante", "niveau_gravite": "info", "titre": "Visite médicale", "description": "Contrôle"}, fiber.StatusOK},
+		{"PUT", "/api/alerts/update/a1", map[string]interface{}{"titre": "Visa prolongé"}, fiber.StatusOK},
+		{"PATCH", "/api/alerts/update/a1", map[string]interface{}{"titre": "Visa prolongé"}, fiber.StatusOK},
+		{"PUT", "/api/alerts/resolve/a1", map[string]interface{}{"comment_resolution": "Régularisé"}, fiber.StatusOK},
+		{"DELETE", "/api/alerts/delete/a1", nil, fiber.StatusOK},
+
+		{"GET", "/api/biometrics/get/b1", nil, fiber.StatusOK},
+		{"POST", "/api/biometrics/create", map[string]interface{}{"migrant_uuid": "m1", "type_biometrie": "iris", "donnees_biometriques": "gabarit-iris", "algorithme_encodage": "ISO19794", "date_capture": time.Now()}, fiber.StatusOK},
+		{"PUT", "/api/biometrics/update/b1", map[string]interface{}{"algorithme_encodage": "ISO29794"}, fiber.StatusOK},
+		{"DELETE", "/api/biometrics/delete/b1", nil, fiber.StatusOK},
+
+		{"GET", "/api/geolocations/get/g1", nil, fiber.StatusOK},
+		{"POST", "/api/geolocations/create", map[string]interface{}{"latitude": -11.66, "longitude": 27.48}, fiber.StatusBadRequest},
+		{"PUT", "/api/geolocations/update/g1", map[string]interface{}{"latitude": -4.4}, fiber.StatusOK},
+		{"DELETE", "/api/geolocations/delete/g1", nil, fiber.StatusOK},
+
+		{"GET", "/api/migrants/get/m1", nil, fiber.StatusOK},
+		{"POST", "/api/migrants/create", map[string]interface{}{"identite_uuid": "i1", "statut_migratoire": "refugie"}, fiber.StatusOK},
+		{"PUT", "/api/migrants/update/m1", map[string]interface{}{"ville_actuelle": "Matadi"}, fiber.StatusOK},
+		{"PATCH", "/api/migrants/update/m1", map[string]interface{}{"ville_actuelle": "Matadi"}, fiber.StatusOK},
+		{"DELETE", "/api/migrants/delete/m1", nil, fiber.StatusOK},
+
+		{"GET", "/api/identites/i1", nil, fiber.StatusOK},
+		{"POST", "/api/identites/create", map[string]interface{}{
+			"nom": "Ilunga", "postnom": "Kasongo", "prenom": "Paul", "date_naissance": time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC),
+			"lieu_naissance": "Likasi", "sexe": "M", "nationalite": "Congolaise", "pays_emetteur": "RDC", "autorite_emetteur": "DGM",
+			"date_emission": emission, "date_expiration": emission.AddDate(5, 0, 0), "numero_passeport": "OB0000009",
+		}, fiber.StatusCreated},
+		{"POST", "/api/identites/create", map[string]interface{}{"nom": "Ilunga"}, fiber.StatusBadRequest},
+		{"PUT", "/api/identites/update/i1", map[string]interface{}{"lieu_naissance": "Mbuji-Mayi"}, fiber.StatusOK},
+		{"PATCH", "/api/identites/update/i1", map[string]interface{}{"lieu_naissance": "Mbuji-Mayi"}, fiber.StatusOK},
+		// Identité encore rattachée au migrant m1
+		{"DELETE", "/api/identites/delete/i1", nil, fiber.StatusBadRequest},
+
+		{"GET", "/api/motif-deplacements/get/mo1", nil, fiber.StatusOK},
+		{"POST", "/api/motif-deplacements/create", map[string]interface{}{"type_motif": "familial"}, fiber.StatusBadRequest},
+		{"PUT", "/api/motif-deplacements/update/mo1", map[string]interface{}{"motif_principal": "Études"}, fiber.StatusOK},
+		{"DELETE", "/api/motif-deplacements/delete/mo1", nil, fiber.StatusOK},
+	}
+	for _, tc := range cas {
+		t.Run(tc.methode+" "+tc.url, func(t *testing.T) {
+			e := preparer(t)
+			statut, reponse, _ := e.appeler(t, tc.methode, tc.url, e.agent, tc.corps)
+			if statut != tc.statut {
+				t.Errorf("statut %d, attendu %d : %v", statut, tc.statut, reponse)
+			}
+		})
+	}
+}
+
+// criblageEnEchec simule des listes de surveillance inaccessibles
+type criblageEnEchec struct{}
+
+func (criblageEnEchec) Cribler(models.Identite) ([]models.CorrespondanceSurveillance, error) {
+	return nil, errors.New("listes de surveillance inaccessibles")
+}
+
+// Un criblage en échec n'annule pas l'enregistrement mais avertit le client
+func TestCriblageEnEchec(t *testing.T) {
+	emission := time.Now().AddDate(-1, 0, 0)
+	cas := []struct {
+		methode string
+		url     string
+		corps   interface{}
+		statut  int
+	}{
+		{"POST", "/api/migrants/create", map[string]interface{}{"identite_uuid": "i1", "statut_migratoire": "refugie"}, fiber.StatusOK},
+		{"POST", "/api/identites/create", map[string]interface{}{
+			"nom": "Ilunga", "postnom": "Kasongo", "prenom": "Paul", "date_naissance": time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC),
+			"lieu_naissance": "Likasi", "sexe": "M", "nationalite": "Congolaise", "pays_emetteur": "RDC", "autorite_emetteur": "DGM",
+			"date_emission": emission, "date_expiration": emission.AddDate(5, 0, 0), "numero_passeport": "OB0000009",
+		}, fiber.StatusCreated},
+		{"PUT", "/api/identites/update/i1", map[string]interface{}{"lieu_naissance": "Mbuji-Mayi"}, fiber.StatusOK},
+		{"PATCH", "/api/identites/update/i1", map[string]interface{}{"lieu_naissance": "Mbuji-Mayi"}, fiber.StatusOK},
+	}
+	for _, tc := range cas {
+		t.Run(tc.methode+" "+tc.url, func(t *testing.T) {
+			e := preparer(t)
+			e.d.Criblage = criblageEnEchec{}
+			e.app = fiber.New(fiber.Config{ErrorHandler: problemes.Gestionnaire})
+			routes.Monter(e.app, e.d, middlewares.NewStockageMemoire())
+
+			statut, reponse, _ := e.appeler(t, tc.methode, tc.url, e.agent, tc.corps)
+			if statut != tc.statut {
+				t.Fatalf("statut %d, attendu %d : %v", statut, tc.statut, reponse)
+			}
+			avertissements, _ := reponse["warnings"].([]interface{})
+			averti := false
+			for _, a := range avertissements {
+				averti = averti || a == depots.AvertissementCriblage
+			}
+			if !averti {
+				t.Errorf("avertissements %v, échec du criblage attendu", reponse["warnings"])
+			}
+		})
+	}
+}
+
+// Les clés de recherche suivent le nom modifié, par PUT comme par PATCH
+func TestRenommageRecalculeLesClesDeRecherche(t *testing.T) {
+	cas := []struct {
+		methode string
+		corps   map[string]interface{}
+		noms    []string
+	}{
+		{"PUT", map[string]interface{}{"nom": "Chibangu"}, []string{"Chibangu", "Mukendi", "Léon"}},
+		{"PATCH", map[string]interface{}{"nom": "Chibangu", "prenom": "Jean"}, []string{"Chibangu", "Mukendi", "Jean"}},
+	}
+	for _, tc := range cas {
+		t.Run(tc.methode, func(t *testing.T) {
+			e := preparer(t)
+			identite, err := e.d.Identites.Trouver("i1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Noms de départ : les clés sont calculées à la création
+			modif := &models.Identite{Nom: "Tshibangu", Postnom: "Mukendi", Prenom: "Léon"}
+			creer(t, e.d.Identites.Modifier(identite, modif))
+
+			statut, reponse, _ := e.appeler(t, tc.methode, "/api/identites/update/i1", e.agent, tc.corps)
+			if statut != fiber.StatusOK {
+				t.Fatalf("statut %d : %v", statut, reponse)
+			}
+			identite, err = e.d.Identites.Trouver("i1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cleNom, clePhonetique := utils.NameSearchKeys(tc.noms...)
+			if identite.CleNom != cleNom || identite.ClePhonetique != clePhonetique {
+				t.Errorf("clés %q/%q, attendues %q/%q", identite.CleNom, identite.ClePhonetique, cleNom, clePhonetique)
+			}
+		})
+	}
+}
+
+// Les listes paginées refusent les filtres, tris et champs absents de leur liste
+// blanche avant toute lecture en base
+func TestListesRefusentLesChampsHorsListeBlanche(t *testing.T) {
+	e := preparer(t)
+	for _, url := range []string{
+		"/api/migrants/paginate?filter[cle_nom]=x",
+		"/api/alerts/paginate?sort=description",
+
+		"/api/doublons/paginate?filter[commentaire]=x",
+		"/api/doublons/paginate?sort=identite_a",
+		"/api/doublons/fusions/paginate?fields=instantane_absorbee",
+		"/api/doublons/fusions/paginate?filter[statut][like]=act&filter[created_at][gte]=hier",
+
+		"/api/watchlists/paginate?filter[description]=x",
+		"/api/watchlists/liste/entries?sort=motif",
+		"/api/watchlists/liste/entries?fields=cle_nom",
+		"/api/watchlists/hits/paginate?filter[commentaire][like]=x",
+		"/api/watchlists/hits/paginate?filter[score][gte]=haut",
+
+		"/api/import/jobs?filter[message][like]=x",
+		"/api/import/jobs?sort=erreurs",
+		"/api/import/jobs/import/errors?filter[message]=x",
+		"/api/import/jobs/import/errors?filter[ligne][gte]=deux",
+
+		"/api/sync/conflicts/paginate?filter[operation][like]=x",
+		"/api/sync/conflicts/paginate?sort=message",
+		"/api/sync/conflicts/paginate?fields=commentaire",
+	} {
+		t.Run(url, func(t *testing.T) {
+			statut, reponse, _ := e.appeler(t, "GET", url, e.agent, nil)
+			if statut != fiber.StatusBadRequest || reponse["code"] != problemes.CodeRequeteListe {
+				t.Errorf("statut %d code %v, attendu 400 %s", statut, reponse["code"], problemes.CodeRequeteListe)
+			}
+		})
+	}
+}
+
+// Une création renvoyée avec la même clé d'idempotence est rejouée par le stockage
+// passé à Monter, sans second enregistrement
+func TestCreationIdempotente(t *testing.T) {
+	e := preparer(t)
+	var crees []interface{}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/migrants/create?token="+e.agent,
+			strings.NewReader(`{"identite_uuid":"i1","statut_migratoire":"refugie"}`))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(middlewares.EnTeteIdempotence, "creation-1")
+		resp, err := e.app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reponse map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&reponse); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("envoi %d : statut %d : %v", i+1, resp.StatusCode, reponse)
+		}
+		if rejoue := resp.Header.Get("Idempotent-Replayed"); (i == 1) != (rejoue == "true") {
+			t.Errorf("envoi %d : Idempotent-Replayed=%q", i+1, rejoue)
+		}
+		crees = append(crees, valeur(reponse, "data.uuid"))
+	}
+	if crees[0] == nil || crees[0] != crees[1] {
+		t.Errorf("migrants %v, attendu le même migrant rejoué", crees)
+	}
+}
+
+// PATCH ne modifie que les champs du correctif, valeurs nulles comprises, et
+// n'accepte qu'un corps JSON
+func TestPatchMigrant(t *testing.T) {
+	cas := []struct {
+		nom         string
+		typeContenu string
+		corps       string
+		statut      int
+		verifier    func(t *testing.T, m *models.Migrant)
+	}{
+		{
+			nom: "merge patch", typeContenu: "application/merge-patch+json",
+			corps: `{"nombre_enfants":0,"telephone":null}`, statut: fiber.StatusOK,
+			verifier: func(t *testing.T, m *models.Migrant) {
+				if m.NombreEnfants != 0 || m.Telephone != "" || m.VilleActuelle != "Kinshasa" {
+					t.Errorf("migrant %d enfant(s), téléphone %q, ville %q ; attendu 0, vide, Kinshasa", m.NombreEnfants, m.Telephone, m.VilleActuelle)
+				}
+			},
+		},
+		{
+			// La situation matrimoniale vide du migrant n'est pas revalidée
+			nom: "json", typeContenu: fiber.MIMEApplicationJSON,
+			corps: `{"ville_actuelle":"Matadi"}`, statut: fiber.StatusOK,
+			verifier: func(t *testing.T, m *models.Migrant) {
+				if m.VilleActuelle != "Matadi" || m.NombreEnfants != 2 {
+					t.Errorf("migrant à %q avec %d enfant(s), attendu Matadi et 2", m.VilleActuelle, m.NombreEnfants)
+				}
+			},
+		},
+		{nom: "champ immuable", typeContenu: "application/merge-patch+json", corps: `{"unite_uuid":"kat"}`, statut: fiber.StatusBadRequest},
+		{nom: "valeur hors liste", typeContenu: "application/merge-patch+json", corps: `{"statut_migratoire":"touriste"}`, statut: fiber.StatusBadRequest},
+		{nom: "type de contenu", typeContenu: "text/plain", corps: `{"ville_actuelle":"Matadi"}`, statut: fiber.StatusUnsupportedMediaType},
+	}
+	for _, tc := range cas {
+		t.Run(tc.nom, func(t *testing.T) {
+			e := preparer(t)
+			migrant, err := e.d.Migrants.Trouver("m1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			creer(t, e.d.Migrants.Modifier(migrant, &models.Migrant{Telephone: "0810000000", VilleActuelle: "Kinshasa", NombreEnfants: 2}))
+
+			req := httptest.NewRequest("PATCH", "/api/migrants/update/m1?token="+e.agent, strings.NewReader(tc.corps))
+			req.Header.Set(fiber.HeaderContentType, tc.typeContenu)
+			resp, err := e.app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.statut {
+				corps, _ := io.ReadAll(resp.Body)
+				t.Fatalf("statut %d, attendu %d : %s", resp.StatusCode, tc.statut, corps)
+			}
+
+			migrant, err = e.d.Migrants.Trouver("m1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.verifier != nil {
+				tc.verifier(t, migrant)
+			} else if migrant.Telephone != "0810000000" || migrant.UniteUUID != "kin" || migrant.StatutMigratoire != "regulier" {
+				t.Errorf("correctif refusé mais migrant modifié : %+v", migrant)
+			}
+		})
+	}
+}
+
+// Les enregistrements d'une autre unité sont refusés à l'agent, en lecture comme en écriture
+func TestHorsPerimetre(t *testing.T) {
+	cas := []struct {
+		methode string
+		url     string
+		corps   interface{}
+	}{
+		{"GET", "/api/alerts/get/a2", nil},
+		{"POST", "/api/alerts/create", map[string]interface{}{"migrant_uuid": "m2", "titre": "Intrusion"}},
+		{"DELETE", "/api/alerts/delete/a2", nil},
+		{"GET", "/api/biometrics/get/b2", nil},
+		{"GET", "/api/geolocations/get/g2", nil},
+		{"POST", "/api/geolocations/create", map[string]interface{}{"identite_uuid": "i2", "latitude": -4.3, "longitude": 15.3}},
+		{"GET", "/api/migrants/get/m2", nil},
+		{"PUT", "/api/migrants/update/m2", map[string]interface{}{"ville_actuelle": "Goma"}},
+		{"GET", "/api/identites/i2", nil},
+		{"PATCH", "/api/identites/update/i2", map[string]interface{}{"lieu_naissance": "Goma"}},
+		{"GET", "/api/motif-deplacements/get/mo2", nil},
+		{"DELETE", "/api/motif-deplacements/delete/mo2", nil},
+	}
+	for _, tc := range cas {
+		t.Run(tc.methode+" "+tc.url, func(t *testing.T) {
+			e := preparer(t)
+			statut, reponse, _ := e.appeler(t, tc.methode, tc.url, e.agent, tc.corps)
+			if statut != fiber.StatusForbidden || reponse["code"] != problemes.CodeHorsPerimetre {
+				t.Errorf("statut %d, attendu 403 : %v", statut, reponse)
+			}
+		})
+	}
+
+	// L'agent de l'autre province voit ses propres enregistrements
+	e := preparer(t)
+	if statut, _, _ := e.appeler(t, "GET", "/api/alerts/get/a2", e.agentAutre, nil); statut != fiber.StatusOK {
+		t.Errorf("agent du Katanga : statut %d, attendu 200", statut)
+	}
+}
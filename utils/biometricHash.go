@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// BiometricFingerprint calcule l'empreinte SHA-256 d'un gabarit biométrique en clair.
+// Deux captures identiques produisent la même empreinte, ce qui permet de
+// rapprocher des enregistrements sans exposer les données biométriques.
+func BiometricFingerprint(typeBiometrie string, data string) string {
+	sum := sha256.Sum256([]byte(typeBiometrie + ":" + strings.TrimSpace(data)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DecryptBiometricData déchiffre des données chiffrées en AES-256-GCM (nonce en préfixe)
+func DecryptBiometricData(encrypted string, key string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("données chiffrées invalides: %v", err)
+	}
+	rawKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", fmt.Errorf("clé de chiffrement invalide: %v", err)
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", fmt.Errorf("données chiffrées trop courtes")
+	}
+
+	nonce, payload := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, payload, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CardPayload est le contenu signé d'une carte d'enregistrement.
+// Il ne contient aucune donnée sensible : la vérification hors ligne se fait
+// avec la clé publique, la vérification en ligne consulte le registre des cartes.
+type CardPayload struct {
+	Version           int    `json:"v"`
+	Serie             string `json:"s"`
+	NumeroIdentifiant string `json:"n"`
+	DateEmission      int64  `json:"iat"`
+	DateExpiration    int64  `json:"exp"`
+	CleID             string `json:"kid"`
+}
+
+var (
+	cardKeyOnce sync.Once
+	cardKey     ed25519.PrivateKey
+	cardKeyErr  error
+)
+
+// LoadCardSigningKey charge la clé Ed25519 de signature des cartes ; appelée au
+// démarrage, l'API refuse de démarrer si elle échoue. CARD_SIGNING_KEY contient la
+// graine de 32 octets en base64 et n'est facultative qu'en développement
+// (APP_ENV=development), où une clé éphémère est générée à chaque démarrage.
+func LoadCardSigningKey() error {
+	cardKeyOnce.Do(func() {
+		cardKey, cardKeyErr = readCardSigningKey()
+	})
+	return cardKeyErr
+}
+
+func readCardSigningKey() (ed25519.PrivateKey, error) {
+	encoded := Env("CARD_SIGNING_KEY")
+	if encoded == "" {
+		if !IsDevelopment() {
+			return nil, errors.New("CARD_SIGNING_KEY manquante : graine Ed25519 de 32 octets en base64 requise hors développement")
+		}
+		log.Println("⚠️ CARD_SIGNING_KEY absente : clé de signature des cartes éphémère, les cartes émises ne seront plus vérifiables après redémarrage")
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.New("CARD_SIGNING_KEY invalide : graine Ed25519 de 32 octets en base64 attendue")
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// cardSigningKey retourne la clé chargée au démarrage
+func cardSigningKey() (ed25519.PrivateKey, error) {
+	if err := LoadCardSigningKey(); err != nil {
+		return nil, err
+	}
+	return cardKey, nil
+}
+
+// CardPublicKey retourne la clé publique de vérification (base64) et son identifiant
+func CardPublicKey() (string, string, error) {
+	key, err := cardSigningKey()
+	if err != nil {
+		return "", "", err
+	}
+	public := key.Public().(ed25519.PublicKey)
+	return base64.StdEncoding.EncodeToString(public), cardKeyID(public), nil
+}
+
+// cardKeyID identifie une clé publique par les 8 premiers octets de son empreinte
+func cardKeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignCard produit le jeton d'une carte : charge utile et signature détachée,
+// encodées en base64url et séparées par un point
+func SignCard(payload CardPayload) (string, error) {
+	key, err := cardSigningKey()
+	if err != nil {
+		return "", err
+	}
+	payload.Version = 1
+	payload.CleID = cardKeyID(key.Public().(ed25519.PublicKey))
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("erreur lors de l'encodage de la carte: %v", err)
+	}
+	signature := ed25519.Sign(key, data)
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyCard vérifie la signature d'un jeton de carte et retourne sa charge utile.
+// L'expiration n'est pas contrôlée ici afin que l'appelant puisse la signaler.
+func VerifyCard(token string) (*CardPayload, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, fmt.Errorf("jeton de carte mal formé")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("jeton de carte mal formé")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signature de carte mal formée")
+	}
+
+	key, err := cardSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	public := key.Public().(ed25519.PublicKey)
+	if !ed25519.Verify(public, data, signature) {
+		return nil, fmt.Errorf("signature de carte invalide")
+	}
+
+	var payload CardPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("jeton de carte mal formé")
+	}
+	return &payload, nil
+}
+
+// CardExpired indique si la carte est expirée à la date donnée
+func (p *CardPayload) CardExpired(now time.Time) bool {
+	return p.DateExpiration > 0 && now.Unix() > p.DateExpiration
+}
+
+// GenerateCardVerificationURL génère l'URL publique encodée dans le QR code d'une carte
+func GenerateCardVerificationURL(baseURL, token string) string {
+	return fmt.Sprintf("%s/api/verify/%s", baseURL, token)
+}
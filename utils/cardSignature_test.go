@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rechargerCle réinitialise la clé chargée une fois par processus
+func rechargerCle(t *testing.T, appEnv, cle string) error {
+	t.Helper()
+	t.Setenv("APP_ENV", appEnv)
+	t.Setenv("CARD_SIGNING_KEY", cle)
+	cardKeyOnce, cardKey, cardKeyErr = sync.Once{}, nil, nil
+	t.Cleanup(func() { cardKeyOnce, cardKey, cardKeyErr = sync.Once{}, nil, nil })
+	return LoadCardSigningKey()
+}
+
+func TestLoadCardSigningKeyRequiredOutsideDevelopment(t *testing.T) {
+	for _, appEnv := range []string{"", "production", "staging"} {
+		if err := rechargerCle(t, appEnv, ""); err == nil {
+			t.Errorf("APP_ENV=%q: clé absente acceptée", appEnv)
+		}
+	}
+	if _, err := SignCard(CardPayload{Serie: "A"}); err == nil {
+		t.Error("carte signée sans clé")
+	}
+}
+
+func TestLoadCardSigningKeyRejectsInvalidSeed(t *testing.T) {
+	courte := base64.StdEncoding.EncodeToString(make([]byte, 16))
+	for _, cle := range []string{"pas du base64 !", courte} {
+		if err := rechargerCle(t, "development", cle); err == nil {
+			t.Errorf("clé invalide %q acceptée", cle)
+		}
+	}
+}
+
+func TestLoadCardSigningKeyEphemeralInDevelopment(t *testing.T) {
+	if err := rechargerCle(t, "development", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := CardPublicKey(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignAndVerifyCard(t *testing.T) {
+	graine := make([]byte, ed25519.SeedSize)
+	for i := range graine {
+		graine[i] = byte(i)
+	}
+	if err := rechargerCle(t, "production", base64.StdEncoding.EncodeToString(graine)); err != nil {
+		t.Fatal(err)
+	}
+
+	jeton, err := SignCard(CardPayload{Serie: "A", NumeroIdentifiant: "MIG-2025-000001", DateExpiration: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := VerifyCard(jeton)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, cleID, _ := CardPublicKey()
+	if payload.NumeroIdentifiant != "MIG-2025-000001" || payload.CleID != cleID {
+		t.Errorf("charge utile inattendue: %+v", payload)
+	}
+
+	// Une charge utile modifiée n'est plus acceptée
+	donnees, signature, _ := strings.Cut(jeton, ".")
+	falsifie := base64.RawURLEncoding.EncodeToString([]byte(`{"v":1,"s":"A","n":"MIG-2025-999999"}`))
+	if _, err := VerifyCard(falsifie + "." + signature); err == nil {
+		t.Error("jeton falsifié accepté")
+	}
+	if _, err := VerifyCard(donnees); err == nil {
+		t.Error("jeton sans signature accepté")
+	}
+}
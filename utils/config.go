@@ -15,4 +15,10 @@ func Env(key string) string {
 		fmt.Print("Error loading .env file")
 	}
 	return os.Getenv(key)
-}
\ No newline at end of file
+}
+
+// IsDevelopment indique si l'API tourne en développement (APP_ENV=development).
+// Toute autre valeur, ou son absence, est traitée comme la production.
+func IsDevelopment() bool {
+	return Env("APP_ENV") == "development"
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// Encodeur minimal du format Mapbox Vector Tile (spécification 2.1).
+// Seules les géométries de type POINT sont prises en charge, ce qui suffit
+// pour les tuiles de clusters servies à la carte.
+
+const (
+	mvtGeomPoint     = 1
+	mvtCmdMoveTo     = 1
+	mvtDefaultExtent = 4096
+)
+
+// MVTFeature représente un point à encoder dans une couche de tuile vectorielle.
+// X et Y sont exprimés en coordonnées de tuile (0..Extent).
+type MVTFeature struct {
+	ID         uint64
+	X          int
+	Y          int
+	Properties map[string]interface{}
+}
+
+// MVTLayer représente une couche nommée de la tuile.
+type MVTLayer struct {
+	Name     string
+	Extent   int
+	Features []MVTFeature
+}
+
+// EncodeMVT sérialise les couches au format protobuf attendu par les clients Mapbox/MapLibre.
+func EncodeMVT(layers []MVTLayer) []byte {
+	var tile []byte
+	for _, layer := range layers {
+		tile = appendBytesField(tile, 3, encodeMVTLayer(layer))
+	}
+	return tile
+}
+
+func encodeMVTLayer(layer MVTLayer) []byte {
+	extent := layer.Extent
+	if extent <= 0 {
+		extent = mvtDefaultExtent
+	}
+
+	keyIndex := map[string]int{}
+	var keys []string
+	valueIndex := map[interface{}]int{}
+	var values [][]byte
+
+	var out []byte
+	out = appendVarintField(out, 15, 2) // version
+	out = appendBytesField(out, 1, []byte(layer.Name))
+
+	for _, feature := range layer.Features {
+		// Ordonner les clés pour obtenir un encodage déterministe
+		names := make([]string, 0, len(feature.Properties))
+		for name := range feature.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var tags []uint64
+		for _, name := range names {
+			value, ok := normalizeMVTValue(feature.Properties[name])
+			if !ok {
+				continue
+			}
+			ki, found := keyIndex[name]
+			if !found {
+				ki = len(keys)
+				keyIndex[name] = ki
+				keys = append(keys, name)
+			}
+			vi, found := valueIndex[value]
+			if !found {
+				vi = len(values)
+				valueIndex[value] = vi
+				values = append(values, encodeMVTValue(value))
+			}
+			tags = append(tags, uint64(ki), uint64(vi))
+		}
+
+		geometry := []uint64{
+			uint64(mvtCmdMoveTo&0x7) | uint64(1)<<3,
+			zigzag(int64(feature.X)),
+			zigzag(int64(feature.Y)),
+		}
+
+		var f []byte
+		if feature.ID != 0 {
+			f = appendVarintField(f, 1, feature.ID)
+		}
+		f = appendPackedField(f, 2, tags)
+		f = appendVarintField(f, 3, mvtGeomPoint)
+		f = appendPackedField(f, 4, geometry)
+
+		out = appendBytesField(out, 2, f)
+	}
+
+	for _, key := range keys {
+		out = appendBytesField(out, 3, []byte(key))
+	}
+	for _, value := range values {
+		out = appendBytesField(out, 4, value)
+	}
+	out = appendVarintField(out, 5, uint64(extent))
+
+	return out
+}
+
+// normalizeMVTValue ramène les valeurs supportées à string, float64, int64 ou bool
+func normalizeMVTValue(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case bool:
+		return value, true
+	case int:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case float64:
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+func encodeMVTValue(v interface{}) []byte {
+	var out []byte
+	switch value := v.(type) {
+	case string:
+		out = appendBytesField(out, 1, []byte(value))
+	case float64:
+		out = appendTag(out, 3, 1)
+		bits := math.Float64bits(value)
+		for i := 0; i < 8; i++ {
+			out = append(out, byte(bits>>(8*i)))
+		}
+	case int64:
+		out = appendVarintField(out, 6, zigzag(value))
+	case bool:
+		b := uint64(0)
+		if value {
+			b = 1
+		}
+		out = appendVarintField(out, 7, b)
+	}
+	return out
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, 0)
+	return appendVarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, 2)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendPackedField(b []byte, field int, values []uint64) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = appendVarint(packed, v)
+	}
+	return appendBytesField(b, field, packed)
+}
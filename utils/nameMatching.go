@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName retire les accents, la ponctuation et met le nom en majuscules.
+// "Tshibangu-Mukendi " devient "TSHIBANGU MUKENDI".
+func NormalizeName(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Diacritique isolé par la décomposition NFD
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// NormalizeDocumentNumber ne conserve que les lettres et chiffres d'un numéro de document.
+// "op-12 345 6" devient "OP123456".
+func NormalizeDocumentNumber(s string) string {
+	return strings.ReplaceAll(NormalizeName(s), " ", "")
+}
+
+// NameTokens découpe un nom normalisé en jetons
+func NameTokens(parts ...string) []string {
+	var tokens []string
+	for _, part := range parts {
+		tokens = append(tokens, strings.Fields(NormalizeName(part))...)
+	}
+	return tokens
+}
+
+// Règles de réécriture appliquées avant l'encodage, dans l'ordre.
+// Elles rapprochent les graphies françaises, anglaises et des langues
+// congolaises (lingala, swahili, tshiluba, kikongo) d'une même prononciation.
+var phoneticRewrites = []struct{ from, to string }{
+	{"TSCH", "X"},
+	{"TSH", "X"},
+	{"TCH", "X"},
+	{"SCH", "X"},
+	{"CH", "X"},
+	{"SH", "X"},
+	{"DJ", "J"},
+	{"DZ", "J"},
+	{"PH", "F"},
+	{"QU", "K"},
+	{"CK", "K"},
+	{"KH", "K"},
+	{"GH", "G"},
+	{"GN", "NY"},
+	{"OU", "U"},
+	{"EAU", "O"},
+	{"AU", "O"},
+}
+
+// PhoneticCode calcule une clé phonétique pour un jeton de nom.
+// Tshibangu, Chibangu et Shibangou donnent tous "XBNG".
+func PhoneticCode(token string) string {
+	s := NormalizeName(token)
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return ""
+	}
+
+	// "X" doit être traité avant les digraphes qui produisent "X"
+	s = strings.ReplaceAll(s, "X", "KS")
+	for _, rule := range phoneticRewrites {
+		s = strings.ReplaceAll(s, rule.from, rule.to)
+	}
+
+	runes := []rune(s)
+	var out []rune
+	for i, r := range runes {
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch r {
+		case 'C':
+			// C doux devant E, I, Y
+			if next == 'E' || next == 'I' || next == 'Y' {
+				r = 'S'
+			} else {
+				r = 'K'
+			}
+		case 'Q':
+			r = 'K'
+		case 'Z':
+			r = 'S'
+		case 'R':
+			// R et L sont interchangeables dans de nombreuses langues bantoues
+			r = 'L'
+		case 'V':
+			r = 'B'
+		case 'H':
+			continue
+		case 'G':
+			// G doux devant E, I
+			if next == 'E' || next == 'I' {
+				r = 'J'
+			}
+		}
+		out = append(out, r)
+	}
+
+	// Consonnes finales muettes en français (Dupont, Petit, Renard)
+	if len(out) > 2 && strings.ContainsRune("STDP", out[len(out)-1]) && isPhoneticVowel(out[len(out)-2]) {
+		out = out[:len(out)-1]
+	}
+
+	// Conserver la première lettre, retirer les voyelles et les doublons
+	var code []rune
+	for i, r := range out {
+		if i > 0 && (isPhoneticVowel(r) || r == out[i-1]) {
+			continue
+		}
+		if i == 0 && isPhoneticVowel(r) {
+			r = 'A'
+		}
+		if len(code) > 0 && code[len(code)-1] == r {
+			continue
+		}
+		code = append(code, r)
+	}
+	return string(code)
+}
+
+func isPhoneticVowel(r rune) bool {
+	return strings.ContainsRune("AEIOUYW", r)
+}
+
+// NameSearchKeys retourne la clé normalisée et la clé phonétique d'un nom complet.
+// Les jetons sont triés pour que l'ordre nom/postnom/prénom n'ait pas d'importance,
+// et entourés d'espaces pour permettre une recherche par jeton (LIKE '% CODE %').
+func NameSearchKeys(parts ...string) (string, string) {
+	tokens := NameTokens(parts...)
+	sort.Strings(tokens)
+
+	codes := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if code := PhoneticCode(token); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	return " " + strings.Join(tokens, " ") + " ", " " + strings.Join(codes, " ") + " "
+}
+
+// trigrams retourne l'ensemble des trigrammes d'un jeton (à la manière de pg_trgm)
+func trigrams(token string) map[string]struct{} {
+	padded := []rune("  " + token + " ")
+	set := make(map[string]struct{}, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// TrigramSimilarity calcule la similarité de Jaccard entre les trigrammes de deux jetons (0..1)
+func TrigramSimilarity(a, b string) float64 {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ta, tb := trigrams(a), trigrams(b)
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	union := len(ta) + len(tb) - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
+
+// TokenSimilarity combine la similarité orthographique et phonétique de deux jetons
+func TokenSimilarity(a, b string) float64 {
+	score := TrigramSimilarity(a, b)
+	if score < 1 {
+		if pa := PhoneticCode(a); pa != "" && pa == PhoneticCode(b) {
+			// Même prononciation : très proche, sans être une égalité stricte
+			if score < 0.85 {
+				score = 0.85
+			}
+		}
+	}
+	return score
+}
+
+// NameMatchScore compare deux noms complets sans tenir compte de l'ordre des jetons.
+// Chaque jeton de la requête est apparié au meilleur jeton candidat non encore utilisé ;
+// le score est la moyenne des similarités obtenues (0..1).
+func NameMatchScore(query, candidate []string) float64 {
+	if len(query) == 0 || len(candidate) == 0 {
+		return 0
+	}
+
+	used := make([]bool, len(candidate))
+	total := 0.0
+	for _, q := range query {
+		best, bestIndex := 0.0, -1
+		for i, cand := range candidate {
+			if used[i] {
+				continue
+			}
+			if s := TokenSimilarity(q, cand); s > best {
+				best, bestIndex = s, i
+			}
+		}
+		if bestIndex >= 0 {
+			used[bestIndex] = true
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
+
+// EscapeLike échappe les caractères spéciaux de LIKE/ILIKE (\, % et _) d'une saisie
+// utilisateur, pour qu'elle soit comparée littéralement (échappement par défaut de Postgres)
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
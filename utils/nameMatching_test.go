@@ -0,0 +1,83 @@
+package utils
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	cas := map[string]string{
+		"OB1234567": "OB1234567",
+		"100%":      `100\%`,
+		"a_b":       `a\_b`,
+		`c:\temp`:   `c:\\temp`,
+		`%_\`:       `\%\_\\`,
+	}
+	for entree, attendu := range cas {
+		if obtenu := EscapeLike(entree); obtenu != attendu {
+			t.Errorf("EscapeLike(%q) = %q, attendu %q", entree, obtenu, attendu)
+		}
+	}
+}
+
+func TestNameSearchKeysIgnoresTokenOrder(t *testing.T) {
+	cle1, phon1 := NameSearchKeys("Chibangu", "Mukendi", "Léon")
+	cle2, phon2 := NameSearchKeys("LEON", "chibangu mukendi")
+	if cle1 != cle2 || phon1 != phon2 {
+		t.Errorf("clés différentes selon l'ordre : %q/%q et %q/%q", cle1, phon1, cle2, phon2)
+	}
+	if cle1 != " CHIBANGU LEON MUKENDI " {
+		t.Errorf("clé de nom %q", cle1)
+	}
+}
+
+func TestPhoneticCode(t *testing.T) {
+	cas := map[string]string{
+		"Tshibangu": "XBNG",
+		"Chibangu":  "XBNG",
+		"Shibangou": "XBNG",
+		"tshibangu": "XBNG",
+		// R et L, V et B confondus
+		"Mbala":   PhoneticCode("Mbara"),
+		"Kavungu": PhoneticCode("Kabungu"),
+		// Consonne finale muette
+		"Petit": PhoneticCode("Peti"),
+		"":      "",
+	}
+	for nom, attendu := range cas {
+		if code := PhoneticCode(nom); code != attendu {
+			t.Errorf("PhoneticCode(%q) = %q, attendu %q", nom, code, attendu)
+		}
+	}
+	if PhoneticCode("Tshibangu") == PhoneticCode("Kabila") {
+		t.Error("Tshibangu et Kabila ont la même clé phonétique")
+	}
+}
+
+func TestNameMatchScoreRanksCandidates(t *testing.T) {
+	requete := NameTokens("Tshibangu", "Mukendi", "Léon")
+	// Du plus proche au plus éloigné
+	candidats := [][]string{
+		NameTokens("LEON", "TSHIBANGU MUKENDI"),
+		NameTokens("Chibangu", "Mukendi", "Leon"),
+		NameTokens("Shibangou", "Mukendi"),
+		NameTokens("Kabila", "Joseph"),
+	}
+	scores := make([]float64, len(candidats))
+	for i, candidat := range candidats {
+		scores[i] = NameMatchScore(requete, candidat)
+	}
+
+	if scores[0] != 1 {
+		t.Errorf("même nom dans un autre ordre : score %v, attendu 1", scores[0])
+	}
+	for i := 1; i < len(scores); i++ {
+		if scores[i] >= scores[i-1] {
+			t.Errorf("%v (%v) classé avant %v (%v)", candidats[i], scores[i], candidats[i-1], scores[i-1])
+		}
+	}
+	// Variante orthographique de même prononciation : jeton presque identique
+	if s := TokenSimilarity("TSHIBANGU", "SHIBANGOU"); s < 0.85 {
+		t.Errorf("TokenSimilarity(TSHIBANGU, SHIBANGOU) = %v, attendu au moins 0,85", s)
+	}
+	if s := NameMatchScore(nil, candidats[0]); s != 0 {
+		t.Errorf("requête vide : score %v, attendu 0", s)
+	}
+}
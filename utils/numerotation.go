@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Jetons reconnus dans un modèle de numéro :
+//
+//	{annee}   année sur quatre chiffres
+//	{bureau}  code du bureau d'enregistrement
+//	{seq:N}   numéro de séquence complété à N chiffres ({seq} sans remplissage)
+//	{cle}     chiffre de contrôle calculé sur le reste du numéro (voir CleControle)
+//
+// "MIG-{annee}-{seq:6}" donne "MIG-2025-000042" et
+// "MIG-{bureau}-{annee}-{seq:6}{cle}" donne "MIG-GOM-2025-0000429".
+var jetonNumero = regexp.MustCompile(`\{(annee|bureau|cle|seq(?::(\d+))?)\}`)
+
+// FormatAvecBureau indique si le modèle comporte le code du bureau
+func FormatAvecBureau(modele string) bool {
+	return strings.Contains(modele, "{bureau}")
+}
+
+// FormatNumero construit un numéro à partir d'un modèle
+func FormatNumero(modele string, annee int, bureau string, sequence int64) string {
+	const marqueCle = "\x00"
+
+	numero := jetonNumero.ReplaceAllStringFunc(modele, func(jeton string) string {
+		m := jetonNumero.FindStringSubmatch(jeton)
+		switch {
+		case m[1] == "annee":
+			return strconv.Itoa(annee)
+		case m[1] == "bureau":
+			return bureau
+		case m[1] == "cle":
+			return marqueCle
+		default:
+			seq := strconv.FormatInt(sequence, 10)
+			if largeur, _ := strconv.Atoi(m[2]); len(seq) < largeur {
+				seq = strings.Repeat("0", largeur-len(seq)) + seq
+			}
+			return seq
+		}
+	})
+
+	if strings.Contains(numero, marqueCle) {
+		cle := strconv.Itoa(CleControle(strings.ReplaceAll(numero, marqueCle, "")))
+		numero = strings.ReplaceAll(numero, marqueCle, cle)
+	}
+	return numero
+}
+
+// SequenceNumero retrouve le numéro de séquence d'un numéro conforme au modèle
+// pour l'année et le bureau donnés
+func SequenceNumero(modele string, annee int, bureau string, numero string) (int64, bool) {
+	var motif strings.Builder
+	motif.WriteString("^")
+	dernier := 0
+	for _, idx := range jetonNumero.FindAllStringSubmatchIndex(modele, -1) {
+		motif.WriteString(regexp.QuoteMeta(modele[dernier:idx[0]]))
+		switch modele[idx[2]:idx[3]] {
+		case "annee":
+			motif.WriteString(strconv.Itoa(annee))
+		case "bureau":
+			motif.WriteString(regexp.QuoteMeta(bureau))
+		case "cle":
+			motif.WriteString(`\d`)
+		default:
+			motif.WriteString(`(?P<seq>\d+)`)
+		}
+		dernier = idx[1]
+	}
+	motif.WriteString(regexp.QuoteMeta(modele[dernier:]))
+	motif.WriteString("$")
+
+	re, err := regexp.Compile(motif.String())
+	if err != nil {
+		return 0, false
+	}
+	m := re.FindStringSubmatch(numero)
+	i := re.SubexpIndex("seq")
+	if m == nil || i < 0 {
+		return 0, false
+	}
+	sequence, err := strconv.ParseInt(m[i], 10, 64)
+	return sequence, err == nil
+}
+
+// CleControle calcule le chiffre de contrôle de Luhn d'un identifiant alphanumérique.
+// Comme pour les codes ISIN, chaque lettre est remplacée par sa valeur (A=10 … Z=35)
+// et les autres caractères sont ignorés.
+func CleControle(s string) int {
+	var chiffres []int
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case unicode.IsDigit(r) && r < unicode.MaxASCII:
+			chiffres = append(chiffres, int(r-'0'))
+		case r >= 'A' && r <= 'Z':
+			v := int(r-'A') + 10
+			chiffres = append(chiffres, v/10, v%10)
+		}
+	}
+
+	somme := 0
+	for i := len(chiffres) - 1; i >= 0; i-- {
+		d := chiffres[i]
+		// Le chiffre de contrôle sera ajouté à droite : le dernier chiffre est doublé
+		if (len(chiffres)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		somme += d
+	}
+	return (10 - somme%10) % 10
+}
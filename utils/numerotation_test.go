@@ -0,0 +1,68 @@
+package utils
+
+import "testing"
+
+func TestFormatNumero(t *testing.T) {
+	cas := []struct {
+		modele   string
+		bureau   string
+		sequence int64
+		attendu  string
+	}{
+		{"MIG-{annee}-{seq:6}", "", 42, "MIG-2025-000042"},
+		{"MIG-{bureau}-{annee}-{seq:6}{cle}", "GOM", 42, "MIG-GOM-2025-0000429"},
+		{"DOS{seq}", "", 7, "DOS7"},
+		{"MIG-{seq:3}", "", 12345, "MIG-12345"},
+	}
+	for _, c := range cas {
+		if obtenu := FormatNumero(c.modele, 2025, c.bureau, c.sequence); obtenu != c.attendu {
+			t.Errorf("FormatNumero(%q, %d) = %q, attendu %q", c.modele, c.sequence, obtenu, c.attendu)
+		}
+	}
+}
+
+func TestSequenceNumeroInverseFormatNumero(t *testing.T) {
+	for _, modele := range []string{"MIG-{annee}-{seq:6}", "MIG-{bureau}-{annee}-{seq:6}{cle}", "{bureau}/{seq}"} {
+		for _, sequence := range []int64{1, 42, 999999, 1234567} {
+			numero := FormatNumero(modele, 2025, "KIN", sequence)
+			obtenu, ok := SequenceNumero(modele, 2025, "KIN", numero)
+			if !ok || obtenu != sequence {
+				t.Errorf("%s: SequenceNumero(%q) = %d, %v ; attendu %d", modele, numero, obtenu, ok, sequence)
+			}
+		}
+	}
+
+	// Autre année ou autre bureau : le numéro n'appartient pas à la série
+	numero := FormatNumero("MIG-{bureau}-{annee}-{seq:6}", 2024, "KIN", 5)
+	if _, ok := SequenceNumero("MIG-{bureau}-{annee}-{seq:6}", 2025, "KIN", numero); ok {
+		t.Error("numéro de 2024 reconnu pour 2025")
+	}
+	if _, ok := SequenceNumero("MIG-{bureau}-{annee}-{seq:6}", 2024, "GOM", numero); ok {
+		t.Error("numéro de KIN reconnu pour GOM")
+	}
+}
+
+func TestCleControleDetectsSingleDigitErrors(t *testing.T) {
+	numero := FormatNumero("MIG-{bureau}-{annee}-{seq:6}{cle}", 2025, "GOM", 42)
+	base := numero[:len(numero)-1]
+	cle := CleControle(base)
+	if got := int(numero[len(numero)-1] - '0'); got != cle {
+		t.Fatalf("clé %d dans %s, attendu %d", got, numero, cle)
+	}
+
+	// Toute substitution d'un chiffre change la clé de contrôle
+	for i := range base {
+		if base[i] < '0' || base[i] > '9' {
+			continue
+		}
+		for d := byte('0'); d <= '9'; d++ {
+			if d == base[i] {
+				continue
+			}
+			modifie := base[:i] + string(d) + base[i+1:]
+			if CleControle(modifie) == cle {
+				t.Errorf("%s et %s ont la même clé %d", base, modifie, cle)
+			}
+		}
+	}
+}
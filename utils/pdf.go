@@ -0,0 +1,373 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Générateur PDF minimal en Go pur : pages de taille libre, texte en Helvetica
+// (encodage WinAnsi), rectangles, lignes et images PNG/JPEG.
+// Toutes les coordonnées sont en millimètres depuis le coin supérieur gauche.
+
+const mmToPt = 72 / 25.4
+
+// Formats de page usuels (largeur, hauteur en mm)
+const (
+	PDFA4Width    = 210.0
+	PDFA4Height   = 297.0
+	PDFCardWidth  = 85.6 // format ID-1 (carte bancaire)
+	PDFCardHeight = 53.98
+)
+
+// PDF est un document en cours de construction
+type PDF struct {
+	title  string
+	pages  []*PDFPage
+	images []*PDFImage
+}
+
+// PDFPage est une page du document
+type PDFPage struct {
+	width, height float64 // en points
+	content       bytes.Buffer
+}
+
+// PDFImage est une image intégrée au document
+type PDFImage struct {
+	name          string
+	Width, Height int
+	data          []byte
+}
+
+// NewPDF crée un document vide
+func NewPDF(title string) *PDF {
+	return &PDF{title: title}
+}
+
+// AddPage ajoute une page aux dimensions données (mm)
+func (d *PDF) AddPage(widthMM, heightMM float64) *PDFPage {
+	page := &PDFPage{width: widthMM * mmToPt, height: heightMM * mmToPt}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// AddImage décode une image PNG ou JPEG et l'intègre au document
+func (d *PDF) AddImage(data []byte) (*PDFImage, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image illisible: %v", err)
+	}
+
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Transparence aplatie sur fond blanc
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := uint32(c.A)
+			blend := func(v uint8) byte {
+				return byte((uint32(v)*a + 255*(255-a)) / 255)
+			}
+			rgb = append(rgb, blend(c.R), blend(c.G), blend(c.B))
+		}
+	}
+
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(rgb)
+	w.Close()
+
+	pdfImg := &PDFImage{
+		name:   fmt.Sprintf("Im%d", len(d.images)+1),
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		data:   buf.Bytes(),
+	}
+	d.images = append(d.images, pdfImg)
+	return pdfImg, nil
+}
+
+// PageCount retourne le nombre de pages
+func (d *PDF) PageCount() int {
+	return len(d.pages)
+}
+
+// Page retourne la page d'indice i (à partir de 0), par exemple pour ajouter les pieds de page
+func (d *PDF) Page(i int) *PDFPage {
+	return d.pages[i]
+}
+
+// Bytes sérialise le document
+func (d *PDF) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	begin := func() int {
+		offsets = append(offsets, out.Len())
+		n := len(offsets)
+		fmt.Fprintf(&out, "%d 0 obj\n", n)
+		return n
+	}
+	end := func() {
+		out.WriteString("endobj\n")
+	}
+	stream := func(dict string, data []byte) {
+		fmt.Fprintf(&out, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		out.Write(data)
+		out.WriteString("\nendstream\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 : catalogue, 2 : arbre des pages, 3-4 : polices, 5 : informations
+	firstImage := 6
+	firstPage := firstImage + len(d.images)
+
+	begin()
+	out.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	end()
+
+	begin()
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	fmt.Fprintf(&out, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(d.pages))
+	end()
+
+	for _, font := range []string{"Helvetica", "Helvetica-Bold"} {
+		begin()
+		fmt.Fprintf(&out, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", font)
+		end()
+	}
+
+	begin()
+	fmt.Fprintf(&out, "<< /Title (%s) /Producer (SysMobembo) /CreationDate (D:%s) >>\n",
+		pdfEscape(d.title), time.Now().Format("20060102150405"))
+	end()
+
+	xobjects := make([]string, len(d.images))
+	for i, img := range d.images {
+		begin()
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+			img.Width, img.Height), img.data)
+		end()
+		xobjects[i] = fmt.Sprintf("/%s %d 0 R", img.name, firstImage+i)
+	}
+
+	resources := "<< /Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+	resources += " >>"
+
+	for i, page := range d.pages {
+		begin()
+		fmt.Fprintf(&out, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>\n",
+			page.width, page.height, resources, firstPage+2*i+1)
+		end()
+
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(page.content.Bytes())
+		w.Close()
+
+		begin()
+		stream("/Filter /FlateDecode", buf.Bytes())
+		end()
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// Height retourne la hauteur de la page en mm
+func (p *PDFPage) Height() float64 {
+	return p.height / mmToPt
+}
+
+// Width retourne la largeur de la page en mm
+func (p *PDFPage) Width() float64 {
+	return p.width / mmToPt
+}
+
+// hexColor convertit une couleur "RRGGBB" en composantes PDF (0..1)
+func hexColor(hex string) (float64, float64, float64) {
+	var r, g, b uint8
+	fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &r, &g, &b)
+	return float64(r) / 255, float64(g) / 255, float64(b) / 255
+}
+
+// SetFillColor définit la couleur de remplissage et du texte
+func (p *PDFPage) SetFillColor(hex string) {
+	r, g, b := hexColor(hex)
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg\n", r, g, b)
+}
+
+// SetStrokeColor définit la couleur des traits
+func (p *PDFPage) SetStrokeColor(hex string) {
+	r, g, b := hexColor(hex)
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f RG\n", r, g, b)
+}
+
+// SetLineWidth définit l'épaisseur des traits (mm)
+func (p *PDFPage) SetLineWidth(mm float64) {
+	fmt.Fprintf(&p.content, "%.2f w\n", mm*mmToPt)
+}
+
+// Rect dessine un rectangle ; style "F" (rempli), "S" (contour) ou "FD" (les deux)
+func (p *PDFPage) Rect(x, y, w, h float64, style string) {
+	op := map[string]string{"F": "f", "S": "S", "FD": "B", "DF": "B"}[style]
+	if op == "" {
+		op = "S"
+	}
+	fmt.Fprintf(&p.content, "%.2f %.2f %.2f %.2f re %s\n", x*mmToPt, p.height-(y+h)*mmToPt, w*mmToPt, h*mmToPt, op)
+}
+
+// Line trace un segment
+func (p *PDFPage) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%.2f %.2f m %.2f %.2f l S\n", x1*mmToPt, p.height-y1*mmToPt, x2*mmToPt, p.height-y2*mmToPt)
+}
+
+// Text écrit une ligne de texte ; y est la ligne de base
+func (p *PDFPage) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x*mmToPt, p.height-y*mmToPt, pdfEscape(s))
+}
+
+// TextAligned écrit une ligne alignée dans une zone de largeur w : "L", "C" ou "R"
+func (p *PDFPage) TextAligned(x, y, w, size float64, bold bool, align string, s string) {
+	switch align {
+	case "C":
+		x += (w - PDFTextWidth(s, size, bold)) / 2
+	case "R":
+		x += w - PDFTextWidth(s, size, bold)
+	}
+	p.Text(x, y, size, bold, s)
+}
+
+// Image place une image dans le rectangle donné
+func (p *PDFPage) Image(img *PDFImage, x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n",
+		w*mmToPt, h*mmToPt, x*mmToPt, p.height-(y+h)*mmToPt, img.name)
+}
+
+// Largeurs des caractères ASCII 32..126 (unités de 1/1000 em)
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// PDFTextWidth retourne la largeur d'un texte en mm
+func PDFTextWidth(s string, size float64, bold bool) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range s {
+		// Les lettres accentuées ont la largeur de leur lettre de base
+		if r > 126 {
+			if base := []rune(norm.NFD.String(string(r))); len(base) > 0 {
+				r = base[0]
+			}
+		}
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000 / mmToPt
+}
+
+// PDFWrapText découpe un texte en lignes ne dépassant pas la largeur donnée (mm)
+func PDFWrapText(s string, size float64, bold bool, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			if PDFTextWidth(line+" "+word, size, bold) > width {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Caractères hors Latin-1 disponibles dans WinAnsiEncoding
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfEscape encode une chaîne en WinAnsi et échappe les caractères réservés
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(s) {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			c = ' '
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			c = byte(r)
+		default:
+			if extra, ok := winAnsiExtra[r]; ok {
+				c = extra
+			} else {
+				c = '?'
+			}
+		}
+		if c >= 0x80 || c < 0x20 {
+			fmt.Fprintf(&b, "\\%03o", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
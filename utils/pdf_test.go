@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var mediaBox = regexp.MustCompile(`/MediaBox \[0 0 ([\d.]+) ([\d.]+)\]`)
+
+func TestPDFPagesEtDimensions(t *testing.T) {
+	doc := NewPDF("Essai")
+	doc.AddPage(PDFA4Width, PDFA4Height).Text(10, 10, 12, false, "Première page")
+	doc.AddPage(PDFCardWidth, PDFCardHeight)
+	doc.AddPage(PDFCardHeight, PDFCardWidth)
+	if doc.PageCount() != 3 {
+		t.Fatalf("%d pages, 3 attendues", doc.PageCount())
+	}
+	if w, h := doc.Page(1).Width(), doc.Page(1).Height(); w != PDFCardWidth || h != PDFCardHeight {
+		t.Errorf("page 2 de %v x %v mm, attendue %v x %v", w, h, PDFCardWidth, PDFCardHeight)
+	}
+
+	data := doc.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("en-tête ou fin de fichier PDF absents")
+	}
+	if !bytes.Contains(data, []byte("/Count 3 >>")) {
+		t.Error("l'arbre des pages ne compte pas 3 pages")
+	}
+
+	// Dimensions en points : 1 mm = 72 / 25,4 pt
+	attendues := [][2]string{{"595.28", "841.89"}, {"242.65", "153.01"}, {"153.01", "242.65"}}
+	boites := mediaBox.FindAllSubmatch(data, -1)
+	if len(boites) != len(attendues) {
+		t.Fatalf("%d pages dans le fichier, %d attendues", len(boites), len(attendues))
+	}
+	for i, boite := range boites {
+		if string(boite[1]) != attendues[i][0] || string(boite[2]) != attendues[i][1] {
+			t.Errorf("page %d de %s x %s pt, attendue %s x %s", i+1, boite[1], boite[2], attendues[i][0], attendues[i][1])
+		}
+	}
+
+	// Chaque entrée de la table xref pointe sur le début de son objet
+	debut := bytes.LastIndex(data, []byte("\nxref\n")) + 1
+	lignes := strings.Split(string(data[debut:]), "\n")
+	objets, _ := strconv.Atoi(strings.Fields(lignes[1])[1])
+	for n := 1; n < objets; n++ {
+		offset, _ := strconv.Atoi(strings.Fields(lignes[2+n])[0])
+		if attendu := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(data[offset:], []byte(attendu)) {
+			t.Errorf("l'entrée xref %d ne pointe pas sur son objet", n)
+		}
+	}
+	if !bytes.Contains(data, []byte(fmt.Sprintf("startxref\n%d\n", debut))) {
+		t.Error("startxref ne pointe pas sur la table xref")
+	}
+}
+
+func TestPDFTextWidth(t *testing.T) {
+	// « W » fait 944/1000 em : 944 * 10 / 1000 pt
+	if w := PDFTextWidth("W", 10, false); fmt.Sprintf("%.4f", w) != fmt.Sprintf("%.4f", 9.44/mmToPt) {
+		t.Errorf("largeur de W %v mm", w)
+	}
+	if PDFTextWidth("é", 10, false) != PDFTextWidth("e", 10, false) {
+		t.Error("une lettre accentuée doit avoir la largeur de sa lettre de base")
+	}
+	if PDFTextWidth("Nom", 10, true) <= PDFTextWidth("Nom", 10, false) {
+		t.Error("le gras doit être plus large")
+	}
+}
+
+func TestPDFWrapText(t *testing.T) {
+	texte := "Cette carte atteste l'enregistrement de son titulaire auprès des services de migration.\n\nFin"
+	lignes := PDFWrapText(texte, 10, false, 50)
+	if len(lignes) < 4 || lignes[len(lignes)-2] != "" || lignes[len(lignes)-1] != "Fin" {
+		t.Fatalf("lignes %q", lignes)
+	}
+	for _, ligne := range lignes {
+		if strings.Contains(ligne, " ") && PDFTextWidth(ligne, 10, false) > 50 {
+			t.Errorf("ligne %q plus large que 50 mm", ligne)
+		}
+	}
+	if strings.Join(strings.Fields(strings.Join(lignes, " ")), " ") != strings.Join(strings.Fields(texte), " ") {
+		t.Error("le découpage a perdu ou déplacé des mots")
+	}
+}
+
+func TestPdfEscape(t *testing.T) {
+	cas := map[string]string{
+		"Carte (verso)": `Carte \(verso\)`,
+		`a\b`:           `a\\b`,
+		"Né à Kinshasa": `N\351 \340 Kinshasa`,
+		"Coût : 5 €":    `Co\373t : 5 \200`,
+		"ligne\nsuite":  "ligne suite",
+		"Ωmega":         "?mega",
+	}
+	for entree, attendu := range cas {
+		if obtenu := pdfEscape(entree); obtenu != attendu {
+			t.Errorf("pdfEscape(%q) = %q, attendu %q", entree, obtenu, attendu)
+		}
+	}
+}
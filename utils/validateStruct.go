@@ -1,16 +1,33 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
 
 type ErrorResponse struct {
 	FailedField string
 	Tag         string
 	Value       string
+	// Field - chemin JSON du champ (ex. "identite.nom"), pour les réponses d'erreur
+	Field string
 }
 
 func ValidateStruct(m interface{}) []*ErrorResponse {
 	var errors []*ErrorResponse
 	validate := validator.New()
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		nom := strings.Split(f.Tag.Get("json"), ",")[0]
+		if nom == "-" {
+			return ""
+		}
+		if nom == "" {
+			return f.Name
+		}
+		return nom
+	})
 	err := validate.Struct(m)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
@@ -18,8 +35,10 @@ func ValidateStruct(m interface{}) []*ErrorResponse {
 			element.FailedField = err.StructNamespace()
 			element.Tag = err.Tag()
 			element.Value = err.Param()
+			// Namespace est préfixé du nom de la structure racine
+			_, element.Field, _ = strings.Cut(err.Namespace(), ".")
 			errors = append(errors, &element)
 		}
 	}
 	return errors
-}
\ No newline at end of file
+}